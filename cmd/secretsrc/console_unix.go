@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// prepareConsole has nothing to do outside Windows, where terminals handle
+// escape sequences and lipgloss picks the color depth they support
+func prepareConsole() func() {
+	return func() {}
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/sys/windows"
+)
+
+// prepareConsole turns on escape sequence handling in the Windows console,
+// which legacy conhost windows leave off. A console that can't handle them
+// gets plain text rather than colors printed as garbage. The returned func
+// puts the console back as it was.
+func prepareConsole() func() {
+	handle := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		// Not a console, such as a mintty pipe, which handles escape
+		// sequences itself
+		return func() {}
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return func() {}
+	}
+	if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		logging.Debugf("console can't handle escape sequences, turning colors off: %v", err)
+		lipgloss.SetColorProfile(termenv.Ascii)
+		return func() {}
+	}
+	return func() {
+		_ = windows.SetConsoleMode(handle, mode)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/azure"
+	"github.com/benjamingriff/secretsrc/pkg/cli"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/demo"
+	"github.com/benjamingriff/secretsrc/pkg/kube"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/ui"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs the app and returns its exit code, so that main exits only once
+// the deferred cleanup, such as restoring the console and closing the debug
+// log, has run
+func run() int {
+	// Subcommands run non-interactively
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		return cli.Run(context.Background(), os.Args[1:], cli.DefaultIO())
+	}
+
+	debug := flag.Bool("debug", false, "write a debug log to ~/.cache/secretsrc/debug.log (secret values are redacted)")
+	tutorial := flag.Bool("tutorial", false, "walk through the core flows with step-by-step prompts")
+	backendFlag := flag.String("backend", "", "secret store to browse: aws, azure, kubernetes or demo, instead of the backend setting")
+	demoMode := flag.Bool("demo", false, "try the UI with generated sample secrets instead of AWS")
+	endpointURL := flag.String("endpoint-url", "", "Secrets Manager endpoint to use instead of AWS's, e.g. http://localhost:4566 for LocalStack")
+	azureVault := flag.String("azure-vault", "", "Azure key vault to browse instead of AWS, by name or URL; signs in with Azure credentials from the environment, a managed identity or the Azure CLI")
+	kubernetes := flag.Bool("kubernetes", false, "browse the Secrets of the current kubectl context and namespace instead of AWS")
+	kubeContext := flag.String("kube-context", "", "kubeconfig context to browse with --kubernetes, instead of the current one")
+	kubeNamespace := flag.String("kube-namespace", "", "namespace to browse with --kubernetes, instead of the context's")
+	dryRun := flag.Bool("dry-run", false, "start with dry-run mode on, listing the writes that would be made instead of making them (D toggles it)")
+	workspaceName := flag.String("workspace", "", "start in a workspace saved with W, with its profile, region and filter")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [secret name or ARN]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Error: only one secret can be opened at a time")
+		flag.Usage()
+		return 2
+	}
+	secret := flag.Arg(0)
+	if *kubeContext != "" || *kubeNamespace != "" {
+		*kubernetes = true
+	}
+	if backends := countSet(*demoMode, *azureVault != "", *kubernetes); backends > 1 {
+		fmt.Fprintln(os.Stderr, "Error: only one of --demo, --azure-vault and --kubernetes can be used")
+		return 2
+	}
+	flagBackend, err := backendFromFlags(*backendFlag, *demoMode, *azureVault != "", *kubernetes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *debug {
+		closeLog, err := enableDebugLog()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: debug logging disabled: %v\n", err)
+		} else {
+			defer closeLog()
+		}
+	}
+
+	cfg, configErr := config.Load()
+	if configErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", configErr)
+		if cfg == nil {
+			cfg = &config.Config{}
+		}
+	}
+
+	// The flags override the backend setting, and its vault and context
+	backend := flagBackend
+	if backend == "" {
+		backend = cfg.StartupBackend()
+	}
+	if *azureVault == "" {
+		*azureVault = cfg.AzureVault
+	}
+	if *kubeContext == "" && *kubeNamespace == "" {
+		*kubeContext, *kubeNamespace = cfg.KubeContext, cfg.KubeNamespace
+	}
+	*demoMode = backend == config.BackendDemo
+	*kubernetes = backend == config.BackendKubernetes
+	if *dryRun && backend != config.BackendAWS {
+		fmt.Fprintln(os.Stderr, "Error: --dry-run only works with AWS")
+		return 2
+	}
+	var vault *azure.Vault
+	if backend == config.BackendAzure {
+		if *azureVault == "" {
+			fmt.Fprintln(os.Stderr, "Error: --backend azure needs --azure-vault or the azure_vault setting")
+			return 2
+		}
+		if vault, err = azure.OpenVault(*azureVault); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	profile, region := resolveStartupTarget(cfg)
+	var workspace config.Workspace
+	if *workspaceName != "" {
+		var ok bool
+		if workspace, ok = cfg.Workspaces[*workspaceName]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: no workspace named %q in config.json\n", *workspaceName)
+			return 1
+		}
+		profile, region = workspace.Profile, workspace.Region
+		if region == "" {
+			// As for any profile: the environment, then the region last used with it
+			if region = aws.GetDefaultRegion(); region == "" {
+				region = cfg.RememberedRegions()[profile]
+			}
+		}
+	}
+	if arn, ok := aws.ParseSecretARN(secret); ok {
+		// The secret's own region, and a profile in its account unless one
+		// was asked for
+		if arn.Region != "" {
+			region = arn.Region
+		}
+		if os.Getenv("AWS_PROFILE") == "" && *workspaceName == "" {
+			if profiles, err := aws.GetAvailableProfiles(); err == nil {
+				profile = aws.ProfileForAccount(arn.Account, profile, profiles)
+			}
+		}
+	}
+	if *demoMode {
+		profile, region = demo.Profile, demo.Region
+	}
+	if vault != nil {
+		// The header shows the vault in place of the profile
+		profile, region = vault.Name(), ""
+	}
+	var cluster *kube.Cluster
+	if *kubernetes {
+		cluster = kube.New(*kubeContext, *kubeNamespace)
+		if err := cluster.Resolve(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		profile, region = cluster.Label(), ""
+	}
+
+	if !*demoMode && !cli.IsInteractive(cli.DefaultIO()) {
+		// Piped or redirected: print the secret as `secretsrc get` does, as
+		// the UI would only write escape sequences into the output
+		if cluster != nil {
+			fmt.Fprintln(os.Stderr, "Error: the UI needs a terminal; use `kubectl get secret` in scripts")
+			return 2
+		}
+		if secret == "" {
+			fmt.Fprintln(os.Stderr, "Error: the UI needs a terminal; use a command such as `secretsrc get <secret>` in scripts")
+			return 2
+		}
+		args := []string{"get", "--profile", profile, "--region", region, "--endpoint-url", *endpointURL, "--", secret}
+		if vault != nil {
+			args = []string{"get", "--azure-vault", vault.URL(), "--", secret}
+		}
+		return cli.Run(context.Background(), args, cli.DefaultIO())
+	}
+
+	defer prepareConsole()()
+
+	t, themeErr := theme.Resolve(cfg.Theme, cfg.Colors)
+	if themeErr != nil {
+		themeErr = fmt.Errorf("invalid theme settings, using default: %w", themeErr)
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", themeErr)
+	}
+	ui.ApplyTheme(t)
+
+	// Settings that can't be used are shown in the header, as the UI hides
+	// anything printed before it starts
+	model := ui.NewModel(profile, region).WithConfig(cfg).WithConfigWarning(configErr).WithConfigWarning(themeErr).
+		WithEndpointURL(*endpointURL).WithFilter(workspace.Filter)
+	if secret != "" {
+		model = model.WithSecret(secret)
+	}
+	if *demoMode {
+		model = model.WithDemo(demo.NewStore())
+	}
+	if vault != nil {
+		model = model.WithBackend(vault, azure.Name)
+	}
+	if cluster != nil {
+		model = model.WithBackend(cluster, kube.Name)
+	}
+	if *tutorial {
+		model = model.WithTutorial()
+	}
+	if *dryRun {
+		model = model.WithDryRun()
+	}
+	if !*demoMode && vault == nil && cluster == nil && *workspaceName == "" && secret == "" && os.Getenv("AWS_PROFILE") == "" && cfg.LastProfile == "" {
+		// First run: guide the user to a profile rather than trying
+		// "default", which may not exist. Without any profiles the default
+		// credential chain is all there is, so that is tried straight away.
+		if profiles, err := aws.GetAvailableProfiles(); err == nil && len(profiles) > 0 {
+			model = model.WithStartupPicker(profiles)
+		}
+	}
+
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if cfg.Mouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	p := tea.NewProgram(model, options...)
+	defer ui.WatchRetries(p)()
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(logging.Writer(os.Stderr), "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// countSet returns how many of flags are set
+func countSet(flags ...bool) int {
+	count := 0
+	for _, set := range flags {
+		if set {
+			count++
+		}
+	}
+	return count
+}
+
+// backendFromFlags returns the backend the flags choose, or "" to use the
+// backend setting
+func backendFromFlags(backend string, demoMode, azureVault, kubernetes bool) (string, error) {
+	if backend != "" && !slices.Contains(config.Backends, backend) {
+		return "", fmt.Errorf("--backend must be %s, not %q", strings.Join(config.Backends, ", "), backend)
+	}
+	flags := []struct {
+		backend string
+		flag    string
+		set     bool
+	}{
+		{config.BackendDemo, "--demo", demoMode},
+		{config.BackendAzure, "--azure-vault", azureVault},
+		{config.BackendKubernetes, "--kubernetes", kubernetes},
+	}
+	for _, f := range flags {
+		if !f.set {
+			continue
+		}
+		if backend != "" && backend != f.backend {
+			return "", fmt.Errorf("--backend %s can't be used with %s", backend, f.flag)
+		}
+		backend = f.backend
+	}
+	return backend, nil
+}
+
+// enableDebugLog routes debug logging to the debug log file. The terminal is
+// owned by the UI, so the log cannot go to stderr.
+func enableDebugLog() (func(), error) {
+	path, err := config.DebugLogPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open debug log: %w", err)
+	}
+
+	logging.EnableDebug(file)
+	logging.Debugf("secretsrc started")
+
+	return func() {
+		logging.EnableDebug(nil)
+		_ = file.Close()
+	}, nil
+}
+
+// resolveStartupTarget picks the profile and region to start with. Environment
+// variables win, then the last profile saved in the config file and the
+// region last used with the profile.
+func resolveStartupTarget(cfg *config.Config) (string, string) {
+	profile := aws.GetDefaultProfile()
+	region := aws.GetDefaultRegion()
+
+	if os.Getenv("AWS_PROFILE") == "" && cfg.LastProfile != "" {
+		profile = cfg.LastProfile
+	}
+	if region == "" {
+		region = cfg.RememberedRegions()[profile]
+	}
+
+	return profile, region
+}
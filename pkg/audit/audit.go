@@ -0,0 +1,61 @@
+// Package audit appends the actions taken on secrets to a local log, for
+// reviewing what was touched during an incident. Secret names are recorded,
+// values never are.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// Entry is one action, written as a line of JSON
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Source is "ui" or the CLI command that took the action
+	Source string `json:"source"`
+	// Identity is the ARN of the IAM identity the credentials belong to,
+	// empty when STS couldn't say
+	Identity string `json:"identity,omitempty"`
+	Account  string `json:"account,omitempty"`
+	Profile  string `json:"profile"`
+	Region   string `json:"region"`
+	// Action says what was done to which secrets, e.g. "Copied app/db to
+	// the clipboard"
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Append adds entry to the log at path, creating the log and its directory
+// when missing. Anything tracked as a secret value is redacted first.
+func Append(path string, entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC().Truncate(time.Second)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line := logging.Scrub(string(data)) + "\n"
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.WriteString(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+func TestAppendWritesOneRedactedLinePerEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+	logging.TrackSecret("hunter2-audit")
+
+	if err := Append(path, Entry{Source: "ui", Profile: "prod", Region: "eu-west-2", Action: "Read the value of app/db"}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	err := Append(path, Entry{Source: "put", Profile: "prod", Region: "eu-west-2", Action: "Updated app/db", Error: "rejected hunter2-audit"})
+	if err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the log to be created: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2-audit") {
+		t.Fatalf("expected tracked values to be redacted, got %s", data)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a line per entry, got %q", lines)
+	}
+	var entry Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected JSON lines, got %v", err)
+	}
+	if entry.Action != "Read the value of app/db" || entry.Time.IsZero() || entry.Error != "" {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+
+	if info, err := os.Stat(path); err == nil && runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		t.Fatalf("expected the log to be private, got %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,245 @@
+// Package auth resolves the credentials for an AWS profile through a chain
+// of resolvers, shared by the interactive UI and the CLI subcommands.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// Request describes what to authenticate
+type Request struct {
+	Profile string
+	Region  string
+	// EndpointURL overrides the Secrets Manager endpoint, see aws.EndpointURL
+	EndpointURL string
+
+	// MFACode answers an earlier MFARequiredError
+	MFACode string
+
+	// Progress, when set, is called with a short description of each step
+	Progress func(string)
+}
+
+// Session is the state passed along the pipeline. Each resolver reads what
+// earlier ones found and adds to it.
+type Session struct {
+	Request
+
+	// Config is the profile's shared config, empty when the profile has none
+	Config *aws.ProfileConfig
+	// MFA describes the profile's MFA requirement
+	MFA *aws.MFAConfig
+	// Chain is the profile's role chain, from the profile holding the base
+	// credentials to the profile itself, as returned by aws.RoleChain
+	Chain []string
+
+	// Credentials are the credentials resolved so far. While nil the client
+	// is left to the SDK's shared config and default credential chain.
+	Credentials *awssdk.Credentials
+	// Hop is the index in Chain of the profile Credentials belong to. The
+	// roles after it still have to be assumed.
+	Hop int
+
+	// Done is set when later resolvers have nothing to do: the credentials
+	// are final, or the SDK handles the rest of the chain itself (SSO)
+	Done bool
+}
+
+// MFAProfile returns the profile MFA sessions are created and cached for:
+// the source profile when the profile assumes a role
+func (s *Session) MFAProfile() string {
+	if s.MFA != nil && s.MFA.SourceProfile != "" {
+		return s.MFA.SourceProfile
+	}
+	return s.Profile
+}
+
+// mfaHop returns the index in Chain of the profile MFA sessions are for
+func (s *Session) mfaHop() int {
+	if s.MFA != nil && s.MFA.SourceProfile != "" {
+		return 0
+	}
+	return len(s.Chain) - 1
+}
+
+// resolved records creds as the credentials for Chain[hop]
+func (s *Session) resolved(creds awssdk.Credentials, hop int) {
+	s.Credentials = &creds
+	s.Hop = hop
+}
+
+func (s *Session) progress(format string, args ...any) {
+	text := fmt.Sprintf(format, args...)
+	logging.Debug("auth progress", "profile", s.Profile, "step", text)
+	if s.Progress != nil {
+		s.Progress(text)
+	}
+}
+
+// Resolver is one step of the pipeline
+type Resolver interface {
+	// Name identifies the step in errors
+	Name() string
+	// Resolve inspects the session and adds to it. An error stops the pipeline.
+	Resolve(ctx context.Context, s *Session) error
+}
+
+// Names of the standard resolvers, as reported by StepError
+const (
+	StepCLICache = "aws-cli-cache"
+	StepProcess  = "credential-process"
+	StepCache    = "cache"
+	StepSSO      = "sso"
+	StepMFA      = "mfa"
+	StepRole     = "role"
+)
+
+// StepError reports which resolver failed
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// FailedAt reports whether err came from the named step
+func FailedAt(err error, step string) bool {
+	var stepErr *StepError
+	return errors.As(err, &stepErr) && stepErr.Step == step
+}
+
+// MFARequiredError is returned when a new MFA session is needed. Authenticate
+// again with Request.MFACode set to continue.
+type MFARequiredError struct {
+	// Profile is the profile the MFA session is for
+	Profile   string
+	MFASerial string
+}
+
+func (e *MFARequiredError) Error() string {
+	return fmt.Sprintf("MFA code required for %s", e.MFASerial)
+}
+
+// Pipeline authenticates by running its resolvers in order
+type Pipeline struct {
+	resolvers []Resolver
+}
+
+// NewPipeline creates a pipeline from resolvers
+func NewPipeline(resolvers ...Resolver) *Pipeline {
+	return &Pipeline{resolvers: resolvers}
+}
+
+// Default returns the standard pipeline: cached session, credential_process,
+// SSO, MFA, then role assumption. With aws_cli_cache set, sessions from the
+// AWS CLI are tried first.
+func Default() *Pipeline {
+	// A config.yaml that fails to parse still leaves config.json's settings
+	cfg, _ := config.Load()
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	cache := ConfigCache{}
+	sts := AWSSTS{RoleDuration: cfg.RoleDurationOverride()}
+	resolvers := []Resolver{
+		CacheResolver{Cache: cache},
+		ProcessResolver{Cache: cache},
+		SSOResolver{},
+		MFAResolver{STS: sts, Cache: cache},
+		RoleResolver{STS: sts, Cache: cache},
+	}
+
+	if cfg.AWSCLICache {
+		resolvers = append([]Resolver{CLICacheResolver{}}, resolvers...)
+	}
+	return NewPipeline(resolvers...)
+}
+
+// Authenticate resolves credentials for the request and creates a client
+// with them
+func (p *Pipeline) Authenticate(ctx context.Context, req Request) (*aws.Client, error) {
+	session, err := p.Resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	endpointURL := aws.EndpointURL(session.Profile, req.EndpointURL)
+	if session.Credentials == nil {
+		return aws.NewClient(ctx, session.Profile, session.Region, endpointURL)
+	}
+	return aws.NewClientWithMFA(ctx, session.Profile, session.Region, endpointURL, *session.Credentials)
+}
+
+// logStep records what a resolver decided in the debug log: whether the
+// session has credentials and for which profile in the chain, and whether
+// the remaining steps are skipped
+func logStep(step string, s *Session, err error) {
+	attrs := []any{"step", step, "has_credentials", s.Credentials != nil, "done", s.Done}
+	if s.Credentials != nil && s.Hop < len(s.Chain) {
+		attrs = append(attrs, "credentials_for", s.Chain[s.Hop], "source", s.Credentials.Source)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	logging.Debug("auth step", attrs...)
+}
+
+// Resolve runs the resolvers and returns the resulting session
+func (p *Pipeline) Resolve(ctx context.Context, req Request) (*Session, error) {
+	session := &Session{Request: req}
+
+	// An unreadable config file is left to the SDK, which reports the problem
+	session.Config = &aws.ProfileConfig{}
+	session.MFA = &aws.MFAConfig{}
+	session.Chain = []string{req.Profile}
+	if profileConfig, err := aws.GetProfileConfig(req.Profile); err == nil {
+		session.Config = profileConfig
+	} else {
+		logging.Debugf("auth: failed to read config for profile %q: %v", req.Profile, err)
+	}
+	if mfaConfig, err := aws.GetMFAConfig(req.Profile); err == nil {
+		session.MFA = mfaConfig
+	}
+	if chain, err := aws.RoleChain(req.Profile); err == nil {
+		session.Chain = chain
+	} else if errors.Is(err, aws.ErrSourceProfileLoop) {
+		return nil, &StepError{Step: StepRole, Err: err}
+	}
+
+	// The MFA code is left out of the log; only whether one was given is useful
+	logging.Debug("auth: resolving credentials", "profile", req.Profile, "region", req.Region,
+		"chain", strings.Join(session.Chain, " -> "), "mfa_serial", session.MFA.MFASerial,
+		"mfa_profile", session.MFAProfile(), "mfa_code_given", req.MFACode != "")
+
+	for _, resolver := range p.resolvers {
+		if session.Done {
+			break
+		}
+		err := resolver.Resolve(ctx, session)
+		logStep(resolver.Name(), session, err)
+		if err != nil {
+			var mfaErr *MFARequiredError
+			if errors.As(err, &mfaErr) {
+				return nil, err
+			}
+			return nil, &StepError{Step: resolver.Name(), Err: err}
+		}
+	}
+
+	return session, nil
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+)
+
+const testConfig = `[profile direct]
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+
+[profile base]
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+
+[profile admin]
+source_profile = base
+role_arn = arn:aws:iam::222222222222:role/admin
+region = eu-west-2
+
+[profile workload]
+source_profile = admin
+role_arn = arn:aws:iam::444444444444:role/deploy
+
+[profile sso]
+sso_session = corp
+sso_account_id = 333333333333
+sso_role_name = ReadOnly
+
+[profile plain]
+region = us-east-1
+
+[profile process]
+credential_process = get-creds
+
+[profile process-admin]
+source_profile = process
+role_arn = arn:aws:iam::222222222222:role/admin
+`
+
+type fakeCache map[string]awssdk.Credentials
+
+func (c fakeCache) Get(profile string) (awssdk.Credentials, bool) {
+	creds, ok := c[profile]
+	return creds, ok
+}
+
+func (c fakeCache) Save(profile string, creds awssdk.Credentials) error {
+	c[profile] = creds
+	return nil
+}
+
+type fakeSTS struct {
+	sessionCalls int
+	roleCalls    int
+	err          error
+}
+
+func (f *fakeSTS) GetSessionToken(ctx context.Context, profile, region, mfaSerial, code string) (awssdk.Credentials, error) {
+	f.sessionCalls++
+	if f.err != nil {
+		return awssdk.Credentials{}, f.err
+	}
+	return awssdk.Credentials{AccessKeyID: "session-" + profile}, nil
+}
+
+func (f *fakeSTS) AssumeRole(ctx context.Context, profile, region string, source awssdk.Credentials) (awssdk.Credentials, string, error) {
+	f.roleCalls++
+	return awssdk.Credentials{AccessKeyID: "role-" + profile + "-from-" + source.AccessKeyID}, "eu-west-2", nil
+}
+
+func testPipeline(t *testing.T, cache fakeCache, sts *fakeSTS) *Pipeline {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", path)
+
+	return NewPipeline(
+		CacheResolver{Cache: cache},
+		SSOResolver{},
+		MFAResolver{STS: sts, Cache: cache},
+		RoleResolver{STS: sts, Cache: cache},
+	)
+}
+
+func TestPipelineAsksForMFACode(t *testing.T) {
+	cache := fakeCache{}
+	sts := &fakeSTS{}
+	pipeline := testPipeline(t, cache, sts)
+
+	_, err := pipeline.Resolve(context.Background(), Request{Profile: "admin"})
+	var mfaErr *MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		t.Fatalf("expected MFARequiredError, got %v", err)
+	}
+	if mfaErr.Profile != "base" || mfaErr.MFASerial != "arn:aws:iam::111111111111:mfa/alice" {
+		t.Fatalf("expected the MFA session to be for the source profile, got %+v", mfaErr)
+	}
+
+	var steps []string
+	session, err := pipeline.Resolve(context.Background(), Request{
+		Profile:  "admin",
+		MFACode:  "123456",
+		Progress: func(text string) { steps = append(steps, text) },
+	})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got := session.Credentials.AccessKeyID; got != "role-admin-from-session-base" {
+		t.Fatalf("expected role credentials from the MFA session, got %q", got)
+	}
+	if session.Region != "eu-west-2" {
+		t.Fatalf("expected the role's region, got %q", session.Region)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected progress for the MFA and role steps, got %v", steps)
+	}
+	if _, ok := cache["base"]; !ok {
+		t.Fatal("expected the new MFA session to be cached")
+	}
+}
+
+func TestPipelineChainsRoles(t *testing.T) {
+	cache := fakeCache{}
+	sts := &fakeSTS{}
+	pipeline := testPipeline(t, cache, sts)
+
+	session, err := pipeline.Resolve(context.Background(), Request{Profile: "workload", MFACode: "123456"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got := session.Credentials.AccessKeyID; got != "role-workload-from-role-admin-from-session-base" {
+		t.Fatalf("expected credentials assumed through each hop, got %q", got)
+	}
+
+	// With the last hop's credentials gone, the chain resumes from the
+	// cached credentials of the hop before it, without asking for MFA
+	delete(cache, "role/workload")
+	delete(cache, "base")
+	sts.roleCalls = 0
+	session, err = pipeline.Resolve(context.Background(), Request{Profile: "workload"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got := session.Credentials.AccessKeyID; got != "role-workload-from-role-admin-from-session-base" || sts.roleCalls != 1 {
+		t.Fatalf("expected one AssumeRole call from the cached hop, got %q after %d calls", got, sts.roleCalls)
+	}
+}
+
+func TestPipelineUsesCachedSession(t *testing.T) {
+	cache := fakeCache{"direct": {AccessKeyID: "cached"}}
+	sts := &fakeSTS{}
+	pipeline := testPipeline(t, cache, sts)
+
+	session, err := pipeline.Resolve(context.Background(), Request{Profile: "direct"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if session.Credentials.AccessKeyID != "cached" || sts.sessionCalls != 0 {
+		t.Fatalf("expected the cached session without calling STS, got %+v after %d calls", session.Credentials, sts.sessionCalls)
+	}
+}
+
+func TestPipelineReportsFailedStep(t *testing.T) {
+	sts := &fakeSTS{err: errors.New("invalid MFA code")}
+	pipeline := testPipeline(t, fakeCache{}, sts)
+
+	_, err := pipeline.Resolve(context.Background(), Request{Profile: "direct", MFACode: "000000"})
+	if !FailedAt(err, StepMFA) {
+		t.Fatalf("expected the MFA step to fail, got %v", err)
+	}
+}
+
+func TestPipelineLeavesOtherProfilesToTheSDK(t *testing.T) {
+	sts := &fakeSTS{}
+	pipeline := testPipeline(t, fakeCache{}, sts)
+
+	for _, profile := range []string{"sso", "plain", "missing"} {
+		session, err := pipeline.Resolve(context.Background(), Request{Profile: profile})
+		if err != nil {
+			t.Fatalf("%s: Resolve returned error: %v", profile, err)
+		}
+		if session.Credentials != nil {
+			t.Fatalf("%s: expected no credentials from the pipeline, got %+v", profile, session.Credentials)
+		}
+		if session.Done != (profile == "sso") {
+			t.Fatalf("%s: expected only the SSO profile to be delegated", profile)
+		}
+	}
+	if sts.sessionCalls+sts.roleCalls != 0 {
+		t.Fatal("expected no STS calls")
+	}
+}
+
+func TestPipelineRunsCredentialProcess(t *testing.T) {
+	cache := fakeCache{}
+	sts := &fakeSTS{}
+	pipeline := testPipeline(t, cache, sts)
+	runs := 0
+	pipeline.resolvers = append([]Resolver{ProcessResolver{
+		Cache: cache,
+		Run: func(ctx context.Context, command string) (awssdk.Credentials, error) {
+			runs++
+			return awssdk.Credentials{AccessKeyID: "process", CanExpire: true}, nil
+		},
+	}}, pipeline.resolvers...)
+
+	session, err := pipeline.Resolve(context.Background(), Request{Profile: "process-admin"})
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if got := session.Credentials.AccessKeyID; got != "role-process-admin-from-process" {
+		t.Fatalf("expected role credentials from the source profile's process, got %q", got)
+	}
+
+	if _, err := pipeline.Resolve(context.Background(), Request{Profile: "process"}); err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if runs != 1 {
+		t.Fatalf("expected the cached process output to be reused, ran %d times", runs)
+	}
+}
+
+func TestPipelineReportsCredentialProcessFailure(t *testing.T) {
+	pipeline := testPipeline(t, fakeCache{}, &fakeSTS{})
+	pipeline.resolvers = append([]Resolver{ProcessResolver{
+		Cache: fakeCache{},
+		Run: func(ctx context.Context, command string) (awssdk.Credentials, error) {
+			return awssdk.Credentials{}, errors.New("credential_process failed: exit status 1: token expired")
+		},
+	}}, pipeline.resolvers...)
+
+	_, err := pipeline.Resolve(context.Background(), Request{Profile: "process"})
+	if !FailedAt(err, StepProcess) {
+		t.Fatalf("expected the credential_process step to fail, got %v", err)
+	}
+}
+
+func TestCLICacheResolverRequiresSSOLogin(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	pipeline := testPipeline(t, fakeCache{}, &fakeSTS{})
+	pipeline.resolvers = append([]Resolver{CLICacheResolver{}}, pipeline.resolvers...)
+
+	_, err := pipeline.Resolve(context.Background(), Request{Profile: "sso"})
+	if !FailedAt(err, StepCLICache) {
+		t.Fatalf("expected a missing SSO login to fail the AWS CLI cache step, got %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+)
+
+// CredentialCache stores MFA sessions, and the role credentials assumed with
+// them, between runs
+type CredentialCache interface {
+	Get(profile string) (awssdk.Credentials, bool)
+	Save(profile string, creds awssdk.Credentials) error
+}
+
+// STS issues the temporary credentials the pipeline needs
+type STS interface {
+	GetSessionToken(ctx context.Context, profile, region, mfaSerial, code string) (awssdk.Credentials, error)
+	// AssumeRole returns the role credentials and the region to use with them
+	AssumeRole(ctx context.Context, profile, region string, source awssdk.Credentials) (awssdk.Credentials, string, error)
+}
+
+// ConfigCache is the CredentialCache backed by the secretsrc credentials cache
+type ConfigCache struct{}
+
+func (ConfigCache) Get(profile string) (awssdk.Credentials, bool) {
+	cached, valid := config.GetCachedCredentials(profile)
+	if !valid {
+		return awssdk.Credentials{}, false
+	}
+	return awssdk.Credentials{
+		AccessKeyID:     cached.AccessKeyID,
+		SecretAccessKey: cached.SecretAccessKey,
+		SessionToken:    cached.SessionToken,
+		Source:          "CachedMFA",
+		CanExpire:       true,
+		Expires:         cached.ExpiresAt,
+	}, true
+}
+
+func (ConfigCache) Save(profile string, creds awssdk.Credentials) error {
+	return config.SaveCachedCredentials(profile, config.CachedCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		ExpiresAt:       creds.Expires,
+	})
+}
+
+// roleCacheKey is the cache key for role credentials assumed for profile,
+// kept apart from any MFA session for the same profile
+func roleCacheKey(profile string) string {
+	return "role/" + profile
+}
+
+// processCacheKey is the cache key for credential_process output for
+// profile, kept apart from any MFA session for the same profile
+func processCacheKey(profile string) string {
+	return "credential_process/" + profile
+}
+
+// ForgetMFASession deletes the cached MFA session for profile and the role
+// credentials assumed with it, so the next authentication asks for a code.
+// It reports whether the profile requires MFA at all.
+func ForgetMFASession(profile string) bool {
+	mfaConfig, err := aws.GetMFAConfig(profile)
+	if err != nil || !mfaConfig.Required {
+		return false
+	}
+
+	mfaProfile := profile
+	if mfaConfig.SourceProfile != "" {
+		mfaProfile = mfaConfig.SourceProfile
+	}
+	// Ignore errors, the caller drops its client regardless
+	_ = config.DeleteCachedCredentials(mfaProfile)
+	if chain, err := aws.RoleChain(profile); err == nil {
+		for _, hop := range chain[1:] {
+			_ = config.DeleteCachedCredentials(roleCacheKey(hop))
+		}
+	}
+	return true
+}
+
+// ForgetSession deletes every cached credential profile signs in with: its
+// MFA session, the role credentials along its role chain and the output of
+// its credential_process, so the next authentication starts afresh
+func ForgetSession(profile string) {
+	ForgetMFASession(profile)
+
+	chain, err := aws.RoleChain(profile)
+	if err != nil {
+		chain = []string{profile}
+	}
+	// Ignore errors, a stale entry is replaced on the next sign-in anyway
+	for _, hop := range chain[1:] {
+		_ = config.DeleteCachedCredentials(roleCacheKey(hop))
+	}
+	_ = config.DeleteCachedCredentials(processCacheKey(chain[0]))
+}
+
+// AWSSTS is the STS implementation that calls AWS
+type AWSSTS struct {
+	// RoleDuration, when set, overrides the duration_seconds of every role
+	RoleDuration time.Duration
+}
+
+func (AWSSTS) GetSessionToken(ctx context.Context, profile, region, mfaSerial, code string) (awssdk.Credentials, error) {
+	return aws.GetSessionTokenWithMFA(ctx, profile, region, mfaSerial, code)
+}
+
+func (a AWSSTS) AssumeRole(ctx context.Context, profile, region string, source awssdk.Credentials) (awssdk.Credentials, string, error) {
+	return aws.AssumeRole(ctx, profile, region, source, a.RoleDuration)
+}
+
+// CLICacheResolver reuses sessions started with the AWS CLI: role credentials
+// from ~/.aws/cli/cache, and the token from `aws sso login` for SSO profiles
+type CLICacheResolver struct{}
+
+func (CLICacheResolver) Name() string { return StepCLICache }
+
+func (CLICacheResolver) Resolve(ctx context.Context, s *Session) error {
+	if s.Credentials != nil {
+		return nil
+	}
+
+	if s.Config.UsesSSO() {
+		// The SDK reads the token itself, so only check it is usable to
+		// give a clearer error than the SDK's
+		expires, ok, err := aws.SSOTokenExpiry(s.Profile)
+		if err != nil {
+			return err
+		}
+		if !ok || time.Now().After(expires) {
+			return fmt.Errorf("AWS SSO session for profile %s has expired or was never started, run `aws sso login --profile %s`", s.Profile, s.Profile)
+		}
+		return nil
+	}
+
+	if creds, ok := aws.CLICachedRoleCredentials(s.Profile); ok {
+		s.progress("Using AWS CLI session for %s", s.Profile)
+		s.resolved(creds, len(s.Chain)-1)
+		if s.Region == "" {
+			s.Region = s.Config.Region
+		}
+		s.Done = true
+	}
+	return nil
+}
+
+// ProcessResolver runs the credential_process of the profile, or of the
+// profile at the root of its role chain, and caches what it prints until it
+// expires
+type ProcessResolver struct {
+	Cache CredentialCache
+	// Run runs the command, defaulting to aws.RunCredentialProcess
+	Run func(ctx context.Context, command string) (awssdk.Credentials, error)
+}
+
+func (ProcessResolver) Name() string { return StepProcess }
+
+func (r ProcessResolver) Resolve(ctx context.Context, s *Session) error {
+	if s.Credentials != nil {
+		return nil
+	}
+
+	profile, command := s.Chain[0], s.Config.CredentialProcess
+	if profile != s.Profile {
+		source, err := aws.GetProfileConfig(profile)
+		if err != nil {
+			return nil
+		}
+		command = source.CredentialProcess
+	}
+	if command == "" {
+		return nil
+	}
+
+	cacheKey := processCacheKey(profile)
+	if creds, ok := r.Cache.Get(cacheKey); ok {
+		s.progress("Using cached credential_process output for %s", profile)
+		s.resolved(creds, 0)
+		return nil
+	}
+
+	run := r.Run
+	if run == nil {
+		run = aws.RunCredentialProcess
+	}
+	s.progress("Running credential_process for %s", profile)
+	creds, err := run(ctx, command)
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", profile, err)
+	}
+
+	if creds.CanExpire {
+		_ = r.Cache.Save(cacheKey, creds) // Ignore errors, caching is best effort
+	}
+	s.resolved(creds, 0)
+	return nil
+}
+
+// CacheResolver reuses cached role credentials from the furthest hop along
+// the profile's role chain, or else a cached MFA session for profiles that
+// require MFA
+type CacheResolver struct {
+	Cache CredentialCache
+}
+
+func (CacheResolver) Name() string { return StepCache }
+
+func (r CacheResolver) Resolve(ctx context.Context, s *Session) error {
+	if s.Credentials != nil {
+		return nil
+	}
+
+	for hop := len(s.Chain) - 1; hop > 0; hop-- {
+		if creds, ok := r.Cache.Get(roleCacheKey(s.Chain[hop])); ok {
+			s.progress("Using cached role session for %s", s.Chain[hop])
+			s.resolved(creds, hop)
+			return nil
+		}
+	}
+
+	if !s.MFA.Required {
+		return nil
+	}
+	if creds, ok := r.Cache.Get(s.MFAProfile()); ok {
+		s.progress("Using cached MFA session for %s", s.MFAProfile())
+		s.resolved(creds, s.mfaHop())
+	}
+	return nil
+}
+
+// SSOResolver hands IAM Identity Center (SSO) profiles to the SDK, which
+// signs in with the token from `aws sso login`
+type SSOResolver struct{}
+
+func (SSOResolver) Name() string { return StepSSO }
+
+func (SSOResolver) Resolve(ctx context.Context, s *Session) error {
+	if s.Credentials != nil || !s.Config.UsesSSO() {
+		return nil
+	}
+
+	s.progress("Using AWS SSO sign-in for %s", s.Profile)
+	s.Done = true
+	return nil
+}
+
+// MFAResolver creates a new MFA session when no cached one was found. Without
+// a code it stops the pipeline with an MFARequiredError.
+type MFAResolver struct {
+	STS   STS
+	Cache CredentialCache
+}
+
+func (MFAResolver) Name() string { return StepMFA }
+
+func (r MFAResolver) Resolve(ctx context.Context, s *Session) error {
+	if !s.MFA.Required || s.Credentials != nil {
+		return nil
+	}
+
+	if s.MFACode == "" {
+		return &MFARequiredError{Profile: s.MFAProfile(), MFASerial: s.MFA.MFASerial}
+	}
+
+	s.progress("Verifying MFA code for %s", s.MFAProfile())
+	creds, err := r.STS.GetSessionToken(ctx, s.MFAProfile(), s.Region, s.MFA.MFASerial, s.MFACode)
+	if err != nil {
+		return err
+	}
+	s.resolved(creds, s.mfaHop())
+
+	_ = r.Cache.Save(s.MFAProfile(), creds) // Ignore errors, caching is best effort
+	return nil
+}
+
+// RoleResolver assumes the remaining roles of the profile's role chain, one
+// hop at a time, once an earlier step has resolved credentials part of the
+// way. Each hop's credentials are cached when Cache is set. Chains with no
+// resolved credentials are assumed by the SDK itself.
+type RoleResolver struct {
+	STS   STS
+	Cache CredentialCache
+}
+
+func (RoleResolver) Name() string { return StepRole }
+
+func (r RoleResolver) Resolve(ctx context.Context, s *Session) error {
+	if s.Credentials == nil {
+		return nil
+	}
+
+	region := s.Region
+	for hop := s.Hop + 1; hop < len(s.Chain); hop++ {
+		profile := s.Chain[hop]
+		s.progress("Assuming role for %s", profile)
+		creds, hopRegion, err := r.STS.AssumeRole(ctx, profile, region, *s.Credentials)
+		if err != nil {
+			if profile != s.Profile {
+				return fmt.Errorf("profile %s: %w", profile, err)
+			}
+			return err
+		}
+
+		if r.Cache != nil {
+			_ = r.Cache.Save(roleCacheKey(profile), creds) // Ignore errors, caching is best effort
+		}
+		s.resolved(creds, hop)
+		s.Region = hopRegion
+	}
+	return nil
+}
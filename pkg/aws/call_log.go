@@ -0,0 +1,76 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// withCallLogging writes every call made by clients built from the config
+// to the debug log. Parameters and responses are left out, as they can hold
+// secret values and credentials.
+var withCallLogging = config.WithAPIOptions([]func(*middleware.Stack) error{addCallLogging})
+
+// addCallLogging adds logCall to a client's middleware stack, after the
+// service and operation are recorded and ahead of the retries
+func addCallLogging(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("secretsrcCallLog", logCall), middleware.After)
+}
+
+// logCall logs an operation once it has finished, retries included: its
+// service, region, time taken, attempts, request ID and any error
+func logCall(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	if !logging.DebugEnabled() {
+		return next.HandleInitialize(ctx, in)
+	}
+
+	start := time.Now()
+	out, metadata, err := next.HandleInitialize(ctx, in)
+
+	attrs := []any{
+		"service", awsmiddleware.GetServiceID(ctx),
+		"operation", awsmiddleware.GetOperationName(ctx),
+		"region", awsmiddleware.GetRegion(ctx),
+		"duration", time.Since(start).Round(time.Millisecond),
+	}
+	if results, ok := retry.GetAttemptResults(metadata); ok {
+		attrs = append(attrs, "attempts", len(results.Results))
+	}
+	if requestID, ok := awsmiddleware.GetRequestIDMetadata(metadata); ok {
+		attrs = append(attrs, "request_id", requestID)
+	}
+	writeCallLog(attrs, err)
+	return out, metadata, err
+}
+
+// logRawCall logs a call made by a rawService as logCall logs the others
+func logRawCall(service, operation, region string, start time.Time, attempts int, requestID string, err error) {
+	if !logging.DebugEnabled() {
+		return
+	}
+	attrs := []any{
+		"service", service,
+		"operation", operation,
+		"region", region,
+		"duration", time.Since(start).Round(time.Millisecond),
+		"attempts", attempts,
+	}
+	if requestID != "" {
+		attrs = append(attrs, "request_id", requestID)
+	}
+	writeCallLog(attrs, err)
+}
+
+// writeCallLog writes a finished call to the debug log
+func writeCallLog(attrs []any, err error) {
+	if err != nil {
+		logging.Debug("aws call failed", append(attrs, "error", err)...)
+	} else {
+		logging.Debug("aws call", attrs...)
+	}
+}
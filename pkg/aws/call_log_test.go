@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+func TestCallsAreWrittenToTheDebugLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-amzn-RequestId", "req-1234")
+		w.Write([]byte(`{"SecretList":[]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-access-key")
+
+	var out bytes.Buffer
+	logging.EnableDebug(&out)
+	defer logging.EnableDebug(nil)
+
+	client, err := NewClient(context.Background(), "", "eu-west-2", server.URL)
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if _, _, err := client.ListSecrets(context.Background(), 10, nil); err != nil {
+		t.Fatalf("ListSecrets returned error: %v", err)
+	}
+
+	for _, want := range []string{`msg="aws call"`, "service=\"Secrets Manager\"", "operation=ListSecrets", "region=eu-west-2", "attempts=1", "request_id=req-1234"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %s in the debug log:\n%s", want, out.String())
+		}
+	}
+}
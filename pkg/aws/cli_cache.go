@@ -0,0 +1,183 @@
+package aws
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// cliCacheMargin treats cached credentials that are about to expire as
+// expired, so they are not handed out only to fail mid-request
+const cliCacheMargin = time.Minute
+
+// cliCacheDir returns the directory the AWS CLI caches assumed role
+// credentials in
+func cliCacheDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".aws", "cli", "cache")
+}
+
+// ssoCacheDir returns the directory `aws sso login` writes its tokens to
+func ssoCacheDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".aws", "sso", "cache")
+}
+
+// CLICachedRoleCredentials returns the credentials the AWS CLI cached when it
+// last assumed profile's role, if they are still valid. This lets a session
+// started with the CLI, including its MFA prompt, be reused.
+func CLICachedRoleCredentials(profile string) (aws.Credentials, bool) {
+	profileConfig, err := GetProfileConfig(profile)
+	if err != nil || profileConfig.RoleARN == "" || cliCacheDir() == "" {
+		return aws.Credentials{}, false
+	}
+
+	path := filepath.Join(cliCacheDir(), cliCacheKey(profileConfig)+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return aws.Credentials{}, false
+	}
+
+	var cached struct {
+		Credentials struct {
+			AccessKeyID     string `json:"AccessKeyId"`
+			SecretAccessKey string `json:"SecretAccessKey"`
+			SessionToken    string `json:"SessionToken"`
+			Expiration      string `json:"Expiration"`
+		} `json:"Credentials"`
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		logging.Debugf("failed to parse AWS CLI cache file %s: %v", path, err)
+		return aws.Credentials{}, false
+	}
+
+	creds := cached.Credentials
+	expires, err := parseCacheTime(creds.Expiration)
+	if err != nil || creds.AccessKeyID == "" || time.Now().Add(cliCacheMargin).After(expires) {
+		return aws.Credentials{}, false
+	}
+
+	logging.TrackSecret(creds.SecretAccessKey)
+	logging.TrackSecret(creds.SessionToken)
+
+	return aws.Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Source:          "AWSCLICache",
+		CanExpire:       true,
+		Expires:         expires,
+	}, true
+}
+
+// cliCacheKey returns the file name the AWS CLI uses for a role's cached
+// credentials: the SHA-1 of its AssumeRole arguments, minus the session
+// name, serialized like Python's json.dumps(args, sort_keys=True)
+func cliCacheKey(profileConfig *ProfileConfig) string {
+	args := map[string]any{"RoleArn": profileConfig.RoleARN}
+	if profileConfig.ExternalID != "" {
+		args["ExternalId"] = profileConfig.ExternalID
+	}
+	if profileConfig.MFASerial != "" {
+		args["SerialNumber"] = profileConfig.MFASerial
+	}
+	if seconds, err := strconv.Atoi(profileConfig.DurationSeconds); err == nil {
+		args["DurationSeconds"] = seconds
+	}
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = pythonJSON(key) + ": " + pythonJSON(args[key])
+	}
+
+	sum := sha1.Sum([]byte("{" + strings.Join(parts, ", ") + "}"))
+	return hex.EncodeToString(sum[:])
+}
+
+// pythonJSON encodes a string or number the way Python's json module does,
+// which unlike Go does not escape <, > and &
+func pythonJSON(value any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(value)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// SSOTokenExpiry returns when the token from `aws sso login` for profile
+// expires. ok is false when there is no cached token for the profile.
+func SSOTokenExpiry(profile string) (expires time.Time, ok bool, err error) {
+	profileConfig, err := GetProfileConfig(profile)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	// Tokens are keyed by the sso-session name, or the start URL for
+	// profiles that predate sso-session sections
+	key := profileConfig.SSOSession
+	if key == "" {
+		key = profileConfig.SSOStartURL
+	}
+	if key == "" || ssoCacheDir() == "" {
+		return time.Time{}, false, nil
+	}
+
+	sum := sha1.Sum([]byte(key))
+	path := filepath.Join(ssoCacheDir(), hex.EncodeToString(sum[:])+".json")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read SSO token cache: %w", err)
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresAt   string `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(data, &token); err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to parse SSO token cache: %w", err)
+	}
+	if token.AccessToken == "" {
+		return time.Time{}, false, nil
+	}
+
+	expires, err = parseCacheTime(token.ExpiresAt)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to parse SSO token expiry: %w", err)
+	}
+	return expires, true, nil
+}
+
+// parseCacheTime parses an expiry time from the AWS CLI caches, which use
+// RFC 3339 or, in older versions, a "UTC" suffix
+func parseCacheTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05MST", value)
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCLICacheKeyMatchesAWSCLI(t *testing.T) {
+	// Computed with Python: sha1(json.dumps(args, sort_keys=True))
+	got := cliCacheKey(&ProfileConfig{
+		RoleARN:         "arn:aws:iam::222222222222:role/admin",
+		MFASerial:       "arn:aws:iam::111111111111:mfa/alice",
+		DurationSeconds: "3600",
+		RoleSessionName: "ignored",
+	})
+	if want := "054df32db83a16a08161c20bdc0385ac62518a54"; got != want {
+		t.Fatalf("cliCacheKey = %q, want %q", got, want)
+	}
+}
+
+func TestCLICachedRoleCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configPath := filepath.Join(home, "config")
+	writeFile(t, configPath, `[profile admin]
+role_arn = arn:aws:iam::222222222222:role/admin
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+duration_seconds = 3600
+source_profile = base
+
+[profile sso]
+sso_session = corp
+`)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+
+	if _, ok := CLICachedRoleCredentials("admin"); ok {
+		t.Fatal("expected no credentials before the CLI has cached any")
+	}
+
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	writeFile(t, filepath.Join(home, ".aws", "cli", "cache", "054df32db83a16a08161c20bdc0385ac62518a54.json"),
+		`{"Credentials": {"AccessKeyId": "ASIAEXAMPLE", "SecretAccessKey": "secret", "SessionToken": "token", "Expiration": "`+expires+`"}, "ProviderType": "assume-role"}`)
+
+	creds, ok := CLICachedRoleCredentials("admin")
+	if !ok || creds.AccessKeyID != "ASIAEXAMPLE" {
+		t.Fatalf("expected the AWS CLI's cached credentials, got %+v, %v", creds, ok)
+	}
+
+	writeFile(t, filepath.Join(home, ".aws", "sso", "cache", "ee0bfd2552fbd840c02cc48b6e823320543c450f.json"),
+		`{"accessToken": "token", "expiresAt": "2020-01-01T00:00:00UTC"}`)
+	tokenExpires, ok, err := SSOTokenExpiry("sso")
+	if err != nil || !ok || tokenExpires.Year() != 2020 {
+		t.Fatalf("expected the cached SSO token expiry, got %v, %v, %v", tokenExpires, ok, err)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
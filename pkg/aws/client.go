@@ -5,23 +5,76 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
 )
 
+// secretsAPI is the part of the Secrets Manager SDK client that Client
+// uses. *secretsmanager.Client implements it, and tests substitute fakes.
+type secretsAPI interface {
+	ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error)
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+	BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error)
+	DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error)
+	TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error)
+	UntagResource(ctx context.Context, params *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error)
+	DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error)
+	RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error)
+	UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error)
+	CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error)
+	PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error)
+	UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error)
+	GetResourcePolicy(ctx context.Context, params *secretsmanager.GetResourcePolicyInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetResourcePolicyOutput, error)
+	RotateSecret(ctx context.Context, params *secretsmanager.RotateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RotateSecretOutput, error)
+}
+
 // Client wraps the AWS SDK client for Secrets Manager
 type Client struct {
-	sm      *secretsmanager.Client
+	sm      secretsAPI
+	sts     stsAPI // Nil for clients created with NewClientWithAPI
+	kms     kmsAPI // Nil for clients created with NewClientWithAPI
 	profile string
 	region  string
+	// endpoint is the custom Secrets Manager endpoint, empty for AWS
+	endpoint string
+
+	// regions lists the account's regions and regional returns Secrets
+	// Manager in another region. Either may be nil, see ListRegions.
+	regions  regionsAPI
+	regional func(region string) secretsAPI
+
+	// workloads reads ECS and Lambda, nil for a custom endpoint or clients
+	// created with NewClientWithAPI
+	workloads workloadsAPI
+	// iam reads IAM policies, nil like workloads
+	iam iamAPI
+
+	// defaultChain is set when no shared config profile was used, so
+	// credentials come from the environment, SSO or an instance role
+	defaultChain bool
 }
 
-// NewClient creates a new AWS client with the specified profile and region
-func NewClient(ctx context.Context, profile, region string) (*Client, error) {
+// NewClient creates a new AWS client with the specified profile and region.
+// A non-empty endpointURL replaces the Secrets Manager endpoint.
+func NewClient(ctx context.Context, profile, region, endpointURL string) (*Client, error) {
+	endpointOption, err := withEndpoint(endpointURL)
+	if err != nil {
+		return nil, err
+	}
+
 	// Load AWS configuration with profile and region
-	var opts []func(*config.LoadOptions) error
+	opts := []func(*config.LoadOptions) error{withCallLogging}
 
-	if profile != "" {
+	// The SDK fails when an explicitly named profile is missing. An
+	// unconfigured "default" is left to the default credential chain instead,
+	// so environment variables, SSO and instance roles still work without
+	// any ~/.aws files.
+	defaultChain := true
+	if profile != "" && (profile != "default" || ProfileExists(profile)) {
 		opts = append(opts, config.WithSharedConfigProfile(profile))
+		defaultChain = false
 	}
 
 	if region != "" {
@@ -33,27 +86,60 @@ func NewClient(ctx context.Context, profile, region string) (*Client, error) {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
+	logging.Debugf("loaded AWS config for profile %q, region %q, endpoint %q", profile, cfg.Region, endpointURL)
+
 	// Create Secrets Manager client
-	sm := secretsmanager.NewFromConfig(cfg)
+	sm := secretsmanager.NewFromConfig(cfg, endpointOption, withRetries)
 
 	return &Client{
-		sm:      sm,
-		profile: profile,
-		region:  cfg.Region,
+		sm:           sm,
+		sts:          sts.NewFromConfig(cfg, withSTSEndpoint(endpointURL)),
+		kms:          kms.NewFromConfig(cfg, withKMSEndpoint(endpointURL)),
+		regions:      newEC2Regions(cfg, endpointURL),
+		regional:     regionalSecrets(cfg, endpointOption),
+		workloads:    newWorkloads(cfg, endpointURL),
+		iam:          newIAM(cfg, endpointURL),
+		profile:      profile,
+		region:       cfg.Region,
+		endpoint:     endpointURL,
+		defaultChain: defaultChain,
 	}, nil
 }
 
+// NewClientWithAPI creates a client that calls api instead of an SDK client
+// built from the shared config, e.g. a fake in tests
+func NewClientWithAPI(api secretsAPI, profile, region string) *Client {
+	return &Client{
+		sm:      api,
+		profile: profile,
+		region:  region,
+	}
+}
+
 // GetProfile returns the current AWS profile
 func (c *Client) GetProfile() string {
 	return c.profile
 }
 
+// UsesDefaultChain reports whether the client was created without a shared
+// config profile because none is configured
+func (c *Client) UsesDefaultChain() bool {
+	return c.defaultChain
+}
+
 // GetRegion returns the current AWS region
 func (c *Client) GetRegion() string {
 	return c.region
 }
 
-// GetSecretsManagerClient returns the underlying Secrets Manager client
+// GetEndpoint returns the custom Secrets Manager endpoint, or "" for AWS
+func (c *Client) GetEndpoint() string {
+	return c.endpoint
+}
+
+// GetSecretsManagerClient returns the underlying Secrets Manager client, or
+// nil when the client was created with NewClientWithAPI around another API
 func (c *Client) GetSecretsManagerClient() *secretsmanager.Client {
-	return c.sm
+	sm, _ := c.sm.(*secretsmanager.Client)
+	return sm
 }
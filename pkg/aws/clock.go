@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// MaxClockSkew is the clock difference worth warning about. AWS rejects
+// requests signed more than 5 minutes off, and cached MFA sessions expire
+// early or late by the skew, so problems start well before requests fail.
+const MaxClockSkew = time.Minute
+
+// clockCheckTimeout bounds the request made to measure the clock skew
+const clockCheckTimeout = 5 * time.Second
+
+// clockCheckURL returns the STS endpoint whose Date header is used as the
+// reference time. Tests replace it with a local server.
+var clockCheckURL = func(region string) string {
+	if region == "" {
+		return "https://sts.amazonaws.com/"
+	}
+	return fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+}
+
+// clockSkewCodes are the error codes AWS returns for requests signed with a
+// timestamp too far from its own clock
+var clockSkewCodes = map[string]bool{
+	"RequestExpired":       true,
+	"RequestTimeTooSkewed": true,
+	"SignatureExpired":     true,
+}
+
+// MeasureClockSkew compares the local clock with the Date header of an
+// unauthenticated STS request, so it works even when credentials are being
+// rejected. A positive result means the local clock is ahead of AWS. The
+// Date header has one-second resolution.
+func MeasureClockSkew(ctx context.Context, region string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, clockCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, clockCheckURL(region), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create clock check request: %w", err)
+	}
+
+	sent := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach STS: %w", err)
+	}
+	resp.Body.Close()
+	received := time.Now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("STS response has no usable Date header: %w", err)
+	}
+
+	// Compare against the middle of the round trip to cancel out latency
+	local := sent.Add(received.Sub(sent) / 2)
+	return local.Sub(serverTime).Round(time.Second), nil
+}
+
+// ClockSkewMessage describes a clock skew for the user, or returns "" when
+// the skew is small enough to ignore
+func ClockSkewMessage(skew time.Duration) string {
+	if skew > -MaxClockSkew && skew < MaxClockSkew {
+		return ""
+	}
+
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+	seconds := int64(math.Abs(skew.Seconds()))
+	return fmt.Sprintf("Your system clock is off by %d seconds (%s AWS). Sync it with NTP; AWS rejects requests and MFA sessions misbehave when the clock is wrong.", seconds, direction)
+}
+
+// IsClockSkewError reports whether err is AWS rejecting a request because its
+// signature timestamp is too far from the server's clock
+func IsClockSkewError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if clockSkewCodes[apiErr.ErrorCode()] {
+		return true
+	}
+	// Some services report skew as a generic signature error
+	message := apiErr.ErrorMessage()
+	return strings.Contains(message, "Signature expired") ||
+		strings.Contains(message, "Signature not yet current")
+}
@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A server 10 minutes behind the local clock
+		w.Header().Set("Date", time.Now().Add(-10*time.Minute).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	original := clockCheckURL
+	clockCheckURL = func(string) string { return server.URL }
+	defer func() { clockCheckURL = original }()
+
+	skew, err := MeasureClockSkew(context.Background(), "eu-west-2")
+	if err != nil {
+		t.Fatalf("MeasureClockSkew returned error: %v", err)
+	}
+	if skew < 599*time.Second || skew > 601*time.Second {
+		t.Fatalf("expected a skew of about 10m, got %s", skew)
+	}
+
+	message := ClockSkewMessage(skew)
+	if !strings.Contains(message, "ahead of AWS") {
+		t.Fatalf("expected the message to say the clock is ahead, got %q", message)
+	}
+}
+
+func TestClockSkewMessageIgnoresSmallSkew(t *testing.T) {
+	if message := ClockSkewMessage(-30 * time.Second); message != "" {
+		t.Fatalf("expected no message for a small skew, got %q", message)
+	}
+	if message := ClockSkewMessage(-2 * time.Minute); !strings.Contains(message, "off by 120 seconds (behind AWS)") {
+		t.Fatalf("unexpected message %q", message)
+	}
+}
+
+func TestIsClockSkewError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&smithy.GenericAPIError{Code: "RequestExpired", Message: "Request has expired."}, true},
+		{&smithy.GenericAPIError{Code: "InvalidSignatureException", Message: "Signature expired: 20240101T000000Z is now earlier than ..."}, true},
+		{&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tc := range cases {
+		if got := IsClockSkewError(tc.err); got != tc.want {
+			t.Fatalf("IsClockSkewError(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
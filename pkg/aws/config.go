@@ -1,7 +1,6 @@
 package aws
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
@@ -27,21 +26,58 @@ func GetDefaultRegion() string {
 	return ""
 }
 
-// GetAvailableProfiles reads and returns all available AWS profiles from both ~/.aws/credentials and ~/.aws/config
-func GetAvailableProfiles() ([]string, error) {
+// sharedConfigPath returns the path of the shared config file, honouring
+// AWS_CONFIG_FILE like the AWS CLI and SDK do. It returns "" when there is
+// no override and no home directory, e.g. in minimal containers.
+func sharedConfigPath() string {
+	return sharedFilePath("AWS_CONFIG_FILE", "config")
+}
+
+// sharedCredentialsPath returns the path of the shared credentials file,
+// honouring AWS_SHARED_CREDENTIALS_FILE
+func sharedCredentialsPath() string {
+	return sharedFilePath("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+}
+
+func sharedFilePath(envVar, name string) string {
+	if path := os.Getenv(envVar); path != "" {
+		return path
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return ""
+	}
+	return filepath.Join(homeDir, ".aws", name)
+}
+
+// ProfileExists reports whether profile is defined in the shared config or
+// credentials file
+func ProfileExists(profile string) bool {
+	profiles, err := GetAvailableProfiles()
+	if err != nil {
+		return false
+	}
+	for _, name := range profiles {
+		if name == profile {
+			return true
+		}
 	}
+	return false
+}
 
-	credentialsPath := filepath.Join(homeDir, ".aws", "credentials")
-	configPath := filepath.Join(homeDir, ".aws", "config")
+// GetAvailableProfiles reads and returns all available AWS profiles from both
+// the shared credentials and config files. It returns an empty list when
+// neither file exists, which is normal for machines that only use
+// environment variables, SSO or instance roles.
+func GetAvailableProfiles() ([]string, error) {
+	credentialsPath := sharedCredentialsPath()
+	configPath := sharedConfigPath()
 
 	// Use a map to avoid duplicates
 	profileMap := make(map[string]bool)
 
 	// Read from credentials file
-	if _, err := os.Stat(credentialsPath); err == nil {
+	if _, err := os.Stat(credentialsPath); credentialsPath != "" && err == nil {
 		cfg, err := ini.Load(credentialsPath)
 		if err == nil {
 			for _, section := range cfg.Sections() {
@@ -54,7 +90,7 @@ func GetAvailableProfiles() ([]string, error) {
 	}
 
 	// Read from config file
-	if _, err := os.Stat(configPath); err == nil {
+	if _, err := os.Stat(configPath); configPath != "" && err == nil {
 		cfg, err := ini.Load(configPath)
 		if err == nil {
 			for _, section := range cfg.Sections() {
@@ -81,11 +117,6 @@ func GetAvailableProfiles() ([]string, error) {
 		profiles = append(profiles, profile)
 	}
 
-	// If no profiles found, add default
-	if len(profiles) == 0 {
-		profiles = append(profiles, "default")
-	}
-
 	// Sort profiles for consistent ordering
 	// Simple bubble sort since the list is small
 	for i := 0; i < len(profiles)-1; i++ {
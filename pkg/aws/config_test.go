@@ -1,6 +1,11 @@
 package aws
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestGetDefaultRegion(t *testing.T) {
 	t.Run("prefers AWS_REGION", func(t *testing.T) {
@@ -30,3 +35,101 @@ func TestGetDefaultRegion(t *testing.T) {
 		}
 	})
 }
+
+func TestMissingSharedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	profiles, err := GetAvailableProfiles()
+	if err != nil {
+		t.Fatalf("GetAvailableProfiles returned error: %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Fatalf("expected no profiles without config files, got %v", profiles)
+	}
+
+	mfaConfig, err := GetMFAConfig("default")
+	if err != nil {
+		t.Fatalf("GetMFAConfig returned error: %v", err)
+	}
+	if mfaConfig.Required {
+		t.Fatal("expected no MFA requirement without config files")
+	}
+
+	// The default profile falls back to the default credential chain
+	client, err := NewClient(context.Background(), "default", "eu-west-2", "")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if !client.UsesDefaultChain() {
+		t.Fatal("expected the client to use the default credential chain")
+	}
+
+	// A named profile that doesn't exist is still an error
+	if _, err := NewClient(context.Background(), "staging", "eu-west-2", ""); err == nil {
+		t.Fatal("expected an error for a missing named profile")
+	}
+}
+
+func TestGetAvailableProfilesHonoursConfigFileOverride(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	if err := os.WriteFile(configPath, []byte("[default]\nregion = eu-west-2\n\n[profile prod]\nregion = us-east-1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	profiles, err := GetAvailableProfiles()
+	if err != nil {
+		t.Fatalf("GetAvailableProfiles returned error: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0] != "default" || profiles[1] != "prod" {
+		t.Fatalf("expected [default prod], got %v", profiles)
+	}
+	if !ProfileExists("prod") || ProfileExists("staging") {
+		t.Fatal("ProfileExists does not match the config file")
+	}
+}
+
+func TestSharedFilesWrittenOnWindows(t *testing.T) {
+	// Notepad saves with CRLF line endings and, in older versions, a UTF-8
+	// byte order mark
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	config := "\ufeff[default]\r\nregion = eu-west-2\r\n\r\n[profile prod]\r\nregion = us-east-1\r\nrole_arn = arn:aws:iam::123456789012:role/deploy\r\nsource_profile = default\r\n"
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	credentialsPath := filepath.Join(dir, "credentials")
+	credentials := "[default]\r\ncredential_process = C:\\tools\\creds.exe --profile default\r\n"
+	if err := os.WriteFile(credentialsPath, []byte(credentials), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsPath)
+
+	profiles, err := GetAvailableProfiles()
+	if err != nil {
+		t.Fatalf("GetAvailableProfiles returned error: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0] != "default" || profiles[1] != "prod" {
+		t.Fatalf("expected [default prod], got %q", profiles)
+	}
+
+	prod, err := GetProfileConfig("prod")
+	if err != nil {
+		t.Fatalf("GetProfileConfig returned error: %v", err)
+	}
+	if prod.Region != "us-east-1" || prod.SourceProfile != "default" || prod.RoleARN != "arn:aws:iam::123456789012:role/deploy" {
+		t.Fatalf("expected the prod settings without carriage returns, got %+v", prod)
+	}
+	defaultProfile, err := GetProfileConfig("default")
+	if err != nil {
+		t.Fatalf("GetProfileConfig returned error: %v", err)
+	}
+	if defaultProfile.CredentialProcess != `C:\tools\creds.exe --profile default` {
+		t.Fatalf("expected the credential process without a carriage return, got %q", defaultProfile.CredentialProcess)
+	}
+}
@@ -0,0 +1,189 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// ErrSecretExists is returned by WriteSecretCopy when the secret already
+// exists and overwriting wasn't allowed
+var ErrSecretExists = errors.New("secret already exists")
+
+// SecretCopy is a secret's value with the metadata copied along with it.
+// The KMS key is not copied, since keys belong to one region and account:
+// copies are encrypted with the target's default key.
+type SecretCopy struct {
+	Name        string
+	Description string
+	Tags        map[string]string
+	Value       models.SecretValue
+}
+
+// WithRegion returns a client for the same profile and credentials in
+// another region
+func (c *Client) WithRegion(region string) *Client {
+	regional := *c
+	regional.region = region
+	if sm, ok := c.sm.(*secretsmanager.Client); ok {
+		regional.sm = secretsmanager.New(sm.Options(), func(o *secretsmanager.Options) {
+			o.Region = region
+		})
+	}
+	if workloads, ok := c.workloads.(workloads); ok {
+		regional.workloads = workloads.inRegion(region)
+	}
+	if client, ok := c.kms.(*kms.Client); ok {
+		regional.kms = kms.New(client.Options(), func(o *kms.Options) {
+			o.Region = region
+		})
+	}
+	return &regional
+}
+
+// ReadSecretCopy reads a secret's value, description and tags for copying
+func (c *Client) ReadSecretCopy(ctx context.Context, secretName string) (*SecretCopy, error) {
+	described, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		logging.Debugf("DescribeSecret %s failed: %v", secretName, err)
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
+	}
+
+	value, err := c.GetSecret(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	secretCopy := &SecretCopy{
+		Name:        stringValue(described.Name),
+		Description: stringValue(described.Description),
+		Value:       *value,
+	}
+	if secretCopy.Name == "" {
+		secretCopy.Name = secretName
+	}
+	if len(described.Tags) > 0 {
+		secretCopy.Tags = make(map[string]string, len(described.Tags))
+		for _, tag := range described.Tags {
+			secretCopy.Tags[stringValue(tag.Key)] = stringValue(tag.Value)
+		}
+	}
+	return secretCopy, nil
+}
+
+// SecretExists reports whether a secret with the given name exists,
+// including one scheduled for deletion
+func (c *Client) SecretExists(ctx context.Context, secretName string) (bool, error) {
+	exists, _, err := c.SecretOwner(ctx, secretName)
+	return exists, err
+}
+
+// SecretOwner reports whether a secret exists, as SecretExists does, and
+// the AWS service managing it, e.g. "rds", or "" for none
+func (c *Client) SecretOwner(ctx context.Context, secretName string) (bool, string, error) {
+	result, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(secretName),
+	})
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return false, "", nil
+	}
+	if err != nil {
+		logging.Debugf("DescribeSecret %s failed: %v", secretName, err)
+		return false, "", fmt.Errorf("failed to check for secret: %w", err)
+	}
+	return true, stringValue(result.OwningService), nil
+}
+
+// WriteSecretCopy creates the secret, or when overwrite is set and it
+// already exists, stores the value as its new current version and updates
+// its description and tags. Tags the existing secret has beyond those of the
+// copy are kept. It reports whether the secret was created.
+func (c *Client) WriteSecretCopy(ctx context.Context, secretCopy *SecretCopy, overwrite bool) (bool, error) {
+	exists, err := c.SecretExists(ctx, secretCopy.Name)
+	if err != nil {
+		return false, err
+	}
+	if exists && !overwrite {
+		return false, fmt.Errorf("%s in %s: %w", secretCopy.Name, c.region, ErrSecretExists)
+	}
+
+	if !exists {
+		input := &secretsmanager.CreateSecretInput{
+			Name: aws.String(secretCopy.Name),
+			Tags: sdkTags(secretCopy.Tags),
+		}
+		if secretCopy.Description != "" {
+			input.Description = aws.String(secretCopy.Description)
+		}
+		if secretCopy.Value.IsBinary() {
+			input.SecretBinary = secretCopy.Value.Binary
+		} else {
+			input.SecretString = aws.String(secretCopy.Value.String)
+		}
+		if _, err := c.writer(ctx).CreateSecret(ctx, input); err != nil {
+			logging.Debugf("CreateSecret %s failed: %v", secretCopy.Name, err)
+			return false, fmt.Errorf("failed to create secret: %w", err)
+		}
+		return true, nil
+	}
+
+	put := &secretsmanager.PutSecretValueInput{SecretId: aws.String(secretCopy.Name)}
+	if secretCopy.Value.IsBinary() {
+		put.SecretBinary = secretCopy.Value.Binary
+	} else {
+		put.SecretString = aws.String(secretCopy.Value.String)
+	}
+	if _, err := c.writer(ctx).PutSecretValue(ctx, put); err != nil {
+		logging.Debugf("PutSecretValue %s failed: %v", secretCopy.Name, err)
+		return false, fmt.Errorf("failed to update secret value: %w", err)
+	}
+
+	if secretCopy.Description != "" {
+		_, err := c.writer(ctx).UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{
+			SecretId:    aws.String(secretCopy.Name),
+			Description: aws.String(secretCopy.Description),
+		})
+		if err != nil {
+			logging.Debugf("UpdateSecret %s failed: %v", secretCopy.Name, err)
+			return false, fmt.Errorf("failed to update secret description: %w", err)
+		}
+	}
+
+	if len(secretCopy.Tags) > 0 {
+		_, err := c.writer(ctx).TagResource(ctx, &secretsmanager.TagResourceInput{
+			SecretId: aws.String(secretCopy.Name),
+			Tags:     sdkTags(secretCopy.Tags),
+		})
+		if err != nil {
+			logging.Debugf("TagResource %s failed: %v", secretCopy.Name, err)
+			return false, fmt.Errorf("failed to tag secret: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// sdkTags converts tags to the SDK's form, sorted by key
+func sdkTags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sdk := make([]types.Tag, 0, len(keys))
+	for _, key := range keys {
+		sdk = append(sdk, types.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return sdk
+}
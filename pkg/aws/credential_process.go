@@ -0,0 +1,85 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// credentialProcessTimeout limits how long a credential_process may run,
+// matching the SDK's default
+const credentialProcessTimeout = time.Minute
+
+// credentialProcessOutput is the JSON a credential_process prints
+type credentialProcessOutput struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      *time.Time
+}
+
+// RunCredentialProcess runs a profile's credential_process command and returns
+// the credentials it prints. Unlike the SDK's provider, the command's stderr
+// is captured for the error message instead of being written to the terminal
+// the UI is drawing on.
+func RunCredentialProcess(ctx context.Context, command string) (aws.Credentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialProcessTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return aws.Credentials{}, fmt.Errorf("credential_process timed out after %s", credentialProcessTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return aws.Credentials{}, fmt.Errorf("credential_process failed: %v: %s", err, message)
+		}
+		return aws.Credentials{}, fmt.Errorf("credential_process failed: %w", err)
+	}
+
+	// The output holds credentials, so it is never included in errors
+	var output credentialProcessOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return aws.Credentials{}, fmt.Errorf("credential_process printed invalid JSON")
+	}
+	if output.Version != 1 {
+		return aws.Credentials{}, fmt.Errorf("credential_process printed unsupported Version %d, expected 1", output.Version)
+	}
+	if output.AccessKeyID == "" || output.SecretAccessKey == "" {
+		return aws.Credentials{}, fmt.Errorf("credential_process output is missing AccessKeyId or SecretAccessKey")
+	}
+
+	logging.TrackSecret(output.SecretAccessKey)
+	logging.TrackSecret(output.SessionToken)
+
+	creds := aws.Credentials{
+		AccessKeyID:     output.AccessKeyID,
+		SecretAccessKey: output.SecretAccessKey,
+		SessionToken:    output.SessionToken,
+		Source:          "CredentialProcess",
+	}
+	if output.Expiration != nil {
+		creds.CanExpire = true
+		creds.Expires = *output.Expiration
+	}
+	return creds, nil
+}
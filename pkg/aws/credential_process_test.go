@@ -0,0 +1,30 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRunCredentialProcess(t *testing.T) {
+	creds, err := RunCredentialProcess(context.Background(),
+		`printf '{"Version": 1, "AccessKeyId": "AKIAEXAMPLE", "SecretAccessKey": "secret", "Expiration": "2030-01-01T00:00:00Z"}'`)
+	if err != nil {
+		t.Fatalf("RunCredentialProcess returned error: %v", err)
+	}
+	if creds.AccessKeyID != "AKIAEXAMPLE" || !creds.CanExpire || creds.Expires.Year() != 2030 {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+}
+
+func TestRunCredentialProcessErrors(t *testing.T) {
+	_, err := RunCredentialProcess(context.Background(), "echo 'token expired, run login' >&2; exit 1")
+	if err == nil || !strings.Contains(err.Error(), "token expired, run login") {
+		t.Fatalf("expected stderr in the error, got %v", err)
+	}
+
+	_, err = RunCredentialProcess(context.Background(), `printf 'not json secret'`)
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("expected an error without the output, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// DryRunChange is a write a dry run didn't make: the secret and what would
+// have happened to it, e.g. "would write a new value to prod/db: ~password
+// +region". Values are never included.
+type DryRunChange struct {
+	Secret string
+	Action string
+}
+
+// dryRunKey is the context key WithDryRun stores the recorder under
+type dryRunKey struct{}
+
+// WithDryRun returns a context whose calls to the client read as usual but
+// make no changes. Each write is passed to record instead of to AWS, and
+// reported as a success.
+func WithDryRun(ctx context.Context, record func(DryRunChange)) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, record)
+}
+
+// IsDryRun reports whether ctx is a dry run
+func IsDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunKey{}).(func(DryRunChange))
+	return ok
+}
+
+// writer returns the API writes are made through: Secrets Manager, or for a
+// dry run, an API that records them and changes nothing
+func (c *Client) writer(ctx context.Context) secretsAPI {
+	if record, ok := ctx.Value(dryRunKey{}).(func(DryRunChange)); ok {
+		return dryRunAPI{secretsAPI: c.sm, record: record}
+	}
+	return c.sm
+}
+
+// dryRunAPI passes reads through to Secrets Manager and records writes
+type dryRunAPI struct {
+	secretsAPI
+	record func(DryRunChange)
+}
+
+// change records a write to secret. The first verb of format is the
+// secret's name, and args fill the rest.
+func (d dryRunAPI) change(secret *string, format string, args ...any) {
+	name := aws.ToString(secret)
+	logging.Debugf("Dry run: skipped a write to %s", name)
+	d.record(DryRunChange{Secret: name, Action: fmt.Sprintf(format, append([]any{name}, args...)...)})
+}
+
+// The writes below record the change and return an empty success
+
+func (d dryRunAPI) TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	keys := make([]string, len(params.Tags))
+	for i, tag := range params.Tags {
+		keys[i] = aws.ToString(tag.Key)
+	}
+	d.change(params.SecretId, "would tag %s with %s", strings.Join(keys, ", "))
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (d dryRunAPI) UntagResource(ctx context.Context, params *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error) {
+	d.change(params.SecretId, "would remove tag %[2]s from %[1]s", strings.Join(params.TagKeys, ", "))
+	return &secretsmanager.UntagResourceOutput{}, nil
+}
+
+func (d dryRunAPI) DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	d.change(params.SecretId, "would schedule %s for deletion in %d days", aws.ToInt64(params.RecoveryWindowInDays))
+	return &secretsmanager.DeleteSecretOutput{}, nil
+}
+
+func (d dryRunAPI) RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error) {
+	d.change(params.SecretId, "would cancel the deletion of %s")
+	return &secretsmanager.RestoreSecretOutput{}, nil
+}
+
+func (d dryRunAPI) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	d.change(params.SecretId, "would make version %[2]s of %[1]s %[3]s", aws.ToString(params.MoveToVersionId), aws.ToString(params.VersionStage))
+	return &secretsmanager.UpdateSecretVersionStageOutput{}, nil
+}
+
+func (d dryRunAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	detail := ""
+	if keys := valueKeys(nil, params.SecretString, params.SecretBinary); len(keys) > 0 {
+		detail = ": " + strings.Join(keys, " ")
+	}
+	d.change(params.Name, "would create %s%s", detail)
+	return &secretsmanager.CreateSecretOutput{Name: params.Name}, nil
+}
+
+func (d dryRunAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	// The diff needs the current value; without it the write is still
+	// recorded, just without keys
+	var current *secretsmanager.GetSecretValueOutput
+	if result, err := d.secretsAPI.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: params.SecretId}); err == nil {
+		current = result
+	} else {
+		logging.Debugf("Dry run: failed to read %s to compare it: %v", aws.ToString(params.SecretId), err)
+	}
+	keys := valueKeys(current, params.SecretString, params.SecretBinary)
+	detail := ""
+	switch {
+	case current != nil && len(keys) == 0:
+		detail = ", the same as its current one"
+	case len(keys) > 0:
+		detail = ": " + strings.Join(keys, " ")
+	}
+	d.change(params.SecretId, "would write a new value to %s%s", detail)
+	return &secretsmanager.PutSecretValueOutput{Name: params.SecretId}, nil
+}
+
+func (d dryRunAPI) UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error) {
+	var changes []string
+	if params.Description != nil {
+		changes = append(changes, fmt.Sprintf("set its description to %q", *params.Description))
+	}
+	if params.KmsKeyId != nil {
+		changes = append(changes, "encrypt it with KMS key "+*params.KmsKeyId)
+	}
+	if params.SecretString != nil || params.SecretBinary != nil {
+		changes = append(changes, "write a new value")
+	}
+	d.change(params.SecretId, "would update %s: %s", strings.Join(changes, ", "))
+	return &secretsmanager.UpdateSecretOutput{Name: params.SecretId}, nil
+}
+
+func (d dryRunAPI) RotateSecret(ctx context.Context, params *secretsmanager.RotateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RotateSecretOutput, error) {
+	if rules := params.RotationRules; rules != nil && rules.AutomaticallyAfterDays != nil {
+		d.change(params.SecretId, "would turn on rotation of %s every %d days", *rules.AutomaticallyAfterDays)
+	} else {
+		d.change(params.SecretId, "would rotate %s")
+	}
+	return &secretsmanager.RotateSecretOutput{Name: params.SecretId}, nil
+}
+
+// valueKeys returns the keys a new value adds, changes or removes from the
+// current one, marked +, ~ or -; every key is added when there is no
+// current value. Binary values are compared whole.
+func valueKeys(current *secretsmanager.GetSecretValueOutput, text *string, binary []byte) []string {
+	if current == nil {
+		if binary != nil {
+			return []string{"+" + secretvalue.WholeValueKey}
+		}
+		var keys []string
+		for _, field := range secretvalue.CompareFields(aws.ToString(text)) {
+			keys = append(keys, "+"+field.Key)
+		}
+		return keys
+	}
+	if binary != nil || current.SecretBinary != nil {
+		if bytes.Equal(binary, current.SecretBinary) && aws.ToString(text) == aws.ToString(current.SecretString) {
+			return nil
+		}
+		return []string{"~" + secretvalue.WholeValueKey}
+	}
+	return secretvalue.MarkedKeys(secretvalue.DiffFields(secretvalue.CompareFields(aws.ToString(current.SecretString)), secretvalue.CompareFields(aws.ToString(text))))
+}
+
+// Check dryRunAPI still stands in for every write
+var _ secretsAPI = dryRunAPI{}
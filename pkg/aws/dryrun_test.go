@@ -0,0 +1,58 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestDryRunRecordsWritesWithoutMakingThem(t *testing.T) {
+	api := &fakeSecretsAPI{
+		values: map[string]*secretsmanager.GetSecretValueOutput{
+			"app/db": {SecretString: aws.String(`{"password":"hunter2","legacy":"x","host":"db"}`)},
+		},
+	}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+	var changes []string
+	ctx := WithDryRun(context.Background(), func(change DryRunChange) {
+		changes = append(changes, change.Action)
+	})
+	if !IsDryRun(ctx) || IsDryRun(context.Background()) {
+		t.Fatal("expected only the wrapped context to be a dry run")
+	}
+
+	if err := client.PutSecretValue(ctx, "app/db", models.SecretValue{String: `{"password":"hunter3","host":"db","region":"eu"}`}); err != nil {
+		t.Fatalf("PutSecretValue returned error: %v", err)
+	}
+	if err := client.TagSecret(ctx, "app/db", "team", "platform"); err != nil {
+		t.Fatalf("TagSecret returned error: %v", err)
+	}
+	if _, err := client.ScheduleDeletion(ctx, "app/db"); err != nil {
+		t.Fatalf("ScheduleDeletion returned error: %v", err)
+	}
+	if _, err := client.WriteSecretCopy(ctx, &SecretCopy{Name: "app/new", Value: models.SecretValue{String: `{"user":"app"}`}}, false); err != nil {
+		t.Fatalf("WriteSecretCopy returned error: %v", err)
+	}
+
+	if len(api.calls) != 0 {
+		t.Fatalf("expected no writes, got %q", api.calls)
+	}
+	want := []string{
+		"would write a new value to app/db: -legacy ~password +region",
+		"would tag app/db with team",
+		"would schedule app/db for deletion in 30 days",
+		"would create app/new: +user",
+	}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected changes %q, got %q", want, changes)
+	}
+	for _, change := range changes {
+		if strings.Contains(change, "hunter") {
+			t.Fatalf("expected no values in %q", change)
+		}
+	}
+}
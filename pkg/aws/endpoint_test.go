@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestEndpointURL(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	writeFile(t, configPath, `[profile local]
+region = us-east-1
+endpoint_url = http://localhost:4566
+`)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", "")
+
+	if got := EndpointURL("local", ""); got != "http://localhost:4566" {
+		t.Fatalf("expected the profile's endpoint_url, got %q", got)
+	}
+
+	t.Setenv("AWS_ENDPOINT_URL", "http://localstack:4566")
+	if got := EndpointURL("local", ""); got != "http://localstack:4566" {
+		t.Fatalf("expected AWS_ENDPOINT_URL to win over the profile, got %q", got)
+	}
+
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", "http://secrets:4566")
+	if got := EndpointURL("local", ""); got != "http://secrets:4566" {
+		t.Fatalf("expected the service-specific variable to win, got %q", got)
+	}
+
+	if got := EndpointURL("local", "http://flag:4566"); got != "http://flag:4566" {
+		t.Fatalf("expected the override to win, got %q", got)
+	}
+}
+
+func TestNewClientWithEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	client, err := NewClient(context.Background(), "", "us-east-1", "http://localhost:4566")
+	if err != nil {
+		t.Fatalf("NewClient returned error: %v", err)
+	}
+	if client.GetEndpoint() != "http://localhost:4566" {
+		t.Fatalf("expected the custom endpoint, got %q", client.GetEndpoint())
+	}
+
+	if _, err := NewClient(context.Background(), "", "us-east-1", "localhost:4566"); err == nil {
+		t.Fatal("expected an error for an endpoint without a scheme")
+	}
+}
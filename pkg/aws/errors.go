@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+)
+
+// ErrorKind is a common cause of a failed AWS call, for explaining it
+type ErrorKind int
+
+const (
+	// ErrorOther is any failure not recognised below
+	ErrorOther ErrorKind = iota
+	// ErrorExpiredToken is a session that has expired, e.g. an MFA or SSO session
+	ErrorExpiredToken
+	// ErrorAccessDenied is a call the credentials aren't allowed to make
+	ErrorAccessDenied
+	// ErrorNoCredentials is no credentials being found, or AWS not
+	// recognising the access key
+	ErrorNoCredentials
+	// ErrorWrongRegion is a missing or unknown region, whose endpoint
+	// can't be resolved
+	ErrorWrongRegion
+	// ErrorNotFound is a secret that doesn't exist in the region
+	ErrorNotFound
+	// ErrorClockSkew is a request rejected for the local clock being wrong
+	ErrorClockSkew
+	// ErrorNetwork is AWS not being reachable at all
+	ErrorNetwork
+)
+
+// expiredTokenCodes are the error codes for expired sessions
+var expiredTokenCodes = map[string]bool{
+	"ExpiredToken":          true,
+	"ExpiredTokenException": true,
+	"RequestExpired":        true,
+}
+
+// accessDeniedCodes are the error codes for calls that aren't allowed
+var accessDeniedCodes = map[string]bool{
+	"AccessDenied":          true,
+	"AccessDeniedException": true,
+	"UnauthorizedOperation": true,
+}
+
+// invalidCredentialsCodes are the error codes for access keys AWS doesn't
+// recognise
+var invalidCredentialsCodes = map[string]bool{
+	"UnrecognizedClientException": true,
+	"InvalidClientTokenId":        true,
+	"InvalidAccessKeyId":          true,
+}
+
+// ClassifyError works out the common cause of an AWS failure, or
+// ErrorOther when it isn't one of them
+func ClassifyError(err error) ErrorKind {
+	if err == nil {
+		return ErrorOther
+	}
+	if IsClockSkewError(err) {
+		return ErrorClockSkew
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.ErrorCode()
+		var notFound *smtypes.ResourceNotFoundException
+		switch {
+		case expiredTokenCodes[code]:
+			return ErrorExpiredToken
+		case accessDeniedCodes[code]:
+			return ErrorAccessDenied
+		case invalidCredentialsCodes[code]:
+			return ErrorNoCredentials
+		case errors.As(err, &notFound):
+			return ErrorNotFound
+		case strings.Contains(apiErr.ErrorMessage(), "scoped to a valid region"):
+			return ErrorWrongRegion
+		}
+		return ErrorOther
+	}
+
+	var missingRegion *aws.MissingRegionError
+	var endpointNotFound *aws.EndpointNotFoundError
+	if errors.As(err, &missingRegion) || errors.As(err, &endpointNotFound) {
+		return ErrorWrongRegion
+	}
+	// Sessions found to have expired before AWS was called, by the SDK's
+	// SSO provider or the auth pipeline
+	if strings.Contains(err.Error(), "expired") {
+		return ErrorExpiredToken
+	}
+	var signingErr *v4.SigningError
+	if errors.As(err, &signingErr) || strings.Contains(err.Error(), "failed to retrieve credentials") {
+		return ErrorNoCredentials
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		// An unknown region has no endpoint to look up
+		if dnsErr.IsNotFound {
+			return ErrorWrongRegion
+		}
+		return ErrorNetwork
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return ErrorNetwork
+	}
+	return ErrorOther
+}
+
+// IsExpiredSession reports whether err is AWS rejecting temporary
+// credentials that have expired, which signing in again fixes. STS reports
+// an expired session token as InvalidClientTokenId.
+func IsExpiredSession(err error) bool {
+	if ClassifyError(err) == ErrorExpiredToken {
+		return true
+	}
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidClientTokenId"
+}
+
+// ErrorRequestID returns the request ID AWS gave a failed call, to quote
+// to AWS support, or "" when the call never got a response
+func ErrorRequestID(err error) string {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.ServiceRequestID()
+	}
+	return ""
+}
+
+// ErrorOperation returns the AWS operation that failed, such as
+// GetSecretValue, or "" when err isn't from an AWS call
+func ErrorOperation(err error) string {
+	var opErr *smithy.OperationError
+	if errors.As(err, &opErr) {
+		return opErr.Operation()
+	}
+	return ""
+}
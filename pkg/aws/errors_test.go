@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"expired token", &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "The security token included in the request is expired"}, ErrorExpiredToken},
+		{"access denied", &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}, ErrorAccessDenied},
+		{"unknown access key", &smithy.GenericAPIError{Code: "UnrecognizedClientException", Message: "The security token included in the request is invalid."}, ErrorNoCredentials},
+		{"missing secret", &smtypes.ResourceNotFoundException{Message: aws.String("Secrets Manager can't find the specified secret.")}, ErrorNotFound},
+		{"clock skew", &smithy.GenericAPIError{Code: "InvalidSignatureException", Message: "Signature expired: 20240101T000000Z"}, ErrorClockSkew},
+		{"missing region", &aws.MissingRegionError{}, ErrorWrongRegion},
+		{"unknown region", &net.DNSError{Name: "secretsmanager.eu-nowhere-1.amazonaws.com", IsNotFound: true}, ErrorWrongRegion},
+		{"no network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorNetwork},
+		{"no credentials", fmt.Errorf("failed to retrieve credentials: %w", errors.New("no profile")), ErrorNoCredentials},
+		{"sso session", errors.New("the SSO session has expired or is invalid"), ErrorExpiredToken},
+		{"other", context.DeadlineExceeded, ErrorOther},
+		{"nil", nil, ErrorOther},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.err
+			if err != nil {
+				err = &smithy.OperationError{ServiceID: "Secrets Manager", OperationName: "GetSecretValue", Err: err}
+			}
+			if got := ClassifyError(err); got != tc.want {
+				t.Fatalf("ClassifyError(%v) = %v, want %v", err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestErrorRequestIDAndOperation(t *testing.T) {
+	err := &smithy.OperationError{
+		ServiceID:     "Secrets Manager",
+		OperationName: "GetSecretValue",
+		Err: &awshttp.ResponseError{
+			ResponseError: &smithyhttp.ResponseError{
+				Response: &smithyhttp.Response{Response: &http.Response{StatusCode: 400}},
+				Err:      &smithy.GenericAPIError{Code: "AccessDeniedException"},
+			},
+			RequestID: "4f2c1a9e-request",
+		},
+	}
+	if got := ErrorRequestID(err); got != "4f2c1a9e-request" {
+		t.Fatalf("expected the request ID, got %q", got)
+	}
+	if got := ErrorOperation(err); got != "GetSecretValue" {
+		t.Fatalf("expected the operation, got %q", got)
+	}
+	if got := ErrorRequestID(errors.New("dial tcp: timeout")); got != "" {
+		t.Fatalf("expected no request ID without a response, got %q", got)
+	}
+}
+
+func TestIsExpiredSession(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&smithy.GenericAPIError{Code: "ExpiredTokenException"}, true},
+		{&smithy.GenericAPIError{Code: "InvalidClientTokenId"}, true},
+		{&smithy.GenericAPIError{Code: "AccessDeniedException"}, false},
+		{&smithy.GenericAPIError{Code: "RequestExpired", Message: "Request has expired."}, false},
+	}
+	for _, tc := range cases {
+		if got := IsExpiredSession(tc.err); got != tc.want {
+			t.Fatalf("IsExpiredSession(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
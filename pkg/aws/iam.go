@@ -0,0 +1,314 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// PolicyGrant is an IAM user, group or role whose policy allows Secrets
+// Manager actions on a secret
+type PolicyGrant struct {
+	Principal string   // Such as "role/app-api", "user/alice" or "group/devs"
+	Policy    string   // The inline or managed policy's name
+	Managed   bool     // Attached managed policy, rather than an inline one
+	Actions   []string // The actions allowed, as written in the policy
+	Resource  string   // The resource pattern matching the secret
+}
+
+// Broad reports whether the grant covers every resource, rather than
+// naming the secret or a pattern of secret names
+func (g PolicyGrant) Broad() bool {
+	return g.Resource == "*"
+}
+
+// iamAPI is the part of the IAM SDK client that Client uses
+type iamAPI interface {
+	GetAccountAuthorizationDetails(ctx context.Context, params *iam.GetAccountAuthorizationDetailsInput, optFns ...func(*iam.Options)) (*iam.GetAccountAuthorizationDetailsOutput, error)
+	GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error)
+	GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error)
+}
+
+// newIAM creates an IAM client from cfg, or returns nil for a custom
+// endpoint, which only stands in for Secrets Manager
+func newIAM(cfg aws.Config, endpointURL string) iamAPI {
+	if endpointURL != "" || cfg.Credentials == nil {
+		return nil
+	}
+	return iam.NewFromConfig(withRetryer(cfg))
+}
+
+// inlinePolicy is a policy embedded in a user, group or role. Documents are
+// URL encoded JSON.
+type inlinePolicy struct {
+	Name     string
+	Document string
+}
+
+// attachedPolicy is a managed policy attached to a user, group or role
+type attachedPolicy struct {
+	Name string
+	ARN  string
+}
+
+// principalDetails is a user, group or role and its policies, named like
+// "role/app-api"
+type principalDetails struct {
+	Name     string
+	Inline   []inlinePolicy
+	Attached []attachedPolicy
+}
+
+// authorizationDetails is the part of GetAccountAuthorizationDetails used,
+// gathered from every page. Documents holds the default version of each
+// customer managed policy, by ARN.
+type authorizationDetails struct {
+	Principals []principalDetails
+	Documents  map[string]string
+}
+
+// newPrincipal converts a user, group or role's policies
+func newPrincipal(name string, inline []iamtypes.PolicyDetail, attached []iamtypes.AttachedPolicy) principalDetails {
+	details := principalDetails{Name: name}
+	for _, policy := range inline {
+		details.Inline = append(details.Inline, inlinePolicy{Name: stringValue(policy.PolicyName), Document: stringValue(policy.PolicyDocument)})
+	}
+	for _, policy := range attached {
+		details.Attached = append(details.Attached, attachedPolicy{Name: stringValue(policy.PolicyName), ARN: stringValue(policy.PolicyArn)})
+	}
+	return details
+}
+
+// authorizationDetails returns every user, group and role with its
+// policies, and every customer managed policy
+func (c *Client) authorizationDetails(ctx context.Context) (*authorizationDetails, error) {
+	details := &authorizationDetails{Documents: make(map[string]string)}
+	paginator := iam.NewGetAccountAuthorizationDetailsPaginator(c.iam, &iam.GetAccountAuthorizationDetailsInput{
+		Filter: []iamtypes.EntityType{iamtypes.EntityTypeUser, iamtypes.EntityTypeGroup, iamtypes.EntityTypeRole, iamtypes.EntityTypeLocalManagedPolicy},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range page.UserDetailList {
+			details.Principals = append(details.Principals, newPrincipal("user/"+stringValue(user.UserName), user.UserPolicyList, user.AttachedManagedPolicies))
+		}
+		for _, group := range page.GroupDetailList {
+			details.Principals = append(details.Principals, newPrincipal("group/"+stringValue(group.GroupName), group.GroupPolicyList, group.AttachedManagedPolicies))
+		}
+		for _, role := range page.RoleDetailList {
+			details.Principals = append(details.Principals, newPrincipal("role/"+stringValue(role.RoleName), role.RolePolicyList, role.AttachedManagedPolicies))
+		}
+		for _, policy := range page.Policies {
+			for _, version := range policy.PolicyVersionList {
+				if version.IsDefaultVersion {
+					details.Documents[stringValue(policy.Arn)] = stringValue(version.Document)
+				}
+			}
+		}
+	}
+	return details, nil
+}
+
+// policyDocument returns the default version of a managed policy, used for
+// AWS managed policies, which the authorization details leave out
+func (c *Client) policyDocument(ctx context.Context, policyARN string) (string, error) {
+	policy, err := c.iam.GetPolicy(ctx, &iam.GetPolicyInput{PolicyArn: &policyARN})
+	if err != nil {
+		return "", err
+	}
+	if policy.Policy == nil {
+		return "", fmt.Errorf("GetPolicy returned no policy")
+	}
+	version, err := c.iam.GetPolicyVersion(ctx, &iam.GetPolicyVersionInput{PolicyArn: &policyARN, VersionId: policy.Policy.DefaultVersionId})
+	if err != nil {
+		return "", err
+	}
+	if version.PolicyVersion == nil {
+		return "", fmt.Errorf("GetPolicyVersion returned no version")
+	}
+	return stringValue(version.PolicyVersion.Document), nil
+}
+
+// FindPolicyGrants lists the IAM users, groups and roles of the account
+// whose inline or attached policies allow Secrets Manager actions on the
+// secret. Deny statements and those using NotAction or NotResource aren't
+// evaluated, so this shows who is granted access rather than the outcome
+// of every policy.
+func (c *Client) FindPolicyGrants(ctx context.Context, secret *models.SecretDetails) ([]PolicyGrant, error) {
+	if c.iam == nil {
+		return nil, errors.New("IAM can't be read through a custom endpoint")
+	}
+	details, err := c.authorizationDetails(ctx)
+	if err != nil {
+		logging.Debugf("GetAccountAuthorizationDetails failed: %v", err)
+		return nil, fmt.Errorf("failed to read IAM policies: %w", err)
+	}
+	if err := c.readAttachedPolicies(ctx, details); err != nil {
+		return nil, err
+	}
+
+	var grants []PolicyGrant
+	for _, principal := range details.Principals {
+		for _, policy := range principal.Inline {
+			if actions, resource, ok := grantsAccess(policy.Document, secret.ARN); ok {
+				grants = append(grants, PolicyGrant{Principal: principal.Name, Policy: policy.Name, Actions: actions, Resource: resource})
+			}
+		}
+		for _, attached := range principal.Attached {
+			if actions, resource, ok := grantsAccess(details.Documents[attached.ARN], secret.ARN); ok {
+				grants = append(grants, PolicyGrant{Principal: principal.Name, Policy: attached.Name, Managed: true, Actions: actions, Resource: resource})
+			}
+		}
+	}
+
+	// Policies naming the secret come before those granting every resource
+	sort.SliceStable(grants, func(i, j int) bool {
+		if grants[i].Broad() != grants[j].Broad() {
+			return !grants[i].Broad()
+		}
+		return grants[i].Principal < grants[j].Principal
+	})
+	return grants, nil
+}
+
+// readAttachedPolicies adds the documents of the AWS managed policies
+// attached to any principal, which the authorization details don't include
+func (c *Client) readAttachedPolicies(ctx context.Context, details *authorizationDetails) error {
+	var missing []string
+	seen := make(map[string]bool)
+	for _, principal := range details.Principals {
+		for _, attached := range principal.Attached {
+			if _, ok := details.Documents[attached.ARN]; !ok && !seen[attached.ARN] {
+				seen[attached.ARN] = true
+				missing = append(missing, attached.ARN)
+			}
+		}
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, policyConcurrency)
+	for _, arn := range missing {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			document, err := c.policyDocument(ctx, arn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logging.Debugf("reading managed policy %s failed: %v", arn, err)
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to read %s: %w", arn, err)
+				}
+				return
+			}
+			details.Documents[arn] = document
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// identityStatement is the part of an identity policy statement read.
+// Action and Resource may each be a string or a list.
+type identityStatement struct {
+	Effect   string          `json:"Effect"`
+	Action   json.RawMessage `json:"Action"`
+	Resource json.RawMessage `json:"Resource"`
+}
+
+// grantsAccess reports whether a URL encoded policy document allows Secrets
+// Manager actions on the secret with the given ARN, returning the actions
+// and the resource pattern that matched. A pattern naming the secret is
+// preferred to "*".
+func grantsAccess(encoded, arn string) ([]string, string, bool) {
+	if encoded == "" {
+		return nil, "", false
+	}
+	document, err := url.QueryUnescape(encoded)
+	if err != nil {
+		document = encoded
+	}
+	var policy struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil, "", false
+	}
+	var statements []identityStatement
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var statement identityStatement
+		if err := json.Unmarshal(policy.Statement, &statement); err != nil {
+			return nil, "", false
+		}
+		statements = []identityStatement{statement}
+	}
+
+	var actions []string
+	matched := ""
+	for _, statement := range statements {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		var allowed []string
+		for _, action := range stringOrList(statement.Action) {
+			lower := strings.ToLower(action)
+			if lower == "*" || strings.HasPrefix(lower, "secretsmanager:") {
+				allowed = append(allowed, action)
+			}
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+		for _, resource := range stringOrList(statement.Resource) {
+			if !wildcardMatch(resource, arn) {
+				continue
+			}
+			if matched == "" || matched == "*" {
+				matched = resource
+			}
+			actions = append(actions, allowed...)
+			break
+		}
+	}
+	return actions, matched, matched != ""
+}
+
+// stringOrList reads a policy element written as a string or a list
+func stringOrList(raw json.RawMessage) []string {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return []string{one}
+	}
+	var list []string
+	json.Unmarshal(raw, &list)
+	return list
+}
+
+// wildcardMatch matches an IAM resource pattern, where * and ? match any
+// characters including "/", against an ARN
+func wildcardMatch(pattern, arn string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, _ := regexp.MatchString("^"+expr+"$", arn)
+	return matched
+}
@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// policy URL encodes a policy document as IAM returns it
+func policy(document string) string {
+	return url.QueryEscape(document)
+}
+
+func TestFindPolicyGrantsReadsInlineAndManagedPolicies(t *testing.T) {
+	readDB := policy(`{"Statement":[{"Effect":"Allow","Action":["secretsmanager:GetSecretValue"],"Resource":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/*"}]}`)
+	readOther := policy(`{"Statement":{"Effect":"Allow","Action":"secretsmanager:GetSecretValue","Resource":"arn:aws:secretsmanager:*:*:secret:staging/*"}}`)
+	admin := policy(`{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`)
+	s3 := policy(`{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "/iam/aws4_request") {
+			t.Errorf("expected a request signed for IAM, got %q", r.Header.Get("Authorization"))
+		}
+		r.ParseForm()
+		switch r.Form.Get("Action") {
+		case "GetAccountAuthorizationDetails":
+			if r.Form.Get("Marker") == "" {
+				w.Write([]byte(`<GetAccountAuthorizationDetailsResponse><GetAccountAuthorizationDetailsResult>
+					<IsTruncated>true</IsTruncated><Marker>page2</Marker>
+					<UserDetailList><member><UserName>alice</UserName>
+						<UserPolicyList><member><PolicyName>s3</PolicyName><PolicyDocument>` + s3 + `</PolicyDocument></member></UserPolicyList>
+						<AttachedManagedPolicies><member><PolicyName>AdministratorAccess</PolicyName><PolicyArn>arn:aws:iam::aws:policy/AdministratorAccess</PolicyArn></member></AttachedManagedPolicies>
+					</member></UserDetailList>
+				</GetAccountAuthorizationDetailsResult></GetAccountAuthorizationDetailsResponse>`))
+				return
+			}
+			w.Write([]byte(`<GetAccountAuthorizationDetailsResponse><GetAccountAuthorizationDetailsResult>
+				<IsTruncated>false</IsTruncated>
+				<RoleDetailList>
+					<member><RoleName>app-api</RoleName><AttachedManagedPolicies><member><PolicyName>read-prod</PolicyName><PolicyArn>arn:aws:iam::123456789012:policy/read-prod</PolicyArn></member></AttachedManagedPolicies></member>
+					<member><RoleName>staging-api</RoleName><RolePolicyList><member><PolicyName>read</PolicyName><PolicyDocument>` + readOther + `</PolicyDocument></member></RolePolicyList></member>
+				</RoleDetailList>
+				<Policies><member><Arn>arn:aws:iam::123456789012:policy/read-prod</Arn><PolicyVersionList>
+					<member><Document>` + s3 + `</Document><IsDefaultVersion>false</IsDefaultVersion></member>
+					<member><Document>` + readDB + `</Document><IsDefaultVersion>true</IsDefaultVersion></member>
+				</PolicyVersionList></member></Policies>
+			</GetAccountAuthorizationDetailsResult></GetAccountAuthorizationDetailsResponse>`))
+		case "GetPolicy":
+			w.Write([]byte(`<GetPolicyResponse><GetPolicyResult><Policy><DefaultVersionId>v7</DefaultVersionId></Policy></GetPolicyResult></GetPolicyResponse>`))
+		case "GetPolicyVersion":
+			if r.Form.Get("VersionId") != "v7" {
+				t.Errorf("expected the default version to be read, got %s", r.Form.Get("VersionId"))
+			}
+			w.Write([]byte(`<GetPolicyVersionResponse><GetPolicyVersionResult><PolicyVersion><Document>` + admin + `</Document></PolicyVersion></GetPolicyVersionResult></GetPolicyVersionResponse>`))
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<ErrorResponse><Error><Code>AccessDenied</Code><Message>no</Message></Error></ErrorResponse>`))
+		}
+	}))
+	defer server.Close()
+	cfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""), BaseEndpoint: aws.String(server.URL)}
+	client := &Client{iam: newIAM(cfg, "")}
+	grants, err := client.FindPolicyGrants(context.Background(), &models.SecretDetails{Name: "prod/db", ARN: dbARN})
+	if err != nil {
+		t.Fatalf("FindPolicyGrants returned error: %v", err)
+	}
+	if len(grants) != 2 {
+		t.Fatalf("expected the role's policy and the admin's, got %+v", grants)
+	}
+	if grants[0].Principal != "role/app-api" || grants[0].Policy != "read-prod" || !grants[0].Managed || grants[0].Broad() {
+		t.Fatalf("expected the role naming prod/* first, got %+v", grants[0])
+	}
+	if grants[1].Principal != "user/alice" || !grants[1].Broad() || grants[1].Actions[0] != "*" {
+		t.Fatalf("expected alice's AdministratorAccess, got %+v", grants[1])
+	}
+}
+
+func TestFindPolicyGrantsReportsIAMErrorsLikeOtherCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized to perform iam:GetAccountAuthorizationDetails</Message></Error><RequestId>req-1</RequestId></ErrorResponse>`))
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""), BaseEndpoint: aws.String(server.URL)}
+	client := &Client{iam: newIAM(cfg, "")}
+	_, err := client.FindPolicyGrants(context.Background(), &models.SecretDetails{Name: "prod/db", ARN: dbARN})
+	if ClassifyError(err) != ErrorAccessDenied {
+		t.Fatalf("expected access denied, got %v", err)
+	}
+	if ErrorOperation(err) != "GetAccountAuthorizationDetails" || ErrorRequestID(err) != "req-1" {
+		t.Fatalf("expected the operation and request ID, got %q and %q", ErrorOperation(err), ErrorRequestID(err))
+	}
+}
+
+func TestGrantsAccessIgnoresDenyAndOtherServices(t *testing.T) {
+	tests := []struct {
+		document string
+		want     bool
+	}{
+		{`{"Statement":[{"Effect":"Deny","Action":"secretsmanager:*","Resource":"*"}]}`, false},
+		{`{"Statement":[{"Effect":"Allow","Action":"kms:Decrypt","Resource":"*"}]}`, false},
+		{`{"Statement":[{"Effect":"Allow","Action":"SecretsManager:Get*","Resource":["arn:aws:s3:::bucket","` + dbARN + `"]}]}`, true},
+		{`{"Statement":[{"Effect":"Allow","Action":"secretsmanager:*","Resource":"arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-??????"}]}`, true},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		if _, _, ok := grantsAccess(policy(tt.document), dbARN); ok != tt.want {
+			t.Errorf("grantsAccess(%s) = %v, want %v", tt.document, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// stsAPI is the part of the STS SDK client that Client uses
+type stsAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// errNoSTS is returned when the client has no STS client to ask
+var errNoSTS = errors.New("no STS client")
+
+// CallerIdentity returns the account and identity the client's credentials
+// belong to. It needs no permissions beyond valid credentials.
+func (c *Client) CallerIdentity(ctx context.Context) (*models.CallerIdentity, error) {
+	if c.sts == nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", errNoSTS)
+	}
+	result, err := c.sts.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		logging.Debugf("GetCallerIdentity failed: %v", err)
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+	return &models.CallerIdentity{
+		Account: stringValue(result.Account),
+		ARN:     stringValue(result.Arn),
+		UserID:  stringValue(result.UserId),
+	}, nil
+}
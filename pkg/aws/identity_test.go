@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// fakeSTS answers GetCallerIdentity with a canned identity
+type fakeSTS struct {
+	output *sts.GetCallerIdentityOutput
+	err    error
+}
+
+func (f *fakeSTS) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	return f.output, f.err
+}
+
+func TestCallerIdentity(t *testing.T) {
+	client := NewClientWithAPI(&fakeSecretsAPI{}, "dev", "eu-west-1")
+	if _, err := client.CallerIdentity(context.Background()); !errors.Is(err, errNoSTS) {
+		t.Fatalf("expected errNoSTS without an STS client, got %v", err)
+	}
+
+	client.sts = &fakeSTS{output: &sts.GetCallerIdentityOutput{
+		Account: aws.String("123456789012"),
+		Arn:     aws.String("arn:aws:sts::123456789012:assumed-role/dev/alice"),
+		UserId:  aws.String("AROAEXAMPLE:alice"),
+	}}
+	identity, err := client.CallerIdentity(context.Background())
+	if err != nil {
+		t.Fatalf("CallerIdentity returned error: %v", err)
+	}
+	want := models.CallerIdentity{
+		Account: "123456789012",
+		ARN:     "arn:aws:sts::123456789012:assumed-role/dev/alice",
+		UserID:  "AROAEXAMPLE:alice",
+	}
+	if *identity != want {
+		t.Fatalf("unexpected identity %+v", *identity)
+	}
+
+	denied := errors.New("expired token")
+	client.sts = &fakeSTS{err: denied}
+	if _, err := client.CallerIdentity(context.Background()); !errors.Is(err, denied) {
+		t.Fatalf("expected the STS error to be wrapped, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// defaultKeyAlias is the alias of the AWS managed key Secrets Manager uses
+// when no key is chosen
+const defaultKeyAlias = "alias/" + models.DefaultKMSKey
+
+// kmsAPI is the part of the KMS SDK client that Client uses
+type kmsAPI interface {
+	ListAliases(ctx context.Context, params *kms.ListAliasesInput, optFns ...func(*kms.Options)) (*kms.ListAliasesOutput, error)
+}
+
+// withKMSEndpoint points a KMS client at the same custom endpoint as
+// Secrets Manager, which LocalStack serves both from. endpointURL must
+// already have been checked by withEndpoint.
+func withKMSEndpoint(endpointURL string) func(*kms.Options) {
+	return func(o *kms.Options) {
+		if endpointURL != "" {
+			o.BaseEndpoint = &endpointURL
+		}
+	}
+}
+
+// ListKMSKeys returns the keys a secret can be encrypted with in the
+// client's region: the default aws/secretsmanager key, then the customer
+// managed keys by alias. Keys without an alias, and the other AWS managed
+// keys, are left out.
+func (c *Client) ListKMSKeys(ctx context.Context) ([]models.KMSKey, error) {
+	if c.kms == nil {
+		return nil, fmt.Errorf("failed to list KMS keys: KMS isn't available for this client")
+	}
+
+	keys := []models.KMSKey{{Alias: defaultKeyAlias}}
+	var customer []models.KMSKey
+	paginator := kms.NewListAliasesPaginator(c.kms, &kms.ListAliasesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logging.Debugf("ListAliases failed: %v", err)
+			return nil, fmt.Errorf("failed to list KMS keys: %w", err)
+		}
+		for _, alias := range page.Aliases {
+			name := stringValue(alias.AliasName)
+			switch {
+			case name == defaultKeyAlias:
+				keys[0].KeyID = stringValue(alias.TargetKeyId)
+			case alias.TargetKeyId == nil || strings.HasPrefix(name, "alias/aws/"):
+				continue
+			default:
+				customer = append(customer, models.KMSKey{Alias: name, KeyID: *alias.TargetKeyId})
+			}
+		}
+	}
+
+	sort.Slice(customer, func(i, j int) bool {
+		return customer[i].Alias < customer[j].Alias
+	})
+	return append(keys, customer...), nil
+}
+
+// ChangeKMSKey encrypts a secret with another KMS key, given by ID, ARN or
+// alias. The caller needs kms:Decrypt on the old key and kms:GenerateDataKey
+// and kms:Decrypt on the new one.
+func (c *Client) ChangeKMSKey(ctx context.Context, secretName, kmsKeyID string) error {
+	_, err := c.writer(ctx).UpdateSecret(ctx, &secretsmanager.UpdateSecretInput{
+		SecretId: aws.String(secretName),
+		KmsKeyId: aws.String(kmsKeyID),
+	})
+	if err != nil {
+		logging.Debugf("UpdateSecret %s failed: %v", secretName, err)
+		return fmt.Errorf("failed to change KMS key: %w", err)
+	}
+	return nil
+}
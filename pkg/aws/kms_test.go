@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// fakeKMSAPI serves pages of aliases in place of KMS
+type fakeKMSAPI struct {
+	pages [][]kmstypes.AliasListEntry
+}
+
+func (f *fakeKMSAPI) ListAliases(ctx context.Context, params *kms.ListAliasesInput, optFns ...func(*kms.Options)) (*kms.ListAliasesOutput, error) {
+	page := 0
+	if params.Marker != nil {
+		page = int((*params.Marker)[0] - '0')
+	}
+	output := &kms.ListAliasesOutput{Aliases: f.pages[page]}
+	if page+1 < len(f.pages) {
+		output.Truncated = true
+		output.NextMarker = aws.String(string(rune('0' + page + 1)))
+	}
+	return output, nil
+}
+
+func TestListKMSKeysOffersDefaultAndCustomerKeys(t *testing.T) {
+	client := NewClientWithAPI(&fakeSecretsAPI{}, "default", "eu-west-2")
+	client.kms = &fakeKMSAPI{pages: [][]kmstypes.AliasListEntry{
+		{
+			{AliasName: aws.String("alias/payments"), TargetKeyId: aws.String("key-2")},
+			{AliasName: aws.String("alias/aws/rds"), TargetKeyId: aws.String("key-rds")},
+		},
+		{
+			{AliasName: aws.String("alias/aws/secretsmanager"), TargetKeyId: aws.String("key-default")},
+			{AliasName: aws.String("alias/unused")},
+			{AliasName: aws.String("alias/app"), TargetKeyId: aws.String("key-1")},
+		},
+	}}
+
+	keys, err := client.ListKMSKeys(context.Background())
+	if err != nil {
+		t.Fatalf("ListKMSKeys returned error: %v", err)
+	}
+	var aliases []string
+	for _, key := range keys {
+		aliases = append(aliases, key.Alias)
+	}
+	if len(keys) != 3 || aliases[0] != "alias/aws/secretsmanager" || aliases[1] != "alias/app" || aliases[2] != "alias/payments" {
+		t.Fatalf("expected the default key, then customer keys by alias, got %q", aliases)
+	}
+	if keys[0].KeyID != "key-default" || keys[1].KeyID != "key-1" {
+		t.Fatalf("expected the key IDs the aliases point to, got %+v", keys)
+	}
+
+	if _, err := NewClientWithAPI(&fakeSecretsAPI{}, "default", "eu-west-2").ListKMSKeys(context.Background()); err == nil {
+		t.Fatal("expected an error without a KMS client")
+	}
+}
+
+func TestChangeKMSKeyUpdatesSecret(t *testing.T) {
+	api := &fakeSecretsAPI{}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+
+	if err := client.ChangeKMSKey(context.Background(), "app/db", "alias/payments"); err != nil {
+		t.Fatalf("ChangeKMSKey returned error: %v", err)
+	}
+	if len(api.calls) != 1 || api.calls[0] != "UpdateSecret app/db kms=alias/payments" {
+		t.Fatalf("expected one UpdateSecret with the new key, got %q", api.calls)
+	}
+}
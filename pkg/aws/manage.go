@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// RecoveryWindowDays is how long a secret scheduled for deletion can still
+// be restored. It is the Secrets Manager default and maximum.
+const RecoveryWindowDays = 30
+
+// TagSecret adds a tag to a secret, replacing the value of an existing tag
+// with the same key
+func (c *Client) TagSecret(ctx context.Context, secretName, key, value string) error {
+	_, err := c.writer(ctx).TagResource(ctx, &secretsmanager.TagResourceInput{
+		SecretId: aws.String(secretName),
+		Tags:     []types.Tag{{Key: aws.String(key), Value: aws.String(value)}},
+	})
+	if err != nil {
+		logging.Debugf("TagResource failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to tag secret: %w", err)
+	}
+	return nil
+}
+
+// UntagSecret removes a tag from a secret. Removing a tag the secret doesn't
+// have is not an error.
+func (c *Client) UntagSecret(ctx context.Context, secretName, key string) error {
+	_, err := c.writer(ctx).UntagResource(ctx, &secretsmanager.UntagResourceInput{
+		SecretId: aws.String(secretName),
+		TagKeys:  []string{key},
+	})
+	if err != nil {
+		logging.Debugf("UntagResource failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to untag secret: %w", err)
+	}
+	return nil
+}
+
+// ScheduleDeletion deletes a secret after RecoveryWindowDays, and returns
+// the date it will be deleted. Until then it can be restored with
+// RestoreSecret.
+func (c *Client) ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error) {
+	result, err := c.writer(ctx).DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+		SecretId:             aws.String(secretName),
+		RecoveryWindowInDays: aws.Int64(RecoveryWindowDays),
+	})
+	if err != nil {
+		logging.Debugf("DeleteSecret failed for %q: %v", secretName, err)
+		return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if result.DeletionDate == nil {
+		return time.Now().AddDate(0, 0, RecoveryWindowDays), nil
+	}
+	return *result.DeletionDate, nil
+}
+
+// PutSecretValue writes a new current version of an existing secret, as
+// SecretBinary for a binary value and SecretString otherwise
+func (c *Client) PutSecretValue(ctx context.Context, secretName string, value models.SecretValue) error {
+	input := &secretsmanager.PutSecretValueInput{SecretId: aws.String(secretName)}
+	if value.IsBinary() {
+		input.SecretBinary = value.Binary
+	} else {
+		input.SecretString = aws.String(value.String)
+	}
+	if _, err := c.writer(ctx).PutSecretValue(ctx, input); err != nil {
+		logging.Debugf("PutSecretValue failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to update secret value: %w", err)
+	}
+	return nil
+}
+
+// EnableRotation turns on rotation of a secret by a Lambda function every
+// days days, without rotating it now: a new secret's value is already fresh
+func (c *Client) EnableRotation(ctx context.Context, secretName, lambdaARN string, days int) error {
+	_, err := c.writer(ctx).RotateSecret(ctx, &secretsmanager.RotateSecretInput{
+		SecretId:          aws.String(secretName),
+		RotationLambdaARN: aws.String(lambdaARN),
+		RotationRules:     &types.RotationRulesType{AutomaticallyAfterDays: aws.Int64(int64(days))},
+		RotateImmediately: aws.Bool(false),
+	})
+	if err != nil {
+		logging.Debugf("RotateSecret failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to turn on rotation: %w", err)
+	}
+	return nil
+}
+
+// RestoreSecret cancels the scheduled deletion of a secret
+func (c *Client) RestoreSecret(ctx context.Context, secretName string) error {
+	_, err := c.writer(ctx).RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		logging.Debugf("RestoreSecret failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to restore secret: %w", err)
+	}
+	return nil
+}
+
+// RestorePreviousVersion makes the version labelled AWSPREVIOUS current
+// again, undoing the last value written to a secret. The version it replaces
+// becomes AWSPREVIOUS in turn.
+func (c *Client) RestorePreviousVersion(ctx context.Context, secretName string) error {
+	described, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		logging.Debugf("DescribeSecret %s failed: %v", secretName, err)
+		return fmt.Errorf("failed to describe secret: %w", err)
+	}
+
+	var current, previous string
+	for id, stages := range described.VersionIdsToStages {
+		for _, stage := range stages {
+			switch stage {
+			case "AWSCURRENT":
+				current = id
+			case "AWSPREVIOUS":
+				previous = id
+			}
+		}
+	}
+	if previous == "" {
+		return fmt.Errorf("%s has no previous version to restore", secretName)
+	}
+
+	_, err = c.writer(ctx).UpdateSecretVersionStage(ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(secretName),
+		VersionStage:        aws.String("AWSCURRENT"),
+		MoveToVersionId:     aws.String(previous),
+		RemoveFromVersionId: aws.String(current),
+	})
+	if err != nil {
+		logging.Debugf("UpdateSecretVersionStage failed for %q: %v", secretName, err)
+		return fmt.Errorf("failed to restore the previous version: %w", err)
+	}
+	return nil
+}
@@ -2,15 +2,19 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
 	"gopkg.in/ini.v1"
 )
 
@@ -27,67 +31,166 @@ type ProfileConfig struct {
 	SourceProfile string
 	RoleARN       string
 	Region        string
+
+	// Optional role settings, which the AWS CLI includes in its cache key
+	ExternalID      string
+	RoleSessionName string
+	DurationSeconds string
+
+	// SSOStartURL and SSOSession are set for IAM Identity Center (SSO) profiles
+	SSOStartURL  string
+	SSOSession   string
+	SSOAccountID string
+
+	// CredentialProcess is an external command that prints credentials
+	CredentialProcess string
+
+	// EndpointURL replaces the AWS endpoints, e.g. for LocalStack
+	EndpointURL string
+}
+
+// UsesSSO reports whether the profile signs in through IAM Identity Center
+func (p *ProfileConfig) UsesSSO() bool {
+	return p.SSOStartURL != "" || p.SSOSession != ""
 }
 
-// GetProfileConfig gets configuration for a profile including source profile info
+// GetProfileConfig gets configuration for a profile including source profile info.
+// A missing config file is not an error: the profile simply has no settings.
 func GetProfileConfig(profile string) (*ProfileConfig, error) {
-	homeDir, err := os.UserHomeDir()
+	// Look for the profile section
+	sectionName := fmt.Sprintf("profile %s", profile)
+	if profile == "default" {
+		sectionName = "default"
+	}
+
+	section, err := loadSection(sharedConfigPath(), sectionName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to load config file: %w", err)
 	}
 
-	configPath := filepath.Join(homeDir, ".aws", "config")
+	profileConfig := &ProfileConfig{}
+	if section != nil {
+		profileConfig = &ProfileConfig{
+			MFASerial:     section.Key("mfa_serial").String(),
+			SourceProfile: section.Key("source_profile").String(),
+			RoleARN:       section.Key("role_arn").String(),
+			Region:        section.Key("region").String(),
+
+			ExternalID:      section.Key("external_id").String(),
+			RoleSessionName: section.Key("role_session_name").String(),
+			DurationSeconds: section.Key("duration_seconds").String(),
+
+			SSOStartURL:  section.Key("sso_start_url").String(),
+			SSOSession:   section.Key("sso_session").String(),
+			SSOAccountID: section.Key("sso_account_id").String(),
+
+			CredentialProcess: section.Key("credential_process").String(),
 
-	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return &ProfileConfig{}, nil
+			EndpointURL: section.Key("endpoint_url").String(),
+		}
 	}
 
-	cfg, err := ini.Load(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config file: %w", err)
+	// credential_process can also be set in the credentials file, where
+	// sections are named after the profile alone
+	if profileConfig.CredentialProcess == "" {
+		credentialsSection, err := loadSection(sharedCredentialsPath(), profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load credentials file: %w", err)
+		}
+		if credentialsSection != nil {
+			profileConfig.CredentialProcess = credentialsSection.Key("credential_process").String()
+		}
 	}
 
-	// Look for the profile section
-	sectionName := fmt.Sprintf("profile %s", profile)
-	if profile == "default" {
-		sectionName = "default"
+	return profileConfig, nil
+}
+
+// loadSection returns a section of an ini file, or nil when the file or the
+// section does not exist
+func loadSection(path, name string) (*ini.Section, error) {
+	if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+		return nil, nil
 	}
 
-	section, err := cfg.GetSection(sectionName)
+	cfg, err := ini.Load(path)
 	if err != nil {
-		// Profile not found in config
-		return &ProfileConfig{}, nil
+		return nil, err
 	}
 
-	return &ProfileConfig{
-		MFASerial:     section.Key("mfa_serial").String(),
-		SourceProfile: section.Key("source_profile").String(),
-		RoleARN:       section.Key("role_arn").String(),
-		Region:        section.Key("region").String(),
-	}, nil
+	section, err := cfg.GetSection(name)
+	if err != nil {
+		return nil, nil
+	}
+	return section, nil
+}
+
+// ErrSourceProfileLoop is returned by RoleChain when source_profile settings
+// form a loop
+var ErrSourceProfileLoop = errors.New("source_profile loop")
+
+// RoleChain follows source_profile from profile to the profile that holds
+// the base credentials. It returns that profile first, followed by each
+// profile whose role is assumed in turn, ending with profile itself. A
+// profile without a role is a chain of one.
+func RoleChain(profile string) ([]string, error) {
+	chain := []string{profile}
+	seen := map[string]bool{profile: true}
+	for current := profile; ; {
+		profileConfig, err := GetProfileConfig(current)
+		if err != nil {
+			return nil, err
+		}
+		if profileConfig.RoleARN == "" || profileConfig.SourceProfile == "" {
+			break
+		}
+
+		source := profileConfig.SourceProfile
+		chain = append([]string{source}, chain...)
+		if source == current {
+			// A role assumed with the profile's own static credentials
+			break
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("%w: profile %s refers back to profile %s", ErrSourceProfileLoop, current, source)
+		}
+		seen[source] = true
+		current = source
+	}
+	return chain, nil
 }
 
-// GetMFAConfig checks if a profile requires MFA, resolving source profiles
+// GetMFAConfig checks if a profile requires MFA, following source profiles
+// through any chain of roles
 func GetMFAConfig(profile string) (*MFAConfig, error) {
 	config, err := GetProfileConfig(profile)
 	if err != nil {
 		return nil, err
 	}
 
-	// If this profile has a source_profile, check the source for MFA
-	if config.SourceProfile != "" {
-		sourceConfig, err := GetProfileConfig(config.SourceProfile)
-		if err != nil {
+	// Roles are assumed with the credentials of the profile at the root of
+	// the chain, so that is where MFA is needed
+	chain, err := RoleChain(profile)
+	if err != nil {
+		return nil, err
+	}
+	source := chain[0]
+	sourceConfig := config
+	if source != profile {
+		if sourceConfig, err = GetProfileConfig(source); err != nil {
 			return nil, err
 		}
-		if sourceConfig.MFASerial != "" {
-			return &MFAConfig{
-				MFASerial:     sourceConfig.MFASerial,
-				Required:      true,
-				SourceProfile: config.SourceProfile,
-			}, nil
-		}
+	}
+
+	// A credential_process handles any MFA itself
+	if sourceConfig.CredentialProcess != "" {
+		return &MFAConfig{Required: false}, nil
+	}
+	if len(chain) > 1 && sourceConfig.MFASerial != "" {
+		return &MFAConfig{
+			MFASerial:     sourceConfig.MFASerial,
+			Required:      true,
+			SourceProfile: source,
+		}, nil
 	}
 
 	// Check if this profile directly has MFA
@@ -104,7 +207,7 @@ func GetMFAConfig(profile string) (*MFAConfig, error) {
 // GetSessionTokenWithMFA gets temporary credentials using MFA
 func GetSessionTokenWithMFA(ctx context.Context, profile, region, mfaSerial, mfaToken string) (aws.Credentials, error) {
 	// Load config without MFA to get base credentials
-	var opts []func(*config.LoadOptions) error
+	opts := []func(*config.LoadOptions) error{withCallLogging}
 	if profile != "" {
 		opts = append(opts, config.WithSharedConfigProfile(profile))
 	}
@@ -135,6 +238,10 @@ func GetSessionTokenWithMFA(ctx context.Context, profile, region, mfaSerial, mfa
 		return aws.Credentials{}, fmt.Errorf("no credentials returned from STS")
 	}
 
+	// Session credentials are as sensitive as secret values
+	logging.TrackSecret(*result.Credentials.SecretAccessKey)
+	logging.TrackSecret(*result.Credentials.SessionToken)
+
 	return aws.Credentials{
 		AccessKeyID:     *result.Credentials.AccessKeyId,
 		SecretAccessKey: *result.Credentials.SecretAccessKey,
@@ -145,10 +252,16 @@ func GetSessionTokenWithMFA(ctx context.Context, profile, region, mfaSerial, mfa
 	}, nil
 }
 
-// NewClientWithMFA creates a new AWS client using MFA credentials
-func NewClientWithMFA(ctx context.Context, profile, region string, creds aws.Credentials) (*Client, error) {
+// NewClientWithMFA creates a new AWS client using MFA credentials. A
+// non-empty endpointURL replaces the Secrets Manager endpoint.
+func NewClientWithMFA(ctx context.Context, profile, region, endpointURL string, creds aws.Credentials) (*Client, error) {
+	endpointOption, err := withEndpoint(endpointURL)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create config with the MFA credentials
-	var opts []func(*config.LoadOptions) error
+	opts := []func(*config.LoadOptions) error{withCallLogging}
 
 	// Use static credentials from the MFA session
 	opts = append(opts, config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
@@ -165,29 +278,51 @@ func NewClientWithMFA(ctx context.Context, profile, region string, creds aws.Cre
 	}
 
 	// Create Secrets Manager client
-	sm := secretsmanager.NewFromConfig(cfg)
+	sm := secretsmanager.NewFromConfig(cfg, endpointOption, withRetries)
 
 	return &Client{
-		sm:      sm,
-		profile: profile,
-		region:  cfg.Region,
+		sm:       sm,
+		sts:      sts.NewFromConfig(cfg, withSTSEndpoint(endpointURL)),
+		kms:      kms.NewFromConfig(cfg, withKMSEndpoint(endpointURL)),
+		regions:  newEC2Regions(cfg, endpointURL),
+		regional: regionalSecrets(cfg, endpointOption),
+		profile:  profile,
+		region:   cfg.Region,
+		endpoint: endpointURL,
 	}, nil
 }
 
-// NewClientWithMFAForRole creates a new AWS client for a role assumption profile using MFA credentials
-func NewClientWithMFAForRole(ctx context.Context, profile, region string, sourceCreds aws.Credentials) (*Client, error) {
+// NewClientWithMFAForRole creates a new AWS client for a role assumption
+// profile using MFA credentials. A non-zero duration, such as the
+// role_duration setting from config.RoleDurationOverride, overrides the
+// profile's duration_seconds.
+func NewClientWithMFAForRole(ctx context.Context, profile, region string, sourceCreds aws.Credentials, duration time.Duration) (*Client, error) {
+	roleCreds, roleRegion, err := AssumeRole(ctx, profile, region, sourceCreds, duration)
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithMFA(ctx, profile, roleRegion, EndpointURL(profile, ""), roleCreds)
+}
+
+// AssumeRole assumes a profile's role_arn with the source profile's
+// credentials, applying its external_id, role_session_name and
+// duration_seconds settings. A non-zero duration overrides duration_seconds.
+// It returns the role credentials and the region to use, which falls back to
+// the profile's own region setting.
+func AssumeRole(ctx context.Context, profile, region string, sourceCreds aws.Credentials, duration time.Duration) (aws.Credentials, string, error) {
 	// Get the profile configuration to find the role ARN
 	profileConfig, err := GetProfileConfig(profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get profile config: %w", err)
+		return aws.Credentials{}, "", fmt.Errorf("failed to get profile config: %w", err)
 	}
 
-	if profileConfig.RoleARN == "" {
-		return nil, fmt.Errorf("profile %s does not have a role_arn configured", profile)
+	input, err := assumeRoleInput(profile, profileConfig, duration)
+	if err != nil {
+		return aws.Credentials{}, "", err
 	}
 
 	// Create a config with the source credentials
-	var opts []func(*config.LoadOptions) error
+	opts := []func(*config.LoadOptions) error{withCallLogging}
 
 	// Use the MFA session credentials from the source profile
 	opts = append(opts, config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
@@ -202,51 +337,61 @@ func NewClientWithMFAForRole(ctx context.Context, profile, region string, source
 
 	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return aws.Credentials{}, "", fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
 	// Use STS to assume the role
 	stsClient := sts.NewFromConfig(cfg)
 
 	// Assume the role
-	assumeRoleOutput, err := stsClient.AssumeRole(ctx, &sts.AssumeRoleInput{
-		RoleArn:         &profileConfig.RoleARN,
-		RoleSessionName: aws.String(fmt.Sprintf("secretsrc-%s", profile)),
-	})
+	assumeRoleOutput, err := stsClient.AssumeRole(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to assume role: %w", err)
+		return aws.Credentials{}, "", fmt.Errorf("failed to assume role: %w", err)
 	}
 
 	if assumeRoleOutput.Credentials == nil {
-		return nil, fmt.Errorf("no credentials returned from AssumeRole")
+		return aws.Credentials{}, "", fmt.Errorf("no credentials returned from AssumeRole")
 	}
 
-	// Create config with the assumed role credentials
-	roleCreds := aws.Credentials{
+	logging.TrackSecret(*assumeRoleOutput.Credentials.SecretAccessKey)
+	logging.TrackSecret(*assumeRoleOutput.Credentials.SessionToken)
+
+	return aws.Credentials{
 		AccessKeyID:     *assumeRoleOutput.Credentials.AccessKeyId,
 		SecretAccessKey: *assumeRoleOutput.Credentials.SecretAccessKey,
 		SessionToken:    *assumeRoleOutput.Credentials.SessionToken,
 		Source:          "AssumeRole",
 		CanExpire:       true,
 		Expires:         *assumeRoleOutput.Credentials.Expiration,
-	}
+	}, cfg.Region, nil
+}
 
-	roleConfig, err := config.LoadDefaultConfig(ctx,
-		config.WithCredentialsProvider(credentials.StaticCredentialsProvider{
-			Value: roleCreds,
-		}),
-		config.WithRegion(cfg.Region),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create config with assumed role credentials: %w", err)
+// assumeRoleInput builds the AssumeRole request for a profile's role
+func assumeRoleInput(profile string, profileConfig *ProfileConfig, duration time.Duration) (*sts.AssumeRoleInput, error) {
+	if profileConfig.RoleARN == "" {
+		return nil, fmt.Errorf("profile %s does not have a role_arn configured", profile)
 	}
 
-	// Create Secrets Manager client with assumed role credentials
-	sm := secretsmanager.NewFromConfig(roleConfig)
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(profileConfig.RoleARN),
+		RoleSessionName: aws.String(fmt.Sprintf("secretsrc-%s", profile)),
+	}
+	if profileConfig.RoleSessionName != "" {
+		input.RoleSessionName = aws.String(profileConfig.RoleSessionName)
+	}
+	if profileConfig.ExternalID != "" {
+		input.ExternalId = aws.String(profileConfig.ExternalID)
+	}
 
-	return &Client{
-		sm:      sm,
-		profile: profile,
-		region:  roleConfig.Region,
-	}, nil
+	if duration == 0 && profileConfig.DurationSeconds != "" {
+		seconds, err := strconv.Atoi(profileConfig.DurationSeconds)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s has an invalid duration_seconds %q", profile, profileConfig.DurationSeconds)
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	if duration > 0 {
+		input.DurationSeconds = aws.Int32(int32(duration / time.Second))
+	}
+	return input, nil
 }
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRoleChain(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	writeFile(t, configPath, `[profile base]
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+
+[profile hub]
+source_profile = base
+role_arn = arn:aws:iam::222222222222:role/hub
+
+[profile workload]
+source_profile = hub
+role_arn = arn:aws:iam::333333333333:role/deploy
+
+[profile loop-a]
+source_profile = loop-b
+role_arn = arn:aws:iam::444444444444:role/a
+
+[profile loop-b]
+source_profile = loop-a
+role_arn = arn:aws:iam::444444444444:role/b
+`)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+
+	chain, err := RoleChain("workload")
+	if err != nil {
+		t.Fatalf("RoleChain returned error: %v", err)
+	}
+	if want := []string{"base", "hub", "workload"}; !reflect.DeepEqual(chain, want) {
+		t.Fatalf("RoleChain = %v, want %v", chain, want)
+	}
+
+	mfaConfig, err := GetMFAConfig("workload")
+	if err != nil || !mfaConfig.Required || mfaConfig.SourceProfile != "base" {
+		t.Fatalf("expected MFA for the root of the chain, got %+v, %v", mfaConfig, err)
+	}
+
+	if _, err := RoleChain("loop-a"); !errors.Is(err, ErrSourceProfileLoop) {
+		t.Fatalf("expected ErrSourceProfileLoop, got %v", err)
+	}
+}
+
+func TestAssumeRoleInput(t *testing.T) {
+	profileConfig := &ProfileConfig{
+		RoleARN:         "arn:aws:iam::222222222222:role/vendor",
+		ExternalID:      "shared-secret-id",
+		RoleSessionName: "alice",
+		DurationSeconds: "7200",
+	}
+
+	input, err := assumeRoleInput("vendor", profileConfig, 0)
+	if err != nil {
+		t.Fatalf("assumeRoleInput returned error: %v", err)
+	}
+	if *input.ExternalId != "shared-secret-id" || *input.RoleSessionName != "alice" || *input.DurationSeconds != 7200 {
+		t.Fatalf("expected the profile's role settings, got %+v", input)
+	}
+
+	input, err = assumeRoleInput("vendor", profileConfig, 4*time.Hour)
+	if err != nil || *input.DurationSeconds != 14400 {
+		t.Fatalf("expected the duration override to win, got %+v, %v", input, err)
+	}
+
+	input, err = assumeRoleInput("plain", &ProfileConfig{RoleARN: "arn:aws:iam::222222222222:role/plain"}, 0)
+	if err != nil || *input.RoleSessionName != "secretsrc-plain" || input.DurationSeconds != nil || input.ExternalId != nil {
+		t.Fatalf("expected defaults for a bare role, got %+v, %v", input, err)
+	}
+
+	if _, err := assumeRoleInput("vendor", &ProfileConfig{RoleARN: "arn", DurationSeconds: "1h"}, 0); err == nil {
+		t.Fatal("expected an error for a non-numeric duration_seconds")
+	}
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// policyConcurrency bounds how many resource policies are fetched at once
+const policyConcurrency = 8
+
+// GetResourcePolicy returns the resource policy attached to a secret as
+// JSON, or "" when it has none
+func (c *Client) GetResourcePolicy(ctx context.Context, secretName string) (string, error) {
+	result, err := c.sm.GetResourcePolicy(ctx, &secretsmanager.GetResourcePolicyInput{
+		SecretId: &secretName,
+	})
+	if err != nil {
+		logging.Debugf("GetResourcePolicy %s failed: %v", secretName, err)
+		return "", fmt.Errorf("failed to get resource policy: %w", err)
+	}
+	return stringValue(result.ResourcePolicy), nil
+}
+
+// GetResourcePolicies fetches the resource policies of several secrets,
+// keyed by name with "" for none. Secrets whose policy can't be read, e.g.
+// without secretsmanager:GetResourcePolicy, are left out; an expired
+// session fails the whole call so it can be signed in again.
+func (c *Client) GetResourcePolicies(ctx context.Context, secretNames []string) (map[string]string, error) {
+	policies := make(map[string]string, len(secretNames))
+	var mu sync.Mutex
+	var expired error
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, policyConcurrency)
+	for _, name := range secretNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			policy, err := c.GetResourcePolicy(ctx, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				policies[name] = policy
+			case IsExpiredSession(err):
+				expired = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get resource policies: %w", err)
+	}
+	if expired != nil {
+		return nil, expired
+	}
+	logging.Debugf("read the resource policies of %d of %d secrets", len(policies), len(secretNames))
+	return policies, nil
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// accountFromARN returns the account ID field of an ARN such as
+// arn:aws:iam::123456789012:role/deploy, or "" if it has none
+func accountFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
+// SecretARN is the parts of a Secrets Manager secret ARN such as
+// arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf
+type SecretARN struct {
+	Partition string
+	Region    string
+	Account   string
+	// Name is the secret's name, without the six random characters AWS
+	// adds to the ARN
+	Name string
+}
+
+// ParseSecretARN splits a secret ARN into its parts, reporting false for
+// anything that isn't one. A partial ARN, without the random suffix, is
+// accepted as AWS accepts it.
+func ParseSecretARN(arn string) (SecretARN, bool) {
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) != 7 || parts[0] != "arn" || parts[2] != "secretsmanager" || parts[5] != "secret" || parts[6] == "" {
+		return SecretARN{}, false
+	}
+	name := parts[6]
+	if i := strings.LastIndex(name, "-"); i > 0 && len(name)-i-1 == 6 {
+		name = name[:i]
+	}
+	return SecretARN{Partition: parts[1], Region: parts[3], Account: parts[4], Name: name}, true
+}
+
+// ProfileForAccount returns the profile to use for account: preferred when
+// it is in that account or its account isn't known from the config, else
+// the first of profiles known to be in it, else preferred
+func ProfileForAccount(account, preferred string, profiles []string) string {
+	if account == "" {
+		return preferred
+	}
+	if known := SummarizeProfile(preferred).Account; known == "" || known == account {
+		return preferred
+	}
+	for _, summary := range SummarizeProfiles(profiles) {
+		if summary.Account == account {
+			return summary.Name
+		}
+	}
+	return preferred
+}
+
+// SummarizeProfile describes a profile from the shared config files. A
+// profile whose settings can't be read is summarized by name alone.
+func SummarizeProfile(profile string) models.ProfileSummary {
+	summary := models.ProfileSummary{Name: profile}
+	profileConfig, err := GetProfileConfig(profile)
+	if err != nil {
+		logging.Debugf("reading profile %s for the profile selector failed: %v", profile, err)
+		return summary
+	}
+
+	summary.Region = profileConfig.Region
+	summary.SSO = profileConfig.UsesSSO()
+	summary.CredentialProcess = profileConfig.CredentialProcess != ""
+	if profileConfig.RoleARN != "" {
+		summary.RoleSource = profileConfig.SourceProfile
+	}
+	for _, account := range []string{
+		accountFromARN(profileConfig.RoleARN),
+		profileConfig.SSOAccountID,
+		accountFromARN(profileConfig.MFASerial),
+	} {
+		if account != "" {
+			summary.Account = account
+			break
+		}
+	}
+
+	if mfaConfig, err := GetMFAConfig(profile); err == nil {
+		summary.MFA = mfaConfig.Required
+	}
+	return summary
+}
+
+// SummarizeProfiles describes each of profiles, in the same order
+func SummarizeProfiles(profiles []string) []models.ProfileSummary {
+	summaries := make([]models.ProfileSummary, len(profiles))
+	for i, profile := range profiles {
+		summaries[i] = SummarizeProfile(profile)
+	}
+	return summaries
+}
@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestSummarizeProfiles(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	writeFile(t, configPath, `[profile base]
+region = eu-west-1
+mfa_serial = arn:aws:iam::111111111111:mfa/alice
+
+[profile prod]
+source_profile = base
+role_arn = arn:aws:iam::222222222222:role/deploy
+region = us-east-1
+
+[profile sso-dev]
+sso_session = corp
+sso_account_id = 333333333333
+
+[profile tool]
+credential_process = /usr/local/bin/creds
+`)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	got := SummarizeProfiles([]string{"base", "prod", "sso-dev", "tool", "unknown"})
+	want := []models.ProfileSummary{
+		{Name: "base", Account: "111111111111", Region: "eu-west-1", MFA: true},
+		{Name: "prod", Account: "222222222222", Region: "us-east-1", MFA: true, RoleSource: "base"},
+		{Name: "sso-dev", Account: "333333333333", SSO: true},
+		{Name: "tool", CredentialProcess: true},
+		{Name: "unknown"},
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("summary %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseSecretARN(t *testing.T) {
+	for arn, want := range map[string]SecretARN{
+		"arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf":  {"aws", "eu-west-2", "123456789012", "app/db"},
+		"arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db":         {"aws", "eu-west-2", "123456789012", "app/db"},
+		"arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:my-service": {"aws-cn", "cn-north-1", "123456789012", "my-service"},
+	} {
+		if got, ok := ParseSecretARN(arn); !ok || got != want {
+			t.Errorf("ParseSecretARN(%s) = %+v, %v; want %+v", arn, got, ok, want)
+		}
+	}
+	for _, notARN := range []string{"app/db", "arn:aws:iam::123456789012:role/deploy", "arn:aws:secretsmanager:eu-west-2:123456789012:secret:"} {
+		if _, ok := ParseSecretARN(notARN); ok {
+			t.Errorf("expected %q not to parse", notARN)
+		}
+	}
+}
+
+func TestProfileForAccount(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	writeFile(t, configPath, `[profile dev]
+sso_account_id = 111111111111
+
+[profile prod]
+sso_account_id = 222222222222
+
+[profile tool]
+credential_process = /usr/local/bin/creds
+`)
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	profiles := []string{"dev", "prod", "tool"}
+
+	for _, tc := range []struct{ account, preferred, want string }{
+		{"222222222222", "dev", "prod"},
+		{"111111111111", "dev", "dev"},
+		{"222222222222", "tool", "tool"}, // Its account isn't known, so it may be right
+		{"999999999999", "dev", "dev"},
+		{"", "dev", "dev"},
+	} {
+		if got := ProfileForAccount(tc.account, tc.preferred, profiles); got != tc.want {
+			t.Errorf("ProfileForAccount(%s, %s) = %s, want %s", tc.account, tc.preferred, got, tc.want)
+		}
+	}
+}
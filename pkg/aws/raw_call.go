@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// maxRawResponse bounds how much of a response to a raw call is read
+const maxRawResponse = 4 << 20
+
+// rawService calls an AWS API whose SDK module isn't a dependency, such as
+// Lambda's ListFunctions. Calls get what the SDK clients built from the same
+// config get: its retryer, the call log, and errors that ClassifyError,
+// ErrorOperation and ErrorRequestID understand.
+type rawService struct {
+	serviceID   string // As the SDK names the service, e.g. "Lambda"
+	signingName string // e.g. "lambda"
+	// baseEndpoint is cfg's base endpoint, as for the SDK clients, or empty
+	// for the service's regional endpoint
+	baseEndpoint string
+	region       string
+	credentials  aws.CredentialsProvider
+	httpClient   aws.HTTPClient
+	retryer      func() aws.Retryer
+}
+
+// newRawService calls a service in cfg's region, or in fallbackRegion when
+// cfg has none
+func newRawService(cfg aws.Config, serviceID, signingName, fallbackRegion string) rawService {
+	region := cfg.Region
+	if region == "" {
+		region = fallbackRegion
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	retryer := cfg.Retryer
+	if retryer == nil {
+		retryer = newRetryer
+	}
+	return rawService{
+		serviceID:    serviceID,
+		signingName:  signingName,
+		baseEndpoint: aws.ToString(cfg.BaseEndpoint),
+		region:       region,
+		credentials:  cfg.Credentials,
+		httpClient:   httpClient,
+		retryer:      retryer,
+	}
+}
+
+// inRegion returns the service in another region
+func (s rawService) inRegion(region string) rawService {
+	s.region = region
+	return s
+}
+
+// endpoint returns where calls are sent, with a trailing slash
+func (s rawService) endpoint() string {
+	switch {
+	case s.baseEndpoint != "":
+		return strings.TrimSuffix(s.baseEndpoint, "/") + "/"
+	case strings.HasPrefix(s.region, "cn-"):
+		return fmt.Sprintf("https://%s.%s.amazonaws.com.cn/", s.signingName, s.region)
+	}
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", s.signingName, s.region)
+}
+
+// apiErrorDecoder reads the error code and message from a failed response's
+// body, returning "" for a body it doesn't recognize
+type apiErrorDecoder func(resp *http.Response, body []byte) (code, message string)
+
+// call sends the request newRequest makes for each attempt, retrying as the
+// retryer allows, and returns the body of the successful response
+func (s rawService) call(ctx context.Context, operation string, newRequest func(ctx context.Context) (*http.Request, []byte, error), decodeError apiErrorDecoder) ([]byte, error) {
+	retryer := s.retryer()
+	start := time.Now()
+	attempts := 0
+	for {
+		attempts++
+		body, requestID, err := s.attempt(ctx, retryer, operation, newRequest, decodeError)
+		if err != nil && attempts < retryer.MaxAttempts() && retryer.IsErrorRetryable(err) {
+			if delay, delayErr := retryer.RetryDelay(attempts, err); delayErr == nil && waitFor(ctx, delay) {
+				continue
+			}
+		}
+		logRawCall(s.serviceID, operation, s.region, start, attempts, requestID, err)
+		if err != nil {
+			return nil, &smithy.OperationError{ServiceID: s.serviceID, OperationName: operation, Err: err}
+		}
+		return body, nil
+	}
+}
+
+// waitFor waits for delay, returning false if ctx ends first
+func waitFor(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// attempt signs and sends one request, returning the response body and
+// request ID
+func (s rawService) attempt(ctx context.Context, retryer aws.Retryer, operation string, newRequest func(ctx context.Context) (*http.Request, []byte, error), decodeError apiErrorDecoder) ([]byte, string, error) {
+	if v2, ok := retryer.(aws.RetryerV2); ok {
+		// Lets the adaptive retryer slow down once AWS starts throttling
+		release, err := v2.GetAttemptToken(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		body, requestID, err := s.send(ctx, operation, newRequest, decodeError)
+		release(err)
+		return body, requestID, err
+	}
+	return s.send(ctx, operation, newRequest, decodeError)
+}
+
+// send signs and sends one request
+func (s rawService) send(ctx context.Context, operation string, newRequest func(ctx context.Context) (*http.Request, []byte, error), decodeError apiErrorDecoder) ([]byte, string, error) {
+	req, payload, err := newRequest(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create %s request: %w", operation, err)
+	}
+	if s.credentials == nil {
+		return nil, "", &v4.SigningError{Err: fmt.Errorf("failed to retrieve credentials: no credentials provider")}
+	}
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, "", &v4.SigningError{Err: fmt.Errorf("failed to retrieve credentials: %w", err)}
+	}
+	hash := sha256.Sum256(payload)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), s.signingName, s.region, time.Now()); err != nil {
+		return nil, "", &v4.SigningError{Err: fmt.Errorf("failed to sign http request, %w", err)}
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, "", &smithyhttp.RequestSendError{Err: err}
+	}
+	defer resp.Body.Close()
+	requestID := resp.Header.Get("X-Amzn-Requestid")
+	if requestID == "" {
+		requestID = resp.Header.Get("X-Amz-Request-Id")
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRawResponse))
+	if err != nil {
+		return nil, requestID, &smithy.DeserializationError{Err: fmt.Errorf("failed to read %s response: %w", operation, err)}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, requestID, nil
+	}
+
+	code, message := decodeError(resp, body)
+	if code == "" {
+		code = resp.Status
+	}
+	fault := smithy.FaultServer
+	if resp.StatusCode < 500 {
+		fault = smithy.FaultClient
+	}
+	return nil, requestID, &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: resp},
+			Err:      &smithy.GenericAPIError{Code: code, Message: message, Fault: fault},
+		},
+		RequestID: requestID,
+	}
+}
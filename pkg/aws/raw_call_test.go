@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestRawServiceRetriesAndReportsErrorsLikeTheSDK(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/lambda/aws4_request") {
+			t.Errorf("expected a request signed for Lambda, got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("X-Amzn-Requestid", "req-"+r.URL.Query().Get("n"))
+		switch {
+		case r.URL.Query().Get("n") == "denied":
+			w.Header().Set("X-Amzn-ErrorType", "AccessDeniedException:http://internal.amazon.com/")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"Type":"User","Message":"not allowed"}`))
+		case calls == 1:
+			w.Header().Set("X-Amzn-ErrorType", "TooManyRequestsException")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"Type":"User","Message":"Rate exceeded"}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	var events []RetryEvent
+	stop := OnRetry(func(event RetryEvent) { events = append(events, event) })
+	defer stop()
+	cfg := aws.Config{
+		Region:       "eu-west-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("AKID", "secret", ""),
+		BaseEndpoint: aws.String(server.URL),
+		Retryer: func() aws.Retryer {
+			// The retryer from newRetryer, without waiting between attempts
+			return &observedRetryer{RetryerV2: retry.NewStandard(func(o *retry.StandardOptions) {
+				o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+			})}
+		},
+	}
+	service := newRawService(cfg, "Lambda", "lambda", "")
+	call := func(n string) error {
+		_, err := service.call(context.Background(), "ListFunctions", func(ctx context.Context) (*http.Request, []byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, service.endpoint()+"?n="+n, nil)
+			return req, nil, err
+		}, lambdaError)
+		return err
+	}
+
+	if err := call("1"); err != nil {
+		t.Fatalf("expected the throttled call to be retried, got %v", err)
+	}
+	if calls != 2 || len(events) != 1 || !events[0].Throttled {
+		t.Fatalf("expected one throttled retry, got %d calls and %+v", calls, events)
+	}
+
+	err := call("denied")
+	if ClassifyError(err) != ErrorAccessDenied {
+		t.Fatalf("expected access denied, got %v", err)
+	}
+	if ErrorOperation(err) != "ListFunctions" || ErrorRequestID(err) != "req-denied" {
+		t.Fatalf("expected the operation and request ID, got %q and %q", ErrorOperation(err), ErrorRequestID(err))
+	}
+	if calls != 3 {
+		t.Fatalf("expected access denied not to be retried, got %d calls", calls)
+	}
+}
@@ -0,0 +1,171 @@
+package aws
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// regionProbeConcurrency bounds how many regions are checked for secrets at
+// once
+const regionProbeConcurrency = 8
+
+// ec2APIVersion is the EC2 query API version DescribeRegions is called with
+const ec2APIVersion = "2016-11-15"
+
+// regionsAPI lists the regions enabled for the account
+type regionsAPI interface {
+	DescribeRegions(ctx context.Context) ([]string, error)
+}
+
+// ec2Regions calls EC2 DescribeRegions through a rawService, as the EC2 SDK
+// module isn't a dependency
+type ec2Regions struct {
+	ec2 rawService
+}
+
+// describeRegionsResponse is the part of the DescribeRegions response used
+type describeRegionsResponse struct {
+	Regions []struct {
+		Name string `xml:"regionName"`
+	} `xml:"regionInfo>item"`
+}
+
+// ec2Error reads the code and message of a failed EC2 response
+func ec2Error(resp *http.Response, body []byte) (string, string) {
+	var failure struct {
+		Errors []struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Errors>Error"`
+	}
+	if xml.Unmarshal(body, &failure) != nil || len(failure.Errors) == 0 {
+		return "", ""
+	}
+	return failure.Errors[0].Code, failure.Errors[0].Message
+}
+
+// newEC2Regions lists regions with cfg's credentials, or returns nil for a
+// custom endpoint, where GetCommonRegions is used instead
+func newEC2Regions(cfg aws.Config, endpointURL string) regionsAPI {
+	if endpointURL != "" || cfg.Credentials == nil {
+		return nil
+	}
+	return ec2Regions{ec2: newRawService(withRetryer(cfg), "EC2", "ec2", "us-east-1")}
+}
+
+// DescribeRegions returns the names of the regions enabled for the account,
+// leaving out opt-in regions that haven't been enabled
+func (e ec2Regions) DescribeRegions(ctx context.Context) ([]string, error) {
+	query := url.Values{"Action": {"DescribeRegions"}, "Version": {ec2APIVersion}}
+	body, err := e.ec2.call(ctx, "DescribeRegions", func(ctx context.Context) (*http.Request, []byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.ec2.endpoint()+"?"+query.Encode(), nil)
+		return req, nil, err
+	}, ec2Error)
+	if err != nil {
+		return nil, err
+	}
+
+	var result describeRegionsResponse
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse DescribeRegions response: %w", err)
+	}
+	names := make([]string, 0, len(result.Regions))
+	for _, region := range result.Regions {
+		if region.Name != "" {
+			names = append(names, region.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.New("DescribeRegions returned no regions")
+	}
+	return names, nil
+}
+
+// regionalSecrets returns Secrets Manager clients for other regions built
+// from cfg, with the same custom endpoint and retryer as the client's own.
+// Each region's client is created once and reused.
+func regionalSecrets(cfg aws.Config, endpointOption func(*secretsmanager.Options)) func(region string) secretsAPI {
+	var mu sync.Mutex
+	clients := make(map[string]secretsAPI)
+	return func(region string) secretsAPI {
+		mu.Lock()
+		defer mu.Unlock()
+		if client, ok := clients[region]; ok {
+			return client
+		}
+		client := secretsmanager.NewFromConfig(cfg, endpointOption, withRetries, func(o *secretsmanager.Options) {
+			o.Region = region
+		})
+		clients[region] = client
+		return client
+	}
+}
+
+// ListRegions returns the regions enabled for the account, or
+// GetCommonRegions when they can't be listed (e.g. without
+// ec2:DescribeRegions), marking those where Secrets Manager has secrets.
+// Regions that can't be checked are left unmarked.
+func (c *Client) ListRegions(ctx context.Context) ([]models.Region, error) {
+	names := GetCommonRegions()
+	if c.regions != nil {
+		enabled, err := c.regions.DescribeRegions(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("failed to list regions: %w", ctx.Err())
+		case err != nil:
+			logging.Debugf("listing enabled regions failed, using the common regions: %v", err)
+		default:
+			names = enabled
+			sort.Strings(names)
+		}
+	}
+
+	regions := make([]models.Region, len(names))
+	for i, name := range names {
+		regions[i].Name = name
+	}
+	if c.regional == nil {
+		return regions, nil
+	}
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, regionProbeConcurrency)
+	for i := range regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			regions[i].HasSecrets = c.hasSecrets(ctx, regions[i].Name)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list regions: %w", err)
+	}
+	return regions, nil
+}
+
+// hasSecrets reports whether Secrets Manager has at least one secret in a
+// region, treating errors as none
+func (c *Client) hasSecrets(ctx context.Context, region string) bool {
+	result, err := c.regional(region).ListSecrets(ctx, &secretsmanager.ListSecretsInput{
+		MaxResults: aws.Int32(1),
+	})
+	if err != nil {
+		logging.Debugf("checking %s for secrets failed: %v", region, err)
+		return false
+	}
+	return len(result.SecretList) > 0
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// fakeRegions answers DescribeRegions with canned regions
+type fakeRegions struct {
+	names []string
+	err   error
+}
+
+func (f fakeRegions) DescribeRegions(ctx context.Context) ([]string, error) {
+	return f.names, f.err
+}
+
+func TestDescribeRegionsSignsAndParsesTheQueryAPI(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") != "DescribeRegions" || !strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/ec2/aws4_request") {
+			t.Errorf("unexpected request %s with authorization %q", r.URL, r.Header.Get("Authorization"))
+		}
+		if fail {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`<Response><Errors><Error><Code>UnauthorizedOperation</Code><Message>not allowed</Message></Error></Errors></Response>`))
+			return
+		}
+		w.Write([]byte(`<DescribeRegionsResponse><regionInfo>
+			<item><regionName>eu-west-1</regionName></item>
+			<item><regionName>ap-southeast-5</regionName></item>
+		</regionInfo></DescribeRegionsResponse>`))
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""), BaseEndpoint: aws.String(server.URL)}
+	regions := newEC2Regions(cfg, "")
+	names, err := regions.DescribeRegions(context.Background())
+	if err != nil {
+		t.Fatalf("DescribeRegions returned error: %v", err)
+	}
+	if strings.Join(names, ",") != "eu-west-1,ap-southeast-5" {
+		t.Fatalf("unexpected regions %v", names)
+	}
+
+	fail = true
+	_, err = regions.DescribeRegions(context.Background())
+	if ClassifyError(err) != ErrorAccessDenied || ErrorOperation(err) != "DescribeRegions" {
+		t.Fatalf("expected EC2 to be denied, got %v", err)
+	}
+
+	if newEC2Regions(cfg, "http://localhost:4566") != nil {
+		t.Fatal("expected custom endpoints to use the common regions")
+	}
+}
+
+func TestListRegionsMarksRegionsWithSecrets(t *testing.T) {
+	client := NewClientWithAPI(&fakeSecretsAPI{}, "dev", "eu-west-1")
+	client.regions = fakeRegions{names: []string{"eu-west-1", "ap-southeast-5", "us-east-1"}}
+	client.regional = func(region string) secretsAPI {
+		switch region {
+		case "ap-southeast-5":
+			return &fakeSecretsAPI{pages: [][]types.SecretListEntry{{{Name: aws.String("app/db")}}}}
+		case "us-east-1":
+			return &fakeSecretsAPI{err: errors.New("access denied")}
+		}
+		return &fakeSecretsAPI{pages: [][]types.SecretListEntry{{}}}
+	}
+
+	regions, err := client.ListRegions(context.Background())
+	if err != nil {
+		t.Fatalf("ListRegions returned error: %v", err)
+	}
+	want := []models.Region{{Name: "ap-southeast-5", HasSecrets: true}, {Name: "eu-west-1"}, {Name: "us-east-1"}}
+	if len(regions) != len(want) {
+		t.Fatalf("unexpected regions %+v", regions)
+	}
+	for i := range want {
+		if regions[i] != want[i] {
+			t.Fatalf("unexpected regions %+v", regions)
+		}
+	}
+
+	client.regions = fakeRegions{err: errors.New("UnauthorizedOperation")}
+	client.regional = nil
+	regions, err = client.ListRegions(context.Background())
+	if err != nil || len(regions) != len(GetCommonRegions()) {
+		t.Fatalf("expected the common regions when DescribeRegions fails, got %d (%v)", len(regions), err)
+	}
+}
+
+func TestRegionalSecretsReusesOneClientPerRegionWithTheRetryer(t *testing.T) {
+	regional := regionalSecrets(aws.Config{Region: "eu-west-1"}, func(*secretsmanager.Options) {})
+
+	client := regional("ap-southeast-5").(*secretsmanager.Client)
+	if regional("ap-southeast-5") != secretsAPI(client) {
+		t.Fatal("expected the region's client to be reused")
+	}
+	if regional("us-east-1") == secretsAPI(client) {
+		t.Fatal("expected another region to get its own client")
+	}
+	if client.Options().Region != "ap-southeast-5" {
+		t.Fatalf("expected the client to call ap-southeast-5, got %s", client.Options().Region)
+	}
+	if _, ok := client.Options().Retryer.(*observedRetryer); !ok {
+		t.Fatalf("expected the shared retryer, got %T", client.Options().Retryer)
+	}
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+const (
+	// retryMaxAttempts is higher than the SDK's default of 3, so bulk
+	// operations ride out a burst of throttling instead of failing
+	retryMaxAttempts = 8
+	// retryMaxBackoff caps the exponential backoff between attempts
+	retryMaxBackoff = 20 * time.Second
+)
+
+// RetryEvent describes a failed call that is about to be retried
+type RetryEvent struct {
+	Attempt   int           // The attempt that failed, starting at 1
+	Delay     time.Duration // How long until the next attempt
+	Throttled bool          // Whether AWS throttled the call
+	Err       error
+}
+
+// retryObservers holds the functions registered with OnRetry
+var retryObservers = struct {
+	sync.Mutex
+	fns    map[int]func(RetryEvent)
+	nextID int
+}{fns: make(map[int]func(RetryEvent))}
+
+// OnRetry calls fn before every retry of an AWS call. fn runs on
+// the goroutine making the call, so it must not block. The returned function
+// unregisters it.
+func OnRetry(fn func(RetryEvent)) func() {
+	retryObservers.Lock()
+	defer retryObservers.Unlock()
+	id := retryObservers.nextID
+	retryObservers.nextID++
+	retryObservers.fns[id] = fn
+
+	return func() {
+		retryObservers.Lock()
+		defer retryObservers.Unlock()
+		delete(retryObservers.fns, id)
+	}
+}
+
+func notifyRetry(event RetryEvent) {
+	retryObservers.Lock()
+	fns := make([]func(RetryEvent), 0, len(retryObservers.fns))
+	for _, fn := range retryObservers.fns {
+		fns = append(fns, fn)
+	}
+	retryObservers.Unlock()
+
+	for _, fn := range fns {
+		fn(event)
+	}
+}
+
+// newRetryer returns the retryer for the client's AWS calls: the SDK's
+// adaptive mode, which slows the client down once AWS starts throttling, with
+// exponential backoff and full jitter between attempts
+func newRetryer() aws.Retryer {
+	return &observedRetryer{RetryerV2: retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+			so.MaxAttempts = retryMaxAttempts
+			so.MaxBackoff = retryMaxBackoff
+			so.Backoff = retry.NewExponentialJitterBackoff(retryMaxBackoff)
+		})
+	})}
+}
+
+// withRetries gives a Secrets Manager client the retryer from newRetryer
+func withRetries(o *secretsmanager.Options) {
+	o.Retryer = newRetryer()
+}
+
+// withRetryer returns cfg with the retryer from newRetryer, for the clients
+// of other services built from it
+func withRetryer(cfg aws.Config) aws.Config {
+	cfg.Retryer = newRetryer
+	return cfg
+}
+
+// observedRetryer reports each retry to the OnRetry observers
+type observedRetryer struct {
+	aws.RetryerV2
+}
+
+func (r *observedRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay, delayErr := r.RetryerV2.RetryDelay(attempt, err)
+	if delayErr != nil {
+		return delay, delayErr
+	}
+
+	throttled := retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err) == aws.TrueTernary
+	logging.Debugf("retrying after attempt %d in %s (throttled: %t): %v", attempt, delay, throttled, err)
+	notifyRetry(RetryEvent{Attempt: attempt, Delay: delay, Throttled: throttled, Err: err})
+	return delay, nil
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestRetryerReportsThrottling(t *testing.T) {
+	var events []RetryEvent
+	stop := OnRetry(func(event RetryEvent) { events = append(events, event) })
+	defer stop()
+
+	retryer := newRetryer()
+	if retryer.MaxAttempts() != retryMaxAttempts {
+		t.Fatalf("MaxAttempts = %d, want %d", retryer.MaxAttempts(), retryMaxAttempts)
+	}
+
+	throttle := &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}
+	if !retryer.IsErrorRetryable(throttle) {
+		t.Fatal("expected throttling to be retryable")
+	}
+	delay, err := retryer.RetryDelay(1, throttle)
+	if err != nil {
+		t.Fatalf("RetryDelay returned error: %v", err)
+	}
+	if delay > retryMaxBackoff {
+		t.Fatalf("delay %s exceeds the maximum backoff", delay)
+	}
+
+	if _, err := retryer.RetryDelay(2, errors.New("connection reset")); err != nil {
+		t.Fatalf("RetryDelay returned error: %v", err)
+	}
+
+	if len(events) != 2 || !events[0].Throttled || events[0].Delay != delay || events[1].Throttled {
+		t.Fatalf("expected a throttled then an unthrottled retry, got %+v", events)
+	}
+
+	stop()
+	_, _ = retryer.RetryDelay(3, throttle)
+	if len(events) != 2 {
+		t.Fatal("expected no events after unregistering")
+	}
+}
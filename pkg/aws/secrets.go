@@ -2,9 +2,16 @@ package aws
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
 	"github.com/benjamingriff/secretsrc/pkg/models"
 )
 
@@ -20,56 +27,304 @@ func (c *Client) ListSecrets(ctx context.Context, maxResults int32, nextToken *s
 
 	result, err := c.sm.ListSecrets(ctx, input)
 	if err != nil {
+		logging.Debugf("ListSecrets failed (profile %q, region %q): %v", c.profile, c.region, err)
 		return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
+	logging.Debugf("ListSecrets returned %d secrets (more pages: %t)", len(result.SecretList), result.NextToken != nil)
 
 	secrets := make([]models.Secret, 0, len(result.SecretList))
 	for _, entry := range result.SecretList {
-		secret := models.Secret{
-			ARN:             stringValue(entry.ARN),
-			Name:            stringValue(entry.Name),
-			Description:     stringValue(entry.Description),
-			LastChangedDate: entry.LastChangedDate,
-		}
+		secrets = append(secrets, secretFromEntry(entry))
+	}
+
+	return secrets, result.NextToken, nil
+}
 
-		// Convert tags
-		if len(entry.Tags) > 0 {
-			secret.Tags = make(map[string]string)
-			for _, tag := range entry.Tags {
-				if tag.Key != nil && tag.Value != nil {
-					secret.Tags[*tag.Key] = *tag.Value
-				}
+// secretFromEntry converts a ListSecrets entry to a Secret
+func secretFromEntry(entry types.SecretListEntry) models.Secret {
+	secret := models.Secret{
+		ARN:              stringValue(entry.ARN),
+		Name:             stringValue(entry.Name),
+		Description:      stringValue(entry.Description),
+		LastChangedDate:  entry.LastChangedDate,
+		LastAccessedDate: entry.LastAccessedDate,
+		LastRotatedDate:  entry.LastRotatedDate,
+		OwningService:    stringValue(entry.OwningService),
+		KmsKeyID:         stringValue(entry.KmsKeyId),
+	}
+	if entry.RotationEnabled != nil {
+		secret.RotationEnabled = *entry.RotationEnabled
+	}
+
+	// Convert tags
+	if len(entry.Tags) > 0 {
+		secret.Tags = make(map[string]string)
+		for _, tag := range entry.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				secret.Tags[*tag.Key] = *tag.Value
 			}
 		}
+	}
+
+	return secret
+}
 
-		secrets = append(secrets, secret)
+// FindSecrets lists every secret whose name starts with prefix, following all
+// pages. The prefix is applied server-side with the ListSecrets name filter,
+// which AWS matches case-insensitively; an empty prefix lists all secrets.
+func (c *Client) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	input := &secretsmanager.ListSecretsInput{}
+	if prefix != "" {
+		input.Filters = []types.Filter{{
+			Key:    types.FilterNameStringTypeName,
+			Values: []string{prefix},
+		}}
 	}
 
-	return secrets, result.NextToken, nil
+	var secrets []models.Secret
+	paginator := secretsmanager.NewListSecretsPaginator(c.sm, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logging.Debugf("ListSecrets with prefix %q failed: %v", prefix, err)
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, entry := range page.SecretList {
+			secrets = append(secrets, secretFromEntry(entry))
+		}
+	}
+	logging.Debugf("ListSecrets with prefix %q found %d secrets", prefix, len(secrets))
+
+	return secrets, nil
 }
 
-// GetSecretValue retrieves and decrypts a secret value
+// ErrBinarySecret is returned by GetSecretValue for secrets stored as SecretBinary
+var ErrBinarySecret = errors.New("secret is stored as binary, not text")
+
+// GetSecretValue retrieves and decrypts a text secret value
 func (c *Client) GetSecretValue(ctx context.Context, secretName string) (string, error) {
+	value, err := c.GetSecret(ctx, secretName)
+	if err != nil {
+		return "", err
+	}
+	if value.IsBinary() {
+		return "", fmt.Errorf("secret %s: %w", secretName, ErrBinarySecret)
+	}
+	return value.String, nil
+}
+
+// GetSecret retrieves and decrypts a secret, whether stored as text or binary
+func (c *Client) GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error) {
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: &secretName,
 	}
 
 	result, err := c.sm.GetSecretValue(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to get secret value: %w", err)
+		logging.Debugf("GetSecretValue %s failed: %v", secretName, err)
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+
+	return secretValue("GetSecretValue", secretName, result.SecretString, result.SecretBinary)
+}
+
+// secretValue converts a value returned by operation, registering it for
+// redaction before anything else can print it
+func secretValue(operation, secretName string, text *string, binary []byte) (*models.SecretValue, error) {
+	if text != nil {
+		logging.TrackSecret(*text)
+		logging.Debugf("%s %s returned %d bytes of text", operation, secretName, len(*text))
+		return &models.SecretValue{String: *text}, nil
+	}
+
+	if binary != nil {
+		logging.TrackSecret(base64.StdEncoding.EncodeToString(binary))
+		logging.TrackSecret(hex.EncodeToString(binary))
+		logging.Debugf("%s %s returned %d bytes of binary", operation, secretName, len(binary))
+		return &models.SecretValue{Binary: binary}, nil
+	}
+
+	return nil, fmt.Errorf("secret has no value")
+}
+
+// MaxBatchSecrets is the most secrets BatchGetSecretValue returns per call
+const MaxBatchSecrets = 20
+
+// batchUnsupportedCodes are the errors for endpoints without
+// BatchGetSecretValue, such as older emulators
+var batchUnsupportedCodes = map[string]bool{
+	"UnknownOperationException": true,
+	"InvalidAction":             true,
+	"NotImplemented":            true,
+}
+
+// GetSecrets retrieves and decrypts several secrets, keyed by the names
+// given, with one BatchGetSecretValue call per MaxBatchSecrets secrets. When
+// batch calls aren't allowed, which takes the secretsmanager:BatchGetSecretValue
+// permission, or the endpoint doesn't support them, the secrets are fetched
+// one at a time instead. A secret that can't be read fails the whole call.
+func (c *Client) GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error) {
+	values := make(map[string]*models.SecretValue, len(secretNames))
+	if len(secretNames) == 1 {
+		// A partial ARN can't be matched to the entry a batch call returns
+		return c.getSecretsOneByOne(ctx, secretNames, values)
+	}
+	for start := 0; start < len(secretNames); start += MaxBatchSecrets {
+		batch := secretNames[start:min(start+MaxBatchSecrets, len(secretNames))]
+		err := c.batchGetSecrets(ctx, batch, values)
+		if errors.Is(err, errBatchUnavailable) {
+			logging.Debugf("fetching secrets one at a time: %v", err)
+			return c.getSecretsOneByOne(ctx, secretNames, values)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// batchGetSecrets fetches up to MaxBatchSecrets secrets into values
+func (c *Client) batchGetSecrets(ctx context.Context, secretNames []string, values map[string]*models.SecretValue) error {
+	input := &secretsmanager.BatchGetSecretValueInput{SecretIdList: secretNames}
+	// Secrets can be asked for by name or ARN; entries come back with both
+	requested := make(map[string]bool, len(secretNames))
+	for _, name := range secretNames {
+		requested[name] = true
+	}
+
+	paginator := secretsmanager.NewBatchGetSecretValuePaginator(c.sm, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			logging.Debugf("BatchGetSecretValue of %d secrets failed: %v", len(secretNames), err)
+			if batchUnsupported(err) {
+				return fmt.Errorf("%w: %v", errBatchUnavailable, err)
+			}
+			return fmt.Errorf("failed to get secret values: %w", err)
+		}
+		if len(page.Errors) > 0 {
+			failed := page.Errors[0]
+			apiErr := &smithy.GenericAPIError{Code: stringValue(failed.ErrorCode), Message: stringValue(failed.Message)}
+			return fmt.Errorf("%s: failed to get secret value: %w", stringValue(failed.SecretId), apiErr)
+		}
+		for _, entry := range page.SecretValues {
+			name := stringValue(entry.Name)
+			if !requested[name] {
+				name = stringValue(entry.ARN)
+			}
+			value, err := secretValue("BatchGetSecretValue", name, entry.SecretString, entry.SecretBinary)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			values[name] = value
+		}
+	}
+
+	for _, name := range secretNames {
+		if values[name] == nil {
+			return fmt.Errorf("%s: failed to get secret value: not returned by BatchGetSecretValue", name)
+		}
+	}
+	return nil
+}
+
+// getSecretsOneByOne fetches the secrets not already in values with a
+// GetSecretValue call each
+func (c *Client) getSecretsOneByOne(ctx context.Context, secretNames []string, values map[string]*models.SecretValue) (map[string]*models.SecretValue, error) {
+	for _, name := range secretNames {
+		if values[name] != nil {
+			continue
+		}
+		value, err := c.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// errBatchUnavailable is returned by batchGetSecrets when BatchGetSecretValue
+// can't be called at all
+var errBatchUnavailable = errors.New("BatchGetSecretValue is unavailable")
+
+// batchUnsupported reports whether a failed BatchGetSecretValue call was
+// refused outright, for lack of permission or support
+func batchUnsupported(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return ClassifyError(err) == ErrorAccessDenied || batchUnsupportedCodes[apiErr.ErrorCode()]
+}
+
+// DescribeSecret retrieves the extended metadata for a secret without its value
+func (c *Client) DescribeSecret(ctx context.Context, secretName string) (*models.SecretDetails, error) {
+	result, err := c.sm.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: &secretName,
+	})
+	if err != nil {
+		logging.Debugf("DescribeSecret %s failed: %v", secretName, err)
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
 	}
 
-	// Return the secret string (most secrets are stored as strings)
-	if result.SecretString != nil {
-		return *result.SecretString, nil
+	details := &models.SecretDetails{
+		Name:             stringValue(result.Name),
+		ARN:              stringValue(result.ARN),
+		Description:      stringValue(result.Description),
+		LastChangedDate:  result.LastChangedDate,
+		KmsKeyID:         stringValue(result.KmsKeyId),
+		CreatedDate:      result.CreatedDate,
+		LastAccessedDate: result.LastAccessedDate,
+		LastRotatedDate:  result.LastRotatedDate,
+		NextRotationDate: result.NextRotationDate,
+		OwningService:    stringValue(result.OwningService),
+	}
+	if result.RotationEnabled != nil {
+		details.RotationEnabled = *result.RotationEnabled
+	}
+	if len(result.Tags) > 0 {
+		details.Tags = make(map[string]string, len(result.Tags))
+		for _, tag := range result.Tags {
+			details.Tags[stringValue(tag.Key)] = stringValue(tag.Value)
+		}
 	}
 
-	// If the secret is binary, return a message
-	if result.SecretBinary != nil {
-		return "[Binary secret - not displayable as text]", nil
+	for id, stages := range result.VersionIdsToStages {
+		details.Versions = append(details.Versions, models.SecretVersion{ID: id, Stages: stages})
 	}
+	sortVersions(details.Versions)
 
-	return "", fmt.Errorf("secret has no value")
+	return details, nil
+}
+
+// versionStageRank orders versions by their most significant staging label
+var versionStageRank = map[string]int{
+	"AWSCURRENT":  0,
+	"AWSPENDING":  1,
+	"AWSPREVIOUS": 2,
+}
+
+// sortVersions puts the current version first, then pending, previous, and
+// any versions with only custom labels, breaking ties by version ID
+func sortVersions(versions []models.SecretVersion) {
+	rank := func(v models.SecretVersion) int {
+		best := len(versionStageRank)
+		for _, stage := range v.Stages {
+			if r, ok := versionStageRank[stage]; ok && r < best {
+				best = r
+			}
+		}
+		return best
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		ri, rj := rank(versions[i]), rank(versions[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return versions[i].ID < versions[j].ID
+	})
 }
 
 // stringValue safely dereferences a string pointer
@@ -0,0 +1,430 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// fakeSecretsAPI serves canned responses in place of Secrets Manager
+type fakeSecretsAPI struct {
+	pages       [][]types.SecretListEntry
+	values      map[string]*secretsmanager.GetSecretValueOutput
+	describe    *secretsmanager.DescribeSecretOutput
+	err         error
+	batchErr    error // Returned by BatchGetSecretValue alone
+	batchCalls  int
+	listFilters [][]types.Filter
+	calls       []string // Write operations, as "Operation secret"
+	// policies are the resource policies by secret; others are denied
+	policies map[string]string
+}
+
+func (f *fakeSecretsAPI) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.listFilters = append(f.listFilters, params.Filters)
+
+	page := 0
+	if params.NextToken != nil {
+		page = int((*params.NextToken)[0] - '0')
+	}
+	output := &secretsmanager.ListSecretsOutput{SecretList: f.pages[page]}
+	if page+1 < len(f.pages) {
+		output.NextToken = aws.String(string(rune('0' + page + 1)))
+	}
+	return output, nil
+}
+
+func (f *fakeSecretsAPI) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	output, ok := f.values[*params.SecretId]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+	return output, nil
+}
+
+func (f *fakeSecretsAPI) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.batchErr != nil {
+		return nil, f.batchErr
+	}
+	f.batchCalls++
+
+	output := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range params.SecretIdList {
+		value, ok := f.values[id]
+		if !ok {
+			output.Errors = append(output.Errors, types.APIErrorType{
+				SecretId:  aws.String(id),
+				ErrorCode: aws.String("ResourceNotFoundException"),
+				Message:   aws.String("secret not found"),
+			})
+			continue
+		}
+		output.SecretValues = append(output.SecretValues, types.SecretValueEntry{
+			Name:         aws.String(id),
+			ARN:          aws.String("arn:aws:secretsmanager:eu-west-2:123456789012:secret:" + id),
+			SecretString: value.SecretString,
+			SecretBinary: value.SecretBinary,
+		})
+	}
+	return output, nil
+}
+
+func (f *fakeSecretsAPI) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.describe == nil {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("secret not found")}
+	}
+	return f.describe, nil
+}
+
+func (f *fakeSecretsAPI) TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, "TagResource "+*params.SecretId+" "+*params.Tags[0].Key+"="+*params.Tags[0].Value)
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) UntagResource(ctx context.Context, params *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, "UntagResource "+*params.SecretId+" "+params.TagKeys[0])
+	return &secretsmanager.UntagResourceOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, fmt.Sprintf("DeleteSecret %s %d", *params.SecretId, *params.RecoveryWindowInDays))
+	return &secretsmanager.DeleteSecretOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, "RestoreSecret "+*params.SecretId)
+	return &secretsmanager.RestoreSecretOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, fmt.Sprintf("UpdateSecretVersionStage %s %s %s->%s", *params.SecretId, *params.VersionStage, aws.ToString(params.RemoveFromVersionId), aws.ToString(params.MoveToVersionId)))
+	return &secretsmanager.UpdateSecretVersionStageOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, fmt.Sprintf("CreateSecret %s %s %d tags", *params.Name, *params.SecretString, len(params.Tags)))
+	return &secretsmanager.CreateSecretOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	value := aws.ToString(params.SecretString)
+	if params.SecretBinary != nil {
+		value = fmt.Sprintf("%d bytes", len(params.SecretBinary))
+	}
+	f.calls = append(f.calls, "PutSecretValue "+*params.SecretId+" "+value)
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) RotateSecret(ctx context.Context, params *secretsmanager.RotateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RotateSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.calls = append(f.calls, fmt.Sprintf("RotateSecret %s %s every %d days", *params.SecretId, *params.RotationLambdaARN, *params.RotationRules.AutomaticallyAfterDays))
+	return &secretsmanager.RotateSecretOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	call := "UpdateSecret " + *params.SecretId + " " + aws.ToString(params.Description)
+	if params.KmsKeyId != nil {
+		call = "UpdateSecret " + *params.SecretId + " kms=" + *params.KmsKeyId
+	}
+	f.calls = append(f.calls, call)
+	return &secretsmanager.UpdateSecretOutput{}, nil
+}
+
+func (f *fakeSecretsAPI) GetResourcePolicy(ctx context.Context, params *secretsmanager.GetResourcePolicyInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetResourcePolicyOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	policy, ok := f.policies[*params.SecretId]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform secretsmanager:GetResourcePolicy"}
+	}
+	output := &secretsmanager.GetResourcePolicyOutput{Name: params.SecretId}
+	if policy != "" {
+		output.ResourcePolicy = aws.String(policy)
+	}
+	return output, nil
+}
+
+func TestListSecretsConvertsEntries(t *testing.T) {
+	api := &fakeSecretsAPI{pages: [][]types.SecretListEntry{
+		{{
+			Name: aws.String("app/db"),
+			ARN:  aws.String("arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf"),
+			Tags: []types.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+		}},
+		{{Name: aws.String("app/api")}},
+	}}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+
+	secrets, next, err := client.ListSecrets(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("ListSecrets returned error: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "app/db" || secrets[0].Tags["team"] != "platform" || next == nil {
+		t.Fatalf("unexpected first page: %+v, next %v", secrets, next)
+	}
+
+	found, err := client.FindSecrets(context.Background(), "app/")
+	if err != nil || len(found) != 2 {
+		t.Fatalf("expected FindSecrets to follow every page, got %d, %v", len(found), err)
+	}
+	if filters := api.listFilters[len(api.listFilters)-1]; len(filters) != 1 || filters[0].Values[0] != "app/" {
+		t.Fatalf("expected the prefix to be sent as a name filter, got %+v", filters)
+	}
+}
+
+func TestGetSecretTextAndBinary(t *testing.T) {
+	client := NewClientWithAPI(&fakeSecretsAPI{values: map[string]*secretsmanager.GetSecretValueOutput{
+		"text":   {SecretString: aws.String("hunter2-text")},
+		"binary": {SecretBinary: []byte{0x00, 0xff}},
+	}}, "default", "eu-west-2")
+	ctx := context.Background()
+
+	if value, err := client.GetSecretValue(ctx, "text"); err != nil || value != "hunter2-text" {
+		t.Fatalf("expected the text value, got %q, %v", value, err)
+	}
+	if _, err := client.GetSecretValue(ctx, "binary"); !errors.Is(err, ErrBinarySecret) {
+		t.Fatalf("expected ErrBinarySecret, got %v", err)
+	}
+	if value, err := client.GetSecret(ctx, "binary"); err != nil || !value.IsBinary() {
+		t.Fatalf("expected the binary value, got %+v, %v", value, err)
+	}
+
+	var notFound *types.ResourceNotFoundException
+	if _, err := client.GetSecret(ctx, "missing"); !errors.As(err, &notFound) {
+		t.Fatalf("expected the SDK error to be wrapped, got %v", err)
+	}
+}
+
+func TestGetSecretsBatchesCalls(t *testing.T) {
+	api := &fakeSecretsAPI{values: map[string]*secretsmanager.GetSecretValueOutput{}}
+	var names []string
+	for i := range 45 {
+		name := fmt.Sprintf("app/%02d", i)
+		api.values[name] = &secretsmanager.GetSecretValueOutput{SecretString: aws.String("hunter2-" + name)}
+		names = append(names, name)
+	}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+	ctx := context.Background()
+
+	values, err := client.GetSecrets(ctx, names)
+	if err != nil || len(values) != 45 || values["app/44"].String != "hunter2-app/44" {
+		t.Fatalf("expected every value, got %d values, %v", len(values), err)
+	}
+	if api.batchCalls != 3 {
+		t.Fatalf("expected 3 batches of up to 20, got %d calls", api.batchCalls)
+	}
+
+	if _, err := client.GetSecrets(ctx, []string{"app/00", "missing"}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Fatalf("expected the missing secret to be named in the error, got %v", err)
+	}
+
+	// Without permission for batch calls, each secret is fetched on its own
+	api.batchErr = &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform secretsmanager:BatchGetSecretValue"}
+	values, err = client.GetSecrets(ctx, names[:2])
+	if err != nil || len(values) != 2 || values["app/01"].String != "hunter2-app/01" {
+		t.Fatalf("expected a fallback to GetSecretValue, got %d values, %v", len(values), err)
+	}
+}
+
+func TestDescribeSecretSortsVersions(t *testing.T) {
+	client := NewClientWithAPI(&fakeSecretsAPI{describe: &secretsmanager.DescribeSecretOutput{
+		RotationEnabled: aws.Bool(true),
+		VersionIdsToStages: map[string][]string{
+			"old": {"AWSPREVIOUS"},
+			"new": {"AWSCURRENT"},
+		},
+	}}, "default", "eu-west-2")
+
+	details, err := client.DescribeSecret(context.Background(), "app/db")
+	if err != nil {
+		t.Fatalf("DescribeSecret returned error: %v", err)
+	}
+	if !details.RotationEnabled || len(details.Versions) != 2 || details.Versions[0].ID != "new" {
+		t.Fatalf("unexpected details: %+v", details)
+	}
+}
+
+func TestSortVersionsPutsCurrentFirst(t *testing.T) {
+	versions := []models.SecretVersion{
+		{ID: "c", Stages: []string{"custom"}},
+		{ID: "b", Stages: []string{"AWSPREVIOUS"}},
+		{ID: "a", Stages: []string{"AWSPENDING"}},
+		{ID: "d", Stages: []string{"release", "AWSCURRENT"}},
+	}
+
+	sortVersions(versions)
+
+	want := []string{"d", "a", "b", "c"}
+	for i, id := range want {
+		if versions[i].ID != id {
+			t.Fatalf("version %d = %q, want %q", i, versions[i].ID, id)
+		}
+	}
+}
+
+func TestWriteOperationsCallTheAPI(t *testing.T) {
+	api := &fakeSecretsAPI{}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+	ctx := context.Background()
+
+	if err := client.TagSecret(ctx, "app/db", "team", "platform"); err != nil {
+		t.Fatalf("TagSecret returned error: %v", err)
+	}
+	if err := client.UntagSecret(ctx, "app/db", "owner"); err != nil {
+		t.Fatalf("UntagSecret returned error: %v", err)
+	}
+	deletion, err := client.ScheduleDeletion(ctx, "app/db")
+	if err != nil {
+		t.Fatalf("ScheduleDeletion returned error: %v", err)
+	}
+	if deletion.Before(time.Now().AddDate(0, 0, RecoveryWindowDays-1)) {
+		t.Fatalf("expected the deletion after the recovery window, got %s", deletion)
+	}
+
+	if err := client.RestoreSecret(ctx, "app/db"); err != nil {
+		t.Fatalf("RestoreSecret returned error: %v", err)
+	}
+	if err := client.PutSecretValue(ctx, "app/db", models.SecretValue{String: "hunter3"}); err != nil {
+		t.Fatalf("PutSecretValue returned error: %v", err)
+	}
+	if err := client.PutSecretValue(ctx, "app/cert", models.SecretValue{Binary: []byte{0, 1, 2}}); err != nil {
+		t.Fatalf("PutSecretValue returned error: %v", err)
+	}
+
+	want := []string{"TagResource app/db team=platform", "UntagResource app/db owner", "DeleteSecret app/db 30", "RestoreSecret app/db",
+		"PutSecretValue app/db hunter3", "PutSecretValue app/cert 3 bytes"}
+	if strings.Join(api.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected calls %q, got %q", want, api.calls)
+	}
+}
+
+func TestRestorePreviousVersionMovesTheCurrentLabel(t *testing.T) {
+	api := &fakeSecretsAPI{describe: &secretsmanager.DescribeSecretOutput{
+		VersionIdsToStages: map[string][]string{
+			"v2": {"AWSCURRENT"},
+			"v1": {"AWSPREVIOUS"},
+		},
+	}}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+
+	if err := client.RestorePreviousVersion(context.Background(), "app/db"); err != nil {
+		t.Fatalf("RestorePreviousVersion returned error: %v", err)
+	}
+	if want := "UpdateSecretVersionStage app/db AWSCURRENT v2->v1"; len(api.calls) != 1 || api.calls[0] != want {
+		t.Fatalf("expected %q, got %q", want, api.calls)
+	}
+
+	api.describe.VersionIdsToStages = map[string][]string{"v1": {"AWSCURRENT"}}
+	if err := client.RestorePreviousVersion(context.Background(), "app/db"); err == nil || !strings.Contains(err.Error(), "no previous version") {
+		t.Fatalf("expected a secret with one version to be refused, got %v", err)
+	}
+}
+
+func TestWriteSecretCopyCreatesOrOverwrites(t *testing.T) {
+	ctx := context.Background()
+	secretCopy := &SecretCopy{
+		Name:        "app/db",
+		Description: "Database credentials",
+		Tags:        map[string]string{"team": "platform"},
+		Value:       models.SecretValue{String: "hunter2-copy"},
+	}
+
+	missing := &fakeSecretsAPI{}
+	created, err := NewClientWithAPI(missing, "default", "eu-west-1").WriteSecretCopy(ctx, secretCopy, false)
+	if err != nil || !created {
+		t.Fatalf("expected the secret to be created, got %v, %v", created, err)
+	}
+	if len(missing.calls) != 1 || missing.calls[0] != "CreateSecret app/db hunter2-copy 1 tags" {
+		t.Fatalf("unexpected calls %q", missing.calls)
+	}
+
+	existing := &fakeSecretsAPI{describe: &secretsmanager.DescribeSecretOutput{Name: aws.String("app/db")}}
+	client := NewClientWithAPI(existing, "default", "eu-west-1")
+	if _, err := client.WriteSecretCopy(ctx, secretCopy, false); !errors.Is(err, ErrSecretExists) {
+		t.Fatalf("expected ErrSecretExists without overwrite, got %v", err)
+	}
+	if len(existing.calls) != 0 {
+		t.Fatalf("expected nothing to be written, got %q", existing.calls)
+	}
+
+	created, err = client.WriteSecretCopy(ctx, secretCopy, true)
+	if err != nil || created {
+		t.Fatalf("expected the secret to be overwritten, got %v, %v", created, err)
+	}
+	want := []string{"PutSecretValue app/db hunter2-copy", "UpdateSecret app/db Database credentials", "TagResource app/db team=platform"}
+	if strings.Join(existing.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected calls %q, got %q", want, existing.calls)
+	}
+}
+
+func TestGetResourcePoliciesSkipsUnreadablePolicies(t *testing.T) {
+	api := &fakeSecretsAPI{policies: map[string]string{
+		"app/db":  `{"Version":"2012-10-17","Statement":[]}`,
+		"app/api": "",
+	}}
+	client := NewClientWithAPI(api, "default", "eu-west-2")
+
+	policies, err := client.GetResourcePolicies(context.Background(), []string{"app/db", "app/api", "app/denied"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 2 || policies["app/db"] == "" || policies["app/api"] != "" {
+		t.Fatalf("expected the two readable policies, got %v", policies)
+	}
+	if _, ok := policies["app/denied"]; ok {
+		t.Fatal("expected the denied policy to be left out")
+	}
+
+	api.err = &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "the security token included in the request is expired"}
+	if _, err := client.GetResourcePolicies(context.Background(), []string{"app/db"}); !IsExpiredSession(err) {
+		t.Fatalf("expected the expired session to fail the call, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// describeConcurrency bounds how many task definitions are described at once
+const describeConcurrency = 8
+
+// SecretReference is where an ECS task definition or Lambda function reads
+// a secret, and which version of it
+type SecretReference struct {
+	Kind      string // "ECS" or "Lambda"
+	Name      string // Task definition family:revision, or function name
+	Where     string // Container and variable, or environment variable
+	Key       string // JSON key read, empty for the whole value
+	Stage     string // Staging label pinned, empty for AWSCURRENT
+	VersionID string // Version pinned, empty when a staging label is used
+}
+
+// Pinned reports whether the reference reads a fixed version or a staging
+// label other than AWSCURRENT, so it doesn't follow rotation
+func (r SecretReference) Pinned() bool {
+	return r.VersionID != "" || (r.Stage != "" && r.Stage != "AWSCURRENT")
+}
+
+// workloadsAPI lists what ECS and Lambda read from the environment
+type workloadsAPI interface {
+	TaskDefinitions(ctx context.Context) ([]taskDefinition, error)
+	Functions(ctx context.Context) ([]lambdaFunction, error)
+}
+
+// taskDefinition is the part of an ECS task definition that is checked
+type taskDefinition struct {
+	Family     string
+	Revision   int32
+	Containers []ecstypes.ContainerDefinition
+}
+
+// lambdaFunction is the part of a Lambda function configuration that is
+// checked
+type lambdaFunction struct {
+	Name        string `json:"FunctionName"`
+	Environment struct {
+		Variables map[string]string `json:"Variables"`
+	} `json:"Environment"`
+}
+
+// ecsAPI is the part of the ECS SDK client that workloads uses
+type ecsAPI interface {
+	ListTaskDefinitions(ctx context.Context, params *ecs.ListTaskDefinitionsInput, optFns ...func(*ecs.Options)) (*ecs.ListTaskDefinitionsOutput, error)
+	DescribeTaskDefinition(ctx context.Context, params *ecs.DescribeTaskDefinitionInput, optFns ...func(*ecs.Options)) (*ecs.DescribeTaskDefinitionOutput, error)
+}
+
+// workloads reads ECS through the SDK client, and Lambda through a
+// rawService, as the Lambda SDK module isn't a dependency
+type workloads struct {
+	ecs    ecsAPI
+	lambda rawService
+}
+
+// newWorkloads reads ECS and Lambda with cfg's credentials, or returns nil
+// for a custom endpoint, which only stands in for Secrets Manager
+func newWorkloads(cfg aws.Config, endpointURL string) workloadsAPI {
+	if endpointURL != "" || cfg.Credentials == nil {
+		return nil
+	}
+	cfg = withRetryer(cfg)
+	return workloads{
+		ecs:    ecs.NewFromConfig(cfg),
+		lambda: newRawService(cfg, "Lambda", "lambda", ""),
+	}
+}
+
+// inRegion returns workloads reading another region
+func (w workloads) inRegion(region string) workloads {
+	if client, ok := w.ecs.(*ecs.Client); ok {
+		w.ecs = ecs.New(client.Options(), func(o *ecs.Options) {
+			o.Region = region
+		})
+	}
+	w.lambda = w.lambda.inRegion(region)
+	return w
+}
+
+// TaskDefinitions returns every active task definition revision. Inactive
+// ones have been deregistered, so no new tasks use them.
+func (w workloads) TaskDefinitions(ctx context.Context) ([]taskDefinition, error) {
+	var arns []string
+	paginator := ecs.NewListTaskDefinitionsPaginator(w.ecs, &ecs.ListTaskDefinitionsInput{Status: ecstypes.TaskDefinitionStatusActive})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arns = append(arns, page.TaskDefinitionArns...)
+	}
+
+	definitions := make([]taskDefinition, len(arns))
+	errs := make([]error, len(arns))
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, describeConcurrency)
+	for i, arn := range arns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			described, err := w.ecs.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{TaskDefinition: &arn})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			if definition := described.TaskDefinition; definition != nil {
+				definitions[i] = taskDefinition{Family: stringValue(definition.Family), Revision: definition.Revision, Containers: definition.ContainerDefinitions}
+			}
+		}()
+	}
+	wg.Wait()
+	// Every describe usually fails the same way, e.g. without
+	// ecs:DescribeTaskDefinition, so only the first error is kept
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return definitions, nil
+}
+
+// lambdaError reads a failed Lambda response: the code is in a header, and
+// the body holds the message
+func lambdaError(resp *http.Response, body []byte) (string, string) {
+	var failure struct {
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+	}
+	json.Unmarshal(body, &failure)
+	code, _, _ := strings.Cut(resp.Header.Get("X-Amzn-ErrorType"), ":")
+	return code, failure.Message
+}
+
+// Functions returns every Lambda function's configuration
+func (w workloads) Functions(ctx context.Context) ([]lambdaFunction, error) {
+	var functions []lambdaFunction
+	marker := ""
+	for {
+		query := url.Values{"MaxItems": {"50"}}
+		if marker != "" {
+			query.Set("Marker", marker)
+		}
+		data, err := w.lambda.call(ctx, "ListFunctions", func(ctx context.Context) (*http.Request, []byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.lambda.endpoint()+"2015-03-31/functions/?"+query.Encode(), nil)
+			return req, nil, err
+		}, lambdaError)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Functions  []lambdaFunction `json:"Functions"`
+			NextMarker string           `json:"NextMarker"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse ListFunctions response: %w", err)
+		}
+		functions = append(functions, page.Functions...)
+		if page.NextMarker == "" {
+			return functions, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// FindSecretReferences lists the ECS task definitions and Lambda functions
+// in the client's region that read the secret, by its ARN or name. When
+// only one of the services can be read, its references are returned along
+// with the other's error.
+func (c *Client) FindSecretReferences(ctx context.Context, secret *models.SecretDetails) ([]SecretReference, error) {
+	if c.workloads == nil {
+		return nil, errors.New("ECS and Lambda can't be read through a custom endpoint")
+	}
+	var refs []SecretReference
+	var errs []error
+
+	definitions, err := c.workloads.TaskDefinitions(ctx)
+	if err != nil {
+		logging.Debugf("listing ECS task definitions failed: %v", err)
+		errs = append(errs, fmt.Errorf("ECS: %w", err))
+	}
+	for _, definition := range definitions {
+		for _, container := range definition.Containers {
+			for _, s := range container.Secrets {
+				if ref, ok := matchReference(secret, stringValue(s.ValueFrom)); ok {
+					ref.Kind = "ECS"
+					ref.Name = fmt.Sprintf("%s:%d", definition.Family, definition.Revision)
+					ref.Where = stringValue(container.Name) + " " + stringValue(s.Name)
+					refs = append(refs, ref)
+				}
+			}
+		}
+	}
+
+	functions, err := c.workloads.Functions(ctx)
+	if err != nil {
+		logging.Debugf("listing Lambda functions failed: %v", err)
+		errs = append(errs, fmt.Errorf("Lambda: %w", err))
+	}
+	for _, function := range functions {
+		for variable, value := range function.Environment.Variables {
+			if ref, ok := matchReference(secret, value); ok {
+				ref.Kind = "Lambda"
+				ref.Name = function.Name
+				ref.Where = variable
+				refs = append(refs, ref)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find references: %w", err)
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		if refs[i].Name != refs[j].Name {
+			return refs[i].Name < refs[j].Name
+		}
+		return refs[i].Where < refs[j].Where
+	})
+	return refs, errors.Join(errs...)
+}
+
+// matchReference reports whether value names the secret, by its full ARN,
+// its ARN without the random suffix or its name, and reads the
+// ":json-key:version-stage:version-id" ECS adds after it
+func matchReference(secret *models.SecretDetails, value string) (SecretReference, bool) {
+	ids := []string{secret.ARN}
+	if i := len(secret.ARN) - 7; i > 0 && secret.ARN[i] == '-' {
+		ids = append(ids, secret.ARN[:i])
+	}
+	ids = append(ids, secret.Name)
+
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		rest, ok := strings.CutPrefix(value, id)
+		if !ok || (rest != "" && rest[0] != ':') {
+			continue
+		}
+		var ref SecretReference
+		if rest != "" {
+			parts := strings.SplitN(rest[1:], ":", 3)
+			parts = append(parts, "", "")
+			ref.Key, ref.Stage, ref.VersionID = parts[0], parts[1], parts[2]
+		}
+		return ref, true
+	}
+	return SecretReference{}, false
+}
@@ -0,0 +1,115 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+const dbARN = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-AbCdEf"
+
+func TestFindSecretReferencesReadsECSAndLambda(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("expected %s to be signed", r.URL)
+		}
+		if strings.HasPrefix(r.URL.Path, "/2015-03-31/functions") {
+			w.Write([]byte(`{"Functions":[
+				{"FunctionName":"billing","Environment":{"Variables":{"DB_SECRET":"prod/db","OTHER":"prod/db-old"}}},
+				{"FunctionName":"reports"}
+			]}`))
+			return
+		}
+		var input map[string]string
+		json.NewDecoder(r.Body).Decode(&input)
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonEC2ContainerServiceV20141113.ListTaskDefinitions":
+			if input["nextToken"] == "" {
+				w.Write([]byte(`{"taskDefinitionArns":["api:3"],"nextToken":"more"}`))
+				return
+			}
+			w.Write([]byte(`{"taskDefinitionArns":["api:4"]}`))
+		case "AmazonEC2ContainerServiceV20141113.DescribeTaskDefinition":
+			valueFrom := dbARN + ":password:AWSPREVIOUS:"
+			if input["taskDefinition"] == "api:4" {
+				valueFrom = dbARN
+			}
+			w.Write([]byte(`{"taskDefinition":{"family":"api","revision":` + input["taskDefinition"][4:] + `,
+				"containerDefinitions":[{"name":"web","secrets":[{"name":"DB","valueFrom":"` + valueFrom + `"}]}]}}`))
+		}
+	}))
+	defer server.Close()
+	cfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""), BaseEndpoint: aws.String(server.URL)}
+	client := &Client{workloads: newWorkloads(cfg, "")}
+	refs, err := client.FindSecretReferences(context.Background(), &models.SecretDetails{Name: "prod/db", ARN: dbARN})
+	if err != nil {
+		t.Fatalf("FindSecretReferences returned error: %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("expected two task definitions and one function, got %+v", refs)
+	}
+	pinned := refs[0]
+	if pinned.Name != "api:3" || pinned.Where != "web DB" || pinned.Key != "password" || pinned.Stage != "AWSPREVIOUS" || !pinned.Pinned() {
+		t.Fatalf("expected api:3 pinned to AWSPREVIOUS, got %+v", pinned)
+	}
+	if refs[1].Name != "api:4" || refs[1].Pinned() {
+		t.Fatalf("expected api:4 to follow AWSCURRENT, got %+v", refs[1])
+	}
+	if refs[2].Kind != "Lambda" || refs[2].Name != "billing" || refs[2].Where != "DB_SECRET" {
+		t.Fatalf("expected billing's DB_SECRET, got %+v", refs[2])
+	}
+}
+
+func TestFindSecretReferencesReportsServiceErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/2015-03-31/functions") {
+			w.Write([]byte(`{"Functions":[{"FunctionName":"billing","Environment":{"Variables":{"DB":"` + dbARN + `"}}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazonaws#AccessDeniedException","message":"not allowed"}`))
+	}))
+	defer server.Close()
+	cfg := aws.Config{Region: "eu-west-1", Credentials: credentials.NewStaticCredentialsProvider("AKID", "secret", ""), BaseEndpoint: aws.String(server.URL)}
+	client := &Client{workloads: newWorkloads(cfg, "")}
+	refs, err := client.FindSecretReferences(context.Background(), &models.SecretDetails{Name: "prod/db", ARN: dbARN})
+	if ClassifyError(err) != ErrorAccessDenied || ErrorOperation(err) != "ListTaskDefinitions" || !strings.HasPrefix(err.Error(), "ECS: ") {
+		t.Fatalf("expected ECS to be denied, got %v", err)
+	}
+	if len(refs) != 1 || refs[0].Name != "billing" {
+		t.Fatalf("expected Lambda's references despite ECS failing, got %+v", refs)
+	}
+
+	if _, err := NewClientWithAPI(nil, "default", "eu-west-1").FindSecretReferences(context.Background(), &models.SecretDetails{}); err == nil {
+		t.Fatal("expected an error without ECS and Lambda")
+	}
+}
+
+func TestMatchReferenceParsesVersionSuffix(t *testing.T) {
+	secret := &models.SecretDetails{Name: "prod/db", ARN: dbARN}
+	tests := []struct {
+		value   string
+		matches bool
+		want    SecretReference
+	}{
+		{value: dbARN, matches: true},
+		{value: dbARN + "::AWSPENDING:", matches: true, want: SecretReference{Stage: "AWSPENDING"}},
+		{value: strings.TrimSuffix(dbARN, "-AbCdEf") + ":user::v1", matches: true, want: SecretReference{Key: "user", VersionID: "v1"}},
+		{value: "prod/db:password", matches: true, want: SecretReference{Key: "password"}},
+		{value: "prod/db-replica"},
+		{value: "arn:aws:ssm:eu-west-1:123456789012:parameter/prod/db"},
+	}
+	for _, tt := range tests {
+		ref, ok := matchReference(secret, tt.value)
+		if ok != tt.matches || ref != tt.want {
+			t.Errorf("matchReference(%q) = %+v, %v; want %+v, %v", tt.value, ref, ok, tt.want, tt.matches)
+		}
+	}
+}
@@ -0,0 +1,397 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// binaryContentType marks values stored base64 encoded by PutSecretValue,
+// as Key Vault only holds text
+const binaryContentType = "application/octet-stream;base64"
+
+// secretURL returns the URL of a secret, without a version, which stands in
+// for its ARN
+func (v *Vault) secretURL(name string) string {
+	return v.url + "/secrets/" + name
+}
+
+// stringValue dereferences s, "" when nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// tagValues converts azsecrets tags, nil when there are none
+func tagValues(tags map[string]*string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(tags))
+	for key, value := range tags {
+		values[key] = stringValue(value)
+	}
+	return values
+}
+
+// tagPointers converts tags for azsecrets
+func tagPointers(tags map[string]string) map[string]*string {
+	if tags == nil {
+		return nil
+	}
+	pointers := make(map[string]*string, len(tags))
+	for key, value := range tags {
+		pointers[key] = to.Ptr(value)
+	}
+	return pointers
+}
+
+// enabled reports whether a version can be read, which it can unless
+// disabled
+func enabled(props *azsecrets.SecretProperties) bool {
+	return props.Attributes == nil || props.Attributes.Enabled == nil || *props.Attributes.Enabled
+}
+
+// updated returns when a version last changed, nil when unknown
+func updated(props *azsecrets.SecretProperties) *time.Time {
+	if props.Attributes == nil {
+		return nil
+	}
+	return props.Attributes.Updated
+}
+
+// created returns when a version was made, zero when unknown
+func created(props *azsecrets.SecretProperties) time.Time {
+	if props.Attributes == nil || props.Attributes.Created == nil {
+		return time.Time{}
+	}
+	return *props.Attributes.Created
+}
+
+// toSecret converts a listed secret
+func (v *Vault) toSecret(props *azsecrets.SecretProperties) models.Secret {
+	name := props.ID.Name()
+	return models.Secret{
+		Name:            name,
+		ARN:             v.secretURL(name),
+		Description:     stringValue(props.ContentType),
+		LastChangedDate: updated(props),
+		Tags:            tagValues(props.Tags),
+	}
+}
+
+// page reads the next page of a listing, leaving out the secrets that back
+// certificates, which are managed through them
+func (v *Vault) page(ctx context.Context, pager secretPager) ([]models.Secret, *string, error) {
+	page, err := pager.NextPage(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	secrets := make([]models.Secret, 0, len(page.Value))
+	for _, props := range page.Value {
+		if props == nil || props.ID == nil || props.Managed != nil && *props.Managed {
+			continue
+		}
+		secrets = append(secrets, v.toSecret(props))
+	}
+	if !pager.More() {
+		return secrets, nil, nil
+	}
+	return secrets, page.NextLink, nil
+}
+
+// ListSecrets returns a page of secrets, as many as Key Vault puts in one:
+// azsecrets has no page size, so maxResults isn't used. The token is the
+// next page's link; only the latest listing can be continued, as starting
+// a new one forgets the others.
+func (v *Vault) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	v.mu.Lock()
+	var pager secretPager
+	if nextToken == nil {
+		clear(v.pagers)
+		pager = v.client.NewListSecretPropertiesPager(nil)
+	} else {
+		pager = v.pagers[*nextToken]
+		delete(v.pagers, *nextToken)
+	}
+	v.mu.Unlock()
+	if pager == nil {
+		return nil, nil, fmt.Errorf("failed to list secrets: the listing for next token %q has ended, list from the first page again", *nextToken)
+	}
+
+	secrets, next, err := v.page(ctx, pager)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	if next != nil {
+		v.mu.Lock()
+		v.pagers[*next] = pager
+		v.mu.Unlock()
+	}
+	return secrets, next, nil
+}
+
+// FindSecrets returns every secret whose name starts with prefix, ignoring
+// case. Key Vault can't filter, so every page is listed.
+func (v *Vault) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	var matched []models.Secret
+	pager := v.client.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		secrets, _, err := v.page(ctx, pager)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, secret := range secrets {
+			if strings.HasPrefix(strings.ToLower(secret.Name), strings.ToLower(prefix)) {
+				matched = append(matched, secret)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// GetSecret returns the current value of a secret. Values written as binary
+// by PutSecretValue are decoded.
+func (v *Vault) GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error) {
+	resp, err := v.client.GetSecret(ctx, secretName, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+
+	value := stringValue(resp.Value)
+	logging.TrackSecret(value)
+	if stringValue(resp.ContentType) == binaryContentType {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode binary secret %s: %w", secretName, err)
+		}
+		logging.TrackSecret(hex.EncodeToString(data))
+		return &models.SecretValue{Binary: data}, nil
+	}
+	return &models.SecretValue{String: value}, nil
+}
+
+// GetSecrets returns several secret values, keyed by name. Key Vault has no
+// batch read, so they are read one at a time.
+func (v *Vault) GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error) {
+	values := make(map[string]*models.SecretValue, len(secretNames))
+	for _, name := range secretNames {
+		value, err := v.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// PutSecretValue stores value as the new current version of a secret,
+// creating the secret when missing. The tags of the current version are
+// carried over, as Key Vault keeps tags per version.
+func (v *Vault) PutSecretValue(ctx context.Context, secretName string, value models.SecretValue) error {
+	params := azsecrets.SetSecretParameters{Value: to.Ptr(value.String)}
+	if value.IsBinary() {
+		params.Value = to.Ptr(base64.StdEncoding.EncodeToString(value.Binary))
+		params.ContentType = to.Ptr(binaryContentType)
+	}
+
+	current, err := v.currentVersion(ctx, secretName)
+	switch {
+	case IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to put secret value: %w", err)
+	default:
+		params.Tags = current.Tags
+		if !value.IsBinary() && stringValue(current.ContentType) != binaryContentType {
+			params.ContentType = current.ContentType
+		}
+	}
+
+	if _, err := v.client.SetSecret(ctx, secretName, params, nil); err != nil {
+		return fmt.Errorf("failed to put secret value: %w", err)
+	}
+	return nil
+}
+
+// versions lists every version of a secret, newest first
+func (v *Vault) versions(ctx context.Context, secretName string) ([]*azsecrets.SecretProperties, error) {
+	var versions []*azsecrets.SecretProperties
+	pager := v.client.NewListSecretPropertiesVersionsPager(secretName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, props := range page.Value {
+			if props != nil && props.ID != nil {
+				versions = append(versions, props)
+			}
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return created(versions[i]).After(created(versions[j]))
+	})
+	return versions, nil
+}
+
+// currentVersion returns the version GetSecret reads: the newest enabled
+// one
+func (v *Vault) currentVersion(ctx context.Context, secretName string) (*azsecrets.SecretProperties, error) {
+	versions, err := v.versions(ctx, secretName)
+	if err != nil {
+		return nil, err
+	}
+	for _, props := range versions {
+		if enabled(props) {
+			return props, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", secretName, errNoVersion)
+}
+
+// DescribeSecret returns the metadata of a secret, without reading its
+// value. The current version is labelled AWSCURRENT, as in Secrets Manager.
+func (v *Vault) DescribeSecret(ctx context.Context, secretName string) (*models.SecretDetails, error) {
+	versions, err := v.versions(ctx, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("failed to describe secret: %s: %w", secretName, errNoVersion)
+	}
+
+	details := &models.SecretDetails{
+		Name: secretName,
+		ARN:  v.secretURL(secretName),
+	}
+	if first := created(versions[len(versions)-1]); !first.IsZero() {
+		details.CreatedDate = &first
+	}
+	current := false
+	for _, props := range versions {
+		entry := models.SecretVersion{ID: props.ID.Version()}
+		if !current && enabled(props) {
+			current = true
+			entry.Stages = []string{"AWSCURRENT"}
+			details.Description = stringValue(props.ContentType)
+			details.Tags = tagValues(props.Tags)
+			details.LastChangedDate = updated(props)
+		}
+		details.Versions = append(details.Versions, entry)
+	}
+	return details, nil
+}
+
+// updateTags replaces the tags of the current version of a secret with
+// what change makes of them
+func (v *Vault) updateTags(ctx context.Context, secretName string, change func(tags map[string]string)) error {
+	current, err := v.currentVersion(ctx, secretName)
+	if err != nil {
+		return err
+	}
+	tags := maps.Clone(tagValues(current.Tags))
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	change(tags)
+	params := azsecrets.UpdateSecretPropertiesParameters{Tags: tagPointers(tags)}
+	_, err = v.client.UpdateSecretProperties(ctx, secretName, current.ID.Version(), params, nil)
+	return err
+}
+
+// TagSecret sets a tag on the current version of a secret
+func (v *Vault) TagSecret(ctx context.Context, secretName, key, value string) error {
+	err := v.updateTags(ctx, secretName, func(tags map[string]string) { tags[key] = value })
+	if err != nil {
+		return fmt.Errorf("failed to tag secret: %w", err)
+	}
+	return nil
+}
+
+// UntagSecret removes a tag from the current version of a secret
+func (v *Vault) UntagSecret(ctx context.Context, secretName, key string) error {
+	err := v.updateTags(ctx, secretName, func(tags map[string]string) { delete(tags, key) })
+	if err != nil {
+		return fmt.Errorf("failed to untag secret: %w", err)
+	}
+	return nil
+}
+
+// ScheduleDeletion soft-deletes a secret, returning when the vault purges
+// it. Until then it can be recovered with RestoreSecret.
+func (v *Vault) ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error) {
+	resp, err := v.client.DeleteSecret(ctx, secretName, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if resp.ScheduledPurgeDate == nil {
+		return time.Time{}, nil
+	}
+	return *resp.ScheduledPurgeDate, nil
+}
+
+// RestoreSecret recovers a soft-deleted secret
+func (v *Vault) RestoreSecret(ctx context.Context, secretName string) error {
+	if _, err := v.client.RecoverDeletedSecret(ctx, secretName, nil); err != nil {
+		return fmt.Errorf("failed to restore secret: %w", err)
+	}
+	return nil
+}
+
+// GetResourcePolicies returns no policy for each secret: access to Key
+// Vault is granted on the whole vault, not per secret
+func (v *Vault) GetResourcePolicies(ctx context.Context, secretNames []string) (map[string]string, error) {
+	policies := make(map[string]string, len(secretNames))
+	for _, name := range secretNames {
+		policies[name] = ""
+	}
+	return policies, nil
+}
+
+// ListKMSKeys fails, Key Vault secrets aren't encrypted with KMS keys
+func (v *Vault) ListKMSKeys(ctx context.Context) ([]models.KMSKey, error) {
+	return nil, fmt.Errorf("failed to list KMS keys: %w", ErrUnsupported)
+}
+
+// ChangeKMSKey fails, Key Vault secrets aren't encrypted with KMS keys
+func (v *Vault) ChangeKMSKey(ctx context.Context, secretName, kmsKeyID string) error {
+	return fmt.Errorf("failed to change KMS key: %w", ErrUnsupported)
+}
+
+// ListRegions fails, a vault belongs to one Azure region
+func (v *Vault) ListRegions(ctx context.Context) ([]models.Region, error) {
+	return nil, fmt.Errorf("failed to list regions: %w", ErrUnsupported)
+}
+
+// UsesDefaultChain is always false, it refers to the AWS chain
+func (v *Vault) UsesDefaultChain() bool {
+	return false
+}
+
+// GetEndpoint is always empty, the vault's URL is shown instead
+func (v *Vault) GetEndpoint() string {
+	return ""
+}
+
+// GetProfile returns the vault's name, which stands in for the AWS profile
+// in the audit log
+func (v *Vault) GetProfile() string {
+	return v.Name()
+}
+
+// GetRegion is always empty, a vault isn't addressed by region
+func (v *Vault) GetRegion() string {
+	return ""
+}
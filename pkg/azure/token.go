@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// CallerIdentity returns who the vault's tokens are issued to, read from
+// the claims of a token for the vault: the tenant stands in for the account,
+// and the user or application for the ARN. The credential caches the token
+// the requests to the vault use.
+func (v *Vault) CallerIdentity(ctx context.Context) (*models.CallerIdentity, error) {
+	token, err := v.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{v.resource + "/.default"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity, set Azure credentials in the environment or run `az login`: %w", err)
+	}
+	claims, err := tokenClaims(token.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get caller identity: %w", err)
+	}
+
+	identity := &models.CallerIdentity{Account: claims.TenantID, UserID: claims.ObjectID}
+	for _, name := range []string{claims.UPN, claims.UniqueName, claims.AppID} {
+		if name != "" {
+			identity.ARN = name
+			break
+		}
+	}
+	return identity, nil
+}
+
+// claims are the parts of a token's claims that say who it was issued to
+type claims struct {
+	TenantID   string `json:"tid"`
+	ObjectID   string `json:"oid"`
+	UPN        string `json:"upn"`
+	UniqueName string `json:"unique_name"`
+	AppID      string `json:"appid"`
+}
+
+// tokenClaims decodes the claims of a JWT, without verifying it: Key Vault
+// does that, this is only for display
+func tokenClaims(token string) (claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims{}, fmt.Errorf("the token isn't a JWT")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims{}, fmt.Errorf("failed to decode the token: %w", err)
+	}
+	var c claims
+	if err := json.Unmarshal(data, &c); err != nil {
+		return claims{}, fmt.Errorf("failed to decode the token: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,150 @@
+// Package azure reads and writes the secrets of an Azure Key Vault with the
+// azsecrets client, so the UI and CLI can work on a vault instead of Secrets
+// Manager. Requests are signed in with azidentity, and azcore retries them
+// when the vault throttles.
+package azure
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// Name is what the UI calls the backend, e.g. in the header
+const Name = "Azure Key Vault"
+
+// ErrUnsupported is returned for the Secrets Manager features Key Vault
+// has no equivalent of, such as KMS keys
+var ErrUnsupported = errors.New("not supported by Azure Key Vault")
+
+// errNoVersion is returned for a secret none of whose versions is enabled,
+// which GetSecret can't read either
+var errNoVersion = errors.New("the secret has no enabled version")
+
+// secretPager lists a vault's secrets a page at a time
+type secretPager = *runtime.Pager[azsecrets.ListSecretPropertiesResponse]
+
+// Vault is a client for the secrets of one key vault. It is safe for
+// concurrent use.
+type Vault struct {
+	url        string // e.g. https://myvault.vault.azure.net, without a trailing slash
+	resource   string // The audience of the tokens CallerIdentity reads
+	credential azcore.TokenCredential
+	client     *azsecrets.Client
+
+	mu sync.Mutex
+	// pagers are the listings ListSecrets has handed out next tokens for,
+	// keyed by token. azsecrets can't resume a listing from a token, so the
+	// pager is kept until its next page is asked for.
+	pagers map[string]secretPager
+}
+
+// NewVault creates a client for vault, given by name or URL, signed in with
+// credential. A bare name is taken to be in the Azure public cloud.
+func NewVault(vault string, credential azcore.TokenCredential) (*Vault, error) {
+	return newVault(vault, credential, nil)
+}
+
+// newVault creates a client with options for the azsecrets client, which
+// tests use to reach a fake vault
+func newVault(vault string, credential azcore.TokenCredential, options *azsecrets.ClientOptions) (*Vault, error) {
+	vaultURL, err := VaultURL(vault)
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &azsecrets.ClientOptions{}
+	}
+	options.PerCallPolicies = append(options.PerCallPolicies, callLog{})
+	client, err := azsecrets.NewClient(vaultURL, credential, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Key Vault client: %w", err)
+	}
+	u, _ := url.Parse(vaultURL)
+	_, suffix, _ := strings.Cut(u.Host, ".")
+	return &Vault{
+		url:        vaultURL,
+		resource:   "https://" + suffix,
+		credential: credential,
+		client:     client,
+		pagers:     make(map[string]secretPager),
+	}, nil
+}
+
+// OpenVault creates a client for vault, signed in with azidentity's default
+// credential chain: environment variables, workload and managed identity,
+// then the Azure CLI and Azure Developer CLI sign-ins
+func OpenVault(vault string) (*Vault, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Azure sign-in: %w", err)
+	}
+	return NewVault(vault, credential)
+}
+
+// VaultURL returns the URL of vault, given by name (myvault) or URL
+// (https://myvault.vault.azure.net)
+func VaultURL(vault string) (string, error) {
+	vault = strings.TrimSpace(vault)
+	if vault == "" {
+		return "", fmt.Errorf("no key vault given")
+	}
+	if !strings.Contains(vault, "://") {
+		if strings.Contains(vault, ".") {
+			vault = "https://" + vault
+		} else {
+			vault = "https://" + vault + ".vault.azure.net"
+		}
+	}
+	u, err := url.Parse(vault)
+	if err != nil || u.Host == "" || !strings.Contains(u.Host, ".") {
+		return "", fmt.Errorf("invalid key vault %q: expected a name or a URL such as https://myvault.vault.azure.net", vault)
+	}
+	if u.Scheme != "https" {
+		return "", fmt.Errorf("invalid key vault %q: Key Vault is only reached over https", vault)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// URL returns the vault's URL
+func (v *Vault) URL() string {
+	return v.url
+}
+
+// Name returns the vault's name, the first label of its host
+func (v *Vault) Name() string {
+	host := strings.TrimPrefix(strings.TrimPrefix(v.url, "https://"), "http://")
+	name, _, _ := strings.Cut(host, ".")
+	return name
+}
+
+// IsNotFound reports whether err says the secret doesn't exist
+func IsNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.Is(err, errNoVersion) || errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// callLog logs the vault requests that fail, after azcore's retries. Paths
+// are logged, values and tokens never are.
+type callLog struct{}
+
+func (callLog) Do(req *policy.Request) (*http.Response, error) {
+	resp, err := req.Next()
+	switch {
+	case err != nil:
+		logging.Debugf("Key Vault %s %s failed: %v", req.Raw().Method, req.Raw().URL.Path, err)
+	case resp.StatusCode >= 300:
+		logging.Debugf("Key Vault %s %s failed: %s", req.Raw().Method, req.Raw().URL.Path, resp.Status)
+	}
+	return resp, err
+}
@@ -0,0 +1,354 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// fakeToken is the token fakeCredential issues, for tenant "tenant" and
+// user alex@example.com
+const fakeToken = "a.eyJ0aWQiOiJ0ZW5hbnQiLCJ1cG4iOiJhbGV4QGV4YW1wbGUuY29tIn0.c"
+
+// fakeCredential issues a token that lasts an hour, counting requests
+type fakeCredential struct {
+	t     *testing.T
+	calls int
+}
+
+func (f *fakeCredential) GetToken(ctx context.Context, options policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.calls++
+	if strings.Join(options.Scopes, " ") != "https://vault.azure.net/.default" {
+		f.t.Errorf("unexpected scopes %q", options.Scopes)
+	}
+	return azcore.AccessToken{Token: fakeToken, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeVault is a Key Vault with one version per secret, enough for the
+// requests Vault makes. Requests without a token get the challenge that
+// tells azsecrets which tenant and scope to sign in for.
+type fakeVault struct {
+	mu       sync.Mutex
+	url      string
+	secrets  map[string]azsecrets.Secret
+	order    []string
+	deleted  map[string]azsecrets.Secret
+	throttle int // Requests still to be answered with 429
+	requests int
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *Vault) {
+	t.Helper()
+	f := &fakeVault{secrets: make(map[string]azsecrets.Secret), deleted: make(map[string]azsecrets.Secret)}
+	server := httptest.NewTLSServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+	f.url = server.URL
+
+	vault, err := newVault(server.URL, &fakeCredential{t: t}, &azsecrets.ClientOptions{
+		ClientOptions: azcore.ClientOptions{
+			Transport: server.Client(),
+			Retry:     policy.RetryOptions{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+		},
+		// The challenge names vault.azure.net, not the test server
+		DisableChallengeResourceVerification: true,
+	})
+	if err != nil {
+		t.Fatalf("newVault returned error: %v", err)
+	}
+	return f, vault
+}
+
+func (f *fakeVault) add(name, value string, tags map[string]string) {
+	f.secrets[name] = azsecrets.Secret{
+		ID:         f.id(name, "v1"),
+		Attributes: f.attributes(1700000000),
+		Tags:       tagPointers(tags),
+		Value:      to.Ptr(value),
+	}
+	f.order = append(f.order, name)
+}
+
+func (f *fakeVault) id(name, version string) *azsecrets.ID {
+	return to.Ptr(azsecrets.ID(fmt.Sprintf("%s/secrets/%s/%s", f.url, name, version)))
+}
+
+func (f *fakeVault) attributes(unix int64) *azsecrets.SecretAttributes {
+	at := time.Unix(unix, 0)
+	return &azsecrets.SecretAttributes{Enabled: to.Ptr(true), Created: &at, Updated: &at}
+}
+
+// properties is a secret as listed, without its value
+func properties(secret azsecrets.Secret) *azsecrets.SecretProperties {
+	return &azsecrets.SecretProperties{ID: secret.ID, Attributes: secret.Attributes, ContentType: secret.ContentType, Tags: secret.Tags}
+}
+
+func (f *fakeVault) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if r.Header.Get("Authorization") == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+fakeToken || r.URL.Query().Get("api-version") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if f.throttle > 0 {
+		f.throttle--
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":"Throttled","message":"too many requests"}}`)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":"SecretNotFound","message":"not found"}}`)
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		// Two secrets a page, the second linked by nextLink
+		start := 0
+		fmt.Sscan(r.URL.Query().Get("skip"), &start)
+		page := azsecrets.SecretPropertiesListResult{}
+		for _, name := range f.order[start:min(start+2, len(f.order))] {
+			page.Value = append(page.Value, properties(f.secrets[name]))
+		}
+		if start+2 < len(f.order) {
+			// As Key Vault's, with the API version of the request
+			page.NextLink = to.Ptr(fmt.Sprintf("%s/secrets?api-version=%s&skip=%d", f.url, r.URL.Query().Get("api-version"), start+2))
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodGet && len(parts) == 2:
+		secret, ok := f.secrets[parts[1]]
+		if !ok {
+			notFound()
+			return
+		}
+		json.NewEncoder(w).Encode(secret)
+	case r.Method == http.MethodGet && len(parts) == 3 && parts[2] == "versions":
+		secret, ok := f.secrets[parts[1]]
+		if !ok {
+			notFound()
+			return
+		}
+		json.NewEncoder(w).Encode(azsecrets.SecretPropertiesListResult{Value: []*azsecrets.SecretProperties{properties(secret)}})
+	case r.Method == http.MethodPut && len(parts) == 2:
+		var params azsecrets.SetSecretParameters
+		json.NewDecoder(r.Body).Decode(&params)
+		if _, ok := f.secrets[parts[1]]; !ok {
+			f.order = append(f.order, parts[1])
+		}
+		secret := azsecrets.Secret{
+			ID:          f.id(parts[1], "v2"),
+			Attributes:  f.attributes(1700000100),
+			ContentType: params.ContentType,
+			Tags:        params.Tags,
+			Value:       params.Value,
+		}
+		f.secrets[parts[1]] = secret
+		json.NewEncoder(w).Encode(secret)
+	case r.Method == http.MethodPatch && len(parts) == 3:
+		secret, ok := f.secrets[parts[1]]
+		if !ok || secret.ID.Version() != parts[2] {
+			notFound()
+			return
+		}
+		var params azsecrets.UpdateSecretPropertiesParameters
+		json.NewDecoder(r.Body).Decode(&params)
+		secret.Tags = params.Tags
+		f.secrets[parts[1]] = secret
+		json.NewEncoder(w).Encode(properties(secret))
+	case r.Method == http.MethodDelete && len(parts) == 2:
+		secret, ok := f.secrets[parts[1]]
+		if !ok {
+			notFound()
+			return
+		}
+		delete(f.secrets, parts[1])
+		f.deleted[parts[1]] = secret
+		fmt.Fprint(w, `{"scheduledPurgeDate":1700864000}`)
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "deletedsecrets" && parts[2] == "recover":
+		secret, ok := f.deleted[parts[1]]
+		if !ok {
+			notFound()
+			return
+		}
+		f.secrets[parts[1]] = secret
+		delete(f.deleted, parts[1])
+		json.NewEncoder(w).Encode(properties(secret))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestVaultURL(t *testing.T) {
+	tests := []struct {
+		vault string
+		want  string
+		err   bool
+	}{
+		{vault: "myvault", want: "https://myvault.vault.azure.net"},
+		{vault: "myvault.vault.azure.cn", want: "https://myvault.vault.azure.cn"},
+		{vault: "https://myvault.vault.azure.net/", want: "https://myvault.vault.azure.net"},
+		{vault: "http://myvault.vault.azure.net", err: true},
+		{vault: "", err: true},
+	}
+	for _, tt := range tests {
+		got, err := VaultURL(tt.vault)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("VaultURL(%q) = %q, %v; want %q, error %v", tt.vault, got, err, tt.want, tt.err)
+		}
+	}
+
+	vault, err := NewVault("myvault.vault.azure.cn", &fakeCredential{t: t})
+	if err != nil {
+		t.Fatalf("NewVault returned error: %v", err)
+	}
+	if vault.Name() != "myvault" || vault.resource != "https://vault.azure.cn" {
+		t.Fatalf("expected the name and token resource to come from the URL, got %q and %q", vault.Name(), vault.resource)
+	}
+}
+
+func TestVaultListsAndReadsSecrets(t *testing.T) {
+	fake, vault := newFakeVault(t)
+	fake.add("app-db", `{"password":"hunter2"}`, map[string]string{"team": "core"})
+	fake.add("app-api", "key-1", nil)
+	fake.add("billing-db", "key-2", nil)
+	ctx := context.Background()
+
+	secrets, next, err := vault.ListSecrets(ctx, 20, nil)
+	if err != nil {
+		t.Fatalf("ListSecrets returned error: %v", err)
+	}
+	if len(secrets) != 2 || next == nil {
+		t.Fatalf("expected a first page of two and a next token, got %d secrets and %v", len(secrets), next)
+	}
+	if secrets[0].Name != "app-db" || secrets[0].ARN != fake.url+"/secrets/app-db" || secrets[0].Tags["team"] != "core" {
+		t.Fatalf("unexpected secret %+v", secrets[0])
+	}
+	more, last, err := vault.ListSecrets(ctx, 20, next)
+	if err != nil || len(more) != 1 || more[0].Name != "billing-db" || last != nil {
+		t.Fatalf("expected the last page from the next token, got %+v, %v, %v", more, last, err)
+	}
+	if _, _, err := vault.ListSecrets(ctx, 20, next); err == nil {
+		t.Fatal("expected a next token that was already followed to be refused")
+	}
+
+	found, err := vault.FindSecrets(ctx, "APP-")
+	if err != nil {
+		t.Fatalf("FindSecrets returned error: %v", err)
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected both app- secrets across pages, got %+v", found)
+	}
+
+	value, err := vault.GetSecret(ctx, "app-db")
+	if err != nil {
+		t.Fatalf("GetSecret returned error: %v", err)
+	}
+	if value.String != `{"password":"hunter2"}` {
+		t.Fatalf("unexpected value %+v", value)
+	}
+	if _, err := vault.GetSecret(ctx, "missing"); !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	details, err := vault.DescribeSecret(ctx, "app-db")
+	if err != nil {
+		t.Fatalf("DescribeSecret returned error: %v", err)
+	}
+	if details.Tags["team"] != "core" || len(details.Versions) != 1 || details.Versions[0].ID != "v1" || details.Versions[0].Stages[0] != "AWSCURRENT" {
+		t.Fatalf("unexpected details %+v", details)
+	}
+}
+
+func TestVaultWritesSecrets(t *testing.T) {
+	fake, vault := newFakeVault(t)
+	fake.add("app-db", "old", map[string]string{"team": "core"})
+	ctx := context.Background()
+
+	if err := vault.PutSecretValue(ctx, "app-db", models.SecretValue{String: "new"}); err != nil {
+		t.Fatalf("PutSecretValue returned error: %v", err)
+	}
+	if got := fake.secrets["app-db"]; stringValue(got.Value) != "new" || stringValue(got.Tags["team"]) != "core" {
+		t.Fatalf("expected the value replaced and the tags kept, got %+v", got)
+	}
+
+	if err := vault.PutSecretValue(ctx, "tls-key", models.SecretValue{Binary: []byte{0, 1, 2}}); err != nil {
+		t.Fatalf("PutSecretValue returned error: %v", err)
+	}
+	value, err := vault.GetSecret(ctx, "tls-key")
+	if err != nil || string(value.Binary) != "\x00\x01\x02" {
+		t.Fatalf("expected a binary value to round trip, got %+v, %v", value, err)
+	}
+
+	if err := vault.TagSecret(ctx, "app-db", "env", "prod"); err != nil {
+		t.Fatalf("TagSecret returned error: %v", err)
+	}
+	if err := vault.UntagSecret(ctx, "app-db", "team"); err != nil {
+		t.Fatalf("UntagSecret returned error: %v", err)
+	}
+	if tags := fake.secrets["app-db"].Tags; len(tags) != 1 || stringValue(tags["env"]) != "prod" {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+
+	purge, err := vault.ScheduleDeletion(ctx, "app-db")
+	if err != nil || !purge.Equal(time.Unix(1700864000, 0)) {
+		t.Fatalf("expected the purge date, got %v, %v", purge, err)
+	}
+	if err := vault.RestoreSecret(ctx, "app-db"); err != nil {
+		t.Fatalf("RestoreSecret returned error: %v", err)
+	}
+	if _, ok := fake.secrets["app-db"]; !ok {
+		t.Fatal("expected the secret to be recovered")
+	}
+
+	if err := vault.ChangeKMSKey(ctx, "app-db", "key"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected KMS keys to be unsupported, got %v", err)
+	}
+}
+
+func TestVaultRetriesWhenThrottled(t *testing.T) {
+	fake, vault := newFakeVault(t)
+	fake.add("app-db", "value", nil)
+	fake.throttle = 2
+
+	value, err := vault.GetSecret(context.Background(), "app-db")
+	if err != nil || value.String != "value" {
+		t.Fatalf("expected the read to be retried past the throttling, got %+v, %v", value, err)
+	}
+	if fake.throttle != 0 {
+		t.Fatalf("expected both throttled requests to be retried, %d left", fake.throttle)
+	}
+}
+
+func TestCallerIdentity(t *testing.T) {
+	vault, err := NewVault("myvault", &fakeCredential{t: t})
+	if err != nil {
+		t.Fatalf("NewVault returned error: %v", err)
+	}
+	identity, err := vault.CallerIdentity(context.Background())
+	if err != nil {
+		t.Fatalf("CallerIdentity returned error: %v", err)
+	}
+	if identity.Account != "tenant" || identity.ARN != "alex@example.com" {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+}
@@ -0,0 +1,202 @@
+// Package backup writes the values of many secrets to one encrypted
+// archive, and reads them back, for moving secrets between accounts and
+// for disaster recovery drills. Archives are encrypted and decrypted by the
+// age or gpg CLI; the plaintext is only ever piped to and from them, never
+// written to disk.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// Version is the archive format written, which restore checks
+const Version = 1
+
+// The tools an archive can be encrypted with
+const (
+	ToolAge = "age"
+	ToolGPG = "gpg"
+)
+
+// Archive is the plaintext of a backup: where the secrets came from, and
+// each one's value, description and tags
+type Archive struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Account string    `json:"account,omitempty"`
+	Profile string    `json:"profile,omitempty"`
+	Region  string    `json:"region"`
+	Secrets []Secret  `json:"secrets"`
+}
+
+// Secret is one secret of an archive. Exactly one of String and Binary is
+// set.
+type Secret struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	String      string            `json:"string,omitempty"`
+	Binary      []byte            `json:"binary,omitempty"` // Base64 in the archive
+}
+
+// NewArchive builds an archive of secrets with their values, keyed by
+// name, sorted by name. Every secret needs a value.
+func NewArchive(secrets []models.Secret, values map[string]*models.SecretValue) (Archive, error) {
+	archive := Archive{Version: Version, Created: time.Now().UTC()}
+	for _, secret := range secrets {
+		value := values[secret.Name]
+		if value == nil {
+			return Archive{}, fmt.Errorf("no value was read for %s", secret.Name)
+		}
+		archive.Secrets = append(archive.Secrets, Secret{
+			Name:        secret.Name,
+			Description: secret.Description,
+			Tags:        secret.Tags,
+			String:      value.String,
+			Binary:      value.Binary,
+		})
+	}
+	sort.Slice(archive.Secrets, func(i, j int) bool { return archive.Secrets[i].Name < archive.Secrets[j].Name })
+	return archive, nil
+}
+
+// Entries returns the archive's secrets as they are restored, the same way
+// a manifest's are imported
+func (a Archive) Entries() []secretvalue.ManifestEntry {
+	entries := make([]secretvalue.ManifestEntry, len(a.Secrets))
+	for i, secret := range a.Secrets {
+		entries[i] = secretvalue.ManifestEntry{
+			Name:        secret.Name,
+			Description: secret.Description,
+			Tags:        secret.Tags,
+			Value:       models.SecretValue{String: secret.String, Binary: secret.Binary},
+		}
+	}
+	return entries
+}
+
+// Source describes where the archive came from, e.g. "123456789012
+// (prod, eu-west-1)"
+func (a Archive) Source() string {
+	source := a.Region
+	if a.Profile != "" {
+		source = a.Profile + ", " + source
+	}
+	if a.Account != "" {
+		return a.Account + " (" + source + ")"
+	}
+	return source
+}
+
+// runner runs a CLI with input on its stdin, returning its output
+type runner func(ctx context.Context, input []byte, name string, args ...string) ([]byte, error)
+
+// run is the runner used, replaced in tests
+var run runner = runCLI
+
+// runCLI runs age or gpg. Their errors, such as an unknown recipient, are
+// returned as the CLI words them.
+func runCLI(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s isn't installed or isn't on the PATH", name)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		logging.Debugf("%s failed: %s", name, message)
+		return nil, fmt.Errorf("%s failed: %s", name, message)
+	}
+	return out, nil
+}
+
+// Encrypt encodes the archive and encrypts it with tool, age or gpg, to
+// each of the recipients: age1… or ssh- public keys for age, and key IDs
+// or email addresses for gpg
+func Encrypt(ctx context.Context, archive Archive, tool string, recipients []string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("a backup needs at least one recipient to encrypt it to")
+	}
+	var args []string
+	switch tool {
+	case ToolAge:
+		for _, recipient := range recipients {
+			args = append(args, "--recipient", recipient)
+		}
+	case ToolGPG:
+		args = []string{"--batch", "--encrypt"}
+		for _, recipient := range recipients {
+			args = append(args, "--recipient", recipient)
+		}
+	default:
+		return nil, fmt.Errorf("backups are encrypted with age or gpg, not %q", tool)
+	}
+	plaintext, err := json.Marshal(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the backup: %w", err)
+	}
+	return run(ctx, plaintext, tool, args...)
+}
+
+// ageHeaders start the binary and armored forms of age files
+var ageHeaders = []string{"age-encryption.org/v1", "-----BEGIN AGE ENCRYPTED FILE-----"}
+
+// Tool returns whether data was encrypted with age or gpg, from its header
+func Tool(data []byte) string {
+	for _, header := range ageHeaders {
+		if bytes.HasPrefix(data, []byte(header)) {
+			return ToolAge
+		}
+	}
+	return ToolGPG
+}
+
+// Decrypt decrypts a backup and decodes its archive. age needs the identity
+// file holding the private key; gpg finds the key itself, asking through
+// its agent for a passphrase.
+func Decrypt(ctx context.Context, data []byte, ageIdentity string) (Archive, error) {
+	var plaintext []byte
+	var err error
+	switch Tool(data) {
+	case ToolAge:
+		if ageIdentity == "" {
+			return Archive{}, errors.New("the backup is encrypted with age: give the identity file to decrypt it with")
+		}
+		plaintext, err = run(ctx, data, ToolAge, "--decrypt", "--identity", ageIdentity)
+	default:
+		plaintext, err = run(ctx, data, ToolGPG, "--quiet", "--decrypt")
+	}
+	if err != nil {
+		return Archive{}, err
+	}
+
+	var archive Archive
+	if err := json.Unmarshal(plaintext, &archive); err != nil {
+		return Archive{}, fmt.Errorf("the decrypted file isn't a secretsrc backup: %w", err)
+	}
+	if archive.Version != Version {
+		return Archive{}, fmt.Errorf("the backup is format %d, which this version of secretsrc can't read", archive.Version)
+	}
+	if len(archive.Secrets) == 0 {
+		return Archive{}, errors.New("the backup has no secrets")
+	}
+	return archive, nil
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestEncryptAndDecryptThroughTheCLIs(t *testing.T) {
+	var calls [][]string
+	original := run
+	run = func(ctx context.Context, input []byte, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		if slices.Contains(args, "--decrypt") {
+			return bytes.TrimPrefix(input, []byte("age-encryption.org/v1\n")), nil
+		}
+		return append([]byte("age-encryption.org/v1\n"), input...), nil
+	}
+	defer func() { run = original }()
+
+	secrets := []models.Secret{{Name: "prod/db", Tags: map[string]string{"Owner": "data"}}, {Name: "prod/cert"}}
+	values := map[string]*models.SecretValue{
+		"prod/db":   {String: `{"password":"hunter2"}`},
+		"prod/cert": {Binary: []byte{0xde, 0xad}},
+	}
+	archive, err := NewArchive(secrets, values)
+	if err != nil {
+		t.Fatalf("NewArchive returned error: %v", err)
+	}
+	archive.Account, archive.Region = "123456789012", "eu-west-1"
+
+	encrypted, err := Encrypt(context.Background(), archive, ToolAge, []string{"age1abc", "age1def"})
+	if err != nil {
+		t.Fatalf("Encrypt returned error: %v", err)
+	}
+	if want := []string{"age", "--recipient", "age1abc", "--recipient", "age1def"}; !slices.Equal(calls[0], want) {
+		t.Fatalf("expected %v, got %v", want, calls[0])
+	}
+	if Tool(encrypted) != ToolAge {
+		t.Fatal("expected the age header to be recognised")
+	}
+
+	if _, err := Decrypt(context.Background(), encrypted, ""); err == nil || !strings.Contains(err.Error(), "identity") {
+		t.Fatalf("expected an age backup to need an identity, got %v", err)
+	}
+	restored, err := Decrypt(context.Background(), encrypted, "key.txt")
+	if err != nil {
+		t.Fatalf("Decrypt returned error: %v", err)
+	}
+	if restored.Source() != "123456789012 (eu-west-1)" || len(restored.Secrets) != 2 {
+		t.Fatalf("expected the archive back, got %+v", restored)
+	}
+	entries := restored.Entries()
+	if entries[0].Name != "prod/cert" || !bytes.Equal(entries[0].Value.Binary, []byte{0xde, 0xad}) {
+		t.Fatalf("expected the binary secret first, got %+v", entries[0])
+	}
+	if entries[1].Value.String != `{"password":"hunter2"}` || entries[1].Tags["Owner"] != "data" {
+		t.Fatalf("expected the text secret with its tags, got %+v", entries[1])
+	}
+}
+
+func TestEncryptNeedsARecipientAndAKnownTool(t *testing.T) {
+	if _, err := Encrypt(context.Background(), Archive{}, ToolGPG, nil); err == nil {
+		t.Fatal("expected a backup without recipients to be refused")
+	}
+	if _, err := Encrypt(context.Background(), Archive{}, "zip", []string{"x"}); err == nil {
+		t.Fatal("expected an unknown tool to be refused")
+	}
+	if Tool([]byte("\x85\x02gpg packet")) != ToolGPG {
+		t.Fatal("expected anything but age to be decrypted with gpg")
+	}
+}
@@ -0,0 +1,40 @@
+// Package browser opens URLs in the default web browser, through the
+// opener each platform ships with: open on macOS, rundll32 on Windows and
+// xdg-open elsewhere.
+package browser
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Open opens url in the default browser. It fails when the platform's
+// opener isn't installed or can't be started; the browser itself is left
+// running in the background.
+func Open(url string) error {
+	name, args := command(runtime.GOOS, url)
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("failed to find %s to open a browser: %w", name, err)
+	}
+
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open a browser: %w", err)
+	}
+	// The opener returns once the browser has the URL; waiting reaps it
+	go cmd.Wait()
+	return nil
+}
+
+// command returns the opener to run on goos and its arguments
+func command(goos, url string) (string, []string) {
+	switch goos {
+	case "darwin":
+		return "open", []string{url}
+	case "windows":
+		// start would need the URL quoted for cmd.exe, where & splits commands
+		return "rundll32", []string{"url.dll,FileProtocolHandler", url}
+	}
+	return "xdg-open", []string{url}
+}
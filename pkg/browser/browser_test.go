@@ -0,0 +1,14 @@
+package browser
+
+import "testing"
+
+func TestCommandPassesTheURLWhole(t *testing.T) {
+	url := "https://eu-west-2.console.aws.amazon.com/secretsmanager/secret?name=app%2Fdb&region=eu-west-2"
+
+	for goos, want := range map[string]string{"darwin": "open", "windows": "rundll32", "linux": "xdg-open", "freebsd": "xdg-open"} {
+		name, args := command(goos, url)
+		if name != want || args[len(args)-1] != url {
+			t.Errorf("%s: got %s %q, want %s with the URL last", goos, name, args, want)
+		}
+	}
+}
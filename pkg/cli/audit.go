@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/audit"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// auditedStore is what an audit entry says the action was taken on, and as
+// whom
+type auditedStore interface {
+	GetProfile() string
+	GetRegion() string
+	CallerIdentity(ctx context.Context) (*models.CallerIdentity, error)
+}
+
+// recordAudit appends an action taken by command to the audit log, when the
+// audit_log setting names one. Failing to write it is reported on stderr
+// but doesn't fail the command, whose action has already been taken.
+func recordAudit(ctx context.Context, client auditedStore, command, action string, err error, stdio IO) {
+	cfg, _ := config.Load()
+	if cfg == nil || cfg.AuditLogPath() == "" {
+		return
+	}
+
+	entry := audit.Entry{Source: command, Profile: client.GetProfile(), Region: client.GetRegion(), Action: action}
+	if identity, err := client.CallerIdentity(ctx); err == nil && identity != nil {
+		entry.Identity, entry.Account = identity.ARN, identity.Account
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if writeErr := audit.Append(cfg.AuditLogPath(), entry); writeErr != nil {
+		fmt.Fprintf(stdio.Stderr, "Warning: %v\n", writeErr)
+	}
+}
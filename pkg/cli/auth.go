@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/auth"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/azure"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// secretStore is what list, get and put need: *aws.Client, or *azure.Vault
+// with --azure-vault
+type secretStore interface {
+	inventory.Source
+	auditedStore
+	GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error)
+	GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error)
+	PutSecretValue(ctx context.Context, secretName string, value models.SecretValue) error
+}
+
+// newStore creates the client for list, get and put: a Key Vault client with
+// --azure-vault, signed in with the default Azure credentials, and an AWS
+// client otherwise
+func newStore(ctx context.Context, flags *clientFlags, stdio IO) (secretStore, error) {
+	if flags.azureVault == "" {
+		client, err := newClient(ctx, flags, stdio)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+	if flags.debug {
+		logging.EnableDebug(stdio.Stderr)
+	}
+	return azure.OpenVault(flags.azureVault)
+}
+
+// newClient creates an AWS client for the CLI, reusing cached MFA credentials
+// and prompting for a code on stderr/stdin when the profile requires MFA
+func newClient(ctx context.Context, flags *clientFlags, stdio IO) (*aws.Client, error) {
+	if flags.debug {
+		logging.EnableDebug(stdio.Stderr)
+	}
+
+	// Steps are logged by the pipeline, which is enough with --debug
+	pipeline := auth.Default()
+	req := auth.Request{Profile: flags.profile, Region: flags.region, EndpointURL: flags.endpointURL}
+
+	client, err := pipeline.Authenticate(ctx, req)
+	var mfaErr *auth.MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		return client, err
+	}
+
+	req.MFACode, err = promptMFAToken(mfaErr.MFASerial, stdio)
+	if err != nil {
+		return nil, err
+	}
+	client, err = pipeline.Authenticate(ctx, req)
+	if auth.FailedAt(err, auth.StepMFA) {
+		return nil, fmt.Errorf("MFA authentication failed: %w", err)
+	}
+	return client, err
+}
+
+// promptMFAToken asks for a 6-digit MFA code. The prompt goes to stderr so
+// stdout stays clean for piping.
+func promptMFAToken(mfaSerial string, stdio IO) (string, error) {
+	if stdio.Stdin == nil {
+		return "", fmt.Errorf("MFA code for %s needed, but stdin holds the value; run another command first to cache MFA credentials", mfaSerial)
+	}
+	fmt.Fprintf(stdio.Stderr, "MFA code for %s: ", mfaSerial)
+
+	line, err := bufio.NewReader(stdio.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read MFA code: %w", err)
+	}
+
+	token := strings.TrimSpace(line)
+	if len(token) != 6 {
+		return "", fmt.Errorf("MFA code must be 6 digits")
+	}
+	return token, nil
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/backup"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// maxBackupSize is the largest encrypted backup restore reads, in bytes
+const maxBackupSize = 256 << 20
+
+// recipientFlags holds the repeatable --age-recipient and --gpg-recipient
+type recipientFlags struct {
+	age []string
+	gpg []string
+}
+
+// tool returns the encryption tool and recipients given; exactly one of
+// the two flags must be used
+func (f *recipientFlags) tool() (string, []string, error) {
+	switch {
+	case len(f.age) > 0 && len(f.gpg) > 0:
+		return "", nil, errors.New("encrypt with either --age-recipient or --gpg-recipient, not both")
+	case len(f.age) > 0:
+		return backup.ToolAge, f.age, nil
+	case len(f.gpg) > 0:
+		return backup.ToolGPG, f.gpg, nil
+	}
+	return "", nil, errors.New("--age-recipient or --gpg-recipient is required: backups are always encrypted")
+}
+
+// runBackup implements `secretsrc backup`, which writes the values of every
+// secret, or those under --prefix, to one age or gpg encrypted file
+func runBackup(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("backup", "--output <file> (--age-recipient <key> | --gpg-recipient <id>) [flags]", stdio)
+	clientOpts := addClientFlags(fs)
+	output := fs.String("output", "", "file to write the encrypted backup to; it must not exist yet (required)")
+	prefix := fs.String("prefix", "", "only back up secrets whose names start with this prefix")
+	recipients := &recipientFlags{}
+	fs.Func("age-recipient", "age public key (age1… or ssh-…) to encrypt to (repeatable)", func(value string) error {
+		recipients.age = append(recipients.age, value)
+		return nil
+	})
+	fs.Func("gpg-recipient", "gpg key ID or email to encrypt to (repeatable)", func(value string) error {
+		recipients.gpg = append(recipients.gpg, value)
+		return nil
+	})
+	confirmAccount := fs.String("confirm-account", "", "the account ID, to back up without being asked to type it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *output == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("--output is required")
+	}
+	tool, to, err := recipients.tool()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(*output); err == nil {
+		return fmt.Errorf("%s already exists; backups never replace a file", *output)
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	account, err := accountID(ctx, client)
+	if err != nil {
+		return err
+	}
+	secrets, err := inventory.New(client, inventory.DefaultPageSize).Find(ctx, *prefix)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		return fmt.Errorf("no secrets to back up in %s", client.GetRegion())
+	}
+
+	fmt.Fprintf(stdio.Stderr, "This reads the values of %d secrets in account %s (%s, %s) and writes them to %s, encrypted with %s to %s.\n",
+		len(secrets), account, client.GetProfile(), client.GetRegion(), *output, tool, strings.Join(to, ", "))
+	fmt.Fprintln(stdio.Stderr, "Anyone with one of those private keys can read every value in it.")
+	if err := confirmAccountID(account, *confirmAccount, "back up", stdio); err != nil {
+		return err
+	}
+
+	names := secretNames(secrets)
+	values, err := client.GetSecrets(ctx, names)
+	recordAudit(ctx, client, "backup", fmt.Sprintf("Read the values of %d secrets to back them up to %s", len(names), *output), err, stdio)
+	if err != nil {
+		return err
+	}
+	archive, err := backup.NewArchive(secrets, values)
+	if err != nil {
+		return err
+	}
+	archive.Account, archive.Profile, archive.Region = account, client.GetProfile(), client.GetRegion()
+	encrypted, err := backup.Encrypt(ctx, archive, tool, to)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create the backup: %w", err)
+	}
+	if _, err := file.Write(encrypted); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write the backup: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write the backup: %w", err)
+	}
+	fmt.Fprintf(stdio.Stderr, "Backed up %d secrets to %s\n", len(secrets), *output)
+	return nil
+}
+
+// runRestore implements `secretsrc restore`, which decrypts a backup and
+// creates its secrets, replacing the values of existing ones only with
+// --overwrite
+func runRestore(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("restore", "[flags] <backup file>", stdio)
+	clientOpts := addClientFlags(fs)
+	ageIdentity := fs.String("age-identity", "", "age identity file to decrypt an age backup with")
+	overwrite := fs.Bool("overwrite", false, "replace the values of secrets that exist with different ones")
+	confirmAccount := fs.String("confirm-account", "", "the account ID, to restore without being asked to type it")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one backup file")
+	}
+	path := positional[0]
+
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the backup: %w", err)
+	}
+	if len(encrypted) > maxBackupSize {
+		return fmt.Errorf("%s is over %d MiB, too large to be a backup", path, maxBackupSize>>20)
+	}
+	archive, err := backup.Decrypt(ctx, encrypted, *ageIdentity)
+	if err != nil {
+		return err
+	}
+	entries := archive.Entries()
+	schemas, err := compileSchemas()
+	if err != nil {
+		return err
+	}
+	var invalid []error
+	for _, entry := range entries {
+		invalid = append(invalid, schemas.Validate(entry.Name, entry.Value))
+	}
+	if err := errors.Join(invalid...); err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	account, err := accountID(ctx, client)
+	if err != nil {
+		return err
+	}
+	changes, err := planImport(ctx, client, entries, stdio)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdio.Stdout, "Restoring the backup of %s taken %s into %s (%s, %s)\n\n",
+		archive.Source(), archive.Created.Format("2006-01-02 15:04 MST"), account, client.GetProfile(), client.GetRegion())
+	writes := printImportPlan(stdio.Stdout, changes)
+
+	var existing []string
+	for _, change := range changes {
+		if !change.Create && !change.Unchanged() {
+			existing = append(existing, change.Entry.Name)
+		}
+	}
+	if len(existing) > 0 && !*overwrite {
+		err := fmt.Errorf("%d secrets already exist with other values (%s); pass --overwrite to replace them", len(existing), strings.Join(existing, ", "))
+		if clientOpts.dryRun {
+			fmt.Fprintf(stdio.Stderr, "Note: %v\n", err)
+			return nil
+		}
+		return err
+	}
+	if clientOpts.dryRun || writes == 0 {
+		return nil
+	}
+	fmt.Fprintf(stdio.Stderr, "This writes %d secrets to account %s (%s, %s).\n", writes, account, client.GetProfile(), client.GetRegion())
+	if err := confirmAccountID(account, *confirmAccount, "restore", stdio); err != nil {
+		return err
+	}
+	return writeImport(ctx, client, "restore", path, changes, stdio)
+}
+
+// accountID returns the ID of the account the client is signed in to, which
+// backup and restore are confirmed with
+func accountID(ctx context.Context, client *aws.Client) (string, error) {
+	identity, err := client.CallerIdentity(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the account: %w", err)
+	}
+	return identity.Account, nil
+}
+
+// compileSchemas compiles the schemas setting, which values are checked
+// against before they are written
+func compileSchemas() (*secretvalue.Schemas, error) {
+	cfg, _ := config.Load()
+	if cfg == nil {
+		return &secretvalue.Schemas{}, nil
+	}
+	return secretvalue.CompileSchemas(cfg.SchemaRules())
+}
+
+// confirmAccountID has the account ID typed before a backup or restore, or
+// given with --confirm-account when there is no terminal to type it on
+func confirmAccountID(account, given, action string, stdio IO) error {
+	if given != "" {
+		if given != account {
+			return fmt.Errorf("--confirm-account %s doesn't match account %s", given, account)
+		}
+		return nil
+	}
+	if !isTerminal(stdio.Stdin) {
+		return fmt.Errorf("pass --confirm-account %s to %s without a terminal to confirm on", account, action)
+	}
+
+	fmt.Fprintf(stdio.Stderr, "Type the account ID, %s, to %s: ", account, action)
+	line, err := bufio.NewReader(stdio.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(line) != account {
+		return fmt.Errorf("cancelled")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBackupNeedsOneKindOfRecipient(t *testing.T) {
+	if _, _, err := (&recipientFlags{}).tool(); err == nil || !strings.Contains(err.Error(), "always encrypted") {
+		t.Fatalf("expected a recipient to be required, got %v", err)
+	}
+	if _, _, err := (&recipientFlags{age: []string{"age1x"}, gpg: []string{"ops@example.com"}}).tool(); err == nil {
+		t.Fatal("expected age and gpg recipients together to be refused")
+	}
+	tool, to, err := (&recipientFlags{gpg: []string{"ops@example.com"}}).tool()
+	if err != nil || tool != "gpg" || len(to) != 1 {
+		t.Fatalf("expected gpg, got %s %v %v", tool, to, err)
+	}
+}
+
+func TestConfirmAccountIDWithoutATerminal(t *testing.T) {
+	stdio := IO{Stdin: strings.NewReader("123456789012\n"), Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	if err := confirmAccountID("123456789012", "", "restore", stdio); err == nil || !strings.Contains(err.Error(), "--confirm-account 123456789012") {
+		t.Fatalf("expected --confirm-account to be required without a terminal, got %v", err)
+	}
+	if err := confirmAccountID("123456789012", "210987654321", "restore", stdio); err == nil {
+		t.Fatal("expected another account's ID to be refused")
+	}
+	if err := confirmAccountID("123456789012", "123456789012", "restore", stdio); err != nil {
+		t.Fatalf("expected the matching ID to confirm, got %v", err)
+	}
+}
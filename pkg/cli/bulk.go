@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// defaultBulkLimit is how many secrets a pattern may match before get asks
+// for confirmation
+const defaultBulkLimit = 20
+
+// bulkFlags holds the flags for fetching several secrets with one get
+type bulkFlags struct {
+	output string
+	limit  int
+	yes    bool
+}
+
+// addBulkFlags registers --output, --max and --yes on fs
+func addBulkFlags(fs *flag.FlagSet) *bulkFlags {
+	f := &bulkFlags{}
+	fs.StringVar(&f.output, "output", "", "print a document keyed by secret name: json or yaml (default json for patterns)")
+	fs.IntVar(&f.limit, "max", defaultBulkLimit, "ask for confirmation when a pattern matches more secrets than this")
+	fs.BoolVar(&f.yes, "yes", false, "fetch every matching secret without asking for confirmation")
+	return f
+}
+
+// format returns the requested document format, or "" when --output is unset
+func (f *bulkFlags) format() (secretvalue.DocumentFormat, error) {
+	if f.output == "" {
+		return "", nil
+	}
+	return secretvalue.ParseDocumentFormat(f.output)
+}
+
+// runBulkGet fetches every secret matching pattern and prints them as one
+// document keyed by secret name
+func runBulkGet(ctx context.Context, client secretStore, pattern string, format secretvalue.DocumentFormat, keyOpts *keyFlags, opts *bulkFlags, stdio IO) error {
+	if format == "" {
+		format = secretvalue.FormatJSON
+	}
+
+	names := []string{pattern}
+	if inventory.IsPattern(pattern) {
+		matched, err := inventory.New(client, inventory.DefaultPageSize).Search(ctx, pattern)
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("no secrets match %q", pattern)
+		}
+
+		names = make([]string, len(matched))
+		for i, secret := range matched {
+			names[i] = secret.Name
+		}
+	}
+
+	if err := confirmBulk(len(names), opts, stdio); err != nil {
+		return err
+	}
+
+	values, err := client.GetSecrets(ctx, names)
+	recordAudit(ctx, client, "get", "Read the values of "+strings.Join(names, ", "), err, stdio)
+	if err != nil {
+		return err
+	}
+
+	doc := make(map[string]any, len(names))
+	for _, name := range names {
+		value := values[name]
+		if value.IsBinary() {
+			if keyOpts.key != "" {
+				return fmt.Errorf("--key cannot be used with binary secret %s", name)
+			}
+			doc[name] = base64.StdEncoding.EncodeToString(value.Binary)
+			continue
+		}
+
+		if keyOpts.key != "" {
+			result, err := extractKey(value.String, keyOpts)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			doc[name] = result
+			continue
+		}
+
+		if parsed, err := secretvalue.Parse(value.String); err == nil {
+			doc[name] = parsed
+		} else {
+			doc[name] = value.String
+		}
+	}
+
+	data, err := secretvalue.EncodeDocument(doc, format)
+	if err != nil {
+		return err
+	}
+	_, err = stdio.Stdout.Write(data)
+	return err
+}
+
+// confirmBulk asks before fetching more than --max secrets. Without a
+// terminal to ask on, it fails unless --yes was given.
+func confirmBulk(count int, opts *bulkFlags, stdio IO) error {
+	if count <= opts.limit || opts.yes {
+		return nil
+	}
+
+	if !isTerminal(stdio.Stdin) {
+		return fmt.Errorf("pattern matched %d secrets, more than --max %d; pass --yes or raise --max to fetch them", count, opts.limit)
+	}
+
+	fmt.Fprintf(stdio.Stderr, "Fetch %d secrets? [y/N] ", count)
+	line, err := bufio.NewReader(stdio.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("cancelled")
+	}
+	return nil
+}
+
+// isTerminal reports whether r is an interactive terminal
+func isTerminal(r any) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
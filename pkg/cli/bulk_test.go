@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmBulkRequiresYesWithoutTerminal(t *testing.T) {
+	stdio := IO{Stdin: strings.NewReader("y\n"), Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+
+	if err := confirmBulk(5, &bulkFlags{limit: 20}, stdio); err != nil {
+		t.Fatalf("expected counts within --max to pass, got %v", err)
+	}
+	if err := confirmBulk(25, &bulkFlags{limit: 20}, stdio); err == nil {
+		t.Fatal("expected a large match without a terminal to be refused")
+	}
+	if err := confirmBulk(25, &bulkFlags{limit: 20, yes: true}, stdio); err != nil {
+		t.Fatalf("expected --yes to skip confirmation, got %v", err)
+	}
+}
+
+func TestParseInterspersedAllowsTrailingFlags(t *testing.T) {
+	fs := newFlagSet("get", "", IO{Stderr: &bytes.Buffer{}})
+	output := fs.String("output", "", "")
+
+	positional, err := parseInterspersed(fs, []string{"app/prod/*", "--output", "yaml", "--", "--literal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *output != "yaml" {
+		t.Fatalf("expected --output after the pattern to be parsed, got %q", *output)
+	}
+	if len(positional) != 2 || positional[0] != "app/prod/*" || positional[1] != "--literal" {
+		t.Fatalf("unexpected positional arguments %v", positional)
+	}
+}
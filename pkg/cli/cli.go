@@ -0,0 +1,330 @@
+// Package cli implements the non-interactive secretsrc subcommands.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// IO bundles the streams a command reads from and writes to
+type IO struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// command is a single CLI subcommand
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string, stdio IO) error
+}
+
+// commands maps subcommand names to their implementations
+var commands = map[string]command{
+	"get": {
+		summary: "Print a secret value (or a single key with --key)",
+		run:     runGet,
+	},
+	"put": {
+		summary: "Write the value piped to stdin as a secret's new version",
+		run:     runPut,
+	},
+	"env": {
+		summary: "Print secret fields as dotenv lines",
+		run:     runEnv,
+	},
+	"exec": {
+		summary: "Run a command with secret fields injected as environment variables",
+		run:     runExec,
+	},
+	"list": {
+		summary: "Print the names of secrets, or their metadata with --output json",
+		run:     runList,
+	},
+	"describe": {
+		summary: "Print a secret's metadata and versions (never its value)",
+		run:     runDescribe,
+	},
+	"export": {
+		summary: "Print a CSV or JSON report of secret metadata (never values)",
+		run:     runExport,
+	},
+	"k8s-secret": {
+		summary: "Print a secret as a Kubernetes Secret manifest",
+		run:     runK8sSecret,
+	},
+	"import": {
+		summary: "Create or update the secrets of a YAML/JSON manifest, showing what changes first",
+		run:     runImport,
+	},
+	"backup": {
+		summary: "Write every secret's value to one age/GPG-encrypted file, after typing the account ID",
+		run:     runBackup,
+	},
+	"restore": {
+		summary: "Create the secrets of an encrypted backup, showing what changes first",
+		run:     runRestore,
+	},
+	"diff-accounts": {
+		summary: "Compare the secrets in two profiles/regions and report drift",
+		run:     runDiffAccounts,
+	},
+}
+
+// exitError carries a specific process exit code back to Run
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// IsCommand reports whether name is a known subcommand
+func IsCommand(name string) bool {
+	if name == "help" || name == "-h" || name == "--help" {
+		return true
+	}
+	_, ok := commands[name]
+	return ok
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(ctx context.Context, args []string, stdio IO) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stdio.Stdout)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stdio.Stderr, "Error: unknown command %q\n\n", args[0])
+		printUsage(stdio.Stderr)
+		return 2
+	}
+
+	if err := cmd.run(ctx, args[1:], stdio); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			return exitErr.code
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(logging.Writer(stdio.Stderr), "Error: %v\n", err)
+		if aws.IsClockSkewError(err) {
+			reportClockSkew(ctx, stdio)
+		}
+		return 1
+	}
+	return 0
+}
+
+// reportClockSkew explains a signature error caused by a wrong system clock,
+// which AWS reports only as an expired or invalid request
+func reportClockSkew(ctx context.Context, stdio IO) {
+	skew, err := aws.MeasureClockSkew(ctx, aws.GetDefaultRegion())
+	if err != nil {
+		return
+	}
+	if message := aws.ClockSkewMessage(skew); message != "" {
+		fmt.Fprintln(stdio.Stderr, message)
+	}
+}
+
+// DefaultIO returns an IO wired to the process standard streams
+func DefaultIO() IO {
+	return IO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+// IsInteractive reports whether stdio's output is a terminal, where the UI
+// can be drawn, rather than a pipe or file
+func IsInteractive(stdio IO) bool {
+	return isTerminal(stdio.Stdout)
+}
+
+// printUsage writes the list of subcommands
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: secretsrc [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run without a command to start the interactive UI.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet creates a flag set for a subcommand that reports errors instead of exiting
+func newFlagSet(name, usage string, stdio IO) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stdio.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stdio.Stderr, "Usage: secretsrc %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseInterspersed parses fs from args, allowing flags after positional
+// arguments (`secretsrc get app/db --key password`). Arguments after "--" are
+// always positional. It returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		consumed := len(args) - len(rest)
+		if len(rest) == 0 || (consumed > 0 && args[consumed-1] == "--") {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// clientFlags holds the flags shared by every command that talks to AWS
+type clientFlags struct {
+	profile     string
+	region      string
+	endpointURL string
+	debug       bool
+	dryRun      bool
+	azureVault  string // Set by the commands that take --azure-vault
+}
+
+// addClientFlags registers --profile, --region, --endpoint-url, --debug and
+// --dry-run on fs
+func addClientFlags(fs *flag.FlagSet) *clientFlags {
+	f := &clientFlags{}
+	fs.StringVar(&f.profile, "profile", aws.GetDefaultProfile(), "AWS profile to use")
+	fs.StringVar(&f.region, "region", aws.GetDefaultRegion(), "AWS region to use")
+	fs.StringVar(&f.endpointURL, "endpoint-url", "", endpointURLUsage)
+	fs.BoolVar(&f.debug, "debug", false, "write debug logging to stderr (secret values are redacted)")
+	fs.BoolVar(&f.dryRun, "dry-run", false, "print what would be created, updated, deleted or tagged, with the keys that change, and change nothing")
+	return f
+}
+
+// dryRunContext returns ctx, or with --dry-run, a context in which the
+// client prints each write to stdout instead of making it
+func (f *clientFlags) dryRunContext(ctx context.Context, stdio IO) context.Context {
+	if !f.dryRun {
+		return ctx
+	}
+	return aws.WithDryRun(ctx, func(change aws.DryRunChange) {
+		fmt.Fprintf(stdio.Stdout, "Dry run: %s\n", change.Action)
+	})
+}
+
+// addVaultFlag registers --azure-vault on fs, for the commands that can work
+// on an Azure key vault instead of Secrets Manager
+func addVaultFlag(fs *flag.FlagSet, f *clientFlags) {
+	fs.StringVar(&f.azureVault, "azure-vault", "", "Azure key vault to use instead of Secrets Manager, by name or URL; signs in with Azure credentials from the environment, a managed identity or the Azure CLI")
+}
+
+// endpointURLUsage describes --endpoint-url, which diff-accounts registers too
+const endpointURLUsage = "Secrets Manager endpoint to use instead of AWS's, e.g. http://localhost:4566 for LocalStack"
+
+// outputFormat is how list and describe print their results
+type outputFormat string
+
+const (
+	outputText outputFormat = "text"
+	outputJSON outputFormat = "json"
+)
+
+// addOutputFlag registers --output for text or JSON output on fs
+func addOutputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", string(outputText), "output format: text or json, whose fields stay stable between releases")
+}
+
+// parseOutputFormat converts the value of --output to an outputFormat
+func parseOutputFormat(name string) (outputFormat, error) {
+	switch format := outputFormat(strings.ToLower(name)); format {
+	case outputText, outputJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported output format %q (expected text or json)", name)
+}
+
+// keyFlags holds the --key/--default pair used to extract a single value from a secret
+type keyFlags struct {
+	key          string
+	defaultValue string
+	hasDefault   bool
+}
+
+// addKeyFlags registers --key and --default on fs
+func addKeyFlags(fs *flag.FlagSet) *keyFlags {
+	f := &keyFlags{}
+	fs.StringVar(&f.key, "key", "", "JSONPath-style key to extract from a JSON secret (e.g. db.hosts[0].name)")
+	fs.Func("default", "value to use when --key does not resolve", func(value string) error {
+		f.defaultValue = value
+		f.hasDefault = true
+		return nil
+	})
+	return f
+}
+
+// validate checks that --default is only used alongside --key
+func (f *keyFlags) validate() error {
+	if f.hasDefault && f.key == "" {
+		return fmt.Errorf("--default requires --key")
+	}
+	return nil
+}
+
+// mappingFlags holds the flags that adjust environment variable naming
+type mappingFlags struct {
+	mapping secretvalue.EnvMapping
+}
+
+// addMappingFlags registers the env var naming flags on fs
+func addMappingFlags(fs *flag.FlagSet) *mappingFlags {
+	f := &mappingFlags{mapping: secretvalue.EnvMapping{Rename: map[string]string{}}}
+	fs.StringVar(&f.mapping.Prefix, "prefix", "", "prefix added to every variable name")
+	fs.BoolVar(&f.mapping.UpperSnake, "upper-snake", false, "convert field keys to UPPER_SNAKE_CASE")
+	fs.Func("rename", "rename a field as key=NAME (repeatable)", func(value string) error {
+		key, name, ok := strings.Cut(value, "=")
+		if !ok || key == "" || name == "" {
+			return fmt.Errorf("expected key=NAME, got %q", value)
+		}
+		f.mapping.Rename[key] = name
+		return nil
+	})
+	fs.Func("exclude", "skip a field by key or glob pattern (repeatable)", func(value string) error {
+		f.mapping.Exclude = append(f.mapping.Exclude, value)
+		return nil
+	})
+	return f
+}
+
+// resolve layers the flag rules over the env mapping from the config file
+func (f *mappingFlags) resolve() secretvalue.EnvMapping {
+	cfg, _ := config.Load()
+	if cfg == nil {
+		return f.mapping
+	}
+	return cfg.Env.Merge(f.mapping)
+}
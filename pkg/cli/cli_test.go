@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+func TestRunRedactsSecretsFromErrors(t *testing.T) {
+	logging.TrackSecret("s3cr3t-value")
+
+	commands["leaky"] = command{run: func(ctx context.Context, args []string, stdio IO) error {
+		return fmt.Errorf("unexpected value %q", "s3cr3t-value")
+	}}
+	defer delete(commands, "leaky")
+
+	var stderr bytes.Buffer
+	code := Run(context.Background(), []string{"leaky"}, IO{Stdout: &bytes.Buffer{}, Stderr: &stderr})
+
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if strings.Contains(stderr.String(), "s3cr3t-value") || !strings.Contains(stderr.String(), logging.Redacted) {
+		t.Fatalf("expected the secret to be redacted, got %q", stderr.String())
+	}
+}
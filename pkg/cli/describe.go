@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// description is a secret's metadata as `describe --output json` prints it.
+// Dates are omitted when AWS didn't return them.
+type description struct {
+	Name            string               `json:"name"`
+	ARN             string               `json:"arn"`
+	Description     string               `json:"description,omitempty"`
+	Tags            map[string]string    `json:"tags,omitempty"`
+	KMSKey          string               `json:"kms_key"`
+	DefaultKey      bool                 `json:"default_kms_key"`
+	Created         *time.Time           `json:"created,omitempty"`
+	LastChanged     *time.Time           `json:"last_changed,omitempty"`
+	LastAccessed    *time.Time           `json:"last_accessed,omitempty"`
+	LastRotated     *time.Time           `json:"last_rotated,omitempty"`
+	NextRotation    *time.Time           `json:"next_rotation,omitempty"`
+	RotationEnabled bool                 `json:"rotation_enabled"`
+	OwningService   string               `json:"owning_service,omitempty"`
+	Versions        []descriptionVersion `json:"versions"`
+}
+
+// descriptionVersion is a version of a secret and its staging labels
+type descriptionVersion struct {
+	ID     string   `json:"id"`
+	Stages []string `json:"stages"`
+}
+
+// runDescribe implements `secretsrc describe`
+func runDescribe(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("describe", "[flags] <secret-name>", stdio)
+	clientOpts := addClientFlags(fs)
+	output := addOutputFlag(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one secret name")
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	details, err := client.DescribeSecret(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if format == outputJSON {
+		data, err := encodeDescription(details)
+		if err != nil {
+			return err
+		}
+		_, err = stdio.Stdout.Write(data)
+		return err
+	}
+	writeDescription(stdio.Stdout, details)
+	return nil
+}
+
+// encodeDescription renders a secret's metadata as JSON
+func encodeDescription(details *models.SecretDetails) ([]byte, error) {
+	doc := description{
+		Name:            details.Name,
+		ARN:             details.ARN,
+		Description:     details.Description,
+		Tags:            details.Tags,
+		KMSKey:          kmsKeyName(details.KmsKeyID),
+		DefaultKey:      models.UsesDefaultKey(details.KmsKeyID),
+		Created:         details.CreatedDate,
+		LastChanged:     details.LastChangedDate,
+		LastAccessed:    details.LastAccessedDate,
+		LastRotated:     details.LastRotatedDate,
+		NextRotation:    details.NextRotationDate,
+		RotationEnabled: details.RotationEnabled,
+		OwningService:   details.OwningService,
+		Versions:        make([]descriptionVersion, len(details.Versions)),
+	}
+	for i, version := range details.Versions {
+		doc.Versions[i] = descriptionVersion{ID: version.ID, Stages: version.Stages}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// writeDescription prints a secret's metadata as aligned "label: value"
+// lines for people to read
+func writeDescription(w io.Writer, details *models.SecretDetails) {
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%-15s %s\n", label+":", value)
+		}
+	}
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	line("Name", details.Name)
+	line("ARN", details.ARN)
+	line("Description", details.Description)
+	line("KMS key", kmsKeyName(details.KmsKeyID))
+	line("Created", date(details.CreatedDate))
+	line("Last changed", date(details.LastChangedDate))
+	line("Last accessed", date(details.LastAccessedDate))
+	if details.RotationEnabled {
+		line("Last rotated", date(details.LastRotatedDate))
+		line("Next rotation", date(details.NextRotationDate))
+	} else {
+		line("Rotation", "disabled")
+	}
+	line("Managed by", details.OwningService)
+
+	keys := make([]string, 0, len(details.Tags))
+	for key := range details.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		line("Tag", key+"="+details.Tags[key])
+	}
+	for _, version := range details.Versions {
+		line("Version", version.ID+" "+strings.Join(version.Stages, ", "))
+	}
+}
+
+// kmsKeyName is a secret's KMS key as AWS reports it, or aws/secretsmanager
+// for the default key, as in export reports
+func kmsKeyName(keyID string) string {
+	if keyID == "" {
+		return models.DefaultKMSKey
+	}
+	return keyID
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestDescriptionOutputs(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	details := &models.SecretDetails{
+		Name:        "app/db",
+		ARN:         "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf",
+		Tags:        map[string]string{"team": "platform"},
+		CreatedDate: &created,
+		Versions:    []models.SecretVersion{{ID: "v2", Stages: []string{"AWSCURRENT"}}},
+	}
+
+	data, err := encodeDescription(details)
+	if err != nil {
+		t.Fatalf("encodeDescription returned error: %v", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("expected valid JSON, got %v: %s", err, data)
+	}
+	if doc["name"] != "app/db" || doc["kms_key"] != models.DefaultKMSKey || doc["default_kms_key"] != true || doc["created"] != "2024-03-01T12:00:00Z" {
+		t.Fatalf("unexpected fields: %s", data)
+	}
+	if _, ok := doc["last_rotated"]; ok {
+		t.Fatalf("expected dates AWS didn't return to be left out: %s", data)
+	}
+	if doc["rotation_enabled"] != false || len(doc["versions"].([]any)) != 1 {
+		t.Fatalf("expected rotation and versions to be present: %s", data)
+	}
+
+	var text bytes.Buffer
+	writeDescription(&text, details)
+	for _, want := range []string{"Name:           app/db", "Rotation:       disabled", "Tag:            team=platform", "Version:        v2 AWSCURRENT"} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("expected %q in:\n%s", want, text.String())
+		}
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	if format, err := parseOutputFormat("JSON"); err != nil || format != outputJSON {
+		t.Fatalf("expected json, got %q, %v", format, err)
+	}
+	if _, err := parseOutputFormat("yaml"); err == nil {
+		t.Fatal("expected unsupported formats to be refused")
+	}
+}
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// inventoryDiff is the result of comparing the secret names of two accounts
+type inventoryDiff struct {
+	onlyA   []string
+	onlyB   []string
+	both    []string
+	changed []string // Names in both whose values differ (only with --values)
+}
+
+// count returns the number of differences found
+func (d *inventoryDiff) count() int {
+	return len(d.onlyA) + len(d.onlyB) + len(d.changed)
+}
+
+// runDiffAccounts implements `secretsrc diff-accounts`
+func runDiffAccounts(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("diff-accounts", "--a <profile> --b <profile> [flags]", stdio)
+	profileA := fs.String("a", "", "AWS profile for the first account (required)")
+	profileB := fs.String("b", "", "AWS profile for the second account (required)")
+	regionA := fs.String("a-region", aws.GetDefaultRegion(), "AWS region for the first account")
+	regionB := fs.String("b-region", aws.GetDefaultRegion(), "AWS region for the second account")
+	prefix := fs.String("prefix", "", "only compare secrets whose names start with this prefix")
+	values := fs.Bool("values", false, "also fetch and compare the values of secrets present in both")
+	endpointURL := fs.String("endpoint-url", "", endpointURLUsage)
+	debug := fs.Bool("debug", false, "write debug logging to stderr (secret values are redacted)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *profileA == "" || *profileB == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("--a and --b are required")
+	}
+
+	clientA, err := newClient(ctx, &clientFlags{profile: *profileA, region: *regionA, endpointURL: *endpointURL, debug: *debug}, stdio)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *profileA, err)
+	}
+	clientB, err := newClient(ctx, &clientFlags{profile: *profileB, region: *regionB, endpointURL: *endpointURL, debug: *debug}, stdio)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *profileB, err)
+	}
+
+	secretsA, err := inventory.New(clientA, inventory.DefaultPageSize).Find(ctx, *prefix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *profileA, err)
+	}
+	secretsB, err := inventory.New(clientB, inventory.DefaultPageSize).Find(ctx, *prefix)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *profileB, err)
+	}
+
+	diff := diffInventories(secretNames(secretsA), secretNames(secretsB))
+	if *values {
+		valuesA, err := clientA.GetSecrets(ctx, diff.both)
+		recordAudit(ctx, clientA, "diff-accounts", "Read the values of "+strings.Join(diff.both, ", ")+" to compare them", err, stdio)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *profileA, err)
+		}
+		valuesB, err := clientB.GetSecrets(ctx, diff.both)
+		recordAudit(ctx, clientB, "diff-accounts", "Read the values of "+strings.Join(diff.both, ", ")+" to compare them", err, stdio)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *profileB, err)
+		}
+		for _, name := range diff.both {
+			if !sameValue(valuesA[name], valuesB[name]) {
+				diff.changed = append(diff.changed, name)
+			}
+		}
+	}
+
+	labelA := fmt.Sprintf("%s (%s)", *profileA, clientA.GetRegion())
+	labelB := fmt.Sprintf("%s (%s)", *profileB, clientB.GetRegion())
+	printDiff(stdio, diff, labelA, labelB, *values)
+
+	// Like diff(1), differences exit 1 so drift can fail a pipeline
+	if diff.count() > 0 {
+		return &exitError{code: 1}
+	}
+	return nil
+}
+
+// secretNames returns the names of secrets
+func secretNames(secrets []models.Secret) []string {
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.Name
+	}
+	return names
+}
+
+// diffInventories splits two lists of names into those only in a, only in b,
+// and in both, each sorted
+func diffInventories(a, b []string) inventoryDiff {
+	inB := make(map[string]bool, len(b))
+	for _, name := range b {
+		inB[name] = true
+	}
+
+	var diff inventoryDiff
+	inA := make(map[string]bool, len(a))
+	for _, name := range a {
+		inA[name] = true
+		if inB[name] {
+			diff.both = append(diff.both, name)
+		} else {
+			diff.onlyA = append(diff.onlyA, name)
+		}
+	}
+	for _, name := range b {
+		if !inA[name] {
+			diff.onlyB = append(diff.onlyB, name)
+		}
+	}
+
+	sort.Strings(diff.onlyA)
+	sort.Strings(diff.onlyB)
+	sort.Strings(diff.both)
+	return diff
+}
+
+// sameValue reports whether a secret's values in the two accounts match.
+// JSON values are compared by content, so key order and whitespace do not
+// count as drift.
+func sameValue(valueA, valueB *models.SecretValue) bool {
+	if valueA.IsBinary() || valueB.IsBinary() {
+		return valueA.IsBinary() == valueB.IsBinary() && bytes.Equal(valueA.Binary, valueB.Binary)
+	}
+	if valueA.String == valueB.String {
+		return true
+	}
+
+	docA, errA := secretvalue.Parse(valueA.String)
+	docB, errB := secretvalue.Parse(valueB.String)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return reflect.DeepEqual(docA, docB)
+}
+
+// printDiff writes a summary of the differences. Values are never printed.
+func printDiff(stdio IO, diff inventoryDiff, labelA, labelB string, comparedValues bool) {
+	out := stdio.Stdout
+	fmt.Fprintf(out, "Comparing %s with %s\n", labelA, labelB)
+
+	section := func(title, marker string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(out, "\n%s (%d):\n", title, len(names))
+		for _, name := range names {
+			fmt.Fprintf(out, "  %s %s\n", marker, name)
+		}
+	}
+	section("Only in "+labelA, "-", diff.onlyA)
+	section("Only in "+labelB, "+", diff.onlyB)
+	section("Values differ", "~", diff.changed)
+
+	summary := fmt.Sprintf("\n%d differences, %d secrets in both", diff.count(), len(diff.both))
+	if !comparedValues {
+		summary += " (names only; use --values to compare values)"
+	}
+	fmt.Fprintln(out, summary)
+}
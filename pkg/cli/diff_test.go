@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffInventories(t *testing.T) {
+	diff := diffInventories(
+		[]string{"prod/db", "shared/api", "only/a"},
+		[]string{"shared/api", "only/b", "prod/db", "also/b"},
+	)
+
+	if want := []string{"only/a"}; !reflect.DeepEqual(diff.onlyA, want) {
+		t.Fatalf("onlyA = %v, want %v", diff.onlyA, want)
+	}
+	if want := []string{"also/b", "only/b"}; !reflect.DeepEqual(diff.onlyB, want) {
+		t.Fatalf("onlyB = %v, want %v", diff.onlyB, want)
+	}
+	if want := []string{"prod/db", "shared/api"}; !reflect.DeepEqual(diff.both, want) {
+		t.Fatalf("both = %v, want %v", diff.both, want)
+	}
+	if diff.count() != 3 {
+		t.Fatalf("count = %d, want 3", diff.count())
+	}
+}
+
+func TestDiffInventoriesIdentical(t *testing.T) {
+	diff := diffInventories([]string{"a", "b"}, []string{"b", "a"})
+	if diff.count() != 0 {
+		t.Fatalf("expected no differences, got %+v", diff)
+	}
+}
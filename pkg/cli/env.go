@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// runEnv implements `secretsrc env`
+func runEnv(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("env", "[flags] <secret-name>", stdio)
+	clientOpts := addClientFlags(fs)
+	keyOpts := addKeyFlags(fs)
+	mappingOpts := addMappingFlags(fs)
+	shell := fs.Bool("shell", false, "prefix each line with `export` so the output can be eval'd")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := keyOpts.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one secret name")
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+
+	value, err := client.GetSecretValue(ctx, positional[0])
+	recordAudit(ctx, client, "env", "Read the value of "+positional[0], err, stdio)
+	if err != nil {
+		return err
+	}
+
+	doc, err := extractKey(value, keyOpts)
+	if err != nil {
+		return err
+	}
+
+	fields, err := secretvalue.Fields(doc)
+	if err != nil {
+		return err
+	}
+
+	vars, err := mappingOpts.resolve().Apply(fields)
+	if err != nil {
+		return err
+	}
+
+	output := secretvalue.FormatDotenv(vars)
+	if *shell {
+		lines := strings.SplitAfter(output, "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = "export " + line
+			}
+		}
+		output = strings.Join(lines, "")
+	}
+
+	fmt.Fprint(stdio.Stdout, output)
+	return nil
+}
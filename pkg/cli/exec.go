@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// runExec implements `secretsrc exec`
+func runExec(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("exec", "(--secret <name> [--secret <name>...] | --app <name>) [flags] -- <command> [args...]", stdio)
+	clientOpts := addClientFlags(fs)
+	keyOpts := addKeyFlags(fs)
+	mappingOpts := addMappingFlags(fs)
+	var secretNames []string
+	fs.Func("secret", "secret to inject into the command environment (repeatable; later secrets override earlier keys)", func(value string) error {
+		secretNames = append(secretNames, value)
+		return nil
+	})
+	envName := fs.String("env", "", "environment variable name to use when the secret (or --key) is not a JSON object")
+	appName := fs.String("app", "", "app from the config file whose secrets to inject, composed as its apps entry says, instead of --secret")
+	watchOpts := addWatchFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := watchOpts.validate(); err != nil {
+		return err
+	}
+	if err := keyOpts.validate(); err != nil {
+		return err
+	}
+	app, err := loadApp(*appName)
+	if err != nil {
+		return err
+	}
+	if *appName != "" {
+		if len(secretNames) > 0 || keyOpts.key != "" || *envName != "" {
+			return fmt.Errorf("--app can't be combined with --secret, --key or --env")
+		}
+		secretNames = app.SecretNames()
+	}
+	if len(secretNames) == 0 {
+		fs.Usage()
+		return fmt.Errorf("--secret or --app is required")
+	}
+	if *envName != "" && len(secretNames) > 1 {
+		return fmt.Errorf("--env can only be used with a single --secret")
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no command given")
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+
+	mapping := mappingOpts.resolve()
+	resolve := func(ctx context.Context) ([]string, error) {
+		values := make([]string, len(secretNames))
+		for i, name := range secretNames {
+			value, err := client.GetSecretValue(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			values[i] = value
+		}
+		if *appName != "" {
+			return appEnvVars(app, values, mapping)
+		}
+		return envVarsForSecrets(secretNames, values, keyOpts, *envName, mapping)
+	}
+
+	// Only the first read is recorded, not the polls of --watch
+	vars, err := resolve(ctx)
+	recordAudit(ctx, client, "exec", "Read the values of "+strings.Join(secretNames, ", "), err, stdio)
+	if err != nil {
+		return err
+	}
+
+	if watchOpts.enabled {
+		return runWatched(ctx, fs.Args(), vars, resolve, watchOpts, stdio)
+	}
+	return runWithEnv(fs.Args(), vars, stdio)
+}
+
+// envVarsForSecrets converts secrets (or the part of each selected by --key)
+// into KEY=VALUE pairs. JSON objects contribute one variable per top-level
+// field, with later secrets overriding keys from earlier ones, named
+// according to mapping. With envName, the single secret is assigned to that
+// variable as-is.
+func envVarsForSecrets(names, values []string, keyOpts *keyFlags, envName string, mapping secretvalue.EnvMapping) ([]string, error) {
+	if envName != "" {
+		if keyOpts.key == "" {
+			// Plain (possibly non-JSON) secret assigned to a single variable
+			return []string{envName + "=" + values[0]}, nil
+		}
+
+		doc, err := extractKey(values[0], keyOpts)
+		if err != nil {
+			return nil, err
+		}
+		return []string{envName + "=" + secretvalue.Format(doc)}, nil
+	}
+
+	layers := make([][]secretvalue.Field, 0, len(values))
+	for i, value := range values {
+		doc, err := extractKey(value, keyOpts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", names[i], err)
+		}
+
+		fields, err := secretvalue.Fields(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w; use --env to name the variable", names[i], err)
+		}
+		layers = append(layers, fields)
+	}
+
+	envVars, err := mapping.Apply(secretvalue.MergeFields(layers...))
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make([]string, 0, len(envVars))
+	for _, v := range envVars {
+		vars = append(vars, v.String())
+	}
+	return vars, nil
+}
+
+// loadApp returns the app named in the config file, or an empty app when
+// name is empty
+func loadApp(name string) (secretvalue.App, error) {
+	if name == "" {
+		return secretvalue.App{}, nil
+	}
+	cfg, err := config.Load()
+	if cfg == nil {
+		return secretvalue.App{}, fmt.Errorf("failed to load the config file: %w", err)
+	}
+	app, ok := cfg.App(name)
+	if !ok {
+		return secretvalue.App{}, fmt.Errorf("no app named %q in the config file's apps", name)
+	}
+	return app, nil
+}
+
+// appEnvVars composes an app's environment from the values of its secrets
+func appEnvVars(app secretvalue.App, values []string, mapping secretvalue.EnvMapping) ([]string, error) {
+	envVars, err := app.Compose(values, mapping)
+	if err != nil {
+		return nil, err
+	}
+	vars := make([]string, len(envVars))
+	for i, v := range envVars {
+		vars[i] = v.String()
+	}
+	return vars, nil
+}
+
+// runWithEnv runs argv with vars appended to the current environment,
+// forwarding interrupt signals and propagating the child's exit code
+func runWithEnv(argv []string, vars []string, stdio IO) error {
+	proc, err := startChild(argv, vars, stdio)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case sig := <-signals:
+			_ = proc.cmd.Process.Signal(sig)
+		case err := <-proc.done:
+			return exitStatus(err)
+		}
+	}
+}
+
+// child is a running command started by exec
+type child struct {
+	cmd    *exec.Cmd
+	done   chan error    // Receives the result of Wait once the process exits
+	exited chan struct{} // Closed once the process exits
+}
+
+// startChild starts argv with vars appended to the current environment
+func startChild(argv []string, vars []string, stdio IO) (*child, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), vars...)
+	cmd.Stdin = stdio.Stdin
+	cmd.Stdout = stdio.Stdout
+	cmd.Stderr = stdio.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", argv[0], err)
+	}
+
+	proc := &child{cmd: cmd, done: make(chan error, 1), exited: make(chan struct{})}
+	go func() {
+		err := cmd.Wait()
+		close(proc.exited)
+		proc.done <- err
+	}()
+	return proc, nil
+}
+
+// exitStatus converts the result of Wait into the error Run reports,
+// carrying the child's exit code through
+func exitStatus(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			// Terminated by a signal
+			code = 1
+		}
+		return &exitError{code: code}
+	}
+	return err
+}
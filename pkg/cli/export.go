@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+)
+
+// runExport implements `secretsrc export`
+func runExport(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("export", "[flags]", stdio)
+	clientOpts := addClientFlags(fs)
+	formatName := fs.String("format", "csv", "report format: csv or json")
+	output := fs.String("output", "", "the same as --format, as the other commands name it")
+	prefix := fs.String("prefix", "", "only include secrets whose names start with this prefix")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *output != "" {
+		formatName = output
+	}
+	format, err := inventory.ParseReportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := inventory.New(client, inventory.DefaultPageSize).Find(ctx, *prefix)
+	if err != nil {
+		return err
+	}
+
+	data, err := inventory.EncodeReport(secrets, format)
+	if err != nil {
+		return err
+	}
+	_, err = stdio.Stdout.Write(data)
+	return err
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// runGet implements `secretsrc get`
+func runGet(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("get", "[flags] <secret-name-or-pattern>", stdio)
+	clientOpts := addClientFlags(fs)
+	addVaultFlag(fs, clientOpts)
+	keyOpts := addKeyFlags(fs)
+	bulkOpts := addBulkFlags(fs)
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := keyOpts.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one secret name or pattern")
+	}
+	name := positional[0]
+
+	format, err := bulkOpts.format()
+	if err != nil {
+		return err
+	}
+
+	client, err := newStore(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+
+	// Patterns and --output produce one document keyed by secret name
+	if inventory.IsPattern(name) || format != "" {
+		return runBulkGet(ctx, client, name, format, keyOpts, bulkOpts, stdio)
+	}
+
+	value, err := client.GetSecret(ctx, name)
+	recordAudit(ctx, client, "get", "Read the value of "+name, err, stdio)
+	if err != nil {
+		return err
+	}
+
+	if value.IsBinary() {
+		if keyOpts.key != "" {
+			return fmt.Errorf("--key cannot be used with binary secret %s", name)
+		}
+		// Write the bytes untouched so the output can be redirected to a file
+		_, err := stdio.Stdout.Write(value.Binary)
+		return err
+	}
+
+	if keyOpts.key == "" {
+		fmt.Fprintln(stdio.Stdout, value.String)
+		return nil
+	}
+
+	result, err := extractKey(value.String, keyOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdio.Stdout, secretvalue.Format(result))
+	return nil
+}
+
+// extractKey resolves --key against a secret value, falling back to --default
+// when the key is absent
+func extractKey(value string, keyOpts *keyFlags) (any, error) {
+	doc, err := secretvalue.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if keyOpts.key == "" {
+		return doc, nil
+	}
+
+	result, err := secretvalue.Lookup(doc, keyOpts.key)
+	if errors.Is(err, secretvalue.ErrKeyNotFound) && keyOpts.hasDefault {
+		return keyOpts.defaultValue, nil
+	}
+	return result, err
+}
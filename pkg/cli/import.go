@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// runImport implements `secretsrc import`, which creates or updates the
+// secrets of a YAML or JSON manifest after printing what would change
+func runImport(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("import", "[flags] <manifest.yaml|manifest.json>", stdio)
+	clientOpts := addClientFlags(fs)
+	yes := fs.Bool("yes", false, "write without asking for confirmation")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one manifest file")
+	}
+	path := positional[0]
+
+	entries, err := secretvalue.ReadManifest(path)
+	if err != nil {
+		return err
+	}
+	schemas, err := compileSchemas()
+	if err != nil {
+		return err
+	}
+	var invalid []error
+	for _, entry := range entries {
+		invalid = append(invalid, schemas.Validate(entry.Name, entry.Value))
+	}
+	if err := errors.Join(invalid...); err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	changes, err := planImport(ctx, client, entries, stdio)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdio.Stdout, "Importing %s into %s (%s)\n\n", path, client.GetProfile(), client.GetRegion())
+	writes := printImportPlan(stdio.Stdout, changes)
+	if clientOpts.dryRun || writes == 0 {
+		return nil
+	}
+	if err := confirmImport(writes, *yes, stdio); err != nil {
+		return err
+	}
+	return writeImport(ctx, client, "import", path, changes, stdio)
+}
+
+// writeImport writes each secret the plan changes, carrying on past the
+// ones that fail, and reports each on stderr. command and from are for the
+// audit log.
+func writeImport(ctx context.Context, client *aws.Client, command, from string, changes []secretvalue.ImportChange, stdio IO) error {
+	failed, writes := 0, 0
+	for _, change := range changes {
+		if change.Unchanged() {
+			continue
+		}
+		writes++
+		entry := change.Entry
+		if change.Owner != "" {
+			// planImport refuses these; a secret is never overwritten past it
+			failed++
+			fmt.Fprintf(stdio.Stderr, "Failed %s: managed by %s\n", entry.Name, models.OwningServiceName(change.Owner))
+			continue
+		}
+		secretCopy := &aws.SecretCopy{Name: entry.Name, Description: entry.Description, Tags: entry.Tags, Value: entry.Value}
+		created, err := client.WriteSecretCopy(ctx, secretCopy, !change.Create)
+		action := "Updated " + entry.Name
+		if created {
+			action = "Created " + entry.Name
+		}
+		recordAudit(ctx, client, command, action+" from "+from, err, stdio)
+		if err != nil {
+			failed++
+			fmt.Fprintf(stdio.Stderr, "Failed %s: %v\n", entry.Name, err)
+			continue
+		}
+		fmt.Fprintln(stdio.Stderr, action)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d secrets failed to write", failed, writes)
+	}
+	return nil
+}
+
+// planImport compares each entry with the current value of its secret. It
+// refuses to change secrets another AWS service manages.
+func planImport(ctx context.Context, client *aws.Client, entries []secretvalue.ManifestEntry, stdio IO) ([]secretvalue.ImportChange, error) {
+	changes, read, err := secretvalue.PlanManifest(ctx, client, entries)
+	if len(read) > 0 {
+		recordAudit(ctx, client, "import", "Read the values of "+strings.Join(read, ", ")+" to compare them with the manifest", err, stdio)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changes, secretvalue.CheckManaged(changes)
+}
+
+// printImportPlan writes each secret with what importing it changes, key by
+// key, and returns how many secrets would be written. Values are never
+// printed.
+func printImportPlan(w io.Writer, changes []secretvalue.ImportChange) int {
+	created, updated := 0, 0
+	for _, change := range changes {
+		switch {
+		case change.Create:
+			created++
+			fmt.Fprintf(w, "+ %s (create)\n", change.Entry.Name)
+		case change.Unchanged():
+			fmt.Fprintf(w, "= %s (unchanged)\n", change.Entry.Name)
+		default:
+			updated++
+			fmt.Fprintf(w, "~ %s (update)\n", change.Entry.Name)
+		}
+		if !change.Unchanged() {
+			for _, key := range change.Keys() {
+				fmt.Fprintf(w, "    %s %s\n", key[:1], key[1:])
+			}
+		}
+	}
+	fmt.Fprintf(w, "\n%d to create, %d to update, %d unchanged\n", created, updated, len(changes)-created-updated)
+	return created + updated
+}
+
+// confirmImport asks before writing. Without a terminal to ask on, it fails
+// unless --yes was given.
+func confirmImport(count int, yes bool, stdio IO) error {
+	if yes {
+		return nil
+	}
+	if !isTerminal(stdio.Stdin) {
+		return fmt.Errorf("pass --yes to write %d secrets without a terminal to confirm on, or --dry-run to only compare", count)
+	}
+
+	fmt.Fprintf(stdio.Stderr, "Write %d secrets? [y/N] ", count)
+	line, err := bufio.NewReader(stdio.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if answer := strings.ToLower(strings.TrimSpace(line)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("cancelled")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+func TestPrintImportPlan(t *testing.T) {
+	entry := func(name, value string) secretvalue.ManifestEntry {
+		return secretvalue.ManifestEntry{Name: name, Value: models.SecretValue{String: value}}
+	}
+	changes := []secretvalue.ImportChange{
+		secretvalue.PlanImport(entry("prod/api-key", "sk_live_a"), nil),
+		secretvalue.PlanImport(entry("prod/cache", `{"url":"redis://"}`), &models.SecretValue{String: `{"url":"redis://"}`}),
+		secretvalue.PlanImport(entry("prod/db", `{"password":"hunter3"}`), &models.SecretValue{String: `{"password":"hunter2"}`}),
+	}
+	var out bytes.Buffer
+	if writes := printImportPlan(&out, changes); writes != 2 {
+		t.Fatalf("expected 2 secrets to write, got %d", writes)
+	}
+	want := "+ prod/api-key (create)\n    + (value)\n= prod/cache (unchanged)\n~ prod/db (update)\n    ~ password\n\n1 to create, 1 to update, 1 unchanged\n"
+	if out.String() != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), "sk_live") || strings.Contains(out.String(), "hunter") {
+		t.Fatal("expected no values in the plan")
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// runK8sSecret implements `secretsrc k8s-secret`
+func runK8sSecret(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("k8s-secret", "[flags] <secret-name>", stdio)
+	clientOpts := addClientFlags(fs)
+	name := fs.String("name", "", "name of the Kubernetes Secret (default derived from the secret name, e.g. app-prod-db)")
+	namespace := fs.String("namespace", "", "namespace of the Kubernetes Secret (left out by default)")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one secret name")
+	}
+
+	manifestName := *name
+	if manifestName == "" {
+		manifestName = secretvalue.KubernetesName(positional[0])
+	}
+	// Check the names before anything is fetched
+	if err := secretvalue.ValidateKubernetesNames(manifestName, *namespace); err != nil {
+		return err
+	}
+
+	client, err := newClient(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+
+	value, err := client.GetSecret(ctx, positional[0])
+	recordAudit(ctx, client, "k8s-secret", "Read the value of "+positional[0], err, stdio)
+	if err != nil {
+		return err
+	}
+
+	data, err := secretvalue.KubernetesData(value.String, value.Binary)
+	if err != nil {
+		return err
+	}
+	manifest, err := secretvalue.KubernetesManifest(manifestName, *namespace, data)
+	if err != nil {
+		return err
+	}
+	_, err = stdio.Stdout.Write(manifest)
+	return err
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+)
+
+// runList implements `secretsrc list`
+func runList(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("list", "[flags]", stdio)
+	clientOpts := addClientFlags(fs)
+	addVaultFlag(fs, clientOpts)
+	prefix := fs.String("prefix", "", "only list secrets whose names start with this prefix")
+	output := addOutputFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	format, err := parseOutputFormat(*output)
+	if err != nil {
+		return err
+	}
+
+	client, err := newStore(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	secrets, err := inventory.New(client, inventory.DefaultPageSize).Find(ctx, *prefix)
+	if err != nil {
+		return err
+	}
+
+	if format == outputJSON {
+		// The same entries as `export --format json`
+		data, err := inventory.EncodeReport(secrets, inventory.ReportJSON)
+		if err != nil {
+			return err
+		}
+		_, err = stdio.Stdout.Write(data)
+		return err
+	}
+
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.Name
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(stdio.Stdout, name)
+	}
+	return nil
+}
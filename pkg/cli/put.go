@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// maxSecretSize is the largest value Secrets Manager stores, in bytes
+const maxSecretSize = 65536
+
+// runPut implements `secretsrc put`, which writes the value piped to stdin
+// as the new current version of a secret
+func runPut(ctx context.Context, args []string, stdio IO) error {
+	fs := newFlagSet("put", "[flags] <secret-name> < value", stdio)
+	clientOpts := addClientFlags(fs)
+	addVaultFlag(fs, clientOpts)
+	binary := fs.Bool("binary", false, "store the bytes read as SecretBinary, untouched, instead of SecretString")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one secret name")
+	}
+	name := positional[0]
+	if clientOpts.dryRun && clientOpts.azureVault != "" {
+		return fmt.Errorf("--dry-run only works with Secrets Manager, not --azure-vault")
+	}
+
+	// Values are never taken as arguments, where they would end up in the
+	// shell history and the process list
+	if isTerminal(stdio.Stdin) {
+		return fmt.Errorf("pipe the value to stdin, e.g. cat value.json | secretsrc put %s", name)
+	}
+	value, err := readValue(stdio.Stdin, *binary)
+	if err != nil {
+		return err
+	}
+
+	// Stdin has been read, so an MFA code can't be asked for on it
+	stdio.Stdin = nil
+	client, err := newStore(ctx, clientOpts, stdio)
+	if err != nil {
+		return err
+	}
+	if clientOpts.dryRun {
+		return client.PutSecretValue(clientOpts.dryRunContext(ctx, stdio), name, value)
+	}
+	err = client.PutSecretValue(ctx, name, value)
+	recordAudit(ctx, client, "put", "Updated the value of "+name, err, stdio)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdio.Stderr, "Updated %s\n", name)
+	return nil
+}
+
+// readValue reads a secret value from r. A text value loses one trailing
+// newline, as added by echo or an editor; a binary one is kept as it is.
+func readValue(r io.Reader, binary bool) (models.SecretValue, error) {
+	if r == nil {
+		return models.SecretValue{}, fmt.Errorf("no value on stdin")
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSecretSize+1))
+	if err != nil {
+		return models.SecretValue{}, fmt.Errorf("failed to read the value from stdin: %w", err)
+	}
+	if len(data) > maxSecretSize {
+		return models.SecretValue{}, fmt.Errorf("the value is over the %d byte limit of Secrets Manager", maxSecretSize)
+	}
+	if len(data) == 0 {
+		return models.SecretValue{}, fmt.Errorf("no value on stdin")
+	}
+	if binary {
+		return models.SecretValue{Binary: data}, nil
+	}
+
+	text := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if text == "" {
+		return models.SecretValue{}, fmt.Errorf("no value on stdin")
+	}
+	return models.SecretValue{String: text}, nil
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReadValueFromStdin(t *testing.T) {
+	value, err := readValue(strings.NewReader("{\"password\":\"hunter2\"}\n"), false)
+	if err != nil || value.String != `{"password":"hunter2"}` || value.IsBinary() {
+		t.Fatalf("expected the text without its trailing newline, got %+v, %v", value, err)
+	}
+
+	value, err = readValue(strings.NewReader("line one\r\nline two\r\n"), false)
+	if err != nil || value.String != "line one\r\nline two" {
+		t.Fatalf("expected only the last line ending dropped, got %q, %v", value.String, err)
+	}
+
+	value, err = readValue(bytes.NewReader([]byte{0xde, 0xad, '\n'}), true)
+	if err != nil || !bytes.Equal(value.Binary, []byte{0xde, 0xad, '\n'}) {
+		t.Fatalf("expected binary values untouched, got %v, %v", value.Binary, err)
+	}
+
+	for _, input := range []string{"", "\n"} {
+		if _, err := readValue(strings.NewReader(input), false); err == nil || !strings.Contains(err.Error(), "no value") {
+			t.Fatalf("expected an error for %q, got %v", input, err)
+		}
+	}
+
+	if _, err := readValue(strings.NewReader(strings.Repeat("x", maxSecretSize+1)), false); err == nil {
+		t.Fatalf("expected values over the size limit to be refused")
+	}
+}
+
+func TestPutRequiresAPipedValue(t *testing.T) {
+	var stderr bytes.Buffer
+	code := Run(context.Background(), []string{"put"}, IO{Stdin: strings.NewReader("value"), Stdout: &bytes.Buffer{}, Stderr: &stderr})
+	if code != 1 || !strings.Contains(stderr.String(), "expected exactly one secret name") {
+		t.Fatalf("expected put without a name to fail, got %d: %s", code, stderr.String())
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// platformSignals are the extra signals exec --signal accepts on this platform
+var platformSignals = map[string]os.Signal{
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
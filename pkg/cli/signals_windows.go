@@ -0,0 +1,8 @@
+//go:build windows
+
+package cli
+
+import "os"
+
+// platformSignals are the extra signals exec --signal accepts on this platform
+var platformSignals = map[string]os.Signal{}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// restartGracePeriod is how long a child gets to exit after SIGTERM before it is killed
+const restartGracePeriod = 10 * time.Second
+
+// watchFlags holds the exec --watch settings
+type watchFlags struct {
+	enabled    bool
+	interval   time.Duration
+	signalName string
+	signal     os.Signal // Signal sent on change; nil means restart the child
+}
+
+// addWatchFlags registers --watch, --interval and --signal on fs
+func addWatchFlags(fs *flag.FlagSet) *watchFlags {
+	f := &watchFlags{}
+	fs.BoolVar(&f.enabled, "watch", false, "poll the secrets and restart the command when a value changes")
+	fs.DurationVar(&f.interval, "interval", 30*time.Second, "how often --watch polls for changes")
+	fs.StringVar(&f.signalName, "signal", "", "with --watch, send this signal (e.g. HUP) on change instead of restarting")
+	return f
+}
+
+// validate checks the watch flags and resolves the signal name
+func (f *watchFlags) validate() error {
+	if !f.enabled {
+		if f.signalName != "" {
+			return fmt.Errorf("--signal requires --watch")
+		}
+		return nil
+	}
+
+	if f.interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+
+	if f.signalName != "" {
+		sig, err := parseSignal(f.signalName)
+		if err != nil {
+			return err
+		}
+		f.signal = sig
+	}
+	return nil
+}
+
+// parseSignal converts a signal name such as "HUP" or "SIGUSR1" to a signal
+func parseSignal(name string) (os.Signal, error) {
+	name = strings.TrimPrefix(strings.ToUpper(name), "SIG")
+
+	signals := map[string]os.Signal{
+		"HUP":  syscall.SIGHUP,
+		"INT":  syscall.SIGINT,
+		"QUIT": syscall.SIGQUIT,
+		"TERM": syscall.SIGTERM,
+	}
+	for signalName, sig := range platformSignals {
+		signals[signalName] = sig
+	}
+
+	if sig, ok := signals[name]; ok {
+		return sig, nil
+	}
+	return nil, fmt.Errorf("unsupported signal %q", name)
+}
+
+// envResolver fetches the secrets and returns the environment for the child
+type envResolver func(ctx context.Context) ([]string, error)
+
+// runWatched runs argv like runWithEnv, but polls resolve every interval and
+// restarts (or signals) the child whenever the resulting environment changes.
+// It returns once the child exits on its own or after an interrupt.
+func runWatched(ctx context.Context, argv []string, vars []string, resolve envResolver, opts *watchFlags, stdio IO) error {
+	proc, err := startChild(argv, vars, stdio)
+	if err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	fingerprint := envFingerprint(vars)
+	restarting := false
+	stopping := false
+
+	for {
+		select {
+		case sig := <-signals:
+			stopping = true
+			_ = proc.cmd.Process.Signal(sig)
+
+		case err := <-proc.done:
+			if !restarting || stopping {
+				return exitStatus(err)
+			}
+
+			restarting = false
+			proc, err = startChild(argv, vars, stdio)
+			if err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			if restarting || stopping {
+				continue
+			}
+
+			newVars, err := resolve(ctx)
+			if err != nil {
+				fmt.Fprintf(logging.Writer(stdio.Stderr), "secretsrc: failed to refresh secrets: %v\n", err)
+				continue
+			}
+
+			newFingerprint := envFingerprint(newVars)
+			if newFingerprint == fingerprint {
+				continue
+			}
+			fingerprint = newFingerprint
+
+			if opts.signal != nil {
+				fmt.Fprintf(stdio.Stderr, "secretsrc: secret changed, sending %v to %s\n", opts.signal, argv[0])
+				_ = proc.cmd.Process.Signal(opts.signal)
+				continue
+			}
+
+			fmt.Fprintf(stdio.Stderr, "secretsrc: secret changed, restarting %s\n", argv[0])
+			vars = newVars
+			restarting = true
+			terminate(proc)
+		}
+	}
+}
+
+// terminate asks the child to exit and kills it if it is still running after
+// the grace period
+func terminate(proc *child) {
+	_ = proc.cmd.Process.Signal(syscall.SIGTERM)
+
+	time.AfterFunc(restartGracePeriod, func() {
+		select {
+		case <-proc.exited:
+		default:
+			_ = proc.cmd.Process.Kill()
+		}
+	})
+}
+
+// envFingerprint returns a digest of vars so changes can be detected without
+// keeping a second copy of the values around
+func envFingerprint(vars []string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.Join(vars, "\x00")))
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWatchedRestartsChildWhenSecretsChange(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	calls := 0
+	resolve := func(ctx context.Context) ([]string, error) {
+		calls++
+		return []string{"VALUE=second"}, nil
+	}
+
+	var stdout bytes.Buffer
+	stdio := IO{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: io.Discard}
+	argv := []string{"sh", "-c", `echo "$VALUE"; [ "$VALUE" = second ] && exit 3; exec sleep 5`}
+	opts := &watchFlags{enabled: true, interval: 50 * time.Millisecond}
+
+	err := runWatched(context.Background(), argv, []string{"VALUE=first"}, resolve, opts, stdio)
+
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != 3 {
+		t.Fatalf("expected the restarted child's exit code 3, got %v", err)
+	}
+	if got := stdout.String(); got != "first\nsecond\n" {
+		t.Fatalf("expected the child to run with the old then new value, got %q", got)
+	}
+	if calls == 0 {
+		t.Fatal("expected the secrets to be polled")
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"HUP", "sighup", "TERM"} {
+		if _, err := parseSignal(name); err != nil {
+			t.Fatalf("expected %q to parse, got %v", name, err)
+		}
+	}
+	if _, err := parseSignal("BOGUS"); err == nil {
+		t.Fatal("expected unknown signal to be rejected")
+	}
+}
@@ -0,0 +1,226 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// keyringService and keyringUser name the cache key in the OS keychain
+	keyringService = "secretsrc"
+	keyringUser    = "credentials-cache"
+
+	// cacheFormatVersion is written to encrypted cache files
+	cacheFormatVersion = 1
+)
+
+// Key sources recorded in the cache file, so loading uses the same key
+const (
+	keySourceKeychain = "keychain"
+	keySourceMachine  = "machine"
+)
+
+// encryptedCache is the on-disk form of the credentials cache and the other
+// files sealed with sealJSON
+type encryptedCache struct {
+	Version    int    `json:"version"`
+	KeySource  string `json:"key_source"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// encryptCache seals the cache with AES-256-GCM. The key lives in the OS
+// keychain (macOS Keychain, Secret Service, Windows Credential Manager) when
+// one is available, otherwise it is derived from the machine.
+func encryptCache(cache *CredentialsCache) ([]byte, error) {
+	return sealJSON("credentials cache", cache)
+}
+
+// decryptCache opens a cache written by encryptCache. migrated is true when
+// data is a plaintext cache from an older version, which should be rewritten.
+func decryptCache(data []byte) (cache *CredentialsCache, migrated bool, err error) {
+	var decrypted CredentialsCache
+	migrated, err = openJSON("credentials cache", data, &decrypted)
+	if err != nil {
+		return nil, false, err
+	}
+	return &decrypted, migrated, nil
+}
+
+// sealJSON encrypts v as JSON with the cache key, for files that shouldn't
+// be readable if copied off the machine. what names the file for errors.
+func sealJSON(what string, v any) ([]byte, error) {
+	plaintext, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", what, err)
+	}
+
+	key, source, err := cacheKeyForWrite()
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return json.MarshalIndent(encryptedCache{
+		Version:    cacheFormatVersion,
+		KeySource:  source,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(source)),
+	}, "", "  ")
+}
+
+// openJSON decrypts data written by sealJSON into v. plaintext is true when
+// data is unencrypted JSON, as written before encryption, which is decoded
+// as it is and should be rewritten.
+func openJSON(what string, data []byte, v any) (plaintext bool, err error) {
+	var envelope encryptedCache
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+
+	if envelope.Version == 0 {
+		if err := json.Unmarshal(data, v); err != nil {
+			return false, fmt.Errorf("failed to parse %s: %w", what, err)
+		}
+		return true, nil
+	}
+	if envelope.Version != cacheFormatVersion {
+		return false, fmt.Errorf("unsupported %s version %d", what, envelope.Version)
+	}
+
+	key, err := cacheKeyForRead(envelope.KeySource)
+	if err != nil {
+		return false, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return false, err
+	}
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return false, fmt.Errorf("%s is corrupt", what)
+	}
+
+	decrypted, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, []byte(envelope.KeySource))
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt %s: %w", what, err)
+	}
+	if err := json.Unmarshal(decrypted, v); err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", what, err)
+	}
+	return false, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
+
+// cacheKeyForWrite returns the keychain key, creating it on first use, or
+// the machine key when no keychain is available
+func cacheKeyForWrite() ([]byte, string, error) {
+	key, err := keychainKey()
+	if errors.Is(err, keyring.ErrNotFound) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, "", fmt.Errorf("failed to generate cache key: %w", err)
+		}
+		err = keyring.Set(keyringService, keyringUser, base64.StdEncoding.EncodeToString(key))
+	}
+	if err == nil {
+		return key, keySourceKeychain, nil
+	}
+
+	logging.Debugf("OS keychain unavailable, using machine-derived cache key: %v", err)
+	key, err = machineKey()
+	if err != nil {
+		return nil, "", err
+	}
+	return key, keySourceMachine, nil
+}
+
+// cacheKeyForRead returns the key a cache was written with
+func cacheKeyForRead(source string) ([]byte, error) {
+	switch source {
+	case keySourceKeychain:
+		key, err := keychainKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache key from OS keychain: %w", err)
+		}
+		return key, nil
+	case keySourceMachine:
+		return machineKey()
+	default:
+		return nil, fmt.Errorf("unknown credentials cache key source %q", source)
+	}
+}
+
+// keychainKey reads the cache key from the OS keychain
+func keychainKey() ([]byte, error) {
+	encoded, err := keyring.Get(keyringService, keyringUser)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("cache key in OS keychain is invalid")
+	}
+	return key, nil
+}
+
+// machineKey derives a key from the machine ID and home directory. It keeps
+// the cache unreadable if the file is copied to another machine or account,
+// but unlike a keychain key it does not protect against other programs
+// running as the same user.
+func machineKey() ([]byte, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte("secretsrc credentials cache v1\x00"))
+	hash.Write([]byte(machineID()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(homeDir))
+	return hash.Sum(nil), nil
+}
+
+// machineID returns a stable identifier for this machine, falling back to
+// the hostname where no machine ID file exists (e.g. macOS, Windows)
+func machineID() string {
+	for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -2,16 +2,265 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/zalando/go-keyring"
 )
 
-// Config represents the application configuration
+// Config represents the application configuration. The state the UI
+// remembers is saved to config.json; every setting can also be given in
+// config.yaml, which takes precedence.
 type Config struct {
-	LastProfile string `json:"last_profile"`
-	LastRegion  string `json:"last_region"`
+	LastProfile string            `json:"last_profile" yaml:"-"`
+	LastRegion  string            `json:"last_region" yaml:"-"`
+	Theme       string            `json:"theme,omitempty" yaml:"theme"`   // Built-in theme name: dark, light, high-contrast
+	Colors      map[string]string `json:"colors,omitempty" yaml:"colors"` // Hex color overrides keyed by role (primary, subtle, ...)
+	Layout      string            `json:"layout,omitempty" yaml:"layout"` // Secret list layout: grid or list
+	// PageSize is how many secrets are requested per ListSecrets call, from
+	// 1 to 100. Defaults to 50.
+	PageSize int `json:"page_size,omitempty" yaml:"page_size"`
+	// KeyBindings gives actions another key, keyed by action name such as
+	// refresh or copy_plain
+	KeyBindings map[string]string `json:"keybindings,omitempty" yaml:"keybindings"`
+	// ClipboardTimeout clears a copied value from the clipboard after this
+	// long, as a Go duration, unless something else was copied since. Empty
+	// or "0" leaves it there.
+	ClipboardTimeout string `json:"clipboard_timeout,omitempty" yaml:"clipboard_timeout"`
+	// ConfirmWithKey confirms deleting or overwriting secrets by pressing y,
+	// instead of typing the secret's name
+	ConfirmWithKey bool `json:"confirm_with_key,omitempty" yaml:"confirm_with_key"`
+
+	// ProfileRegions is the region last used with each profile
+	ProfileRegions map[string]string `json:"profile_regions,omitempty" yaml:"-"`
+	// Workspaces are named profile, region and filter presets
+	Workspaces map[string]Workspace `json:"workspaces,omitempty" yaml:"-"`
+
+	// LockAfter locks the UI after this long without input, as a Go duration
+	// such as "15m". Empty or "0" never locks.
+	LockAfter string `json:"lock_after,omitempty" yaml:"lock_after"`
+	// LockRequiresMFA drops the cached MFA session on lock so unlocking asks
+	// for a new code
+	LockRequiresMFA bool `json:"lock_requires_mfa,omitempty" yaml:"lock_requires_mfa"`
+
+	// RevealTimeout is how long a revealed value stays visible, as a Go
+	// duration such as "30s". "0" disables the automatic re-mask.
+	RevealTimeout string `json:"reveal_timeout,omitempty" yaml:"reveal_timeout"`
+	// RequestTimeout bounds each AWS operation the UI starts, retries
+	// included, as a Go duration. Defaults to one minute.
+	RequestTimeout string `json:"request_timeout,omitempty" yaml:"request_timeout"`
+	// SecretListCacheTTL is how long the last loaded secret list is shown
+	// at startup while a fresh one loads, as a Go duration. Defaults to
+	// 24 hours; "0" disables the cache.
+	SecretListCacheTTL string `json:"secret_list_ttl,omitempty" yaml:"secret_list_ttl"`
+	// TrackValueChanges keeps an encrypted journal of a hash of each value
+	// viewed, to tell when a secret changed since it was last viewed
+	TrackValueChanges bool `json:"track_value_changes,omitempty" yaml:"track_value_changes"`
+	// ValueCacheTTL keeps fetched values in memory for this long, as a Go
+	// duration such as "2m", so viewing a secret again doesn't fetch it
+	// again. Empty or "0" clears values as soon as you navigate away.
+	ValueCacheTTL string `json:"value_cache_ttl,omitempty" yaml:"value_cache_ttl"`
+	// WatchInterval is how often watch mode re-lists the secrets, as a Go
+	// duration. Defaults to 30 seconds.
+	WatchInterval string `json:"watch_interval,omitempty" yaml:"watch_interval"`
+	// ScrollbackProtection blanks the screen before quitting and before
+	// suspending with ctrl+z, so terminals that copy the alternate screen
+	// into their scrollback don't keep the values shown
+	ScrollbackProtection bool `json:"scrollback_protection,omitempty" yaml:"scrollback_protection"`
+	// Mouse turns on clicking and scrolling. The terminal then needs shift
+	// held to select text.
+	Mouse bool `json:"mouse,omitempty" yaml:"mouse"`
+	// DetailPanel shows the selected secret's details beside the list
+	DetailPanel bool `json:"detail_panel,omitempty" yaml:"detail_panel"`
+	// DetailPanelWidth is the panel's share of the width, in percent from
+	// 25 to 70. Defaults to 45.
+	DetailPanelWidth int `json:"detail_panel_width,omitempty" yaml:"detail_panel_width"`
+	// StaleAfterDays is how many days without access mark a secret as stale
+	// on the audit screen. Defaults to DefaultStaleAfterDays.
+	StaleAfterDays int `json:"stale_after_days,omitempty" yaml:"stale_after_days"`
+
+	// Backend is the secret store browsed at startup: "aws" (the default),
+	// "azure", "kubernetes" or "demo". The backend flags override it.
+	Backend string `json:"backend,omitempty" yaml:"backend"`
+	// AzureVault is the key vault browsed with the azure backend, by name or URL
+	AzureVault string `json:"azure_vault,omitempty" yaml:"azure_vault"`
+	// KubeContext and KubeNamespace are the kubeconfig context and namespace
+	// browsed with the kubernetes backend, instead of the current ones
+	KubeContext   string `json:"kube_context,omitempty" yaml:"kube_context"`
+	KubeNamespace string `json:"kube_namespace,omitempty" yaml:"kube_namespace"`
+
+	// CredentialStore is where MFA session credentials are cached: "file"
+	// (the default) or "keyring"
+	CredentialStore string `json:"credential_store,omitempty" yaml:"credential_store"`
+	// AWSCLICache reuses sessions the AWS CLI cached in ~/.aws/cli/cache and
+	// ~/.aws/sso/cache before asking for an MFA code
+	AWSCLICache bool `json:"aws_cli_cache,omitempty" yaml:"aws_cli_cache"`
+	// RoleDuration is how long assumed role sessions last, as a Go duration
+	// such as "4h". It overrides duration_seconds in the AWS profiles.
+	RoleDuration string `json:"role_duration,omitempty" yaml:"role_duration"`
+
+	// AuditLog is a file to append a line to for each secret read, copied,
+	// written or deleted, with names but never values. Relative paths are
+	// from the config directory. Empty keeps no audit log.
+	AuditLog string `json:"audit_log,omitempty" yaml:"audit_log"`
+	// PasswordManagers are the password managers, "1password" and
+	// "bitwarden", whose items can be imported as new secrets with I. They
+	// are read through the op and bw CLIs. Empty imports from none.
+	PasswordManagers []string `json:"password_managers,omitempty" yaml:"password_managers"`
+	// StatePaths are Terraform state files and CloudFormation templates, or
+	// directories of them, searched with W for resources that mention a
+	// secret. Relative paths are from the config directory.
+	StatePaths []string `json:"state_paths,omitempty" yaml:"state_paths"`
+
+	// Env controls how secret fields are named when exported as environment variables
+	Env secretvalue.EnvMapping `json:"env" yaml:"env"`
+	// Lint configures the compliance checks on the lint screen
+	Lint inventory.LintRules `json:"lint,omitempty" yaml:"lint"`
+	// Schemas are JSON schemas that values written to matching secrets
+	// must pass. Relative schema paths are from the config directory.
+	Schemas []secretvalue.SchemaRule `json:"schemas,omitempty" yaml:"schemas"`
+	// SyncRules rename, drop and set keys of the values synced from one
+	// secret to another with S, by source secret name
+	SyncRules secretvalue.SyncRules `json:"sync_rules,omitempty" yaml:"sync_rules"`
+	// Apps name the secrets an application needs and how their fields
+	// become its environment, for `exec --app` and the app: filter
+	Apps []secretvalue.App `json:"apps,omitempty" yaml:"apps"`
+	// Templates are the keys, tags and rotation new secrets can be created
+	// with, from N on the secret list
+	Templates []secretvalue.Template `json:"templates,omitempty" yaml:"templates"`
+}
+
+// RoleDurationOverride returns the role_duration setting, or 0 when it is
+// unset or invalid so the profiles' own settings apply
+func (c *Config) RoleDurationOverride() time.Duration {
+	if c.RoleDuration == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(c.RoleDuration)
+	if err != nil || duration < 0 {
+		logging.Debugf("ignoring invalid role_duration %q", c.RoleDuration)
+		return 0
+	}
+	return duration
+}
+
+// App returns the app with the given name
+func (c *Config) App(name string) (secretvalue.App, bool) {
+	for _, app := range c.Apps {
+		if app.Name == name {
+			return app, true
+		}
+	}
+	return secretvalue.App{}, false
+}
+
+// Workspace is a profile, region and secret list filter saved under a name,
+// to switch to together
+type Workspace struct {
+	Profile string `json:"profile"`
+	Region  string `json:"region,omitempty"`
+	Filter  string `json:"filter,omitempty"`
+}
+
+// SaveWorkspace adds a workspace, replacing any with the same name
+func (c *Config) SaveWorkspace(name string, workspace Workspace) {
+	if c.Workspaces == nil {
+		c.Workspaces = make(map[string]Workspace)
+	}
+	c.Workspaces[name] = workspace
+}
+
+// RememberRegion records a profile and region as the last used, and the
+// region as the one to restore for the profile. An empty region, left for
+// the SDK to resolve, is not remembered for the profile.
+func (c *Config) RememberRegion(profile, region string) {
+	c.LastProfile = profile
+	c.LastRegion = region
+	if region == "" {
+		return
+	}
+	if c.ProfileRegions == nil {
+		c.ProfileRegions = make(map[string]string)
+	}
+	c.ProfileRegions[profile] = region
+}
+
+// RememberedRegions returns the region last used with each profile. Configs
+// saved before profile_regions existed only remember the last profile's.
+func (c *Config) RememberedRegions() map[string]string {
+	regions := make(map[string]string, len(c.ProfileRegions)+1)
+	for profile, region := range c.ProfileRegions {
+		regions[profile] = region
+	}
+	if c.LastProfile != "" && c.LastRegion != "" {
+		regions[c.LastProfile] = c.LastRegion
+	}
+	return regions
+}
+
+// SchemaRules returns the schemas setting with each schema path made
+// absolute: ~ is the home directory, and relative paths are from the config
+// directory
+func (c *Config) SchemaRules() []secretvalue.SchemaRule {
+	rules := make([]secretvalue.SchemaRule, len(c.Schemas))
+	for i, rule := range c.Schemas {
+		rules[i] = rule
+		rules[i].Schema = configPath(rule.Schema)
+	}
+	return rules
+}
+
+// AuditLogPath returns the audit_log setting made absolute as the schema
+// paths are, or "" when there is no audit log
+func (c *Config) AuditLogPath() string {
+	return configPath(c.AuditLog)
+}
+
+// StatePathList returns the state_paths setting made absolute as the
+// schema paths are
+func (c *Config) StatePathList() []string {
+	paths := make([]string, len(c.StatePaths))
+	for i, path := range c.StatePaths {
+		paths[i] = configPath(path)
+	}
+	return paths
+}
+
+// configPath makes a path from the config file absolute: ~ is the home
+// directory, and relative paths are from the config directory
+func configPath(path string) string {
+	switch {
+	case hasHomePrefix(path, filepath.Separator):
+		if expanded, err := ExpandHome(path); err == nil {
+			return expanded
+		}
+	case path != "" && !filepath.IsAbs(path):
+		if dir, err := configDir(); err == nil {
+			return filepath.Join(dir, path)
+		}
+	}
+	return path
+}
+
+// DefaultStaleAfterDays is used when stale_after_days is unset
+const DefaultStaleAfterDays = 90
+
+// StaleDays returns the stale_after_days setting, or DefaultStaleAfterDays
+// when it is unset or invalid
+func (c *Config) StaleDays() int {
+	if c.StaleAfterDays <= 0 {
+		if c.StaleAfterDays < 0 {
+			logging.Debugf("ignoring invalid stale_after_days %d", c.StaleAfterDays)
+		}
+		return DefaultStaleAfterDays
+	}
+	return c.StaleAfterDays
 }
 
 // CachedCredentials represents cached AWS credentials
@@ -29,19 +278,36 @@ type CredentialsCache struct {
 
 // getConfigPath returns the path to the config file
 func getConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	return filePath(configDir, "config.json")
+}
+
+// Load loads config.json overlaid with the settings in config.yaml. When
+// config.yaml can't be read the error is returned along with config.json's
+// configuration, so callers can carry on with it.
+func Load() (*Config, error) {
+	cfg, err := loadState()
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return nil, err
 	}
+	if err := cfg.loadSettings(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
 
-	configDir := filepath.Join(homeDir, ".aws", "secretsrc")
-	configFile := filepath.Join(configDir, "config.json")
-
-	return configFile, nil
+// Update applies change to config.json and saves it. config.yaml is left
+// out so its settings aren't copied into config.json.
+func Update(change func(cfg *Config)) error {
+	cfg, err := loadState()
+	if err != nil {
+		cfg = &Config{}
+	}
+	change(cfg)
+	return Save(cfg)
 }
 
-// Load loads the configuration from disk
-func Load() (*Config, error) {
+// loadState loads config.json alone
+func loadState() (*Config, error) {
 	configFile, err := getConfigPath()
 	if err != nil {
 		return nil, err
@@ -73,8 +339,7 @@ func Save(cfg *Config) error {
 	}
 
 	// Create config directory if it doesn't exist
-	configDir := filepath.Dir(configFile)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
@@ -90,17 +355,19 @@ func Save(cfg *Config) error {
 	return nil
 }
 
-// getCredentialsCachePath returns the path to the credentials cache file
-func getCredentialsCachePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+// DebugLogPath returns the path the interactive UI writes its debug log to
+func DebugLogPath() (string, error) {
+	dir, err := cacheDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
+		return "", err
 	}
 
-	configDir := filepath.Join(homeDir, ".aws", "secretsrc")
-	cacheFile := filepath.Join(configDir, "cache.json")
+	return filepath.Join(dir, "debug.log"), nil
+}
 
-	return cacheFile, nil
+// getCredentialsCachePath returns the path to the credentials cache file
+func getCredentialsCachePath() (string, error) {
+	return filePath(cacheDir, "cache.json")
 }
 
 // LoadCredentialsCache loads cached credentials from disk
@@ -122,16 +389,21 @@ func LoadCredentialsCache() (*CredentialsCache, error) {
 		return nil, fmt.Errorf("failed to read credentials cache: %w", err)
 	}
 
-	var cache CredentialsCache
-	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, fmt.Errorf("failed to parse credentials cache: %w", err)
+	cache, migrated, err := decryptCache(data)
+	if err != nil {
+		return nil, err
 	}
 
 	if cache.Profiles == nil {
 		cache.Profiles = make(map[string]CachedCredentials)
 	}
 
-	return &cache, nil
+	if migrated {
+		// Rewrite a plaintext cache from an older version encrypted
+		_ = SaveCredentialsCache(cache) // Ignore errors, the next save retries
+	}
+
+	return cache, nil
 }
 
 // SaveCredentialsCache saves cached credentials to disk
@@ -141,15 +413,14 @@ func SaveCredentialsCache(cache *CredentialsCache) error {
 		return err
 	}
 
-	// Create config directory if it doesn't exist
-	configDir := filepath.Dir(cacheFile)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	// Create cache directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	data, err := json.MarshalIndent(cache, "", "  ")
+	data, err := encryptCache(cache)
 	if err != nil {
-		return fmt.Errorf("failed to marshal credentials cache: %w", err)
+		return err
 	}
 
 	// Write with restricted permissions (0600) for security
@@ -162,6 +433,24 @@ func SaveCredentialsCache(cache *CredentialsCache) error {
 
 // GetCachedCredentials retrieves cached credentials for a profile if they exist and are still valid
 func GetCachedCredentials(profile string) (*CachedCredentials, bool) {
+	if credentialStore() == CredentialStoreKeyring {
+		creds, err := getKeyringCredentials(profile)
+		switch {
+		case err == nil:
+			if time.Now().After(creds.ExpiresAt) {
+				_ = deleteKeyringCredentials(profile) // Ignore errors
+				return nil, false
+			}
+			logging.TrackSecret(creds.SecretAccessKey)
+			logging.TrackSecret(creds.SessionToken)
+			return creds, true
+		case errors.Is(err, keyring.ErrNotFound):
+			return nil, false
+		}
+		// No usable keyring, e.g. a headless server, so sessions were saved to the file
+		logging.Debugf("OS keyring unavailable, reading the credentials cache file: %v", err)
+	}
+
 	cache, err := LoadCredentialsCache()
 	if err != nil {
 		return nil, false
@@ -180,11 +469,22 @@ func GetCachedCredentials(profile string) (*CachedCredentials, bool) {
 		return nil, false
 	}
 
+	logging.TrackSecret(creds.SecretAccessKey)
+	logging.TrackSecret(creds.SessionToken)
+
 	return &creds, true
 }
 
 // SaveCachedCredentials saves credentials for a profile
 func SaveCachedCredentials(profile string, creds CachedCredentials) error {
+	if credentialStore() == CredentialStoreKeyring {
+		err := saveKeyringCredentials(profile, creds)
+		if err == nil {
+			return nil
+		}
+		logging.Debugf("OS keyring unavailable, saving to the credentials cache file: %v", err)
+	}
+
 	cache, err := LoadCredentialsCache()
 	if err != nil {
 		cache = &CredentialsCache{
@@ -195,3 +495,24 @@ func SaveCachedCredentials(profile string, creds CachedCredentials) error {
 	cache.Profiles[profile] = creds
 	return SaveCredentialsCache(cache)
 }
+
+// DeleteCachedCredentials removes the cached credentials for a profile
+func DeleteCachedCredentials(profile string) error {
+	if credentialStore() == CredentialStoreKeyring {
+		// The session may be in the file if the keyring was unavailable when it was saved
+		if err := deleteKeyringCredentials(profile); err != nil {
+			logging.Debugf("failed to delete cached credentials from OS keyring: %v", err)
+		}
+	}
+
+	cache, err := LoadCredentialsCache()
+	if err != nil {
+		return err
+	}
+	if _, exists := cache.Profiles[profile]; !exists {
+		return nil
+	}
+
+	delete(cache.Profiles, profile)
+	return SaveCredentialsCache(cache)
+}
@@ -0,0 +1,465 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/zalando/go-keyring"
+)
+
+func TestCredentialsCacheIsEncryptedAtRest(t *testing.T) {
+	keyring.MockInit()
+	tempHome(t)
+
+	creds := CachedCredentials{
+		AccessKeyID:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret-access-key-value",
+		SessionToken:    "session-token-value",
+		ExpiresAt:       time.Now().Add(time.Hour),
+	}
+	if err := SaveCachedCredentials("prod", creds); err != nil {
+		t.Fatalf("SaveCachedCredentials returned error: %v", err)
+	}
+
+	data := readCacheFile(t)
+	if strings.Contains(data, "secret-access-key-value") || strings.Contains(data, "session-token-value") {
+		t.Fatal("expected the cache file not to contain credentials in plaintext")
+	}
+	if !strings.Contains(data, `"key_source": "keychain"`) {
+		t.Fatalf("expected the keychain key to be used, got %s", data)
+	}
+
+	cached, ok := GetCachedCredentials("prod")
+	if !ok || cached.SessionToken != "session-token-value" {
+		t.Fatalf("expected cached credentials to round-trip, got %+v, %v", cached, ok)
+	}
+}
+
+func TestCredentialsCacheFallsBackToMachineKey(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	tempHome(t)
+
+	if err := SaveCachedCredentials("prod", CachedCredentials{SessionToken: "session-token-value", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveCachedCredentials returned error: %v", err)
+	}
+
+	data := readCacheFile(t)
+	if strings.Contains(data, "session-token-value") || !strings.Contains(data, `"key_source": "machine"`) {
+		t.Fatalf("expected the machine key to encrypt the cache, got %s", data)
+	}
+	if _, ok := GetCachedCredentials("prod"); !ok {
+		t.Fatal("expected cached credentials to round-trip with the machine key")
+	}
+}
+
+func TestPlaintextCredentialsCacheIsMigrated(t *testing.T) {
+	keyring.MockInit()
+	home := tempHome(t)
+
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	plaintext := `{"profiles":{"prod":{"access_key_id":"ASIAEXAMPLE","secret_access_key":"secret-access-key-value","session_token":"session-token-value","expires_at":"` + expires + `"}}}`
+	path := filepath.Join(home, ".aws", "secretsrc", "cache.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(plaintext), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, ok := GetCachedCredentials("prod")
+	if !ok || cached.SecretAccessKey != "secret-access-key-value" {
+		t.Fatalf("expected the plaintext cache to be readable, got %+v, %v", cached, ok)
+	}
+	if strings.Contains(readCacheFile(t), "secret-access-key-value") {
+		t.Fatal("expected reading a plaintext cache to rewrite it encrypted")
+	}
+}
+
+// tempHome points the home directory at a temporary one, with the config
+// and cache directories in their default places under it
+func tempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// os.UserHomeDir reads USERPROFILE on Windows
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	return home
+}
+
+func readCacheFile(t *testing.T) string {
+	t.Helper()
+	path, err := getCredentialsCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cache file: %v", err)
+	}
+	return string(data)
+}
+
+func TestKeyringCredentialStore(t *testing.T) {
+	keyring.MockInit()
+	tempHome(t)
+	if err := Save(&Config{CredentialStore: CredentialStoreKeyring}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveCachedCredentials("prod", CachedCredentials{SessionToken: "session-token-value", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveCachedCredentials returned error: %v", err)
+	}
+	path, err := getCredentialsCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("expected the keyring store not to write cache.json")
+	}
+
+	cached, ok := GetCachedCredentials("prod")
+	if !ok || cached.SessionToken != "session-token-value" {
+		t.Fatalf("expected cached credentials to round-trip through the keyring, got %+v, %v", cached, ok)
+	}
+
+	if err := DeleteCachedCredentials("prod"); err != nil {
+		t.Fatalf("DeleteCachedCredentials returned error: %v", err)
+	}
+	if _, ok := GetCachedCredentials("prod"); ok {
+		t.Fatal("expected deleted credentials to be gone from the keyring")
+	}
+}
+
+func TestKeyringCredentialStoreFallsBackToFile(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	tempHome(t)
+	if err := Save(&Config{CredentialStore: CredentialStoreKeyring}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveCachedCredentials("prod", CachedCredentials{SessionToken: "session-token-value", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveCachedCredentials returned error: %v", err)
+	}
+	if !strings.Contains(readCacheFile(t), `"key_source": "machine"`) {
+		t.Fatal("expected the session to be saved to the cache file without a keyring")
+	}
+	if _, ok := GetCachedCredentials("prod"); !ok {
+		t.Fatal("expected the session to be read back from the cache file")
+	}
+}
+
+func TestSecretListCacheExpires(t *testing.T) {
+	tempHome(t)
+
+	secrets := []models.Secret{{Name: "app/db", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf"}}
+	if err := SaveCachedSecretList("prod", "eu-west-2", secrets, time.Hour); err != nil {
+		t.Fatalf("SaveCachedSecretList returned error: %v", err)
+	}
+
+	list, ok := GetCachedSecretList("prod", "eu-west-2", time.Hour)
+	if !ok || len(list.Secrets) != 1 || list.Secrets[0].ARN != secrets[0].ARN {
+		t.Fatalf("expected the cached list to round-trip, got %+v, %v", list, ok)
+	}
+	if _, ok := GetCachedSecretList("prod", "us-east-1", time.Hour); ok {
+		t.Fatal("expected lists to be cached per region")
+	}
+	if _, ok := GetCachedSecretList("prod", "eu-west-2", 0); ok {
+		t.Fatal("expected a list older than the TTL to be ignored")
+	}
+
+	path, err := getSecretListCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected the cache file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected the cache file to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestValueHistoryRecordsHashesEncrypted(t *testing.T) {
+	keyring.MockInit()
+	tempHome(t)
+
+	arn := "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf"
+	viewed := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	first := HashValue(models.SecretValue{String: "hunter2"})
+	if _, found, err := RecordValueView(arn, first, viewed); err != nil || found {
+		t.Fatalf("expected a first view, got %v, %v", found, err)
+	}
+
+	second := HashValue(models.SecretValue{String: "hunter3"})
+	previous, found, err := RecordValueView(arn, second, viewed.AddDate(0, 0, 7))
+	if err != nil || !found || previous.Hash != first || !previous.ViewedAt.Equal(viewed) {
+		t.Fatalf("expected the first view back, got %+v, %v, %v", previous, found, err)
+	}
+	if first == second || HashValue(models.SecretValue{Binary: []byte("hunter2")}) == first {
+		t.Fatal("expected different values to hash differently")
+	}
+
+	path, err := getValueHistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Contains(string(data), arn) || strings.Contains(string(data), second) {
+		t.Fatalf("expected the journal to be encrypted, got %s (%v)", data, err)
+	}
+	views, err := LoadValueViews()
+	if err != nil || views[arn].Hash != second {
+		t.Fatalf("expected the latest view to be loaded, got %+v, %v", views, err)
+	}
+}
+
+func TestSecretListTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      DefaultSecretListTTL,
+		"0":     0,
+		"2h":    2 * time.Hour,
+		"bogus": DefaultSecretListTTL,
+	}
+	for value, want := range cases {
+		cfg := &Config{SecretListCacheTTL: value}
+		if got := cfg.SecretListTTL(); got != want {
+			t.Fatalf("SecretListTTL() for %q = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestRememberedRegions(t *testing.T) {
+	// Saved before profile_regions existed
+	cfg := &Config{LastProfile: "dev", LastRegion: "eu-west-1"}
+	if got := cfg.RememberedRegions()["dev"]; got != "eu-west-1" {
+		t.Fatalf("expected the last region for the last profile, got %q", got)
+	}
+
+	cfg.RememberRegion("prod", "us-east-1")
+	cfg.RememberRegion("dev", "eu-west-2")
+	cfg.RememberRegion("sso", "")
+	regions := cfg.RememberedRegions()
+	if regions["prod"] != "us-east-1" || regions["dev"] != "eu-west-2" {
+		t.Fatalf("unexpected regions %v", regions)
+	}
+	if _, ok := regions["sso"]; ok || cfg.LastProfile != "sso" || cfg.LastRegion != "" {
+		t.Fatalf("expected an unresolved region not to be remembered, got %v and %+v", regions, cfg)
+	}
+}
+
+func TestWorkspacesRoundTrip(t *testing.T) {
+	tempHome(t)
+
+	cfg := &Config{}
+	cfg.SaveWorkspace("prod-eu", Workspace{Profile: "prod", Region: "eu-west-1", Filter: "payments/"})
+	if err := Save(cfg); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got := loaded.Workspaces["prod-eu"]; got != (Workspace{Profile: "prod", Region: "eu-west-1", Filter: "payments/"}) {
+		t.Fatalf("unexpected workspace %+v", got)
+	}
+}
+
+func TestSettingsFileOverridesConfigJSON(t *testing.T) {
+	tempHome(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	if err := Save(&Config{LastProfile: "dev", Layout: "grid", Theme: "light"}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	settingsFile, err := SettingsPath()
+	if err != nil {
+		t.Fatalf("SettingsPath returned error: %v", err)
+	}
+	settings := "layout: list\npage_size: 20\nlast_profile: ignored\nkeybindings:\n  refresh: ctrl+r\nenv:\n  prefix: APP_\n"
+	if err := os.MkdirAll(filepath.Dir(settingsFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsFile, []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Layout != "list" || cfg.PageSize != 20 || cfg.Theme != "light" || cfg.Env.Prefix != "APP_" || cfg.KeyBindings["refresh"] != "ctrl+r" {
+		t.Fatalf("expected config.yaml to override config.json, got %+v", cfg)
+	}
+	if cfg.LastProfile != "dev" {
+		t.Fatalf("expected the last profile to come from config.json only, got %q", cfg.LastProfile)
+	}
+
+	if err := Update(func(cfg *Config) { cfg.LastRegion = "eu-west-1" }); err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+	saved, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState returned error: %v", err)
+	}
+	if saved.LastRegion != "eu-west-1" || saved.Layout != "grid" || saved.PageSize != 0 {
+		t.Fatalf("expected config.yaml's settings not to be saved to config.json, got %+v", saved)
+	}
+
+	if err := os.WriteFile(settingsFile, []byte("layout: [list\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load()
+	if err == nil || cfg == nil || cfg.Layout != "grid" {
+		t.Fatalf("expected a parse error with config.json's settings, got %+v, %v", cfg, err)
+	}
+}
+
+func TestValidateReportsEveryInvalidSetting(t *testing.T) {
+	if err := (&Config{Layout: "list", PageSize: 100, RevealTimeout: "0", LockAfter: "15m"}).Validate(); err != nil {
+		t.Fatalf("expected valid settings, got %v", err)
+	}
+
+	cfg := &Config{Layout: "table", PageSize: 500, LockAfter: "soon", ClipboardTimeout: "-1s", CredentialStore: "vault", Backend: "gcp",
+		Lint: inventory.LintRules{Disabled: []string{"no_rotation", "spelling"}}, Apps: []secretvalue.App{{Name: "billing"}},
+		Templates: []secretvalue.Template{{Name: "rds", Fields: []secretvalue.TemplateField{{Key: "port", Type: "number", Default: "x"}}}}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected invalid settings to be reported")
+	}
+	for _, setting := range []string{"layout", "page_size", "lock_after", "clipboard_timeout", "credential_store", "backend", "lint.disabled", "app billing", "template rds"} {
+		if !strings.Contains(err.Error(), setting) {
+			t.Errorf("expected %s to be reported, got %v", setting, err)
+		}
+	}
+}
+
+func TestStartupBackend(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, BackendAWS},
+		{Config{Backend: BackendKubernetes}, BackendKubernetes},
+		{Config{Backend: BackendAzure, AzureVault: "prod-vault"}, BackendAzure},
+		{Config{Backend: BackendAzure}, BackendAWS}, // No vault to open
+		{Config{Backend: "gcp"}, BackendAWS},
+	}
+	for _, test := range tests {
+		if got := test.cfg.StartupBackend(); got != test.want {
+			t.Errorf("StartupBackend() with %+v = %q, want %q", test.cfg, got, test.want)
+		}
+	}
+	if err := (&Config{Backend: BackendAzure}).Validate(); err == nil || !strings.Contains(err.Error(), "azure_vault") {
+		t.Errorf("expected the azure backend without azure_vault to be reported, got %v", err)
+	}
+}
+
+func TestSchemaRulesResolvePaths(t *testing.T) {
+	home := tempHome(t)
+	cfg := &Config{Schemas: []secretvalue.SchemaRule{
+		{Pattern: "*/database", Schema: "schemas/database.json"},
+		{Pattern: "*/api", Schema: "~/api.json"},
+		{Pattern: "*/queue", Schema: "/etc/schemas/queue.json"},
+	}}
+
+	rules := cfg.SchemaRules()
+	want := []string{
+		filepath.Join(home, ".config", "secretsrc", "schemas", "database.json"),
+		filepath.Join(home, "api.json"),
+		"/etc/schemas/queue.json",
+	}
+	for i, rule := range rules {
+		if rule.Schema != want[i] || rule.Pattern != cfg.Schemas[i].Pattern {
+			t.Errorf("expected %s for %s, got %+v", want[i], cfg.Schemas[i].Pattern, rule)
+		}
+	}
+}
+
+func TestFilesMoveFromTheLegacyDirectory(t *testing.T) {
+	home := tempHome(t)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	legacy := filepath.Join(home, ".aws", "secretsrc", "config.json")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{"last_profile": "prod"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil || cfg.LastProfile != "prod" {
+		t.Fatalf("expected the legacy config to load, got %+v, %v", cfg, err)
+	}
+	if _, err := os.Stat(filepath.Join(configHome, "secretsrc", "config.json")); err != nil {
+		t.Fatalf("expected the config to move to XDG_CONFIG_HOME: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Fatalf("expected the legacy config to be gone, got %v", err)
+	}
+
+	// A cache directory that can't be created leaves the legacy file in use
+	blocked := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", blocked)
+	legacyCache := filepath.Join(home, ".aws", "secretsrc", "secret_lists.json")
+	if err := os.WriteFile(legacyCache, []byte(`{}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := getSecretListCachePath(); err != nil || path != legacyCache {
+		t.Fatalf("expected the legacy path as a fallback, got %q, %v", path, err)
+	}
+}
+
+func TestUserDirsFollowThePlatform(t *testing.T) {
+	home := tempHome(t)
+	appData := t.TempDir()
+	t.Setenv("APPDATA", appData)
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	if dir, err := platformUserDir("windows", "APPDATA", "XDG_CONFIG_HOME", ".config"); err != nil || dir != filepath.Join(appData, "secretsrc") {
+		t.Fatalf("expected %%APPDATA%%\\secretsrc on Windows, got %q, %v", dir, err)
+	}
+	if dir, err := platformUserDir("linux", "APPDATA", "XDG_CONFIG_HOME", ".config"); err != nil || dir != filepath.Join(configHome, "secretsrc") {
+		t.Fatalf("expected XDG_CONFIG_HOME elsewhere, got %q, %v", dir, err)
+	}
+
+	// Without APPDATA, Windows falls back to the home directory too
+	t.Setenv("APPDATA", "")
+	if dir, err := platformUserDir("windows", "APPDATA", "XDG_CONFIG_HOME", ".config"); err != nil || dir != filepath.Join(home, ".config", "secretsrc") {
+		t.Fatalf("expected a directory under the home directory, got %q, %v", dir, err)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := tempHome(t)
+	for path, want := range map[string]string{
+		"~":              home,
+		"~/out/app.json": filepath.Join(home, "out", "app.json"),
+		"out/app.json":   "out/app.json",
+		"~someone/x":     "~someone/x",
+	} {
+		if got, err := ExpandHome(path); err != nil || got != want {
+			t.Errorf("ExpandHome(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	// Windows paths are written with backslashes
+	if !hasHomePrefix(`~\Documents\app.json`, '\\') || hasHomePrefix(`~\Documents`, '/') {
+		t.Fatal(`expected ~\ to be expanded only where \ separates paths`)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/zalando/go-keyring"
+)
+
+// Values for the credential_store setting
+const (
+	// CredentialStoreFile caches MFA sessions in the encrypted cache.json (default)
+	CredentialStoreFile = "file"
+	// CredentialStoreKeyring caches MFA sessions in the OS keyring, one entry per profile
+	CredentialStoreKeyring = "keyring"
+)
+
+// keyringCredentialsPrefix prefixes the keyring user for a profile's cached
+// credentials, keeping them apart from the cache encryption key
+const keyringCredentialsPrefix = "sts/"
+
+// credentialStore returns the configured credential store
+func credentialStore() string {
+	cfg, _ := Load()
+	if cfg == nil {
+		return CredentialStoreFile
+	}
+
+	switch cfg.CredentialStore {
+	case "", CredentialStoreFile:
+		return CredentialStoreFile
+	case CredentialStoreKeyring:
+		return CredentialStoreKeyring
+	default:
+		logging.Debugf("unknown credential_store %q, using %s", cfg.CredentialStore, CredentialStoreFile)
+		return CredentialStoreFile
+	}
+}
+
+// getKeyringCredentials reads a profile's cached credentials from the OS
+// keyring. It returns keyring.ErrNotFound when none are stored.
+func getKeyringCredentials(profile string) (*CachedCredentials, error) {
+	data, err := keyring.Get(keyringService, keyringCredentialsPrefix+profile)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds CachedCredentials
+	if err := json.Unmarshal([]byte(data), &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse cached credentials from OS keyring: %w", err)
+	}
+	return &creds, nil
+}
+
+// saveKeyringCredentials stores a profile's cached credentials in the OS keyring
+func saveKeyringCredentials(profile string, creds CachedCredentials) error {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cached credentials: %w", err)
+	}
+	return keyring.Set(keyringService, keyringCredentialsPrefix+profile, string(data))
+}
+
+// deleteKeyringCredentials removes a profile's cached credentials from the
+// OS keyring. Nothing being stored is not an error.
+func deleteKeyringCredentials(profile string) error {
+	err := keyring.Delete(keyringService, keyringCredentialsPrefix+profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// appDirName is the directory Secret Src keeps its files in, under the
+// platform's config and cache directories
+const appDirName = "secretsrc"
+
+// configDir returns the directory for config.json and config.yaml:
+// %APPDATA%\secretsrc on Windows, and $XDG_CONFIG_HOME/secretsrc (by default
+// ~/.config/secretsrc) elsewhere
+func configDir() (string, error) {
+	return userDir("APPDATA", "XDG_CONFIG_HOME", ".config")
+}
+
+// cacheDir returns the directory for cached credentials, secret lists and
+// the debug log: %LOCALAPPDATA%\secretsrc on Windows, and
+// $XDG_CACHE_HOME/secretsrc (by default ~/.cache/secretsrc) elsewhere
+func cacheDir() (string, error) {
+	return userDir("LOCALAPPDATA", "XDG_CACHE_HOME", ".cache")
+}
+
+// userDir returns the secretsrc directory under the Windows folder named by
+// windowsEnv, or the XDG directory named by xdgEnv with fallback under the
+// home directory
+func userDir(windowsEnv, xdgEnv, fallback string) (string, error) {
+	return platformUserDir(runtime.GOOS, windowsEnv, xdgEnv, fallback)
+}
+
+// platformUserDir is userDir for the platform named by goos
+func platformUserDir(goos, windowsEnv, xdgEnv, fallback string) (string, error) {
+	if goos == "windows" {
+		if dir := os.Getenv(windowsEnv); dir != "" {
+			return filepath.Join(dir, appDirName), nil
+		}
+	} else if dir := os.Getenv(xdgEnv); filepath.IsAbs(dir) {
+		// The XDG spec says relative paths are invalid and to be ignored
+		return filepath.Join(dir, appDirName), nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, fallback, appDirName), nil
+}
+
+// ExpandHome expands a leading ~ in path to the home directory. On Windows
+// ~\ is expanded as well as ~/.
+func ExpandHome(path string) (string, error) {
+	if !hasHomePrefix(path, filepath.Separator) {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, path[1:]), nil
+}
+
+// hasHomePrefix reports whether path is ~ or starts with ~ and then / or
+// the platform's separator
+func hasHomePrefix(path string, separator byte) bool {
+	return path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(separator))
+}
+
+// legacyDir returns ~/.aws/secretsrc, where every file was kept before the
+// config and cache directories were used
+func legacyDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".aws", appDirName), nil
+}
+
+// filePath returns the path to name in the directory from dir. A file left
+// in the legacy directory by an older version is moved there the first
+// time; if it can't be moved, the legacy path keeps being used.
+func filePath(dir func() (string, error), name string) (string, error) {
+	base, err := dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(base, name)
+
+	legacy, err := legacyDir()
+	if err != nil {
+		return path, nil
+	}
+	legacyPath := filepath.Join(legacy, name)
+
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		return path, nil
+	}
+	if err := moveFile(legacyPath, path); err != nil {
+		logging.Debugf("failed to move %s to %s, still using it: %v", legacyPath, path, err)
+		return legacyPath, nil
+	}
+	logging.Debugf("moved %s to %s", legacyPath, path)
+	return path, nil
+}
+
+// moveFile moves a file, copying it when it can't be renamed, e.g. across
+// file systems
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(to)
+		return err
+	}
+	src.Close()
+	return os.Remove(from)
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// DefaultSecretListTTL is how long a cached secret list is shown at startup
+const DefaultSecretListTTL = 24 * time.Hour
+
+// CachedSecretList is the last secret list loaded for a profile and region.
+// It holds metadata only, never secret values.
+type CachedSecretList struct {
+	SavedAt time.Time       `json:"saved_at"`
+	Secrets []models.Secret `json:"secrets"`
+}
+
+// secretListCache stores the cached lists keyed by secretListKey
+type secretListCache struct {
+	Lists map[string]CachedSecretList `json:"lists"`
+}
+
+// SecretListTTL returns the secret_list_ttl setting, DefaultSecretListTTL
+// when it is unset or invalid, or 0 when the cache is disabled with "0"
+func (c *Config) SecretListTTL() time.Duration {
+	if c.SecretListCacheTTL == "" {
+		return DefaultSecretListTTL
+	}
+	ttl, err := time.ParseDuration(c.SecretListCacheTTL)
+	if err != nil || ttl < 0 {
+		logging.Debugf("ignoring invalid secret_list_ttl %q", c.SecretListCacheTTL)
+		return DefaultSecretListTTL
+	}
+	return ttl
+}
+
+// getSecretListCachePath returns the path to the secret list cache file
+func getSecretListCachePath() (string, error) {
+	return filePath(cacheDir, "secret_lists.json")
+}
+
+// secretListKey identifies a profile and region in the cache
+func secretListKey(profile, region string) string {
+	return profile + "/" + region
+}
+
+// loadSecretListCache reads the cache file, which is empty when missing
+func loadSecretListCache() (*secretListCache, error) {
+	cacheFile, err := getSecretListCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &secretListCache{Lists: make(map[string]CachedSecretList)}
+	data, err := os.ReadFile(cacheFile)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret list cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse secret list cache: %w", err)
+	}
+	if cache.Lists == nil {
+		cache.Lists = make(map[string]CachedSecretList)
+	}
+	return cache, nil
+}
+
+// GetCachedSecretList returns the secret list cached for profile and region
+// if it was saved within ttl
+func GetCachedSecretList(profile, region string, ttl time.Duration) (*CachedSecretList, bool) {
+	cache, err := loadSecretListCache()
+	if err != nil {
+		logging.Debugf("ignoring secret list cache: %v", err)
+		return nil, false
+	}
+
+	list, exists := cache.Lists[secretListKey(profile, region)]
+	if !exists || time.Since(list.SavedAt) > ttl {
+		return nil, false
+	}
+	return &list, true
+}
+
+// SaveCachedSecretList replaces the secret list cached for profile and
+// region, dropping lists for other profiles that are older than ttl
+func SaveCachedSecretList(profile, region string, secrets []models.Secret, ttl time.Duration) error {
+	cache, err := loadSecretListCache()
+	if err != nil {
+		// A damaged cache is rebuilt
+		cache = &secretListCache{Lists: make(map[string]CachedSecretList)}
+	}
+
+	for key, list := range cache.Lists {
+		if time.Since(list.SavedAt) > ttl {
+			delete(cache.Lists, key)
+		}
+	}
+	cache.Lists[secretListKey(profile, region)] = CachedSecretList{SavedAt: time.Now(), Secrets: secrets}
+
+	cacheFile, err := getSecretListCachePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret list cache: %w", err)
+	}
+
+	// Secret names can be sensitive, so the file is private like the credentials cache
+	if err := os.WriteFile(cacheFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secret list cache: %w", err)
+	}
+	return nil
+}
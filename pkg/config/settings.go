@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/passwords"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxPageSize is the most secrets ListSecrets returns in one call
+const MaxPageSize = 100
+
+// Values for the backend setting
+const (
+	BackendAWS        = "aws"
+	BackendAzure      = "azure"
+	BackendKubernetes = "kubernetes"
+	BackendDemo       = "demo"
+)
+
+// Backends lists the values of the backend setting
+var Backends = []string{BackendAWS, BackendAzure, BackendKubernetes, BackendDemo}
+
+// SettingsPath returns the path to config.yaml, beside config.json
+func SettingsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadSettings overlays the settings in config.yaml, if there is one.
+// Settings it leaves out keep their config.json values.
+func (c *Config) loadSettings() error {
+	settingsFile, err := SettingsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(settingsFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", settingsFile, err)
+	}
+
+	// Decode into a copy so a file that fails part way isn't half applied
+	settings := *c
+	settings.Colors = maps.Clone(c.Colors)
+	settings.KeyBindings = maps.Clone(c.KeyBindings)
+	settings.Env.Rename = maps.Clone(c.Env.Rename)
+	settings.Lint.RequiredTags = slices.Clone(c.Lint.RequiredTags)
+	settings.Lint.Disabled = slices.Clone(c.Lint.Disabled)
+	settings.Schemas = slices.Clone(c.Schemas)
+	settings.PasswordManagers = slices.Clone(c.PasswordManagers)
+	settings.StatePaths = slices.Clone(c.StatePaths)
+	settings.SyncRules = slices.Clone(c.SyncRules)
+	settings.Apps = slices.Clone(c.Apps)
+	settings.Templates = slices.Clone(c.Templates)
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", settingsFile, err)
+	}
+	*c = settings
+	return nil
+}
+
+// StartupBackend returns the backend setting, or BackendAWS when it's unset
+// or can't be used
+func (c *Config) StartupBackend() string {
+	if !slices.Contains(Backends, c.Backend) || (c.Backend == BackendAzure && c.AzureVault == "") {
+		return BackendAWS
+	}
+	return c.Backend
+}
+
+// Validate reports every setting with a value that can't be used. Invalid
+// settings fall back to their defaults, so the errors are warnings.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Layout != "" && c.Layout != "grid" && c.Layout != "list" {
+		errs = append(errs, fmt.Errorf("layout must be grid or list, not %q", c.Layout))
+	}
+	if c.PageSize < 0 || c.PageSize > MaxPageSize {
+		errs = append(errs, fmt.Errorf("page_size must be from 1 to %d, not %d", MaxPageSize, c.PageSize))
+	}
+	if c.DetailPanelWidth != 0 && (c.DetailPanelWidth < 25 || c.DetailPanelWidth > 70) {
+		errs = append(errs, fmt.Errorf("detail_panel_width must be from 25 to 70, not %d", c.DetailPanelWidth))
+	}
+	if c.StaleAfterDays < 0 {
+		errs = append(errs, fmt.Errorf("stale_after_days must be positive, not %d", c.StaleAfterDays))
+	}
+	switch {
+	case c.Backend != "" && !slices.Contains(Backends, c.Backend):
+		errs = append(errs, fmt.Errorf("backend must be %s, not %q", strings.Join(Backends, ", "), c.Backend))
+	case c.Backend == BackendAzure && c.AzureVault == "":
+		errs = append(errs, fmt.Errorf("backend azure needs azure_vault to name the key vault"))
+	}
+	switch c.CredentialStore {
+	case "", CredentialStoreFile, CredentialStoreKeyring:
+	default:
+		errs = append(errs, fmt.Errorf("credential_store must be %s or %s, not %q",
+			CredentialStoreFile, CredentialStoreKeyring, c.CredentialStore))
+	}
+
+	for _, manager := range c.PasswordManagers {
+		if !slices.Contains(passwords.Names, manager) {
+			errs = append(errs, fmt.Errorf("password_managers must list %s, not %q", strings.Join(passwords.Names, " or "), manager))
+		}
+	}
+	if slices.Contains(c.StatePaths, "") {
+		errs = append(errs, fmt.Errorf("state_paths can't have an empty path"))
+	}
+	if err := c.SyncRules.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	apps := make(map[string]bool, len(c.Apps))
+	for _, app := range c.Apps {
+		switch {
+		case app.Name == "":
+			errs = append(errs, fmt.Errorf("apps must each have a name"))
+		case apps[app.Name]:
+			errs = append(errs, fmt.Errorf("apps has %s more than once", app.Name))
+		case len(app.Secrets) == 0:
+			errs = append(errs, fmt.Errorf("app %s has no secrets", app.Name))
+		case slices.Contains(app.SecretNames(), ""):
+			errs = append(errs, fmt.Errorf("app %s has a secret without a name", app.Name))
+		}
+		apps[app.Name] = true
+	}
+	templates := make(map[string]bool, len(c.Templates))
+	for _, template := range c.Templates {
+		if err := template.Validate(); err != nil {
+			errs = append(errs, err)
+		} else if templates[template.Name] {
+			errs = append(errs, fmt.Errorf("templates has %s more than once", template.Name))
+		}
+		templates[template.Name] = true
+	}
+
+	durations := []struct {
+		name  string
+		value string
+	}{
+		{"lock_after", c.LockAfter},
+		{"reveal_timeout", c.RevealTimeout},
+		{"request_timeout", c.RequestTimeout},
+		{"secret_list_ttl", c.SecretListCacheTTL},
+		{"watch_interval", c.WatchInterval},
+		{"value_cache_ttl", c.ValueCacheTTL},
+		{"role_duration", c.RoleDuration},
+		{"clipboard_timeout", c.ClipboardTimeout},
+	}
+	for _, duration := range durations {
+		if duration.value == "" || duration.value == "0" {
+			continue
+		}
+		parsed, err := time.ParseDuration(duration.value)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Errorf("%s must be a duration such as 30s or 15m, not %q", duration.name, duration.value))
+		}
+	}
+
+	if err := c.Lint.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// ValueView is when a secret's value was last viewed, with a hash of the
+// value so a later view can tell whether it changed. The value itself is
+// never recorded.
+type ValueView struct {
+	Hash     string    `json:"hash"`
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+// valueHistory is the journal of viewed values, keyed by secret ARN
+type valueHistory struct {
+	Views map[string]ValueView `json:"views"`
+}
+
+// getValueHistoryPath returns the path to the value history journal
+func getValueHistoryPath() (string, error) {
+	return filePath(cacheDir, "value_history.json")
+}
+
+// HashValue returns the hash of a secret value recorded in the journal
+func HashValue(value models.SecretValue) string {
+	sum := sha256.New()
+	if value.IsBinary() {
+		sum.Write([]byte{1})
+		sum.Write(value.Binary)
+	} else {
+		sum.Write([]byte{0})
+		sum.Write([]byte(value.String))
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// loadValueHistory reads the journal, which is empty when missing
+func loadValueHistory() (*valueHistory, error) {
+	historyFile, err := getValueHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	history := &valueHistory{Views: make(map[string]ValueView)}
+	data, err := os.ReadFile(historyFile)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read value history: %w", err)
+	}
+	if _, err := openJSON("value history", data, history); err != nil {
+		return nil, err
+	}
+	if history.Views == nil {
+		history.Views = make(map[string]ValueView)
+	}
+	return history, nil
+}
+
+// LoadValueViews returns when each secret's value was last viewed, keyed
+// by ARN
+func LoadValueViews() (map[string]ValueView, error) {
+	history, err := loadValueHistory()
+	if err != nil {
+		return nil, err
+	}
+	return history.Views, nil
+}
+
+// RecordValueView records that the value of the secret with the given ARN
+// was viewed at viewedAt, returning the view it replaces if there was one
+func RecordValueView(arn, hash string, viewedAt time.Time) (ValueView, bool, error) {
+	history, err := loadValueHistory()
+	if err != nil {
+		// A journal that can't be read is started again
+		history = &valueHistory{Views: make(map[string]ValueView)}
+	}
+	previous, found := history.Views[arn]
+	history.Views[arn] = ValueView{Hash: hash, ViewedAt: viewedAt}
+
+	historyFile, err := getValueHistoryPath()
+	if err != nil {
+		return previous, found, err
+	}
+	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
+		return previous, found, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := sealJSON("value history", history)
+	if err != nil {
+		return previous, found, err
+	}
+	if err := os.WriteFile(historyFile, data, 0600); err != nil {
+		return previous, found, fmt.Errorf("failed to write value history: %w", err)
+	}
+	return previous, found, nil
+}
@@ -0,0 +1,353 @@
+// Package demo provides an in-memory secret store filled with generated
+// sample secrets, so the UI can be tried, recorded and tested without AWS.
+package demo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// Profile and Region are what the UI shows while running against the store
+const (
+	Profile = "demo"
+	Region  = "eu-west-2"
+)
+
+// accountID is the fake account the sample ARNs belong to
+const accountID = "123456789012"
+
+var (
+	environments = []string{"prod", "staging", "dev"}
+	services     = []string{"api", "auth", "billing", "notifications", "reports", "search", "web", "worker"}
+)
+
+// entry is a secret with its value and metadata
+type entry struct {
+	secret  models.Secret
+	value   models.SecretValue
+	details models.SecretDetails
+}
+
+// Store is a read-only, in-memory stand-in for the Secrets Manager client.
+// Its contents are generated from a fixed seed, so every run shows the same
+// secrets. It is safe for concurrent use.
+type Store struct {
+	entries []entry
+}
+
+// NewStore creates a store with sample secrets for a few services across
+// prod, staging and dev, enough to fill more than one page
+func NewStore() *Store {
+	rng := rand.New(rand.NewSource(1))
+	now := time.Now().Truncate(time.Hour)
+
+	s := &Store{}
+	for _, env := range environments {
+		for _, service := range services {
+			s.add(rng, now, env, service, "database",
+				fmt.Sprintf("Database credentials for the %s service (%s)", service, env),
+				models.SecretValue{String: mustJSON(map[string]any{
+					"engine":   "postgres",
+					"host":     fmt.Sprintf("%s-%s.cluster-demo.%s.rds.amazonaws.com", service, env, Region),
+					"port":     5432,
+					"username": service + "_app",
+					"password": randomString(rng, 24),
+					"dbname":   service,
+				})}, true)
+
+			s.add(rng, now, env, service, "api-key",
+				fmt.Sprintf("Third-party API key used by %s (%s)", service, env),
+				models.SecretValue{String: "sk_demo_" + randomString(rng, 32)}, false)
+
+			s.add(rng, now, env, service, "config",
+				fmt.Sprintf("Runtime configuration for %s (%s)", service, env),
+				models.SecretValue{String: mustJSON(map[string]any{
+					"log_level": map[string]string{"prod": "warn", "staging": "info", "dev": "debug"}[env],
+					"feature_flags": map[string]bool{
+						"new_checkout": env != "prod",
+						"audit_log":    true,
+					},
+					"upstreams": []map[string]any{
+						{"name": "primary", "url": fmt.Sprintf("https://%s.%s.internal.example.com", service, env)},
+						{"name": "fallback", "url": fmt.Sprintf("https://%s-fallback.%s.internal.example.com", service, env)},
+					},
+				})}, false)
+		}
+
+		key := make([]byte, 48)
+		rng.Read(key)
+		s.add(rng, now, env, "web", "tls-key",
+			fmt.Sprintf("TLS private key for the web frontend (%s), stored as binary", env),
+			models.SecretValue{Binary: key}, false)
+	}
+
+	// RDS manages the master user of the reports databases
+	for i := range s.entries {
+		if strings.HasSuffix(s.entries[i].secret.Name, "/reports/database") {
+			s.entries[i].secret.OwningService = "rds"
+			s.entries[i].details.OwningService = "rds"
+		}
+	}
+	return s
+}
+
+// add generates the metadata for one sample secret
+func (s *Store) add(rng *rand.Rand, now time.Time, env, service, kind, description string, value models.SecretValue, rotated bool) {
+	name := fmt.Sprintf("%s/%s/%s", env, service, kind)
+	created := now.Add(-time.Duration(200+rng.Intn(400)) * 24 * time.Hour)
+	changed := now.Add(-time.Duration(1+rng.Intn(90*24)) * time.Hour)
+	accessed := now.Add(-time.Duration(rng.Intn(7)) * 24 * time.Hour).Truncate(24 * time.Hour)
+
+	details := models.SecretDetails{
+		CreatedDate:      &created,
+		LastAccessedDate: &accessed,
+		Versions: []models.SecretVersion{
+			{ID: randomVersionID(rng), Stages: []string{"AWSCURRENT"}},
+			{ID: randomVersionID(rng), Stages: []string{"AWSPREVIOUS"}},
+		},
+	}
+	if env == "prod" {
+		details.KmsKeyID = fmt.Sprintf("arn:aws:kms:%s:%s:key/%s", Region, accountID, randomVersionID(rng))
+	}
+	if rotated {
+		next := changed.Add(30 * 24 * time.Hour)
+		details.RotationEnabled = true
+		details.LastRotatedDate = &changed
+		details.NextRotationDate = &next
+	}
+
+	s.entries = append(s.entries, entry{
+		secret: models.Secret{
+			Name:             name,
+			ARN:              fmt.Sprintf("arn:aws:secretsmanager:%s:%s:secret:%s-%s", Region, accountID, name, randomString(rng, 6)),
+			Description:      description,
+			LastChangedDate:  &changed,
+			LastAccessedDate: &accessed,
+			LastRotatedDate:  details.LastRotatedDate,
+			KmsKeyID:         details.KmsKeyID,
+			RotationEnabled:  details.RotationEnabled,
+			Tags: map[string]string{
+				"Environment": env,
+				"Service":     service,
+				"ManagedBy":   "secretsrc-demo",
+			},
+		},
+		value:   value,
+		details: details,
+	})
+}
+
+// ListSecrets returns a page of secrets. The token is the offset of the
+// next page.
+func (s *Store) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	offset := 0
+	if nextToken != nil {
+		var err error
+		if offset, err = strconv.Atoi(*nextToken); err != nil || offset < 0 || offset > len(s.entries) {
+			return nil, nil, fmt.Errorf("failed to list secrets: invalid next token %q", *nextToken)
+		}
+	}
+
+	end := min(offset+int(maxResults), len(s.entries))
+	secrets := make([]models.Secret, 0, end-offset)
+	for _, e := range s.entries[offset:end] {
+		secrets = append(secrets, e.secret)
+	}
+
+	if end == len(s.entries) {
+		return secrets, nil, nil
+	}
+	token := strconv.Itoa(end)
+	return secrets, &token, nil
+}
+
+// FindSecrets returns every secret whose name starts with prefix, ignoring
+// case like the ListSecrets name filter
+func (s *Store) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	var secrets []models.Secret
+	for _, e := range s.entries {
+		if strings.HasPrefix(strings.ToLower(e.secret.Name), strings.ToLower(prefix)) {
+			secrets = append(secrets, e.secret)
+		}
+	}
+	return secrets, nil
+}
+
+// GetSecretValue returns a text secret value
+func (s *Store) GetSecretValue(ctx context.Context, secretName string) (string, error) {
+	value, err := s.GetSecret(ctx, secretName)
+	if err != nil {
+		return "", err
+	}
+	if value.IsBinary() {
+		return "", fmt.Errorf("secret %s: %w", secretName, aws.ErrBinarySecret)
+	}
+	return value.String, nil
+}
+
+// GetSecret returns a secret value, whether text or binary
+func (s *Store) GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error) {
+	e, err := s.find(secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+
+	// Tracked like real values, so redaction behaves the same in demos
+	if e.value.IsBinary() {
+		logging.TrackSecret(base64.StdEncoding.EncodeToString(e.value.Binary))
+		logging.TrackSecret(hex.EncodeToString(e.value.Binary))
+		return &models.SecretValue{Binary: append([]byte(nil), e.value.Binary...)}, nil
+	}
+	logging.TrackSecret(e.value.String)
+	return &models.SecretValue{String: e.value.String}, nil
+}
+
+// GetSecrets returns several secret values, keyed by name
+func (s *Store) GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error) {
+	values := make(map[string]*models.SecretValue, len(secretNames))
+	for _, name := range secretNames {
+		value, err := s.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// DescribeSecret returns the metadata of a secret
+func (s *Store) DescribeSecret(ctx context.Context, secretName string) (*models.SecretDetails, error) {
+	e, err := s.find(secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
+	}
+	details := e.details
+	details.Name = e.secret.Name
+	details.ARN = e.secret.ARN
+	details.Description = e.secret.Description
+	details.Tags = maps.Clone(e.secret.Tags)
+	details.LastChangedDate = e.secret.LastChangedDate
+	return &details, nil
+}
+
+// UsesDefaultChain is always false, the store needs no credentials
+func (s *Store) UsesDefaultChain() bool {
+	return false
+}
+
+// GetEndpoint is always empty, the store has no endpoint
+func (s *Store) GetEndpoint() string {
+	return ""
+}
+
+// CallerIdentity is always nil, the store belongs to no account
+func (s *Store) CallerIdentity(ctx context.Context) (*models.CallerIdentity, error) {
+	return nil, nil
+}
+
+// ListRegions returns the common regions, with secrets only in Region
+func (s *Store) ListRegions(ctx context.Context) ([]models.Region, error) {
+	names := aws.GetCommonRegions()
+	regions := make([]models.Region, len(names))
+	for i, name := range names {
+		regions[i] = models.Region{Name: name, HasSecrets: name == Region}
+	}
+	return regions, nil
+}
+
+// ListKMSKeys returns the default key and a sample customer managed key
+func (s *Store) ListKMSKeys(ctx context.Context) ([]models.KMSKey, error) {
+	return []models.KMSKey{
+		{Alias: "alias/aws/secretsmanager"},
+		{Alias: "alias/demo-secrets", KeyID: "1234abcd-12ab-34cd-56ef-1234567890ab"},
+	}, nil
+}
+
+// GetResourcePolicies returns no policy for each secret, none have one
+func (s *Store) GetResourcePolicies(ctx context.Context, secretNames []string) (map[string]string, error) {
+	policies := make(map[string]string, len(secretNames))
+	for _, name := range secretNames {
+		if _, err := s.find(name); err == nil {
+			policies[name] = ""
+		}
+	}
+	return policies, nil
+}
+
+// ErrReadOnly is returned by the operations that would change a secret
+var ErrReadOnly = errors.New("the demo store is read-only")
+
+// TagSecret fails, the store is read-only
+func (s *Store) TagSecret(ctx context.Context, secretName, key, value string) error {
+	return fmt.Errorf("failed to tag secret: %w", ErrReadOnly)
+}
+
+// UntagSecret fails, the store is read-only
+func (s *Store) UntagSecret(ctx context.Context, secretName, key string) error {
+	return fmt.Errorf("failed to untag secret: %w", ErrReadOnly)
+}
+
+// ScheduleDeletion fails, the store is read-only
+func (s *Store) ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", ErrReadOnly)
+}
+
+// RestoreSecret fails, the store is read-only
+func (s *Store) RestoreSecret(ctx context.Context, secretName string) error {
+	return fmt.Errorf("failed to restore secret: %w", ErrReadOnly)
+}
+
+// ChangeKMSKey fails, the store is read-only
+func (s *Store) ChangeKMSKey(ctx context.Context, secretName, kmsKeyID string) error {
+	return fmt.Errorf("failed to change KMS key: %w", ErrReadOnly)
+}
+
+// find looks a secret up by name or ARN
+func (s *Store) find(secretName string) (entry, error) {
+	for _, e := range s.entries {
+		if e.secret.Name == secretName || e.secret.ARN == secretName {
+			return e, nil
+		}
+	}
+	return entry{}, fmt.Errorf("ResourceNotFoundException: Secrets Manager can't find the specified secret %s", secretName)
+}
+
+// mustJSON encodes a sample value, which cannot fail for the maps used here
+func mustJSON(value any) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// randomString returns n random alphanumeric characters
+func randomString(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphanumeric[rng.Intn(len(alphanumeric))]
+	}
+	return string(b)
+}
+
+// randomVersionID returns a UUID-shaped identifier
+func randomVersionID(rng *rand.Rand) string {
+	b := make([]byte, 16)
+	rng.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
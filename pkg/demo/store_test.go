@@ -0,0 +1,60 @@
+package demo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+)
+
+func TestStorePagesThroughSampleSecrets(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	var names []string
+	var token *string
+	for {
+		secrets, next, err := store.ListSecrets(ctx, 50, token)
+		if err != nil {
+			t.Fatalf("ListSecrets returned error: %v", err)
+		}
+		for _, secret := range secrets {
+			names = append(names, secret.Name)
+		}
+		if next == nil {
+			break
+		}
+		token = next
+	}
+	if len(names) <= 50 || len(names) != len(store.entries) {
+		t.Fatalf("expected more than one page covering every secret, got %d of %d", len(names), len(store.entries))
+	}
+
+	if again := NewStore(); again.entries[0].secret.ARN != store.entries[0].secret.ARN {
+		t.Fatal("expected the same sample secrets on every run")
+	}
+}
+
+func TestStoreValues(t *testing.T) {
+	store := NewStore()
+	ctx := context.Background()
+
+	prod, err := store.FindSecrets(ctx, "PROD/billing/")
+	if err != nil || len(prod) != 3 {
+		t.Fatalf("expected the three prod billing secrets, got %d, %v", len(prod), err)
+	}
+
+	if _, err := store.GetSecretValue(ctx, "prod/web/tls-key"); !errors.Is(err, aws.ErrBinarySecret) {
+		t.Fatalf("expected ErrBinarySecret for the binary sample, got %v", err)
+	}
+
+	details, err := store.DescribeSecret(ctx, prod[0].ARN)
+	if err != nil || len(details.Versions) == 0 {
+		t.Fatalf("expected details when describing by ARN, got %+v, %v", details, err)
+	}
+
+	if _, err := store.GetSecret(ctx, "prod/missing"); err == nil {
+		t.Fatal("expected an error for an unknown secret")
+	}
+}
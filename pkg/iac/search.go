@@ -0,0 +1,245 @@
+// Package iac searches infrastructure as code, Terraform state and
+// CloudFormation templates, for the resources that mention a secret, to
+// find what a secret is wired into without reaching AWS.
+package iac
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxFileSize bounds the files read, so a directory holding large
+// unrelated JSON or YAML doesn't stall the search
+const maxFileSize = 32 << 20
+
+// skippedDirs are directories never searched: provider caches and VCS
+// metadata hold no state of their own
+var skippedDirs = map[string]bool{".git": true, ".terraform": true, "node_modules": true, "cdk.out": true}
+
+// Reference is a resource in a state file or template that mentions a
+// secret
+type Reference struct {
+	File     string // The file it is in
+	Resource string // Terraform address, such as "module.api.aws_iam_policy.read", or CloudFormation logical ID
+	Kind     string // "Terraform" or "CloudFormation"
+}
+
+// Secret is how a secret can be mentioned: by ARN, the ARN without its
+// random suffix, or name
+type Secret struct {
+	Name string
+	ARN  string
+}
+
+// mentions reports whether a string in a resource refers to the secret:
+// it holds the ARN, or the ARN without its suffix, or is the name, as a
+// data source or SecretId is written, or resolves it in a CloudFormation
+// dynamic reference
+func (s Secret) mentions(value string) bool {
+	arn := s.ARN
+	if i := len(arn) - 7; i > 0 && arn[i] == '-' {
+		arn = arn[:i]
+	}
+	switch {
+	case arn != "" && strings.Contains(value, arn):
+		return true
+	case s.Name == "":
+		return false
+	case value == s.Name:
+		return true
+	}
+	resolve := "{{resolve:secretsmanager:" + s.Name
+	return strings.Contains(value, resolve+":") || strings.Contains(value, resolve+"}}")
+}
+
+// Search looks through paths, files or directories searched recursively,
+// for Terraform state (.tfstate) and CloudFormation templates (.json,
+// .yaml, .yml and .template with a Resources section) whose resources
+// mention the secret. Other files are skipped; files that can't be read or
+// parsed are reported in the error alongside what was found.
+func Search(paths []string, secret Secret) ([]Reference, error) {
+	var refs []Reference
+	var errs []error
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			if entry.IsDir() {
+				if path != root && skippedDirs[entry.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			found, err := searchFile(path, secret)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			}
+			refs = append(refs, found...)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].File != refs[j].File {
+			return refs[i].File < refs[j].File
+		}
+		return refs[i].Resource < refs[j].Resource
+	})
+	return refs, errors.Join(errs...)
+}
+
+// searchFile searches one file, by its extension
+func searchFile(path string, secret Secret) ([]Reference, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".tfstate", ".json", ".yaml", ".yml", ".template":
+	default:
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxFileSize {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext == ".tfstate" {
+		return searchState(path, data, secret)
+	}
+	return searchTemplate(path, data, secret)
+}
+
+// terraformState is the part of a Terraform state file read
+type terraformState struct {
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   any            `json:"index_key"`
+			Attributes map[string]any `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// searchState lists the resource instances of a Terraform state whose
+// attributes mention the secret
+func searchState(path string, data []byte, secret Secret) ([]Reference, error) {
+	var state terraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("not a Terraform state: %w", err)
+	}
+	var refs []Reference
+	for _, resource := range state.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			if !mentionedIn(instance.Attributes, secret) {
+				continue
+			}
+			instanceAddress := address
+			switch key := instance.IndexKey.(type) {
+			case string:
+				instanceAddress += fmt.Sprintf("[%q]", key)
+			case float64:
+				instanceAddress += fmt.Sprintf("[%d]", int(key))
+			}
+			refs = append(refs, Reference{File: path, Resource: instanceAddress, Kind: "Terraform"})
+		}
+	}
+	return refs, nil
+}
+
+// mentionedIn reports whether any string in a decoded JSON value mentions
+// the secret
+func mentionedIn(value any, secret Secret) bool {
+	switch v := value.(type) {
+	case string:
+		return secret.mentions(v)
+	case []any:
+		for _, item := range v {
+			if mentionedIn(item, secret) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range v {
+			if mentionedIn(item, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchTemplate lists the resources of a CloudFormation template whose
+// properties mention the secret. YAML is read as nodes so short form
+// intrinsic functions such as !Sub don't need to be understood; JSON
+// templates are YAML too.
+func searchTemplate(path string, data []byte, secret Secret) ([]Reference, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		// Not every JSON or YAML file is a template
+		return nil, nil
+	}
+	resources := mappingValue(doc.Content[0], "Resources")
+	if resources == nil || resources.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var refs []Reference
+	for i := 0; i+1 < len(resources.Content); i += 2 {
+		if nodeMentions(resources.Content[i+1], secret) {
+			refs = append(refs, Reference{File: path, Resource: resources.Content[i].Value, Kind: "CloudFormation"})
+		}
+	}
+	return refs, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// nodeMentions reports whether any scalar under node mentions the secret
+func nodeMentions(node *yaml.Node, secret Secret) bool {
+	if node.Kind == yaml.ScalarNode {
+		return secret.mentions(node.Value)
+	}
+	for _, child := range node.Content {
+		if nodeMentions(child, secret) {
+			return true
+		}
+	}
+	return false
+}
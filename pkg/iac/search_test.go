@@ -0,0 +1,58 @@
+package iac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const dbARN = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-AbCdEf"
+
+func TestSearchFindsStateAndTemplateResources(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"infra/terraform.tfstate": `{"resources":[
+			{"mode":"managed","type":"aws_iam_policy","name":"read","module":"module.api","instances":[{"attributes":{"policy":"{\"Resource\":\"arn:aws:secretsmanager:eu-west-1:123456789012:secret:prod/db-*\"}"}}]},
+			{"mode":"data","type":"aws_secretsmanager_secret","name":"db","instances":[{"index_key":"eu","attributes":{"name":"prod/db"}}]},
+			{"mode":"managed","type":"aws_s3_bucket","name":"logs","instances":[{"attributes":{"bucket":"prod/db-replica"}}]}
+		]}`,
+		"stacks/api.yaml":                 "Resources:\n  Task:\n    Type: AWS::ECS::TaskDefinition\n    Properties:\n      Password: !Sub '{{resolve:secretsmanager:prod/db:SecretString:password}}'\n  Queue:\n    Type: AWS::SQS::Queue\n",
+		"stacks/notes.json":               `{"name":"prod/db"}`,
+		"stacks/README.md":                "prod/db",
+		"infra/.terraform/cached.tfstate": `{"resources":[{"mode":"managed","type":"x","name":"y","instances":[{"attributes":{"name":"prod/db"}}]}]}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refs, err := Search([]string{dir}, Secret{Name: "prod/db", ARN: dbARN})
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	want := []string{
+		`data.aws_secretsmanager_secret.db["eu"]`,
+		"module.api.aws_iam_policy.read",
+		"Task",
+	}
+	if len(refs) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, refs)
+	}
+	for i, ref := range refs {
+		if ref.Resource != want[i] {
+			t.Fatalf("expected %v, got %+v", want, refs)
+		}
+	}
+	if refs[2].Kind != "CloudFormation" || filepath.Base(refs[2].File) != "api.yaml" {
+		t.Fatalf("expected the template resource, got %+v", refs[2])
+	}
+
+	if _, err := Search([]string{filepath.Join(dir, "missing")}, Secret{Name: "prod/db"}); err == nil {
+		t.Fatal("expected a missing path to be reported")
+	}
+}
@@ -0,0 +1,149 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// AuditEntry is a secret with how long it has gone without being accessed
+type AuditEntry struct {
+	Secret models.Secret
+	// IdleDays is the number of days since the secret was last accessed, or
+	// since it last changed when it never has been. -1 when neither is known.
+	IdleDays int
+	// Stale is set when IdleDays reaches the threshold or is unknown
+	Stale bool
+}
+
+// NeverAccessed reports whether AWS has no record of the secret being read
+func (e AuditEntry) NeverAccessed() bool {
+	return e.Secret.LastAccessedDate == nil
+}
+
+// AuditTotals counts the entries of an audit
+type AuditTotals struct {
+	Secrets       int
+	Stale         int
+	NeverAccessed int
+}
+
+// Audit lists secrets by when they were last accessed, least recently first
+// with never accessed secrets ahead of the rest, and marks those idle for
+// staleDays or more as stale. A secret that was never accessed is idle since
+// it last changed, so a new secret isn't stale straight away.
+func Audit(secrets []models.Secret, staleDays int, now time.Time) []AuditEntry {
+	entries := make([]AuditEntry, len(secrets))
+	for i, secret := range secrets {
+		since := secret.LastAccessedDate
+		if since == nil {
+			since = secret.LastChangedDate
+		}
+		entries[i] = AuditEntry{Secret: secret, IdleDays: -1, Stale: true}
+		if since != nil {
+			entries[i].IdleDays = max(int(now.Sub(*since).Hours()/24), 0)
+			entries[i].Stale = entries[i].IdleDays >= staleDays
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].Secret.LastAccessedDate, entries[j].Secret.LastAccessedDate
+		switch {
+		case a == nil && b != nil:
+			return true
+		case a != nil && b == nil:
+			return false
+		case a != nil && !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return strings.ToLower(entries[i].Secret.Name) < strings.ToLower(entries[j].Secret.Name)
+	})
+	return entries
+}
+
+// Totals counts the secrets, stale secrets and never accessed secrets
+func Totals(entries []AuditEntry) AuditTotals {
+	totals := AuditTotals{Secrets: len(entries)}
+	for _, entry := range entries {
+		if entry.Stale {
+			totals.Stale++
+		}
+		if entry.NeverAccessed() {
+			totals.NeverAccessed++
+		}
+	}
+	return totals
+}
+
+// auditEntry is one secret in a JSON audit
+type auditEntry struct {
+	Name         string     `json:"name"`
+	ARN          string     `json:"arn"`
+	LastAccessed *time.Time `json:"last_accessed,omitempty"`
+	LastChanged  *time.Time `json:"last_changed,omitempty"`
+	IdleDays     *int       `json:"idle_days,omitempty"`
+	Stale        bool       `json:"stale"`
+}
+
+// auditColumns are the CSV header
+var auditColumns = []string{"name", "arn", "last_accessed", "last_changed", "idle_days", "stale"}
+
+// EncodeAudit renders an audit in the order given, with the dates and idle
+// days it was based on. Unknown idle days are left out.
+func EncodeAudit(entries []AuditEntry, format ReportFormat) ([]byte, error) {
+	if format == ReportJSON {
+		encoded := make([]auditEntry, len(entries))
+		for i, entry := range entries {
+			encoded[i] = auditEntry{
+				Name:         entry.Secret.Name,
+				ARN:          entry.Secret.ARN,
+				LastAccessed: entry.Secret.LastAccessedDate,
+				LastChanged:  entry.Secret.LastChangedDate,
+				Stale:        entry.Stale,
+			}
+			if entry.IdleDays >= 0 {
+				encoded[i].IdleDays = &entry.IdleDays
+			}
+		}
+		data, err := json.MarshalIndent(encoded, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(auditColumns); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	for _, entry := range entries {
+		idleDays := ""
+		if entry.IdleDays >= 0 {
+			idleDays = strconv.Itoa(entry.IdleDays)
+		}
+		record := []string{
+			entry.Secret.Name,
+			entry.Secret.ARN,
+			formatDate(entry.Secret.LastAccessedDate),
+			formatDate(entry.Secret.LastChangedDate),
+			idleDays,
+			strconv.FormatBool(entry.Stale),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to encode CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
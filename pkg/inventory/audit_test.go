@@ -0,0 +1,54 @@
+package inventory
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestAuditSortsByLastAccessAndMarksStale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		date := now.AddDate(0, 0, -days)
+		return &date
+	}
+	secrets := []models.Secret{
+		{Name: "recent", LastAccessedDate: daysAgo(2)},
+		{Name: "old", LastAccessedDate: daysAgo(200)},
+		{Name: "new-unused", LastChangedDate: daysAgo(5)},
+		{Name: "unknown"},
+	}
+
+	entries := Audit(secrets, 90, now)
+	var order []string
+	for _, entry := range entries {
+		order = append(order, entry.Secret.Name)
+	}
+	if got := strings.Join(order, ","); got != "new-unused,unknown,old,recent" {
+		t.Fatalf("unexpected order %s", got)
+	}
+
+	stale := map[string]bool{}
+	for _, entry := range entries {
+		stale[entry.Secret.Name] = entry.Stale
+	}
+	if !stale["old"] || !stale["unknown"] || stale["recent"] || stale["new-unused"] {
+		t.Fatalf("unexpected stale secrets %v", stale)
+	}
+
+	totals := Totals(entries)
+	if totals != (AuditTotals{Secrets: 4, Stale: 2, NeverAccessed: 2}) {
+		t.Fatalf("unexpected totals %+v", totals)
+	}
+
+	data, err := EncodeAudit(entries, ReportCSV)
+	if err != nil {
+		t.Fatalf("EncodeAudit returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if lines[0] != "name,arn,last_accessed,last_changed,idle_days,stale" || lines[2] != "unknown,,,,,true" {
+		t.Fatalf("unexpected CSV %q", lines)
+	}
+}
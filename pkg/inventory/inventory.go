@@ -0,0 +1,328 @@
+// Package inventory keeps track of the secrets in an account and region. The
+// UI and the CLI subcommands both read secrets through it.
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// DefaultPageSize is how many secrets are requested per ListSecrets call
+const DefaultPageSize int32 = 50
+
+// Source lists secrets from Secrets Manager. *aws.Client implements it.
+type Source interface {
+	ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error)
+	FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error)
+}
+
+// Page is one page of ListSecrets results
+type Page struct {
+	Secrets   []models.Secret
+	NextToken *string // nil on the last page
+}
+
+// EventKind says what changed in an inventory
+type EventKind int
+
+const (
+	PageLoaded EventKind = iota // A page was fetched from AWS
+	Reset                       // Cached pages were dropped
+)
+
+// Event is sent to subscribers when the inventory changes
+type Event struct {
+	Kind EventKind
+	Page int // Index of the loaded page for PageLoaded
+}
+
+// Service loads secrets page by page and caches the pages it has seen, so
+// moving back and forth does not call AWS again. It is safe for concurrent
+// use.
+type Service struct {
+	source   Source
+	pageSize int32
+
+	mu          sync.Mutex
+	pages       []Page
+	generation  int // Incremented by Reset, so stale loads are not cached
+	subscribers map[int]func(Event)
+	nextID      int
+}
+
+// New creates an inventory backed by source
+func New(source Source, pageSize int32) *Service {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	return &Service{
+		source:      source,
+		pageSize:    pageSize,
+		subscribers: make(map[int]func(Event)),
+	}
+}
+
+// Page returns page index, fetching it when it isn't cached. Pages are
+// fetched in order, so index can be at most one past the last cached page.
+func (s *Service) Page(ctx context.Context, index int) (Page, error) {
+	s.mu.Lock()
+	if index < len(s.pages) {
+		page := s.pages[index]
+		s.mu.Unlock()
+		return page, nil
+	}
+	if index > len(s.pages) {
+		s.mu.Unlock()
+		return Page{}, fmt.Errorf("page %d requested before page %d was loaded", index+1, len(s.pages)+1)
+	}
+
+	var token *string
+	if index > 0 {
+		token = s.pages[index-1].NextToken
+		if token == nil {
+			s.mu.Unlock()
+			return Page{}, fmt.Errorf("there is no page %d", index+1)
+		}
+	}
+	generation := s.generation
+	s.mu.Unlock()
+
+	secrets, nextToken, err := s.source.ListSecrets(ctx, s.pageSize, token)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Secrets: secrets, NextToken: nextToken}
+
+	s.mu.Lock()
+	// Only cache the page if nothing changed while it was loading
+	if s.generation == generation && index == len(s.pages) {
+		s.pages = append(s.pages, page)
+	}
+	s.mu.Unlock()
+
+	s.notify(Event{Kind: PageLoaded, Page: index})
+	return page, nil
+}
+
+// Cached returns page index if it has been loaded
+func (s *Service) Cached(index int) (Page, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.pages) {
+		return Page{}, false
+	}
+	return s.pages[index], true
+}
+
+// HasNext reports whether there is a page after index
+func (s *Service) HasNext(index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.pages) {
+		return false
+	}
+	return index+1 < len(s.pages) || s.pages[index].NextToken != nil
+}
+
+// Loaded returns the secrets of every cached page, in order
+func (s *Service) Loaded() []models.Secret {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var secrets []models.Secret
+	for _, page := range s.pages {
+		secrets = append(secrets, page.Secrets...)
+	}
+	return secrets
+}
+
+// LoadAll fetches the pages after the last cached one until the last page,
+// calling progress after each with the number of pages and secrets loaded,
+// and returns every secret. Pages fetched before an error stay cached, so
+// it carries on from there when called again. Throttled calls are retried
+// by the source.
+func (s *Service) LoadAll(ctx context.Context, progress func(pages, secrets int)) ([]models.Secret, error) {
+	for !s.AllLoaded() {
+		s.mu.Lock()
+		index := len(s.pages)
+		s.mu.Unlock()
+
+		if _, err := s.Page(ctx, index); err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(index+1, len(s.Loaded()))
+		}
+	}
+	return s.Loaded(), nil
+}
+
+// AllLoaded reports whether every page has been fetched
+func (s *Service) AllLoaded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pages) > 0 && s.pages[len(s.pages)-1].NextToken == nil
+}
+
+// Reset drops the cached pages, so the next Page call fetches fresh results
+func (s *Service) Reset() {
+	s.mu.Lock()
+	s.pages = nil
+	s.generation++
+	s.mu.Unlock()
+
+	s.notify(Event{Kind: Reset})
+}
+
+// SetSource replaces the source with one for the same account and region,
+// e.g. after its credentials are renewed. Cached pages are kept.
+func (s *Service) SetSource(source Source) {
+	s.mu.Lock()
+	s.source = source
+	s.mu.Unlock()
+}
+
+// Find lists every secret whose name starts with prefix
+func (s *Service) Find(ctx context.Context, prefix string) ([]models.Secret, error) {
+	return s.source.FindSecrets(ctx, prefix)
+}
+
+// Subscribe calls fn after each change. fn runs on the goroutine that made
+// the change, so it must not block. The returned function unsubscribes.
+func (s *Service) Subscribe(fn func(Event)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.subscribers[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
+func (s *Service) notify(event Event) {
+	s.mu.Lock()
+	subscribers := make([]func(Event), 0, len(s.subscribers))
+	for _, fn := range s.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// filterScopes are the prefixes that scope a filter to one field, with the
+// matcher for each. Matchers are given the rest of the query in lower case.
+var filterScopes = []struct {
+	prefix string
+	match  func(models.Secret, string) bool
+}{
+	{"name:", matchName},
+	{"desc:", matchDescription},
+	{"tag:", matchTag},
+	{"arn:", matchARN},
+	{"kms:", matchKMSKey},
+}
+
+// Filter returns the secrets whose name, description, ARN, or a tag key or
+// value contains query, ignoring case. A prefix scopes the query to one of
+// them: name:, desc:, arn:, or tag:, where tag:<key>=<value> matches the
+// value of one tag. kms:<key> matches the KMS key ID, ARN or alias, and
+// kms:default the secrets encrypted with the aws/secretsmanager key.
+func Filter(secrets []models.Secret, query string) []models.Secret {
+	if query == "" {
+		return secrets
+	}
+
+	match := matchAny
+	lowerQuery := strings.ToLower(query)
+	for _, scope := range filterScopes {
+		if rest, ok := strings.CutPrefix(lowerQuery, scope.prefix); ok {
+			lowerQuery = strings.TrimSpace(rest)
+			match = scope.match
+			break
+		}
+	}
+
+	filtered := []models.Secret{}
+	for _, secret := range secrets {
+		if match(secret, lowerQuery) {
+			filtered = append(filtered, secret)
+		}
+	}
+	return filtered
+}
+
+// NameTerm returns the text a filter query looks for in secret names, in
+// lower case, or "" when the query is scoped to another field
+func NameTerm(query string) string {
+	lowerQuery := strings.ToLower(query)
+	if rest, ok := strings.CutPrefix(lowerQuery, "name:"); ok {
+		return strings.TrimSpace(rest)
+	}
+	for _, scope := range filterScopes {
+		if strings.HasPrefix(lowerQuery, scope.prefix) {
+			return ""
+		}
+	}
+	return lowerQuery
+}
+
+// matchAny reports whether the name, description, ARN or a tag contains
+// query
+func matchAny(secret models.Secret, query string) bool {
+	return matchName(secret, query) || matchDescription(secret, query) ||
+		matchARN(secret, query) || matchTag(secret, query)
+}
+
+// matchName reports whether a secret's name contains query
+func matchName(secret models.Secret, query string) bool {
+	return strings.Contains(strings.ToLower(secret.Name), query)
+}
+
+// matchDescription reports whether a secret's description contains query
+func matchDescription(secret models.Secret, query string) bool {
+	return strings.Contains(strings.ToLower(secret.Description), query)
+}
+
+// matchARN reports whether a secret's ARN contains query
+func matchARN(secret models.Secret, query string) bool {
+	return strings.Contains(strings.ToLower(secret.ARN), query)
+}
+
+// matchTag reports whether a tag key or value of a secret contains query.
+// A query of key=value matches the value of the tag with that key instead.
+func matchTag(secret models.Secret, query string) bool {
+	wantKey, wantValue, byKey := strings.Cut(query, "=")
+	for key, value := range secret.Tags {
+		key, value = strings.ToLower(key), strings.ToLower(value)
+		if byKey {
+			if key == strings.TrimSpace(wantKey) && strings.Contains(value, strings.TrimSpace(wantValue)) {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(key, query) || strings.Contains(value, query) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKMSKey reports whether a secret's KMS key contains key, or is the
+// default key when key is "default"
+func matchKMSKey(secret models.Secret, key string) bool {
+	if key == "default" {
+		return models.UsesDefaultKey(secret.KmsKeyID)
+	}
+	return strings.Contains(strings.ToLower(models.KMSKeyLabel(secret.KmsKeyID)), key) ||
+		strings.Contains(strings.ToLower(secret.KmsKeyID), key)
+}
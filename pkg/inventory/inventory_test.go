@@ -0,0 +1,182 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// fakeSource serves pages of two secrets each
+type fakeSource struct {
+	total int
+	calls int
+}
+
+func (f *fakeSource) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	f.calls++
+	start := 0
+	if nextToken != nil {
+		fmt.Sscanf(*nextToken, "%d", &start)
+	}
+
+	var secrets []models.Secret
+	for i := start; i < start+2 && i < f.total; i++ {
+		secrets = append(secrets, models.Secret{Name: fmt.Sprintf("secret-%d", i)})
+	}
+	if start+2 >= f.total {
+		return secrets, nil, nil
+	}
+	token := fmt.Sprintf("%d", start+2)
+	return secrets, &token, nil
+}
+
+func (f *fakeSource) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	return nil, nil
+}
+
+func TestServicePagesAndCaches(t *testing.T) {
+	source := &fakeSource{total: 3}
+	inv := New(source, 2)
+	ctx := context.Background()
+
+	var events []Event
+	unsubscribe := inv.Subscribe(func(e Event) { events = append(events, e) })
+
+	if _, err := inv.Page(ctx, 1); err == nil {
+		t.Fatal("expected page 2 to need page 1 first")
+	}
+
+	first, err := inv.Page(ctx, 0)
+	if err != nil || len(first.Secrets) != 2 {
+		t.Fatalf("expected the first page, got %+v, %v", first, err)
+	}
+	if !inv.HasNext(0) {
+		t.Fatal("expected a second page")
+	}
+	second, err := inv.Page(ctx, 1)
+	if err != nil || len(second.Secrets) != 1 || inv.HasNext(1) {
+		t.Fatalf("expected a final page of one secret, got %+v, %v", second, err)
+	}
+
+	if _, err := inv.Page(ctx, 0); err != nil || source.calls != 2 {
+		t.Fatalf("expected the first page from the cache, got %d calls", source.calls)
+	}
+	if got := inv.Loaded(); len(got) != 3 || got[2].Name != "secret-2" {
+		t.Fatalf("expected all loaded secrets in order, got %v", got)
+	}
+
+	inv.Reset()
+	if _, ok := inv.Cached(0); ok {
+		t.Fatal("expected Reset to drop cached pages")
+	}
+
+	unsubscribe()
+	inv.Reset()
+	if len(events) != 3 || events[1].Page != 1 || events[2].Kind != Reset {
+		t.Fatalf("expected two loads and one reset before unsubscribing, got %+v", events)
+	}
+}
+
+func TestLoadAllCarriesOnFromTheCachedPages(t *testing.T) {
+	source := &fakeSource{total: 7}
+	inv := New(source, 2)
+	ctx := context.Background()
+
+	if _, err := inv.Page(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	var progress []int
+	secrets, err := inv.LoadAll(ctx, func(pages, secrets int) { progress = append(progress, pages, secrets) })
+	if err != nil || len(secrets) != 7 || secrets[6].Name != "secret-6" {
+		t.Fatalf("expected every secret in order, got %v, %v", secrets, err)
+	}
+	if source.calls != 4 || fmt.Sprint(progress) != "[2 4 3 6 4 7]" {
+		t.Fatalf("expected the three remaining pages, reported as they loaded, got %d calls and %v", source.calls, progress)
+	}
+	if !inv.AllLoaded() {
+		t.Fatal("expected every page to be loaded")
+	}
+
+	if _, err := inv.LoadAll(ctx, nil); err != nil || source.calls != 4 {
+		t.Fatalf("expected nothing more to fetch, got %d calls, %v", source.calls, err)
+	}
+}
+
+func TestFilterIgnoresCase(t *testing.T) {
+	secrets := []models.Secret{{Name: "App/DB"}, {Name: "app/api"}, {Name: "billing"}}
+
+	if got := Filter(secrets, "APP"); len(got) != 2 {
+		t.Fatalf("expected both app secrets, got %v", got)
+	}
+	if got := Filter(secrets, ""); len(got) != 3 {
+		t.Fatalf("expected an empty query to keep everything, got %v", got)
+	}
+}
+
+func TestFilterSearchesDescriptionsTagsAndARNs(t *testing.T) {
+	secrets := []models.Secret{
+		{Name: "app/db", Description: "Postgres primary", ARN: "arn:aws:secretsmanager:eu-west-2:111111111111:secret:app/db-AbCdEf",
+			Tags: map[string]string{"Team": "payments", "Env": "prod"}},
+		{Name: "app/api", Description: "Stripe key for payments", ARN: "arn:aws:secretsmanager:eu-west-2:222222222222:secret:app/api-GhIjKl",
+			Tags: map[string]string{"Env": "staging"}},
+		{Name: "payments/legacy", ARN: "arn:aws:secretsmanager:eu-west-2:111111111111:secret:payments/legacy-MnOpQr"},
+	}
+
+	names := func(got []models.Secret) string {
+		var names []string
+		for _, secret := range got {
+			names = append(names, secret.Name)
+		}
+		return strings.Join(names, " ")
+	}
+	cases := map[string]string{
+		"payments":          "app/db app/api payments/legacy",
+		"name:payments":     "payments/legacy",
+		"desc:PAYMENTS":     "app/api",
+		"tag:payments":      "app/db",
+		"tag:env=prod":      "app/db",
+		"tag:Env = stag":    "app/api",
+		"tag:team=staging":  "",
+		"arn:222222222222":  "app/api",
+		"arn: 111111111111": "app/db payments/legacy",
+	}
+	for query, want := range cases {
+		if got := names(Filter(secrets, query)); got != want {
+			t.Errorf("Filter(%q) = %q, want %q", query, got, want)
+		}
+	}
+
+	for query, want := range map[string]string{"Pay": "pay", "name: Pay": "pay", "tag:pay": ""} {
+		if got := NameTerm(query); got != want {
+			t.Errorf("NameTerm(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestFilterAndSortByKMSKey(t *testing.T) {
+	secrets := []models.Secret{
+		{Name: "app/web", KmsKeyID: "arn:aws:kms:eu-west-2:123456789012:alias/payments"},
+		{Name: "app/db"},
+		{Name: "app/api", KmsKeyID: "arn:aws:kms:eu-west-2:123456789012:alias/aws/secretsmanager"},
+		{Name: "app/kms", KmsKeyID: "arn:aws:kms:eu-west-2:123456789012:key/1234abcd"},
+	}
+
+	if got := Filter(secrets, "kms:default"); len(got) != 2 || got[0].Name != "app/db" || got[1].Name != "app/api" {
+		t.Fatalf("expected the secrets on the default key, got %v", got)
+	}
+	if got := Filter(secrets, "KMS:Payments"); len(got) != 1 || got[0].Name != "app/web" {
+		t.Fatalf("expected the secret on the payments key, got %v", got)
+	}
+
+	sorted := Sort(secrets, SortKMSKey)
+	var names []string
+	for _, secret := range sorted {
+		names = append(names, secret.Name)
+	}
+	if got := strings.Join(names, " "); got != "app/api app/db app/web app/kms" {
+		t.Fatalf("expected the default key first, then grouped by key, got %s", got)
+	}
+}
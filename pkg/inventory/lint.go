@@ -0,0 +1,301 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// Names of the lint checks, as used in the disabled setting
+const (
+	CheckRequiredTags = "required_tags"
+	CheckDefaultKey   = "default_kms_key"
+	CheckNoRotation   = "no_rotation"
+	CheckNotAccessed  = "not_accessed"
+	CheckBroadPolicy  = "broad_policy"
+)
+
+// LintCheck is one of the compliance checks run over the secrets
+type LintCheck struct {
+	Name  string
+	Title string
+	// Weight is how much a failure counts against the score, from 1 for
+	// hygiene to 3 for exposure
+	Weight int
+}
+
+// lintChecks are every check, in the order findings are listed
+var lintChecks = []LintCheck{
+	{Name: CheckBroadPolicy, Title: "Resource policy allows anyone", Weight: 3},
+	{Name: CheckDefaultKey, Title: "Encrypted with the default aws/secretsmanager key", Weight: 2},
+	{Name: CheckNoRotation, Title: "Rotation not configured", Weight: 2},
+	{Name: CheckRequiredTags, Title: "Missing required tags", Weight: 1},
+	{Name: CheckNotAccessed, Title: "Not accessed recently", Weight: 1},
+}
+
+// LintRules configures the lint checks. Every check runs unless disabled;
+// required_tags only runs when tags are required.
+type LintRules struct {
+	RequiredTags []string `json:"required_tags,omitempty" yaml:"required_tags"` // Tag keys every secret must have
+	Disabled     []string `json:"disabled,omitempty" yaml:"disabled"`           // Names of checks to skip
+}
+
+// Validate reports disabled checks that don't exist
+func (r LintRules) Validate() error {
+	var errs []error
+	for _, name := range r.Disabled {
+		if findCheck(name) == nil {
+			errs = append(errs, fmt.Errorf("lint.disabled has unknown check %q", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Checks returns the checks that run with these rules
+func (r LintRules) Checks() []LintCheck {
+	var checks []LintCheck
+	for _, check := range lintChecks {
+		disabled := false
+		for _, name := range r.Disabled {
+			disabled = disabled || name == check.Name
+		}
+		if check.Name == CheckRequiredTags && len(r.RequiredTags) == 0 {
+			disabled = true
+		}
+		if !disabled {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// NeedsPolicies reports whether the rules check resource policies, which
+// have to be fetched one secret at a time
+func (r LintRules) NeedsPolicies() bool {
+	for _, check := range r.Checks() {
+		if check.Name == CheckBroadPolicy {
+			return true
+		}
+	}
+	return false
+}
+
+// findCheck returns the check with the given name, or nil
+func findCheck(name string) *LintCheck {
+	for i := range lintChecks {
+		if lintChecks[i].Name == name {
+			return &lintChecks[i]
+		}
+	}
+	return nil
+}
+
+// LintFinding is a check a secret failed, with what was wrong
+type LintFinding struct {
+	Check  LintCheck
+	Detail string
+}
+
+// LintEntry is a secret with the checks it failed
+type LintEntry struct {
+	Secret   models.Secret
+	Findings []LintFinding
+}
+
+// LintReport is the result of running the checks over the secrets
+type LintReport struct {
+	Checks  []LintCheck
+	Entries []LintEntry // Secrets with findings first, the worst first
+	// Score is the share of the weighted checks passed, from 0 to 100
+	Score int
+	// Failed counts the secrets failing each check, by check name
+	Failed map[string]int
+	// Unchecked counts the secrets whose resource policy couldn't be read,
+	// which the policy check skipped
+	Unchecked int
+}
+
+// LintInput is what the checks are run over
+type LintInput struct {
+	Secrets []models.Secret
+	// Policies are the resource policies by secret name, "" for none.
+	// Secrets missing from it are skipped by the policy check.
+	Policies  map[string]string
+	StaleDays int
+	Now       time.Time
+}
+
+// Lint runs the checks the rules enable over the secrets and scores them
+func Lint(input LintInput, rules LintRules) LintReport {
+	report := LintReport{Checks: rules.Checks(), Failed: map[string]int{}}
+	total, failed := 0, 0
+	audited := Audit(input.Secrets, input.StaleDays, input.Now)
+	idle := make(map[string]AuditEntry, len(audited))
+	for _, entry := range audited {
+		idle[entry.Secret.Name] = entry
+	}
+
+	for _, secret := range input.Secrets {
+		entry := LintEntry{Secret: secret}
+		for _, check := range report.Checks {
+			detail, ran := runCheck(check.Name, secret, input, rules, idle[secret.Name])
+			if !ran {
+				if check.Name == CheckBroadPolicy {
+					report.Unchecked++
+				}
+				continue
+			}
+			total += check.Weight
+			if detail != "" {
+				failed += check.Weight
+				report.Failed[check.Name]++
+				entry.Findings = append(entry.Findings, LintFinding{Check: check, Detail: detail})
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	report.Score = 100
+	if total > 0 {
+		report.Score = (total - failed) * 100 / total
+	}
+	sort.SliceStable(report.Entries, func(i, j int) bool {
+		a, b := weight(report.Entries[i].Findings), weight(report.Entries[j].Findings)
+		if a != b {
+			return a > b
+		}
+		return strings.ToLower(report.Entries[i].Secret.Name) < strings.ToLower(report.Entries[j].Secret.Name)
+	})
+	return report
+}
+
+// weight adds up the weights of the failed checks
+func weight(findings []LintFinding) int {
+	sum := 0
+	for _, finding := range findings {
+		sum += finding.Check.Weight
+	}
+	return sum
+}
+
+// runCheck runs one check on a secret. It returns what is wrong, "" when
+// the secret passes, and false when the check couldn't be run on it.
+func runCheck(name string, secret models.Secret, input LintInput, rules LintRules, idle AuditEntry) (string, bool) {
+	switch name {
+	case CheckRequiredTags:
+		var missing []string
+		for _, key := range rules.RequiredTags {
+			if _, ok := secret.Tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return "missing " + strings.Join(missing, ", "), true
+		}
+	case CheckDefaultKey:
+		if models.UsesDefaultKey(secret.KmsKeyID) {
+			return "encrypted with " + models.DefaultKMSKey, true
+		}
+	case CheckNoRotation:
+		if !secret.RotationEnabled {
+			return "rotation is not enabled", true
+		}
+	case CheckNotAccessed:
+		if idle.Stale {
+			switch {
+			case idle.IdleDays < 0:
+				return "no access or change date", true
+			case idle.NeverAccessed():
+				return fmt.Sprintf("never accessed, changed %d days ago", idle.IdleDays), true
+			default:
+				return fmt.Sprintf("not accessed in %d days", idle.IdleDays), true
+			}
+		}
+	case CheckBroadPolicy:
+		policy, ok := input.Policies[secret.Name]
+		if !ok {
+			return "", false
+		}
+		if BroadPolicy(policy) {
+			return "the resource policy allows any principal without a condition", true
+		}
+	}
+	return "", true
+}
+
+// policyDocument is the part of an IAM policy BroadPolicy reads. Statement
+// may be one statement or a list, and Principal "*" or a map of lists or
+// strings.
+type policyDocument struct {
+	Statement json.RawMessage `json:"Statement"`
+}
+
+// policyStatement is one statement of a policyDocument
+type policyStatement struct {
+	Effect    string          `json:"Effect"`
+	Principal json.RawMessage `json:"Principal"`
+	Condition json.RawMessage `json:"Condition"`
+}
+
+// BroadPolicy reports whether a resource policy has a statement allowing
+// any principal ("*") without a condition to narrow it, which opens the
+// secret to every AWS account. An empty or unreadable policy is not broad.
+func BroadPolicy(policy string) bool {
+	if policy == "" {
+		return false
+	}
+	var document policyDocument
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return false
+	}
+
+	var statements []policyStatement
+	if err := json.Unmarshal(document.Statement, &statements); err != nil {
+		var statement policyStatement
+		if err := json.Unmarshal(document.Statement, &statement); err != nil {
+			return false
+		}
+		statements = []policyStatement{statement}
+	}
+
+	for _, statement := range statements {
+		if statement.Effect == "Allow" && anyPrincipal(statement.Principal) && len(statement.Condition) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPrincipal reports whether a statement's principal is everyone: "*" or
+// {"AWS": "*"}, alone or in a list
+func anyPrincipal(principal json.RawMessage) bool {
+	var wildcard string
+	if json.Unmarshal(principal, &wildcard) == nil {
+		return wildcard == "*"
+	}
+	var byType map[string]json.RawMessage
+	if json.Unmarshal(principal, &byType) != nil {
+		return false
+	}
+	aws, ok := byType["AWS"]
+	if !ok {
+		return false
+	}
+	if json.Unmarshal(aws, &wildcard) == nil {
+		return wildcard == "*"
+	}
+	var list []string
+	if json.Unmarshal(aws, &list) == nil {
+		for _, entry := range list {
+			if entry == "*" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestLintScoresFindingsPerSecret(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -2)
+	old := now.AddDate(0, 0, -200)
+	secrets := []models.Secret{
+		{Name: "good", LastAccessedDate: &recent, RotationEnabled: true,
+			KmsKeyID: "arn:aws:kms:eu-west-2:123456789012:key/1234", Tags: map[string]string{"Owner": "payments"}},
+		{Name: "bad", LastAccessedDate: &old},
+		{Name: "unreadable", LastAccessedDate: &recent, RotationEnabled: true,
+			KmsKeyID: "alias/payments", Tags: map[string]string{"Owner": "payments"}},
+	}
+	policies := map[string]string{
+		"good": "",
+		"bad":  `{"Version":"2012-10-17","Statement":{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"secretsmanager:GetSecretValue","Resource":"*"}}`,
+	}
+
+	report := Lint(LintInput{Secrets: secrets, Policies: policies, StaleDays: 90, Now: now},
+		LintRules{RequiredTags: []string{"Owner"}})
+
+	if len(report.Checks) != 5 {
+		t.Fatalf("expected every check to run, got %d", len(report.Checks))
+	}
+	if report.Entries[0].Secret.Name != "bad" || len(report.Entries[0].Findings) != 5 {
+		t.Fatalf("expected bad first with every finding, got %+v", report.Entries[0])
+	}
+	if report.Entries[0].Findings[0].Check.Name != CheckBroadPolicy {
+		t.Fatalf("expected the broad policy to be listed first, got %+v", report.Entries[0].Findings)
+	}
+	for _, entry := range report.Entries[1:] {
+		if len(entry.Findings) != 0 {
+			t.Fatalf("expected %s to pass, got %+v", entry.Secret.Name, entry.Findings)
+		}
+	}
+	if report.Unchecked != 1 || report.Failed[CheckNoRotation] != 1 {
+		t.Fatalf("unexpected counts: unchecked %d, failed %v", report.Unchecked, report.Failed)
+	}
+	// good passes 9 points, unreadable 6 without its policy, bad fails 9
+	if report.Score != 62 {
+		t.Fatalf("expected a score of 62, got %d", report.Score)
+	}
+}
+
+func TestLintRulesChooseChecks(t *testing.T) {
+	rules := LintRules{Disabled: []string{CheckBroadPolicy, CheckNotAccessed}}
+	var names []string
+	for _, check := range rules.Checks() {
+		names = append(names, check.Name)
+	}
+	if len(names) != 2 || names[0] != CheckDefaultKey || names[1] != CheckNoRotation {
+		t.Fatalf("expected required_tags to be skipped without tags, got %v", names)
+	}
+	if rules.NeedsPolicies() {
+		t.Fatal("expected no policies to be needed with broad_policy disabled")
+	}
+	if err := (LintRules{Disabled: []string{"rotation"}}).Validate(); err == nil {
+		t.Fatal("expected an unknown check to be reported")
+	}
+}
+
+func TestBroadPolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		broad  bool
+	}{
+		{"", false},
+		{"not json", false},
+		{`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"*"}]}`, true},
+		{`{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::123456789012:root","*"]}}]}`, true},
+		{`{"Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-123"}}}]}`, false},
+		{`{"Statement":[{"Effect":"Deny","Principal":"*"}]}`, false},
+		{`{"Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"}}]}`, false},
+	}
+	for _, tt := range tests {
+		if got := BroadPolicy(tt.policy); got != tt.broad {
+			t.Errorf("BroadPolicy(%s) = %t, want %t", tt.policy, got, tt.broad)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// ReportFormat is an output format for inventory reports
+type ReportFormat string
+
+const (
+	ReportCSV  ReportFormat = "csv"
+	ReportJSON ReportFormat = "json"
+)
+
+// ParseReportFormat converts a format name such as "csv" or "json" to a ReportFormat
+func ParseReportFormat(name string) (ReportFormat, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return ReportCSV, nil
+	case "json":
+		return ReportJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported report format %q (expected csv or json)", name)
+	}
+}
+
+// ReportFormatForPath picks the report format from a file extension, defaulting to CSV
+func ReportFormatForPath(path string) ReportFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ReportJSON
+	}
+	return ReportCSV
+}
+
+// reportEntry is one secret in a JSON report. Dates are omitted when AWS
+// didn't return them.
+type reportEntry struct {
+	Name         string            `json:"name"`
+	ARN          string            `json:"arn"`
+	Description  string            `json:"description,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+	LastChanged  *time.Time        `json:"last_changed,omitempty"`
+	LastAccessed *time.Time        `json:"last_accessed,omitempty"`
+	LastRotated  *time.Time        `json:"last_rotated,omitempty"`
+	KMSKey       string            `json:"kms_key"`
+	DefaultKey   bool              `json:"default_kms_key"`
+}
+
+// reportColumns are the CSV header
+var reportColumns = []string{"name", "arn", "description", "tags", "last_changed", "last_accessed", "last_rotated", "kms_key", "default_kms_key"}
+
+// EncodeReport renders the metadata of secrets, never their values, for
+// audits and spreadsheets. Secrets are sorted by name. In CSV, tags are
+// written as key=value pairs separated by semicolons and dates as RFC 3339.
+// The KMS key is written as AWS reports it, or as aws/secretsmanager for the
+// default key, which default_kms_key flags for compliance checks.
+func EncodeReport(secrets []models.Secret, format ReportFormat) ([]byte, error) {
+	sorted := make([]models.Secret, len(secrets))
+	copy(sorted, secrets)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	if format == ReportJSON {
+		entries := make([]reportEntry, len(sorted))
+		for i, secret := range sorted {
+			entries[i] = reportEntry{
+				Name:         secret.Name,
+				ARN:          secret.ARN,
+				Description:  secret.Description,
+				Tags:         secret.Tags,
+				LastChanged:  secret.LastChangedDate,
+				LastAccessed: secret.LastAccessedDate,
+				LastRotated:  secret.LastRotatedDate,
+				KMSKey:       reportKMSKey(secret.KmsKeyID),
+				DefaultKey:   models.UsesDefaultKey(secret.KmsKeyID),
+			}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(reportColumns); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	for _, secret := range sorted {
+		record := []string{
+			secret.Name,
+			secret.ARN,
+			secret.Description,
+			formatTags(secret.Tags),
+			formatDate(secret.LastChangedDate),
+			formatDate(secret.LastAccessedDate),
+			formatDate(secret.LastRotatedDate),
+			reportKMSKey(secret.KmsKeyID),
+			strconv.FormatBool(models.UsesDefaultKey(secret.KmsKeyID)),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to encode CSV: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// reportKMSKey returns the KMS key as AWS reports it, or the default key's
+// alias when AWS leaves it out
+func reportKMSKey(kmsKeyID string) string {
+	if kmsKeyID == "" {
+		return models.DefaultKMSKey
+	}
+	return kmsKeyID
+}
+
+// formatTags writes tags as key=value pairs sorted by key
+func formatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+// formatDate writes a date as RFC 3339 in UTC, or nothing when it is unset
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.UTC().Format(time.RFC3339)
+}
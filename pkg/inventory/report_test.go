@@ -0,0 +1,78 @@
+package inventory
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func reportSecrets() []models.Secret {
+	changed := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []models.Secret{
+		{Name: "app/web", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/web-GhIjKl"},
+		{
+			Name:            "app/db",
+			ARN:             "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf",
+			Description:     "Database, primary",
+			Tags:            map[string]string{"team": "core", "env": "prod"},
+			LastChangedDate: &changed,
+			LastRotatedDate: &changed,
+			KmsKeyID:        "arn:aws:kms:eu-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		},
+	}
+}
+
+func TestEncodeReportCSV(t *testing.T) {
+	data, err := EncodeReport(reportSecrets(), ReportCSV)
+	if err != nil {
+		t.Fatalf("EncodeReport returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"name,arn,description,tags,last_changed,last_accessed,last_rotated,kms_key,default_kms_key",
+		`app/db,arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf,"Database, primary",env=prod;team=core,2025-03-01T12:00:00Z,,2025-03-01T12:00:00Z,arn:aws:kms:eu-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab,false`,
+		"app/web,arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/web-GhIjKl,,,,,,aws/secretsmanager,true",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got %q", len(want), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+}
+
+func TestEncodeReportJSON(t *testing.T) {
+	data, err := EncodeReport(reportSecrets(), ReportJSON)
+	if err != nil {
+		t.Fatalf("EncodeReport returned error: %v", err)
+	}
+
+	var entries []map[string]any
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("expected a JSON array, got %s", data)
+	}
+	if len(entries) != 2 || entries[0]["name"] != "app/db" || entries[0]["last_rotated"] != "2025-03-01T12:00:00Z" {
+		t.Fatalf("expected entries sorted by name with their dates, got %v", entries)
+	}
+	if _, ok := entries[1]["last_changed"]; ok {
+		t.Fatalf("expected missing dates to be omitted, got %v", entries[1])
+	}
+	if entries[0]["default_kms_key"] != false || entries[1]["kms_key"] != "aws/secretsmanager" || entries[1]["default_kms_key"] != true {
+		t.Fatalf("expected the default KMS key to be flagged, got %v", entries)
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	if ReportFormatForPath("audit.JSON") != ReportJSON || ReportFormatForPath("audit.csv") != ReportCSV || ReportFormatForPath("audit") != ReportCSV {
+		t.Fatal("expected the format to follow the extension, defaulting to CSV")
+	}
+	if _, err := ParseReportFormat("yaml"); err == nil {
+		t.Fatal("expected an unsupported format to be rejected")
+	}
+}
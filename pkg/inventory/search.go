@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// IsPattern reports whether name contains glob metacharacters
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// Search lists the secrets matching a glob pattern, using the literal start
+// of the pattern to narrow the listing server-side
+func (s *Service) Search(ctx context.Context, pattern string) ([]models.Secret, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	secrets, err := s.Find(ctx, serverPrefix(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return matchSecrets(secrets, pattern)
+}
+
+// literalPrefix returns the part of a glob before its first metacharacter
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// serverPrefix returns the secret name prefix that narrows a pattern
+// server-side. ARN patterns are narrowed by the name that follows ":secret:".
+func serverPrefix(pattern string) string {
+	if !strings.HasPrefix(pattern, "arn:") {
+		return literalPrefix(pattern)
+	}
+
+	const marker = ":secret:"
+	prefix := literalPrefix(pattern)
+	if i := strings.Index(prefix, marker); i >= 0 {
+		return prefix[i+len(marker):]
+	}
+	return ""
+}
+
+// matchSecrets filters secrets by a glob pattern, matched against the ARN for
+// patterns starting with "arn:" and against the name otherwise. As with
+// path.Match, "*" does not match "/".
+func matchSecrets(secrets []models.Secret, pattern string) ([]models.Secret, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	var matched []models.Secret
+	for _, secret := range secrets {
+		subject := secret.Name
+		if strings.HasPrefix(pattern, "arn:") {
+			subject = secret.ARN
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			matched = append(matched, secret)
+		}
+	}
+	return matched, nil
+}
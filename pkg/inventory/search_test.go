@@ -0,0 +1,50 @@
+package inventory
+
+import (
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestServerPrefix(t *testing.T) {
+	tests := map[string]string{
+		"app/prod/*": "app/prod/",
+		"app/*/db":   "app/",
+		"*":          "",
+		"arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/*": "app/prod/",
+		"arn:aws:secretsmanager:*:*:secret:app/*":                         "",
+	}
+	for pattern, want := range tests {
+		if got := serverPrefix(pattern); got != want {
+			t.Fatalf("serverPrefix(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestMatchSecretsByNameAndARN(t *testing.T) {
+	secrets := []models.Secret{
+		{Name: "app/prod/db", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/db-AbCdEf"},
+		{Name: "app/prod/api/key", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/api/key-GhIjKl"},
+		{Name: "app/staging/db", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/staging/db-MnOpQr"},
+	}
+
+	matched, err := matchSecrets(secrets, "app/prod/*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matched) != 1 || matched[0].Name != "app/prod/db" {
+		t.Fatalf("expected only app/prod/db, got %v", matched)
+	}
+
+	matched, err = matchSecrets(secrets, "arn:aws:secretsmanager:eu-west-2:*:secret:app/*/db-*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matched) != 2 {
+		t.Fatalf("expected both db secrets by ARN, got %v", matched)
+	}
+
+	if _, err := matchSecrets(secrets, "app/[prod"); err == nil {
+		t.Fatal("expected a malformed pattern to be rejected")
+	}
+}
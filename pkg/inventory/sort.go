@@ -0,0 +1,117 @@
+package inventory
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// SortOrder controls the order secrets are displayed in
+type SortOrder int
+
+const (
+	SortDefault        SortOrder = iota // Order returned by AWS
+	SortNameAsc                         // Name A-Z
+	SortNameDesc                        // Name Z-A
+	SortChangedNewest                   // Most recently changed first
+	SortChangedOldest                   // Least recently changed first
+	SortAccessedNewest                  // Most recently accessed first
+	SortKMSKey                          // Grouped by KMS key, the default key first
+	sortOrderCount
+)
+
+// Label returns a short description of the sort order for display
+func (o SortOrder) Label() string {
+	switch o {
+	case SortNameAsc:
+		return "name ↑"
+	case SortNameDesc:
+		return "name ↓"
+	case SortChangedNewest:
+		return "changed (newest)"
+	case SortChangedOldest:
+		return "changed (oldest)"
+	case SortAccessedNewest:
+		return "accessed (newest)"
+	case SortKMSKey:
+		return "KMS key"
+	default:
+		return "default"
+	}
+}
+
+// Next returns the following sort order in the cycle
+func (o SortOrder) Next() SortOrder {
+	return (o + 1) % sortOrderCount
+}
+
+// Sort returns a sorted copy of secrets. Secrets without the relevant
+// date always sort last, and ties fall back to the name.
+func Sort(secrets []models.Secret, order SortOrder) []models.Secret {
+	if order == SortDefault {
+		return secrets
+	}
+
+	sorted := make([]models.Secret, len(secrets))
+	copy(sorted, secrets)
+
+	byName := func(a, b models.Secret) bool {
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+	byDate := func(a, b *time.Time, newestFirst bool) (less bool, decided bool) {
+		switch {
+		case a == nil && b == nil:
+			return false, false
+		case a == nil:
+			return false, true
+		case b == nil:
+			return true, true
+		case a.Equal(*b):
+			return false, false
+		case newestFirst:
+			return a.After(*b), true
+		default:
+			return a.Before(*b), true
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		switch order {
+		case SortNameDesc:
+			return byName(b, a)
+		case SortChangedNewest, SortChangedOldest:
+			if less, decided := byDate(a.LastChangedDate, b.LastChangedDate, order == SortChangedNewest); decided {
+				return less
+			}
+		case SortAccessedNewest:
+			if less, decided := byDate(a.LastAccessedDate, b.LastAccessedDate, true); decided {
+				return less
+			}
+		case SortKMSKey:
+			if less, decided := byKMSKey(a, b); decided {
+				return less
+			}
+		}
+		return byName(a, b)
+	})
+
+	return sorted
+}
+
+// byKMSKey orders secrets on the default key before the others, then by key
+func byKMSKey(a, b models.Secret) (less bool, decided bool) {
+	aDefault, bDefault := models.UsesDefaultKey(a.KmsKeyID), models.UsesDefaultKey(b.KmsKeyID)
+	switch {
+	case aDefault && bDefault:
+		return false, false
+	case aDefault != bDefault:
+		return aDefault, true
+	case a.KmsKeyID == b.KmsKeyID:
+		return false, false
+	default:
+		return a.KmsKeyID < b.KmsKeyID, true
+	}
+}
@@ -0,0 +1,353 @@
+// Package kube reads the Secrets of a Kubernetes namespace through kubectl,
+// so what is mounted in a cluster can be browsed and compared with Secrets
+// Manager. It never writes to the cluster.
+package kube
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// Name is what the UI calls the backend, e.g. in the header
+const Name = "Kubernetes"
+
+// ErrReadOnly is returned by the operations that would change a Secret
+var ErrReadOnly = errors.New("Kubernetes Secrets are read-only in Secret Src")
+
+// ErrUnsupported is returned for the Secrets Manager features Kubernetes
+// has no equivalent of, such as KMS keys
+var ErrUnsupported = errors.New("not supported for Kubernetes Secrets")
+
+// Cluster reads the Secrets of one namespace, as kubectl is configured to
+// reach it: the kubeconfig's current context and namespace unless others
+// are given. It is safe for concurrent use.
+type Cluster struct {
+	context   string // Empty for the kubeconfig's current context
+	namespace string // Empty for the context's namespace
+
+	// run runs kubectl, replaced in tests
+	run func(ctx context.Context, args ...string) ([]byte, error)
+
+	mu     sync.Mutex
+	listed []models.Secret // The last listing, which ListSecrets pages through
+}
+
+// New creates a cluster client. Empty kubeContext and namespace use the
+// kubeconfig's current ones.
+func New(kubeContext, namespace string) *Cluster {
+	return &Cluster{context: kubeContext, namespace: namespace, run: runKubectl}
+}
+
+// runKubectl runs kubectl, returning its output
+func runKubectl(ctx context.Context, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "kubectl", args...).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("kubectl is needed to read Kubernetes Secrets: %w", err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		message := strings.TrimSpace(string(exitErr.Stderr))
+		if message == "" {
+			message = err.Error()
+		}
+		logging.Debugf("kubectl %s failed: %s", args[0], message)
+		return nil, errors.New(message)
+	}
+	return out, err
+}
+
+// kubectl runs kubectl against the cluster's context and namespace
+func (c *Cluster) kubectl(ctx context.Context, args ...string) ([]byte, error) {
+	if c.context != "" {
+		args = append(args, "--context", c.context)
+	}
+	if c.namespace != "" {
+		args = append(args, "--namespace", c.namespace)
+	}
+	return c.run(ctx, args...)
+}
+
+// Resolve looks up the kubeconfig's current context and namespace, for
+// those not given, so they can be shown
+func (c *Cluster) Resolve(ctx context.Context) error {
+	if c.context == "" {
+		out, err := c.run(ctx, "config", "current-context")
+		if err != nil {
+			return fmt.Errorf("failed to read the current kubeconfig context: %w", err)
+		}
+		c.context = strings.TrimSpace(string(out))
+	}
+	if c.namespace == "" {
+		out, err := c.run(ctx, "config", "view", "--minify", "--context", c.context, "--output", "jsonpath={..namespace}")
+		if err != nil {
+			return fmt.Errorf("failed to read the namespace of context %s: %w", c.context, err)
+		}
+		if c.namespace = strings.TrimSpace(string(out)); c.namespace == "" {
+			c.namespace = "default"
+		}
+	}
+	return nil
+}
+
+// Label returns the context and namespace, e.g. "prod/payments", once
+// resolved
+func (c *Cluster) Label() string {
+	context := c.context
+	if context == "" {
+		context = "current context"
+	}
+	if c.namespace == "" {
+		return context
+	}
+	return context + "/" + c.namespace
+}
+
+// object is a Secret as kubectl prints it
+type object struct {
+	Metadata struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace"`
+		CreationTimestamp time.Time         `json:"creationTimestamp"`
+		ResourceVersion   string            `json:"resourceVersion"`
+		Labels            map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Type string            `json:"type"`
+	Data map[string]string `json:"data"` // Base64 encoded
+}
+
+// toSecret converts a Secret's metadata. namespace/name stands in for the
+// ARN, and the labels for tags.
+func (o object) toSecret() models.Secret {
+	created := o.Metadata.CreationTimestamp
+	return models.Secret{
+		Name:            o.Metadata.Name,
+		ARN:             o.Metadata.Namespace + "/" + o.Metadata.Name,
+		Description:     o.Type,
+		LastChangedDate: &created,
+		Tags:            o.Metadata.Labels,
+	}
+}
+
+// ListSecrets returns a page of Secrets. kubectl lists them all at once, so
+// the first page lists them and the token is the offset of the next.
+// Values are read too, as kubectl can't leave them out, but not decoded.
+func (c *Cluster) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	offset := 0
+	if nextToken == nil {
+		out, err := c.kubectl(ctx, "get", "secrets", "--output", "json")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		var list struct {
+			Items []object `json:"items"`
+		}
+		if err := json.Unmarshal(out, &list); err != nil {
+			return nil, nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		c.listed = make([]models.Secret, len(list.Items))
+		for i, item := range list.Items {
+			c.listed[i] = item.toSecret()
+		}
+		sort.Slice(c.listed, func(i, j int) bool { return c.listed[i].Name < c.listed[j].Name })
+	} else {
+		var err error
+		if offset, err = strconv.Atoi(*nextToken); err != nil || offset < 0 || offset > len(c.listed) {
+			return nil, nil, fmt.Errorf("failed to list secrets: invalid next token %q", *nextToken)
+		}
+	}
+
+	end := min(offset+int(max(maxResults, 1)), len(c.listed))
+	secrets := append([]models.Secret(nil), c.listed[offset:end]...)
+	if end == len(c.listed) {
+		return secrets, nil, nil
+	}
+	token := strconv.Itoa(end)
+	return secrets, &token, nil
+}
+
+// FindSecrets returns every Secret whose name starts with prefix, ignoring
+// case
+func (c *Cluster) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	var matched []models.Secret
+	var token *string
+	for {
+		secrets, next, err := c.ListSecrets(ctx, 100, token)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			if strings.HasPrefix(strings.ToLower(secret.Name), strings.ToLower(prefix)) {
+				matched = append(matched, secret)
+			}
+		}
+		if next == nil {
+			return matched, nil
+		}
+		token = next
+	}
+}
+
+// get reads one Secret
+func (c *Cluster) get(ctx context.Context, name string) (object, error) {
+	out, err := c.kubectl(ctx, "get", "secret", name, "--output", "json")
+	if err != nil {
+		return object{}, err
+	}
+	var obj object
+	if err := json.Unmarshal(out, &obj); err != nil {
+		return object{}, err
+	}
+	return obj, nil
+}
+
+// GetSecret returns a Secret's data decoded, as a JSON object of its keys
+// so it compares key by key with a JSON secret in Secrets Manager. A Secret
+// holding one binary key is returned as those bytes; binary keys among
+// others stay base64 encoded.
+func (c *Cluster) GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error) {
+	obj, err := c.get(ctx, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+
+	fields := make(map[string]string, len(obj.Data))
+	for key, encoded := range obj.Data {
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key %s of %s: %w", key, secretName, err)
+		}
+		if !utf8.Valid(data) {
+			if len(obj.Data) == 1 {
+				logging.TrackSecret(encoded)
+				return &models.SecretValue{Binary: data}, nil
+			}
+			fields[key] = encoded
+		} else {
+			fields[key] = string(data)
+		}
+		logging.TrackSecret(fields[key])
+	}
+	value, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &models.SecretValue{String: string(value)}, nil
+}
+
+// GetSecrets returns several Secrets' data, keyed by name
+func (c *Cluster) GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error) {
+	values := make(map[string]*models.SecretValue, len(secretNames))
+	for _, name := range secretNames {
+		value, err := c.GetSecret(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// DescribeSecret returns a Secret's metadata. Its resource version stands
+// in for the current version.
+func (c *Cluster) DescribeSecret(ctx context.Context, secretName string) (*models.SecretDetails, error) {
+	obj, err := c.get(ctx, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe secret: %w", err)
+	}
+	secret := obj.toSecret()
+	return &models.SecretDetails{
+		Name:            secret.Name,
+		ARN:             secret.ARN,
+		Description:     secret.Description,
+		Tags:            secret.Tags,
+		LastChangedDate: secret.LastChangedDate,
+		CreatedDate:     secret.LastChangedDate,
+		Versions:        []models.SecretVersion{{ID: obj.Metadata.ResourceVersion, Stages: []string{"AWSCURRENT"}}},
+	}, nil
+}
+
+// GetResourcePolicies returns no policy for each Secret: access is granted
+// by RBAC on the namespace, not per Secret
+func (c *Cluster) GetResourcePolicies(ctx context.Context, secretNames []string) (map[string]string, error) {
+	policies := make(map[string]string, len(secretNames))
+	for _, name := range secretNames {
+		policies[name] = ""
+	}
+	return policies, nil
+}
+
+// GetProfile returns the context, which stands in for the AWS profile
+func (c *Cluster) GetProfile() string {
+	return c.context
+}
+
+// GetRegion returns the namespace, which stands in for the AWS region
+func (c *Cluster) GetRegion() string {
+	return c.namespace
+}
+
+// UsesDefaultChain is always false, kubectl signs in
+func (c *Cluster) UsesDefaultChain() bool {
+	return false
+}
+
+// GetEndpoint is always empty, the context is shown instead
+func (c *Cluster) GetEndpoint() string {
+	return ""
+}
+
+// CallerIdentity is always nil, kubectl doesn't say who it signs in as
+func (c *Cluster) CallerIdentity(ctx context.Context) (*models.CallerIdentity, error) {
+	return nil, nil
+}
+
+// ListRegions fails, namespaces are chosen with --kube-namespace
+func (c *Cluster) ListRegions(ctx context.Context) ([]models.Region, error) {
+	return nil, fmt.Errorf("failed to list regions: %w", ErrUnsupported)
+}
+
+// ListKMSKeys fails, Secrets aren't encrypted with KMS keys Secret Src can
+// change
+func (c *Cluster) ListKMSKeys(ctx context.Context) ([]models.KMSKey, error) {
+	return nil, fmt.Errorf("failed to list KMS keys: %w", ErrUnsupported)
+}
+
+// TagSecret fails, Secrets are read-only
+func (c *Cluster) TagSecret(ctx context.Context, secretName, key, value string) error {
+	return fmt.Errorf("failed to tag secret: %w", ErrReadOnly)
+}
+
+// UntagSecret fails, Secrets are read-only
+func (c *Cluster) UntagSecret(ctx context.Context, secretName, key string) error {
+	return fmt.Errorf("failed to untag secret: %w", ErrReadOnly)
+}
+
+// ScheduleDeletion fails, Secrets are read-only
+func (c *Cluster) ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", ErrReadOnly)
+}
+
+// RestoreSecret fails, Secrets are read-only
+func (c *Cluster) RestoreSecret(ctx context.Context, secretName string) error {
+	return fmt.Errorf("failed to restore secret: %w", ErrReadOnly)
+}
+
+// ChangeKMSKey fails, Secrets are read-only
+func (c *Cluster) ChangeKMSKey(ctx context.Context, secretName, kmsKeyID string) error {
+	return fmt.Errorf("failed to change KMS key: %w", ErrReadOnly)
+}
@@ -0,0 +1,79 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeKubectl answers the kubectl commands Cluster runs
+func fakeKubectl(t *testing.T) func(ctx context.Context, args ...string) ([]byte, error) {
+	t.Helper()
+	return func(ctx context.Context, args ...string) ([]byte, error) {
+		switch strings.Join(args, " ") {
+		case "config current-context":
+			return []byte("prod\n"), nil
+		case "config view --minify --context prod --output jsonpath={..namespace}":
+			return []byte("payments"), nil
+		case "get secrets --output json --context prod --namespace payments":
+			return []byte(`{"items":[
+				{"metadata":{"name":"app-db","namespace":"payments","creationTimestamp":"2024-03-01T10:00:00Z","labels":{"app":"api"}},"type":"Opaque","data":{"password":"aHVudGVyMg=="}},
+				{"metadata":{"name":"api-tls","namespace":"payments","creationTimestamp":"2024-03-02T10:00:00Z"},"type":"kubernetes.io/tls","data":{"tls.key":"/w=="}}
+			]}`), nil
+		case "get secret app-db --output json --context prod --namespace payments":
+			return []byte(`{"metadata":{"name":"app-db","namespace":"payments","creationTimestamp":"2024-03-01T10:00:00Z","resourceVersion":"42"},"type":"Opaque","data":{"password":"aHVudGVyMg==","user":"YXBw"}}`), nil
+		case "get secret api-tls --output json --context prod --namespace payments":
+			return []byte(`{"metadata":{"name":"api-tls","namespace":"payments"},"data":{"tls.key":"/w=="}}`), nil
+		}
+		return nil, errors.New(`Error from server (NotFound): secrets "missing" not found`)
+	}
+}
+
+func TestClusterReadsSecretsThroughKubectl(t *testing.T) {
+	cluster := New("", "")
+	cluster.run = fakeKubectl(t)
+	ctx := context.Background()
+
+	if err := cluster.Resolve(ctx); err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	if cluster.Label() != "prod/payments" {
+		t.Fatalf("expected the current context and namespace, got %q", cluster.Label())
+	}
+
+	secrets, next, err := cluster.ListSecrets(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("ListSecrets returned error: %v", err)
+	}
+	if len(secrets) != 1 || next == nil || secrets[0].Name != "api-tls" || secrets[0].ARN != "payments/api-tls" {
+		t.Fatalf("expected a first page of one, sorted by name, got %+v and %v", secrets, next)
+	}
+	rest, next, err := cluster.ListSecrets(ctx, 1, next)
+	if err != nil || len(rest) != 1 || next != nil || rest[0].Tags["app"] != "api" {
+		t.Fatalf("expected the last page, got %+v, %v, %v", rest, next, err)
+	}
+
+	value, err := cluster.GetSecret(ctx, "app-db")
+	if err != nil {
+		t.Fatalf("GetSecret returned error: %v", err)
+	}
+	if !strings.Contains(value.String, `"password": "hunter2"`) || !strings.Contains(value.String, `"user": "app"`) {
+		t.Fatalf("expected the keys decoded as a JSON object, got %q", value.String)
+	}
+	binary, err := cluster.GetSecret(ctx, "api-tls")
+	if err != nil || len(binary.Binary) != 1 || binary.Binary[0] != 0xff {
+		t.Fatalf("expected a single binary key as bytes, got %+v, %v", binary, err)
+	}
+
+	details, err := cluster.DescribeSecret(ctx, "app-db")
+	if err != nil || details.Versions[0].ID != "42" {
+		t.Fatalf("expected the resource version, got %+v, %v", details, err)
+	}
+	if _, err := cluster.GetSecret(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Fatalf("expected kubectl's error, got %v", err)
+	}
+	if err := cluster.TagSecret(ctx, "app-db", "k", "v"); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("expected Secrets to be read-only, got %v", err)
+	}
+}
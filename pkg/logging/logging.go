@@ -0,0 +1,291 @@
+// Package logging provides debug logging that never writes a loaded secret
+// value. Secret values are registered with TrackSecret as they are fetched,
+// and every log, debug, and error writer created here replaces them with
+// [REDACTED] before the text reaches its destination.
+//
+// The debug log is structured with log/slog: each line is a message with
+// key=value attributes, such as the operation and region of an AWS call.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+)
+
+// Redacted replaces secret values in scrubbed output
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the shortest value that is scrubbed. Shorter values such
+// as "1" or "true" occur in ordinary log text too often to redact usefully.
+const minSecretLength = 4
+
+// Scrubber remembers secret values and removes them from text
+type Scrubber struct {
+	mu       sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer // Rebuilt on the next Scrub after values change
+}
+
+// NewScrubber creates an empty Scrubber
+func NewScrubber() *Scrubber {
+	return &Scrubber{values: make(map[string]struct{})}
+}
+
+// Add registers secret values. Each value is also registered in the escaped
+// forms it takes when printed with %q or embedded in JSON.
+func (s *Scrubber) Add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, value := range values {
+		for _, variant := range variants(value) {
+			if len(variant) < minSecretLength {
+				continue
+			}
+			if _, ok := s.values[variant]; !ok {
+				s.values[variant] = struct{}{}
+				s.replacer = nil
+			}
+		}
+	}
+}
+
+// Scrub returns text with every registered value replaced by Redacted
+func (s *Scrubber) Scrub(text string) string {
+	s.mu.RLock()
+	replacer := s.replacer
+	empty := len(s.values) == 0
+	s.mu.RUnlock()
+
+	if empty {
+		return text
+	}
+	if replacer == nil {
+		replacer = s.buildReplacer()
+	}
+	return replacer.Replace(text)
+}
+
+// buildReplacer creates the replacer for the current values. Longer values
+// come first so a secret that contains another is redacted as a whole.
+func (s *Scrubber) buildReplacer() *strings.Replacer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replacer != nil {
+		return s.replacer
+	}
+
+	values := make([]string, 0, len(s.values))
+	for value := range s.values {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	pairs := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		pairs = append(pairs, value, Redacted)
+	}
+	s.replacer = strings.NewReplacer(pairs...)
+	return s.replacer
+}
+
+// Writer wraps w so registered values are removed from everything written to
+// it. Each Write is scrubbed on its own, so callers should write whole lines
+// (as log.Logger and fmt.Fprintf do) rather than streaming partial values.
+func (s *Scrubber) Writer(w io.Writer) io.Writer {
+	return &scrubbingWriter{scrubber: s, w: w}
+}
+
+// scrubbingWriter is the io.Writer returned by Scrubber.Writer
+type scrubbingWriter struct {
+	scrubber *Scrubber
+	w        io.Writer
+}
+
+// Write scrubs p and writes the result, reporting the original length on success
+func (sw *scrubbingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(sw.w, sw.scrubber.Scrub(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// variants returns value together with its %q and JSON-escaped forms
+func variants(value string) []string {
+	result := []string{value}
+
+	quoted := strconv.Quote(value)
+	result = append(result, quoted[1:len(quoted)-1])
+
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err == nil {
+		encoded := strings.TrimSuffix(b.String(), "\n")
+		result = append(result, encoded[1:len(encoded)-1])
+	}
+
+	return result
+}
+
+// jsonValues returns the formatted value of every object, array, and scalar
+// nested inside a JSON secret so a single field is scrubbed as well as the
+// whole document. It returns nil when value is not JSON.
+func jsonValues(value string) []string {
+	doc, err := secretvalue.Parse(value)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	var walk func(node any)
+	walk = func(node any) {
+		switch n := node.(type) {
+		case map[string]any:
+			for _, child := range n {
+				walk(child)
+			}
+		case []any:
+			for _, child := range n {
+				walk(child)
+			}
+		case nil, bool:
+			return
+		}
+		values = append(values, secretvalue.Format(node))
+	}
+	walk(doc)
+
+	return values
+}
+
+var (
+	secrets = NewScrubber()
+
+	debugMu  sync.RWMutex
+	debugLog *slog.Logger // nil while debug logging is disabled
+)
+
+// TrackSecret registers a loaded secret value for scrubbing. When the value
+// is JSON, every value nested inside it is registered too.
+func TrackSecret(value string) {
+	secrets.Add(value)
+	secrets.Add(jsonValues(value)...)
+}
+
+// Scrub removes tracked secret values from text
+func Scrub(text string) string {
+	return secrets.Scrub(text)
+}
+
+// Writer wraps w so tracked secret values are removed from everything written to it
+func Writer(w io.Writer) io.Writer {
+	return secrets.Writer(w)
+}
+
+// EnableDebug sends debug logging to w through the secret scrubber, as
+// slog text lines. Passing nil disables debug logging again.
+func EnableDebug(w io.Writer) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+
+	if w == nil {
+		debugLog = nil
+		return
+	}
+	handler := slog.NewTextHandler(Writer(w), &slog.HandlerOptions{Level: slog.LevelDebug})
+	debugLog = slog.New(&scrubbingHandler{Handler: handler})
+}
+
+// DebugEnabled reports whether debug logging is enabled, for callers that
+// would otherwise do work only to log it
+func DebugEnabled() bool {
+	return logger() != nil
+}
+
+// Debug writes a debug line with key=value attributes when debug logging is
+// enabled, e.g. Debug("aws call", "operation", "ListSecrets")
+func Debug(msg string, args ...any) {
+	if l := logger(); l != nil {
+		l.Debug(msg, args...)
+	}
+}
+
+// Debugf writes a debug line when debug logging is enabled
+func Debugf(format string, args ...any) {
+	if l := logger(); l != nil {
+		l.Debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// logger returns the debug logger, or nil while debug logging is disabled
+func logger() *slog.Logger {
+	debugMu.RLock()
+	defer debugMu.RUnlock()
+	return debugLog
+}
+
+// scrubbingHandler removes tracked secret values from messages and
+// attributes before they are formatted. The text handler escapes quotes
+// again in values that already contain escaped ones, which the writer
+// couldn't recognise afterwards.
+type scrubbingHandler struct {
+	slog.Handler
+}
+
+// Handle scrubs the record and passes it on
+func (h *scrubbingHandler) Handle(ctx context.Context, r slog.Record) error {
+	scrubbed := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		scrubbed.AddAttrs(scrubAttr(attr))
+		return true
+	})
+	return h.Handler.Handle(ctx, scrubbed)
+}
+
+// WithAttrs scrubs attributes added with Logger.With
+func (h *scrubbingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	scrubbed := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		scrubbed[i] = scrubAttr(attr)
+	}
+	return &scrubbingHandler{Handler: h.Handler.WithAttrs(scrubbed)}
+}
+
+// WithGroup keeps scrubbing the grouped attributes
+func (h *scrubbingHandler) WithGroup(name string) slog.Handler {
+	return &scrubbingHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// scrubAttr scrubs an attribute's value, formatting values other than
+// numbers, bools, times and durations as text first
+func scrubAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		scrubbed := make([]any, len(group))
+		for i, child := range group {
+			scrubbed[i] = scrubAttr(child)
+		}
+		return slog.Group(attr.Key, scrubbed...)
+	case slog.KindString, slog.KindAny:
+		return slog.String(attr.Key, Scrub(value.String()))
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
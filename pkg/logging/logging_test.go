@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScrubberRedactsValuesInEscapedForms(t *testing.T) {
+	s := NewScrubber()
+	s.Add("p@ss\"word\n")
+
+	var out bytes.Buffer
+	w := s.Writer(&out)
+	fmt.Fprintf(w, "raw=%s quoted=%q json={\"v\":\"p@ss\\\"word\\n\"}\n", "p@ss\"word\n", "p@ss\"word\n")
+
+	if strings.Contains(out.String(), "p@ss") {
+		t.Fatalf("secret leaked into output: %q", out.String())
+	}
+	if got := strings.Count(out.String(), Redacted); got != 3 {
+		t.Fatalf("expected 3 redactions, got %d in %q", got, out.String())
+	}
+}
+
+func TestScrubberPrefersLongestValue(t *testing.T) {
+	s := NewScrubber()
+	s.Add("token", "token-with-suffix")
+
+	if got := s.Scrub("a token-with-suffix b"); got != "a "+Redacted+" b" {
+		t.Fatalf("unexpected scrub result %q", got)
+	}
+}
+
+func TestScrubberIgnoresShortValues(t *testing.T) {
+	s := NewScrubber()
+	s.Add("1", "abc")
+
+	if got := s.Scrub("1 abc 2024"); got != "1 abc 2024" {
+		t.Fatalf("short values should not be redacted, got %q", got)
+	}
+}
+
+func TestDebugfNeverWritesTrackedSecrets(t *testing.T) {
+	TrackSecret(`{"user":"admin-user","password":"hunter2!","nested":{"port":5432,"hosts":["db-primary.internal"]}}`)
+
+	var out bytes.Buffer
+	EnableDebug(&out)
+	defer EnableDebug(nil)
+
+	Debugf("password is %s", "hunter2!")
+	Debugf("env DB_HOST=%v", "db-primary.internal")
+	Debugf("nested %s", `{"hosts":["db-primary.internal"],"port":5432}`)
+	Debugf("error: %v", fmt.Errorf("bad value %q", "admin-user"))
+
+	for _, leaked := range []string{"hunter2!", "db-primary.internal", "admin-user", "5432"} {
+		if strings.Contains(out.String(), leaked) {
+			t.Fatalf("debug output leaked %q:\n%s", leaked, out.String())
+		}
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 4 {
+		t.Fatalf("expected 4 debug lines, got %d:\n%s", lines, out.String())
+	}
+}
+
+func TestDebugfIsSilentUntilEnabled(t *testing.T) {
+	var out bytes.Buffer
+	EnableDebug(&out)
+	EnableDebug(nil)
+
+	Debugf("should not appear")
+	if out.Len() != 0 {
+		t.Fatalf("expected no output after disabling, got %q", out.String())
+	}
+}
+
+func TestDebugRedactsAttributes(t *testing.T) {
+	TrackSecret(`quoted "secret" value`)
+
+	var out bytes.Buffer
+	EnableDebug(&out)
+	defer EnableDebug(nil)
+
+	Debug("aws call", "operation", "GetSecretValue", "error", fmt.Errorf("bad value %q", `quoted "secret" value`), "attempts", 2)
+	Debug(fmt.Sprintf("value %q", `quoted "secret" value`))
+
+	if strings.Contains(out.String(), "secret") {
+		t.Fatalf("debug output leaked the value:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "operation=GetSecretValue") || !strings.Contains(out.String(), "attempts=2") {
+		t.Fatalf("expected structured attributes, got:\n%s", out.String())
+	}
+}
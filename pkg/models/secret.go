@@ -1,16 +1,129 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // Secret represents an AWS Secrets Manager secret
 type Secret struct {
-	Name            string
-	ARN             string
-	Description     string
-	LastChangedDate *time.Time
-	Tags            map[string]string
+	Name             string
+	ARN              string
+	Description      string
+	LastChangedDate  *time.Time
+	LastAccessedDate *time.Time
+	LastRotatedDate  *time.Time
+	OwningService    string // Set when the secret is managed by another AWS service, e.g. "rds"
+	KmsKeyID         string // Empty when the AWS managed key (aws/secretsmanager) is used
+	RotationEnabled  bool
+	Tags             map[string]string
+}
+
+// DefaultKMSKey is the AWS managed key secrets are encrypted with when no
+// key is chosen
+const DefaultKMSKey = "aws/secretsmanager"
+
+// UsesDefaultKey reports whether a secret's KMS key is the AWS managed
+// aws/secretsmanager key, which AWS reports as no key or by its alias
+func UsesDefaultKey(kmsKeyID string) bool {
+	return kmsKeyID == "" || strings.HasSuffix(kmsKeyID, "alias/"+DefaultKMSKey)
+}
+
+// KMSKeyLabel returns a short name for a KMS key: the key or alias part of
+// an ARN, such as "key/1234abcd-…" or "alias/payments", or DefaultKMSKey
+func KMSKeyLabel(kmsKeyID string) string {
+	if UsesDefaultKey(kmsKeyID) {
+		return DefaultKMSKey
+	}
+	if i := strings.LastIndex(kmsKeyID, ":"); i >= 0 {
+		return kmsKeyID[i+1:]
+	}
+	return kmsKeyID
+}
+
+// owningServiceNames are how the services that manage secrets are usually
+// written
+var owningServiceNames = map[string]string{
+	"rds":        "RDS",
+	"redshift":   "Redshift",
+	"docdb":      "DocumentDB",
+	"documentdb": "DocumentDB",
+}
+
+// OwningServiceName returns how an OwningService such as "rds" is shown,
+// or the service as given when it isn't a known one
+func OwningServiceName(service string) string {
+	if name, ok := owningServiceNames[service]; ok {
+		return name
+	}
+	return service
+}
+
+// SecretValue is a decrypted secret. Exactly one of String and Binary is set.
+type SecretValue struct {
+	String string
+	Binary []byte
+}
+
+// IsBinary reports whether the secret was stored as SecretBinary
+func (v *SecretValue) IsBinary() bool {
+	return v.Binary != nil
+}
+
+// SecretDetails holds the extended metadata returned by DescribeSecret
+type SecretDetails struct {
+	Name             string
+	ARN              string
+	Description      string
+	Tags             map[string]string
+	LastChangedDate  *time.Time
+	KmsKeyID         string // Empty when the AWS managed key (aws/secretsmanager) is used
+	CreatedDate      *time.Time
+	LastAccessedDate *time.Time
+	LastRotatedDate  *time.Time
+	NextRotationDate *time.Time
+	RotationEnabled  bool
+	OwningService    string // Set when the secret is managed by another AWS service
+	Versions         []SecretVersion
+}
+
+// SecretVersion is a version of a secret and the staging labels attached to it
+type SecretVersion struct {
+	ID     string
+	Stages []string
+}
+
+// CallerIdentity is who a client's credentials belong to, as reported by
+// STS GetCallerIdentity
+type CallerIdentity struct {
+	Account string
+	ARN     string
+	UserID  string
+}
+
+// Region is an AWS region the account can use
+type Region struct {
+	Name string
+	// HasSecrets is set when Secrets Manager has at least one secret there
+	HasSecrets bool
+}
+
+// ProfileSummary is what ~/.aws/config says about a profile, to tell
+// similarly named profiles apart
+type ProfileSummary struct {
+	Name string
+	// Account is taken from role_arn, sso_account_id or mfa_serial, in that
+	// order. Empty when none of them is set.
+	Account string
+	Region  string
+	// MFA is set when signing in needs an MFA code, for the profile itself
+	// or the source profile of its role
+	MFA bool
+	SSO bool
+	// RoleSource is the source_profile of a profile that assumes a role
+	RoleSource string
+	// CredentialProcess is set when an external command supplies credentials
+	CredentialProcess bool
 }
 
 // AppState represents the application configuration state
@@ -18,3 +131,9 @@ type AppState struct {
 	CurrentProfile string
 	CurrentRegion  string
 }
+
+// KMSKey is a KMS key secrets can be encrypted with, by one of its aliases
+type KMSKey struct {
+	Alias string // e.g. "alias/payments"
+	KeyID string // ID of the key the alias points to, empty for the default key until AWS creates it
+}
@@ -0,0 +1,66 @@
+// Package notify shows desktop notifications through the notifier each
+// platform ships with: osascript on macOS, PowerShell on Windows and
+// notify-send elsewhere.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// timeout bounds how long the notifier may take to return
+const timeout = 5 * time.Second
+
+// Send shows a desktop notification with a title and message. It fails when
+// the platform's notifier isn't installed or reports an error.
+func Send(title, message string) error {
+	name, args := command(runtime.GOOS, title, message)
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("failed to find %s to show notifications: %w", name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if output, err := exec.CommandContext(ctx, name, args...).CombinedOutput(); err != nil {
+		if text := strings.TrimSpace(string(output)); text != "" {
+			return fmt.Errorf("failed to show notification: %v: %s", err, text)
+		}
+		return fmt.Errorf("failed to show notification: %w", err)
+	}
+	return nil
+}
+
+// command returns the notifier to run on goos and its arguments
+func command(goos, title, message string) (string, []string) {
+	switch goos {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		return "osascript", []string{"-e", script}
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, %s, %s, 'Info')
+Start-Sleep -Seconds 1
+$n.Dispose()`, powerShellString(title), powerShellString(message))
+		return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	return "notify-send", []string{"--app-name=secretsrc", title, message}
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// powerShellString quotes s as a PowerShell single-quoted string, in which
+// only quotes need escaping
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommandQuotesTextForEachPlatform(t *testing.T) {
+	title, message := `Secret "rotated"`, `it's app\db`
+
+	name, args := command("darwin", title, message)
+	want := `display notification "it's app\\db" with title "Secret \"rotated\""`
+	if name != "osascript" || args[1] != want {
+		t.Fatalf("got %s %q, want osascript %q", name, args, want)
+	}
+
+	name, args = command("windows", title, message)
+	if name != "powershell" || !strings.Contains(args[len(args)-1], `'Secret "rotated"', 'it''s app\db'`) {
+		t.Fatalf("got %s %q", name, args)
+	}
+
+	name, args = command("linux", title, message)
+	if name != "notify-send" || args[1] != title || args[2] != message {
+		t.Fatalf("got %s %q", name, args)
+	}
+}
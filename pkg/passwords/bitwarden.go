@@ -0,0 +1,88 @@
+package passwords
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// bitwardenLinkedField is the type of a custom field that points at another
+// field rather than holding a value
+const bitwardenLinkedField = 3
+
+// Bitwarden reads items with the Bitwarden CLI, bw, unlocked with
+// `bw unlock` and BW_SESSION exported. It never prompts: a locked vault is
+// an error.
+type Bitwarden struct {
+	run runner
+}
+
+// Name returns "Bitwarden"
+func (b *Bitwarden) Name() string {
+	return "Bitwarden"
+}
+
+// List returns every item in the vault. bw lists items with their fields,
+// but only the names are kept.
+func (b *Bitwarden) List(ctx context.Context) ([]Item, error) {
+	out, err := b.run(ctx, "bw", "list", "items", "--nointeraction")
+	if err != nil {
+		return nil, err
+	}
+	var listed []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("failed to read the items bw listed: %w", err)
+	}
+	items := make([]Item, len(listed))
+	for i, item := range listed {
+		items[i] = Item{Manager: b.Name(), ID: item.ID, Title: item.Name}
+	}
+	sortItems(items)
+	return items, nil
+}
+
+// Fields returns a login's username, password and first URL, the item's
+// notes, and its custom fields by name. TOTP seeds are left out.
+func (b *Bitwarden) Fields(ctx context.Context, id string) (map[string]string, error) {
+	out, err := b.run(ctx, "bw", "get", "item", id, "--nointeraction")
+	if err != nil {
+		return nil, err
+	}
+	var item struct {
+		Login *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			URIs     []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+		} `json:"login"`
+		Notes  string `json:"notes"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+			Type  int    `json:"type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(out, &item); err != nil {
+		return nil, fmt.Errorf("failed to read the item bw returned: %w", err)
+	}
+
+	fields := make(map[string]string)
+	if item.Login != nil {
+		addField(fields, "username", item.Login.Username)
+		addField(fields, "password", item.Login.Password)
+		if len(item.Login.URIs) > 0 {
+			addField(fields, "url", item.Login.URIs[0].URI)
+		}
+	}
+	for _, field := range item.Fields {
+		if field.Type != bitwardenLinkedField {
+			addField(fields, field.Name, field.Value)
+		}
+	}
+	addField(fields, "notes", item.Notes)
+	return fields, nil
+}
@@ -0,0 +1,83 @@
+package passwords
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// OnePassword reads items with the 1Password CLI, op, signed in with
+// `op signin` or through the desktop app
+type OnePassword struct {
+	run runner
+}
+
+// Name returns "1Password"
+func (p *OnePassword) Name() string {
+	return "1Password"
+}
+
+// List returns every item in the vaults op can see
+func (p *OnePassword) List(ctx context.Context) ([]Item, error) {
+	out, err := p.run(ctx, "op", "item", "list", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var listed []struct {
+		ID    string `json:"id"`
+		Title string `json:"title"`
+		Vault struct {
+			Name string `json:"name"`
+		} `json:"vault"`
+	}
+	if err := json.Unmarshal(out, &listed); err != nil {
+		return nil, fmt.Errorf("failed to read the items op listed: %w", err)
+	}
+	items := make([]Item, len(listed))
+	for i, item := range listed {
+		items[i] = Item{Manager: p.Name(), ID: item.ID, Title: item.Title, Vault: item.Vault.Name}
+	}
+	sortItems(items)
+	return items, nil
+}
+
+// Fields returns an item's fields by label. Notes are named "notes" and
+// the primary URL "url"; one-time password seeds are left out.
+func (p *OnePassword) Fields(ctx context.Context, id string) (map[string]string, error) {
+	out, err := p.run(ctx, "op", "item", "get", id, "--format", "json", "--reveal")
+	if err != nil {
+		return nil, err
+	}
+	var item struct {
+		Fields []struct {
+			Label   string `json:"label"`
+			Type    string `json:"type"`
+			Purpose string `json:"purpose"`
+			Value   string `json:"value"`
+		} `json:"fields"`
+		URLs []struct {
+			Primary bool   `json:"primary"`
+			Href    string `json:"href"`
+		} `json:"urls"`
+	}
+	if err := json.Unmarshal(out, &item); err != nil {
+		return nil, fmt.Errorf("failed to read the item op returned: %w", err)
+	}
+
+	fields := make(map[string]string)
+	for _, field := range item.Fields {
+		switch {
+		case field.Type == "OTP":
+		case field.Purpose == "NOTES":
+			addField(fields, "notes", field.Value)
+		default:
+			addField(fields, field.Label, field.Value)
+		}
+	}
+	for _, url := range item.URLs {
+		if url.Primary {
+			addField(fields, "url", url.Href)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,110 @@
+// Package passwords reads items from password managers through their CLIs,
+// 1Password's op and Bitwarden's bw, so a credential kept there can be
+// copied into a new secret. Nothing is ever written to a password manager.
+package passwords
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+)
+
+// The names password managers are configured by, in password_managers
+const (
+	OnePasswordName = "1password"
+	BitwardenName   = "bitwarden"
+)
+
+// Names lists every supported password manager
+var Names = []string{OnePasswordName, BitwardenName}
+
+// Item is an entry in a password manager, without its fields
+type Item struct {
+	Manager string // e.g. "1Password"
+	ID      string
+	Title   string
+	Vault   string // The vault or folder it is in, when the manager says
+}
+
+// Label returns how the item is listed, e.g. "1Password: Stripe (Team)"
+func (i Item) Label() string {
+	label := i.Manager + ": " + i.Title
+	if i.Vault != "" {
+		label += " (" + i.Vault + ")"
+	}
+	return label
+}
+
+// Manager is a password manager read through its CLI
+type Manager interface {
+	// Name returns the manager's display name, e.g. "1Password"
+	Name() string
+	// List returns every item, sorted by title
+	List(ctx context.Context) ([]Item, error)
+	// Fields returns an item's fields by name, such as username and
+	// password, leaving out empty ones
+	Fields(ctx context.Context, id string) (map[string]string, error)
+}
+
+// runner runs a CLI, returning its output
+type runner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// runCLI runs a password manager CLI. Its errors, such as a locked vault,
+// are returned as the CLI words them.
+func runCLI(ctx context.Context, name string, args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s isn't installed or isn't on the PATH", name)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		message := strings.TrimSpace(string(exitErr.Stderr))
+		if message == "" {
+			message = err.Error()
+		}
+		logging.Debugf("%s %s failed: %s", name, args[0], message)
+		return nil, fmt.Errorf("%s %s failed: %s", name, args[0], message)
+	}
+	return out, err
+}
+
+// New returns the password manager configured as name, "1password" or
+// "bitwarden"
+func New(name string) (Manager, error) {
+	switch name {
+	case OnePasswordName:
+		return &OnePassword{run: runCLI}, nil
+	case BitwardenName:
+		return &Bitwarden{run: runCLI}, nil
+	}
+	return nil, fmt.Errorf("unknown password manager %q, expected %s", name, strings.Join(Names, " or "))
+}
+
+// sortItems orders items by title, then vault
+func sortItems(items []Item) {
+	sort.Slice(items, func(i, j int) bool {
+		if !strings.EqualFold(items[i].Title, items[j].Title) {
+			return strings.ToLower(items[i].Title) < strings.ToLower(items[j].Title)
+		}
+		return items[i].Vault < items[j].Vault
+	})
+}
+
+// addField sets a field unless it is empty or already set, tracking the
+// value for redaction
+func addField(fields map[string]string, name, value string) {
+	name = strings.TrimSpace(name)
+	if name == "" || value == "" {
+		return
+	}
+	if _, ok := fields[name]; ok {
+		return
+	}
+	logging.TrackSecret(value)
+	fields[name] = value
+}
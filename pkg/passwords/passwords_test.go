@@ -0,0 +1,78 @@
+package passwords
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeRunner answers commands from a table of outputs, keyed by the full
+// command line
+func fakeRunner(outputs map[string]string) runner {
+	return func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		out, ok := outputs[name+" "+strings.Join(args, " ")]
+		if !ok {
+			return nil, errors.New("unexpected command")
+		}
+		return []byte(out), nil
+	}
+}
+
+func TestOnePasswordReadsItems(t *testing.T) {
+	op := &OnePassword{run: fakeRunner(map[string]string{
+		"op item list --format json": `[{"id":"b","title":"stripe","vault":{"name":"Team"}},{"id":"a","title":"Postgres","vault":{"name":"Private"}}]`,
+		"op item get b --format json --reveal": `{"fields":[
+			{"label":"username","type":"STRING","purpose":"USERNAME","value":"payments"},
+			{"label":"password","type":"CONCEALED","purpose":"PASSWORD","value":"hunter2"},
+			{"label":"notesPlain","type":"STRING","purpose":"NOTES","value":""},
+			{"label":"one-time password","type":"OTP","value":"otpauth://totp/x"}
+		],"urls":[{"primary":true,"href":"https://dashboard.stripe.com"}]}`,
+	})}
+
+	items, err := op.List(context.Background())
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	if len(items) != 2 || items[0].Title != "Postgres" || items[1].Label() != "1Password: stripe (Team)" {
+		t.Fatalf("expected the items sorted by title, got %+v", items)
+	}
+
+	fields, err := op.Fields(context.Background(), "b")
+	if err != nil {
+		t.Fatalf("Fields returned error: %v", err)
+	}
+	want := map[string]string{"username": "payments", "password": "hunter2", "url": "https://dashboard.stripe.com"}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %v without empty notes or the OTP seed, got %v", want, fields)
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Fatalf("expected %s=%s, got %v", key, value, fields)
+		}
+	}
+}
+
+func TestBitwardenReadsItems(t *testing.T) {
+	bw := &Bitwarden{run: fakeRunner(map[string]string{
+		"bw list items --nointeraction": `[{"id":"1","name":"Mailgun","login":{"password":"not kept"}}]`,
+		"bw get item 1 --nointeraction": `{"login":{"username":"api","password":"key-123","totp":"seed","uris":[{"uri":"https://mailgun.com"}]},
+			"notes":"rotate yearly","fields":[{"name":"domain","value":"mg.example.com","type":0},{"name":"linked","value":null,"type":3}]}`,
+	})}
+
+	items, err := bw.List(context.Background())
+	if err != nil || len(items) != 1 || items[0].Label() != "Bitwarden: Mailgun" {
+		t.Fatalf("unexpected items %+v, %v", items, err)
+	}
+	fields, err := bw.Fields(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Fields returned error: %v", err)
+	}
+	if len(fields) != 5 || fields["password"] != "key-123" || fields["domain"] != "mg.example.com" || fields["notes"] != "rotate yearly" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	if _, err := New("lastpass"); err == nil {
+		t.Fatal("expected an unknown password manager to be rejected")
+	}
+}
@@ -0,0 +1,93 @@
+package secretvalue
+
+import (
+	"fmt"
+	"slices"
+)
+
+// App is a named set of secrets an application needs, composed into one
+// environment by `exec --app`
+type App struct {
+	Name    string      `json:"name" yaml:"name"`
+	Secrets []AppSecret `json:"secrets" yaml:"secrets"`
+	Env     EnvMapping  `json:"env,omitempty" yaml:"env"` // Naming for every secret's fields, layered over the global env
+}
+
+// AppSecret is one secret of an app and how its value becomes variables
+type AppSecret struct {
+	Secret  string            `json:"secret" yaml:"secret"`
+	Key     string            `json:"key,omitempty" yaml:"key"`         // JSONPath-style key to use instead of the whole value
+	Env     string            `json:"env,omitempty" yaml:"env"`         // One variable holding the value (or key) as is, instead of one per field
+	Rename  map[string]string `json:"rename,omitempty" yaml:"rename"`   // Field key -> exact variable name, for this secret only
+	Exclude []string          `json:"exclude,omitempty" yaml:"exclude"` // Field keys of this secret to skip; glob patterns are allowed
+}
+
+// SecretNames returns the names of the app's secrets, in order
+func (a App) SecretNames() []string {
+	names := make([]string, len(a.Secrets))
+	for i, secret := range a.Secrets {
+		names[i] = secret.Secret
+	}
+	return names
+}
+
+// Uses reports whether the named secret is one of the app's
+func (a App) Uses(name string) bool {
+	return slices.Contains(a.SecretNames(), name)
+}
+
+// Compose maps the values of the app's secrets, in the same order, to
+// environment variables. Fields are named by mapping, the app's env and then
+// the secret's own renames and excludes; a variable set by a later secret
+// replaces one set by an earlier secret.
+func (a App) Compose(values []string, mapping EnvMapping) ([]EnvVar, error) {
+	if len(values) != len(a.Secrets) {
+		return nil, fmt.Errorf("expected %d values for %s, got %d", len(a.Secrets), a.Name, len(values))
+	}
+	mapping = mapping.Merge(a.Env)
+
+	var vars []EnvVar
+	index := make(map[string]int)
+	for i, secret := range a.Secrets {
+		secretVars, err := secret.vars(values[i], mapping)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", secret.Secret, err)
+		}
+		for _, v := range secretVars {
+			if at, ok := index[v.Name]; ok {
+				vars[at] = v
+				continue
+			}
+			index[v.Name] = len(vars)
+			vars = append(vars, v)
+		}
+	}
+	return vars, nil
+}
+
+// vars maps one secret's value to variables
+func (s AppSecret) vars(value string, mapping EnvMapping) ([]EnvVar, error) {
+	if s.Env != "" && s.Key == "" {
+		// Held whole, so plain text secrets can be used too
+		return []EnvVar{{Name: s.Env, Value: value}}, nil
+	}
+
+	doc, err := Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	if s.Key != "" {
+		if doc, err = Lookup(doc, s.Key); err != nil {
+			return nil, err
+		}
+	}
+	if s.Env != "" {
+		return []EnvVar{{Name: s.Env, Value: Format(doc), Key: s.Key}}, nil
+	}
+
+	fields, err := Fields(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%w; set env to name the variable", err)
+	}
+	return mapping.Merge(EnvMapping{Rename: s.Rename, Exclude: s.Exclude}).Apply(fields)
+}
@@ -0,0 +1,49 @@
+package secretvalue
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppComposesEnvironment(t *testing.T) {
+	app := App{
+		Name: "billing-api",
+		Env:  EnvMapping{UpperSnake: true},
+		Secrets: []AppSecret{
+			{Secret: "prod/billing/db", Rename: map[string]string{"password": "DB_PASSWORD"}, Exclude: []string{"engine"}},
+			{Secret: "prod/billing/stripe", Key: "keys.live", Env: "STRIPE_KEY"},
+			{Secret: "prod/billing/overrides"},
+			{Secret: "prod/billing/token", Env: "API_TOKEN"},
+		},
+	}
+	values := []string{
+		`{"host": "db.internal", "password": "hunter2", "engine": "postgres"}`,
+		`{"keys": {"live": "sk_live_1"}}`,
+		`{"host": "replica.internal"}`,
+		"plain-token",
+	}
+
+	vars, err := app.Compose(values, EnvMapping{Prefix: "APP_"})
+	if err != nil {
+		t.Fatalf("Compose returned error: %v", err)
+	}
+	var got []string
+	for _, v := range vars {
+		got = append(got, v.String())
+	}
+	want := "APP_HOST=replica.internal DB_PASSWORD=hunter2 STRIPE_KEY=sk_live_1 API_TOKEN=plain-token"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(got, " "))
+	}
+	if !app.Uses("prod/billing/stripe") || app.Uses("prod/other") {
+		t.Fatal("expected Uses to match the app's secrets only")
+	}
+
+	if _, err := app.Compose(values[:1], EnvMapping{}); err == nil {
+		t.Fatal("expected a missing value to be an error")
+	}
+	values[2] = "not json"
+	if _, err := app.Compose(values, EnvMapping{}); err == nil || !strings.Contains(err.Error(), "prod/billing/overrides") {
+		t.Fatalf("expected the secret that isn't JSON to be named, got %v", err)
+	}
+}
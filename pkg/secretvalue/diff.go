@@ -0,0 +1,93 @@
+package secretvalue
+
+import "sort"
+
+// DiffStatus is how a key differs between two secret values
+type DiffStatus int
+
+const (
+	// DiffSame is a key with the same value in both
+	DiffSame DiffStatus = iota
+	// DiffChanged is a key in both with different values
+	DiffChanged
+	// DiffOnlyA is a key only the first value has
+	DiffOnlyA
+	// DiffOnlyB is a key only the second value has
+	DiffOnlyB
+)
+
+// WholeValueKey is the key a value that isn't a JSON object is compared
+// under, so plain text secrets can be diffed too
+const WholeValueKey = "(value)"
+
+// FieldDiff is one key of a DiffFields comparison. A and B are empty for
+// the side that doesn't have the key.
+type FieldDiff struct {
+	Key    string
+	Status DiffStatus
+	A      string
+	B      string
+}
+
+// CompareFields returns the top-level fields of a secret value for
+// DiffFields: the fields of a JSON object, or the whole value under
+// WholeValueKey
+func CompareFields(value string) []Field {
+	if doc, err := Parse(value); err == nil {
+		if fields, err := Fields(doc); err == nil {
+			return fields
+		}
+	}
+	return []Field{{Key: WholeValueKey, Value: value}}
+}
+
+// DiffFields compares two field lists key by key and returns every key of
+// either, sorted
+func DiffFields(a, b []Field) []FieldDiff {
+	valuesB := make(map[string]string, len(b))
+	for _, field := range b {
+		valuesB[field.Key] = field.Value
+	}
+
+	diffs := make([]FieldDiff, 0, len(a)+len(b))
+	inA := make(map[string]bool, len(a))
+	for _, field := range a {
+		inA[field.Key] = true
+		valueB, ok := valuesB[field.Key]
+		switch {
+		case !ok:
+			diffs = append(diffs, FieldDiff{Key: field.Key, Status: DiffOnlyA, A: field.Value})
+		case valueB != field.Value:
+			diffs = append(diffs, FieldDiff{Key: field.Key, Status: DiffChanged, A: field.Value, B: valueB})
+		default:
+			diffs = append(diffs, FieldDiff{Key: field.Key, Status: DiffSame, A: field.Value, B: valueB})
+		}
+	}
+	for _, field := range b {
+		if !inA[field.Key] {
+			diffs = append(diffs, FieldDiff{Key: field.Key, Status: DiffOnlyB, B: field.Value})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
+
+// MarkedKeys returns the keys that differ, each marked + when only the
+// second value has it, - when only the first does and ~ when it changed
+func MarkedKeys(diffs []FieldDiff) []string {
+	var keys []string
+	for _, diff := range diffs {
+		switch diff.Status {
+		case DiffChanged:
+			keys = append(keys, "~"+diff.Key)
+		case DiffOnlyA:
+			keys = append(keys, "-"+diff.Key)
+		case DiffOnlyB:
+			keys = append(keys, "+"+diff.Key)
+		}
+	}
+	return keys
+}
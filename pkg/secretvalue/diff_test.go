@@ -0,0 +1,31 @@
+package secretvalue
+
+import "testing"
+
+func TestDiffFields(t *testing.T) {
+	a := CompareFields(`{"host":"db.staging","port":5432,"debug":true}`)
+	b := CompareFields(`{"port":5432,"host":"db.prod","replica":"db-ro.prod"}`)
+
+	diffs := DiffFields(a, b)
+	want := []FieldDiff{
+		{Key: "debug", Status: DiffOnlyA, A: "true"},
+		{Key: "host", Status: DiffChanged, A: "db.staging", B: "db.prod"},
+		{Key: "port", Status: DiffSame, A: "5432", B: "5432"},
+		{Key: "replica", Status: DiffOnlyB, B: "db-ro.prod"},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d diffs, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Fatalf("diff %d = %+v, want %+v", i, diffs[i], want[i])
+		}
+	}
+}
+
+func TestDiffFieldsComparesPlainValuesWhole(t *testing.T) {
+	diffs := DiffFields(CompareFields("sk_live_a"), CompareFields(`{"key":"sk_live_a"}`))
+	if len(diffs) != 2 || diffs[0].Key != WholeValueKey || diffs[0].Status != DiffOnlyA || diffs[1].Status != DiffOnlyB {
+		t.Fatalf("expected a plain value to be compared as a whole, got %+v", diffs)
+	}
+}
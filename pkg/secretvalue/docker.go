@@ -0,0 +1,76 @@
+package secretvalue
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ComposeService is the placeholder service the secrets are granted to in
+// a compose snippet, to be renamed when pasted
+const ComposeService = "app"
+
+// composeNamePattern matches the names compose accepts for secrets
+var composeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// composeSnippet is a compose file declaring file-based secrets and granting
+// them to one service
+type composeSnippet struct {
+	Services map[string]composeService `yaml:"services"`
+	Secrets  map[string]composeSecret  `yaml:"secrets"`
+}
+
+type composeService struct {
+	Secrets []string `yaml:"secrets"`
+}
+
+type composeSecret struct {
+	File string `yaml:"file"`
+}
+
+// FormatDockerEnv renders variables as an env file for docker run
+// --env-file. Docker reads everything after the = as the value, so values
+// are never quoted, and a value with a line break can't be written.
+func FormatDockerEnv(vars []EnvVar) (string, error) {
+	var b strings.Builder
+	for _, v := range vars {
+		if strings.ContainsAny(v.Value, "\n\r") {
+			return "", fmt.Errorf("%s has a multi-line value, which an env file can't hold", v.Name)
+		}
+		b.WriteString(v.String())
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// ComposeSecrets renders a compose snippet that declares each field as a
+// secret read from a file named after its key in dir, and grants them all
+// to ComposeService. The values themselves are not part of the snippet.
+func ComposeSecrets(fields []Field, dir string) ([]byte, error) {
+	snippet := composeSnippet{
+		Services: map[string]composeService{ComposeService: {Secrets: make([]string, 0, len(fields))}},
+		Secrets:  make(map[string]composeSecret, len(fields)),
+	}
+	for _, field := range fields {
+		if !composeNamePattern.MatchString(field.Key) {
+			return nil, fmt.Errorf("field %q can't be a compose secret name: use letters, digits, '-', '_' and '.'", field.Key)
+		}
+		snippet.Services[ComposeService] = composeService{Secrets: append(snippet.Services[ComposeService].Secrets, field.Key)}
+		snippet.Secrets[field.Key] = composeSecret{File: "./" + path.Join(dir, field.Key)}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(snippet); err != nil {
+		return nil, fmt.Errorf("failed to encode compose snippet: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode compose snippet: %w", err)
+	}
+	return buf.Bytes(), nil
+}
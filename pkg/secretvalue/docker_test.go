@@ -0,0 +1,46 @@
+package secretvalue
+
+import "testing"
+
+func TestFormatDockerEnvLeavesValuesUnquoted(t *testing.T) {
+	got, err := FormatDockerEnv([]EnvVar{
+		{Name: "DB_PASSWORD", Value: `p@ss "word" $x`},
+		{Name: "PORT", Value: "5432"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "DB_PASSWORD=p@ss \"word\" $x\nPORT=5432\n"; got != want {
+		t.Fatalf("unexpected env file %q, want %q", got, want)
+	}
+
+	if _, err := FormatDockerEnv([]EnvVar{{Name: "CERT", Value: "line1\nline2"}}); err == nil {
+		t.Fatal("expected a multi-line value to be rejected")
+	}
+}
+
+func TestComposeSecretsReferencesFiles(t *testing.T) {
+	got, err := ComposeSecrets([]Field{{Key: "password", Value: "hunter2"}, {Key: "username", Value: "app"}}, "secrets")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `services:
+  app:
+    secrets:
+      - password
+      - username
+secrets:
+  password:
+    file: ./secrets/password
+  username:
+    file: ./secrets/username
+`
+	if string(got) != want {
+		t.Fatalf("unexpected snippet:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := ComposeSecrets([]Field{{Key: "bad/key"}}, "secrets"); err == nil {
+		t.Fatal("expected an invalid secret name to be rejected")
+	}
+}
@@ -0,0 +1,105 @@
+package secretvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentFormat is an output format for documents built from secrets
+type DocumentFormat string
+
+const (
+	FormatJSON DocumentFormat = "json"
+	FormatYAML DocumentFormat = "yaml"
+)
+
+// ParseDocumentFormat converts a format name such as "json" or "yml" to a DocumentFormat
+func ParseDocumentFormat(name string) (DocumentFormat, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (expected json or yaml)", name)
+	}
+}
+
+// FormatForPath picks the document format from a file extension, defaulting to JSON
+func FormatForPath(path string) DocumentFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// Bundle merges secret values into one document keyed by secret name. Values
+// that are valid JSON are embedded as parsed JSON, everything else as a string.
+func Bundle(values map[string]string) map[string]any {
+	doc := make(map[string]any, len(values))
+	for name, value := range values {
+		if parsed, err := Parse(value); err == nil {
+			doc[name] = parsed
+		} else {
+			doc[name] = value
+		}
+	}
+	return doc
+}
+
+// EncodeDocument renders doc in the given format. JSON is indented and YAML
+// keeps numbers from parsed secrets as numbers.
+func EncodeDocument(doc any, format DocumentFormat) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		data, err := yaml.Marshal(yamlValue(doc))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return data, nil
+
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// yamlValue converts the json.Number values produced by Parse into YAML
+// number nodes, which yaml.Marshal would otherwise quote as strings
+func yamlValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, child := range v {
+			converted[key] = yamlValue(child)
+		}
+		return converted
+	case []any:
+		converted := make([]any, len(v))
+		for i, child := range v {
+			converted[i] = yamlValue(child)
+		}
+		return converted
+	case json.Number:
+		tag := "!!float"
+		if _, err := v.Int64(); err == nil {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	default:
+		return value
+	}
+}
@@ -0,0 +1,57 @@
+package secretvalue
+
+import "testing"
+
+func TestBundleEmbedsJSONValues(t *testing.T) {
+	doc := Bundle(map[string]string{
+		"app/db":    `{"host":"db.internal","port":5432}`,
+		"app/token": "plain-token",
+	})
+
+	got, err := EncodeDocument(doc, FormatJSON)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{
+  "app/db": {
+    "host": "db.internal",
+    "port": 5432
+  },
+  "app/token": "plain-token"
+}
+`
+	if string(got) != want {
+		t.Fatalf("unexpected JSON:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEncodeDocumentYAMLKeepsNumbers(t *testing.T) {
+	doc := Bundle(map[string]string{
+		"app/db": `{"port":5432,"ratio":0.5,"zip":"01234"}`,
+	})
+
+	got, err := EncodeDocument(doc, FormatYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "app/db:\n    port: 5432\n    ratio: 0.5\n    zip: \"01234\"\n"
+	if string(got) != want {
+		t.Fatalf("unexpected YAML:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := map[string]DocumentFormat{
+		"out.yaml":     FormatYAML,
+		"out.YML":      FormatYAML,
+		"out.json":     FormatJSON,
+		"fixtures/out": FormatJSON,
+	}
+	for path, want := range tests {
+		if got := FormatForPath(path); got != want {
+			t.Fatalf("FormatForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
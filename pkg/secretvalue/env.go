@@ -0,0 +1,164 @@
+package secretvalue
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// EnvMapping controls how secret fields are turned into environment variable names
+type EnvMapping struct {
+	Prefix     string            `json:"prefix,omitempty" yaml:"prefix"`           // Prepended to every generated name
+	UpperSnake bool              `json:"upper_snake,omitempty" yaml:"upper_snake"` // Convert keys like dbHost or db-host to DB_HOST
+	Rename     map[string]string `json:"rename,omitempty" yaml:"rename"`           // Field key -> exact variable name (prefix and casing not applied)
+	Exclude    []string          `json:"exclude,omitempty" yaml:"exclude"`         // Field keys to skip; glob patterns are allowed
+}
+
+// EnvVar is a single environment variable derived from a secret field
+type EnvVar struct {
+	Name  string
+	Value string
+	Key   string // Source field key
+}
+
+// String renders the variable as NAME=VALUE
+func (v EnvVar) String() string {
+	return v.Name + "=" + v.Value
+}
+
+// Apply maps fields to environment variables. It returns an error if two
+// fields would produce the same variable name.
+func (m EnvMapping) Apply(fields []Field) ([]EnvVar, error) {
+	vars := make([]EnvVar, 0, len(fields))
+	sources := make(map[string]string, len(fields))
+
+	for _, field := range fields {
+		if m.excluded(field.Key) {
+			continue
+		}
+
+		name := m.Name(field.Key)
+		if name == "" {
+			return nil, fmt.Errorf("field %q maps to an empty variable name", field.Key)
+		}
+		if other, exists := sources[name]; exists {
+			return nil, fmt.Errorf("fields %q and %q both map to %s; add a rename or exclude rule", other, field.Key, name)
+		}
+		sources[name] = field.Key
+
+		vars = append(vars, EnvVar{Name: name, Value: field.Value, Key: field.Key})
+	}
+
+	return vars, nil
+}
+
+// Name returns the variable name for a field key
+func (m EnvMapping) Name(key string) string {
+	if renamed, ok := m.Rename[key]; ok {
+		return renamed
+	}
+
+	name := key
+	if m.UpperSnake {
+		name = ToUpperSnake(name)
+	}
+	return m.Prefix + name
+}
+
+// Merge returns m with other's settings layered on top: a non-empty prefix
+// and UpperSnake override, renames are merged and excludes are appended
+func (m EnvMapping) Merge(other EnvMapping) EnvMapping {
+	merged := EnvMapping{
+		Prefix:     m.Prefix,
+		UpperSnake: m.UpperSnake || other.UpperSnake,
+		Rename:     make(map[string]string, len(m.Rename)+len(other.Rename)),
+		Exclude:    append(append([]string{}, m.Exclude...), other.Exclude...),
+	}
+	if other.Prefix != "" {
+		merged.Prefix = other.Prefix
+	}
+	for key, name := range m.Rename {
+		merged.Rename[key] = name
+	}
+	for key, name := range other.Rename {
+		merged.Rename[key] = name
+	}
+	return merged
+}
+
+// excluded reports whether key matches any exclude pattern
+func (m EnvMapping) excluded(key string) bool {
+	for _, pattern := range m.Exclude {
+		if pattern == key {
+			return true
+		}
+		if matched, err := path.Match(pattern, key); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ToUpperSnake converts a key such as "dbHost", "db-host" or "db.host" to "DB_HOST"
+func ToUpperSnake(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// Split camelCase boundaries: aB -> A_B, and ABc -> A_BC
+			if i > 0 && unicode.IsUpper(r) {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					writeSeparator(&b)
+				}
+			}
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			writeSeparator(&b)
+		}
+	}
+
+	return strings.Trim(b.String(), "_")
+}
+
+// writeSeparator writes an underscore unless the builder already ends with one
+func writeSeparator(b *strings.Builder) {
+	if s := b.String(); s != "" && !strings.HasSuffix(s, "_") {
+		b.WriteByte('_')
+	}
+}
+
+// IsDotenvPath reports whether path names a dotenv file, such as .env or
+// prod.env
+func IsDotenvPath(p string) bool {
+	return strings.EqualFold(filepath.Ext(p), ".env")
+}
+
+// FormatDotenv renders variables as a dotenv document, quoting values that
+// need it
+func FormatDotenv(vars []EnvVar) string {
+	var b strings.Builder
+	for _, v := range vars {
+		b.WriteString(v.Name)
+		b.WriteByte('=')
+		b.WriteString(quoteDotenv(v.Value))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// quoteDotenv double-quotes a value when it contains characters a dotenv
+// parser would otherwise misread
+func quoteDotenv(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\"'#$\\`=") {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`, "`", "\\`")
+	return `"` + replacer.Replace(value) + `"`
+}
@@ -0,0 +1,115 @@
+package secretvalue
+
+import "testing"
+
+func TestToUpperSnake(t *testing.T) {
+	tests := map[string]string{
+		"dbHost":       "DB_HOST",
+		"db-host":      "DB_HOST",
+		"db.host":      "DB_HOST",
+		"DB_HOST":      "DB_HOST",
+		"APIKey":       "API_KEY",
+		"oauth2Secret": "OAUTH2_SECRET",
+		"  spaced  ":   "SPACED",
+	}
+
+	for input, want := range tests {
+		if got := ToUpperSnake(input); got != want {
+			t.Fatalf("ToUpperSnake(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEnvMappingApply(t *testing.T) {
+	fields := []Field{
+		{Key: "dbHost", Value: "db.internal"},
+		{Key: "password", Value: "hunter2"},
+		{Key: "internal_note", Value: "skip me"},
+		{Key: "port", Value: "5432"},
+	}
+
+	mapping := EnvMapping{
+		Prefix:     "APP_",
+		UpperSnake: true,
+		Rename:     map[string]string{"password": "DATABASE_PASSWORD"},
+		Exclude:    []string{"internal_*"},
+	}
+
+	vars, err := mapping.Apply(fields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"APP_DB_HOST=db.internal", "DATABASE_PASSWORD=hunter2", "APP_PORT=5432"}
+	if len(vars) != len(want) {
+		t.Fatalf("expected %d vars, got %d: %v", len(want), len(vars), vars)
+	}
+	for i, v := range vars {
+		if v.String() != want[i] {
+			t.Fatalf("var %d = %q, want %q", i, v.String(), want[i])
+		}
+	}
+}
+
+func TestEnvMappingApplyRejectsCollisions(t *testing.T) {
+	fields := []Field{{Key: "db-host", Value: "a"}, {Key: "dbHost", Value: "b"}}
+
+	if _, err := (EnvMapping{UpperSnake: true}).Apply(fields); err == nil {
+		t.Fatal("expected colliding names to be rejected")
+	}
+}
+
+func TestFormatDotenvQuotesWhenNeeded(t *testing.T) {
+	got := FormatDotenv([]EnvVar{
+		{Name: "PLAIN", Value: "abc123"},
+		{Name: "SPACED", Value: "hello world"},
+		{Name: "TRICKY", Value: "a\"b$c\nd"},
+		{Name: "EMPTY", Value: ""},
+	})
+
+	want := "PLAIN=abc123\nSPACED=\"hello world\"\nTRICKY=\"a\\\"b\\$c\\nd\"\nEMPTY=\"\"\n"
+	if got != want {
+		t.Fatalf("unexpected dotenv output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMergeFieldsLaterLayersWin(t *testing.T) {
+	base := []Field{{Key: "host", Value: "db.internal"}, {Key: "port", Value: "5432"}}
+	overrides := []Field{{Key: "host", Value: "localhost"}, {Key: "debug", Value: "true"}}
+
+	got := MergeFields(base, overrides)
+	want := []Field{{Key: "debug", Value: "true"}, {Key: "host", Value: "localhost"}, {Key: "port", Value: "5432"}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d fields, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("field %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCombineFieldsPrefixesConflicts(t *testing.T) {
+	fields, conflicts := CombineFields([]SecretFields{
+		{Secret: "prod/api", Fields: []Field{{Key: "host", Value: "db"}, {Key: "password", Value: "one"}}},
+		{Secret: "prod/worker", Fields: []Field{{Key: "host", Value: "db"}, {Key: "password", Value: "two"}, {Key: "queue", Value: "jobs"}}},
+	})
+
+	want := []Field{
+		{Key: "PROD_API_password", Value: "one"},
+		{Key: "PROD_WORKER_password", Value: "two"},
+		{Key: "host", Value: "db"},
+		{Key: "queue", Value: "jobs"},
+	}
+	if len(fields) != len(want) {
+		t.Fatalf("expected %v, got %v", want, fields)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, fields)
+		}
+	}
+	if len(conflicts) != 1 || conflicts[0] != "password" {
+		t.Fatalf("expected password to be reported as a conflict, got %v", conflicts)
+	}
+}
@@ -0,0 +1,103 @@
+package secretvalue
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Field is a top-level key of a JSON object secret with its formatted value
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields returns the top-level fields of a parsed JSON object sorted by key.
+// It returns an error if the document is not an object.
+func Fields(doc any) ([]Field, error) {
+	object, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("secret value is not a JSON object")
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, Field{Key: key, Value: Format(object[key])})
+	}
+	return fields, nil
+}
+
+// MergeFields combines several field lists into one sorted by key. When a key
+// appears in more than one layer, the value from the later layer wins.
+func MergeFields(layers ...[]Field) []Field {
+	merged := make(map[string]string)
+	for _, layer := range layers {
+		for _, field := range layer {
+			merged[field.Key] = field.Value
+		}
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, Field{Key: key, Value: merged[key]})
+	}
+	return fields
+}
+
+// SecretFields is the fields of one secret, for CombineFields
+type SecretFields struct {
+	Secret string
+	Fields []Field
+}
+
+// CombineFields merges the fields of several secrets into one list sorted by
+// key. A key found in more than one secret with the same value appears once.
+// When the values differ, nothing is dropped: each secret's field is kept
+// under its key prefixed with the secret name in UPPER_SNAKE_CASE, and the
+// key is returned in conflicts.
+func CombineFields(secrets []SecretFields) (fields []Field, conflicts []string) {
+	values := make(map[string]map[string]bool)
+	for _, secret := range secrets {
+		for _, field := range secret.Fields {
+			if values[field.Key] == nil {
+				values[field.Key] = make(map[string]bool)
+			}
+			values[field.Key][field.Value] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		for _, field := range secret.Fields {
+			if len(values[field.Key]) > 1 {
+				field.Key = ToUpperSnake(secret.Secret) + "_" + field.Key
+			}
+			if !seen[field.Key] {
+				seen[field.Key] = true
+				fields = append(fields, field)
+			}
+		}
+	}
+	for key, distinct := range values {
+		if len(distinct) > 1 {
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+	sort.Strings(conflicts)
+	return fields, conflicts
+}
@@ -0,0 +1,137 @@
+package secretvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesValueKey is the data key for a value that isn't a JSON object,
+// including binary values
+const KubernetesValueKey = "value"
+
+var (
+	// kubernetesNamePattern matches a DNS subdomain, which Secret names must be
+	kubernetesNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	// kubernetesLabelPattern matches a DNS label, which namespaces must be
+	kubernetesLabelPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// kubernetesKeyPattern matches the keys allowed in a Secret's data
+	kubernetesKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	// kubernetesNameInvalid matches the runs of characters KubernetesName replaces
+	kubernetesNameInvalid = regexp.MustCompile(`[^a-z0-9.]+`)
+)
+
+// kubernetesSecret is the manifest of a Kubernetes Secret. Field order
+// follows the usual kubectl output.
+type kubernetesSecret struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   kubernetesMetadata `yaml:"metadata"`
+	Type       string             `yaml:"type"`
+	Data       map[string]string  `yaml:"data"`
+}
+
+type kubernetesMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// KubernetesName suggests a Secret name for a secret, such as app-prod-db
+// for app/prod/db: lowercase, with other characters replaced by dashes
+func KubernetesName(secretName string) string {
+	name := kubernetesNameInvalid.ReplaceAllString(strings.ToLower(secretName), "-")
+	name = strings.Trim(name, "-.")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-.")
+	}
+	if name == "" {
+		return "secret"
+	}
+	return name
+}
+
+// ParseKubernetesTarget splits a kubectl-style namespace/name, where the
+// namespace is optional, and checks both are valid
+func ParseKubernetesTarget(target string) (name, namespace string, err error) {
+	name = strings.TrimSpace(target)
+	if before, after, ok := strings.Cut(name, "/"); ok {
+		namespace, name = before, after
+	}
+	if err := ValidateKubernetesNames(name, namespace); err != nil {
+		return "", "", err
+	}
+	return name, namespace, nil
+}
+
+// ValidateKubernetesNames checks that name is a valid Secret name and
+// namespace, unless empty, a valid namespace
+func ValidateKubernetesNames(name, namespace string) error {
+	if len(name) > 253 || !kubernetesNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid Secret name %q: use lowercase letters, digits, '-' and '.'", name)
+	}
+	if namespace != "" && (len(namespace) > 63 || !kubernetesLabelPattern.MatchString(namespace)) {
+		return fmt.Errorf("invalid namespace %q: use lowercase letters, digits and '-'", namespace)
+	}
+	return nil
+}
+
+// KubernetesData returns the data of a Secret holding a secret value. Each
+// top-level field of a JSON object becomes a key; any other value, or binary
+// when it is set, is stored whole under KubernetesValueKey.
+func KubernetesData(value string, binary []byte) (map[string][]byte, error) {
+	if binary != nil {
+		return map[string][]byte{KubernetesValueKey: binary}, nil
+	}
+
+	doc, err := Parse(value)
+	if err != nil {
+		return map[string][]byte{KubernetesValueKey: []byte(value)}, nil
+	}
+	fields, err := Fields(doc)
+	if err != nil {
+		return map[string][]byte{KubernetesValueKey: []byte(value)}, nil
+	}
+
+	data := make(map[string][]byte, len(fields))
+	for _, field := range fields {
+		if !kubernetesKeyPattern.MatchString(field.Key) {
+			return nil, fmt.Errorf("field %q can't be a Secret key: use letters, digits, '-', '_' and '.'", field.Key)
+		}
+		data[field.Key] = []byte(field.Value)
+	}
+	return data, nil
+}
+
+// KubernetesManifest renders a Secret manifest in YAML, with each data value
+// base64 encoded
+func KubernetesManifest(name, namespace string, data map[string][]byte) ([]byte, error) {
+	if err := ValidateKubernetesNames(name, namespace); err != nil {
+		return nil, err
+	}
+
+	manifest := kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   kubernetesMetadata{Name: name, Namespace: namespace},
+		Type:       "Opaque",
+		Data:       make(map[string]string, len(data)),
+	}
+	for key, value := range data {
+		manifest.Data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package secretvalue
+
+import "testing"
+
+func TestKubernetesManifestEncodesFields(t *testing.T) {
+	data, err := KubernetesData(`{"password":"hunter2","port":5432}`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := KubernetesManifest("app-db", "payments", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app-db
+  namespace: payments
+type: Opaque
+data:
+  password: aHVudGVyMg==
+  port: NTQzMg==
+`
+	if string(got) != want {
+		t.Fatalf("unexpected manifest:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestKubernetesDataStoresOtherValuesWhole(t *testing.T) {
+	data, err := KubernetesData("plain-token", nil)
+	if err != nil || string(data[KubernetesValueKey]) != "plain-token" || len(data) != 1 {
+		t.Fatalf("expected the plain value under %q, got %v (%v)", KubernetesValueKey, data, err)
+	}
+
+	data, err = KubernetesData("", []byte{0xde, 0xad})
+	if err != nil || len(data[KubernetesValueKey]) != 2 {
+		t.Fatalf("expected the binary value under %q, got %v (%v)", KubernetesValueKey, data, err)
+	}
+
+	if _, err := KubernetesData(`{"bad key":"x"}`, nil); err == nil {
+		t.Fatal("expected an error for a field that isn't a valid Secret key")
+	}
+}
+
+func TestParseKubernetesTarget(t *testing.T) {
+	name, namespace, err := ParseKubernetesTarget(" payments/app-db ")
+	if err != nil || name != "app-db" || namespace != "payments" {
+		t.Fatalf("unexpected result %q %q (%v)", name, namespace, err)
+	}
+	if _, _, err := ParseKubernetesTarget("App_DB"); err == nil {
+		t.Fatal("expected an invalid name to be rejected")
+	}
+	if got := KubernetesName("App/Prod_DB"); got != "app-prod-db" {
+		t.Fatalf("unexpected suggested name %q", got)
+	}
+}
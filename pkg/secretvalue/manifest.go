@@ -0,0 +1,252 @@
+package secretvalue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// maxManifestSize is the largest manifest ReadManifest reads, in bytes
+const maxManifestSize = 16 << 20
+
+// ManifestEntry is one secret of an import manifest
+type ManifestEntry struct {
+	Name        string
+	Description string
+	Tags        map[string]string
+	Value       models.SecretValue
+}
+
+// manifestSecret is how an entry is written in the secrets list of a
+// manifest. A value that isn't a string is stored as JSON.
+type manifestSecret struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Tags        map[string]string `yaml:"tags"`
+	Value       any               `yaml:"value"`
+}
+
+// ParseManifest reads a YAML or JSON import manifest. It is either a
+// secrets list, whose entries have a name and value and may have a
+// description and tags, or a document keyed by secret name, as
+// `secretsrc get --output` and the export screen write. Entries are
+// returned sorted by name; names used twice or without a value are errors.
+func ParseManifest(data []byte) ([]ManifestEntry, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the manifest: %w", err)
+	}
+	if len(doc) == 0 {
+		return nil, errors.New("the manifest has no secrets")
+	}
+
+	var secrets []manifestSecret
+	if list, ok := doc["secrets"].([]any); ok && len(doc) == 1 {
+		var wrapper struct {
+			Secrets []manifestSecret `yaml:"secrets"`
+		}
+		if err := yaml.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse the manifest: %w", err)
+		}
+		secrets = wrapper.Secrets
+		if len(list) == 0 {
+			return nil, errors.New("the manifest has no secrets")
+		}
+	} else {
+		for name, value := range doc {
+			secrets = append(secrets, manifestSecret{Name: name, Value: value})
+		}
+	}
+
+	entries := make([]ManifestEntry, 0, len(secrets))
+	seen := make(map[string]bool, len(secrets))
+	var errs []error
+	for i, secret := range secrets {
+		name := strings.TrimSpace(secret.Name)
+		switch {
+		case name == "":
+			errs = append(errs, fmt.Errorf("secret %d has no name", i+1))
+			continue
+		case seen[name]:
+			errs = append(errs, fmt.Errorf("%s is in the manifest more than once", name))
+			continue
+		}
+		seen[name] = true
+		value, err := manifestValue(secret.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		entries = append(entries, ManifestEntry{Name: name, Description: secret.Description, Tags: secret.Tags, Value: value})
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// ReadManifest reads and parses the manifest at path
+func ReadManifest(path string) ([]ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the manifest: %w", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the manifest: %w", err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("the manifest is over %d MiB", maxManifestSize>>20)
+	}
+	return ParseManifest(data)
+}
+
+// manifestValue converts a value from the manifest to a secret value:
+// strings as they are and anything else as JSON
+func manifestValue(value any) (models.SecretValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return models.SecretValue{}, errors.New("no value")
+	case string:
+		if v == "" {
+			return models.SecretValue{}, errors.New("no value")
+		}
+		return models.SecretValue{String: v}, nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return models.SecretValue{}, fmt.Errorf("failed to encode the value as JSON: %w", err)
+	}
+	return models.SecretValue{String: strings.TrimSuffix(buf.String(), "\n")}, nil
+}
+
+// ImportChange is what importing a manifest entry does to the secret: it
+// is created, or the keys in Diffs are added, changed or removed
+type ImportChange struct {
+	Entry  ManifestEntry
+	Create bool
+	Diffs  []FieldDiff // Only the keys that differ; empty when the value is the same
+	Owner  string      // The AWS service managing the existing secret, which alone can change it
+}
+
+// PlanImport compares an entry with the current value of its secret, nil
+// when the secret doesn't exist yet
+func PlanImport(entry ManifestEntry, current *models.SecretValue) ImportChange {
+	change := ImportChange{Entry: entry, Create: current == nil}
+	if current == nil {
+		return change
+	}
+	if current.IsBinary() || entry.Value.IsBinary() {
+		if !bytes.Equal(current.Binary, entry.Value.Binary) || current.String != entry.Value.String {
+			change.Diffs = []FieldDiff{{Key: WholeValueKey, Status: DiffChanged}}
+		}
+		return change
+	}
+	diffs := DiffFields(CompareFields(current.String), CompareFields(entry.Value.String))
+	change.Diffs = slices.DeleteFunc(diffs, func(diff FieldDiff) bool { return diff.Status == DiffSame })
+	return change
+}
+
+// ManifestTarget is the account a manifest is imported into, which the
+// current values of its secrets are read from
+type ManifestTarget interface {
+	SecretOwner(ctx context.Context, secretName string) (bool, string, error)
+	GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error)
+}
+
+// PlanManifest plans the import of each entry against the secret's current
+// value in target. It returns the names of the secrets whose values were
+// read, for the audit log, even when reading them failed.
+func PlanManifest(ctx context.Context, target ManifestTarget, entries []ManifestEntry) ([]ImportChange, []string, error) {
+	var existing []string
+	owners := map[string]string{}
+	for _, entry := range entries {
+		exists, owner, err := target.SecretOwner(ctx, entry.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+		if exists {
+			existing = append(existing, entry.Name)
+			owners[entry.Name] = owner
+		}
+	}
+	values := map[string]*models.SecretValue{}
+	if len(existing) > 0 {
+		var err error
+		if values, err = target.GetSecrets(ctx, existing); err != nil {
+			return nil, existing, err
+		}
+	}
+	changes := make([]ImportChange, len(entries))
+	for i, entry := range entries {
+		// Secrets that don't exist yet have no value
+		changes[i] = PlanImport(entry, values[entry.Name])
+		changes[i].Owner = owners[entry.Name]
+	}
+	return changes, existing, nil
+}
+
+// Unchanged reports whether the secret already has the entry's value
+func (c ImportChange) Unchanged() bool {
+	return !c.Create && len(c.Diffs) == 0
+}
+
+// Keys returns the keys the import adds, changes or removes, marked +, ~
+// or -. Every key of a secret being created is added.
+func (c ImportChange) Keys() []string {
+	if c.Create {
+		var keys []string
+		for _, field := range CompareFields(c.Entry.Value.String) {
+			keys = append(keys, "+"+field.Key)
+		}
+		return keys
+	}
+	// The manifest is the second value, so keys only it has are added
+	return MarkedKeys(c.Diffs)
+}
+
+// CheckManaged refuses changes to secrets another AWS service manages, such
+// as RDS, which can only be changed through that service. Unchanged ones
+// are left alone, so they don't matter.
+func CheckManaged(changes []ImportChange) error {
+	var managed []string
+	for _, change := range changes {
+		if change.Owner != "" && !change.Unchanged() {
+			managed = append(managed, fmt.Sprintf("%s (%s)", change.Entry.Name, models.OwningServiceName(change.Owner)))
+		}
+	}
+	switch len(managed) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("%s is managed by another AWS service and can only be changed through it; leave it out to import the rest", managed[0])
+	}
+	return fmt.Errorf("%d secrets are managed by other AWS services and can only be changed through them: %s. Leave them out to import the rest",
+		len(managed), strings.Join(managed, ", "))
+}
+
+// Summary describes the change without any values, e.g. "create: +host
+// +port" or "update: ~password +region -legacy"
+func (c ImportChange) Summary() string {
+	switch {
+	case c.Create:
+		return "create: " + strings.Join(c.Keys(), " ")
+	case c.Unchanged():
+		return "unchanged"
+	}
+	return "update: " + strings.Join(c.Keys(), " ")
+}
@@ -0,0 +1,73 @@
+package secretvalue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestParseManifestListAndKeyedForms(t *testing.T) {
+	entries, err := ParseManifest([]byte(`
+secrets:
+  - name: prod/db
+    description: Postgres
+    tags: { Owner: data }
+    value: { host: db.prod, port: 5432 }
+  - name: prod/api-key
+    value: sk_live_a
+`))
+	if err != nil {
+		t.Fatalf("ParseManifest returned error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "prod/api-key" || entries[0].Value.String != "sk_live_a" {
+		t.Fatalf("expected the entries sorted by name, got %+v", entries)
+	}
+	if db := entries[1]; db.Value.String != `{"host":"db.prod","port":5432}` || db.Description != "Postgres" || db.Tags["Owner"] != "data" {
+		t.Fatalf("expected the object value as JSON with its metadata, got %+v", db)
+	}
+
+	// What `secretsrc get --output json` prints imports as it is
+	entries, err = ParseManifest([]byte(`{"staging/db": {"host": "db.staging"}, "staging/token": "abc"}`))
+	if err != nil || len(entries) != 2 || entries[0].Value.String != `{"host":"db.staging"}` {
+		t.Fatalf("expected a document keyed by name, got %+v, %v", entries, err)
+	}
+
+	_, err = ParseManifest([]byte("secrets:\n  - name: a\n  - value: x\n  - name: b\n    value: x\n  - name: b\n    value: y\n"))
+	for _, want := range []string{"a: no value", "secret 2 has no name", "b is in the manifest more than once"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q to be reported, got %v", want, err)
+		}
+	}
+}
+
+func TestPlanImportDiffsKeysWithoutValues(t *testing.T) {
+	entry := ManifestEntry{Name: "prod/db", Value: models.SecretValue{String: `{"host":"db.prod","password":"new","region":"eu"}`}}
+
+	if change := PlanImport(entry, nil); !change.Create || change.Summary() != "create: +host +password +region" {
+		t.Fatalf("expected a new secret with every key added, got %+v", change)
+	}
+	current := &models.SecretValue{String: `{"region":"eu","password":"old","legacy":true,"host":"db.prod"}`}
+	if change := PlanImport(entry, current); change.Summary() != "update: -legacy ~password" {
+		t.Fatalf("expected the changed and removed keys, got %q", change.Summary())
+	}
+	current = &models.SecretValue{String: `{"region": "eu", "password": "new", "host": "db.prod"}`}
+	if change := PlanImport(entry, current); !change.Unchanged() {
+		t.Fatalf("expected key order and spacing to be ignored, got %+v", change.Diffs)
+	}
+}
+
+func TestCheckManagedRefusesChangesToManagedSecrets(t *testing.T) {
+	entry := ManifestEntry{Name: "prod/rds", Value: models.SecretValue{String: `{"password":"new"}`}}
+	change := PlanImport(entry, &models.SecretValue{String: `{"password":"new"}`})
+	change.Owner = "rds"
+	if err := CheckManaged([]ImportChange{change}); err != nil {
+		t.Fatalf("expected an unchanged managed secret to be allowed, got %v", err)
+	}
+
+	change = PlanImport(entry, &models.SecretValue{String: `{"password":"old"}`})
+	change.Owner = "rds"
+	if err := CheckManaged([]ImportChange{change}); err == nil || !strings.Contains(err.Error(), "prod/rds (RDS) is managed") {
+		t.Fatalf("expected the managed secret to be refused, got %v", err)
+	}
+}
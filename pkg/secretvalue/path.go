@@ -0,0 +1,190 @@
+// Package secretvalue works with the decrypted contents of secret strings.
+package secretvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrKeyNotFound is returned when a key path does not resolve within a secret
+var ErrKeyNotFound = errors.New("key not found")
+
+// PathSegment is a single step in a key path: either an object key or an array index
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// String renders the segment the way it would appear in a path
+func (s PathSegment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+	return s.Key
+}
+
+// ParsePath parses a JSONPath-style key path such as "db.hosts[0].name".
+// A leading "$" or "$." is accepted, and keys containing dots can be quoted
+// with brackets: `config["log.level"]`.
+func ParsePath(path string) ([]PathSegment, error) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return nil, fmt.Errorf("empty key path")
+	}
+
+	var segments []PathSegment
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, PathSegment{Key: current.String()})
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			if current.Len() == 0 && (i == 0 || path[i-1] != ']') {
+				return nil, fmt.Errorf("invalid key path %q: empty key at offset %d", path, i)
+			}
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid key path %q: unclosed '['", path)
+			}
+			inner := path[i+1 : i+end]
+			segment, err := parseBracket(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid key path %q: %w", path, err)
+			}
+			segments = append(segments, segment)
+			i += end
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty key path")
+	}
+	return segments, nil
+}
+
+// parseBracket parses the contents of a [...] path segment
+func parseBracket(inner string) (PathSegment, error) {
+	if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+		return PathSegment{Key: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return PathSegment{}, fmt.Errorf("bracket segment %q must be a non-negative index or a quoted key", inner)
+	}
+	return PathSegment{Index: index, IsIndex: true}, nil
+}
+
+// Parse decodes a secret string as JSON, preserving numbers exactly as written
+func Parse(value string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("secret value is not valid JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// Lookup resolves path against a parsed JSON document
+func Lookup(doc any, path string) (any, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for i, segment := range segments {
+		walked := formatSegments(segments[:i+1])
+		if segment.IsIndex {
+			array, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s is not an array", ErrKeyNotFound, formatSegments(segments[:i]))
+			}
+			if segment.Index >= len(array) {
+				return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, walked)
+			}
+			current = array[segment.Index]
+			continue
+		}
+
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not an object", ErrKeyNotFound, formatSegments(segments[:i]))
+		}
+		value, exists := object[segment.Key]
+		if !exists {
+			return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, walked)
+		}
+		current = value
+	}
+
+	return current, nil
+}
+
+// Extract parses a secret string and returns the formatted value at path
+func Extract(value, path string) (string, error) {
+	doc, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := Lookup(doc, path)
+	if err != nil {
+		return "", err
+	}
+	return Format(result), nil
+}
+
+// Format renders a JSON value for output: strings are returned as-is and
+// everything else is rendered as compact JSON
+func Format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// formatSegments renders segments back into a readable path
+func formatSegments(segments []PathSegment) string {
+	if len(segments) == 0 {
+		return "$"
+	}
+
+	var b strings.Builder
+	for i, segment := range segments {
+		if !segment.IsIndex && i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(segment.String())
+	}
+	return b.String()
+}
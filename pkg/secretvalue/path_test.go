@@ -0,0 +1,51 @@
+package secretvalue
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	value := `{"db":{"host":"db.internal","port":5432,"replicas":[{"host":"r1"},{"host":"r2"}]},"log.level":"debug","enabled":true,"nothing":null}`
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "db.host", want: "db.internal"},
+		{path: "$.db.port", want: "5432"},
+		{path: "db.replicas[1].host", want: "r2"},
+		{path: "db.replicas[0]", want: `{"host":"r1"}`},
+		{path: `["log.level"]`, want: "debug"},
+		{path: "enabled", want: "true"},
+		{path: "nothing", want: "null"},
+	}
+
+	for _, tt := range tests {
+		got, err := Extract(value, tt.path)
+		if err != nil {
+			t.Fatalf("Extract(%q) returned error: %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Fatalf("Extract(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMissingKeys(t *testing.T) {
+	value := `{"db":{"host":"db.internal","replicas":["r1"]}}`
+
+	for _, path := range []string{"db.user", "db.replicas[3]", "db.host.name", "missing[0]"} {
+		if _, err := Extract(value, path); !errors.Is(err, ErrKeyNotFound) {
+			t.Fatalf("expected ErrKeyNotFound for %q, got %v", path, err)
+		}
+	}
+}
+
+func TestParsePathRejectsMalformedPaths(t *testing.T) {
+	for _, path := range []string{"", "$", "a..b", "a[", "a[-1]", "a[x]"} {
+		if _, err := ParsePath(path); err == nil {
+			t.Fatalf("expected %q to be rejected", path)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package secretvalue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaRule attaches a JSON schema to the secrets whose names match a glob
+type SchemaRule struct {
+	Pattern string `json:"pattern" yaml:"pattern"` // Glob over secret names; "*" does not match "/"
+	Schema  string `json:"schema" yaml:"schema"`   // Path to the JSON schema file
+}
+
+// Schemas are compiled schema rules, which values are checked against
+// before they are written to a secret. The zero value has no rules.
+type Schemas struct {
+	rules []compiledRule
+}
+
+// compiledRule is a SchemaRule with its schema compiled
+type compiledRule struct {
+	pattern string
+	file    string // Base name of the schema file, for errors
+	schema  *jsonschema.Schema
+}
+
+// CompileSchemas compiles the schema of each rule. Rules with an invalid
+// pattern or a schema that can't be read or compiled are left out, and
+// reported together in the error.
+func CompileSchemas(rules []SchemaRule) (*Schemas, error) {
+	schemas := &Schemas{}
+	var errs []error
+	for _, rule := range rules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil || rule.Pattern == "" {
+			errs = append(errs, fmt.Errorf("schemas has an invalid pattern %q", rule.Pattern))
+			continue
+		}
+		schema, err := jsonschema.Compile(rule.Schema)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to compile the schema for %s: %w", rule.Pattern, err))
+			continue
+		}
+		schemas.rules = append(schemas.rules, compiledRule{
+			pattern: rule.Pattern,
+			file:    filepath.Base(rule.Schema),
+			schema:  schema,
+		})
+	}
+	return schemas, errors.Join(errs...)
+}
+
+// Validate checks a value about to be written to the named secret against
+// the schema of every rule matching the name. Values of secrets no rule
+// matches always pass; binary values never pass a schema.
+func (s *Schemas) Validate(name string, value models.SecretValue) error {
+	if s == nil {
+		return nil
+	}
+	for _, rule := range s.rules {
+		if matched, _ := path.Match(rule.pattern, name); !matched {
+			continue
+		}
+		if value.IsBinary() {
+			return fmt.Errorf("%s must be JSON matching %s, not a binary value", name, rule.file)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader([]byte(value.String)))
+		decoder.UseNumber()
+		var doc any
+		if err := decoder.Decode(&doc); err != nil {
+			return fmt.Errorf("%s must be JSON matching %s: %w", name, rule.file, err)
+		}
+		if err := rule.schema.Validate(doc); err != nil {
+			return fmt.Errorf("%s doesn't match %s: %s", name, rule.file, schemaProblems(err))
+		}
+	}
+	return nil
+}
+
+// schemaProblems lists what a validation error found wrong, leaving out the
+// errors that only group others
+func schemaProblems(err error) string {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err.Error()
+	}
+
+	var problems []string
+	var collect func(*jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			problem := e.Message
+			if e.InstanceLocation != "" {
+				problem = e.InstanceLocation + ": " + problem
+			}
+			problems = append(problems, problem)
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	return strings.Join(problems, "; ")
+}
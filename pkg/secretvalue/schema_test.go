@@ -0,0 +1,51 @@
+package secretvalue
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestSchemasValidateMatchingSecrets(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "database.json")
+	schema := `{"type": "object", "required": ["username", "password"], "properties": {"port": {"type": "integer"}}}`
+	if err := os.WriteFile(schemaPath, []byte(schema), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas, err := CompileSchemas([]SchemaRule{
+		{Pattern: "*/*/database", Schema: schemaPath},
+		{Pattern: "[", Schema: schemaPath},
+		{Pattern: "*/api", Schema: filepath.Join(t.TempDir(), "missing.json")},
+	})
+	if err == nil || !strings.Contains(err.Error(), `invalid pattern "["`) || !strings.Contains(err.Error(), "*/api") {
+		t.Fatalf("expected the bad pattern and missing schema to be reported, got %v", err)
+	}
+
+	valid := models.SecretValue{String: `{"username": "app", "password": "hunter2", "port": 5432}`}
+	if err := schemas.Validate("prod/orders/database", valid); err != nil {
+		t.Fatalf("expected a valid value to pass, got %v", err)
+	}
+	missing := models.SecretValue{String: `{"username": "app", "port": "5432"}`}
+	err = schemas.Validate("prod/orders/database", missing)
+	if err == nil || !strings.Contains(err.Error(), "password") || !strings.Contains(err.Error(), "/port") {
+		t.Fatalf("expected the missing password and string port to be reported, got %v", err)
+	}
+	if err := schemas.Validate("prod/orders/database", models.SecretValue{String: "hunter2"}); err == nil {
+		t.Fatal("expected a value that isn't JSON to fail")
+	}
+	if err := schemas.Validate("prod/orders/database", models.SecretValue{Binary: []byte{1}}); err == nil {
+		t.Fatal("expected a binary value to fail")
+	}
+	if err := schemas.Validate("prod/orders/api-key", missing); err != nil {
+		t.Fatalf("expected secrets without a schema to pass, got %v", err)
+	}
+
+	var none *Schemas
+	if err := none.Validate("prod/orders/database", missing); err != nil {
+		t.Fatalf("expected no schemas to pass everything, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package secretvalue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// SyncRule transforms the values of the secrets whose names match a glob as
+// they are synced to another secret, e.g. from a key vault into AWS
+type SyncRule struct {
+	Pattern string            `json:"pattern" yaml:"pattern"`         // Glob over source secret names; "*" does not match "/"
+	Rename  map[string]string `json:"rename,omitempty" yaml:"rename"` // Source key -> destination key
+	Drop    []string          `json:"drop,omitempty" yaml:"drop"`     // Source keys left out; glob patterns are allowed
+	Set     map[string]string `json:"set,omitempty" yaml:"set"`       // Keys written with a fixed value, replacing any synced one
+}
+
+// KeyMapping is where one key of a synced value ends up
+type KeyMapping struct {
+	From string // The source key, "" for a key a rule sets
+	To   string // The destination key, "" for a key that is dropped
+}
+
+// SyncRules are the sync rules from the config. The first rule matching a
+// secret's name applies to it; secrets no rule matches are synced as they
+// are.
+type SyncRules []SyncRule
+
+// Validate reports rules with an invalid pattern or an empty key
+func (r SyncRules) Validate() error {
+	var errs []error
+	for _, rule := range r {
+		if _, err := path.Match(rule.Pattern, ""); err != nil || rule.Pattern == "" {
+			errs = append(errs, fmt.Errorf("sync_rules has an invalid pattern %q", rule.Pattern))
+			continue
+		}
+		for from, to := range rule.Rename {
+			if from == "" || to == "" {
+				errs = append(errs, fmt.Errorf("sync_rules for %s renames %q to %q, keys can't be empty", rule.Pattern, from, to))
+			}
+		}
+		for _, pattern := range rule.Drop {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				errs = append(errs, fmt.Errorf("sync_rules for %s drops an invalid pattern %q", rule.Pattern, pattern))
+			}
+		}
+		if _, ok := rule.Set[""]; ok {
+			errs = append(errs, fmt.Errorf("sync_rules for %s sets an empty key", rule.Pattern))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Rule returns the rule applying to the named source secret, if any
+func (r SyncRules) Rule(name string) (SyncRule, bool) {
+	for _, rule := range r {
+		if matched, _ := path.Match(rule.Pattern, name); matched {
+			return rule, true
+		}
+	}
+	return SyncRule{}, false
+}
+
+// Transform returns the value to write when syncing the named source
+// secret, and where each of its keys ends up. A value that isn't a JSON
+// object is synced whole, under WholeValueKey, unless a rule would change
+// its keys.
+func (r SyncRules) Transform(name string, value models.SecretValue) (models.SecretValue, []KeyMapping, error) {
+	rule, _ := r.Rule(name)
+	changesKeys := len(rule.Rename) > 0 || len(rule.Drop) > 0 || len(rule.Set) > 0
+
+	var object map[string]json.RawMessage
+	if value.IsBinary() || json.Unmarshal([]byte(value.String), &object) != nil || object == nil {
+		if changesKeys {
+			return models.SecretValue{}, nil, fmt.Errorf("%s isn't a JSON object, so the sync rule for %s can't rename, drop or set its keys", name, rule.Pattern)
+		}
+		return value, []KeyMapping{{From: WholeValueKey, To: WholeValueKey}}, nil
+	}
+	if !changesKeys {
+		mappings := make([]KeyMapping, 0, len(object))
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			mappings = append(mappings, KeyMapping{From: key, To: key})
+		}
+		return value, mappings, nil
+	}
+
+	synced := make(map[string]json.RawMessage, len(object)+len(rule.Set))
+	sources := make(map[string]string, len(object))
+	var mappings []KeyMapping
+	for _, key := range slices.Sorted(maps.Keys(object)) {
+		if dropped(rule.Drop, key) {
+			mappings = append(mappings, KeyMapping{From: key})
+			continue
+		}
+		to := key
+		if renamed, ok := rule.Rename[key]; ok {
+			to = renamed
+		}
+		if other, exists := sources[to]; exists {
+			return models.SecretValue{}, nil, fmt.Errorf("keys %q and %q of %s both sync to %s; add a rename or drop rule", other, key, name, to)
+		}
+		sources[to] = key
+		synced[to] = object[key]
+		mappings = append(mappings, KeyMapping{From: key, To: to})
+	}
+	for _, key := range slices.Sorted(maps.Keys(rule.Set)) {
+		data, err := json.Marshal(rule.Set[key])
+		if err != nil {
+			return models.SecretValue{}, nil, err
+		}
+		synced[key] = data
+		if _, fromSource := sources[key]; !fromSource {
+			mappings = append(mappings, KeyMapping{To: key})
+		}
+	}
+
+	data, err := json.Marshal(synced)
+	if err != nil {
+		return models.SecretValue{}, nil, fmt.Errorf("failed to encode the synced value: %w", err)
+	}
+	return models.SecretValue{String: string(data)}, mappings, nil
+}
+
+// dropped reports whether key matches one of the drop patterns
+func dropped(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
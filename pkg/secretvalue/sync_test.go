@@ -0,0 +1,52 @@
+package secretvalue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+func TestSyncRulesTransformKeys(t *testing.T) {
+	rules := SyncRules{
+		{Pattern: "app-*", Rename: map[string]string{"pass": "password"}, Drop: []string{"note*"}, Set: map[string]string{"engine": "postgres"}},
+		{Pattern: "*", Rename: map[string]string{"ignored": "x"}},
+	}
+
+	value := models.SecretValue{String: `{"user": "app", "pass": "hunter2", "notes": "old", "port": 5432}`}
+	synced, mappings, err := rules.Transform("app-db", value)
+	if err != nil {
+		t.Fatalf("Transform returned error: %v", err)
+	}
+	if synced.String != `{"engine":"postgres","password":"hunter2","port":5432,"user":"app"}` {
+		t.Fatalf("unexpected synced value %s", synced.String)
+	}
+	want := []KeyMapping{{From: "notes"}, {From: "pass", To: "password"}, {From: "port", To: "port"}, {From: "user", To: "user"}, {To: "engine"}}
+	if len(mappings) != len(want) {
+		t.Fatalf("expected %v, got %v", want, mappings)
+	}
+	for i := range want {
+		if mappings[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, mappings)
+		}
+	}
+
+	if _, _, err := rules.Transform("app-token", models.SecretValue{String: "plain"}); err == nil || !strings.Contains(err.Error(), "isn't a JSON object") {
+		t.Fatalf("expected a rule changing keys to refuse plain text, got %v", err)
+	}
+	clash := SyncRules{{Pattern: "*", Rename: map[string]string{"a": "b"}}}
+	if _, _, err := clash.Transform("x", models.SecretValue{String: `{"a": 1, "b": 2}`}); err == nil || !strings.Contains(err.Error(), "both sync to b") {
+		t.Fatalf("expected a clash to be refused, got %v", err)
+	}
+	if err := (SyncRules{{Pattern: "["}, {Pattern: "*", Drop: []string{""}}}).Validate(); err == nil {
+		t.Fatal("expected invalid patterns to be reported")
+	}
+}
+
+func TestSyncRulesPassValuesThroughUnmatched(t *testing.T) {
+	value := models.SecretValue{Binary: []byte{0, 1}}
+	synced, mappings, err := SyncRules(nil).Transform("cert", value)
+	if err != nil || !synced.IsBinary() || len(mappings) != 1 || mappings[0].To != WholeValueKey {
+		t.Fatalf("expected a binary value to sync whole, got %v %v %v", synced, mappings, err)
+	}
+}
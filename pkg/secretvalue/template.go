@@ -0,0 +1,159 @@
+package secretvalue
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// passwordAlphabet is what generated values are made of: characters that
+// need no quoting in URLs, shells or connection strings
+const passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// Template is a starting point for new secrets: the keys of their JSON
+// value, with defaults, and the tags and rotation they are created with
+type Template struct {
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description,omitempty" yaml:"description"` // Description of the secrets created
+	Fields      []TemplateField   `json:"fields" yaml:"fields"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags"`
+	Rotation    *TemplateRotation `json:"rotation,omitempty" yaml:"rotation"`
+}
+
+// TemplateField is one key of a template's value
+type TemplateField struct {
+	Key      string `json:"key" yaml:"key"`
+	Default  string `json:"default,omitempty" yaml:"default"`
+	Type     string `json:"type,omitempty" yaml:"type"`         // string (default), number or boolean
+	Generate int    `json:"generate,omitempty" yaml:"generate"` // Length of a random value to generate instead of asking, e.g. for a password
+	Secret   bool   `json:"secret,omitempty" yaml:"secret"`     // Masked as it is typed
+	Optional bool   `json:"optional,omitempty" yaml:"optional"` // May be left empty, which leaves the key out
+}
+
+// TemplateRotation turns on rotation for the secrets created
+type TemplateRotation struct {
+	Lambda string `json:"lambda" yaml:"lambda"` // ARN of the rotation function
+	Days   int    `json:"days" yaml:"days"`     // Rotate this many days after the last rotation
+}
+
+// fieldTypes are the types a template field can have
+var fieldTypes = []string{"", "string", "number", "boolean"}
+
+// Validate reports a template without a name or fields, a field without a
+// key, a key used twice, an unknown type or a default of the wrong type
+func (t Template) Validate() error {
+	if t.Name == "" {
+		return errors.New("templates must each have a name")
+	}
+	if len(t.Fields) == 0 {
+		return fmt.Errorf("template %s has no fields", t.Name)
+	}
+	var errs []error
+	keys := make(map[string]bool, len(t.Fields))
+	for _, field := range t.Fields {
+		switch {
+		case field.Key == "":
+			errs = append(errs, fmt.Errorf("template %s has a field without a key", t.Name))
+		case keys[field.Key]:
+			errs = append(errs, fmt.Errorf("template %s has %s more than once", t.Name, field.Key))
+		case !slices.Contains(fieldTypes, field.Type):
+			errs = append(errs, fmt.Errorf("template %s field %s must be a string, number or boolean, not %q", t.Name, field.Key, field.Type))
+		case field.Generate < 0 || field.Generate > 4096:
+			errs = append(errs, fmt.Errorf("template %s field %s must generate 1 to 4096 characters", t.Name, field.Key))
+		case field.Default != "":
+			if _, err := field.value(field.Default); err != nil {
+				errs = append(errs, fmt.Errorf("template %s field %s: %w", t.Name, field.Key, err))
+			}
+		}
+		keys[field.Key] = true
+	}
+	if t.Rotation != nil && (t.Rotation.Lambda == "" || t.Rotation.Days < 1 || t.Rotation.Days > 1000) {
+		errs = append(errs, fmt.Errorf("template %s rotation needs a lambda and days from 1 to 1000", t.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// Asked returns the fields to ask for, in order: all but the generated ones
+func (t Template) Asked() []TemplateField {
+	var asked []TemplateField
+	for _, field := range t.Fields {
+		if field.Generate == 0 {
+			asked = append(asked, field)
+		}
+	}
+	return asked
+}
+
+// Build makes the value of a new secret from the fields asked for, keyed
+// by field key, generating the others. Every field that isn't optional
+// needs a value, of its type.
+func (t Template) Build(values map[string]string) (models.SecretValue, error) {
+	object := make(map[string]any, len(t.Fields))
+	for _, field := range t.Fields {
+		text := values[field.Key]
+		if field.Generate > 0 {
+			generated, err := GeneratePassword(field.Generate)
+			if err != nil {
+				return models.SecretValue{}, err
+			}
+			text = generated
+		}
+		if strings.TrimSpace(text) == "" {
+			if field.Optional {
+				continue
+			}
+			return models.SecretValue{}, fmt.Errorf("%s needs a value", field.Key)
+		}
+		value, err := field.value(text)
+		if err != nil {
+			return models.SecretValue{}, fmt.Errorf("%s: %w", field.Key, err)
+		}
+		object[field.Key] = value
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return models.SecretValue{}, fmt.Errorf("failed to encode the value: %w", err)
+	}
+	return models.SecretValue{String: string(data)}, nil
+}
+
+// value converts text typed for the field to its type
+func (f TemplateField) value(text string) (any, error) {
+	switch f.Type {
+	case "number":
+		number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a number", text)
+		}
+		return number, nil
+	case "boolean":
+		boolean, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't true or false", text)
+		}
+		return boolean, nil
+	}
+	return text, nil
+}
+
+// GeneratePassword returns n random characters from passwordAlphabet, read
+// from crypto/rand
+func GeneratePassword(n int) (string, error) {
+	var b strings.Builder
+	limit := big.NewInt(int64(len(passwordAlphabet)))
+	for range n {
+		i, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate a value: %w", err)
+		}
+		b.WriteByte(passwordAlphabet[i.Int64()])
+	}
+	return b.String(), nil
+}
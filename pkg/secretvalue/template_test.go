@@ -0,0 +1,58 @@
+package secretvalue
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTemplateBuildsTypedValue(t *testing.T) {
+	template := Template{Name: "rds-postgres", Fields: []TemplateField{
+		{Key: "host"},
+		{Key: "port", Type: "number", Default: "5432"},
+		{Key: "ssl", Type: "boolean", Optional: true},
+		{Key: "password", Generate: 24},
+	}}
+	if err := template.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if asked := template.Asked(); len(asked) != 3 || asked[2].Key != "ssl" {
+		t.Fatalf("expected the generated password not to be asked for, got %+v", asked)
+	}
+
+	value, err := template.Build(map[string]string{"host": "db.internal", "port": "5433"})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal([]byte(value.String), &object); err != nil {
+		t.Fatalf("expected a JSON object, got %s", value.String)
+	}
+	if object["host"] != "db.internal" || object["port"] != float64(5433) || len(object["password"].(string)) != 24 {
+		t.Fatalf("unexpected value %s", value.String)
+	}
+	if _, ok := object["ssl"]; ok {
+		t.Fatalf("expected the empty optional key to be left out, got %s", value.String)
+	}
+
+	if _, err := template.Build(map[string]string{"host": "db", "port": "five"}); err == nil || !strings.Contains(err.Error(), "port") {
+		t.Fatalf("expected the port to be rejected, got %v", err)
+	}
+	if _, err := template.Build(map[string]string{"port": "5432"}); err == nil || !strings.Contains(err.Error(), "host needs a value") {
+		t.Fatalf("expected the missing host to be reported, got %v", err)
+	}
+}
+
+func TestTemplateValidateReportsBadFields(t *testing.T) {
+	template := Template{Name: "api", Fields: []TemplateField{
+		{Key: "port", Type: "number", Default: "http"},
+		{Key: "port"},
+		{Key: "mode", Type: "enum"},
+	}, Rotation: &TemplateRotation{Days: 30}}
+	err := template.Validate()
+	for _, want := range []string{`"http" isn't a number`, "port more than once", `not "enum"`, "rotation needs a lambda"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q to be reported, got %v", want, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/iac"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// secretAccessMsg reports who IAM policies grant access to a secret, what
+// its resource policy says and which state files and templates mention
+// it. Each part that couldn't be read has its error.
+type secretAccessMsg struct {
+	name      string
+	grants    []aws.PolicyGrant
+	grantsErr error
+	policy    string
+	policyErr error
+	files     []iac.Reference
+	filesErr  error
+	err       error // The secret couldn't be described
+}
+
+// findSecretAccess describes the secret, for its ARN, then reads IAM, its
+// resource policy and the state paths
+func findSecretAccess(ctx context.Context, client *aws.Client, name string, statePaths []string) tea.Cmd {
+	return func() tea.Msg {
+		details, err := client.DescribeSecret(ctx, name)
+		if err != nil {
+			return secretAccessMsg{name: name, err: err}
+		}
+		msg := secretAccessMsg{name: name}
+		msg.grants, msg.grantsErr = client.FindPolicyGrants(ctx, details)
+		msg.policy, msg.policyErr = client.GetResourcePolicy(ctx, name)
+		if len(statePaths) > 0 {
+			msg.files, msg.filesErr = iac.Search(statePaths, iac.Secret{Name: details.Name, ARN: details.ARN})
+		}
+		if err := ctx.Err(); err != nil {
+			msg.err = err
+		}
+		return msg
+	}
+}
+
+// startFindAccess looks for who can read the open secret
+func (m Model) startFindAccess() (tea.Model, tea.Cmd) {
+	client, ok := m.awsSource("Finding who can access a secret")
+	if !ok {
+		return m, nil
+	}
+	name := m.grid.SelectedSecret().Name
+	return m, findSecretAccess(m.startLoad("Looking for "+name+" in IAM policies…"), client, name, m.statePaths)
+}
+
+// showSecretAccess lists the principals granted access to the secret by
+// IAM policies, then its resource policy and the files mentioning it
+func (m Model) showSecretAccess(msg secretAccessMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showErrorFor(m.currentProfile, m.currentRegion, "Failed to find who can access "+msg.name, msg.err)
+		return m, nil
+	}
+
+	var items []components.SummaryItem
+	specific := 0
+	for _, grant := range msg.grants {
+		policy := grant.Policy + " (inline)"
+		if grant.Managed {
+			policy = grant.Policy
+		}
+		detail := fmt.Sprintf("%s allows %s on %s", policy, strings.Join(grant.Actions, ", "), grant.Resource)
+		status := components.ItemPending
+		if !grant.Broad() {
+			// Named by a policy: the grants to look at first
+			status = components.ItemSucceeded
+			specific++
+		}
+		items = append(items, components.SummaryItem{Name: grant.Principal, Status: status, Detail: detail})
+	}
+	if msg.policy != "" {
+		detail := "allows the principals it names"
+		if inventory.BroadPolicy(msg.policy) {
+			detail = "allows any principal without a condition"
+		}
+		items = append(items, components.SummaryItem{Name: "Resource policy", Status: components.ItemPending, Detail: detail})
+	}
+	for _, ref := range msg.files {
+		items = append(items, components.SummaryItem{Name: ref.Kind + " " + ref.Resource, Status: components.ItemPending, Detail: filepath.Base(ref.File)})
+	}
+
+	intro := fmt.Sprintf("%d IAM policy grants name %s, %d more cover every secret.", specific, msg.name, len(msg.grants)-specific)
+	if len(m.statePaths) > 0 {
+		intro += fmt.Sprintf(" %d resources in state_paths mention it.", len(msg.files))
+	}
+	intro += " Deny statements, NotAction, NotResource and conditions aren't evaluated."
+	var unchecked []string
+	for _, err := range []error{msg.grantsErr, msg.policyErr, msg.filesErr} {
+		if err != nil {
+			unchecked = append(unchecked, err.Error())
+		}
+	}
+	if len(unchecked) > 0 {
+		intro += " Not checked: " + strings.Join(unchecked, "; ")
+	}
+	m.openScreen(ScreenAccess, newResultsScreen("Who can access "+msg.name, intro, items), ScreenSecretDetail)
+	return m, nil
+}
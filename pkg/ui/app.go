@@ -2,16 +2,24 @@ package ui
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/atotto/clipboard"
-	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/benjamingriff/secretsrc/pkg/auth"
 	"github.com/benjamingriff/secretsrc/pkg/aws"
 	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
 	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/passwords"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
 	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -25,43 +33,199 @@ const (
 	ScreenProfileSelector
 	ScreenRegionSelector
 	ScreenMFAInput
+	ScreenSaveSecret
+	ScreenExportSecrets
+	ScreenLocked
+	ScreenSecretActions
+	ScreenExportReport
+	ScreenBulkActions
+	ScreenBulkTag
+	ScreenBulkUntag
+	ScreenBulkConfirm
+	ScreenBulkResults
+	ScreenCopyRegion
+	ScreenCopyConfirm
+	ScreenCopyProfile
+	ScreenSecondMFA
+	ScreenCompareProfile
+	ScreenCompareRegion
+	ScreenComparePick
+	ScreenCompare
+	ScreenSaveConfirm
+	ScreenKubeManifest
+	ScreenExportFormat
+	ScreenAudit
+	ScreenAuditExport
+	ScreenSetup
+	ScreenWorkspaces
+	ScreenWorkspaceName
+	ScreenKMSKeyPicker
+	ScreenKMSKeyConfirm
+	ScreenLint
+	ScreenUndo
+	ScreenImportPick
+	ScreenImportName
+	ScreenSyncProfile
+	ScreenSyncRegion
+	ScreenSyncName
+	ScreenSyncPreview
+	ScreenSyncConfirm
+	ScreenReferences
+	ScreenAccess
+	ScreenTemplatePick
+	ScreenTemplateName
+	ScreenTemplateField
+	ScreenTemplateConfirm
+	ScreenManifestPath
+	ScreenManifestConfirm
+	ScreenBackends
+	ScreenBackendVault
+	ScreenApps
 )
 
-// Model is the main Bubble Tea model
+// SecretStore is what the UI reads secrets from. *aws.Client implements it,
+// demo.Store stands in for it in --demo mode and azure.Vault with
+// --azure-vault.
+type SecretStore interface {
+	inventory.Source
+	GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error)
+	GetSecrets(ctx context.Context, secretNames []string) (map[string]*models.SecretValue, error)
+	DescribeSecret(ctx context.Context, secretName string) (*models.SecretDetails, error)
+	UsesDefaultChain() bool
+	GetEndpoint() string
+	CallerIdentity(ctx context.Context) (*models.CallerIdentity, error)
+	ListRegions(ctx context.Context) ([]models.Region, error)
+
+	TagSecret(ctx context.Context, secretName, key, value string) error
+	UntagSecret(ctx context.Context, secretName, key string) error
+	ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error)
+	RestoreSecret(ctx context.Context, secretName string) error
+	ListKMSKeys(ctx context.Context) ([]models.KMSKey, error)
+	GetResourcePolicies(ctx context.Context, secretNames []string) (map[string]string, error)
+	ChangeKMSKey(ctx context.Context, secretName, kmsKeyID string) error
+}
+
+// Model is the root Bubble Tea model. It handles the secret list and detail
+// screens itself, and routes every other screen to a sub-model (see screen).
 type Model struct {
 	// Current screen
 	currentScreen Screen
 
 	// AWS client and state
-	awsClient      *aws.Client
+	awsClient      SecretStore
 	currentProfile string
 	currentRegion  string
+	identity       *models.CallerIdentity // Nil until loaded, or when STS can't say
+	regions        []models.Region        // Listed for awsClient, nil until the region selector first opens
+	profileRegions map[string]string      // Region last used with each profile, restored when switching back
+	workspaces     map[string]config.Workspace
+	apps           []secretvalue.App // Secrets grouped by the app using them, from the config
 
 	// Secret data
-	secrets       []models.Secret
-	selectedIndex int
-	secretValue   string
-	secretFields  []components.SecretField
-	nextToken     *string
-	hasMore       bool
+	secrets        []models.Secret
+	selectedIndex  int
+	secretValue    string
+	secretBinary   []byte       // Set instead of secretValue for binary secrets
+	binaryFormat   binaryFormat // How secretBinary is displayed
+	valueRevealed  bool         // Loaded values are masked until revealed
+	revealSeq      int          // Incremented on each reveal toggle to ignore stale re-mask ticks
+	revealTimeout  time.Duration
+	fieldCursor    int             // Selected JSON field while the value is masked
+	revealedFields map[string]bool // JSON fields revealed individually
+	secretFields   []components.SecretField
+	secretDetails  *models.SecretDetails // Extended metadata for the detail screen
+	detailsError   string
 
 	// Pagination state
-	pageHistory []secretPage // History of loaded pages
-	currentPage int          // Current page index in history
+	inventory   *inventory.Service // Pages loaded for the current profile and region
+	currentPage int                // Index of the page shown
+	allPages    bool               // Every page is shown as one list, after "a"
+	deepLink    string             // Secret to open once the list loads, from the command line
+	pageSize    int32              // Secrets per ListSecrets call, 0 for inventory's default
 
 	// UI components
-	grid            components.SecretGrid
-	fieldSelector   components.SecretFieldSelector
-	profileSelector components.ProfileSelector
-	regionSelector  components.RegionSelector
-	mfaInput        components.MFAInput
-	keys            KeyMap
+	grid       components.SecretGrid
+	keys       KeyMap
+	keyAliases keyAliases // Extra keys from the keybindings setting
+
+	// Active sub-model screen, nil on the secret list and detail screens
+	screen       screen
+	screenReturn Screen // Screen shown when the active screen closes
+
+	// Export state
+	exportTargets []string               // Secrets the export prompt writes
+	exportFormat  exportFormat           // What the export prompt writes them as
+	envMapping    secretvalue.EnvMapping // Variable naming for combined .env exports
+	schemas       *secretvalue.Schemas   // Checked before values are written, from the config
+	savePath      string                 // Existing file a save is waiting for confirmation to replace
+
+	// Audit state
+	audit     auditState // Secrets listed for the audit screen
+	staleDays int        // Days without access that make a secret stale, from the config
+
+	// Value history state
+	trackValues      bool                        // Record a hash of each viewed value, from the config
+	auditLog         string                      // File actions on secrets are appended to, from the config
+	passwordManagers []passwords.Manager         // Imported from with I, from the config
+	statePaths       []string                    // Terraform state and CloudFormation templates searched with W, from the config
+	templates        []secretvalue.Template      // New secrets are created from with N, from the config
+	valueViews       map[string]config.ValueView // Last view of each secret's value, by ARN
+	values           valueCache                  // Values fetched this session, kept for value_cache_ttl
+
+	// Scrollback state
+	scrollbackProtection bool // Blank the screen before quitting or suspending, from the config
+	blanked              bool // The screen is left blank while quitting or suspended
+
+	// Lint state
+	lintRules inventory.LintRules // Which compliance checks run, from the config
+
+	// Bulk state
+	bulkOp      bulkOperation // Operation being set up or run on the marked secrets
+	bulkChanged bool          // The last bulk operation changed secrets, so the list reloads after its results
+
+	// Copy and compare state
+	copyTo        copyDestination       // Where the open secret is being copied
+	compare       compareState          // What the open secret is being compared with
+	syncTo        syncState             // The secret the open one is being synced to
+	syncRules     secretvalue.SyncRules // Keys renamed, dropped and set when syncing, from the config
+	importing     passwordImport        // The password manager item being imported
+	creating      templateDraft         // The secret being created from a template
+	manifest      manifestImport        // The manifest being imported
+	secondPurpose secondPurpose         // What the second profile being signed in to is for
+
+	// kmsChange is the KMS key the open secret is being moved to
+	kmsChange kmsChange
+
+	// dryRun collects the writes skipped while dry-run mode is on, nil
+	// while it is off
+	dryRun *dryRunLog
+
+	// endpointURL overrides the Secrets Manager endpoint, from --endpoint-url
+	endpointURL string
+	// backend replaces AWS for every profile and region when set, by --demo,
+	// --azure-vault, --kubernetes or the backend picker. backendName says
+	// which in the header.
+	backend     SecretStore
+	backendName string
+	// awsProfile and awsRegion are where to return to AWS from another
+	// backend picked with V
+	awsProfile, awsRegion string
 
 	// MFA state
-	pendingMFAProfile       string
-	pendingMFARegion        string
-	pendingMFASourceProfile string
-	mfaSerial               string
+	pendingMFAProfile string
+	pendingMFARegion  string
+
+	// reauth holds the operations to retry once an expired session is renewed
+	reauth reauthState
+
+	// Requests
+	requestTimeout time.Duration      // Bounds each AWS operation
+	loadCancel     context.CancelFunc // Cancels the load shown as loading
+	listCacheTTL   time.Duration      // How long cached secret lists are shown, 0 disables
+	cachedAt       time.Time          // When the shown list was cached, zero once it loads from AWS
+	loadingText    string             // What is loading, shown by the spinner
+	spinner        spinner.Model
+	spinning       bool // Whether spinner ticks are scheduled
 
 	// UI state
 	loading       bool
@@ -70,50 +234,122 @@ type Model struct {
 	width         int
 	height        int
 	showHelp      bool
-}
-
-// secretPage represents a page of secrets
-type secretPage struct {
-	secrets   []models.Secret
-	nextToken *string
+	helpOffset    int  // Lines the help screen is scrolled down
+	fullHelp      bool // The footer shows every key of the screen rather than one line
+	tutorial      tutorial
+	clockWarning  string    // Set when the local clock is too far from AWS's
+	configWarning string    // Set when the config files have settings that can't be used
+	errorHint     errorHint // Explains errorMessage when it came from AWS
+
+	// Inactivity lock
+	lockAfter       time.Duration // Zero disables the lock
+	lockRequiresMFA bool
+	lastActivity    time.Time
+
+	// watch re-lists the secrets on an interval, highlighting changes
+	watch watchState
+	// jump is the count or f prefix typed on the secret list
+	jump jumpState
+	// lastClick is the secret last clicked, for double clicks
+	lastClick click
+	// split shows the selected secret's details beside the list
+	split splitState
+	// ops is the operations finished this session
+	ops opsLog
+	// undo is the destructive actions done this session that can be undone
+	undo undoStack
+
+	// clipboardTimeout clears copied values from the clipboard after this
+	// long, 0 leaves them
+	clipboardTimeout time.Duration
+	// confirmWithKey confirms deleting or overwriting secrets with y instead
+	// of typing the name
+	confirmWithKey bool
 }
 
 // Custom messages
 type secretsLoadedMsg struct {
+	inventory *inventory.Service
+	page      int
 	secrets   []models.Secret
-	nextToken *string
 	err       error
 }
 
 type secretValueLoadedMsg struct {
-	value string
-	err   error
+	value  string
+	binary []byte
+	err    error
+}
+
+type secretDetailsLoadedMsg struct {
+	name    string
+	details *models.SecretDetails
+	err     error
 }
 
 type clientChangedMsg struct {
-	client  *aws.Client
+	client  SecretStore
 	profile string
 	region  string
 	err     error
 }
 
+type secretSavedMsg struct {
+	path  string
+	bytes int
+	err   error
+}
+
+type secretsExportedMsg struct {
+	path       string
+	count      int
+	report     bool     // Only metadata was written, by exportReport
+	audit      bool     // An access audit was written, by exportAudit
+	conflicts  []string // Keys renamed in a combined .env, see combineDotenv
+	valueFiles int      // Values written beside a compose snippet, see writeComposeSecrets
+	err        error
+}
+
+// noProfilesMessage explains an empty profile list, which is normal on
+// machines that authenticate with environment variables, SSO or instance roles
+const noProfilesMessage = "No AWS profiles configured (no ~/.aws/config or ~/.aws/credentials). Using the default credential chain: environment variables, SSO or instance role. Run `aws configure` to add a profile."
+
 type clearStatusMsg struct{}
 
+// retryMsg reports that an AWS call failed and is about to be retried
+type retryMsg struct {
+	event aws.RetryEvent
+}
+
+// WatchRetries shows AWS retries in p's status bar until the returned
+// function is called
+func WatchRetries(p *tea.Program) func() {
+	return aws.OnRetry(func(event aws.RetryEvent) {
+		p.Send(retryMsg{event: event})
+	})
+}
+
+type clockSkewMsg struct {
+	skew time.Duration
+	err  error
+}
+
 type clipboardCopiedMsg struct {
 	success bool
+	value   string // What was copied, to clear after the clipboard timeout
 	err     error
 }
 
 type mfaRequiredMsg struct {
-	profile       string
-	region        string
-	mfaSerial     string
-	sourceProfile string
+	profile string
+	region  string
 }
 
-type mfaTokenSubmittedMsg struct {
-	creds awssdk.Credentials
-	err   error
+// authProgressMsg reports a step of the auth pipeline. next waits for the
+// step after it.
+type authProgressMsg struct {
+	text string
+	next tea.Cmd
 }
 
 // NewModel creates a new app model
@@ -124,19 +360,170 @@ func NewModel(profile, region string) Model {
 		currentRegion:  region,
 		keys:           DefaultKeyMap(),
 		grid:           components.NewSecretGrid(80, 20),
+		revealTimeout:  defaultRevealTimeout,
+		requestTimeout: defaultRequestTimeout,
+		watch:          watchState{interval: defaultWatchInterval},
+		split:          splitState{width: defaultPanelWidth},
+		staleDays:      config.DefaultStaleAfterDays,
+		profileRegions: map[string]string{},
+		listCacheTTL:   config.DefaultSecretListTTL,
+		spinner:        newLoadingSpinner(),
+		spinning:       true, // Init starts the spinner for the first load
+		lastActivity:   time.Now(),
 		loading:        true,
 	}
 }
 
+// WithConfig applies the UI preferences saved in cfg
+func (m Model) WithConfig(cfg *config.Config) Model {
+	m.grid.SetLayout(components.ParseLayout(cfg.Layout))
+	if cfg.PageSize > 0 && cfg.PageSize <= config.MaxPageSize {
+		m.pageSize = int32(cfg.PageSize)
+	}
+	m.clipboardTimeout = parseClipboardTimeout(cfg.ClipboardTimeout)
+	m.confirmWithKey = cfg.ConfirmWithKey
+	m.revealTimeout = parseRevealTimeout(cfg.RevealTimeout)
+	m.requestTimeout = parseRequestTimeout(cfg.RequestTimeout)
+	m.listCacheTTL = cfg.SecretListTTL()
+	m.lockAfter = parseLockAfter(cfg.LockAfter)
+	m.watch.interval = parseWatchInterval(cfg.WatchInterval)
+	m.split.on = cfg.DetailPanel
+	m.split.width = parsePanelWidth(cfg.DetailPanelWidth)
+	m.lockRequiresMFA = cfg.LockRequiresMFA
+	m.envMapping = cfg.Env
+	m.syncRules = cfg.SyncRules
+	m.staleDays = cfg.StaleDays()
+	m.lintRules = cfg.Lint
+	m.trackValues = cfg.TrackValueChanges
+	m.scrollbackProtection = cfg.ScrollbackProtection
+	m.values.ttl = parseValueCacheTTL(cfg.ValueCacheTTL)
+	m.auditLog = cfg.AuditLogPath()
+	m.passwordManagers = nil
+	for _, name := range cfg.PasswordManagers {
+		// Unknown names are reported by Validate
+		if manager, err := passwords.New(name); err == nil {
+			m.passwordManagers = append(m.passwordManagers, manager)
+		}
+	}
+	m.statePaths = cfg.StatePathList()
+	m.templates = cfg.Templates
+	m.profileRegions = cfg.RememberedRegions()
+	m.workspaces = cfg.Workspaces
+	m.apps = cfg.Apps
+	appSecrets := make(map[string][]string, len(cfg.Apps))
+	for _, app := range cfg.Apps {
+		appSecrets[app.Name] = app.SecretNames()
+	}
+	m.grid.SetApps(appSecrets)
+
+	schemas, schemaErr := secretvalue.CompileSchemas(cfg.SchemaRules())
+	m.schemas = schemas
+
+	aliases, err := parseKeyBindings(cfg.KeyBindings)
+	m.keyAliases = aliases
+	m.configWarning = ""
+	return m.WithConfigWarning(errors.Join(cfg.Validate(), err, schemaErr))
+}
+
+// WithConfigWarning shows err in the header, for settings that couldn't be
+// loaded or used. Nil errors are ignored.
+func (m Model) WithConfigWarning(err error) Model {
+	if err == nil {
+		return m
+	}
+	warning := strings.ReplaceAll(err.Error(), "\n", "; ")
+	if m.configWarning != "" {
+		warning = m.configWarning + "; " + warning
+	}
+	m.configWarning = "Config: " + warning
+	return m
+}
+
+// WithEndpointURL points every client the model creates at endpointURL
+// instead of AWS's Secrets Manager endpoint
+func (m Model) WithEndpointURL(endpointURL string) Model {
+	m.endpointURL = endpointURL
+	return m
+}
+
+// WithDemo runs the model against store instead of AWS, with no
+// authentication and nothing saved to the config file
+func (m Model) WithDemo(store SecretStore) Model {
+	return m.WithBackend(store, demoBackend)
+}
+
+// demoBackend is the backend name of the --demo store
+const demoBackend = "Demo data"
+
+// WithBackend runs the model against store, such as an Azure key vault,
+// instead of AWS. name says which in the header. Nothing is saved to the
+// config file, and the AWS profile and region selectors are unavailable.
+func (m Model) WithBackend(store SecretStore, name string) Model {
+	m.backend = store
+	m.backendName = name
+	return m
+}
+
+// demoData reports whether the model runs against the --demo store
+func (m Model) demoData() bool {
+	return m.backend != nil && m.backendName == demoBackend
+}
+
+// awsBackend reports whether the model runs against AWS, rather than the
+// demo store or another backend
+func (m Model) awsBackend() bool {
+	return m.backend == nil
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		initAWSClient(m.currentProfile, m.currentRegion),
-	)
+	// Init can't keep the cancel func, so the first sign-in only has its
+	// timeout. The secrets load that follows can be cancelled.
+	var history tea.Cmd
+	if m.tracksValues() {
+		history = loadValueViews()
+	}
+	if m.currentScreen == ScreenSetup {
+		// Signing in waits for a profile and region to be picked
+		return tea.Batch(m.spinner.Tick, history)
+	}
+	cmds := []tea.Cmd{m.initAWSClient(m.currentProfile, m.currentRegion), m.spinner.Tick, history}
+	if m.awsBackend() {
+		cmds = append(cmds, checkClockSkew(m.currentRegion))
+	}
+	if m.usesListCache(m.currentProfile) {
+		cmds = append(cmds, loadCachedSecrets(m.currentProfile, m.currentRegion, m.listCacheTTL))
+	}
+	if m.lockAfter > 0 {
+		cmds = append(cmds, scheduleLockCheck(m.lockAfter))
+	}
+	return tea.Batch(cmds...)
 }
 
 // Update handles messages and updates the model
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	logMsg(msg)
+	if !m.tutorial.active {
+		updated, cmd := m.update(msg)
+		model, cmd := updated.(Model).reportDryRun(cmd)
+		model, cmd = model.followSelection(cmd)
+		return model.keepSpinning(cmd)
+	}
+
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		if updated, handled := m.handleTutorialKeys(keyMsg); handled {
+			return updated, nil
+		}
+	}
+
+	updated, cmd := m.update(msg)
+	model, cmd := updated.(Model).reportDryRun(cmd)
+	model, cmd = model.updateTutorial(msg).followSelection(cmd)
+	return model.keepSpinning(cmd)
+}
+
+// update applies msg to the model for the current screen
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
@@ -145,23 +532,51 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		contentWidth, contentHeight := m.contentViewportSize()
 
-		m.grid.SetSize(contentWidth, contentHeight)
-		// Only resize selectors if they're initialized (i.e., we're on their screen)
-		if m.currentScreen == ScreenProfileSelector {
-			m.profileSelector.SetSize(contentWidth, contentHeight)
-		}
-		if m.currentScreen == ScreenRegionSelector {
-			m.regionSelector.SetSize(contentWidth, contentHeight)
+		m.resizeGrid()
+		if m.screen != nil {
+			m.screen = m.screen.SetSize(contentWidth, contentHeight)
 		}
-		if m.currentScreen == ScreenSecretFieldSelector {
-			m.fieldSelector.SetSize(contentWidth, contentHeight)
+		return m, nil
+
+	case spinner.TickMsg:
+		return m.updateSpinner(msg)
+
+	case loadProgressMsg:
+		// Progress from a cancelled load is still read, so the load can finish
+		if m.loading {
+			m.loadingText = msg.text
 		}
+		return m, msg.next
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case tea.ResumeMsg:
+		m.blanked = false
 		return m, nil
 
 	case tea.KeyMsg:
 		// Global keys
-		if msg.String() == "ctrl+c" {
-			return m, tea.Quit
+		switch msg.String() {
+		case "ctrl+c":
+			return m.quit()
+		case "ctrl+z":
+			return m.suspend()
+		}
+		m.lastActivity = time.Now()
+
+		if m.isCancelKey(msg) {
+			return m.cancelInFlight()
+		}
+
+		// Sub-model screens handle their own keys
+		if m.screen != nil {
+			return m.updateScreen(msg)
+		}
+
+		// Extra keys from the config, except while typing a filter
+		if !m.grid.IsFiltering() {
+			msg = m.keyAliases.translate(m.currentScreen, msg)
 		}
 
 		// Handle keys based on current screen
@@ -170,128 +585,567 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleSecretListKeys(msg)
 		case ScreenSecretDetail:
 			return m.handleSecretDetailKeys(msg)
-		case ScreenSecretFieldSelector:
-			return m.handleSecretFieldSelectorKeys(msg)
-		case ScreenProfileSelector:
-			return m.handleProfileSelectorKeys(msg)
-		case ScreenRegionSelector:
-			return m.handleRegionSelectorKeys(msg)
-		case ScreenMFAInput:
-			return m.handleMFAInputKeys(msg)
 		}
 
-	case mfaRequiredMsg:
-		// MFA is required, show input screen
+	case closeScreenMsg:
+		switch m.currentScreen {
+		case ScreenBulkResults:
+			return m.closeBulkResults()
+		case ScreenCopyConfirm:
+			m.copyTo = copyDestination{}
+		case ScreenComparePick, ScreenCompare:
+			m.compare = compareState{}
+		case ScreenImportPick, ScreenImportName:
+			m.importing = passwordImport{}
+		case ScreenTemplatePick, ScreenTemplateName, ScreenTemplateField, ScreenTemplateConfirm:
+			m.creating = templateDraft{}
+		case ScreenManifestPath, ScreenManifestConfirm:
+			m.manifest = manifestImport{}
+		case ScreenSyncProfile, ScreenSyncRegion, ScreenSyncName, ScreenSyncPreview, ScreenSyncConfirm:
+			return m.cancelSync()
+		case ScreenKMSKeyPicker, ScreenKMSKeyConfirm:
+			m.kmsChange = kmsChange{}
+		case ScreenAudit:
+			m.audit = auditState{}
+		case ScreenAuditExport:
+			// Back to the audit the export was started from
+			m.openAudit()
+			return m, nil
+		case ScreenSetup:
+			// Skipped: try the profile and region already resolved
+			return m.chooseStartTarget(startTargetChosenMsg{})
+		case ScreenSaveConfirm:
+			// Back to the path prompt, so another file can be picked
+			path := m.savePath
+			m.savePath = ""
+			if secret := m.grid.SelectedSecret(); secret != nil {
+				m.openScreen(ScreenSaveSecret, newPathScreen("Save "+secret.Name, path), ScreenSecretDetail)
+				return m, nil
+			}
+		}
+		m.closeScreen()
+		return m, nil
+
+	case startTargetChosenMsg:
+		return m.chooseStartTarget(msg)
+
+	case backendOpenedMsg:
+		return m.switchBackend(msg)
+
+	case profileSelectedMsg:
+		switch m.currentScreen {
+		case ScreenCopyProfile:
+			return m.chooseCopyProfile(msg.profile)
+		case ScreenCompareProfile:
+			return m.chooseCompareProfile(msg.profile)
+		case ScreenSyncProfile:
+			return m.chooseSyncProfile(msg.profile)
+		}
+		m.closeScreen()
+		if msg.profile != "" && msg.profile != m.currentProfile {
+			// Profile changed, reinitialize client in the region last used
+			// with it, or stay in this one
+			region := m.currentRegion
+			if remembered := m.profileRegions[msg.profile]; remembered != "" {
+				region = remembered
+			}
+			return m, m.initAWSClient(msg.profile, region)
+		}
+		return m, nil
+
+	case regionSelectedMsg:
+		switch m.currentScreen {
+		case ScreenCopyRegion:
+			return m.chooseCopyRegion(msg.region)
+		case ScreenCompareRegion:
+			return m.chooseCompareRegion(msg.region)
+		case ScreenSyncRegion:
+			return m.chooseSyncRegion(msg.region)
+		}
+		m.closeScreen()
+		if msg.region != "" && msg.region != m.currentRegion {
+			// Region changed, reinitialize client
+			return m, m.initAWSClient(m.currentProfile, msg.region)
+		}
+		return m, nil
+
+	case fieldChosenMsg:
+		m.closeScreen()
+		return m, copyToClipboard(msg.value, false)
+
+	case actionChosenMsg:
+		switch m.currentScreen {
+		case ScreenBulkActions:
+			m.closeScreen()
+			return m.chooseBulkAction(msg.key)
+		case ScreenExportFormat:
+			m.closeScreen()
+			return m.chooseExportFormat(msg.key)
+		case ScreenWorkspaces:
+			m.closeScreen()
+			return m.chooseWorkspace(msg.key)
+		case ScreenUndo:
+			m.closeScreen()
+			return m.chooseUndo(msg.key)
+		case ScreenBackends:
+			m.closeScreen()
+			return m.chooseBackend(msg.key)
+		}
+		// Run the action through its shortcut so both paths behave the same
+		m.closeScreen()
+		return m.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(msg.key)})
+
+	case pathEnteredMsg:
+		switch m.currentScreen {
+		case ScreenSaveSecret:
+			return m, saveSecretFile(msg.path, m.loadedData(), false)
+		case ScreenExportSecrets:
+			return m, exportSecrets(m.startLoad(fmt.Sprintf("Exporting %d secrets…", len(m.exportTargets))), m.awsClient, m.exportTargets, msg.path, m.exportFormat, m.envMapping)
+		case ScreenExportReport:
+			return m, exportReport(m.startLoad("Listing every secret for the report…"), m.inventory, msg.path)
+		case ScreenAuditExport:
+			return m, exportAudit(m.audit, msg.path)
+		case ScreenManifestPath:
+			return m.planManifest(msg.path)
+		}
+		return m, nil
+
+	case textEnteredMsg:
+		switch m.currentScreen {
+		case ScreenKubeManifest:
+			return m.copyKubeManifest(msg.value)
+		case ScreenWorkspaceName:
+			return m.saveWorkspace(msg.value)
+		case ScreenBackendVault:
+			return m.openVaultBackend(msg.value)
+		case ScreenImportName:
+			return m.importPasswordItem(msg.value)
+		case ScreenSyncName:
+			return m.previewSyncTo(msg.value)
+		case ScreenTemplateName:
+			return m.nameTemplateSecret(msg.value)
+		case ScreenTemplateField:
+			return m.fillTemplateField(msg.value)
+		}
+		return m.enterBulkTag(msg.value)
+
+	case confirmedMsg:
+		switch m.currentScreen {
+		case ScreenCopyConfirm:
+			m.closeScreen()
+			return m.copyOpenSecret(true)
+		case ScreenKMSKeyConfirm:
+			m.closeScreen()
+			return m.confirmKMSKey()
+		case ScreenSyncPreview:
+			return m.confirmSync()
+		case ScreenSyncConfirm:
+			m.closeScreen()
+			return m.writeSyncedSecret(true)
+		case ScreenTemplateConfirm:
+			m.closeScreen()
+			return m.writeTemplateSecret()
+		case ScreenManifestConfirm:
+			m.closeScreen()
+			return m.writeManifest()
+		case ScreenSaveConfirm:
+			m.closeScreen()
+			path := m.savePath
+			m.savePath = ""
+			return m, saveSecretFile(path, m.loadedData(), true)
+		}
+		m.closeScreen()
+		ctx := m.startLoad(m.bulkOp.progress(0))
+		return m, runBulk(ctx, m.awsClient, m.bulkOp)
+
+	case secretPickedMsg:
+		picker := m.currentScreen
+		m.closeScreen()
+		if picker == ScreenKMSKeyPicker {
+			return m.chooseKMSKey(msg.name)
+		}
+		if picker == ScreenImportPick {
+			return m.choosePasswordItem(msg.name)
+		}
+		if picker == ScreenTemplatePick {
+			return m.chooseTemplate(msg.name)
+		}
+		if picker == ScreenApps {
+			return m.openAppSecret(msg.name)
+		}
+		return m.compareCounterpart(msg.name)
+
+	case kmsKeysListedMsg:
+		return m.pickKMSKey(msg)
+
+	case kmsKeyChangedMsg:
+		return m.showKMSKeyChanged(msg)
+
+	case auditLoadedMsg:
+		return m.showAudit(msg)
+
+	case exportAuditMsg:
+		return m.promptAuditExport(msg.staleDays)
+
+	case lintLoadedMsg:
+		return m.showLint(msg)
+
+	case pickCounterpartMsg:
+		m.closeScreen()
+		m.pickCounterpart()
+		return m, nil
+
+	case compareListedMsg:
+		return m.compareListed(msg)
+
+	case passwordItemsMsg:
+		return m.pickPasswordItem(msg)
+
+	case passwordFieldsMsg:
+		return m.promptImportName(msg)
+
+	case syncPreviewMsg:
+		return m.showSyncPreview(msg)
+
+	case secretSyncedMsg:
+		return m.showSecretSynced(msg)
+
+	case secretReferencesMsg:
+		return m.showSecretReferences(msg)
+
+	case secretAccessMsg:
+		return m.showSecretAccess(msg)
+
+	case passwordImportedMsg:
+		return m.showPasswordImported(msg)
+
+	case templateCreatedMsg:
+		return m.showTemplateCreated(msg)
+
+	case manifestPlannedMsg:
+		return m.confirmManifest(msg)
+
+	case manifestImportedMsg:
+		return m.showManifestImported(msg)
+
+	case compareLoadedMsg:
+		return m.showComparison(msg)
+
+	case secondMFARequiredMsg:
 		m.pendingMFAProfile = msg.profile
 		m.pendingMFARegion = msg.region
-		m.mfaSerial = msg.mfaSerial
-		m.pendingMFASourceProfile = msg.sourceProfile
-		m.mfaInput = components.NewMFAInput()
-		m.currentScreen = ScreenMFAInput
+		m.openScreen(ScreenSecondMFA, newMFAScreen(), ScreenSecretDetail)
 		m.loading = false
 		return m, nil
 
-	case mfaTokenSubmittedMsg:
-		if msg.err != nil {
-			m.errorMessage = fmt.Sprintf("MFA authentication failed: %v", msg.err)
-			m.loading = false
-			// Stay on MFA screen so user can try again
-			m.mfaInput.Reset()
+	case secondAuthMsg:
+		return m.secondAuthenticated(msg)
+
+	case copyTargetCheckedMsg:
+		return m.confirmCopyTarget(msg)
+
+	case secretCopiedMsg:
+		return m.showSecretCopied(msg)
+
+	case bulkDoneMsg:
+		// Shown even when cancelled, as some secrets may have changed already
+		return m.showBulkResults(msg)
+
+	case undoneMsg:
+		return m.showUndone(msg)
+
+	case mfaCancelledMsg:
+		m.closeScreen()
+		m.reauth.retries = nil
+		m.errorMessage = "MFA authentication cancelled"
+		return m, nil
+
+	case mfaCodeEnteredMsg:
+		if len(msg.code) != 6 {
+			m.errorMessage = "MFA code must be 6 digits"
 			return m, nil
 		}
-		// MFA successful, cache the credentials
-		profileForCache := m.pendingMFAProfile
-		if m.pendingMFASourceProfile != "" {
-			profileForCache = m.pendingMFASourceProfile
+		m.errorMessage = ""
+		if m.currentScreen == ScreenSecondMFA {
+			return m.enterSecondMFACode(msg.code)
 		}
+		return m, authenticate(m.startLoad("Signing in…"), auth.Request{
+			Profile:     m.pendingMFAProfile,
+			Region:      m.pendingMFARegion,
+			EndpointURL: m.endpointURL,
+			MFACode:     msg.code,
+		})
 
-		go func() {
-			cachedCreds := config.CachedCredentials{
-				AccessKeyID:     msg.creds.AccessKeyID,
-				SecretAccessKey: msg.creds.SecretAccessKey,
-				SessionToken:    msg.creds.SessionToken,
-				ExpiresAt:       msg.creds.Expires,
-			}
-			_ = config.SaveCachedCredentials(profileForCache, cachedCreds) // Ignore errors
-		}()
+	case unlockRequestedMsg:
+		return m.unlock()
 
-		// Create client with credentials
-		m.currentScreen = ScreenSecretList
-		m.loading = true
-		return m, createClientWithMFACredentials(m.pendingMFAProfile, m.pendingMFARegion, msg.creds, m.pendingMFASourceProfile)
+	case mfaRequiredMsg:
+		// MFA is required, show input screen
+		m.pendingMFAProfile = msg.profile
+		m.pendingMFARegion = msg.region
+		returnTo := ScreenSecretList
+		if len(m.reauth.retries) > 0 && m.screen == nil {
+			// Signing in again, so go back to the secret that was open
+			returnTo = m.currentScreen
+		}
+		m.openScreen(ScreenMFAInput, newMFAScreen(), returnTo)
+		m.loading = false
+		return m, nil
+
+	case ssoLoginMsg:
+		return m.finishSSOLogin(msg)
+
+	case authProgressMsg:
+		m.statusMessage = msg.text
+		return m, msg.next
 
 	case clientChangedMsg:
+		if cancelled(msg.err) {
+			m.reauth.retries = nil
+			return m, nil
+		}
+		m.statusMessage = ""
 		if msg.err != nil {
-			m.errorMessage = fmt.Sprintf("Failed to initialize AWS client: %v", msg.err)
 			m.loading = false
+			m.reauth.retries = nil
+			if m.currentScreen == ScreenMFAInput {
+				if auth.FailedAt(msg.err, auth.StepMFA) {
+					m.showErrorFor(msg.profile, msg.region, "MFA authentication failed", msg.err)
+					// Stay on MFA screen so user can try again
+					return m.updateScreen(promptErrorMsg{err: msg.err})
+				}
+				m.closeScreen()
+			}
+			m.showErrorFor(msg.profile, msg.region, "Failed to initialize AWS client", msg.err)
 			return m, nil
 		}
+		if m.currentScreen == ScreenMFAInput {
+			m.closeScreen()
+		}
+		if len(m.reauth.retries) > 0 && msg.profile == m.currentProfile && msg.region == m.currentRegion {
+			return m.retryAfterReauth(msg.client)
+		}
 		m.awsClient = msg.client
 		m.currentProfile = msg.profile
 		m.currentRegion = msg.region
-		m.loading = true
+		m.identity = nil
+		m.regions = nil
+		m.grid.ClearMarks() // Marks refer to secrets in the previous account/region
+		m.values.purge()
 
 		// Save profile and region to config for next time
-		go func() {
-			cfg := &config.Config{
-				LastProfile: msg.profile,
-				LastRegion:  msg.region,
+		if m.awsBackend() {
+			if msg.region != "" {
+				m.profileRegions[msg.profile] = msg.region
 			}
-			_ = config.Save(cfg) // Ignore errors, don't block UI
-		}()
+			updateConfig(func(cfg *config.Config) {
+				cfg.RememberRegion(msg.profile, msg.region)
+			})
+		}
+
+		m.inventory = inventory.New(m.awsClient, m.pageSize)
+		m.currentPage = 0
+		return m, tea.Batch(
+			loadSecrets(m.startLoad("Loading secrets…"), m.inventory, 0),
+			loadIdentity(m.awsClient, m.requestTimeout),
+		)
+
+	case identityLoadedMsg:
+		return m.showIdentity(msg)
 
-		return m, loadSecrets(m.awsClient, 50, nil)
+	case regionsLoadedMsg:
+		return m.showRegions(msg)
 
 	case secretsLoadedMsg:
+		if msg.inventory != m.inventory {
+			// Loaded for a profile or region that has since changed
+			return m, nil
+		}
+		if cancelled(msg.err) {
+			return m, nil
+		}
 		m.loading = false
 		if msg.err != nil {
-			m.errorMessage = fmt.Sprintf("Failed to load secrets: %v", msg.err)
+			if updated, cmd, ok := m.reauthenticate(msg.err, retryLoadSecrets(msg.page)); ok {
+				return updated, cmd
+			}
+			m.showError("Failed to load secrets", msg.err)
 			return m, nil
 		}
-		m.secrets = msg.secrets
-		m.nextToken = msg.nextToken
-		m.hasMore = msg.nextToken != nil
-		m.grid.SetSecrets(m.secrets)
+		m.currentPage = msg.page
+		m.allPages = false
+		m.showSecrets(msg.secrets)
+		m.cachedAt = time.Time{}
 		m.errorMessage = ""
-
-		// Update page history for the current page
-		if m.currentPage < len(m.pageHistory) {
-			// Updating existing page
-			m.pageHistory[m.currentPage] = secretPage{
-				secrets:   msg.secrets,
-				nextToken: msg.nextToken,
-			}
-		} else {
-			// New page, add to history
-			m.pageHistory = append(m.pageHistory, secretPage{
-				secrets:   msg.secrets,
-				nextToken: msg.nextToken,
-			})
+		highlighted := tea.Batch(m.highlightChanges(msg.page, msg.secrets), m.followDeepLink())
+		if m.usesListCache(m.currentProfile) {
+			return m, tea.Batch(highlighted, saveCachedSecrets(m.currentProfile, m.currentRegion, m.inventory.Loaded(), m.listCacheTTL))
 		}
+		return m, highlighted
+
+	case consoleOpenedMsg:
+		return m.showConsoleOpened(msg)
+
+	case allSecretsLoadedMsg:
+		return m.showAllLoaded(msg)
+
+	case linkedSecretMsg:
+		return m.openLinkedSecret(msg)
+
+	case cachedSecretsMsg:
+		return m.showCachedSecrets(msg), nil
+
+	case valueCacheExpiredMsg:
+		m.values.expire(time.Now())
+		return m, nil
 
+	case valueViewsLoadedMsg:
+		m.showValueViews(msg.views)
 		return m, nil
 
+	case valueViewRecordedMsg:
+		return m.showValueViewRecorded(msg)
+
 	case secretValueLoadedMsg:
+		if cancelled(msg.err) {
+			return m, nil
+		}
 		m.loading = false
+		if m.currentScreen == ScreenLocked {
+			// The UI locked while the value was loading, so drop it
+			return m, nil
+		}
 		if msg.err != nil {
-			m.errorMessage = fmt.Sprintf("Failed to load secret value: %v", msg.err)
+			if updated, cmd, ok := m.reauthenticate(msg.err, retryLoadValue); ok {
+				return updated, cmd
+			}
+			m.showError("Failed to load secret value", msg.err)
 			return m, nil
 		}
-		m.secretValue = msg.value
-		m.secretFields = parseSecretFields(msg.value)
-		m.errorMessage = ""
+		value := models.SecretValue{String: msg.value, Binary: msg.binary}
+		m.showValue(value)
+		m.recordAudit("Read the value of "+m.selectedName(), nil)
+		return m, tea.Batch(m.recordOpenValue(value), m.values.put(m.selectedName(), value, time.Now()))
+
+	case secretsExportedMsg:
+		if cancelled(msg.err) {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			m.logOperation("Failed to write "+msg.path, msg.err)
+			return m.updateScreen(promptErrorMsg{err: msg.err})
+		}
+		m.closeScreen()
+		m.statusMessage = fmt.Sprintf("Exported %d secrets to %s", msg.count, msg.path) + conflictNote(msg.conflicts)
+		if msg.report {
+			m.statusMessage = fmt.Sprintf("Wrote a report of %d secrets to %s", msg.count, msg.path)
+		}
+		if msg.audit {
+			m.statusMessage = fmt.Sprintf("Wrote an audit of %d secrets to %s", msg.count, msg.path)
+			m.openAudit()
+		}
+		if msg.valueFiles > 0 {
+			m.statusMessage = fmt.Sprintf("Wrote compose secrets for %d secrets to %s, with %d values in %s/", msg.count, msg.path, msg.valueFiles, composeSecretsDir) + conflictNote(msg.conflicts)
+		}
+		m.logOperation(m.statusMessage, nil)
+		return m, clearStatusAfter(3 * time.Second)
+
+	case secretSavedMsg:
+		if errors.Is(msg.err, errFileExists) && m.currentScreen == ScreenSaveSecret {
+			m.savePath = msg.path
+			intro := "This file already exists. It will be replaced by the secret value, with owner-only permissions:"
+			items := []components.SummaryItem{{Name: msg.path}}
+			m.openScreen(ScreenSaveConfirm, newConfirmScreen("Overwrite file?", intro, items), ScreenSecretDetail)
+			return m, nil
+		}
+		if msg.err != nil {
+			m.logOperation(fmt.Sprintf("Failed to save %s to %s", m.selectedName(), msg.path), msg.err)
+			if m.currentScreen != ScreenSaveSecret {
+				// Replacing a file is confirmed after the prompt has closed
+				m.errorMessage = fmt.Sprintf("Failed to save secret: %v", msg.err)
+				return m, nil
+			}
+			return m.updateScreen(promptErrorMsg{err: msg.err})
+		}
+		m.closeScreen()
+		m.statusMessage = fmt.Sprintf("Saved %d bytes to %s", msg.bytes, msg.path)
+		m.logOperation(fmt.Sprintf("Saved %s (%d bytes) to %s", m.selectedName(), msg.bytes, msg.path), nil)
+		return m, clearStatusAfter(3 * time.Second)
+
+	case secretDetailsLoadedMsg:
+		// Ignore results for a secret that is no longer open
+		secret := m.grid.SelectedSecret()
+		if (m.currentScreen == ScreenSecretList && !m.splitActive()) || secret == nil || secret.Name != msg.name {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.detailsError = msg.err.Error()
+			return m, nil
+		}
+		m.secretDetails = msg.details
 		return m, nil
 
 	case clearStatusMsg:
 		m.statusMessage = ""
 		return m, nil
 
+	case retryMsg:
+		m.statusMessage = describeRetry(msg.event)
+		return m, clearStatusAfter(msg.event.Delay + time.Second)
+
+	case lockCheckMsg:
+		return m.checkLock()
+
+	case watchTickMsg:
+		return m.watchTick(msg)
+
+	case panelFollowMsg:
+		return m.loadPanelDetails(msg)
+
+	case clockSkewMsg:
+		// The check is best effort, so a failure to reach STS is not reported
+		if msg.err == nil {
+			m.clockWarning = aws.ClockSkewMessage(msg.skew)
+			m.resizeGrid()
+		}
+		return m, nil
+
+	case pendingGTimeoutMsg:
+		return m.pendingGTimeout(msg)
+
+	case remaskMsg:
+		if msg.seq == m.revealSeq {
+			m.valueRevealed = false
+			m.revealedFields = nil
+		}
+		return m, nil
+
+	case envCopiedMsg:
+		if cancelled(msg.err) {
+			return m, nil
+		}
+		m.loading = false
+		if msg.err != nil {
+			if updated, cmd, ok := m.reauthenticate(msg.err, retryCopyEnv); ok {
+				return updated, cmd
+			}
+			m.showError("Failed to copy .env", msg.err)
+			m.logOperation("Failed to copy .env", msg.err)
+			return m, nil
+		}
+		m.statusMessage = fmt.Sprintf("Copied %d variables from %d secrets as .env", msg.vars, msg.secrets) + conflictNote(msg.conflicts)
+		m.logOperation(m.statusMessage, nil)
+		return m, tea.Batch(clearStatusAfter(4*time.Second), clearClipboardAfter(m.clipboardTimeout, msg.dotenv))
+
 	case clipboardCopiedMsg:
 		if msg.err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to copy to clipboard: %v", msg.err)
+			m.logOperation("Failed to copy "+m.selectedName()+" to the clipboard", msg.err)
 		} else if msg.success {
 			m.statusMessage = "Copied to clipboard!"
-			return m, clearStatusAfter(2 * time.Second)
+			m.logOperation("Copied "+m.selectedName()+" to the clipboard", nil)
+			return m, tea.Batch(clearStatusAfter(2*time.Second), clearClipboardAfter(m.clipboardTimeout, msg.value))
 		}
 		return m, nil
 	}
@@ -305,10 +1159,18 @@ func (m Model) handleSecretListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		cmd := m.grid.Update(msg)
 		return m, cmd
 	}
+	if m.showHelp {
+		return m.handleHelpKeys(msg)
+	}
+	updated, cmd, ok := m.handleJumpKeys(msg)
+	if ok {
+		return updated, cmd
+	}
+	m = updated
 
 	switch msg.String() {
 	case "q", "esc":
-		return m, tea.Quit
+		return m.quit()
 
 	case "enter":
 		// View secret details
@@ -316,75 +1178,217 @@ func (m Model) handleSecretListKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if secret != nil {
 			m.currentScreen = ScreenSecretDetail
 			m.clearSecretValueState()
+			m.clearSecretDetails()
+			return m, loadSecretDetails(m.awsClient, secret.Name, m.requestTimeout)
 		}
 		return m, nil
 
 	case "r":
-		// Refresh secrets - clear pagination history
-		m.loading = true
-		m.nextToken = nil
-		m.pageHistory = nil
-		m.currentPage = 0
-		return m, loadSecrets(m.awsClient, 50, nil)
+		// Refresh secrets - drop the cached pages
+		return m.refreshSecrets("Refreshing secrets…")
+
+	case "I":
+		// Import a password manager item as a new secret
+		return m.startPasswordImport()
+
+	case "N":
+		// Create a secret from one of the config's templates
+		return m.startTemplateCreate()
+
+	case "O":
+		// Create or update the secrets of a manifest file
+		return m.startManifestImport()
+
+	case "D":
+		// Rehearse changes: report writes instead of making them
+		return m.toggleDryRun()
+
+	case "w":
+		// Toggle watch mode, re-listing the secrets on an interval
+		return m.toggleWatch()
+
+	case "F":
+		// Flag the selected secret to notify about when it rotates
+		return m.toggleRotationFlag()
+
+	case "|":
+		// Show or hide the detail panel beside the list
+		return m.toggleSplit()
+
+	case "o":
+		// Show or hide the operations finished this session
+		m.toggleOperations()
+		return m, nil
+
+	case "X":
+		return m.purgeValues()
+
+	case "u":
+		// List the destructive actions that can be undone
+		return m.openUndo()
+
+	case "<", ">":
+		// Widen or narrow the detail panel
+		if msg.String() == "<" {
+			return m.resizeSplit(panelWidthStep)
+		}
+		return m.resizeSplit(-panelWidthStep)
 
 	case "n":
 		// Load next page
-		if m.hasMore {
+		if !m.hasNextPage() {
+			return m, nil
+		}
+		if page, ok := m.inventory.Cached(m.currentPage + 1); ok {
 			m.currentPage++
-			// Check if we already have this page in history
-			if m.currentPage < len(m.pageHistory) {
-				// Load from history
-				page := m.pageHistory[m.currentPage]
-				m.secrets = page.secrets
-				m.nextToken = page.nextToken
-				m.hasMore = page.nextToken != nil
-				m.grid.SetSecrets(m.secrets)
-				return m, nil
-			}
-			// Need to fetch new page
-			m.loading = true
-			return m, loadSecrets(m.awsClient, 50, m.nextToken)
+			m.showSecrets(page.Secrets)
+			return m, nil
 		}
-		return m, nil
+		text := fmt.Sprintf("Loading page %d (%d secrets loaded)…", m.currentPage+2, len(m.inventory.Loaded()))
+		return m, loadSecrets(m.startLoad(text), m.inventory, m.currentPage+1)
+
+	case "a":
+		// Load every page into one list
+		return m.loadAll()
 
 	case "b":
 		// Go to previous page
-		if m.currentPage > 0 {
+		if m.currentPage == 0 || m.allPages || m.inventory == nil {
+			return m, nil
+		}
+		if page, ok := m.inventory.Cached(m.currentPage - 1); ok {
 			m.currentPage--
-			page := m.pageHistory[m.currentPage]
-			m.secrets = page.secrets
-			m.nextToken = page.nextToken
-			m.hasMore = page.nextToken != nil || m.currentPage < len(m.pageHistory)-1
-			m.grid.SetSecrets(m.secrets)
+			m.showSecrets(page.Secrets)
 		}
 		return m, nil
 
 	case "?":
-		m.showHelp = !m.showHelp
+		// Expand or collapse the key hints in the footer
+		m.toggleFooterHelp()
+		return m, nil
+
+	case "H":
+		// Show every key binding
+		m.showHelp = true
+		m.helpOffset = 0
+		return m, nil
+
+	case "L":
+		// Toggle between grid and list layouts
+		layout := components.LayoutList
+		if m.grid.Layout() == components.LayoutList {
+			layout = components.LayoutGrid
+		}
+		m.grid.SetLayout(layout)
+		updateConfig(func(cfg *config.Config) {
+			cfg.Layout = layout.String()
+		})
+		if m.grid.Compact() {
+			// The terminal is too narrow to see the difference yet
+			m.statusMessage = fmt.Sprintf("Layout: %s, shown once the terminal is %d columns wide", layout, narrowWidth)
+			return m, clearStatusAfter(3 * time.Second)
+		}
+		return m, nil
+
+	case "s":
+		// Cycle through sort orders
+		m.grid.SetSortOrder(m.grid.SortOrder().Next())
+		return m, nil
+
+	case "m":
+		// Mark or unmark the selected secret for export
+		m.grid.ToggleMark()
+		return m, nil
+
+	case "M":
+		m.grid.ClearMarks()
+		return m, nil
+
+	case "e":
+		// Export the marked secrets, or the selected one if none are marked
+		if names := m.exportNames(); len(names) > 0 {
+			m.startExport(names, ScreenSecretList)
+		}
+		return m, nil
+
+	case "E":
+		// Copy the marked secrets, or the selected one, as one combined .env
+		if names := m.exportNames(); len(names) > 0 {
+			ctx := m.startLoad(fmt.Sprintf("Fetching %d secrets…", len(names)))
+			return m, copyCombinedEnv(ctx, m.awsClient, names, m.envMapping)
+		}
+		return m, nil
+
+	case "B":
+		// Run a bulk operation on the marked secrets, or the selected one
+		if names := m.exportNames(); len(names) > 0 {
+			m.bulkOp = bulkOperation{names: names}
+			m.openScreen(ScreenBulkActions, newActionScreen(fmt.Sprintf("Bulk actions for %d secrets", len(names)), bulkActions(len(names))), ScreenSecretList)
+		}
 		return m, nil
 
+	case "A":
+		// Audit when every secret in the account was last accessed
+		return m.startAudit()
+
+	case "C":
+		// Run the compliance checks over every secret in the account
+		return m.startLint()
+
+	case "t":
+		// List the secrets grouped by the app using them
+		return m.openApps()
+
+	case "W":
+		// Switch to a saved workspace, or save the current one
+		return m.openWorkspaces()
+
+	case "V":
+		// Switch to another backend, such as an Azure key vault
+		return m.openBackends()
+
+	case "R":
+		// Export a metadata report of every secret in the account
+		if m.inventory != nil {
+			m.openScreen(ScreenExportReport, newPathScreen("Export a report of every secret as CSV or JSON (by extension)", "secrets-report.csv"), ScreenSecretList)
+		}
+		return m, nil
+
+	case "g":
+		// Reached once a lone g has waited for a second one
+		return m.switchRegion()
+
 	case "p":
+		if !m.awsBackend() && !m.demoData() {
+			m.statusMessage = m.backendName + " has no AWS profiles or regions to switch to"
+			return m, clearStatusAfter(3 * time.Second)
+		}
+
 		// Open profile selector
 		profiles, err := aws.GetAvailableProfiles()
 		if err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to load profiles: %v", err)
 			return m, nil
 		}
-		m.profileSelector = components.NewProfileSelector(profiles, m.currentProfile, m.width, m.height-6)
-		m.currentScreen = ScreenProfileSelector
-		return m, nil
-
-	case "g":
-		// Open region selector
-		regions := aws.GetCommonRegions()
-		m.regionSelector = components.NewRegionSelector(regions, m.currentRegion, m.width, m.height-6)
-		m.currentScreen = ScreenRegionSelector
+		if len(profiles) == 0 {
+			m.statusMessage = noProfilesMessage
+			return m, clearStatusAfter(8 * time.Second)
+		}
+		m.openScreen(ScreenProfileSelector, newProfileScreen(m.summarizeProfiles(profiles), m.currentProfile), ScreenSecretList)
 		return m, nil
 	}
 
 	// Let the grid handle navigation and filter keys
-	cmd := m.grid.Update(msg)
-	return m, cmd
+	return m, m.grid.Update(msg)
+}
+
+// switchRegion opens the region selector, for backends that have regions
+func (m Model) switchRegion() (tea.Model, tea.Cmd) {
+	if !m.awsBackend() && !m.demoData() {
+		m.statusMessage = m.backendName + " has no AWS profiles or regions to switch to"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	return m.startRegionSelector()
 }
 
 // handleSecretDetailKeys handles key presses on the secret detail screen
@@ -394,24 +1398,110 @@ func (m Model) handleSecretDetailKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Go back to list
 		m.currentScreen = ScreenSecretList
 		m.clearSecretValueState()
+		m.clearSecretDetails()
+		return m, nil
+
+	case "o":
+		// Show or hide the operations finished this session
+		m.toggleOperations()
+		return m, nil
+
+	case "X":
+		return m.purgeValues()
+
+	case "?":
+		// Expand or collapse the key hints in the footer
+		m.toggleFooterHelp()
 		return m, nil
 
 	case "v":
 		// View secret value
-		secret := m.grid.SelectedSecret()
-		if secret != nil && m.secretValue == "" {
-			m.loading = true
-			return m, loadSecretValue(m.awsClient, secret.Name)
-		}
-		return m, nil
+		return m.viewValue()
 
 	case "c":
-		// Copy plain text
+		// Copy plain text, or base64 for binary secrets
+		if m.secretBinary != nil {
+			return m, copyToClipboard(formatBinary(m.secretBinary, binaryFormatBase64), false)
+		}
 		if m.secretValue != "" {
 			return m, copyToClipboard(m.secretValue, false)
 		}
 		return m, nil
 
+	case "r":
+		// Reveal or hide the loaded value
+		if m.secretLoaded() {
+			return m.toggleReveal()
+		}
+		return m, nil
+
+	case "up", "down":
+		// Move between the keys of a masked JSON value
+		if len(m.secretFields) > 0 && !m.valueRevealed {
+			if msg.String() == "up" && m.fieldCursor > 0 {
+				m.fieldCursor--
+			} else if msg.String() == "down" && m.fieldCursor < len(m.secretFields)-1 {
+				m.fieldCursor++
+			}
+		}
+		return m, nil
+
+	case "enter":
+		// Reveal or hide the selected key of a masked JSON value
+		if len(m.secretFields) > 0 && !m.valueRevealed {
+			return m.toggleFieldReveal()
+		}
+		return m, nil
+
+	case "y":
+		// Copy the selected key's value
+		if len(m.secretFields) > 0 && !m.valueRevealed && m.fieldCursor < len(m.secretFields) {
+			return m, copyToClipboard(m.secretFields[m.fieldCursor].CopyValue, false)
+		}
+		return m, nil
+
+	case "a":
+		// Open the actions menu
+		m.openScreen(ScreenSecretActions, newActionScreen("Actions", m.detailActions()), ScreenSecretDetail)
+		return m, nil
+
+	case "e":
+		// Export this secret as a document or for Docker
+		if secret := m.grid.SelectedSecret(); secret != nil {
+			m.startExport([]string{secret.Name}, ScreenSecretDetail)
+		}
+		return m, nil
+
+	case "u":
+		// Copy the AWS console link
+		if secret := m.grid.SelectedSecret(); secret != nil {
+			return m, copyToClipboard(consoleURL(secret.ARN, m.currentRegion, secret.Name), false)
+		}
+		return m, nil
+
+	case "O":
+		// Open the secret's page in the AWS console
+		return m.openInConsole()
+
+	case "x":
+		// Switch the binary view between base64 and hex
+		if m.secretBinary != nil {
+			if m.binaryFormat == binaryFormatBase64 {
+				m.binaryFormat = binaryFormatHex
+			} else {
+				m.binaryFormat = binaryFormatBase64
+			}
+		}
+		return m, nil
+
+	case "s":
+		// Save the loaded value to a file
+		secret := m.grid.SelectedSecret()
+		if secret != nil && m.secretLoaded() {
+			m.openScreen(ScreenSaveSecret, newPathScreen("Save "+secret.Name, defaultSavePath(secret.Name)), ScreenSecretDetail)
+		}
+		return m, nil
+
 	case "j":
 		// Copy JSON formatted
 		if m.secretValue != "" {
@@ -422,216 +1512,327 @@ func (m Model) handleSecretDetailKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "k":
 		// Copy a top-level JSON field value
 		if len(m.secretFields) > 0 {
-			contentWidth, contentHeight := m.contentViewportSize()
-			m.fieldSelector = components.NewSecretFieldSelector(m.secretFields, contentWidth, contentHeight)
-			m.currentScreen = ScreenSecretFieldSelector
+			m.openScreen(ScreenSecretFieldSelector, newFieldScreen(m.secretFields), ScreenSecretDetail)
 		}
 		return m, nil
-	}
 
-	return m, nil
-}
+	case "C":
+		// Copy the secret to another region
+		return m.startCopyToRegion()
 
-// handleSecretFieldSelectorKeys handles key presses on the field selector screen.
-func (m Model) handleSecretFieldSelectorKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "esc":
-		m.currentScreen = ScreenSecretDetail
-		return m, nil
+	case "P":
+		// Copy the secret to another profile's account
+		return m.startCopyToProfile()
 
-	case "enter":
-		field := m.fieldSelector.SelectedField()
-		m.currentScreen = ScreenSecretDetail
-		if field != nil {
-			return m, copyToClipboard(field.CopyValue, false)
+	case "D":
+		// Compare the secret with its counterpart in another environment
+		return m.startCompare()
+
+	case "M":
+		// Compare the secret with the Kubernetes Secret in the cluster
+		return m.startCompareCluster()
+
+	case "S":
+		// Sync the secret, from any backend, to a secret in AWS
+		return m.startSync()
+
+	case "U":
+		// List the ECS task definitions and Lambda functions reading the secret
+		return m.startFindReferences()
+
+	case "W":
+		// List who IAM policies let read the secret, and the infrastructure
+		// code that mentions it
+		return m.startFindAccess()
+
+	case "K":
+		// Copy the loaded value as a Kubernetes Secret manifest
+		return m.startKubeManifest()
+
+	case "T":
+		// Copy a Terraform import block and resource for the secret
+		if secret := m.grid.SelectedSecret(); secret != nil {
+			return m, copyToClipboard(terraformImport(*secret, m.secretDetails), false)
 		}
 		return m, nil
+
+	case "E":
+		// Encrypt the secret with another KMS key
+		return m.startKMSKeyChange()
 	}
 
-	cmd := m.fieldSelector.Update(msg)
-	return m, cmd
+	return m, nil
 }
 
-// handleProfileSelectorKeys handles key presses on the profile selector screen
-func (m Model) handleProfileSelectorKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "esc":
-		// Go back to list
-		m.currentScreen = ScreenSecretList
-		return m, nil
+// showSecrets shows a page of secrets in the grid
+func (m *Model) showSecrets(secrets []models.Secret) {
+	m.secrets = secrets
+	m.grid.SetSecrets(secrets)
+}
 
-	case "enter":
-		// Select profile
-		selectedProfile := m.profileSelector.SelectedProfile()
-		if selectedProfile != "" && selectedProfile != m.currentProfile {
-			// Profile changed, reinitialize client
-			m.loading = true
-			m.currentScreen = ScreenSecretList
-			return m, initAWSClient(selectedProfile, m.currentRegion)
-		}
-		// No change, just go back
-		m.currentScreen = ScreenSecretList
-		return m, nil
-	}
+// hasNextPage reports whether there is a page after the one shown
+func (m Model) hasNextPage() bool {
+	return m.inventory != nil && !m.allPages && m.inventory.HasNext(m.currentPage)
+}
 
-	// Let the profile selector handle navigation keys
-	cmd := m.profileSelector.Update(msg)
-	return m, cmd
+// exportNames returns the secrets an export applies to: the marked secrets,
+// or the selected secret when nothing is marked
+func (m Model) exportNames() []string {
+	if names := m.grid.MarkedNames(); len(names) > 0 {
+		return names
+	}
+	if secret := m.grid.SelectedSecret(); secret != nil {
+		return []string{secret.Name}
+	}
+	return nil
 }
 
-// handleRegionSelectorKeys handles key presses on the region selector screen
-func (m Model) handleRegionSelectorKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "q", "esc":
-		// Go back to list
-		m.currentScreen = ScreenSecretList
-		return m, nil
+// Commands
 
-	case "enter":
-		// Select region
-		selectedRegion := m.regionSelector.SelectedRegion()
-		if selectedRegion != "" && selectedRegion != m.currentRegion {
-			// Region changed, reinitialize client
-			m.loading = true
-			m.currentScreen = ScreenSecretList
-			return m, initAWSClient(m.currentProfile, selectedRegion)
+// initAWSClient initializes the AWS client, or switches to the backend
+// that replaces it
+func (m *Model) initAWSClient(profile, region string) tea.Cmd {
+	ctx := m.startLoad("Signing in…")
+	if m.backend != nil {
+		store := m.backend
+		return func() tea.Msg {
+			return clientChangedMsg{client: store, profile: profile, region: region}
 		}
-		// No change, just go back
-		m.currentScreen = ScreenSecretList
-		return m, nil
 	}
-
-	// Let the region selector handle navigation keys
-	cmd := m.regionSelector.Update(msg)
-	return m, cmd
+	return authenticate(ctx, auth.Request{Profile: profile, Region: region, EndpointURL: m.endpointURL})
 }
 
-// handleMFAInputKeys handles key presses on the MFA input screen
-func (m Model) handleMFAInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "esc":
-		// Cancel MFA input, go back to list
-		m.currentScreen = ScreenSecretList
-		m.errorMessage = "MFA authentication cancelled"
-		return m, nil
-
-	case "enter":
-		// Submit MFA token
-		token := m.mfaInput.Value()
-		if len(token) != 6 {
-			m.errorMessage = "MFA code must be 6 digits"
-			return m, nil
+// authenticate runs the auth pipeline in the background. Each step is
+// reported with an authProgressMsg, followed by mfaRequiredMsg when a code
+// is needed or clientChangedMsg with the result.
+func authenticate(ctx context.Context, req auth.Request) tea.Cmd {
+	return runAuth(ctx, req, func(client *aws.Client, err error) tea.Msg {
+		var mfaErr *auth.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return mfaRequiredMsg{profile: req.Profile, region: req.Region}
 		}
-		m.loading = true
-		m.errorMessage = ""
-		// Use source profile for MFA if this is a role assumption
-		profileForMFA := m.pendingMFAProfile
-		if m.pendingMFASourceProfile != "" {
-			profileForMFA = m.pendingMFASourceProfile
+		return clientChangedMsg{
+			client:  client,
+			profile: req.Profile,
+			region:  req.Region,
+			err:     err,
 		}
-		return m, submitMFAToken(m.pendingMFAProfile, profileForMFA, m.pendingMFARegion, m.mfaSerial, token)
+	})
+}
+
+// runAuth runs the auth pipeline in the background, reporting each step
+// with an authProgressMsg and then the message result makes of the outcome
+func runAuth(ctx context.Context, req auth.Request, result func(*aws.Client, error) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		events := make(chan tea.Msg)
+		go func() {
+			req.Progress = func(text string) {
+				events <- authProgressMsg{text: text, next: waitForEvents(events)}
+			}
+
+			client, err := auth.Default().Authenticate(ctx, req)
+			events <- result(client, err)
+		}()
+		return <-events
 	}
+}
 
-	// Let the text input handle key presses
-	cmd := m.mfaInput.Update(msg)
-	return m, cmd
+// loadSecrets loads a page of secrets through the inventory
+func loadSecrets(ctx context.Context, inv *inventory.Service, page int) tea.Cmd {
+	return func() tea.Msg {
+		result, err := inv.Page(ctx, page)
+		return secretsLoadedMsg{
+			inventory: inv,
+			page:      page,
+			secrets:   result.Secrets,
+			err:       err,
+		}
+	}
 }
 
-// Commands
+// loadSecretValue loads a secret value from AWS
+func loadSecretValue(ctx context.Context, client SecretStore, secretName string) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return secretValueLoadedMsg{err: fmt.Errorf("AWS client not initialized")}
+		}
+		value, err := client.GetSecret(ctx, secretName)
+		if err != nil {
+			return secretValueLoadedMsg{err: err}
+		}
+		return secretValueLoadedMsg{
+			value:  value.String,
+			binary: value.Binary,
+		}
+	}
+}
 
-// initAWSClient initializes the AWS client
-func initAWSClient(profile, region string) tea.Cmd {
+// exportSecrets fetches the named secrets and writes them to path in the
+// given format. A document is keyed by secret name, or is one combined
+// dotenv file for .env paths. Binary secrets are included as base64. Each
+// fetched secret is reported with a loadProgressMsg, followed by
+// secretsExportedMsg with the result.
+func exportSecrets(ctx context.Context, client SecretStore, names []string, path string, format exportFormat, mapping secretvalue.EnvMapping) tea.Cmd {
 	return func() tea.Msg {
-		ctx := context.Background()
-
-		// Check if profile requires MFA
-		mfaConfig, err := aws.GetMFAConfig(profile)
-		if err == nil && mfaConfig.Required {
-			// Check for cached credentials
-			profileForCache := profile
-			if mfaConfig.SourceProfile != "" {
-				profileForCache = mfaConfig.SourceProfile
-			}
+		if client == nil {
+			return secretsExportedMsg{err: fmt.Errorf("AWS client not initialized")}
+		}
 
-			if cachedCreds, valid := config.GetCachedCredentials(profileForCache); valid {
-				// Use cached credentials
-				creds := awssdk.Credentials{
-					AccessKeyID:     cachedCreds.AccessKeyID,
-					SecretAccessKey: cachedCreds.SecretAccessKey,
-					SessionToken:    cachedCreds.SessionToken,
-					Source:          "CachedMFA",
-					CanExpire:       true,
-					Expires:         cachedCreds.ExpiresAt,
-				}
+		events := make(chan tea.Msg)
+		go func() {
+			events <- writeExport(ctx, client, names, path, format, mapping, func(fetched int) {
+				text := fmt.Sprintf("Fetched %d of %d secrets…", fetched, len(names))
+				events <- loadProgressMsg{text: text, next: waitForEvents(events)}
+			})
+		}()
+		return <-events
+	}
+}
 
-				var client *aws.Client
-				var clientErr error
+// writeExport does the work of exportSecrets, calling progress after each
+// secret is fetched
+func writeExport(ctx context.Context, client SecretStore, names []string, path string, format exportFormat, mapping secretvalue.EnvMapping, progress func(fetched int)) secretsExportedMsg {
+	values, err := fetchSecrets(ctx, client, names, progress)
+	if err != nil {
+		return secretsExportedMsg{err: err}
+	}
 
-				if mfaConfig.SourceProfile != "" {
-					// Role assumption
-					client, clientErr = aws.NewClientWithMFAForRole(ctx, profile, region, creds)
-				} else {
-					// Direct MFA
-					client, clientErr = aws.NewClientWithMFA(ctx, profile, region, creds)
-				}
+	var data []byte
+	var conflicts []string
+	switch {
+	case format == exportCompose:
+		return writeComposeSecrets(path, names, values)
+	case format == exportDockerEnv:
+		data, conflicts, err = writeDockerEnv(names, values, mapping)
+	case secretvalue.IsDotenvPath(path):
+		var dotenv string
+		dotenv, conflicts, err = combineDotenv(names, values, mapping)
+		data = []byte(dotenv)
+	default:
+		data, err = secretvalue.EncodeDocument(secretvalue.Bundle(values), secretvalue.FormatForPath(path))
+	}
+	if err != nil {
+		return secretsExportedMsg{err: err}
+	}
 
-				return clientChangedMsg{
-					client:  client,
-					profile: profile,
-					region:  region,
-					err:     clientErr,
-				}
-			}
+	written, err := writeSecretFile(path, data)
+	return secretsExportedMsg{path: written, count: len(names), conflicts: conflicts, err: err}
+}
 
-			// No valid cached credentials, prompt for MFA
-			return mfaRequiredMsg{
-				profile:       profile,
-				region:        region,
-				mfaSerial:     mfaConfig.MFASerial,
-				sourceProfile: mfaConfig.SourceProfile,
+// fetchSecrets gets the values of the named secrets, with binary secrets as
+// base64, a batch of aws.MaxBatchSecrets at a time, calling progress after
+// each batch
+func fetchSecrets(ctx context.Context, client SecretStore, names []string, progress func(fetched int)) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for start := 0; start < len(names); start += aws.MaxBatchSecrets {
+		batch := names[start:min(start+aws.MaxBatchSecrets, len(names))]
+		fetched, err := client.GetSecrets(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range fetched {
+			if value.IsBinary() {
+				values[name] = base64.StdEncoding.EncodeToString(value.Binary)
+			} else {
+				values[name] = value.String
 			}
 		}
+		progress(start + len(batch))
+	}
+	return values, nil
+}
 
-		// No MFA required or error checking, proceed normally
-		client, err := aws.NewClient(ctx, profile, region)
-		return clientChangedMsg{
-			client:  client,
-			profile: profile,
-			region:  region,
-			err:     err,
+// exportReport lists every secret and writes a CSV or JSON report of their
+// metadata to path
+func exportReport(ctx context.Context, inv *inventory.Service, path string) tea.Cmd {
+	return func() tea.Msg {
+		if inv == nil {
+			return secretsExportedMsg{err: fmt.Errorf("AWS client not initialized")}
+		}
+
+		secrets, err := inv.Find(ctx, "")
+		if err != nil {
+			return secretsExportedMsg{err: err}
+		}
+		data, err := inventory.EncodeReport(secrets, inventory.ReportFormatForPath(path))
+		if err != nil {
+			return secretsExportedMsg{err: err}
 		}
+
+		written, err := writeSecretFile(path, data)
+		return secretsExportedMsg{path: written, count: len(secrets), report: true, err: err}
 	}
 }
 
-// loadSecrets loads secrets from AWS
-func loadSecrets(client *aws.Client, maxResults int32, nextToken *string) tea.Cmd {
+// saveSecretFile writes a secret value to path, replacing an existing file
+// only when overwrite is set
+func saveSecretFile(path string, data []byte, overwrite bool) tea.Cmd {
 	return func() tea.Msg {
-		if client == nil {
-			return secretsLoadedMsg{err: fmt.Errorf("AWS client not initialized")}
-		}
-		ctx := context.Background()
-		secrets, token, err := client.ListSecrets(ctx, maxResults, nextToken)
-		return secretsLoadedMsg{
-			secrets:   secrets,
-			nextToken: token,
-			err:       err,
+		write := writeSecretFile
+		if overwrite {
+			write = replaceSecretFile
 		}
+		written, err := write(path, data)
+		return secretSavedMsg{path: written, bytes: len(data), err: err}
 	}
 }
 
-// loadSecretValue loads a secret value from AWS
-func loadSecretValue(client *aws.Client, secretName string) tea.Cmd {
+// loadSecretDetails loads the extended metadata for a secret from AWS. It
+// runs alongside the value, so it has a timeout but isn't cancellable.
+func loadSecretDetails(client SecretStore, secretName string, timeout time.Duration) tea.Cmd {
 	return func() tea.Msg {
 		if client == nil {
-			return secretValueLoadedMsg{err: fmt.Errorf("AWS client not initialized")}
+			return secretDetailsLoadedMsg{name: secretName, err: fmt.Errorf("AWS client not initialized")}
 		}
-		ctx := context.Background()
-		value, err := client.GetSecretValue(ctx, secretName)
-		return secretValueLoadedMsg{
-			value: value,
-			err:   err,
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		details, err := client.DescribeSecret(ctx, secretName)
+		return secretDetailsLoadedMsg{
+			name:    secretName,
+			details: details,
+			err:     err,
+		}
+	}
+}
+
+// updateConfig applies change to the saved config in the background,
+// keeping any settings it doesn't touch
+func updateConfig(change func(cfg *config.Config)) {
+	go func() {
+		_ = config.Update(change) // Ignore errors, don't block UI
+	}()
+}
+
+// checkClockSkew measures how far the local clock is from AWS's
+func checkClockSkew(region string) tea.Cmd {
+	return func() tea.Msg {
+		skew, err := aws.MeasureClockSkew(context.Background(), region)
+		if err != nil {
+			logging.Debugf("clock skew check failed: %v", err)
 		}
+		return clockSkewMsg{skew: skew, err: err}
+	}
+}
+
+// describeError formats an AWS error for the footer. Signature errors caused
+// by a wrong system clock are cryptic, so the clock warning replaces them.
+func (m Model) describeError(action string, err error) string {
+	if m.clockWarning != "" && aws.IsClockSkewError(err) {
+		return m.clockWarning
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("%s: no response from AWS after %s", action, m.requestTimeout)
+	}
+	return fmt.Sprintf("%s: %v", action, err)
+}
+
+// describeRetry formats a retry for the status bar
+func describeRetry(event aws.RetryEvent) string {
+	reason := "AWS request failed"
+	if event.Throttled {
+		reason = "Throttled by AWS"
 	}
+	return fmt.Sprintf("%s, retrying in %s (attempt %d)…", reason, event.Delay.Round(100*time.Millisecond), event.Attempt+1)
 }
 
 // clearStatusAfter clears the status message after a delay
@@ -643,8 +1844,32 @@ func clearStatusAfter(delay time.Duration) tea.Cmd {
 
 func (m *Model) clearSecretValueState() {
 	m.secretValue = ""
+	m.secretBinary = nil
+	m.binaryFormat = binaryFormatBase64
+	m.valueRevealed = false
+	m.revealSeq++
+	m.fieldCursor = 0
+	m.revealedFields = nil
 	m.secretFields = nil
-	m.fieldSelector = components.SecretFieldSelector{}
+}
+
+// secretLoaded reports whether the selected secret's value has been fetched
+func (m Model) secretLoaded() bool {
+	return m.secretValue != "" || m.secretBinary != nil
+}
+
+// loadedData returns the loaded value as it is saved to a file
+func (m Model) loadedData() []byte {
+	if m.secretBinary != nil {
+		return m.secretBinary
+	}
+	return []byte(m.secretValue)
+}
+
+func (m *Model) clearSecretDetails() {
+	m.secretDetails = nil
+	m.detailsError = ""
+	m.split.shown = ""
 }
 
 // copyToClipboard copies the value to clipboard
@@ -672,43 +1897,7 @@ func copyToClipboard(value string, asJSON bool) tea.Cmd {
 		err := clipboard.WriteAll(toCopy)
 		return clipboardCopiedMsg{
 			success: err == nil,
-			err:     err,
-		}
-	}
-}
-
-// submitMFAToken submits the MFA token and gets session credentials
-func submitMFAToken(targetProfile, profileForMFA, region, mfaSerial, token string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		creds, err := aws.GetSessionTokenWithMFA(ctx, profileForMFA, region, mfaSerial, token)
-		return mfaTokenSubmittedMsg{
-			creds: creds,
-			err:   err,
-		}
-	}
-}
-
-// createClientWithMFACredentials creates an AWS client with MFA credentials
-func createClientWithMFACredentials(profile, region string, creds awssdk.Credentials, sourceProfile string) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
-		// If this profile uses a source profile (role assumption), we need to handle it differently
-		var client *aws.Client
-		var err error
-
-		if sourceProfile != "" {
-			// This is a role assumption profile
-			client, err = aws.NewClientWithMFAForRole(ctx, profile, region, creds)
-		} else {
-			// Direct MFA authentication
-			client, err = aws.NewClientWithMFA(ctx, profile, region, creds)
-		}
-
-		return clientChangedMsg{
-			client:  client,
-			profile: profile,
-			region:  region,
+			value:   toCopy,
 			err:     err,
 		}
 	}
@@ -1,11 +1,37 @@
 package ui
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/smithy-go"
+	"github.com/benjamingriff/secretsrc/pkg/auth"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/azure"
+	"github.com/benjamingriff/secretsrc/pkg/browser"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/demo"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
 	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/notify"
+	"github.com/benjamingriff/secretsrc/pkg/passwords"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
 	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/zalando/go-keyring"
 )
 
 func TestHandleSecretListKeysEscClearsFilterWithoutQuit(t *testing.T) {
@@ -134,44 +160,58 @@ func TestHandleSecretDetailKeysOpensFieldSelector(t *testing.T) {
 		t.Fatalf("expected current screen %v, got %v", ScreenSecretFieldSelector, updated.currentScreen)
 	}
 
-	selected := updated.fieldSelector.SelectedField()
+	picker := updated.screen.(fieldScreen)
+	selected := picker.selector.SelectedField()
 	if selected == nil || selected.Key != "password" {
 		t.Fatalf("expected password to be selected in field picker, got %+v", selected)
 	}
 }
 
-func TestHandleSecretFieldSelectorEscReturnsToDetail(t *testing.T) {
+func TestFieldSelectorEscReturnsToDetail(t *testing.T) {
 	model := NewModel("default", "eu-west-2")
-	model.currentScreen = ScreenSecretFieldSelector
-	model.fieldSelector = fieldSelectorForTests([]string{"password"})
+	model.openScreen(ScreenSecretFieldSelector, fieldScreenForTests([]string{"password"}), ScreenSecretDetail)
 
-	updatedModel, cmd := model.handleSecretFieldSelectorKeys(tea.KeyMsg{Type: tea.KeyEsc})
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	updated, cmd := deliver(t, updatedModel.(Model), cmd)
 	if cmd != nil {
 		t.Fatal("expected esc to return without side effects")
 	}
-
-	updated := updatedModel.(Model)
 	if updated.currentScreen != ScreenSecretDetail {
 		t.Fatalf("expected current screen %v, got %v", ScreenSecretDetail, updated.currentScreen)
 	}
+	if updated.screen != nil {
+		t.Fatal("expected the field selector to be closed")
+	}
 }
 
-func TestHandleSecretFieldSelectorEnterReturnsCopyCommand(t *testing.T) {
+func TestFieldSelectorEnterReturnsCopyCommand(t *testing.T) {
 	model := NewModel("default", "eu-west-2")
-	model.currentScreen = ScreenSecretFieldSelector
-	model.fieldSelector = fieldSelectorForTests([]string{"password"})
+	model.openScreen(ScreenSecretFieldSelector, fieldScreenForTests([]string{"password"}), ScreenSecretDetail)
 
-	updatedModel, cmd := model.handleSecretFieldSelectorKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, cmd := deliver(t, updatedModel.(Model), cmd)
 	if cmd == nil {
 		t.Fatal("expected enter to return a clipboard copy command")
 	}
-
-	updated := updatedModel.(Model)
 	if updated.currentScreen != ScreenSecretDetail {
 		t.Fatalf("expected current screen %v, got %v", ScreenSecretDetail, updated.currentScreen)
 	}
 }
 
+func TestFieldScreenReportsChosenField(t *testing.T) {
+	var s screen = fieldScreenForTests([]string{"password", "username"})
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected enter to report the chosen field")
+	}
+	msg, ok := cmd().(fieldChosenMsg)
+	if !ok || msg.value != "value-for-username" {
+		t.Fatalf("expected the username field to be chosen, got %#v", msg)
+	}
+}
+
 func TestHandleSecretListKeysEnterClearsSecretValueState(t *testing.T) {
 	model := NewModel("default", "eu-west-2")
 	model.grid.SetSecrets([]models.Secret{
@@ -182,15 +222,20 @@ func TestHandleSecretListKeysEnterClearsSecretValueState(t *testing.T) {
 	model.secretFields = parseSecretFields(model.secretValue)
 	model.currentScreen = ScreenSecretList
 
+	model.secretDetails = &models.SecretDetails{KmsKeyID: "stale"}
+
 	updatedModel, cmd := model.handleSecretListKeys(tea.KeyMsg{Type: tea.KeyEnter})
-	if cmd != nil {
-		t.Fatal("expected enter on the list screen to only change state")
+	if cmd == nil {
+		t.Fatal("expected enter on the list screen to load the secret metadata")
 	}
 
 	updated := updatedModel.(Model)
 	if updated.currentScreen != ScreenSecretDetail {
 		t.Fatalf("expected current screen %v, got %v", ScreenSecretDetail, updated.currentScreen)
 	}
+	if updated.secretDetails != nil {
+		t.Fatal("expected metadata from the previous secret to be cleared")
+	}
 	if updated.secretValue != "" {
 		t.Fatalf("expected secret value to be cleared, got %q", updated.secretValue)
 	}
@@ -199,11 +244,106 @@ func TestHandleSecretListKeysEnterClearsSecretValueState(t *testing.T) {
 	}
 }
 
+func TestHandleSecretListKeysTogglesLayoutKeepingSelection(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSize(120, 30)
+	model.grid.SetSecrets([]models.Secret{
+		{Name: "alpha"},
+		{Name: "beta"},
+		{Name: "gamma"},
+	})
+	model.grid.Update(keyRunes("l"))
+
+	if selected := model.grid.SelectedSecret(); selected == nil || selected.Name != "beta" {
+		t.Fatalf("expected beta to be selected before toggling, got %+v", selected)
+	}
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("L"))
+	updated := updatedModel.(Model)
+
+	if updated.grid.Layout() != components.LayoutList {
+		t.Fatalf("expected list layout, got %v", updated.grid.Layout())
+	}
+	if selected := updated.grid.SelectedSecret(); selected == nil || selected.Name != "beta" {
+		t.Fatalf("expected beta to stay selected after toggling, got %+v", selected)
+	}
+}
+
+func TestHandleSecretListKeysCyclesSortOrder(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{
+		{Name: "beta", LastChangedDate: &older},
+		{Name: "alpha"},
+		{Name: "gamma", LastChangedDate: &newer},
+	})
+
+	expected := []struct {
+		order inventory.SortOrder
+		first string
+	}{
+		{inventory.SortNameAsc, "alpha"},
+		{inventory.SortNameDesc, "gamma"},
+		{inventory.SortChangedNewest, "gamma"},
+		{inventory.SortChangedOldest, "beta"},
+	}
+
+	for _, want := range expected {
+		updatedModel, _ := model.handleSecretListKeys(keyRunes("s"))
+		model = updatedModel.(Model)
+
+		if got := model.grid.SortOrder(); got != want.order {
+			t.Fatalf("expected sort order %v, got %v", want.order, got)
+		}
+		if selected := model.grid.SelectedSecret(); selected == nil || selected.Name != want.first {
+			t.Fatalf("expected %q first for %s, got %+v", want.first, want.order.Label(), selected)
+		}
+	}
+}
+
 func keyRunes(value string) tea.KeyMsg {
 	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(value)}
 }
 
-func fieldSelectorForTests(keys []string) components.SecretFieldSelector {
+// pressRegionKey presses g on the secret list and lets its wait for a second
+// g end at once, switching region
+func pressRegionKey(model Model) (tea.Model, tea.Cmd) {
+	updatedModel, _ := model.Update(keyRunes("g"))
+	model = updatedModel.(Model)
+	return model.Update(pendingGTimeoutMsg{pressed: model.jump.pendingG})
+}
+
+// typeName clears the open prompt and types name into it, a key at a time
+func typeName(model Model, name string) Model {
+	for range 64 {
+		updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+		model = updatedModel.(Model)
+	}
+	for _, r := range name {
+		updatedModel, _ := model.Update(keyRunes(string(r)))
+		model = updatedModel.(Model)
+	}
+	return model
+}
+
+// deliver runs a command returned by a screen and passes the message it
+// reports to the root model, as the Bubble Tea runtime would
+func deliver(t *testing.T, model Model, cmd tea.Cmd) (Model, tea.Cmd) {
+	t.Helper()
+	if cmd == nil {
+		t.Fatal("expected the screen to report a result")
+	}
+	updatedModel, cmd := model.Update(cmd())
+	return updatedModel.(Model), cmd
+}
+
+func fieldScreenForTests(keys []string) fieldScreen {
 	fields := make([]components.SecretField, len(keys))
 	for i, key := range keys {
 		fields[i] = components.SecretField{
@@ -213,7 +353,8 @@ func fieldSelectorForTests(keys []string) components.SecretFieldSelector {
 		}
 	}
 
-	return components.NewSecretFieldSelector(fields, 80, 20)
+	s := newFieldScreen(fields).SetSize(80, 20)
+	return s.(fieldScreen)
 }
 
 func lipglossHeight(value string) int {
@@ -228,3 +369,3110 @@ func lipglossHeight(value string) int {
 	}
 	return lines
 }
+
+func TestSecretDetailsLoadedMsgIgnoresOtherSecrets(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+	model.currentScreen = ScreenSecretDetail
+
+	updatedModel, _ := model.Update(secretDetailsLoadedMsg{name: "beta", details: &models.SecretDetails{}})
+	if updatedModel.(Model).secretDetails != nil {
+		t.Fatal("expected metadata for a different secret to be ignored")
+	}
+
+	details := &models.SecretDetails{KmsKeyID: "alias/app"}
+	updatedModel, _ = model.Update(secretDetailsLoadedMsg{name: "alpha", details: details})
+	if updatedModel.(Model).secretDetails != details {
+		t.Fatal("expected metadata for the open secret to be stored")
+	}
+}
+
+func TestFormatBinaryHexDump(t *testing.T) {
+	data := make([]byte, 18)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	got := formatBinary(data, binaryFormatHex)
+	want := "00000000  00 01 02 03 04 05 06 07  08 09 0a 0b 0c 0d 0e 0f\n00000010  10 11"
+	if got != want {
+		t.Fatalf("unexpected hex dump:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := formatBinary([]byte("hello"), binaryFormatBase64); got != "aGVsbG8=" {
+		t.Fatalf("unexpected base64 %q", got)
+	}
+}
+
+func TestWriteSecretFileRefusesToOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.p12")
+
+	if _, err := writeSecretFile(path, []byte{0x30, 0x82}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected file to exist: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected owner-only permissions, got %v", info.Mode().Perm())
+	}
+
+	if _, err := writeSecretFile(path, []byte("other")); err == nil {
+		t.Fatal("expected an existing file to be left alone")
+	}
+}
+
+func TestReplaceSecretFileKeepsOwnerOnlyPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writeSecretFile(path, []byte("new")); !errors.Is(err, errFileExists) {
+		t.Fatalf("expected errFileExists, got %v", err)
+	}
+	if _, err := replaceSecretFile(path, []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("expected the file to be replaced, got %q (%v)", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected owner-only permissions, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestSaveSecretAsksBeforeOverwriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.pem")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "certs/server"}})
+	model.currentScreen = ScreenSecretDetail
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: "new"})
+	model = updatedModel.(Model)
+
+	updatedModel, _ = model.handleSecretDetailKeys(keyRunes("s"))
+	model = updatedModel.(Model)
+	model, cmd := deliver(t, model, emit(pathEnteredMsg{path: path}))
+	model, _ = deliver(t, model, cmd)
+	if model.currentScreen != ScreenSaveConfirm {
+		t.Fatalf("expected the overwrite confirmation, got screen %v", model.currentScreen)
+	}
+
+	// Declining goes back to the prompt and leaves the file alone
+	updatedModel, _ = model.Update(closeScreenMsg{})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSaveSecret {
+		t.Fatalf("expected the save prompt again, got screen %v", model.currentScreen)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "old" {
+		t.Fatalf("expected the file to be left alone, got %q", data)
+	}
+
+	model, cmd = deliver(t, model, emit(pathEnteredMsg{path: path}))
+	model, _ = deliver(t, model, cmd)
+	model, cmd = deliver(t, model, emit(confirmedMsg{}))
+	model, _ = deliver(t, model, cmd)
+	if model.currentScreen != ScreenSecretDetail {
+		t.Fatalf("expected the detail screen, got screen %v", model.currentScreen)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("expected the file to be replaced, got %q", data)
+	}
+}
+
+func TestKubeManifestPromptSuggestsNameAndRejectsInvalidOnes(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "app/Prod/db"}})
+	model.currentScreen = ScreenSecretDetail
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: `{"password":"hunter2"}`})
+	model = updatedModel.(Model)
+
+	updatedModel, _ = model.handleSecretDetailKeys(keyRunes("K"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenKubeManifest {
+		t.Fatalf("expected the manifest prompt, got screen %v", model.currentScreen)
+	}
+	prompt := model.screen.(textScreen)
+	if got := prompt.input.Value(); got != "app-prod-db" {
+		t.Fatalf("expected suggested name %q, got %q", "app-prod-db", got)
+	}
+
+	model, _ = deliver(t, model, emit(textEnteredMsg{value: "Payments/app_db"}))
+	if model.currentScreen != ScreenKubeManifest {
+		t.Fatalf("expected an invalid name to keep the prompt open, got screen %v", model.currentScreen)
+	}
+}
+
+func TestTerraformImportSkeleton(t *testing.T) {
+	secret := models.Secret{
+		Name:        "app/prod/db",
+		ARN:         "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/db-AbCdEf",
+		Description: `Uses ${var}`,
+		Tags:        map[string]string{"team": "payments", "cost-center": "42", "aws:owner": "x"},
+	}
+	details := &models.SecretDetails{KmsKeyID: "alias/app"}
+
+	want := `# Terraform 1.5 or later imports the secret on the next plan and apply
+import {
+  to = aws_secretsmanager_secret.app_prod_db
+  id = "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/db-AbCdEf"
+}
+
+# With older versions, run instead:
+# terraform import aws_secretsmanager_secret.app_prod_db 'arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/prod/db-AbCdEf'
+
+resource "aws_secretsmanager_secret" "app_prod_db" {
+  name        = "app/prod/db"
+  description = "Uses $${var}"
+  kms_key_id  = "alias/app"
+
+  tags = {
+    "aws:owner" = "x"
+    cost-center = "42"
+    team        = "payments"
+  }
+}
+`
+	if got := terraformImport(secret, details); got != want {
+		t.Fatalf("unexpected snippet:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := terraformResourceName("2024/Key"); got != "secret_2024_key" {
+		t.Fatalf("unexpected resource name %q", got)
+	}
+}
+
+func TestHandleSecretDetailKeysBinarySecret(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "certs/server"}})
+	model.currentScreen = ScreenSecretDetail
+
+	updatedModel, _ := model.Update(secretValueLoadedMsg{binary: []byte{0xde, 0xad, 0xbe, 0xef}})
+	model = updatedModel.(Model)
+
+	updatedModel, _ = model.handleSecretDetailKeys(keyRunes("x"))
+	model = updatedModel.(Model)
+	if model.binaryFormat != binaryFormatHex {
+		t.Fatal("expected x to switch the binary view to hex")
+	}
+
+	updatedModel, _ = model.handleSecretDetailKeys(keyRunes("s"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSaveSecret {
+		t.Fatalf("expected the save prompt, got screen %v", model.currentScreen)
+	}
+	prompt := model.screen.(pathScreen)
+	if got := prompt.input.Value(); got != "server.bin" {
+		t.Fatalf("expected default path %q, got %q", "server.bin", got)
+	}
+}
+
+func TestTutorialAdvancesAsStepsAreCompleted(t *testing.T) {
+	model := NewModel("default", "eu-west-2").WithTutorial()
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+
+	// Opening the profile selector completes the first step
+	model.currentScreen = ScreenProfileSelector
+	model = model.updateTutorial(nil)
+	if model.tutorial.step != 1 {
+		t.Fatalf("expected step 2 after opening the profile selector, got step %d", model.tutorial.step+1)
+	}
+
+	// ctrl+n skips a step without reaching its goal
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	model = updatedModel.(Model)
+	if model.tutorial.step != 2 {
+		t.Fatalf("expected ctrl+n to skip to step 3, got step %d", model.tutorial.step+1)
+	}
+
+	// ctrl+x ends the tutorial
+	updatedModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlX})
+	if updatedModel.(Model).tutorial.active {
+		t.Fatal("expected ctrl+x to end the tutorial")
+	}
+}
+
+func TestHandleSecretListKeysMarksSecretsForExport(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}, {Name: "beta"}})
+
+	if got := model.exportNames(); len(got) != 1 || got[0] != "alpha" {
+		t.Fatalf("expected the selected secret to be exported when nothing is marked, got %v", got)
+	}
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("m"))
+	model = updatedModel.(Model)
+	model.grid.Update(keyRunes("l"))
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("m"))
+	model = updatedModel.(Model)
+
+	if got := model.exportNames(); len(got) != 2 || got[0] != "alpha" || got[1] != "beta" {
+		t.Fatalf("expected both marked secrets, got %v", got)
+	}
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("e"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenExportFormat {
+		t.Fatalf("expected the export format picker, got screen %v", model.currentScreen)
+	}
+	model, _ = deliver(t, model, emit(actionChosenMsg{key: "d"}))
+	if model.currentScreen != ScreenExportSecrets {
+		t.Fatalf("expected the export prompt, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList {
+		t.Fatalf("expected esc to return to the list, got screen %v", model.currentScreen)
+	}
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("M"))
+	model = updatedModel.(Model)
+	if got := model.grid.MarkedNames(); len(got) != 0 {
+		t.Fatalf("expected M to clear marks, got %v", got)
+	}
+}
+
+func TestSecretValueIsMaskedUntilRevealed(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+	model.currentScreen = ScreenSecretDetail
+	model.width = 120
+	model.height = 60
+
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: "hunter2-password"})
+	model = updatedModel.(Model)
+	if strings.Contains(model.View(), "hunter2-password") {
+		t.Fatal("expected the loaded value to be masked")
+	}
+
+	updatedModel, cmd := model.handleSecretDetailKeys(keyRunes("r"))
+	model = updatedModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected revealing to schedule a re-mask")
+	}
+	if !strings.Contains(model.View(), "hunter2-password") {
+		t.Fatal("expected r to reveal the value")
+	}
+
+	// A re-mask from an earlier reveal is ignored
+	updatedModel, _ = model.Update(remaskMsg{seq: model.revealSeq - 1})
+	model = updatedModel.(Model)
+	if !model.valueRevealed {
+		t.Fatal("expected a stale re-mask to be ignored")
+	}
+
+	updatedModel, _ = model.Update(remaskMsg{seq: model.revealSeq})
+	if updatedModel.(Model).valueRevealed {
+		t.Fatal("expected the timeout to mask the value again")
+	}
+}
+
+func TestParseRevealTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      defaultRevealTimeout,
+		"0":     0,
+		"10s":   10 * time.Second,
+		"bogus": defaultRevealTimeout,
+		"-5s":   defaultRevealTimeout,
+	}
+	for value, want := range cases {
+		if got := parseRevealTimeout(value); got != want {
+			t.Fatalf("parseRevealTimeout(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestLintScreenScoresFindings(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+	model.lintRules = inventory.LintRules{RequiredTags: []string{"Owner"}}
+
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("C"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenLint {
+		t.Fatalf("expected the lint screen, got screen %v (%s)", model.currentScreen, model.errorMessage)
+	}
+	lint := model.screen.(lintScreen).lint
+	report := lint.Report()
+	if report.Failed[inventory.CheckRequiredTags] != len(report.Entries) || report.Unchecked != 0 {
+		t.Fatalf("expected every demo secret to miss the Owner tag with every policy read, got %v", report.Failed)
+	}
+	if view := model.screen.View(); !strings.Contains(view, "Score ") || !strings.Contains(view, "missing Owner") {
+		t.Fatalf("expected the score and findings, got %q", view)
+	}
+
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList {
+		t.Fatalf("expected esc to close the checks, got screen %v", model.currentScreen)
+	}
+}
+
+func TestAuditScreenExportsAndReturns(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("A"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenAudit {
+		t.Fatalf("expected the audit screen, got screen %v (%s)", model.currentScreen, model.errorMessage)
+	}
+	if len(model.audit.secrets) == 0 || model.audit.staleDays != 90 {
+		t.Fatalf("expected the listed secrets with the default threshold, got %+v", model.audit)
+	}
+
+	// + raises the threshold, which the export keeps
+	updatedModel, _ = model.Update(keyRunes("+"))
+	model = updatedModel.(Model)
+	updatedModel, cmd = model.Update(keyRunes("e"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenAuditExport || model.audit.staleDays != 120 {
+		t.Fatalf("expected the export prompt at 120 days, got screen %v at %d days", model.currentScreen, model.audit.staleDays)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.json")
+	model, cmd = deliver(t, model, emit(pathEnteredMsg{path: path}))
+	model, _ = deliver(t, model, cmd)
+	if model.currentScreen != ScreenAudit {
+		t.Fatalf("expected to return to the audit after exporting, got screen %v", model.currentScreen)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"idle_days"`) {
+		t.Fatalf("expected the audit to be written as JSON, got %q (%v)", data, err)
+	}
+
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || model.audit.secrets != nil {
+		t.Fatalf("expected esc to close the audit, got screen %v", model.currentScreen)
+	}
+}
+
+func TestCancelKeyAbortsLoad(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+
+	ctx := model.startLoad("Refreshing secrets…")
+	updatedModel, _ := model.Update(keyRunes("x"))
+	model = updatedModel.(Model)
+	if model.loading {
+		t.Fatal("expected x to stop loading")
+	}
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("expected the load's context to be cancelled, got %v", ctx.Err())
+	}
+	if model.statusMessage != "Cancelled" {
+		t.Fatalf("expected a cancelled status, got %q", model.statusMessage)
+	}
+
+	// The cancelled request still reports back, and is dropped
+	updatedModel, _ = model.Update(secretsLoadedMsg{inventory: model.inventory, err: ctx.Err()})
+	model = updatedModel.(Model)
+	if model.errorMessage != "" {
+		t.Fatalf("expected no error for a cancelled load, got %q", model.errorMessage)
+	}
+
+	// Without a load in progress x is left to the screen
+	if model.isCancelKey(keyRunes("x")) {
+		t.Fatal("expected x to do nothing when nothing is loading")
+	}
+}
+
+func TestSpinnerRunsWhileLoading(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.spinning = false
+
+	model.startLoad("Refreshing secrets…")
+	updatedModel, cmd := model.keepSpinning(nil)
+	model = updatedModel.(Model)
+	if cmd == nil || !model.spinning {
+		t.Fatal("expected a load to start the spinner")
+	}
+	if view := model.loadingView(); !strings.Contains(view, "Refreshing secrets…") || !strings.Contains(view, "x/esc: cancel") {
+		t.Fatalf("expected the load and how to cancel it, got %q", view)
+	}
+
+	model.cancelLoad()
+	model.loading = false
+	updatedModel, cmd = model.updateSpinner(model.spinner.Tick().(spinner.TickMsg))
+	if cmd != nil || updatedModel.(Model).spinning {
+		t.Fatal("expected the spinner to stop once nothing is loading")
+	}
+}
+
+func TestExportReportsProgress(t *testing.T) {
+	store := demo.NewStore()
+	path := filepath.Join(t.TempDir(), "export.json")
+	msg := exportSecrets(context.Background(), store, []string{"prod/api/database", "prod/api/config"}, path, exportDocument, secretvalue.EnvMapping{})()
+
+	var progress []string
+	for {
+		update, ok := msg.(loadProgressMsg)
+		if !ok {
+			break
+		}
+		progress = append(progress, update.text)
+		msg = update.next()
+	}
+	// Both secrets are fetched in one batch
+	if len(progress) != 1 || progress[0] != "Fetched 2 of 2 secrets…" {
+		t.Fatalf("expected progress for each batch, got %q", progress)
+	}
+	if result := msg.(secretsExportedMsg); result.err != nil || result.count != 2 {
+		t.Fatalf("expected both secrets to be exported, got %+v", result)
+	}
+}
+
+func TestExportComposeSecretsWritesValueFiles(t *testing.T) {
+	store := demo.NewStore()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "compose.secrets.yaml")
+	msg := exportSecrets(context.Background(), store, []string{"prod/api/database"}, path, exportCompose, secretvalue.EnvMapping{})()
+	for {
+		update, ok := msg.(loadProgressMsg)
+		if !ok {
+			break
+		}
+		msg = update.next()
+	}
+
+	result := msg.(secretsExportedMsg)
+	if result.err != nil {
+		t.Fatalf("expected the export to succeed, got %v", result.err)
+	}
+	snippet, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(snippet), "file: ./secrets/password") {
+		t.Fatalf("expected the snippet to reference the value files, got %q (%v)", snippet, err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "secrets", "password"))
+	if err != nil {
+		t.Fatalf("expected the password to be written beside the snippet: %v", err)
+	}
+	if info.Mode().Perm() != 0600 || result.valueFiles == 0 {
+		t.Fatalf("expected owner-only value files, got %v and %d files", info.Mode().Perm(), result.valueFiles)
+	}
+
+	// A second export would overwrite the value files, so it is refused
+	os.Remove(path)
+	if result := writeComposeSecrets(path, []string{"prod/api/database"}, map[string]string{"prod/api/database": `{"password":"x"}`}); result.err == nil {
+		t.Fatal("expected existing value files to be left alone")
+	}
+}
+
+func TestExportCombinesSecretsIntoDotenv(t *testing.T) {
+	store := demo.NewStore()
+	path := filepath.Join(t.TempDir(), "combined.env")
+	names := []string{"prod/api/database", "prod/auth/database", "prod/api/api-key"}
+	msg := exportSecrets(context.Background(), store, names, path, exportDocument, secretvalue.EnvMapping{UpperSnake: true})()
+	for {
+		update, ok := msg.(loadProgressMsg)
+		if !ok {
+			break
+		}
+		msg = update.next()
+	}
+
+	result := msg.(secretsExportedMsg)
+	if result.err != nil {
+		t.Fatalf("expected the export to succeed, got %v", result.err)
+	}
+	if strings.Join(result.conflicts, ",") != "dbname,host,password,username" {
+		t.Fatalf("expected the differing database keys to conflict, got %v", result.conflicts)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the .env file to be written: %v", err)
+	}
+	dotenv := string(data)
+	for _, want := range []string{"ENGINE=postgres\n", "PORT=5432\n", "PROD_API_DATABASE_PASSWORD=", "PROD_AUTH_DATABASE_PASSWORD=", "PROD_API_API_KEY=sk_demo_"} {
+		if !strings.Contains(dotenv, want) {
+			t.Fatalf("expected %q in the combined .env, got:\n%s", want, dotenv)
+		}
+	}
+}
+
+func TestTimedOutLoadNamesTheTimeout(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.requestTimeout = 30 * time.Second
+	got := model.describeError("Failed to load secrets", context.DeadlineExceeded)
+	if !strings.Contains(got, "30s") {
+		t.Fatalf("expected the timeout in the error, got %q", got)
+	}
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      defaultRequestTimeout,
+		"0":     defaultRequestTimeout,
+		"30s":   30 * time.Second,
+		"bogus": defaultRequestTimeout,
+	}
+	for value, want := range cases {
+		if got := parseRequestTimeout(value); got != want {
+			t.Fatalf("parseRequestTimeout(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestMaskedJSONValueRevealsOneKeyAtATime(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+	model.currentScreen = ScreenSecretDetail
+	model.width = 120
+	model.height = 60
+
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: `{"password":"hunter2-password","username":"admin-user"}`})
+	model = updatedModel.(Model)
+	view := model.View()
+	if !strings.Contains(view, "password") || !strings.Contains(view, "username") {
+		t.Fatal("expected JSON keys to be shown while masked")
+	}
+	if strings.Contains(view, "hunter2-password") || strings.Contains(view, "admin-user") {
+		t.Fatal("expected JSON values to be masked")
+	}
+
+	// Keys are sorted, so down moves from password to username
+	updatedModel, _ = model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyDown})
+	model = updatedModel.(Model)
+	updatedModel, cmd := model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected revealing a key to schedule a re-mask")
+	}
+
+	view = model.View()
+	if !strings.Contains(view, "admin-user") {
+		t.Fatal("expected enter to reveal the selected key")
+	}
+	if strings.Contains(view, "hunter2-password") {
+		t.Fatal("expected other keys to stay masked")
+	}
+
+	updatedModel, _ = model.Update(remaskMsg{seq: model.revealSeq})
+	if strings.Contains(updatedModel.(Model).View(), "admin-user") {
+		t.Fatal("expected the timeout to mask the key again")
+	}
+}
+
+func TestHidingAKeyKeepsTheOthersRemasking(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+	model.currentScreen = ScreenSecretDetail
+	model.width = 120
+	model.height = 60
+	model.revealTimeout = time.Millisecond
+
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: `{"password":"hunter2-password","username":"admin-user"}`})
+	model = updatedModel.(Model)
+
+	// Reveal password, then username, then hide username again
+	updatedModel, _ = model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyDown})
+	model = updatedModel.(Model)
+	updatedModel, timer := model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	if !strings.Contains(model.View(), "hunter2-password") || strings.Contains(model.View(), "admin-user") {
+		t.Fatal("expected only password to be revealed")
+	}
+
+	updatedModel, _ = model.Update(timer())
+	if strings.Contains(updatedModel.(Model).View(), "hunter2-password") {
+		t.Fatal("expected the pending timer to mask password again")
+	}
+}
+
+func TestClockSkewReplacesSignatureErrors(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.width = 100
+	model.height = 30
+
+	updatedModel, _ := model.Update(clockSkewMsg{skew: 10 * time.Minute})
+	model = updatedModel.(Model)
+	if model.clockWarning == "" {
+		t.Fatal("expected a 10 minute skew to set the clock warning")
+	}
+	if !strings.Contains(model.viewHeader(), "off by 600 seconds") {
+		t.Fatal("expected the header to show the clock warning")
+	}
+
+	skewErr := &smithy.GenericAPIError{Code: "InvalidSignatureException", Message: "Signature expired: 20240101T000000Z"}
+	updatedModel, _ = model.Update(secretsLoadedMsg{err: skewErr})
+	if got := updatedModel.(Model).errorMessage; got != model.clockWarning {
+		t.Fatalf("expected the clock warning instead of the AWS error, got %q", got)
+	}
+}
+
+func TestInactivityLockClearsLoadedValue(t *testing.T) {
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{LockAfter: "15m"})
+	model.grid.SetSecrets([]models.Secret{{Name: "alpha"}})
+	model.currentScreen = ScreenSecretDetail
+	model.secretValue = "hunter2-password"
+
+	// Recent input only reschedules the check
+	updatedModel, cmd := model.Update(lockCheckMsg{})
+	model = updatedModel.(Model)
+	if model.currentScreen == ScreenLocked || cmd == nil {
+		t.Fatal("expected an active UI to stay unlocked and check again later")
+	}
+
+	model.lastActivity = time.Now().Add(-16 * time.Minute)
+	updatedModel, _ = model.Update(lockCheckMsg{})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenLocked {
+		t.Fatalf("expected the idle UI to lock, got screen %v", model.currentScreen)
+	}
+	if model.secretLoaded() {
+		t.Fatal("expected locking to clear the loaded value")
+	}
+
+	// Only enter unlocks
+	updatedModel, _ = model.Update(keyRunes("q"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenLocked {
+		t.Fatal("expected other keys to leave the UI locked")
+	}
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || model.screen != nil {
+		t.Fatal("expected enter to unlock to the secret list")
+	}
+}
+
+func TestActionsMenuRunsSelectedAction(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.secretValue = `{"password":"hunter2"}`
+	model.secretFields = parseSecretFields(model.secretValue)
+
+	updatedModel, _ := model.handleSecretDetailKeys(keyRunes("a"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretActions {
+		t.Fatalf("expected a to open the actions menu, got screen %v", model.currentScreen)
+	}
+
+	// Move down to the second action, "Copy plain text", and run it
+	updatedModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	model = updatedModel.(Model)
+	menu := model.screen.(actionScreen).menu
+	if action := menu.SelectedAction(); action == nil || action.Key != "c" {
+		t.Fatalf("expected the copy plain text action, got %+v", action)
+	}
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretDetail {
+		t.Fatal("expected running an action to return to the detail screen")
+	}
+	if cmd == nil {
+		t.Fatal("expected the copy action to return a command")
+	}
+}
+
+func TestConsoleURL(t *testing.T) {
+	got := consoleURL("", "eu-west-2", "app/db password")
+	want := "https://eu-west-2.console.aws.amazon.com/secretsmanager/secret?name=app%2Fdb+password&region=eu-west-2"
+	if got != want {
+		t.Fatalf("consoleURL = %q, want %q", got, want)
+	}
+
+	// The ARN's partition and region win over the region in use
+	for arn, want := range map[string]string{
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:app/db-AbCdEf":        "https://us-east-1.console.aws.amazon.com/secretsmanager/secret?name=app%2Fdb&region=us-east-1",
+		"arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:app/db-AbCdEf":    "https://console.amazonaws.cn/secretsmanager/secret?name=app%2Fdb&region=cn-north-1",
+		"arn:aws-us-gov:secretsmanager:us-gov-west-1:123456789012:secret:app/db-Ab": "https://console.amazonaws-us-gov.com/secretsmanager/secret?name=app%2Fdb&region=us-gov-west-1",
+	} {
+		if got := consoleURL(arn, "eu-west-2", "app/db"); got != want {
+			t.Errorf("consoleURL(%s) = %q, want %q", arn, got, want)
+		}
+	}
+	if got := consoleURL("", "cn-northwest-1", "app/db"); !strings.HasPrefix(got, "https://console.amazonaws.cn/") {
+		t.Fatalf("expected a China region without an ARN to use the China console, got %q", got)
+	}
+}
+
+func TestOpenInConsoleOpensTheBrowser(t *testing.T) {
+	var opened string
+	openBrowser = func(url string) error {
+		opened = url
+		return nil
+	}
+	t.Cleanup(func() { openBrowser = browser.Open })
+
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.showSecrets([]models.Secret{{Name: "app/db", ARN: "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf"}})
+	model.currentScreen = ScreenSecretDetail
+
+	updatedModel, cmd := model.handleSecretDetailKeys(keyRunes("O"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if opened != consoleURL(model.secrets[0].ARN, "eu-west-2", "app/db") || model.statusMessage != "Opened app/db in the AWS console" {
+		t.Fatalf("expected the console page to open, got %q and %q", opened, model.statusMessage)
+	}
+
+	openBrowser = func(string) error { return errors.New("xdg-open not found") }
+	updatedModel, cmd = model.handleSecretDetailKeys(keyRunes("O"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if !strings.Contains(model.errorMessage, "xdg-open not found") || !strings.Contains(model.errorMessage, "u copies the link") {
+		t.Fatalf("expected the failure with a way to copy the link, got %q", model.errorMessage)
+	}
+}
+
+func TestRejectedMFACodeKeepsPromptOpen(t *testing.T) {
+	model := NewModel("admin", "eu-west-2")
+
+	updatedModel, _ := model.Update(mfaRequiredMsg{profile: "admin", region: "eu-west-2"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenMFAInput {
+		t.Fatalf("expected the MFA prompt, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, cmd := model.Update(authProgressMsg{text: "Verifying MFA code for admin"})
+	model = updatedModel.(Model)
+	if model.statusMessage != "Verifying MFA code for admin" || cmd != nil {
+		t.Fatalf("expected auth progress in the status line, got %q", model.statusMessage)
+	}
+
+	rejected := &auth.StepError{Step: auth.StepMFA, Err: errors.New("invalid MFA code")}
+	updatedModel, _ = model.Update(clientChangedMsg{profile: "admin", region: "eu-west-2", err: rejected})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenMFAInput {
+		t.Fatal("expected a rejected code to keep the MFA prompt open")
+	}
+
+	roleErr := &auth.StepError{Step: auth.StepRole, Err: errors.New("access denied")}
+	updatedModel, _ = model.Update(clientChangedMsg{profile: "admin", region: "eu-west-2", err: roleErr})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretList || model.screen != nil {
+		t.Fatal("expected a failure after the MFA step to close the prompt")
+	}
+}
+
+// twoPageSource serves two pages of secrets for paging tests
+type twoPageSource struct{}
+
+func (twoPageSource) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	if nextToken == nil {
+		token := "page-2"
+		return []models.Secret{{Name: "alpha"}}, &token, nil
+	}
+	return []models.Secret{{Name: "beta"}}, nil, nil
+}
+
+func (twoPageSource) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	return nil, nil
+}
+
+func TestSecretListPagesThroughInventory(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.inventory = inventory.New(twoPageSource{}, 1)
+
+	updatedModel, _ := model.Update(loadSecrets(context.Background(), model.inventory, 0)())
+	model = updatedModel.(Model)
+	if !model.hasNextPage() {
+		t.Fatal("expected a next page after the first")
+	}
+
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("n"))
+	updatedModel, _ = updatedModel.(Model).Update(cmd())
+	model = updatedModel.(Model)
+	if model.currentPage != 1 || model.grid.SelectedSecret().Name != "beta" {
+		t.Fatalf("expected n to load the second page, got page %d", model.currentPage+1)
+	}
+
+	updatedModel, cmd = model.handleSecretListKeys(keyRunes("b"))
+	model = updatedModel.(Model)
+	if cmd != nil || model.currentPage != 0 || model.grid.SelectedSecret().Name != "alpha" {
+		t.Fatal("expected b to show the cached first page without loading")
+	}
+
+	// A result for an inventory that has been replaced is ignored
+	stale := secretsLoadedMsg{inventory: inventory.New(twoPageSource{}, 1), secrets: []models.Secret{{Name: "stale"}}}
+	updatedModel, _ = model.Update(stale)
+	model = updatedModel.(Model)
+	if model.grid.SelectedSecret().Name != "alpha" {
+		t.Fatal("expected a stale page to be ignored")
+	}
+}
+
+func TestCachedSecretListShownUntilAWSAnswers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", "")
+	if err := config.SaveCachedSecretList("default", "eu-west-2", []models.Secret{{Name: "cached"}}, time.Hour); err != nil {
+		t.Fatalf("SaveCachedSecretList returned error: %v", err)
+	}
+
+	model := NewModel("default", "eu-west-2")
+	updatedModel, _ := model.Update(loadCachedSecrets("default", "eu-west-2", time.Hour)())
+	model = updatedModel.(Model)
+	if model.grid.SelectedSecret().Name != "cached" || !strings.Contains(model.viewHeader(), "Cached from") {
+		t.Fatal("expected the cached list to be shown and marked as cached")
+	}
+
+	model.inventory = inventory.New(twoPageSource{}, 1)
+	updatedModel, _ = model.Update(loadSecrets(context.Background(), model.inventory, 0)())
+	model = updatedModel.(Model)
+	if model.grid.SelectedSecret().Name != "alpha" || strings.Contains(model.viewHeader(), "Cached from") {
+		t.Fatal("expected the first page from AWS to replace the cached list")
+	}
+
+	// A cached list read after AWS answered is ignored
+	updatedModel, _ = model.Update(loadCachedSecrets("default", "eu-west-2", time.Hour)())
+	model = updatedModel.(Model)
+	if model.grid.SelectedSecret().Name != "alpha" {
+		t.Fatal("expected a late cached list to be ignored")
+	}
+}
+
+func TestReportKeyExportsEverySecret(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("R"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenExportReport {
+		t.Fatalf("expected the report prompt, got screen %v", model.currentScreen)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	updatedModel, cmd := model.Update(pathEnteredMsg{path: path})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || !strings.Contains(model.statusMessage, "report of 75 secrets") {
+		t.Fatalf("expected a report of every secret, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the report to be written: %v", err)
+	}
+	if !strings.Contains(string(data), `"arn"`) || strings.Contains(string(data), "sk_demo_") {
+		t.Fatal("expected a JSON report of metadata without secret values")
+	}
+}
+
+// taggingStore is a demo store that accepts tags, except on one secret
+type taggingStore struct {
+	*demo.Store
+	failOn string
+	tagged []string
+}
+
+func (s *taggingStore) TagSecret(ctx context.Context, secretName, key, value string) error {
+	if secretName == s.failOn {
+		return fmt.Errorf("failed to tag secret: AccessDeniedException: not allowed")
+	}
+	s.tagged = append(s.tagged, secretName+":"+key+"="+value)
+	return nil
+}
+
+func TestBulkTagReportsEachSecret(t *testing.T) {
+	store := &taggingStore{Store: demo.NewStore(), failOn: "prod/api/config"}
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store.Store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	secrets, _ := store.FindSecrets(context.Background(), "prod/api/")
+	model.showSecrets(secrets)
+	model.grid.SetSortOrder(inventory.SortNameAsc)
+
+	// Mark the first two secrets: prod/api/api-key and prod/api/config
+	for _, key := range []string{"m", "l", "m"} {
+		updatedModel, _ := model.handleSecretListKeys(keyRunes(key))
+		model = updatedModel.(Model)
+	}
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("B"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBulkActions {
+		t.Fatalf("expected the bulk actions menu, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, _ = model.Update(actionChosenMsg{key: "t"})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.Update(textEnteredMsg{value: "team"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBulkTag {
+		t.Fatal("expected a tag without a value to be rejected")
+	}
+	updatedModel, _ = model.Update(textEnteredMsg{value: "team=payments"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBulkConfirm || !strings.Contains(model.screen.View(), "prod/api/config") {
+		t.Fatal("expected the secrets to be listed for confirmation")
+	}
+	if len(store.tagged) != 0 {
+		t.Fatal("expected nothing to be tagged before confirming")
+	}
+
+	updatedModel, cmd := model.Update(keyRunes("y"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	msg := cmd()
+	for {
+		update, ok := msg.(loadProgressMsg)
+		if !ok {
+			break
+		}
+		msg = update.next()
+	}
+	updatedModel, _ = model.Update(msg)
+	model = updatedModel.(Model)
+
+	if model.currentScreen != ScreenBulkResults || !strings.Contains(model.screen.View(), "1 succeeded, 1 did not") {
+		t.Fatalf("expected a summary of the results, got screen %v", model.currentScreen)
+	}
+	if len(store.tagged) != 1 || store.tagged[0] != "prod/api/api-key:team=payments" {
+		t.Fatalf("expected the other secret to be tagged, got %q", store.tagged)
+	}
+	if marked := model.grid.MarkedNames(); len(marked) != 1 || marked[0] != "prod/api/config" {
+		t.Fatalf("expected only the failed secret to stay marked, got %q", marked)
+	}
+
+	updatedModel, cmd = model.Update(keyRunes("q"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || !model.loading || cmd == nil {
+		t.Fatal("expected closing the results to reload the list")
+	}
+}
+
+func TestBulkDeleteIsConfirmedByTyping(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.bulkOp = bulkOperation{names: []string{"prod/api/api-key", "prod/api/config"}}
+
+	updatedModel, _ := model.chooseBulkAction("d")
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBulkConfirm || !strings.Contains(model.screen.View(), "delete 2 secrets") {
+		t.Fatalf("expected to be asked to type the confirmation, got screen %v", model.currentScreen)
+	}
+
+	model = typeName(model, "delete 2 secret")
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd != nil {
+		t.Fatal("expected a mistyped confirmation to be refused")
+	}
+	model = typeName(model, "delete 2 secrets")
+	_, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected the typed confirmation to be accepted")
+	}
+	if _, ok := cmd().(confirmedMsg); !ok {
+		t.Fatal("expected the deletion to be confirmed")
+	}
+}
+
+func TestHeaderShowsCallerIdentity(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel("dev", "eu-west-1").WithDemo(store)
+	model.width = 100
+	model.awsClient = store
+	identity := &models.CallerIdentity{
+		Account: "123456789012",
+		ARN:     "arn:aws:sts::123456789012:assumed-role/dev/alice",
+		UserID:  "AROAEXAMPLE:alice",
+	}
+
+	updatedModel, _ := model.Update(identityLoadedMsg{client: demo.NewStore(), identity: identity})
+	model = updatedModel.(Model)
+	if model.identity != nil {
+		t.Fatal("expected an identity for a previous client to be ignored")
+	}
+
+	updatedModel, _ = model.Update(identityLoadedMsg{client: store, identity: identity})
+	model = updatedModel.(Model)
+	header := model.viewHeader()
+	if !strings.Contains(header, "Account: 123456789012") || !strings.Contains(header, "Identity: arn:aws:sts::123456789012:assumed-role/dev/alice (AROAEXAMPLE:alice)") {
+		t.Fatalf("expected the account and identity in the header, got %q", header)
+	}
+
+	updatedModel, _ = model.Update(clientChangedMsg{client: demo.NewStore(), profile: "prod", region: "eu-west-1"})
+	if updatedModel.(Model).identity != nil {
+		t.Fatal("expected the identity to be cleared when the client changes")
+	}
+}
+
+func TestRegionSelectorListsAccountRegions(t *testing.T) {
+	model := NewModel("dev", "eu-west-1")
+	model.loading = false
+	updatedModel, cmd := pressRegionKey(model)
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenRegionSelector || cmd != nil {
+		t.Fatal("expected the common regions straight away without a client")
+	}
+	model.closeScreen()
+
+	store := demo.NewStore()
+	model = model.WithDemo(store)
+	model.awsClient = store
+	updatedModel, cmd = pressRegionKey(model)
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenRegionSelector || model.loading {
+		t.Fatal("expected the region selector once the regions are listed")
+	}
+	marked := 0
+	for _, region := range model.regions {
+		if region.HasSecrets {
+			marked++
+		}
+	}
+	if marked != 1 {
+		t.Fatalf("expected only the demo region to have secrets, got %+v", model.regions)
+	}
+
+	model.closeScreen()
+	updatedModel, cmd = pressRegionKey(model)
+	if updatedModel.(Model).currentScreen != ScreenRegionSelector || cmd != nil {
+		t.Fatal("expected the listed regions to be reused")
+	}
+	updatedModel, _ = model.Update(regionsLoadedMsg{client: demo.NewStore(), regions: commonRegions()})
+	if updatedModel.(Model).currentScreen == ScreenRegionSelector {
+		t.Fatal("expected regions listed for a previous client to be ignored")
+	}
+}
+
+func TestStartupPickerSignsInWithChosenProfile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	if err := os.WriteFile(configPath, []byte("[profile dev]\nregion = eu-west-1\n\n[profile prod]\nregion = us-east-2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+
+	store := demo.NewStore()
+	model := NewModel("default", "").WithDemo(store).WithStartupPicker([]string{"dev", "prod"})
+	if model.currentScreen != ScreenSetup || model.loading {
+		t.Fatal("expected to start on the picker without signing in")
+	}
+
+	// prod, then on to the regions with prod's own region selected
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	updatedModel, _ = updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updatedModel, cmd := updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || model.currentProfile != "prod" || model.currentRegion != "us-east-2" || cmd == nil {
+		t.Fatalf("expected to sign in to prod in us-east-2, got %s in %s", model.currentProfile, model.currentRegion)
+	}
+
+	model = NewModel("default", "eu-west-2").WithDemo(store).WithStartupPicker([]string{"dev", "prod"})
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretList || model.currentProfile != "default" || !model.loading {
+		t.Fatal("expected skipping the picker to sign in with the resolved profile")
+	}
+}
+
+func TestSwitchingProfileRestoresItsRegion(t *testing.T) {
+	model := NewModel("dev", "eu-west-1").WithDemo(demo.NewStore())
+	model.profileRegions = map[string]string{"prod": "us-east-1"}
+
+	updatedModel, cmd := model.Update(profileSelectedMsg{profile: "prod"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentProfile != "prod" || model.currentRegion != "us-east-1" {
+		t.Fatalf("expected prod in its remembered region, got %s in %s", model.currentProfile, model.currentRegion)
+	}
+
+	updatedModel, cmd = model.Update(profileSelectedMsg{profile: "staging"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentProfile != "staging" || model.currentRegion != "us-east-1" {
+		t.Fatalf("expected a profile without a remembered region to keep the current one, got %s in %s", model.currentProfile, model.currentRegion)
+	}
+}
+
+func TestWorkspacesSaveAndSwitch(t *testing.T) {
+	model := NewModel("dev", "eu-west-1").WithDemo(demo.NewStore()).WithFilter("payments/")
+	model.loading = false
+
+	updatedModel, _ := model.Update(keyRunes("W"))
+	updatedModel, _ = updatedModel.(Model).Update(actionChosenMsg{key: saveWorkspaceKey})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenWorkspaceName {
+		t.Fatal("expected a prompt for the workspace name")
+	}
+	updatedModel, _ = model.Update(textEnteredMsg{value: " dev-payments "})
+	model = updatedModel.(Model)
+	want := config.Workspace{Profile: "dev", Region: "eu-west-1", Filter: "payments/"}
+	if model.currentScreen != ScreenSecretList || model.workspaces["dev-payments"] != want {
+		t.Fatalf("expected the current profile, region and filter to be saved, got %+v", model.workspaces)
+	}
+
+	model.workspaces["prod-eu"] = config.Workspace{Profile: "prod", Region: "eu-west-2", Filter: "orders/"}
+	model.grid.SetFilter("")
+	updatedModel, _ = model.Update(keyRunes("W"))
+	// Listed by name: dev-payments is 1, prod-eu is 2
+	updatedModel, cmd := updatedModel.(Model).Update(actionChosenMsg{key: "2"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentProfile != "prod" || model.currentRegion != "eu-west-2" || model.grid.GetFilterQuery() != "orders/" {
+		t.Fatalf("expected to switch to prod-eu, got %s in %s filtered by %q", model.currentProfile, model.currentRegion, model.grid.GetFilterQuery())
+	}
+}
+
+func TestBackendPickerSwitchesToAKeyVault(t *testing.T) {
+	restore := openVault
+	defer func() { openVault = restore }()
+	openVault = func(vault string) (SecretStore, string, error) {
+		if vault != "myvault" {
+			return nil, "", fmt.Errorf("no key vault named %s", vault)
+		}
+		return demo.NewStore(), "myvault", nil
+	}
+
+	model := NewModel("dev", "eu-west-1").WithDryRun()
+	model.loading = false
+	updatedModel, _ := model.Update(keyRunes("V"))
+	updatedModel, _ = updatedModel.(Model).Update(actionChosenMsg{key: backendAzure})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBackendVault {
+		t.Fatal("expected a prompt for the vault")
+	}
+
+	updatedModel, _ = model.Update(textEnteredMsg{value: "othervault"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenBackendVault || !model.awsBackend() {
+		t.Fatal("expected to stay on AWS and at the prompt when the vault can't be opened")
+	}
+
+	updatedModel, cmd := model.Update(textEnteredMsg{value: "myvault"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.backendName != azure.Name || model.currentProfile != "myvault" || model.currentRegion != "" {
+		t.Fatalf("expected to browse myvault, got %s %s in %q", model.backendName, model.currentProfile, model.currentRegion)
+	}
+	if model.dryRun != nil {
+		t.Fatal("expected dry-run mode to end, as only Secrets Manager can rehearse writes")
+	}
+	if model.awsProfile != "dev" || model.awsRegion != "eu-west-1" {
+		t.Fatalf("expected the AWS profile and region to be kept to return to, got %s in %s", model.awsProfile, model.awsRegion)
+	}
+}
+
+func TestDemoModeRunsWithoutAWS(t *testing.T) {
+	model := NewModel(demo.Profile, demo.Region).WithDemo(demo.NewStore())
+
+	model, cmd := deliver(t, model, model.initAWSClient(model.currentProfile, model.currentRegion))
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatal("expected the secrets and the caller identity to load together")
+	}
+	model, _ = deliver(t, model, batch[0])
+	model, _ = deliver(t, model, batch[1])
+	if model.identity != nil || strings.Contains(model.viewHeader(), "Account:") {
+		t.Fatal("expected no account for demo data")
+	}
+	if model.errorMessage != "" || len(model.secrets) != int(inventory.DefaultPageSize) || !model.hasNextPage() {
+		t.Fatalf("expected a full first page of sample secrets, got %d (error %q)", len(model.secrets), model.errorMessage)
+	}
+	if !strings.Contains(model.viewHeader(), "Demo data") {
+		t.Fatal("expected the header to say the data is not real")
+	}
+
+	secret := model.grid.SelectedSecret()
+	updatedModel, _ := model.Update(loadSecretValue(context.Background(), model.awsClient, secret.Name)())
+	model = updatedModel.(Model)
+	if model.secretValue == "" && model.secretBinary == nil {
+		t.Fatalf("expected the value of %s to load, got error %q", secret.Name, model.errorMessage)
+	}
+}
+
+// fakeSecretsAPI stands in for the Secrets Manager SDK client behind an
+// aws.Client
+type fakeSecretsAPI struct {
+	value string
+}
+
+func (f fakeSecretsAPI) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	return &secretsmanager.ListSecretsOutput{}, nil
+}
+
+func (f fakeSecretsAPI) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: &f.value}, nil
+}
+
+func (f fakeSecretsAPI) BatchGetSecretValue(ctx context.Context, params *secretsmanager.BatchGetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.BatchGetSecretValueOutput, error) {
+	output := &secretsmanager.BatchGetSecretValueOutput{}
+	for _, id := range params.SecretIdList {
+		output.SecretValues = append(output.SecretValues, smtypes.SecretValueEntry{Name: &id, SecretString: &f.value})
+	}
+	return output, nil
+}
+
+func (f fakeSecretsAPI) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not allowed"}
+}
+
+func (f fakeSecretsAPI) TagResource(ctx context.Context, params *secretsmanager.TagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.TagResourceOutput, error) {
+	return &secretsmanager.TagResourceOutput{}, nil
+}
+
+func (f fakeSecretsAPI) UntagResource(ctx context.Context, params *secretsmanager.UntagResourceInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UntagResourceOutput, error) {
+	return &secretsmanager.UntagResourceOutput{}, nil
+}
+
+func (f fakeSecretsAPI) DeleteSecret(ctx context.Context, params *secretsmanager.DeleteSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DeleteSecretOutput, error) {
+	return &secretsmanager.DeleteSecretOutput{}, nil
+}
+
+func (f fakeSecretsAPI) RestoreSecret(ctx context.Context, params *secretsmanager.RestoreSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error) {
+	return &secretsmanager.RestoreSecretOutput{}, nil
+}
+
+func (f fakeSecretsAPI) UpdateSecretVersionStage(ctx context.Context, params *secretsmanager.UpdateSecretVersionStageInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretVersionStageOutput, error) {
+	return &secretsmanager.UpdateSecretVersionStageOutput{}, nil
+}
+
+func (f fakeSecretsAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	return &secretsmanager.CreateSecretOutput{}, nil
+}
+
+func (f fakeSecretsAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func (f fakeSecretsAPI) UpdateSecret(ctx context.Context, params *secretsmanager.UpdateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.UpdateSecretOutput, error) {
+	return &secretsmanager.UpdateSecretOutput{}, nil
+}
+
+func (f fakeSecretsAPI) RotateSecret(ctx context.Context, params *secretsmanager.RotateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.RotateSecretOutput, error) {
+	return &secretsmanager.RotateSecretOutput{}, nil
+}
+
+func (f fakeSecretsAPI) GetResourcePolicy(ctx context.Context, params *secretsmanager.GetResourcePolicyInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetResourcePolicyOutput, error) {
+	return &secretsmanager.GetResourcePolicyOutput{}, nil
+}
+
+func TestSecretCommandsCallTheClient(t *testing.T) {
+	client := aws.NewClientWithAPI(fakeSecretsAPI{value: `{"password":"hunter2-fake"}`}, "default", "eu-west-2")
+
+	valueMsg := loadSecretValue(context.Background(), client, "app/db")().(secretValueLoadedMsg)
+	if valueMsg.err != nil || valueMsg.value != `{"password":"hunter2-fake"}` {
+		t.Fatalf("expected the value from the API, got %+v", valueMsg)
+	}
+
+	detailsMsg := loadSecretDetails(client, "app/db", time.Minute)().(secretDetailsLoadedMsg)
+	if detailsMsg.err == nil || !strings.Contains(detailsMsg.err.Error(), "AccessDeniedException") {
+		t.Fatalf("expected the API error, got %v", detailsMsg.err)
+	}
+}
+
+// existingSecretAPI is a Secrets Manager where every secret exists, managed
+// by owner when set, and which records the values written to it
+type existingSecretAPI struct {
+	fakeSecretsAPI
+	owner   string
+	written []string
+}
+
+func (f *existingSecretAPI) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	output := &secretsmanager.DescribeSecretOutput{Name: params.SecretId}
+	if f.owner != "" {
+		output.OwningService = &f.owner
+	}
+	return output, nil
+}
+
+func (f *existingSecretAPI) PutSecretValue(ctx context.Context, params *secretsmanager.PutSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.PutSecretValueOutput, error) {
+	f.written = append(f.written, *params.SecretId+"="+*params.SecretString)
+	return &secretsmanager.PutSecretValueOutput{}, nil
+}
+
+func TestCopyToRegionConfirmsOverwrite(t *testing.T) {
+	api := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: "hunter2-copy"}}
+	model := NewModel("default", "eu-west-2")
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	updatedModel, _ := model.handleSecretDetailKeys(keyRunes("C"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenCopyRegion {
+		t.Fatalf("expected the region selector, got screen %v", model.currentScreen)
+	}
+	updatedModel, cmd := model.Update(regionSelectedMsg{region: "eu-west-2"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretDetail || model.loading || !strings.Contains(model.statusMessage, "pick another region") {
+		t.Fatalf("expected the current region to be refused, got %q", model.statusMessage)
+	}
+
+	updatedModel, _ = model.handleSecretDetailKeys(keyRunes("C"))
+	model = updatedModel.(Model)
+	updatedModel, cmd = model.Update(regionSelectedMsg{region: "us-east-1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenCopyConfirm || len(api.written) != 0 {
+		t.Fatalf("expected to be asked before overwriting, got screen %v and writes %q", model.currentScreen, api.written)
+	}
+
+	// Overwriting is confirmed by typing the secret's name, not with y
+	updatedModel, _ = model.Update(keyRunes("y"))
+	updatedModel, cmd = updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	if cmd != nil || !strings.Contains(model.screen.View(), "doesn't match") {
+		t.Fatal("expected a wrong name to be refused")
+	}
+	model = typeName(model, "app/db")
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if model.currentScreen != ScreenSecretDetail || model.statusMessage != "Updated app/db in us-east-1" {
+		t.Fatalf("expected the copy to be reported, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+	if len(api.written) != 1 || api.written[0] != "app/db=hunter2-copy" {
+		t.Fatalf("expected the value to be written once, got %q", api.written)
+	}
+}
+
+func TestDryRunCopyLeavesNothingToUndo(t *testing.T) {
+	api := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: "hunter2-copy"}}
+	model := NewModel("default", "eu-west-2").WithDryRun()
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	updatedModel, _ := model.handleSecretDetailKeys(keyRunes("C"))
+	updatedModel, cmd := updatedModel.(Model).Update(regionSelectedMsg{region: "us-east-1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	model = typeName(model, "app/db")
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+
+	if len(api.written) != 0 {
+		t.Fatalf("expected nothing to be written, got %q", api.written)
+	}
+	if len(model.undo.entries) != 0 {
+		t.Fatalf("expected nothing to undo after a dry run, got %+v", model.undo.entries)
+	}
+	if last := model.ops.entries[len(model.ops.entries)-1].text; !strings.HasPrefix(last, "Dry run: would write a new value to app/db") {
+		t.Fatalf("expected the skipped write in the operations pane, got %q", last)
+	}
+}
+
+func TestCopyRefusesToOverwriteManagedSecret(t *testing.T) {
+	api := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: "hunter2-copy"}, owner: "rds"}
+	model := NewModel("default", "eu-west-2")
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	updatedModel, _ := model.handleSecretDetailKeys(keyRunes("C"))
+	updatedModel, cmd := updatedModel.(Model).Update(regionSelectedMsg{region: "us-east-1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretDetail || !strings.Contains(model.errorMessage, "managed by RDS") {
+		t.Fatalf("expected the overwrite to be refused, got screen %v and error %q", model.currentScreen, model.errorMessage)
+	}
+	if model.copyTo.client != nil || len(api.written) != 0 {
+		t.Fatal("expected nothing to be written")
+	}
+}
+
+func TestCopyRefusesValueFailingSchema(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "database.json")
+	if err := os.WriteFile(schemaPath, []byte(`{"type": "object", "required": ["password"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	api := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: `{"username": "app"}`}}
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{
+		ConfirmWithKey: true,
+		Schemas:        []secretvalue.SchemaRule{{Pattern: "app/*", Schema: schemaPath}},
+	})
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	updatedModel, _ := model.handleSecretDetailKeys(keyRunes("C"))
+	updatedModel, cmd := updatedModel.(Model).Update(regionSelectedMsg{region: "us-east-1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	updatedModel, cmd = model.Update(keyRunes("y"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if !strings.Contains(model.errorMessage, "missing properties: 'password'") {
+		t.Fatalf("expected the missing password to be reported, got %q", model.errorMessage)
+	}
+	if len(api.written) != 0 {
+		t.Fatalf("expected nothing to be written, got %q", api.written)
+	}
+}
+
+func TestBulkDeleteRefusesManagedSecrets(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.loading = false
+	model.width, model.height = 120, 40
+	model.grid.SetSize(120, 30)
+	model.showSecrets([]models.Secret{{Name: "app/db", OwningService: "rds"}, {Name: "app/api-key"}})
+	if view := model.grid.View(); !strings.Contains(view, "managed by RDS") {
+		t.Fatalf("expected the managed secret to be badged, got %q", view)
+	}
+
+	model.grid.ToggleMark()
+	model.grid.Update(tea.KeyMsg{Type: tea.KeyRight})
+	model.grid.ToggleMark()
+	model.bulkOp = bulkOperation{names: model.exportNames()}
+	updatedModel, _ := model.chooseBulkAction("d")
+	model = updatedModel.(Model)
+	if model.currentScreen == ScreenBulkConfirm || !strings.Contains(model.errorMessage, "app/db is managed by RDS") {
+		t.Fatalf("expected the deletion to be refused, got screen %v and error %q", model.currentScreen, model.errorMessage)
+	}
+}
+
+func TestChangeKMSKey(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updatedModel.(Model)
+	model.showSecrets([]models.Secret{{Name: "dev/api/config"}, {Name: "prod/reports/database", OwningService: "rds"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	updatedModel, cmd := model.handleSecretDetailKeys(keyRunes("E"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenKMSKeyPicker || !strings.Contains(model.screen.View(), "alias/demo-secrets") {
+		t.Fatalf("expected the KMS key picker, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, _ = model.Update(secretPickedMsg{name: "alias/aws/secretsmanager"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretDetail || !strings.Contains(model.statusMessage, "already encrypted") {
+		t.Fatalf("expected the current key to be refused, got %q", model.statusMessage)
+	}
+
+	updatedModel, cmd = model.handleSecretDetailKeys(keyRunes("E"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	updatedModel, _ = model.Update(secretPickedMsg{name: "alias/demo-secrets"})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenKMSKeyConfirm || !strings.Contains(model.screen.View(), "instead of aws/secretsmanager") {
+		t.Fatalf("expected to be asked to confirm, got screen %v", model.currentScreen)
+	}
+	updatedModel, cmd = model.Update(keyRunes("y"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if !strings.Contains(model.errorMessage, "read-only") || model.kmsChange.target.Alias != "" {
+		t.Fatalf("expected the demo store to refuse the change, got %q", model.errorMessage)
+	}
+
+	model.grid.Update(tea.KeyMsg{Type: tea.KeyRight})
+	model.errorMessage = ""
+	updatedModel, cmd = model.handleSecretDetailKeys(keyRunes("E"))
+	model = updatedModel.(Model)
+	if cmd != nil || !strings.Contains(model.errorMessage, "managed by RDS") {
+		t.Fatalf("expected a managed secret's key to be left alone, got %q", model.errorMessage)
+	}
+}
+
+func TestCopyToProfileKeepsCurrentClient(t *testing.T) {
+	source := aws.NewClientWithAPI(&existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: "hunter2-copy"}}, "dev", "eu-west-2")
+	target := &existingSecretAPI{}
+	model := NewModel("dev", "eu-west-2").WithConfig(&config.Config{ConfirmWithKey: true})
+	model.awsClient = source
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+
+	// A rejected MFA code keeps the prompt open
+	updatedModel, _ := model.Update(secondMFARequiredMsg{profile: "prod", region: "eu-west-2"})
+	model = updatedModel.(Model)
+	mfaErr := &auth.StepError{Step: auth.StepMFA, Err: errors.New("invalid MFA code")}
+	updatedModel, _ = model.Update(secondAuthMsg{profile: "prod", err: mfaErr})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecondMFA {
+		t.Fatalf("expected the MFA prompt to stay open, got screen %v", model.currentScreen)
+	}
+
+	client := aws.NewClientWithAPI(target, "prod", "eu-west-2")
+	updatedModel, cmd := model.Update(secondAuthMsg{client: client, profile: "prod"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenCopyConfirm {
+		t.Fatalf("expected to be asked before overwriting, got screen %v", model.currentScreen)
+	}
+
+	// With confirm_with_key, y confirms
+	updatedModel, cmd = model.Update(keyRunes("y"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if model.statusMessage != "Updated app/db in profile prod" || len(target.written) != 1 {
+		t.Fatalf("expected the secret to be written to the other profile, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+	if model.awsClient != SecretStore(source) || model.currentProfile != "dev" || model.copyTo.client != nil {
+		t.Fatal("expected the current client to stay active and the other profile's client to be dropped")
+	}
+}
+
+// listingSecretAPI is a Secrets Manager holding the named secrets, all with
+// the same value
+type listingSecretAPI struct {
+	fakeSecretsAPI
+	names []string
+}
+
+func (f listingSecretAPI) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	output := &secretsmanager.ListSecretsOutput{}
+	for _, name := range f.names {
+		output.SecretList = append(output.SecretList, smtypes.SecretListEntry{Name: &name})
+	}
+	return output, nil
+}
+
+func TestCompareWithCounterpartInOtherProfile(t *testing.T) {
+	source := aws.NewClientWithAPI(fakeSecretsAPI{value: `{"host":"db.staging","port":5432,"debug":true}`}, "staging", "eu-west-2")
+	model := NewModel("staging", "eu-west-2")
+	model.awsClient = source
+	model.showSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.secondPurpose = secondForCompare
+
+	other := listingSecretAPI{
+		fakeSecretsAPI: fakeSecretsAPI{value: `{"host":"db.prod","port":5432}`},
+		names:          []string{"app/database", "app/api"},
+	}
+	client := aws.NewClientWithAPI(other, "prod", "eu-west-2")
+	updatedModel, cmd := model.Update(secondAuthMsg{client: client, profile: "prod", region: "eu-west-2"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenComparePick || !strings.Contains(model.statusMessage, "pick its counterpart") {
+		t.Fatalf("expected to pick a counterpart when the name doesn't match, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, cmd = model.Update(secretPickedMsg{name: "app/database"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenCompare {
+		t.Fatalf("expected the comparison, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+	}
+	view := model.screen.View()
+	if !strings.Contains(view, "1 differ, 1 only in A, 0 only in B, 1 the same") || strings.Contains(view, "db.prod") {
+		t.Fatalf("expected a masked key-level diff, got:\n%s", view)
+	}
+
+	updatedModel, _ = model.Update(keyRunes("r"))
+	model = updatedModel.(Model)
+	if view := model.screen.View(); !strings.Contains(view, "db.staging") || !strings.Contains(view, "db.prod") {
+		t.Fatalf("expected both values once revealed, got:\n%s", view)
+	}
+
+	updatedModel, cmd = model.Update(keyRunes("q"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretDetail || model.compare.client != nil || model.awsClient != SecretStore(source) {
+		t.Fatal("expected closing the comparison to drop the other profile's client")
+	}
+}
+
+func TestCompareWithTheCluster(t *testing.T) {
+	source := aws.NewClientWithAPI(fakeSecretsAPI{value: `{"password":"hunter2"}`}, "prod", "eu-west-2")
+	model := NewModel("prod", "eu-west-2")
+	model.awsClient = source
+	model.showSecrets([]models.Secret{{Name: "app-db"}})
+	model.currentScreen = ScreenSecretDetail
+
+	cluster := aws.NewClientWithAPI(listingSecretAPI{
+		fakeSecretsAPI: fakeSecretsAPI{value: `{"password":"hunter3"}`},
+		names:          []string{"app-db", "other"},
+	}, "prod-cluster", "payments")
+	restore := newCluster
+	newCluster = func() compareSource { return cluster }
+	defer func() { newCluster = restore }()
+
+	updatedModel, cmd := model.Update(keyRunes("M"))
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if model.currentScreen != ScreenCompare {
+		t.Fatalf("expected the same-named Secret compared, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+	}
+	if view := model.screen.View(); !strings.Contains(view, "1 differ") || !strings.Contains(view, "the cluster") {
+		t.Fatalf("expected a diff against the cluster, got:\n%s", view)
+	}
+}
+
+func TestRetriesShowInStatusBar(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+
+	updatedModel, cmd := model.Update(retryMsg{event: aws.RetryEvent{Attempt: 1, Delay: 1500 * time.Millisecond, Throttled: true}})
+	model = updatedModel.(Model)
+	if model.statusMessage != "Throttled by AWS, retrying in 1.5s (attempt 2)…" || cmd == nil {
+		t.Fatalf("expected a throttling status that clears itself, got %q", model.statusMessage)
+	}
+}
+
+func TestConfiguredKeyBindings(t *testing.T) {
+	cfg := &config.Config{KeyBindings: map[string]string{"workspaces": "ctrl+w", "reveal": "v", "launch": "z"}}
+	model := NewModel("dev", "eu-west-1").WithDemo(demo.NewStore()).WithConfig(cfg)
+	model.loading = false
+
+	for _, want := range []string{`"v" is already in use`, `unknown action "launch"`} {
+		if !strings.Contains(model.configWarning, want) {
+			t.Fatalf("expected the warning to mention %s, got %q", want, model.configWarning)
+		}
+	}
+
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	if updatedModel.(Model).currentScreen != ScreenWorkspaces {
+		t.Fatal("expected ctrl+w to open the workspaces like W")
+	}
+
+	updatedModel, _ = model.Update(keyRunes("/"))
+	updatedModel, _ = updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyCtrlW})
+	if updatedModel.(Model).currentScreen != ScreenSecretList {
+		t.Fatal("expected the extra key to be left alone while filtering")
+	}
+}
+
+func TestMessagesAreLoggedWithoutTypedText(t *testing.T) {
+	var out bytes.Buffer
+	logging.EnableDebug(&out)
+	defer logging.EnableDebug(nil)
+
+	model := NewModel("dev", "eu-west-1").WithDemo(demo.NewStore())
+	model.loading = false
+	updatedModel, _ := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("123456")})
+	updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if strings.Contains(out.String(), "123456") {
+		t.Fatalf("expected typed text to be left out of the debug log:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "type=tea.KeyMsg key=enter") {
+		t.Fatalf("expected key messages in the debug log:\n%s", out.String())
+	}
+}
+
+func TestErrorPanelExplainsAccessDenied(t *testing.T) {
+	model := NewModel("dev", "eu-west-1").WithDemo(demo.NewStore())
+	model.loading = false
+	model.width = 120
+	model.height = 30
+
+	denied := &smithy.OperationError{
+		ServiceID:     "Secrets Manager",
+		OperationName: "ListSecrets",
+		Err:           &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"},
+	}
+	updatedModel, _ := model.Update(secretsLoadedMsg{err: denied})
+	model = updatedModel.(Model)
+	footer := model.viewFooter()
+	for _, want := range []string{"Cause:", "Profile dev isn't allowed to ListSecrets", "Fix:", "Press p"} {
+		if !strings.Contains(footer, want) {
+			t.Fatalf("expected %q in the error panel:\n%s", want, footer)
+		}
+	}
+
+	// A later error without a hint mustn't show the old one
+	model.errorMessage = "Failed to save file"
+	if footer := model.viewFooter(); strings.Contains(footer, "Cause:") {
+		t.Fatalf("expected the hint to be dropped with its error:\n%s", footer)
+	}
+}
+
+func TestExpiredSessionSignsInAgainAndRetries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+
+	store := demo.NewStore()
+	model := NewModel("dev", "eu-west-1")
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+
+	expired := &smithy.OperationError{
+		ServiceID:     "Secrets Manager",
+		OperationName: "ListSecrets",
+		Err:           &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "The security token included in the request is expired"},
+	}
+	updatedModel, cmd := model.Update(secretsLoadedMsg{inventory: model.inventory, err: expired})
+	model = updatedModel.(Model)
+	if cmd == nil || len(model.reauth.retries) != 1 || model.errorMessage != "" {
+		t.Fatalf("expected signing in again instead of an error, got %q", model.errorMessage)
+	}
+	if !strings.Contains(model.statusMessage, "signing in again") {
+		t.Fatalf("expected the re-auth in the status bar, got %q", model.statusMessage)
+	}
+
+	// The sign-in succeeds, and the list is loaded again with the new client
+	inv := model.inventory
+	updatedModel, cmd = model.Update(clientChangedMsg{client: store, profile: "dev", region: "eu-west-1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.inventory != inv || len(model.secrets) == 0 || model.errorMessage != "" {
+		t.Fatalf("expected the secrets to load after signing in again, got %d (error %q)", len(model.secrets), model.errorMessage)
+	}
+
+	// A session rejected straight after signing in is reported, not retried
+	updatedModel, _ = model.Update(secretsLoadedMsg{inventory: model.inventory, err: expired})
+	model = updatedModel.(Model)
+	if len(model.reauth.retries) != 0 || !strings.Contains(model.errorMessage, "Failed to load secrets") {
+		t.Fatalf("expected the second expired session to be reported, got %q", model.errorMessage)
+	}
+}
+
+func TestValueHistoryBadgesChangedSecrets(t *testing.T) {
+	keyring.MockInit()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	arn := "arn:aws:secretsmanager:eu-west-2:123456789012:secret:app/db-AbCdEf"
+	viewed := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	changed := viewed.AddDate(0, 1, 0)
+	if _, _, err := config.RecordValueView(arn, config.HashValue(models.SecretValue{String: "hunter2"}), viewed); err != nil {
+		t.Fatal(err)
+	}
+
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{TrackValueChanges: true})
+	model.loading = false
+	model.grid.SetSize(120, 30)
+	model.showSecrets([]models.Secret{{Name: "app/db", ARN: arn, LastChangedDate: &changed}})
+	model, _ = deliver(t, model, loadValueViews())
+	if view := model.grid.View(); !strings.Contains(view, "changed since viewed Mar 3") {
+		t.Fatalf("expected the changed secret to be badged, got %q", view)
+	}
+
+	updatedModel, cmd := model.Update(secretValueLoadedMsg{value: "hunter3"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.statusMessage != "Value changed since you last viewed it on March 3" {
+		t.Fatalf("expected the change to be reported, got %q", model.statusMessage)
+	}
+	if view := model.grid.View(); strings.Contains(view, "changed since viewed") {
+		t.Fatalf("expected viewing the value to clear the badge, got %q", view)
+	}
+}
+
+// changingSource lists whatever secrets it holds, which tests change
+// between refreshes
+type changingSource struct {
+	secrets *[]models.Secret
+}
+
+func (s changingSource) ListSecrets(ctx context.Context, maxResults int32, nextToken *string) ([]models.Secret, *string, error) {
+	return *s.secrets, nil, nil
+}
+
+func (changingSource) FindSecrets(ctx context.Context, prefix string) ([]models.Secret, error) {
+	return nil, nil
+}
+
+func TestWatchHighlightsNewAndChangedSecrets(t *testing.T) {
+	before := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	after := before.Add(time.Hour)
+	secrets := []models.Secret{
+		{Name: "app/db", LastChangedDate: &before},
+		{Name: "app/api", LastChangedDate: &before},
+	}
+
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{WatchInterval: "1m"})
+	model.inventory = inventory.New(changingSource{secrets: &secrets}, 0)
+	model.awsClient = demo.NewStore()
+	model.loading = false
+	model.grid.SetSize(120, 30)
+	model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+
+	updatedModel, _ := model.Update(keyRunes("w"))
+	model = updatedModel.(Model)
+	if !model.watch.on || model.watch.interval != time.Minute {
+		t.Fatalf("expected watch mode to be on every minute, got %+v", model.watch)
+	}
+	if !strings.Contains(model.viewHeader(), "Watching every 1m0s") {
+		t.Fatalf("expected the header to show watch mode, got %q", model.viewHeader())
+	}
+
+	secrets = []models.Secret{
+		{Name: "app/db", LastChangedDate: &after},
+		{Name: "app/api", LastChangedDate: &before},
+		{Name: "app/queue", LastChangedDate: &after},
+	}
+	model.loading = false
+	updatedModel, cmd := model.watchTick(watchTickMsg{seq: model.watch.seq})
+	model = updatedModel.(Model)
+	if cmd == nil || !model.loading {
+		t.Fatal("expected the tick to refresh the list")
+	}
+	model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+
+	view := model.grid.View()
+	if !strings.Contains(view, "★ new") || !strings.Contains(view, "★ changed") {
+		t.Fatalf("expected the new and changed secrets to be highlighted, got %q", view)
+	}
+	if model.statusMessage != "Since the last refresh: 1 new, 1 changed" {
+		t.Fatalf("unexpected status %q", model.statusMessage)
+	}
+
+	stale := watchTickMsg{seq: model.watch.seq}
+	updatedModel, _ = model.Update(keyRunes("w"))
+	model = updatedModel.(Model)
+	if model.watch.on || strings.Contains(model.grid.View(), "★") {
+		t.Fatal("expected turning watch mode off to clear the highlights")
+	}
+	if _, cmd := model.watchTick(stale); cmd != nil {
+		t.Fatal("expected a tick from before watch mode was turned off to be dropped")
+	}
+}
+
+func TestWatchNotifiesWhenFlaggedSecretRotates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	var notified []string
+	sendNotification = func(title, message string) error {
+		notified = append(notified, title+": "+message)
+		return nil
+	}
+	t.Cleanup(func() { sendNotification = notify.Send })
+
+	rotated := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	rotatedAgain := rotated.AddDate(0, 1, 0)
+	secrets := []models.Secret{
+		{Name: "app/db", LastRotatedDate: &rotated},
+		{Name: "app/api", LastRotatedDate: &rotated},
+	}
+
+	model := NewModel("default", "eu-west-2")
+	model.inventory = inventory.New(changingSource{secrets: &secrets}, 0)
+	model.awsClient = demo.NewStore()
+	model.loading = false
+	model.grid.SetSize(120, 30)
+	model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+
+	flagged := model.grid.SelectedSecret().Name
+	updatedModel, _ := model.Update(keyRunes("F"))
+	model = updatedModel.(Model)
+	if !strings.Contains(model.statusMessage, "press w to start watching") {
+		t.Fatalf("expected a hint to start watching, got %q", model.statusMessage)
+	}
+	if !strings.Contains(model.grid.View(), "⚑ notify on rotation") {
+		t.Fatal("expected the flagged secret to be badged")
+	}
+
+	model.watch.on = true
+	secrets = []models.Secret{
+		{Name: "app/db", LastRotatedDate: &rotatedAgain},
+		{Name: "app/api", LastRotatedDate: &rotatedAgain},
+	}
+	updatedModel, cmd := model.watchTick(watchTickMsg{seq: model.watch.seq})
+	model = updatedModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected the tick to refresh the list")
+	}
+	model, cmd = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+	if !strings.HasSuffix(model.statusMessage, ": "+flagged) {
+		t.Fatalf("expected a banner for the flagged secret, got %q", model.statusMessage)
+	}
+	// The notification is batched with saving the list cache
+	for _, cmd := range cmd().(tea.BatchMsg) {
+		if cmd != nil {
+			cmd()
+		}
+	}
+	if len(notified) != 1 || notified[0] != "Secret rotated: "+flagged {
+		t.Fatalf("expected one notification for the flagged secret, got %q", notified)
+	}
+}
+
+func TestGridShowsRecencyLegend(t *testing.T) {
+	now := time.Now()
+	model := NewModel("default", "eu-west-2")
+	model.grid.SetSize(120, 30)
+	model.showSecrets([]models.Secret{{Name: "app/db", LastChangedDate: &now}})
+
+	for _, layout := range []components.Layout{components.LayoutGrid, components.LayoutList} {
+		model.grid.SetLayout(layout)
+		view := model.grid.View()
+		if !strings.Contains(view, "Changed: ● today  ● this week  ● older") {
+			t.Fatalf("expected the recency legend in the %v layout, got %q", layout, view)
+		}
+	}
+}
+
+func TestSecretListJumpKeys(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.grid.SetLayout(components.LayoutList)
+	model.grid.SetSize(120, 40)
+	var secrets []models.Secret
+	for _, name := range []string{"app/api", "app/db", "billing/stripe", "dev/cache", "prod/api", "prod/db"} {
+		secrets = append(secrets, models.Secret{Name: name})
+	}
+	model.showSecrets(secrets)
+
+	press := func(keys ...string) string {
+		t.Helper()
+		for _, key := range keys {
+			updatedModel, _ := model.Update(keyMsgFor(key))
+			model = updatedModel.(Model)
+		}
+		return model.grid.SelectedSecret().Name
+	}
+
+	if got := press("G"); got != "prod/db" {
+		t.Fatalf("expected G to select the last secret, got %s", got)
+	}
+	if got := press("3", "G"); got != "billing/stripe" {
+		t.Fatalf("expected 3G to select the third secret, got %s", got)
+	}
+	if got := press("2", "j"); got != "prod/api" {
+		t.Fatalf("expected 2j to move down two, got %s", got)
+	}
+	if got := press("home"); got != "app/api" {
+		t.Fatalf("expected home to select the first secret, got %s", got)
+	}
+	// f matches path segments too, and wraps around
+	if got := press("f", "c"); got != "dev/cache" {
+		t.Fatalf("expected fc to jump to dev/cache, got %s", got)
+	}
+	if got := press("f", "d"); got != "prod/db" {
+		t.Fatalf("expected fd to jump to the next secret with a d segment, got %s", got)
+	}
+	if got := press("f", "a"); got != "app/api" {
+		t.Fatalf("expected fa to wrap around to app/api, got %s", got)
+	}
+	if got := press("G", "g", "g"); got != "app/api" {
+		t.Fatalf("expected gg to select the first secret, got %s", got)
+	}
+	if got := press("4", "g", "g"); got != "dev/cache" {
+		t.Fatalf("expected 4gg to select the fourth secret, got %s", got)
+	}
+
+	// A lone g switches region once its wait ends, unless a later key has
+	// been handled since
+	press("g")
+	pressed := model.jump.pendingG
+	if got := press("g"); got != "app/api" || model.currentScreen != ScreenSecretList {
+		t.Fatalf("expected gg not to switch region, got %s on screen %v", got, model.currentScreen)
+	}
+	updatedModel, _ := model.Update(pendingGTimeoutMsg{pressed: pressed})
+	if updatedModel.(Model).currentScreen != ScreenSecretList {
+		t.Fatal("expected the timeout of a finished gg to be ignored")
+	}
+	press("g")
+	updatedModel, _ = model.Update(pendingGTimeoutMsg{pressed: model.jump.pendingG})
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenRegionSelector {
+		t.Fatal("expected a lone g to switch region once its wait ends")
+	}
+	selector := model.screen.(regionScreen).selector
+	current := selector.SelectedRegion()
+	model.closeScreen()
+
+	// A key typed during the wait switches region at once and still counts,
+	// typed on the region selector
+	press("g", "j")
+	if model.currentScreen != ScreenRegionSelector || model.grid.SelectedSecret().Name != "app/api" {
+		t.Fatalf("expected g and another key to switch region, got %s on screen %v", model.grid.SelectedSecret().Name, model.currentScreen)
+	}
+	selector = model.screen.(regionScreen).selector
+	if selector.SelectedRegion() == current {
+		t.Fatalf("expected j to move down the region selector from %s", current)
+	}
+}
+
+func TestMouseSelectsOpensAndRunsHints(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.width, model.height = 120, 40
+	model.grid.SetLayout(components.LayoutList)
+	width, height := model.contentViewportSize()
+	model.grid.SetSize(width, height)
+	model.showSecrets([]models.Secret{{Name: "app/api"}, {Name: "app/db"}, {Name: "app/web"}})
+
+	// Find where things are drawn rather than assuming the layout
+	find := func(text string) (int, int) {
+		t.Helper()
+		for y, line := range strings.Split(model.View(), "\n") {
+			if x := strings.Index(line, text); x >= 0 {
+				return lipgloss.Width(line[:x]), y
+			}
+		}
+		t.Fatalf("%q isn't drawn", text)
+		return 0, 0
+	}
+	press := func(x, y int) {
+		t.Helper()
+		updatedModel, _ := model.Update(tea.MouseMsg{X: x, Y: y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+		model = updatedModel.(Model)
+	}
+
+	x, y := find("app/db")
+	press(x, y)
+	if got := model.grid.SelectedSecret().Name; got != "app/db" {
+		t.Fatalf("expected clicking app/db to select it, got %s", got)
+	}
+	press(x, y)
+	if model.currentScreen != ScreenSecretDetail || model.grid.SelectedSecret().Name != "app/db" {
+		t.Fatal("expected a double click to open app/db")
+	}
+
+	model.currentScreen = ScreenSecretList
+	model.lastClick = click{}
+	x, y = find("? more keys")
+	press(x, y)
+	if !model.fullHelp {
+		t.Fatal("expected clicking the help hint to expand the hints")
+	}
+	model.fullHelp = false
+	x, y = find("m mark")
+	press(x, y)
+	if marked := model.grid.MarkedNames(); len(marked) != 1 || marked[0] != "app/db" {
+		t.Fatalf("expected clicking the mark hint to mark app/db, got %q", marked)
+	}
+}
+
+func TestDetailPanelFollowsSelection(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	model, _ = deliver(t, updatedModel.(Model), loadSecrets(context.Background(), model.inventory, 0))
+	fullWidth, _ := model.contentViewportSize()
+
+	updatedModel, _ = model.Update(keyRunes("|"))
+	model = updatedModel.(Model)
+	first := model.grid.SelectedSecret().Name
+	if !model.splitActive() || model.split.shown != first {
+		t.Fatalf("expected the panel to follow %s, got %+v", first, model.split)
+	}
+	updatedModel, cmd := model.Update(panelFollowMsg{seq: model.split.seq})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.secretDetails == nil {
+		t.Fatalf("expected the panel to load the details of %s", first)
+	}
+	if view := model.View(); !strings.Contains(view, "Rotation:") || !strings.Contains(view, "enter: open to view the value") {
+		t.Fatalf("expected the panel beside the list, got %q", view)
+	}
+
+	updatedModel, _ = model.Update(keyRunes("l"))
+	model = updatedModel.(Model)
+	if second := model.grid.SelectedSecret().Name; second == first || model.split.shown != second || model.secretDetails != nil {
+		t.Fatalf("expected moving the cursor to follow the next secret, got %+v", model.split)
+	}
+	// A follow scheduled for the first secret is dropped
+	if _, cmd := model.loadPanelDetails(panelFollowMsg{seq: model.split.seq - 1}); cmd != nil {
+		t.Fatal("expected a stale follow to be dropped")
+	}
+
+	updatedModel, _ = model.Update(keyRunes(">"))
+	model = updatedModel.(Model)
+	if model.split.width != defaultPanelWidth-panelWidthStep {
+		t.Fatalf("expected > to narrow the panel, got %d%%", model.split.width)
+	}
+	updatedModel, _ = model.Update(keyRunes("|"))
+	model = updatedModel.(Model)
+	if model.splitActive() || model.gridWidth(fullWidth) != fullWidth {
+		t.Fatal("expected | to hide the panel and give the grid the full width")
+	}
+}
+
+func TestOperationsPaneLogsFinishedOperations(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	model.loading = false
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 160, Height: 50})
+	model, _ = deliver(t, updatedModel.(Model), loadSecrets(context.Background(), model.inventory, 0))
+	name := model.grid.SelectedSecret().Name
+
+	updatedModel, _ = model.Update(keyRunes("o"))
+	model = updatedModel.(Model)
+	_, collapsed := model.contentViewportSize()
+	if !model.ops.open || !strings.Contains(model.View(), "Nothing yet") {
+		t.Fatalf("expected o to open an empty operations pane, got %q", model.View())
+	}
+
+	updatedModel, _ = model.Update(secretSavedMsg{path: "/tmp/out.json", bytes: 42})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.Update(kmsKeyChangedMsg{name: name, err: errors.New("access denied")})
+	model = updatedModel.(Model)
+	if len(model.ops.entries) != 2 || !model.ops.entries[1].failed {
+		t.Fatalf("expected a save and a failed key change, got %+v", model.ops.entries)
+	}
+	view := model.View()
+	for _, want := range []string{"Operations (2 this session)", "Saved " + name + " (42 bytes) to /tmp/out.json", "Failed to change the KMS key of " + name + ": access denied"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the pane, got %q", want, view)
+		}
+	}
+	if _, height := model.contentViewportSize(); height >= collapsed {
+		t.Fatalf("expected the grid to shrink as the pane fills, got %d from %d", height, collapsed)
+	}
+
+	updatedModel, _ = model.Update(keyRunes("o"))
+	model = updatedModel.(Model)
+	if model.ops.open || strings.Contains(model.View(), "this session") || len(model.ops.entries) != 2 {
+		t.Fatal("expected o to collapse the pane and keep the log")
+	}
+}
+
+// undoingStore is a demo store that records the changes made to it
+type undoingStore struct {
+	*demo.Store
+	calls []string
+}
+
+func (s *undoingStore) TagSecret(ctx context.Context, secretName, key, value string) error {
+	s.calls = append(s.calls, "tag "+secretName+" "+key+"="+value)
+	return nil
+}
+
+func (s *undoingStore) UntagSecret(ctx context.Context, secretName, key string) error {
+	s.calls = append(s.calls, "untag "+secretName+" "+key)
+	return nil
+}
+
+func (s *undoingStore) ScheduleDeletion(ctx context.Context, secretName string) (time.Time, error) {
+	s.calls = append(s.calls, "delete "+secretName)
+	return time.Now().AddDate(0, 0, 30), nil
+}
+
+func (s *undoingStore) RestoreSecret(ctx context.Context, secretName string) error {
+	s.calls = append(s.calls, "restore "+secretName)
+	return nil
+}
+
+func TestUndoReversesDeletionsAndTagRemovals(t *testing.T) {
+	store := &undoingStore{Store: demo.NewStore()}
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store.Store)
+	model.width, model.height = 120, 50
+	model.awsClient = store
+	model.inventory = inventory.New(store, 0)
+	secrets, _ := store.FindSecrets(context.Background(), "prod/api/")
+	model.showSecrets(secrets)
+	model.grid.SetSortOrder(inventory.SortNameAsc)
+	name := model.grid.SelectedSecret().Name
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("u"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretList || model.statusMessage != "Nothing to undo this session" {
+		t.Fatalf("expected nothing to undo yet, got %q", model.statusMessage)
+	}
+
+	run := func(op bulkOperation) {
+		model.bulkOp = op
+		msg := runBulk(context.Background(), store, op)()
+		for {
+			update, ok := msg.(loadProgressMsg)
+			if !ok {
+				break
+			}
+			msg = update.next()
+		}
+		updatedModel, _ := model.Update(msg)
+		model = updatedModel.(Model)
+		model.closeScreen()
+	}
+	// Only secrets that had the tag can have it re-added
+	run(bulkOperation{kind: bulkUntag, key: "Service", names: []string{name, "missing"}, previous: map[string]string{name: "api"}})
+	run(bulkOperation{kind: bulkDelete, names: []string{name}})
+	run(bulkOperation{kind: bulkTag, key: "team", value: "payments", names: []string{name}})
+	if len(model.undo.entries) != 2 {
+		t.Fatalf("expected the deletion and the tag removal to be undoable, got %+v", model.undo.entries)
+	}
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("u"))
+	model = updatedModel.(Model)
+	view := model.screen.View()
+	if model.currentScreen != ScreenUndo || !strings.Contains(view, "Restore "+name) || !strings.Contains(view, "Re-add the tag Service to "+name) {
+		t.Fatalf("expected the undoable actions to be listed, got %q", view)
+	}
+	if strings.Index(view, "Restore "+name) > strings.Index(view, "Re-add the tag") {
+		t.Fatal("expected the newest action first")
+	}
+
+	store.calls = nil
+	updatedModel, cmd := model.Update(actionChosenMsg{key: "1"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("u"))
+	model = updatedModel.(Model)
+	updatedModel, cmd = model.Update(actionChosenMsg{key: "1"})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.Update(cmd())
+	model = updatedModel.(Model)
+	want := []string{"restore " + name, "tag " + name + " Service=api"}
+	if strings.Join(store.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected calls %q, got %q", want, store.calls)
+	}
+	if len(model.undo.entries) != 0 || !strings.Contains(model.statusMessage, "Re-add the tag Service") {
+		t.Fatalf("expected both actions undone, got %+v (status %q)", model.undo.entries, model.statusMessage)
+	}
+}
+
+func TestHelpScreenIsGeneratedFromTheKeyMap(t *testing.T) {
+	model := NewModel(demo.Profile, demo.Region)
+	model.width, model.height = 100, 30
+	aliases, err := parseKeyBindings(map[string]string{"refresh": "f5", "copy_plain": "Y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	model.keyAliases = aliases
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("H"))
+	model = updatedModel.(Model)
+	if !model.showHelp {
+		t.Fatal("expected H to open the help screen")
+	}
+	text := strings.Join(model.helpLines(), "\n")
+	for _, want := range []string{"SECRET DETAIL", "r/f5", "refresh", "c/Y", "copy plain", "change KMS key", "undo"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in the help, got %q", want, text)
+		}
+	}
+
+	view := model.viewHelp()
+	if !strings.Contains(view, "↓") || strings.Contains(view, "↑ ") {
+		t.Fatalf("expected the help to be cut off at the bottom, got %q", view)
+	}
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("j"))
+	model = updatedModel.(Model)
+	if model.helpOffset != 1 || model.grid.SelectedIndex() != 0 {
+		t.Fatal("expected j to scroll the help rather than move the cursor")
+	}
+	updatedModel, _ = model.handleSecretListKeys(keyMsgFor("end"))
+	model = updatedModel.(Model)
+	if view := model.viewHelp(); !strings.Contains(view, "clipboard timeout") || strings.Contains(view, "↓ ") {
+		t.Fatalf("expected end to scroll to the bottom, got %q", view)
+	}
+	updatedModel, _ = model.handleSecretListKeys(keyMsgFor("esc"))
+	if updatedModel.(Model).showHelp {
+		t.Fatal("expected esc to close the help")
+	}
+}
+
+func TestFooterHintsTruncateAndExpand(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.width, model.height = 90, 40
+	model.showSecrets([]models.Secret{{Name: "app/api"}, {Name: "app/db"}})
+
+	footer := model.viewFooter()
+	if lipgloss.Height(footer) != 1 || !strings.Contains(footer, "…") || !strings.HasSuffix(footer, "? more keys") {
+		t.Fatalf("expected one line of hints cut short before ?, got %q", footer)
+	}
+	width, _ := model.innerSize()
+	if lipgloss.Width(footer) > width {
+		t.Fatalf("expected the hints to fit in %d columns, got %d", width, lipgloss.Width(footer))
+	}
+
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("?"))
+	model = updatedModel.(Model)
+	footer = model.viewFooter()
+	if !model.fullHelp || !strings.Contains(footer, "cycle sort") || !strings.HasSuffix(footer, "? fewer keys") {
+		t.Fatalf("expected ? to expand the hints, got %q", footer)
+	}
+	if lipgloss.Height(footer) > 40/2+1 {
+		t.Fatalf("expected the expanded hints to leave room for the list, got %d lines", lipgloss.Height(footer))
+	}
+
+	model.currentScreen = ScreenSecretDetail
+	model.fullHelp = false
+	model.secretValue = `{"user":"app"}`
+	model.secretFields = []components.SecretField{{Key: "user"}}
+	if footer := model.viewFooter(); !strings.Contains(footer, "↑/↓ select a JSON key") {
+		t.Fatalf("expected hints for the JSON keys, got %q", footer)
+	}
+}
+
+func TestNarrowTerminalUsesCompactLayouts(t *testing.T) {
+	changed := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	model.showSecrets([]models.Secret{{
+		Name:            "payments/production/stripe-webhook-signing-secret",
+		ARN:             "arn:aws:secretsmanager:eu-west-2:123456789012:secret:payments/production/stripe-webhook-signing-secret-AbCdEf",
+		Description:     "Signing secret for the Stripe webhooks of the production payments service",
+		LastChangedDate: &changed,
+		Tags:            map[string]string{"team": "payments", "owner": "platform-engineering-on-call-rotation"},
+	}, {Name: "app/db"}})
+
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 60, Height: 40})
+	model = updatedModel.(Model)
+	if !model.grid.Compact() {
+		t.Fatal("expected a 60 column terminal to get the compact list")
+	}
+	fits := func(screen string) {
+		t.Helper()
+		for _, line := range strings.Split(model.View(), "\n") {
+			if lipgloss.Width(line) > 60 {
+				t.Fatalf("expected the %s to fit in 60 columns, got %d: %q", screen, lipgloss.Width(line), line)
+			}
+		}
+	}
+	fits("secret list")
+	if view := model.View(); strings.Contains(view, "DESCRIPTION") || !strings.Contains(view, "Secret Src") {
+		t.Fatalf("expected names alone under a short title, got %q", view)
+	}
+
+	model.currentScreen = ScreenSecretDetail
+	model.secretDetails = &models.SecretDetails{KmsKeyID: "arn:aws:kms:eu-west-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}
+	model.secretValue = `{"token":"whsec_0123456789abcdef"}`
+	model.secretFields = []components.SecretField{{Key: "token"}}
+	fits("secret detail")
+	if view := model.View(); !strings.Contains(view, "Name:") || strings.Contains(view, "Name: payments") {
+		t.Fatalf("expected the labels above their values, got %q", view)
+	}
+	if view := model.View(); strings.Contains(view, "to copy as plain text") {
+		t.Fatalf("expected the copy instructions to be left to the footer, got %q", view)
+	}
+
+	updatedModel, _ = model.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	model = updatedModel.(Model)
+	if model.grid.Compact() || !strings.Contains(model.View(), "to copy as plain text") {
+		t.Fatal("expected a wider terminal to get the full layouts back")
+	}
+}
+
+func TestLoadAllShowsEveryPageAsOneList(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 20)
+	model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+	if model.grid.Len() != 20 || !model.hasNextPage() {
+		t.Fatalf("expected the first page of 20, got %d", model.grid.Len())
+	}
+
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("a"))
+	model = updatedModel.(Model)
+	var progress []string
+	for !model.allPages {
+		if !model.loading {
+			t.Fatalf("expected every page to load, got %q", model.errorMessage)
+		}
+		model, cmd = deliver(t, model, cmd)
+		progress = append(progress, model.loadingText)
+	}
+	total := len(model.inventory.Loaded())
+	if model.grid.Len() != total || total <= 20 || model.hasNextPage() {
+		t.Fatalf("expected all %d secrets in the grid with no more pages, got %d", total, model.grid.Len())
+	}
+	if !strings.Contains(progress[0], "2 pages, 40 secrets") {
+		t.Fatalf("expected progress for each page, got %q", progress)
+	}
+	if !strings.Contains(model.viewHeader(), "All pages loaded") {
+		t.Fatalf("expected the header to say every page is loaded, got %q", model.viewHeader())
+	}
+
+	// Sorting covers every secret, not just the first page
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("s"))
+	model = updatedModel.(Model)
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("s"))
+	model = updatedModel.(Model)
+	names := make([]string, 0, total)
+	for _, secret := range model.inventory.Loaded() {
+		names = append(names, secret.Name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	model.grid.Select(0)
+	if first := model.grid.SelectedSecret().Name; first != names[0] {
+		t.Fatalf("expected Z-A over every secret to start with %s, got %s", names[0], first)
+	}
+
+	// A refresh goes back to the first page
+	updatedModel, cmd = model.handleSecretListKeys(keyRunes("r"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.allPages || model.grid.Len() != 20 {
+		t.Fatalf("expected the refresh to show the first page again, got %d secrets", model.grid.Len())
+	}
+}
+
+func TestHeaderShowsTheSelectedSecretsPosition(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 20)
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 60})
+	model, _ = deliver(t, updatedModel.(Model), loadSecrets(context.Background(), model.inventory, 0))
+
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("n"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	model.grid.Select(4)
+	if position := model.listPosition(); position != "Secret 25 of 40+ (page 1/1, AWS page 2)" {
+		t.Fatalf("expected the position counting the first AWS page, got %q", position)
+	}
+	if !strings.Contains(model.viewHeader(), "Secret 25 of 40+") {
+		t.Fatalf("expected the position in the header, got %q", model.viewHeader())
+	}
+
+	// Loading every page makes the total exact and the grid's screens the pages
+	updatedModel, cmd = model.handleSecretListKeys(keyRunes("a"))
+	model = updatedModel.(Model)
+	for !model.allPages {
+		model, cmd = deliver(t, model, cmd)
+	}
+	total := len(model.inventory.Loaded())
+	_, screens := model.grid.Screen()
+	model.grid.Select(36)
+	screen, _ := model.grid.Screen()
+	if want := fmt.Sprintf("Secret 37 of %d (page %d/%d)", total, screen, screens); model.listPosition() != want {
+		t.Fatalf("expected %q, got %q", want, model.listPosition())
+	}
+
+	model.grid.SetFilter("prod/")
+	model.grid.Select(1)
+	if position := model.listPosition(); !strings.HasPrefix(position, fmt.Sprintf("Secret 2 of %d matching", model.grid.Len())) {
+		t.Fatalf("expected the position among the matches, got %q", position)
+	}
+}
+
+func TestSecretGivenOnTheCommandLineOpensItsDetails(t *testing.T) {
+	store := demo.NewStore()
+	all, err := inventory.New(store, 100).LoadAll(context.Background(), nil)
+	if err != nil || len(all) <= 20 {
+		t.Fatalf("expected more than a page of demo secrets, got %d, %v", len(all), err)
+	}
+	load := func(target string) Model {
+		model := NewModel(demo.Profile, demo.Region).WithDemo(store).WithSecret(target)
+		model.awsClient = store
+		model.inventory = inventory.New(store, 20)
+		model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+		if model.deepLink != "" || !model.loading {
+			t.Fatalf("expected the link to be followed once the first page loads")
+		}
+		// The lookup is batched with the first page's own commands
+		model.deepLink = target
+		model, _ = deliver(t, model, model.followDeepLink())
+		return model
+	}
+
+	// By name, on the first page
+	first := all[0]
+	model := load(first.Name)
+	if model.currentScreen != ScreenSecretDetail || model.grid.SelectedSecret().Name != first.Name {
+		t.Fatalf("expected the details of %s, got screen %v", first.Name, model.currentScreen)
+	}
+	if model.grid.Len() != 20 {
+		t.Fatalf("expected the first page to stay listed, got %d secrets", model.grid.Len())
+	}
+
+	// By ARN, beyond the first page, and without its random suffix
+	last := all[len(all)-1]
+	model = load(last.ARN[:len(last.ARN)-7])
+	if model.currentScreen != ScreenSecretDetail || model.grid.SelectedSecret().Name != last.Name {
+		t.Fatalf("expected the details of %s, got screen %v", last.Name, model.currentScreen)
+	}
+	if model.grid.Len() != 1 || !strings.Contains(model.statusMessage, "r lists every secret") {
+		t.Fatalf("expected %s listed on its own, got %d secrets, %q", last.Name, model.grid.Len(), model.statusMessage)
+	}
+
+	model = load("no/such/secret")
+	if model.currentScreen != ScreenSecretList || !strings.Contains(model.errorMessage, "No secret no/such/secret") {
+		t.Fatalf("expected an error for a missing secret, got %q", model.errorMessage)
+	}
+}
+
+func TestAuditLogRecordsActionsWithoutValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	model := NewModel("prod", "eu-west-2").WithConfig(&config.Config{AuditLog: path})
+	model.identity = &models.CallerIdentity{Account: "123456789012", ARN: "arn:aws:iam::123456789012:user/alice"}
+	model.grid.SetSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	updatedModel, _ := model.Update(secretValueLoadedMsg{value: "hunter2-audited"})
+	model = updatedModel.(Model)
+	updatedModel, _ = model.Update(clipboardCopiedMsg{success: true, value: "hunter2-audited"})
+	model = updatedModel.(Model)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected the audit log to be written: %v", err)
+	}
+	log := string(data)
+	for _, want := range []string{`"action":"Read the value of app/db"`, `"action":"Copied app/db to the clipboard"`, `"identity":"arn:aws:iam::123456789012:user/alice"`, `"profile":"prod"`} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("expected %s in the audit log:\n%s", want, log)
+		}
+	}
+	if strings.Contains(log, "hunter2-audited") {
+		t.Fatalf("expected no values in the audit log:\n%s", log)
+	}
+
+	// Demo data is never recorded
+	demoModel := NewModel(demo.Profile, demo.Region).WithConfig(&config.Config{AuditLog: path}).WithDemo(demo.NewStore())
+	demoModel.logOperation("Copied demo/secret to the clipboard", nil)
+	if after, _ := os.ReadFile(path); len(after) != len(data) {
+		t.Fatalf("expected nothing recorded for demo data")
+	}
+}
+
+func TestValueCacheKeepsValuesForTheTTL(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel(demo.Profile, demo.Region).WithConfig(&config.Config{ValueCacheTTL: "2m"}).WithDemo(store)
+	model.awsClient = store
+	model.inventory = inventory.New(store, 20)
+	model, _ = deliver(t, model, loadSecrets(context.Background(), model.inventory, 0))
+
+	open := func(model Model) Model {
+		updatedModel, _ := model.Update(keyMsgFor("enter"))
+		updatedModel, cmd := updatedModel.(Model).handleSecretDetailKeys(keyRunes("v"))
+		model = updatedModel.(Model)
+		if model.loading {
+			model, _ = deliver(t, model, cmd)
+		}
+		return model
+	}
+	back := func(model Model) Model {
+		updatedModel, _ := model.handleSecretDetailKeys(tea.KeyMsg{Type: tea.KeyEsc})
+		return updatedModel.(Model)
+	}
+
+	model = open(model)
+	if !model.secretLoaded() || model.values.entries[model.selectedName()].fetchedAt.IsZero() {
+		t.Fatalf("expected the value to be fetched and cached")
+	}
+	model = back(model)
+	if model.secretLoaded() {
+		t.Fatalf("expected the shown value to be cleared on leaving the detail screen")
+	}
+
+	// Viewing it again within the TTL uses the cached value
+	updatedModel, _ := model.Update(keyMsgFor("enter"))
+	updatedModel, _ = updatedModel.(Model).handleSecretDetailKeys(keyRunes("v"))
+	model = updatedModel.(Model)
+	if model.loading || !model.secretLoaded() || !strings.Contains(model.statusMessage, "Cached value") {
+		t.Fatalf("expected the cached value without a fetch, got loading %v, %q", model.loading, model.statusMessage)
+	}
+
+	// X drops every cached value, so the next view fetches again
+	model = back(model)
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("X"))
+	model = updatedModel.(Model)
+	if len(model.values.entries) != 0 || !strings.Contains(model.statusMessage, "Cleared 1 cached values") {
+		t.Fatalf("expected the cache to be purged, got %q", model.statusMessage)
+	}
+	updatedModel, _ = model.Update(keyMsgFor("enter"))
+	updatedModel, _ = updatedModel.(Model).handleSecretDetailKeys(keyRunes("v"))
+	if !updatedModel.(Model).loading {
+		t.Fatalf("expected the value to be fetched again after purging")
+	}
+
+	// Values past the TTL are dropped
+	cache := valueCache{ttl: time.Minute}
+	cache.put("app/db", models.SecretValue{String: "hunter2"}, time.Now().Add(-2*time.Minute))
+	if _, ok := cache.get("app/db", time.Now()); ok {
+		t.Fatalf("expected an expired value not to be used")
+	}
+	cache.expire(time.Now())
+	if len(cache.entries) != 0 {
+		t.Fatalf("expected expired values to be dropped")
+	}
+}
+
+func TestScrollbackProtectionBlanksTheScreenBeforeLeaving(t *testing.T) {
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{ScrollbackProtection: true})
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	model = updatedModel.(Model)
+	model.grid.SetSecrets([]models.Secret{{Name: "app/db"}})
+	model.currentScreen = ScreenSecretDetail
+	updatedModel, _ = model.Update(secretValueLoadedMsg{value: "hunter2-scrollback"})
+	model = updatedModel.(Model)
+
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	suspended := updatedModel.(Model)
+	if cmd == nil || suspended.View() != "" {
+		t.Fatalf("expected the screen blanked before suspending, got %q", suspended.View())
+	}
+	updatedModel, _ = suspended.Update(tea.ResumeMsg{})
+	if updatedModel.(Model).View() == "" {
+		t.Fatal("expected the UI drawn again on resume")
+	}
+
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	quitting := updatedModel.(Model)
+	if cmd == nil || quitting.View() != "" || quitting.secretLoaded() {
+		t.Fatalf("expected the screen blanked and the value cleared before quitting")
+	}
+
+	// Without the setting the last frame is left as it is
+	model.scrollbackProtection = false
+	updatedModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if updatedModel.(Model).View() == "" {
+		t.Fatal("expected the screen left drawn without scrollback_protection")
+	}
+}
+
+func TestBackendReplacesAWS(t *testing.T) {
+	store := demo.NewStore()
+	model := NewModel("myvault", "").WithBackend(store, "Azure Key Vault")
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	model = updatedModel.(Model)
+
+	msg := model.initAWSClient("myvault", "")()
+	if changed, ok := msg.(clientChangedMsg); !ok || changed.client != store {
+		t.Fatalf("expected the backend instead of signing in to AWS, got %#v", msg)
+	}
+	updatedModel, _ = model.Update(msg)
+	model = updatedModel.(Model)
+	if header := model.viewHeader(); !strings.Contains(header, "Azure Key Vault: myvault") || strings.Contains(header, "Region:") {
+		t.Fatalf("expected the vault in the header, got %q", header)
+	}
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("p"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenSecretList || !strings.Contains(model.statusMessage, "no AWS profiles") {
+		t.Fatalf("expected profiles to be unavailable, got screen %v and %q", model.currentScreen, model.statusMessage)
+	}
+	if model.usesListCache("myvault") || model.demoData() {
+		t.Fatal("expected the backend's list never to be cached or taken for demo data")
+	}
+}
+
+// fakePasswordManager holds one item and its fields
+type fakePasswordManager struct {
+	item   passwords.Item
+	fields map[string]string
+}
+
+func (f fakePasswordManager) Name() string {
+	return f.item.Manager
+}
+
+func (f fakePasswordManager) List(ctx context.Context) ([]passwords.Item, error) {
+	return []passwords.Item{f.item}, nil
+}
+
+func (f fakePasswordManager) Fields(ctx context.Context, id string) (map[string]string, error) {
+	return f.fields, nil
+}
+
+// creatingSecretAPI is a Secrets Manager where no secret exists yet, which
+// records the secrets created in it
+type creatingSecretAPI struct {
+	fakeSecretsAPI
+	created []string
+}
+
+func (f *creatingSecretAPI) DescribeSecret(ctx context.Context, params *secretsmanager.DescribeSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.DescribeSecretOutput, error) {
+	return nil, &smtypes.ResourceNotFoundException{}
+}
+
+func (f *creatingSecretAPI) CreateSecret(ctx context.Context, params *secretsmanager.CreateSecretInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.CreateSecretOutput, error) {
+	f.created = append(f.created, *params.Name+"="+*params.SecretString)
+	return &secretsmanager.CreateSecretOutput{}, nil
+}
+
+func TestImportPasswordManagerItem(t *testing.T) {
+	model := NewModel("default", "eu-west-2")
+	model.loading = false
+	updatedModel, _ := model.handleSecretListKeys(keyRunes("I"))
+	model = updatedModel.(Model)
+	if !strings.Contains(model.statusMessage, "password_managers") {
+		t.Fatalf("expected to be told to configure a password manager, got %q", model.statusMessage)
+	}
+
+	api := &creatingSecretAPI{}
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	item := passwords.Item{Manager: "1Password", ID: "x1", Title: "Stripe API", Vault: "Team"}
+	model.passwordManagers = []passwords.Manager{fakePasswordManager{
+		item:   item,
+		fields: map[string]string{"username": "payments", "password": "sk_live_123"},
+	}}
+	updatedModel, cmd := model.handleSecretListKeys(keyRunes("I"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenImportPick {
+		t.Fatalf("expected the items to pick from, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, cmd = model.Update(secretPickedMsg{name: item.Label()})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenImportName || !strings.Contains(model.screen.View(), "password, username") {
+		t.Fatalf("expected to be asked for a name, got screen %v", model.currentScreen)
+	}
+
+	updatedModel, cmd = model.Update(textEnteredMsg{value: "prod/stripe-api"})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if len(api.created) != 1 || api.created[0] != `prod/stripe-api={"password":"sk_live_123","username":"payments"}` {
+		t.Fatalf("expected the fields as a JSON secret, got %q", api.created)
+	}
+	if model.statusMessage != "Created prod/stripe-api from 1Password: Stripe API (Team) in eu-west-2" {
+		t.Fatalf("expected the import to be reported, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+	if len(model.ops.entries) != 1 || model.ops.entries[0].failed {
+		t.Fatalf("expected the import in the operations log, got %+v", model.ops.entries)
+	}
+}
+
+func TestCreateSecretFromTemplate(t *testing.T) {
+	api := &creatingSecretAPI{}
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{
+		Templates: []secretvalue.Template{{
+			Name: "rds-postgres",
+			Fields: []secretvalue.TemplateField{
+				{Key: "host"},
+				{Key: "port", Type: "number", Default: "5432"},
+				{Key: "password", Generate: 12},
+				{Key: "dbname", Optional: true},
+			},
+			Tags: map[string]string{"Engine": "postgres"},
+		}},
+	})
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	model = updatedModel.(Model)
+	model.loading = false
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("N"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenTemplatePick {
+		t.Fatalf("expected the templates to pick from, got screen %v", model.currentScreen)
+	}
+	updatedModel, _ = model.Update(secretPickedMsg{name: "rds-postgres"})
+	model = updatedModel.(Model)
+	for _, value := range []string{"prod/db", "db.internal", "five"} {
+		updatedModel, _ = model.Update(textEnteredMsg{value: value})
+		model = updatedModel.(Model)
+	}
+	if model.currentScreen != ScreenTemplateField || !strings.Contains(model.screen.View(), "isn't a number") {
+		t.Fatalf("expected the port to be asked for again, got screen %v", model.currentScreen)
+	}
+	for _, value := range []string{"5432", ""} {
+		updatedModel, _ = model.Update(textEnteredMsg{value: value})
+		model = updatedModel.(Model)
+	}
+	if model.currentScreen != ScreenTemplateConfirm {
+		t.Fatalf("expected to confirm the new secret, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+	}
+	if view := model.screen.View(); !strings.Contains(view, "12 generated") || !strings.Contains(view, "tag Engine") {
+		t.Fatalf("expected the generated password and tags to be listed, got %q", view)
+	}
+
+	updatedModel, cmd := model.Update(confirmedMsg{})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if len(api.created) != 1 || !strings.HasPrefix(api.created[0], `prod/db={"host":"db.internal","password":"`) || !strings.HasSuffix(api.created[0], `","port":5432}`) {
+		t.Fatalf("expected a typed value without the optional key, got %q", api.created)
+	}
+	if model.statusMessage != "Created prod/db from the rds-postgres template in eu-west-2" {
+		t.Fatalf("expected the secret to be reported, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+}
+
+func TestImportManifestConfirmsEachSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	manifest := "secrets:\n  - name: prod/db\n    value: { host: db.prod, password: hunter2 }\n  - name: prod/token\n    value: abc\n"
+	if err := os.WriteFile(path, []byte(manifest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	api := &creatingSecretAPI{}
+	model := NewModel("default", "eu-west-2")
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	model = updatedModel.(Model)
+	model.loading = false
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("O"))
+	model = updatedModel.(Model)
+	if model.currentScreen != ScreenManifestPath {
+		t.Fatalf("expected to be asked for the manifest, got screen %v", model.currentScreen)
+	}
+	updatedModel, cmd := model.Update(pathEnteredMsg{path: path})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenManifestConfirm {
+		t.Fatalf("expected to confirm the import, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+	}
+	view := model.screen.View()
+	if !strings.Contains(view, "create: +host +password") || !strings.Contains(view, "2 to create") || strings.Contains(view, "hunter2") {
+		t.Fatalf("expected the keys of each secret without values, got %q", view)
+	}
+
+	updatedModel, cmd = model.Update(confirmedMsg{})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if len(api.created) != 2 || api.created[0] != `prod/db={"host":"db.prod","password":"hunter2"}` || api.created[1] != "prod/token=abc" {
+		t.Fatalf("expected both secrets to be created, got %q", api.created)
+	}
+	if model.currentScreen != ScreenBulkResults || !model.bulkChanged {
+		t.Fatalf("expected the results, reloading the list when closed, got screen %v", model.currentScreen)
+	}
+}
+
+func TestImportManifestOverwritesLikeOtherOverwrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(path, []byte("prod/db: { password: hunter3 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	start := func(api *existingSecretAPI) Model {
+		model := NewModel("default", "eu-west-2")
+		model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+		updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+		model = updatedModel.(Model)
+		model.loading = false
+		updatedModel, _ = model.handleSecretListKeys(keyRunes("O"))
+		updatedModel, cmd := updatedModel.(Model).Update(pathEnteredMsg{path: path})
+		model, _ = deliver(t, updatedModel.(Model), cmd)
+		return model
+	}
+
+	managed := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: `{"password":"hunter2"}`}, owner: "rds"}
+	model := start(managed)
+	if model.currentScreen == ScreenManifestConfirm || !strings.Contains(model.errorMessage, "prod/db (RDS) is managed") {
+		t.Fatalf("expected the managed secret to be refused, got screen %v and error %q", model.currentScreen, model.errorMessage)
+	}
+
+	api := &existingSecretAPI{fakeSecretsAPI: fakeSecretsAPI{value: `{"password":"hunter2"}`}}
+	model = start(api)
+	if model.currentScreen != ScreenManifestConfirm {
+		t.Fatalf("expected to confirm the import, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+	}
+	// Overwriting is confirmed by typing the secret's name, not with y
+	updatedModel, _ := model.Update(keyRunes("y"))
+	updatedModel, cmd := updatedModel.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model = updatedModel.(Model)
+	if cmd != nil || !strings.Contains(model.screen.View(), "doesn't match") {
+		t.Fatal("expected y not to confirm an overwrite")
+	}
+	model = typeName(model, "prod/db")
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if len(api.written) != 1 || api.written[0] != `prod/db={"password":"hunter3"}` {
+		t.Fatalf("expected the secret to be overwritten, got %q", api.written)
+	}
+	if len(model.undo.entries) != 1 || model.undo.entries[0].kind != undoOverwrite || model.undo.entries[0].names[0] != "prod/db" {
+		t.Fatalf("expected the overwrite to be undoable, got %+v", model.undo.entries)
+	}
+}
+
+func TestDryRunListsWritesWithoutMakingThem(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(path, []byte("prod/db: { host: db.prod, password: hunter2 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	api := &creatingSecretAPI{}
+	model := NewModel("default", "eu-west-2")
+	model.awsClient = aws.NewClientWithAPI(api, "default", "eu-west-2")
+	model.auditLog = filepath.Join(dir, "audit.log")
+	updatedModel, _ := model.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	model = updatedModel.(Model)
+	model.loading = false
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("D"))
+	model = updatedModel.(Model)
+	if model.dryRun == nil || !strings.Contains(model.View(), "DRY RUN") {
+		t.Fatal("expected D to turn on dry-run mode, shown in the header")
+	}
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("O"))
+	model = updatedModel.(Model)
+	updatedModel, cmd := model.Update(pathEnteredMsg{path: path})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	updatedModel, cmd = model.Update(confirmedMsg{})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+
+	if len(api.created) != 0 {
+		t.Fatalf("expected nothing to be created, got %q", api.created)
+	}
+	var logged []string
+	for _, entry := range model.ops.entries {
+		logged = append(logged, entry.text)
+	}
+	if want := []string{"Dry run: would create prod/db: +host +password"}; strings.Join(logged, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected only the skipped write in the operations pane, got %q", logged)
+	}
+	if !model.ops.open || !strings.Contains(model.statusMessage, "none made") {
+		t.Fatalf("expected the pane to open with a status, got %q", model.statusMessage)
+	}
+	if _, err := os.Stat(model.auditLog); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing in the audit log, got %v", err)
+	}
+
+	updatedModel, _ = model.handleSecretListKeys(keyRunes("D"))
+	model = updatedModel.(Model)
+	if model.dryRun != nil {
+		t.Fatal("expected D to turn dry-run mode off again")
+	}
+}
+
+func TestSyncFromBackendPreviewsBeforeWriting(t *testing.T) {
+	source := aws.NewClientWithAPI(fakeSecretsAPI{value: `{"user": "app", "pass": "hunter2"}`}, "myvault", "")
+	dest := &creatingSecretAPI{}
+	model := NewModel("myvault", "").WithConfig(&config.Config{
+		SyncRules: secretvalue.SyncRules{{Pattern: "app-*", Rename: map[string]string{"pass": "password"}}},
+	}).WithBackend(source, "Azure Key Vault")
+	model.awsClient = source
+	model.showSecrets([]models.Secret{{Name: "app-db"}})
+	model.currentScreen = ScreenSecretDetail
+	model.loading = false
+
+	preview := func(model Model) Model {
+		model.secondPurpose = secondForSync
+		updatedModel, _ := model.Update(secondAuthMsg{client: aws.NewClientWithAPI(dest, "prod", "eu-west-2"), profile: "prod", region: "eu-west-2"})
+		model = updatedModel.(Model)
+		if model.currentScreen != ScreenSyncName {
+			t.Fatalf("expected to be asked for the destination, got screen %v", model.currentScreen)
+		}
+		updatedModel, cmd := model.Update(textEnteredMsg{value: "prod/app/db"})
+		model, _ = deliver(t, updatedModel.(Model), cmd)
+		if model.currentScreen != ScreenSyncPreview {
+			t.Fatalf("expected the preview, got screen %v (error %q)", model.currentScreen, model.errorMessage)
+		}
+		return model
+	}
+
+	model = preview(model)
+	if view := model.screen.View(); !strings.Contains(view, "pass → password") || !strings.Contains(view, "which will be created") {
+		t.Fatalf("expected the key mapping in the preview, got %q", view)
+	}
+	updatedModel, cmd := model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if len(dest.created) != 0 || model.statusMessage != "Nothing was written to prod/app/db" {
+		t.Fatalf("expected leaving the preview to write nothing, got %q and %q", dest.created, model.statusMessage)
+	}
+
+	model = preview(model)
+	updatedModel, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, cmd = deliver(t, updatedModel.(Model), cmd)
+	model, _ = deliver(t, model, cmd)
+	if len(dest.created) != 1 || dest.created[0] != `prod/app/db={"password":"hunter2","user":"app"}` {
+		t.Fatalf("expected the mapped value to be written, got %q", dest.created)
+	}
+	if model.statusMessage != "Created prod/app/db in prod (eu-west-2) from app-db" || model.syncTo.client != nil {
+		t.Fatalf("expected the sync to be reported, got %q (error %q)", model.statusMessage, model.errorMessage)
+	}
+}
+
+func TestAppFilterGroupsItsSecrets(t *testing.T) {
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{Apps: []secretvalue.App{
+		{Name: "billing-api", Secrets: []secretvalue.AppSecret{{Secret: "prod/billing/db"}, {Secret: "prod/shared/smtp"}}},
+		{Name: "worker", Secrets: []secretvalue.AppSecret{{Secret: "prod/shared/smtp"}}},
+	}})
+	model.showSecrets([]models.Secret{{Name: "prod/billing/db"}, {Name: "prod/shared/smtp"}, {Name: "prod/other"}})
+
+	model.grid.SetFilter("app:billing")
+	if model.grid.Len() != 2 {
+		t.Fatalf("expected the app's two secrets, got %d", model.grid.Len())
+	}
+	model.grid.SetFilter("app:")
+	if model.grid.Len() != 2 {
+		t.Fatalf("expected every app's secrets, got %d", model.grid.Len())
+	}
+	if apps := strings.Join(model.appsUsing("prod/shared/smtp"), ", "); apps != "billing-api, worker" {
+		t.Fatalf("expected both apps to use the secret, got %q", apps)
+	}
+}
+
+func TestReferencesFlagVersionsOtherThanCurrent(t *testing.T) {
+	versions := []models.SecretVersion{{ID: "v2", Stages: []string{"AWSCURRENT"}}, {ID: "v1", Stages: []string{"AWSPREVIOUS"}}}
+	tests := []struct {
+		ref  aws.SecretReference
+		want components.ItemStatus
+	}{
+		{ref: aws.SecretReference{Where: "DB"}, want: components.ItemSucceeded},
+		{ref: aws.SecretReference{Where: "DB", Stage: "AWSPREVIOUS"}, want: components.ItemFailed},
+		{ref: aws.SecretReference{Where: "DB", Stage: "AWSPENDING"}, want: components.ItemFailed},
+		{ref: aws.SecretReference{Where: "DB", VersionID: "v1"}, want: components.ItemFailed},
+		{ref: aws.SecretReference{Where: "DB", VersionID: "v2"}, want: components.ItemPending},
+	}
+	for _, tt := range tests {
+		if status, detail := referenceStatus(tt.ref, versions); status != tt.want {
+			t.Errorf("referenceStatus(%+v) = %v (%s), want %v", tt.ref, status, detail, tt.want)
+		}
+	}
+
+	sized, _ := NewModel("default", "eu-west-2").Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	model := sized.(Model)
+	model.showSecrets([]models.Secret{{Name: "prod/db"}})
+	updated, _ := model.showSecretReferences(secretReferencesMsg{
+		name:    "prod/db",
+		details: &models.SecretDetails{Name: "prod/db", Versions: versions},
+		refs:    []aws.SecretReference{{Kind: "ECS", Name: "api:3", Where: "web DB", Stage: "AWSPREVIOUS"}},
+		err:     errors.New("Lambda: AccessDeniedException"),
+	})
+	model = updated.(Model)
+	view := model.View()
+	if model.currentScreen != ScreenReferences || !strings.Contains(view, "1 of 1 references don't read") || !strings.Contains(view, "✗ ECS api:3") {
+		t.Fatalf("expected the stale reference to be reported, got screen %v:\n%s", model.currentScreen, view)
+	}
+}
+
+func TestAccessListsGrantsNamingTheSecretFirst(t *testing.T) {
+	sized, _ := NewModel("default", "eu-west-2").WithConfig(&config.Config{StatePaths: []string{"/srv/infra"}}).Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	model := sized.(Model)
+	model.showSecrets([]models.Secret{{Name: "prod/db"}})
+	updated, _ := model.showSecretAccess(secretAccessMsg{
+		name: "prod/db",
+		grants: []aws.PolicyGrant{
+			{Principal: "role/app-api", Policy: "read-prod", Managed: true, Actions: []string{"secretsmanager:GetSecretValue"}, Resource: "arn:aws:secretsmanager:*:*:secret:prod/*"},
+			{Principal: "user/alice", Policy: "AdministratorAccess", Managed: true, Actions: []string{"*"}, Resource: "*"},
+		},
+		policy:   `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"secretsmanager:GetSecretValue","Resource":"*"}]}`,
+		filesErr: errors.New("/srv/infra: no such file or directory"),
+	})
+	model = updated.(Model)
+	view := model.View()
+	for _, want := range []string{"✓ role/app-api", "• user/alice", "allows any principal", "1 IAM policy grants name", "Not checked: /srv/infra"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q on the access screen, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestAppsScreenGroupsSecretsByApp(t *testing.T) {
+	model := NewModel("default", "eu-west-2").WithConfig(&config.Config{Apps: []secretvalue.App{
+		{Name: "billing-api", Secrets: []secretvalue.AppSecret{{Secret: "prod/billing/db"}, {Secret: "prod/shared/smtp"}}},
+		{Name: "worker", Secrets: []secretvalue.AppSecret{{Secret: "prod/shared/smtp"}, {Secret: "prod/worker/queue"}}},
+	}})
+	model.loading = false
+	model.showSecrets([]models.Secret{{Name: "prod/billing/db"}, {Name: "prod/shared/smtp"}, {Name: "prod/other"}})
+	press := func(keys ...string) {
+		t.Helper()
+		for _, key := range keys {
+			updatedModel, _ := model.Update(keyMsgFor(key))
+			model = updatedModel.(Model)
+		}
+	}
+
+	press("t")
+	if model.currentScreen != ScreenApps {
+		t.Fatalf("expected the apps screen, got %v", model.currentScreen)
+	}
+	view := model.screen.View()
+	for _, want := range []string{"▾ billing-api (2)", "▾ worker (2)", "prod/worker/queue · not on the list"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the apps screen, got %q", want, view)
+		}
+	}
+
+	// Collapsing the first app moves the second up under it
+	press(" ")
+	if view := model.screen.View(); !strings.Contains(view, "▸ billing-api (2)") || strings.Contains(view, "prod/billing/db") {
+		t.Fatalf("expected billing-api collapsed, got %q", view)
+	}
+	press("enter", "down")
+	if view := model.screen.View(); !strings.Contains(view, ">     prod/billing/db") {
+		t.Fatalf("expected billing-api expanded again with its first secret selected, got %q", view)
+	}
+
+	updatedModel, cmd := model.Update(keyMsgFor("enter"))
+	model, _ = deliver(t, updatedModel.(Model), cmd)
+	if model.currentScreen != ScreenSecretDetail || model.grid.SelectedSecret().Name != "prod/billing/db" {
+		t.Fatalf("expected the picked secret's details, got screen %v", model.currentScreen)
+	}
+}
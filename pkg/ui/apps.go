@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// appsUsing returns the names of the apps in the config that use the named
+// secret, as the app: filter groups them
+func (m Model) appsUsing(name string) []string {
+	var names []string
+	for _, app := range m.apps {
+		if app.Uses(name) {
+			names = append(names, app.Name)
+		}
+	}
+	return names
+}
+
+// openApps lists the secrets of each app in the config, grouped under the
+// app's name. A secret used by several apps is listed under each.
+func (m Model) openApps() (tea.Model, tea.Cmd) {
+	groups := make([]components.AppGroup, len(m.apps))
+	for i, app := range m.apps {
+		groups[i] = components.AppGroup{Name: app.Name, Secrets: app.SecretNames()}
+	}
+	listed := make(map[string]bool, len(m.secrets))
+	for _, secret := range m.secrets {
+		listed[secret.Name] = true
+	}
+	view := components.NewAppGroups("Secrets by app", groups, listed)
+	m.openScreen(ScreenApps, newAppsScreen(view), ScreenSecretList)
+	return m, nil
+}
+
+// openAppSecret opens the detail screen of a secret picked from an app,
+// finding it as a secret linked on the command line is found, as it may not
+// be on the page loaded
+func (m Model) openAppSecret(name string) (tea.Model, tea.Cmd) {
+	if m.grid.SelectName(name) {
+		return m.Update(keyMsgFor("enter"))
+	}
+	if m.inventory == nil {
+		m.errorMessage = "No secret " + name + " on the list"
+		return m, nil
+	}
+	m.deepLink = name
+	return m, m.followDeepLink()
+}
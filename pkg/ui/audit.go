@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// auditState is the secret list behind the audit screen, kept while its
+// export prompt is open so the audit can be shown again afterwards
+type auditState struct {
+	secrets   []models.Secret
+	staleDays int
+}
+
+// auditLoadedMsg reports the result of loadAudit
+type auditLoadedMsg struct {
+	secrets []models.Secret
+	err     error
+}
+
+// loadAudit lists every secret with the dates the audit is based on
+func loadAudit(ctx context.Context, inv *inventory.Service) tea.Cmd {
+	return func() tea.Msg {
+		secrets, err := inv.Find(ctx, "")
+		return auditLoadedMsg{secrets: secrets, err: err}
+	}
+}
+
+// exportAudit writes the audit as CSV or JSON, chosen by extension, in the
+// order it is shown
+func exportAudit(audit auditState, path string) tea.Cmd {
+	return func() tea.Msg {
+		entries := inventory.Audit(audit.secrets, audit.staleDays, time.Now())
+		data, err := inventory.EncodeAudit(entries, inventory.ReportFormatForPath(path))
+		if err != nil {
+			return secretsExportedMsg{err: err}
+		}
+		written, err := writeSecretFile(path, data)
+		return secretsExportedMsg{path: written, count: len(entries), audit: true, err: err}
+	}
+}
+
+// startAudit lists every secret in the account for the audit screen
+func (m Model) startAudit() (tea.Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	return m, loadAudit(m.startLoad("Listing every secret for the audit…"), m.inventory)
+}
+
+// showAudit opens the audit screen once the secrets are listed
+func (m Model) showAudit(msg auditLoadedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		if updated, cmd, ok := m.reauthenticate(msg.err, retryAudit); ok {
+			return updated, cmd
+		}
+		m.showError("Failed to list secrets for the audit", msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.audit = auditState{secrets: msg.secrets, staleDays: m.staleDays}
+	m.openAudit()
+	return m, nil
+}
+
+// openAudit shows the audit screen for the listed secrets
+func (m *Model) openAudit() {
+	title := fmt.Sprintf("Secret access audit for %s (%s)", m.currentProfile, m.currentRegion)
+	view := components.NewAuditView(title, m.audit.secrets, m.audit.staleDays, time.Now())
+	m.openScreen(ScreenAudit, newAuditScreen(view), ScreenSecretList)
+}
+
+// promptAuditExport asks where to write the audit, keeping the threshold
+// it was shown with
+func (m Model) promptAuditExport(staleDays int) (tea.Model, tea.Cmd) {
+	m.audit.staleDays = staleDays
+	title := fmt.Sprintf("Export the audit (stale after %d days) as CSV or JSON (by extension)", staleDays)
+	m.openScreen(ScreenAuditExport, newPathScreen(title, "secrets-audit.csv"), ScreenSecretList)
+	return m, nil
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/audit"
+)
+
+// recordAudit appends an action to the audit log, when the audit_log
+// setting names one. Demo data and writes a dry run skipped are never
+// recorded. A log that can't be
+// written is reported in the header once and then left alone, rather than
+// interrupting every action.
+func (m *Model) recordAudit(action string, err error) {
+	if m.auditLog == "" || m.demoData() || m.dryRunPending() {
+		return
+	}
+	entry := audit.Entry{Source: "ui", Profile: m.currentProfile, Region: m.currentRegion, Action: action}
+	if m.identity != nil {
+		entry.Identity, entry.Account = m.identity.ARN, m.identity.Account
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if writeErr := audit.Append(m.auditLog, entry); writeErr != nil {
+		m.auditLog = ""
+		*m = m.WithConfigWarning(fmt.Errorf("audit_log: %w; no more actions are recorded", writeErr))
+	}
+}
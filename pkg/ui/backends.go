@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"context"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/azure"
+	"github.com/benjamingriff/secretsrc/pkg/kube"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The backend picker's entries
+const (
+	backendAWS   = "1"
+	backendAzure = "2"
+	backendKube  = "3"
+)
+
+// awsBackendName is what the picker calls Secrets Manager
+const awsBackendName = "AWS Secrets Manager"
+
+// backendOpenedMsg reports a backend picked with V, ready to sign in to.
+// profile and region are what the header shows for it.
+type backendOpenedMsg struct {
+	store   SecretStore
+	name    string
+	profile string
+	region  string
+	err     error
+}
+
+// openVault creates a Key Vault client, replaced in tests
+var openVault = func(vault string) (SecretStore, string, error) {
+	client, err := azure.OpenVault(vault)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, client.Name(), nil
+}
+
+// openCluster resolves the kubeconfig's current context and namespace,
+// replaced in tests
+var openCluster = func(ctx context.Context) (SecretStore, string, error) {
+	cluster := kube.New("", "")
+	if err := cluster.Resolve(ctx); err != nil {
+		return nil, "", err
+	}
+	return cluster, cluster.Label(), nil
+}
+
+// openBackends lists the backends the secret list can show, marking the
+// current one
+func (m Model) openBackends() (tea.Model, tea.Cmd) {
+	current := m.backendName
+	if m.awsBackend() {
+		current = awsBackendName
+	}
+	describe := func(name, description string) string {
+		if name == current {
+			return "current · " + description
+		}
+		return description
+	}
+	actions := []components.Action{
+		{Key: backendAWS, Title: awsBackendName, Description: describe(awsBackendName, "sign in with an AWS profile")},
+		{Key: backendAzure, Title: azure.Name, Description: describe(azure.Name, "a vault by name or URL, signed in with Azure credentials")},
+		{Key: backendKube, Title: kube.Name, Description: describe(kube.Name, "the current kubectl context and namespace, read-only")},
+	}
+	m.openScreen(ScreenBackends, newActionScreen("Backends", actions), ScreenSecretList)
+	return m, nil
+}
+
+// chooseBackend switches to the backend picked from the menu, asking for the
+// vault first for Key Vault
+func (m Model) chooseBackend(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case backendAWS:
+		if m.awsBackend() {
+			return m, nil
+		}
+		profile, region := m.awsProfile, m.awsRegion
+		if profile == "" {
+			// Started on another backend: as at startup, from the environment
+			profile, region = aws.GetDefaultProfile(), aws.GetDefaultRegion()
+		}
+		return m.switchBackend(backendOpenedMsg{profile: profile, region: region})
+	case backendAzure:
+		m.openScreen(ScreenBackendVault, newTextScreen("Browse an Azure key vault", "Vault name or URL:", "myvault"), ScreenSecretList)
+		return m, nil
+	case backendKube:
+		return m, openClusterBackend(m.startLoad("Reading the kubeconfig…"))
+	}
+	return m, nil
+}
+
+// openVaultBackend switches to the key vault entered at the prompt
+func (m Model) openVaultBackend(vault string) (tea.Model, tea.Cmd) {
+	store, label, err := openVault(vault)
+	if err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	m.closeScreen()
+	return m.switchBackend(backendOpenedMsg{store: store, name: azure.Name, profile: label})
+}
+
+// openClusterBackend resolves the cluster in the background, as it runs
+// kubectl
+func openClusterBackend(ctx context.Context) tea.Cmd {
+	return func() tea.Msg {
+		store, label, err := openCluster(ctx)
+		return backendOpenedMsg{store: store, name: kube.Name, profile: label, err: err}
+	}
+}
+
+// switchBackend signs in to the backend in msg, or to AWS when it has no
+// store. The AWS profile and region are kept to come back to. Dry runs and
+// undo entries belong to the backend they were made on, so both end here.
+func (m Model) switchBackend(msg backendOpenedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.loading = false
+		m.showError("Failed to open "+msg.name, msg.err)
+		return m, nil
+	}
+	if m.awsBackend() {
+		m.awsProfile, m.awsRegion = m.currentProfile, m.currentRegion
+	}
+	m = m.WithBackend(msg.store, msg.name)
+	m.dryRun = nil
+	m.undo = undoStack{}
+	return m, m.initAWSClient(msg.profile, msg.region)
+}
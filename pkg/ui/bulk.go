@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkKind is an operation that can be run on every marked secret
+type bulkKind int
+
+const (
+	bulkTag bulkKind = iota
+	bulkUntag
+	bulkDelete
+)
+
+// bulkOperation is a bulk operation waiting for confirmation or running
+type bulkOperation struct {
+	kind  bulkKind
+	key   string
+	value string
+	names []string
+	// previous is the value of the tag being removed on each secret that
+	// has it, to re-add on undo
+	previous map[string]string
+}
+
+// bulkResult is the outcome of a bulk operation on one secret
+type bulkResult struct {
+	name   string
+	detail string // What happened, e.g. the deletion date
+	err    error
+}
+
+// bulkDoneMsg reports the result of runBulk. Secrets that weren't reached
+// before the operation was cancelled have a context error.
+type bulkDoneMsg struct {
+	op      bulkOperation
+	results []bulkResult
+}
+
+// bulkActions lists the operations offered for the marked secrets
+func bulkActions(count int) []components.Action {
+	return []components.Action{
+		{Key: "t", Title: "Add a tag", Description: fmt.Sprintf("Tag the %d secrets, replacing the value of an existing tag", count)},
+		{Key: "u", Title: "Remove a tag", Description: fmt.Sprintf("Remove a tag from the %d secrets", count)},
+		{Key: "d", Title: "Schedule deletion", Description: fmt.Sprintf("Delete the %d secrets after a %d day recovery window", count, aws.RecoveryWindowDays)},
+	}
+}
+
+// parseTag parses a key=value tag. The value may be empty.
+func parseTag(input string) (key, value string, err error) {
+	key, value, ok := strings.Cut(strings.TrimSpace(input), "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("enter the tag as key=value")
+	}
+	return key, strings.TrimSpace(value), nil
+}
+
+// describe returns what the operation does, for the confirmation screen
+func (op bulkOperation) describe() (title, intro string) {
+	count := len(op.names)
+	switch op.kind {
+	case bulkTag:
+		return fmt.Sprintf("Tag %d secrets", count),
+			fmt.Sprintf("Add the tag %s=%s to these secrets:", op.key, op.value)
+	case bulkUntag:
+		return fmt.Sprintf("Remove a tag from %d secrets", count),
+			fmt.Sprintf("Remove the tag %s from these secrets:", op.key)
+	default:
+		return fmt.Sprintf("Delete %d secrets", count),
+			fmt.Sprintf("Schedule these secrets for deletion. They can be restored for %d days, then are deleted for good:", aws.RecoveryWindowDays)
+	}
+}
+
+// action describes the operation on one secret, for the audit log
+func (op bulkOperation) action(name string) string {
+	switch op.kind {
+	case bulkTag:
+		return fmt.Sprintf("Tagged %s with %s=%s", name, op.key, op.value)
+	case bulkUntag:
+		return fmt.Sprintf("Removed the tag %s from %s", op.key, name)
+	default:
+		return "Scheduled deletion of " + name
+	}
+}
+
+// progress returns the loading text after done of the secrets are finished
+func (op bulkOperation) progress(done int) string {
+	verb := map[bulkKind]string{bulkTag: "Tagged", bulkUntag: "Untagged", bulkDelete: "Scheduled deletion of"}[op.kind]
+	return fmt.Sprintf("%s %d of %d secrets…", verb, done, len(op.names))
+}
+
+// confirmItems lists the secrets the operation will run on
+func (op bulkOperation) confirmItems() []components.SummaryItem {
+	items := make([]components.SummaryItem, len(op.names))
+	for i, name := range op.names {
+		items[i] = components.SummaryItem{Name: name}
+	}
+	return items
+}
+
+// confirmName is what has to be typed to confirm the operation: the secret's
+// name, or "delete N secrets" for several
+func (op bulkOperation) confirmName() string {
+	if len(op.names) == 1 {
+		return op.names[0]
+	}
+	return fmt.Sprintf("delete %d secrets", len(op.names))
+}
+
+// runOne runs the operation on one secret
+func (op bulkOperation) runOne(ctx context.Context, client SecretStore, name string) (string, error) {
+	switch op.kind {
+	case bulkTag:
+		return "", client.TagSecret(ctx, name, op.key, op.value)
+	case bulkUntag:
+		return "", client.UntagSecret(ctx, name, op.key)
+	default:
+		deletionDate, err := client.ScheduleDeletion(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		return "deleted on " + deletionDate.Local().Format("Jan 2 2006"), nil
+	}
+}
+
+// runBulk runs op on each of its secrets in turn. A failure doesn't stop
+// the others. Each finished secret is reported with a loadProgressMsg,
+// followed by bulkDoneMsg with the result for every secret.
+func runBulk(ctx context.Context, client SecretStore, op bulkOperation) tea.Cmd {
+	return func() tea.Msg {
+		events := make(chan tea.Msg)
+		go func() {
+			results := make([]bulkResult, len(op.names))
+			for i, name := range op.names {
+				results[i].name = name
+				if err := ctx.Err(); err != nil {
+					results[i].err = err
+					continue
+				}
+				if client == nil {
+					results[i].err = fmt.Errorf("AWS client not initialized")
+					continue
+				}
+				results[i].detail, results[i].err = op.runOne(ctx, client, name)
+				events <- loadProgressMsg{text: op.progress(i + 1), next: waitForEvents(events)}
+			}
+			events <- bulkDoneMsg{op: op, results: results}
+		}()
+		return <-events
+	}
+}
+
+// chooseBulkAction starts the bulk operation picked from the bulk actions
+// menu: tags are asked for first, deletion goes straight to confirmation
+func (m Model) chooseBulkAction(key string) (tea.Model, tea.Cmd) {
+	switch key {
+	case "t":
+		title := fmt.Sprintf("Tag %d secrets", len(m.bulkOp.names))
+		m.openScreen(ScreenBulkTag, newTextScreen(title, "Tag to add, as key=value:", "team=payments"), ScreenSecretList)
+	case "u":
+		title := fmt.Sprintf("Remove a tag from %d secrets", len(m.bulkOp.names))
+		m.openScreen(ScreenBulkUntag, newTextScreen(title, "Key of the tag to remove:", "team"), ScreenSecretList)
+	case "d":
+		if managed := m.managedSecrets(); len(managed) > 0 {
+			m.errorMessage = managedDeleteMessage(managed, len(m.bulkOp.names))
+			return m, nil
+		}
+		m.bulkOp.kind = bulkDelete
+		m.confirmBulk()
+	}
+	return m, nil
+}
+
+// bulkSecrets returns the secrets a bulk action runs on: the marked ones,
+// or the selected one
+func (m Model) bulkSecrets() []models.Secret {
+	secrets := m.grid.MarkedSecrets()
+	if len(secrets) == 0 {
+		if secret := m.grid.SelectedSecret(); secret != nil {
+			secrets = []models.Secret{*secret}
+		}
+	}
+	return secrets
+}
+
+// managedSecrets returns the secrets a bulk action runs on that another AWS
+// service manages. Deleting them here would fail with AccessDenied.
+func (m Model) managedSecrets() []models.Secret {
+	var managed []models.Secret
+	for _, secret := range m.bulkSecrets() {
+		if secret.OwningService != "" {
+			managed = append(managed, secret)
+		}
+	}
+	return managed
+}
+
+// managedDeleteMessage explains why the managed secrets, of total being
+// deleted, can't be
+func managedDeleteMessage(managed []models.Secret, total int) string {
+	if len(managed) == 1 {
+		secret := managed[0]
+		service := models.OwningServiceName(secret.OwningService)
+		message := fmt.Sprintf("%s is managed by %s and can only be deleted through %s", secret.Name, service, service)
+		if total > 1 {
+			message += ", unmark it to delete the rest"
+		}
+		return message
+	}
+
+	names := make([]string, len(managed))
+	for i, secret := range managed {
+		names[i] = fmt.Sprintf("%s (%s)", secret.Name, models.OwningServiceName(secret.OwningService))
+	}
+	return fmt.Sprintf("%d of the secrets are managed by other AWS services and can only be deleted through them: %s. Unmark them to delete the rest",
+		len(managed), strings.Join(names, ", "))
+}
+
+// enterBulkTag reads the tag typed for a tag or untag operation, then asks
+// for confirmation
+func (m Model) enterBulkTag(value string) (tea.Model, tea.Cmd) {
+	if m.currentScreen == ScreenBulkUntag {
+		key := strings.TrimSpace(value)
+		if key == "" {
+			return m.updateScreen(promptErrorMsg{err: fmt.Errorf("enter the key of the tag to remove")})
+		}
+		m.bulkOp.kind, m.bulkOp.key = bulkUntag, key
+		m.bulkOp.previous = make(map[string]string)
+		for _, secret := range m.bulkSecrets() {
+			if value, ok := secret.Tags[key]; ok {
+				m.bulkOp.previous[secret.Name] = value
+			}
+		}
+	} else {
+		key, tagValue, err := parseTag(value)
+		if err != nil {
+			return m.updateScreen(promptErrorMsg{err: err})
+		}
+		m.bulkOp.kind, m.bulkOp.key, m.bulkOp.value = bulkTag, key, tagValue
+	}
+	m.confirmBulk()
+	return m, nil
+}
+
+// confirmBulk shows the pending bulk operation for confirmation
+func (m *Model) confirmBulk() {
+	title, intro := m.bulkOp.describe()
+	if m.bulkOp.kind == bulkDelete {
+		m.openDestructiveConfirm(ScreenBulkConfirm, title, intro, m.bulkOp.confirmItems(), m.bulkOp.confirmName(), ScreenSecretList)
+		return
+	}
+	m.openScreen(ScreenBulkConfirm, newConfirmScreen(title, intro, m.bulkOp.confirmItems()), ScreenSecretList)
+}
+
+// showBulkResults reports how a bulk operation went and unmarks the secrets
+// it succeeded on
+func (m Model) showBulkResults(msg bulkDoneMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+
+	items := make([]components.SummaryItem, len(msg.results))
+	failed := 0
+	undone := undoEntry{kind: undoDelete, key: msg.op.key, values: msg.op.previous}
+	for i, result := range msg.results {
+		items[i] = components.SummaryItem{Name: result.name, Status: components.ItemSucceeded, Detail: result.detail}
+		switch {
+		case cancelled(result.err):
+			items[i].Status, items[i].Detail = components.ItemPending, "skipped, cancelled"
+			failed++
+		case result.err != nil:
+			items[i].Status, items[i].Detail = components.ItemFailed, m.describeError("failed", result.err)
+			failed++
+		default:
+			m.grid.Unmark(result.name)
+			if _, tagged := msg.op.previous[result.name]; msg.op.kind == bulkDelete || tagged {
+				undone.names = append(undone.names, result.name)
+			}
+		}
+	}
+	if msg.op.kind == bulkUntag {
+		undone.kind = undoUntag
+	}
+	if msg.op.kind != bulkTag {
+		m.pushUndo(undone)
+	}
+
+	title, _ := msg.op.describe()
+	for _, result := range msg.results {
+		if !cancelled(result.err) {
+			m.recordAudit(msg.op.action(result.name), result.err)
+		}
+	}
+	intro := fmt.Sprintf("All %d secrets succeeded.", len(items))
+	if failed > 0 {
+		intro = fmt.Sprintf("%d succeeded, %d did not.", len(items)-failed, failed)
+	}
+	m.logOperation(fmt.Sprintf("%s: %d succeeded, %d did not", title, len(items)-failed, failed), nil)
+	m.openScreen(ScreenBulkResults, newResultsScreen(title, intro, items), ScreenSecretList)
+	m.bulkChanged = failed < len(items)
+	return m, nil
+}
+
+// closeBulkResults closes the results of a bulk operation, and reloads the
+// list if the operation changed any secrets, as deleted secrets drop out of it
+func (m Model) closeBulkResults() (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if !m.bulkChanged || m.inventory == nil {
+		return m, nil
+	}
+	m.bulkChanged = false
+	m.inventory.Reset()
+	m.currentPage = 0
+	return m, loadSecrets(m.startLoad("Refreshing secrets…"), m.inventory, 0)
+}
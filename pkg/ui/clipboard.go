@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// parseClipboardTimeout reads the clipboard_timeout setting. Empty, "0" and
+// invalid values leave copied values on the clipboard.
+func parseClipboardTimeout(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// clearClipboardAfter empties the clipboard after timeout if it still holds
+// copied, so anything copied since is kept. A zero timeout does nothing.
+func clearClipboardAfter(timeout time.Duration, copied string) tea.Cmd {
+	if timeout <= 0 {
+		return nil
+	}
+	return tea.Tick(timeout, func(time.Time) tea.Msg {
+		current, err := clipboard.ReadAll()
+		if err != nil || current != copied {
+			return nil
+		}
+		if err := clipboard.WriteAll(""); err != nil {
+			logging.Debugf("failed to clear the clipboard: %v", err)
+		}
+		return nil
+	})
+}
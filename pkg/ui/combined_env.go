@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// envCopiedMsg reports the result of copyCombinedEnv
+type envCopiedMsg struct {
+	secrets   int
+	vars      int
+	conflicts []string
+	dotenv    string // What was copied, to clear after the clipboard timeout
+	err       error
+}
+
+// combineDotenv merges the top-level fields of several secrets into one
+// dotenv document, named by mapping. A secret that isn't a JSON object
+// becomes a single variable named after the secret. Keys whose values differ
+// between secrets are prefixed with the secret name and returned as conflicts.
+func combineDotenv(names []string, values map[string]string, mapping secretvalue.EnvMapping) (string, []string, error) {
+	vars, conflicts, err := combineEnvVars(names, values, mapping)
+	if err != nil {
+		return "", nil, err
+	}
+	return secretvalue.FormatDotenv(vars), conflicts, nil
+}
+
+// combineEnvVars names the combined fields of several secrets by mapping,
+// see combineDotenv
+func combineEnvVars(names []string, values map[string]string, mapping secretvalue.EnvMapping) ([]secretvalue.EnvVar, []string, error) {
+	fields, conflicts := combineSecretFields(names, values)
+	vars, err := mapping.Apply(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return vars, conflicts, nil
+}
+
+// combineSecretFields merges the top-level fields of several secrets, with
+// a secret that isn't a JSON object as a single field named after it, see
+// combineDotenv
+func combineSecretFields(names []string, values map[string]string) ([]secretvalue.Field, []string) {
+	secrets := make([]secretvalue.SecretFields, 0, len(names))
+	for _, name := range names {
+		fields := []secretvalue.Field{{Key: secretvalue.ToUpperSnake(name), Value: values[name]}}
+		if doc, err := secretvalue.Parse(values[name]); err == nil {
+			if objectFields, err := secretvalue.Fields(doc); err == nil {
+				fields = objectFields
+			}
+		}
+		secrets = append(secrets, secretvalue.SecretFields{Secret: name, Fields: fields})
+	}
+
+	return secretvalue.CombineFields(secrets)
+}
+
+// copyCombinedEnv fetches the named secrets and copies them to the clipboard
+// as one combined dotenv document. Each fetched secret is reported with a
+// loadProgressMsg, followed by envCopiedMsg with the result.
+func copyCombinedEnv(ctx context.Context, client SecretStore, names []string, mapping secretvalue.EnvMapping) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil {
+			return envCopiedMsg{err: fmt.Errorf("AWS client not initialized")}
+		}
+
+		events := make(chan tea.Msg)
+		go func() {
+			values, err := fetchSecrets(ctx, client, names, func(fetched int) {
+				text := fmt.Sprintf("Fetched %d of %d secrets…", fetched, len(names))
+				events <- loadProgressMsg{text: text, next: waitForEvents(events)}
+			})
+			if err != nil {
+				events <- envCopiedMsg{err: err}
+				return
+			}
+
+			dotenv, conflicts, err := combineDotenv(names, values, mapping)
+			if err == nil {
+				err = clipboard.WriteAll(dotenv)
+			}
+			events <- envCopiedMsg{
+				secrets:   len(names),
+				vars:      strings.Count(dotenv, "\n"),
+				conflicts: conflicts,
+				dotenv:    dotenv,
+				err:       err,
+			}
+		}()
+		return <-events
+	}
+}
+
+// conflictNote explains the keys combineDotenv renamed, for a status message
+func conflictNote(conflicts []string) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s differed between secrets, so each copy is prefixed with its secret name)", strings.Join(conflicts, ", "))
+}
@@ -0,0 +1,247 @@
+package ui
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/kube"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// compareSource is what environment B is read from: another profile or
+// region, or a Kubernetes cluster
+type compareSource interface {
+	inventory.Source
+	GetSecret(ctx context.Context, secretName string) (*models.SecretValue, error)
+	GetProfile() string
+	GetRegion() string
+}
+
+// compareState is what the open secret is being compared with: environment
+// B, a profile and region or a cluster, and the secrets found there. Like a
+// copy's destination, a client for another profile is dropped once the
+// comparison is closed.
+type compareState struct {
+	profile string        // Chosen before the region is
+	client  compareSource // Reads B
+	label   string        // Shown to the user, e.g. "prod (eu-west-2)"
+	names   []string      // Secrets in B, to pick a counterpart from
+}
+
+// compareListedMsg reports the secrets found in environment B
+type compareListedMsg struct {
+	names []string
+	err   error
+}
+
+// compareLoadedMsg reports the result of loadComparison
+type compareLoadedMsg struct {
+	nameA string
+	nameB string
+	diffs []secretvalue.FieldDiff
+	err   error
+}
+
+// listCompareSecrets lists every secret name in environment B
+func listCompareSecrets(ctx context.Context, client compareSource) tea.Cmd {
+	return func() tea.Msg {
+		secrets, err := inventory.New(client, inventory.DefaultPageSize).Find(ctx, "")
+		if err != nil {
+			return compareListedMsg{err: err}
+		}
+		names := make([]string, len(secrets))
+		for i, secret := range secrets {
+			names[i] = secret.Name
+		}
+		slices.Sort(names)
+		return compareListedMsg{names: names}
+	}
+}
+
+// loadComparison fetches secret nameA with clientA and nameB with clientB
+// and compares their values key by key
+func loadComparison(ctx context.Context, clientA SecretStore, nameA string, clientB compareSource, nameB string) tea.Cmd {
+	return func() tea.Msg {
+		valueA, err := clientA.GetSecret(ctx, nameA)
+		if err != nil {
+			return compareLoadedMsg{nameA: nameA, nameB: nameB, err: err}
+		}
+		valueB, err := clientB.GetSecret(ctx, nameB)
+		if err != nil {
+			return compareLoadedMsg{nameA: nameA, nameB: nameB, err: err}
+		}
+		diffs := secretvalue.DiffFields(compareFields(valueA), compareFields(valueB))
+		return compareLoadedMsg{nameA: nameA, nameB: nameB, diffs: diffs}
+	}
+}
+
+// compareFields returns the fields a value is compared by. Binary values
+// are compared whole, as base64.
+func compareFields(value *models.SecretValue) []secretvalue.Field {
+	if value.IsBinary() {
+		return []secretvalue.Field{{Key: secretvalue.WholeValueKey, Value: base64.StdEncoding.EncodeToString(value.Binary)}}
+	}
+	return secretvalue.CompareFields(value.String)
+}
+
+// startCompare opens the profile selector for environment B
+func (m Model) startCompare() (tea.Model, tea.Cmd) {
+	if _, ok := m.awsSource("Comparing"); !ok {
+		return m, nil
+	}
+	profiles, err := aws.GetAvailableProfiles()
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load profiles: %v", err)
+		return m, nil
+	}
+	if len(profiles) == 0 {
+		// Without profiles only other regions of this one can be compared
+		profiles = []string{m.currentProfile}
+	}
+	m.openScreen(ScreenCompareProfile, newProfileScreen(m.summarizeProfiles(profiles), m.currentProfile), ScreenSecretDetail)
+	return m, nil
+}
+
+// newCluster returns the cluster compared with, replaced in tests
+var newCluster = func() compareSource {
+	return kube.New("", "")
+}
+
+// startCompareCluster compares the open secret with the Secret of the same
+// name in the kubeconfig's current context and namespace, to check what is
+// mounted in the cluster matches what is in AWS
+func (m Model) startCompareCluster() (tea.Model, tea.Cmd) {
+	if _, ok := m.awsSource("Comparing with the cluster"); !ok {
+		return m, nil
+	}
+	m.compare = compareState{}
+	return m.compareWith(newCluster(), "the cluster")
+}
+
+// chooseCompareProfile asks for environment B's region next
+func (m Model) chooseCompareProfile(profile string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if profile == "" {
+		return m, nil
+	}
+	m.compare = compareState{profile: profile}
+	regions := commonRegions() // Environment B may be another account
+	if profile == m.currentProfile {
+		regions = m.knownRegions()
+	}
+	m.openScreen(ScreenCompareRegion, newRegionScreen(regions, m.currentRegion), ScreenSecretDetail)
+	return m, nil
+}
+
+// chooseCompareRegion reaches environment B: another region needs no new
+// sign-in, another profile does
+func (m Model) chooseCompareRegion(region string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	client, ok := m.awsSource("Comparing")
+	profile := m.compare.profile
+	if !ok || region == "" || profile == "" {
+		return m, nil
+	}
+	if profile == m.currentProfile && region == m.currentRegion {
+		m.compare = compareState{}
+		m.statusMessage = "That is where the secret is, pick another profile or region"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	if profile == m.currentProfile {
+		return m.compareWith(client.WithRegion(region), profile+" ("+region+")")
+	}
+	return m.signInSecond(secondForCompare, profile, region)
+}
+
+// compareWith lists the secrets in environment B to find the counterpart
+func (m Model) compareWith(client compareSource, label string) (tea.Model, tea.Cmd) {
+	m.compare.client = client
+	m.compare.label = label
+	ctx := m.startLoad("Listing secrets in " + label + "…")
+	return m, listCompareSecrets(ctx, client)
+}
+
+// compareListed compares with the same-named secret in environment B, or
+// asks for the counterpart when there is none
+func (m Model) compareListed(msg compareListedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	secret := m.grid.SelectedSecret()
+	if msg.err != nil || secret == nil {
+		if msg.err != nil {
+			m.showErrorFor(m.compare.client.GetProfile(), m.compare.client.GetRegion(), "Failed to list secrets in "+m.compare.label, msg.err)
+		}
+		m.compare = compareState{}
+		return m, nil
+	}
+	if len(msg.names) == 0 {
+		m.errorMessage = "No secrets found in " + m.compare.label
+		m.compare = compareState{}
+		return m, nil
+	}
+
+	m.compare.names = msg.names
+	if slices.Contains(msg.names, secret.Name) {
+		return m.compareCounterpart(secret.Name)
+	}
+	if _, ok := m.compare.client.(*kube.Cluster); ok {
+		// Named as K copies it, for a secret synced into the cluster
+		if name := secretvalue.KubernetesName(secret.Name); slices.Contains(msg.names, name) {
+			return m.compareCounterpart(name)
+		}
+	}
+	m.statusMessage = "No " + secret.Name + " in " + m.compare.label + ", pick its counterpart"
+	m.pickCounterpart()
+	return m, clearStatusAfter(4 * time.Second)
+}
+
+// pickCounterpart lists environment B's secrets to compare with
+func (m *Model) pickCounterpart() {
+	selected := ""
+	if secret := m.grid.SelectedSecret(); secret != nil {
+		selected = secret.Name
+	}
+	title := "Compare with a secret in " + m.compare.label
+	m.openScreen(ScreenComparePick, newPickerScreen(title, m.compare.names, selected), ScreenSecretDetail)
+}
+
+// compareCounterpart compares the open secret with nameB in environment B
+func (m Model) compareCounterpart(nameB string) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	clientA, ok := m.awsSource("Comparing")
+	if !ok || m.compare.client == nil {
+		return m, nil
+	}
+	ctx := m.startLoad("Comparing with " + nameB + " in " + m.compare.label + "…")
+	return m, loadComparison(ctx, clientA, secret.Name, m.compare.client, nameB)
+}
+
+// showComparison opens the diff of the two values
+func (m Model) showComparison(msg compareLoadedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showError("Failed to compare with "+msg.nameB, msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+
+	labelA := fmt.Sprintf("%s in %s (%s)", msg.nameA, m.currentProfile, m.currentRegion)
+	labelB := fmt.Sprintf("%s in %s", msg.nameB, m.compare.label)
+	m.recordAudit(fmt.Sprintf("Compared the values of %s and %s", msg.nameA, labelB), nil)
+	diff := components.NewValueDiff("Compare "+msg.nameA, labelA, labelB, msg.diffs)
+	m.openScreen(ScreenCompare, newCompareScreen(diff), ScreenSecretDetail)
+	return m, nil
+}
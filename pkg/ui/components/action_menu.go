@@ -0,0 +1,95 @@
+package components
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action is an operation offered by an actions menu. Key is the shortcut
+// that performs the same operation outside the menu.
+type Action struct {
+	Key         string
+	Title       string
+	Description string
+}
+
+// actionItem is a list item for an action.
+type actionItem struct {
+	action Action
+}
+
+// FilterValue implements list.Item.
+func (i actionItem) FilterValue() string {
+	return i.action.Title
+}
+
+// Title returns the list item title.
+func (i actionItem) Title() string {
+	return i.action.Title + "  (" + i.action.Key + ")"
+}
+
+// Description returns the list item description.
+func (i actionItem) Description() string {
+	return i.action.Description
+}
+
+// ActionMenu is a component for choosing an action from a list.
+type ActionMenu struct {
+	list list.Model
+}
+
+// NewActionMenu creates a new action menu.
+func NewActionMenu(title string, actions []Action, width, height int) ActionMenu {
+	delegate := newListDelegate()
+
+	items := make([]list.Item, len(actions))
+	for i, action := range actions {
+		items[i] = actionItem{action: action}
+	}
+
+	l := list.New(items, delegate, width, height)
+	applyListTheme(&l)
+	l.Title = title
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+
+	return ActionMenu{
+		list: l,
+	}
+}
+
+// SelectedAction returns the selected action, or nil if none is selected.
+func (am *ActionMenu) SelectedAction() *Action {
+	item := am.list.SelectedItem()
+	if item == nil {
+		return nil
+	}
+	actionItem, ok := item.(actionItem)
+	if !ok {
+		return nil
+	}
+	return &actionItem.action
+}
+
+// IsFiltering reports whether the user is typing a filter, when enter and
+// esc belong to the filter rather than the menu.
+func (am *ActionMenu) IsFiltering() bool {
+	return am.list.FilterState() == list.Filtering
+}
+
+// Update updates the menu.
+func (am *ActionMenu) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	am.list, cmd = am.list.Update(msg)
+	return cmd
+}
+
+// View renders the menu.
+func (am *ActionMenu) View() string {
+	return am.list.View()
+}
+
+// SetSize updates the menu dimensions.
+func (am *ActionMenu) SetSize(width, height int) {
+	am.list.SetSize(width, height)
+}
@@ -0,0 +1,196 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// appGroupsChrome is the number of lines around the groups: the title and
+// its spacing, and the scroll hints
+const appGroupsChrome = 4
+
+// AppGroup is an app from the config and the secrets it uses
+type AppGroup struct {
+	Name    string
+	Secrets []string
+}
+
+// AppGroups is a component listing the secrets of each app under the app's
+// name, as a section that can be collapsed
+type AppGroups struct {
+	title     string
+	groups    []AppGroup
+	listed    map[string]bool // The secrets on the list; others are marked
+	collapsed map[string]bool // By app name
+	cursor    int             // Index into rows
+	offset    int
+	width     int
+	height    int
+}
+
+// appRow is a line of the groups: an app's header, or one of its secrets
+type appRow struct {
+	group  int
+	secret int // -1 for the header
+}
+
+// NewAppGroups creates a view of groups. listed names the secrets on the
+// secret list, so those that aren't can be marked.
+func NewAppGroups(title string, groups []AppGroup, listed map[string]bool) AppGroups {
+	return AppGroups{title: title, groups: groups, listed: listed, collapsed: make(map[string]bool)}
+}
+
+// SetSize updates the view dimensions
+func (a *AppGroups) SetSize(width, height int) {
+	a.width = width
+	a.height = height
+	a.scrollToCursor()
+}
+
+// rows lists the headers, and the secrets of the groups that are expanded
+func (a *AppGroups) rows() []appRow {
+	var rows []appRow
+	for i, group := range a.groups {
+		rows = append(rows, appRow{group: i, secret: -1})
+		if a.collapsed[group.Name] {
+			continue
+		}
+		for j := range group.Secrets {
+			rows = append(rows, appRow{group: i, secret: j})
+		}
+	}
+	return rows
+}
+
+// Selected returns the app and secret the cursor is on; secret is "" on an
+// app's header
+func (a *AppGroups) Selected() (app, secret string) {
+	rows := a.rows()
+	if a.cursor >= len(rows) {
+		return "", ""
+	}
+	row := rows[a.cursor]
+	group := a.groups[row.group]
+	if row.secret < 0 {
+		return group.Name, ""
+	}
+	return group.Name, group.Secrets[row.secret]
+}
+
+// Toggle collapses the app the cursor is in, moving the cursor to its
+// header, or expands it again
+func (a *AppGroups) Toggle() {
+	rows := a.rows()
+	if a.cursor >= len(rows) {
+		return
+	}
+	group := rows[a.cursor].group
+	name := a.groups[group].Name
+	a.collapsed[name] = !a.collapsed[name]
+	for i, row := range a.rows() {
+		if row.group == group && row.secret < 0 {
+			a.cursor = i
+			break
+		}
+	}
+	a.scrollToCursor()
+}
+
+// Update moves the cursor
+func (a *AppGroups) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			a.cursor = max(a.cursor-1, 0)
+		case "down", "j":
+			a.cursor = min(a.cursor+1, max(len(a.rows())-1, 0))
+		case "home":
+			a.cursor = 0
+		case "end":
+			a.cursor = max(len(a.rows())-1, 0)
+		}
+		a.scrollToCursor()
+	}
+	return nil
+}
+
+// visibleRows is how many rows fit at the current height
+func (a *AppGroups) visibleRows() int {
+	if a.height == 0 {
+		return len(a.rows())
+	}
+	return max(a.height-appGroupsChrome, 3)
+}
+
+// scrollToCursor scrolls just enough to show the cursor
+func (a *AppGroups) scrollToCursor() {
+	visible := a.visibleRows()
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+visible {
+		a.offset = a.cursor - visible + 1
+	}
+	a.offset = max(min(a.offset, len(a.rows())-visible), 0)
+}
+
+// View renders the groups
+func (a *AppGroups) View() string {
+	t := theme.Current()
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(t.Primary)
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(t.Text)
+	secretStyle := lipgloss.NewStyle().Foreground(t.Text)
+	subtleStyle := lipgloss.NewStyle().Foreground(t.Subtle)
+	selectedStyle := lipgloss.NewStyle().Bold(true).Foreground(t.Primary)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(a.title) + "\n\n")
+	if len(a.groups) == 0 {
+		b.WriteString(subtleStyle.Render("  No apps in the config's apps setting") + "\n")
+		return b.String()
+	}
+
+	rows := a.rows()
+	end := min(a.offset+a.visibleRows(), len(rows))
+	if a.offset > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↑ %d more", a.offset)) + "\n")
+	}
+	for i, row := range rows[a.offset:end] {
+		group := a.groups[row.group]
+		var line string
+		style := secretStyle
+		if row.secret < 0 {
+			marker := "▾"
+			if a.collapsed[group.Name] {
+				marker = "▸"
+			}
+			line = fmt.Sprintf("%s %s (%d)", marker, group.Name, len(group.Secrets))
+			style = headerStyle
+		} else {
+			name := group.Secrets[row.secret]
+			line = "    " + name
+			if !a.listed[name] {
+				line += " · not on the list"
+				style = subtleStyle
+			}
+		}
+		if a.offset+i == a.cursor {
+			style = selectedStyle
+			line = "> " + line
+		} else {
+			line = "  " + line
+		}
+		if a.width > 0 {
+			line = truncate(line, a.width)
+		}
+		b.WriteString(style.Render(line) + "\n")
+	}
+	if end < len(rows) {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↓ %d more", len(rows)-end)) + "\n")
+	}
+	return b.String()
+}
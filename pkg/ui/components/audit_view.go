@@ -0,0 +1,174 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// auditViewChrome is the number of lines around the rows: the title, the
+// totals and the column header with their spacing, and the scroll hints
+const auditViewChrome = 8
+
+// staleStep is how many days + and - change the stale threshold by
+const staleStep = 30
+
+// AuditView is a component listing secrets by when they were last accessed,
+// highlighting those not accessed within a threshold that can be adjusted
+type AuditView struct {
+	title     string
+	secrets   []models.Secret
+	staleDays int
+	now       time.Time
+	entries   []inventory.AuditEntry
+	offset    int
+	width     int
+	height    int
+}
+
+// NewAuditView creates a new audit of secrets as of now, marking those idle
+// for staleDays or more
+func NewAuditView(title string, secrets []models.Secret, staleDays int, now time.Time) AuditView {
+	a := AuditView{title: title, secrets: secrets, now: now}
+	a.SetStaleDays(staleDays)
+	return a
+}
+
+// StaleDays returns the current stale threshold
+func (a *AuditView) StaleDays() int {
+	return a.staleDays
+}
+
+// SetStaleDays changes the stale threshold
+func (a *AuditView) SetStaleDays(days int) {
+	a.staleDays = max(days, 1)
+	a.entries = inventory.Audit(a.secrets, a.staleDays, a.now)
+}
+
+// Entries returns the audited secrets in the order shown
+func (a *AuditView) Entries() []inventory.AuditEntry {
+	return a.entries
+}
+
+// SetSize updates the audit dimensions
+func (a *AuditView) SetSize(width, height int) {
+	a.width = width
+	a.height = height
+	a.offset = min(a.offset, a.maxOffset())
+}
+
+// Update scrolls the rows and adjusts the threshold
+func (a *AuditView) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			a.offset = max(a.offset-1, 0)
+		case "down", "j":
+			a.offset = min(a.offset+1, a.maxOffset())
+		case "+", "=":
+			a.SetStaleDays(a.staleDays + staleStep)
+		case "-":
+			a.SetStaleDays(a.staleDays - staleStep)
+		}
+	}
+	return nil
+}
+
+// visibleRows is how many rows fit at the current height
+func (a *AuditView) visibleRows() int {
+	if a.height == 0 {
+		return len(a.entries)
+	}
+	return max(a.height-auditViewChrome, 3)
+}
+
+// maxOffset is the furthest the rows can scroll
+func (a *AuditView) maxOffset() int {
+	return max(len(a.entries)-a.visibleRows(), 0)
+}
+
+// View renders the audit
+func (a *AuditView) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary)
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(t.Secondary).
+		Bold(true)
+
+	subtleStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	totals := inventory.Totals(a.entries)
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(a.title) + "\n\n")
+	b.WriteString(fmt.Sprintf("%d secrets, %d not accessed in %d days (%d never accessed)",
+		totals.Secrets, totals.Stale, a.staleDays, totals.NeverAccessed) + "\n\n")
+
+	nameWidth := len("Name")
+	for _, entry := range a.entries {
+		nameWidth = max(nameWidth, lipgloss.Width(entry.Secret.Name))
+	}
+	header := fmt.Sprintf("  %s  %-12s  %s", padRight("Name", nameWidth), "Accessed", "Idle")
+	b.WriteString(headerStyle.Render(a.fit(header)) + "\n")
+
+	end := min(a.offset+a.visibleRows(), len(a.entries))
+	if a.offset > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↑ %d more", a.offset)) + "\n")
+	}
+	for _, entry := range a.entries[a.offset:end] {
+		b.WriteString(a.renderRow(entry, nameWidth) + "\n")
+	}
+	if end < len(a.entries) {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↓ %d more", len(a.entries)-end)) + "\n")
+	}
+	if len(a.entries) == 0 {
+		b.WriteString(subtleStyle.Render("  No secrets") + "\n")
+	}
+
+	return b.String()
+}
+
+// renderRow renders one secret, highlighted when it is stale
+func (a *AuditView) renderRow(entry inventory.AuditEntry, nameWidth int) string {
+	t := theme.Current()
+
+	accessed := "never"
+	if entry.Secret.LastAccessedDate != nil {
+		accessed = entry.Secret.LastAccessedDate.Local().Format("Jan 2 2006")
+	}
+	idle := "unknown"
+	switch {
+	case entry.IdleDays < 0:
+	case entry.NeverAccessed():
+		idle = fmt.Sprintf("%d days since changed", entry.IdleDays)
+	default:
+		idle = fmt.Sprintf("%d days", entry.IdleDays)
+	}
+
+	mark := " "
+	style := lipgloss.NewStyle().Foreground(t.Text)
+	if entry.Stale {
+		mark = "!"
+		style = lipgloss.NewStyle().Foreground(t.Error)
+	}
+	line := fmt.Sprintf("%s %s  %-12s  %s", mark, padRight(entry.Secret.Name, nameWidth), accessed, idle)
+	return style.Render(a.fit(line))
+}
+
+// fit truncates a line to the width, once it is known
+func (a *AuditView) fit(line string) string {
+	if a.width > 0 {
+		return truncate(line, a.width)
+	}
+	return line
+}
@@ -2,35 +2,84 @@ package components
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/benjamingriff/secretsrc/pkg/models"
 )
 
 const (
-	MinCellWidth      = 35  // Minimum cell width
-	MaxCellWidth      = 60  // Maximum cell width
+	MinCellWidth      = 35 // Minimum cell width
+	MaxCellWidth      = 60 // Maximum cell width
 	DefaultCellHeight = 4
 	CellSpacing       = 2 // Space between cells
+
+	listDateWidth    = 12 // Width of the date column in list layout ("Jan 02, 2006")
+	listKMSWidth     = 18 // Width of the KMS key column in list layout
+	listChromeHeight = 3  // Header row plus pagination indicator in list layout
+)
+
+// Layout controls how the grid arranges secrets
+type Layout int
+
+const (
+	LayoutGrid Layout = iota // Multi-column cells
+	LayoutList               // Compact single-column rows with description and date columns
 )
 
+// String returns the layout name used in config
+func (l Layout) String() string {
+	if l == LayoutList {
+		return "list"
+	}
+	return "grid"
+}
+
+// ParseLayout converts a config value to a Layout, defaulting to the grid
+func ParseLayout(value string) Layout {
+	if value == "list" {
+		return LayoutList
+	}
+	return LayoutGrid
+}
+
 // SecretGrid displays secrets in a 2D grid layout
 type SecretGrid struct {
-	secrets         []models.Secret  // All secrets
-	filteredSecrets []models.Secret  // Filtered secrets (used for display)
-	cursorRow       int              // Current cursor row (0-based)
-	cursorCol       int              // Current cursor column (0-based)
-	numCols         int              // Number of columns in grid
-	numRows         int              // Number of rows visible on screen
-	cellWidth       int              // Calculated cell width based on available space
-	gridPageIndex   int              // Current screen page index
-	totalGridPages  int              // Total screen pages for filtered secrets
-	width           int              // Available width
-	height          int              // Available height
-	filterQuery     string           // Current filter text
-	filtering       bool             // Whether filter mode is active
+	secrets         []models.Secret     // All secrets
+	filteredSecrets []models.Secret     // Filtered secrets (used for display)
+	cursorRow       int                 // Current cursor row (0-based)
+	cursorCol       int                 // Current cursor column (0-based)
+	numCols         int                 // Number of columns in grid
+	numRows         int                 // Number of rows visible on screen
+	cellWidth       int                 // Calculated cell width based on available space
+	gridPageIndex   int                 // Current screen page index
+	totalGridPages  int                 // Total screen pages for filtered secrets
+	width           int                 // Available width
+	height          int                 // Available height
+	filterQuery     string              // Current filter text
+	filtering       bool                // Whether filter mode is active
+	layout          Layout              // Grid or list layout
+	compact         bool                // Names only, one per line, for narrow terminals
+	sortOrder       inventory.SortOrder // Display order, applied after filtering
+
+	// marked is the secrets marked for bulk actions, by name
+	marked map[string]models.Secret
+	// viewedAt is when each secret's value was last viewed, by ARN, for
+	// badging secrets changed since. Nil unless value history is on.
+	viewedAt map[string]time.Time
+	// highlights labels the secrets new or changed since the last watch
+	// refresh, by name
+	highlights map[string]string
+	// flagged is the secrets to notify about when they rotate, by name
+	flagged map[string]bool
+	// apps is the secret names of each app in the config, for the app:
+	// filter
+	apps map[string][]string
 }
 
 // NewSecretGrid creates a new secret grid component
@@ -58,6 +107,58 @@ func (g *SecretGrid) SetSecrets(secrets []models.Secret) {
 	g.gridPageIndex = 0
 }
 
+// ToggleMark marks or unmarks the selected secret for bulk actions. Marks are
+// kept by name, so they survive filtering, sorting and AWS page changes.
+func (g *SecretGrid) ToggleMark() {
+	secret := g.SelectedSecret()
+	if secret == nil {
+		return
+	}
+	if g.marked == nil {
+		g.marked = make(map[string]models.Secret)
+	}
+	if g.IsMarked(secret.Name) {
+		delete(g.marked, secret.Name)
+	} else {
+		g.marked[secret.Name] = *secret
+	}
+}
+
+// Unmark unmarks the named secret, e.g. once a bulk action is done with it
+func (g *SecretGrid) Unmark(name string) {
+	delete(g.marked, name)
+}
+
+// IsMarked reports whether the named secret is marked
+func (g *SecretGrid) IsMarked(name string) bool {
+	_, ok := g.marked[name]
+	return ok
+}
+
+// MarkedNames returns the names of the marked secrets in sorted order
+func (g *SecretGrid) MarkedNames() []string {
+	names := make([]string, 0, len(g.marked))
+	for name := range g.marked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarkedSecrets returns the marked secrets in name order
+func (g *SecretGrid) MarkedSecrets() []models.Secret {
+	secrets := make([]models.Secret, 0, len(g.marked))
+	for _, name := range g.MarkedNames() {
+		secrets = append(secrets, g.marked[name])
+	}
+	return secrets
+}
+
+// ClearMarks unmarks every secret
+func (g *SecretGrid) ClearMarks() {
+	g.marked = nil
+}
+
 // SetSize updates the grid dimensions
 func (g *SecretGrid) SetSize(width, height int) {
 	g.width = width
@@ -66,8 +167,66 @@ func (g *SecretGrid) SetSize(width, height int) {
 	g.validateCursorPosition()
 }
 
+// SetLayout switches between the grid and list layouts, keeping the selected secret in view
+func (g *SecretGrid) SetLayout(layout Layout) {
+	g.relayout(func() { g.layout = layout })
+}
+
+// SetCompact shows just the secret names, one per line, whatever the
+// layout, for terminals too narrow for the grid or the list's columns. The
+// layout is kept for when there is room again.
+func (g *SecretGrid) SetCompact(compact bool) {
+	if compact == g.compact {
+		return
+	}
+	g.relayout(func() { g.compact = compact })
+}
+
+// Compact reports whether only the secret names are shown
+func (g *SecretGrid) Compact() bool {
+	return g.compact
+}
+
+// relayout makes a change to how the secrets are arranged, keeping the
+// selected secret in view
+func (g *SecretGrid) relayout(change func()) {
+	selectedIdx := g.gridPageIndex*g.numCols*g.numRows + g.cursorIndex()
+
+	change()
+	g.calculateGridDimensions()
+
+	g.gridPageIndex = 0
+	g.cursorRow = 0
+	g.cursorCol = 0
+	if perPage := g.numCols * g.numRows; perPage > 0 && selectedIdx < len(g.filteredSecrets) {
+		g.gridPageIndex = selectedIdx / perPage
+		g.cursorRow = (selectedIdx % perPage) / g.numCols
+		g.cursorCol = (selectedIdx % perPage) % g.numCols
+	}
+}
+
+// Layout returns the current layout
+func (g *SecretGrid) Layout() Layout {
+	return g.layout
+}
+
+// singleColumn reports whether the secrets are drawn one per line, in the
+// list layout or compact
+func (g *SecretGrid) singleColumn() bool {
+	return g.layout == LayoutList || g.compact
+}
+
 // calculateGridDimensions calculates numCols, numRows, cellWidth, and totalGridPages
 func (g *SecretGrid) calculateGridDimensions() {
+	if g.singleColumn() {
+		// One secret per line, leaving room for the column header and pagination
+		g.numRows = max(1, g.height-listChromeHeight)
+		g.numCols = 1
+		g.cellWidth = max(MinCellWidth, g.width)
+		g.updateTotalGridPages()
+		return
+	}
+
 	// Calculate rows based on available height
 	cellHeight := DefaultCellHeight + 1
 	g.numRows = max(1, g.height/cellHeight)
@@ -111,7 +270,11 @@ func (g *SecretGrid) calculateGridDimensions() {
 	g.numCols = optimalCols
 	g.cellWidth = optimalWidth
 
-	// Calculate total grid pages
+	g.updateTotalGridPages()
+}
+
+// updateTotalGridPages recalculates totalGridPages for the filtered secrets
+func (g *SecretGrid) updateTotalGridPages() {
 	secretsPerPage := g.numCols * g.numRows
 	if secretsPerPage > 0 && len(g.filteredSecrets) > 0 {
 		g.totalGridPages = (len(g.filteredSecrets) + secretsPerPage - 1) / secretsPerPage
@@ -155,6 +318,106 @@ func (g *SecretGrid) SelectedSecret() *models.Secret {
 	return nil
 }
 
+// Len returns how many secrets the filter leaves
+func (g *SecretGrid) Len() int {
+	return len(g.filteredSecrets)
+}
+
+// SelectedIndex returns the position of the selected secret among the
+// filtered secrets
+func (g *SecretGrid) SelectedIndex() int {
+	return g.gridPageIndex*g.numCols*g.numRows + g.cursorIndex()
+}
+
+// Screen returns the screen of the grid shown, counting from 1, and how
+// many screens the filtered secrets take
+func (g *SecretGrid) Screen() (int, int) {
+	return g.gridPageIndex + 1, max(g.totalGridPages, 1)
+}
+
+// Select selects the filtered secret at index, clamped to the list, moving
+// to the screen it is on
+func (g *SecretGrid) Select(index int) {
+	perPage := g.numCols * g.numRows
+	if perPage == 0 || len(g.filteredSecrets) == 0 {
+		return
+	}
+	index = max(min(index, len(g.filteredSecrets)-1), 0)
+	g.gridPageIndex = index / perPage
+	g.cursorRow = (index % perPage) / g.numCols
+	g.cursorCol = (index % perPage) % g.numCols
+}
+
+// SelectName selects the filtered secret called name, reporting whether
+// there is one
+func (g *SecretGrid) SelectName(name string) bool {
+	for i, secret := range g.filteredSecrets {
+		if secret.Name == name {
+			g.Select(i)
+			return true
+		}
+	}
+	return false
+}
+
+// SecretAt returns the position among the filtered secrets of the secret
+// drawn at x, y, relative to the top left of the grid's view
+func (g *SecretGrid) SecretAt(x, y int) (int, bool) {
+	visible := g.getVisibleSecrets()
+	if x < 0 || y < 0 || x >= g.width || len(visible) == 0 {
+		return 0, false
+	}
+
+	offset := g.gridPageIndex * g.numCols * g.numRows
+	if g.singleColumn() {
+		// The column header is the first line
+		if y == 0 || y > len(visible) {
+			return 0, false
+		}
+		return offset + y - 1, true
+	}
+
+	col := x / g.cellWidth
+	if col >= g.numCols {
+		return 0, false
+	}
+	// Badges can wrap a cell onto more lines, so rows are measured as drawn
+	top := 0
+	for row := 0; row*g.numCols < len(visible); row++ {
+		height := DefaultCellHeight
+		for c := 0; c < g.numCols && row*g.numCols+c < len(visible); c++ {
+			height = max(height, lipgloss.Height(g.renderCell(visible[row*g.numCols+c], false)))
+		}
+		if y < top+height {
+			if i := row*g.numCols + col; i < len(visible) {
+				return offset + i, true
+			}
+			return 0, false
+		}
+		top += height
+	}
+	return 0, false
+}
+
+// JumpToPrefix selects the next secret after the selected one, wrapping
+// around, whose name or one of its path segments starts with prefix. It
+// reports whether one was found.
+func (g *SecretGrid) JumpToPrefix(prefix string) bool {
+	prefix = strings.ToLower(prefix)
+	count := len(g.filteredSecrets)
+	start := g.SelectedIndex()
+	for step := 1; step <= count; step++ {
+		index := (start + step) % count
+		for _, segment := range strings.Split(strings.ToLower(g.filteredSecrets[index].Name), "/") {
+			if strings.HasPrefix(segment, prefix) {
+				g.Select(index)
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // getVisibleSecrets returns the secrets visible on the current grid page
 func (g *SecretGrid) getVisibleSecrets() []models.Secret {
 	secretsPerPage := g.numCols * g.numRows
@@ -246,19 +509,10 @@ func (g *SecretGrid) prevGridPage() {
 func (g *SecretGrid) applyFilter(query string) {
 	g.filterQuery = query
 
-	if query == "" {
-		g.filteredSecrets = g.secrets
+	if rest, ok := strings.CutPrefix(strings.ToLower(query), appFilterPrefix); ok {
+		g.filteredSecrets = inventory.Sort(g.appSecrets(strings.TrimSpace(rest)), g.sortOrder)
 	} else {
-		filtered := []models.Secret{}
-		lowerQuery := strings.ToLower(query)
-
-		for _, secret := range g.secrets {
-			if strings.Contains(strings.ToLower(secret.Name), lowerQuery) {
-				filtered = append(filtered, secret)
-			}
-		}
-
-		g.filteredSecrets = filtered
+		g.filteredSecrets = inventory.Sort(inventory.Filter(g.secrets, query), g.sortOrder)
 	}
 
 	// Reset navigation state after filter
@@ -268,6 +522,55 @@ func (g *SecretGrid) applyFilter(query string) {
 	g.calculateGridDimensions()
 }
 
+// appFilterPrefix scopes a filter to the secrets of the apps whose names
+// start with the rest of the query
+const appFilterPrefix = "app:"
+
+// SetApps sets the secret names of each app, by app name, for the app:
+// filter
+func (g *SecretGrid) SetApps(apps map[string][]string) {
+	g.apps = apps
+	g.applyFilter(g.filterQuery)
+}
+
+// appSecrets returns the secrets of the apps whose names start with prefix,
+// in lower case
+func (g *SecretGrid) appSecrets(prefix string) []models.Secret {
+	names := make(map[string]bool)
+	for app, secrets := range g.apps {
+		if strings.HasPrefix(strings.ToLower(app), prefix) {
+			for _, name := range secrets {
+				names[name] = true
+			}
+		}
+	}
+	filtered := []models.Secret{}
+	for _, secret := range g.secrets {
+		if names[secret.Name] {
+			filtered = append(filtered, secret)
+		}
+	}
+	return filtered
+}
+
+// SetSortOrder changes the display order of the secrets
+func (g *SecretGrid) SetSortOrder(order inventory.SortOrder) {
+	g.sortOrder = order
+	g.applyFilter(g.filterQuery)
+}
+
+// SortOrder returns the current display order
+func (g *SecretGrid) SortOrder() inventory.SortOrder {
+	return g.sortOrder
+}
+
+// SetFilter shows only the secrets matching query, as if it had been typed
+// after /
+func (g *SecretGrid) SetFilter(query string) {
+	g.filtering = false
+	g.applyFilter(query)
+}
+
 // clearFilter clears the current filter
 func (g *SecretGrid) clearFilter() {
 	g.filterQuery = ""
@@ -343,15 +646,22 @@ func (g *SecretGrid) View() string {
 		if g.filtering && g.filterQuery != "" {
 			return lipgloss.NewStyle().
 				Padding(2).
-				Foreground(lipgloss.Color("241")).
+				Foreground(theme.Current().Subtle).
 				Render(fmt.Sprintf("No secrets match '%s'", g.filterQuery))
 		}
 		return lipgloss.NewStyle().
 			Padding(2).
-			Foreground(lipgloss.Color("241")).
+			Foreground(theme.Current().Subtle).
 			Render("No secrets found")
 	}
 
+	if g.compact {
+		return g.viewCompact(visibleSecrets)
+	}
+	if g.layout == LayoutList {
+		return g.viewList(visibleSecrets)
+	}
+
 	// Build grid
 	rows := []string{}
 
@@ -379,22 +689,268 @@ func (g *SecretGrid) View() string {
 
 	gridView := lipgloss.JoinVertical(lipgloss.Left, rows...)
 
-	// Add pagination indicator if needed
+	return g.withPagination(gridView)
+}
+
+// withPagination appends the recency legend, with the screen indicator
+// when there is more than one screen
+func (g *SecretGrid) withPagination(view string) string {
+	subtleStyle := lipgloss.NewStyle().Foreground(theme.Current().Subtle)
+	footer := recencyLegend()
 	if g.totalGridPages > 1 {
-		paginationInfo := fmt.Sprintf("Screen %d/%d", g.gridPageIndex+1, g.totalGridPages)
-		paginationStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			MarginTop(1)
-		gridView = lipgloss.JoinVertical(lipgloss.Left, gridView, paginationStyle.Render(paginationInfo))
+		footer += subtleStyle.Render(fmt.Sprintf("   Screen %d/%d", g.gridPageIndex+1, g.totalGridPages))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, view, lipgloss.NewStyle().MarginTop(1).Render(footer))
+}
+
+// recency is how recently a secret last changed, which colours its date
+type recency int
+
+const (
+	// recencyOlder is a change before the last seven days, or an unknown date
+	recencyOlder recency = iota
+	// recencyWeek is a change in the last seven days, before today
+	recencyWeek
+	// recencyToday is a change today, in local time
+	recencyToday
+)
+
+// changeRecency returns how recently changed was, as of now
+func changeRecency(changed *time.Time, now time.Time) recency {
+	if changed == nil {
+		return recencyOlder
+	}
+	local := changed.In(now.Location())
+	if local.Year() == now.Year() && local.YearDay() == now.YearDay() {
+		return recencyToday
+	}
+	if now.Sub(*changed) < 7*24*time.Hour {
+		return recencyWeek
+	}
+	return recencyOlder
+}
+
+// recencyStyle colours a date by how recently it was
+func recencyStyle(r recency) lipgloss.Style {
+	t := theme.Current()
+	switch r {
+	case recencyToday:
+		return lipgloss.NewStyle().Foreground(t.Primary).Bold(true)
+	case recencyWeek:
+		return lipgloss.NewStyle().Foreground(t.Secondary)
+	}
+	return lipgloss.NewStyle().Foreground(t.Subtle)
+}
+
+// recencyLegend explains the date colours
+func recencyLegend() string {
+	subtleStyle := lipgloss.NewStyle().Foreground(theme.Current().Subtle)
+	return subtleStyle.Render("Changed: ") +
+		recencyStyle(recencyToday).Render("● today") + "  " +
+		recencyStyle(recencyWeek).Render("● this week") + "  " +
+		recencyStyle(recencyOlder).Render("● older")
+}
+
+// viewList renders the visible secrets as compact rows with name,
+// description, date and KMS key columns
+func (g *SecretGrid) viewList(visibleSecrets []models.Secret) string {
+	t := theme.Current()
+	nameWidth, descWidth := g.listColumnWidths()
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle).
+		Bold(true)
+	header := headerStyle.Render("  " +
+		padRight("NAME", nameWidth) + "  " +
+		padRight("DESCRIPTION", descWidth) + "  " +
+		padRight("LAST CHANGED", listDateWidth) + "  " +
+		"KMS KEY")
+
+	rows := []string{header}
+	for i, secret := range visibleSecrets {
+		rows = append(rows, g.renderRow(secret, i == g.cursorRow, nameWidth, descWidth))
+	}
+
+	return g.withPagination(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// viewCompact renders the visible secrets as names alone, one per line
+func (g *SecretGrid) viewCompact(visibleSecrets []models.Secret) string {
+	headerStyle := lipgloss.NewStyle().
+		Foreground(theme.Current().Subtle).
+		Bold(true)
+
+	rows := []string{headerStyle.Render("  NAME")}
+	for i, secret := range visibleSecrets {
+		rows = append(rows, g.renderCompactRow(secret, i == g.cursorRow))
 	}
 
-	return gridView
+	return g.withPagination(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
+// renderCompactRow renders a secret's name, with its badges when there is
+// room for them beside it
+func (g *SecretGrid) renderCompactRow(secret models.Secret, isSelected bool) string {
+	name := secret.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	if g.IsMarked(secret.Name) {
+		name = markPrefix + name
+	}
+
+	cursor := "  "
+	if isSelected {
+		cursor = "> "
+	}
+	nameStyle := g.nameStyle(secret, isSelected)
+
+	// The cursor gutter takes two columns
+	width := max(g.width-2, 1)
+	badges := g.badges(secret)
+	if badges != "" && lipgloss.Width(name)+2+lipgloss.Width(badges) > width {
+		badges = ""
+	}
+
+	term := inventory.NameTerm(g.filterQuery)
+	row := nameStyle.Render(cursor) + highlightTerm(truncate(name, width), term, nameStyle)
+	if badges != "" {
+		row += "  " + badges
+	}
+	return row
+}
+
+// listColumnWidths splits the available width between the name and description columns
+func (g *SecretGrid) listColumnWidths() (int, int) {
+	// Two-character cursor gutter plus two spaces between each column
+	available := max(g.width-2-listDateWidth-listKMSWidth-6, 20)
+	nameWidth := max(available*55/100, 10)
+	descWidth := max(available-nameWidth, 10)
+	return nameWidth, descWidth
+}
+
+// renderRow renders a single secret as a list row
+func (g *SecretGrid) renderRow(secret models.Secret, isSelected bool, nameWidth, descWidth int) string {
+	t := theme.Current()
+
+	dateStr := "Unknown"
+	if secret.LastChangedDate != nil {
+		dateStr = secret.LastChangedDate.Format("Jan 2, 2006")
+	}
+
+	name := secret.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+
+	if g.IsMarked(secret.Name) {
+		name = markPrefix + name
+	}
+
+	cursor := "  "
+	nameStyle := g.nameStyle(secret, false)
+	if isSelected {
+		cursor = "> "
+		nameStyle = g.nameStyle(secret, true)
+	}
+	subtleStyle := lipgloss.NewStyle().Foreground(t.Subtle)
+
+	// The default key is highlighted, for teams that require their own keys
+	kmsStyle := subtleStyle
+	if models.UsesDefaultKey(secret.KmsKeyID) {
+		kmsStyle = lipgloss.NewStyle().Foreground(t.Secondary)
+	}
+
+	dateStyle := recencyStyle(changeRecency(secret.LastChangedDate, time.Now()))
+
+	term := inventory.NameTerm(g.filterQuery)
+	row := nameStyle.Render(cursor) + highlightTerm(padRight(truncate(name, nameWidth), nameWidth), term, nameStyle) + "  " +
+		subtleStyle.Render(padRight(truncate(secret.Description, descWidth), descWidth)) + "  " +
+		dateStyle.Render(padRight(dateStr, listDateWidth)) + "  " +
+		kmsStyle.Render(padRight(truncate(models.KMSKeyLabel(secret.KmsKeyID), listKMSWidth), listKMSWidth))
+	if badges := g.badges(secret); badges != "" {
+		row += "  " + badges
+	}
+	return row
+}
+
+// ownerBadge marks a secret managed by another AWS service, which can't be
+// deleted or overwritten here
+func ownerBadge(secret models.Secret) string {
+	return lipgloss.NewStyle().
+		Foreground(theme.Current().Secondary).
+		Render("managed by " + models.OwningServiceName(secret.OwningService))
+}
+
+// SetValueViews sets when each secret's value was last viewed, by ARN
+func (g *SecretGrid) SetValueViews(viewedAt map[string]time.Time) {
+	g.viewedAt = viewedAt
+}
+
+// changedBadge marks a secret that changed after its value was last
+// viewed, or returns "" when it hasn't or was never viewed
+func (g *SecretGrid) changedBadge(secret models.Secret) string {
+	viewed, ok := g.viewedAt[secret.ARN]
+	if !ok || secret.ARN == "" || secret.LastChangedDate == nil || !secret.LastChangedDate.After(viewed) {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Current().Primary).
+		Render("● changed since viewed " + viewed.Local().Format("Jan 2"))
+}
+
+// SetHighlights labels secrets found new or changed by the last watch
+// refresh, by name. Nil clears them.
+func (g *SecretGrid) SetHighlights(highlights map[string]string) {
+	g.highlights = highlights
+}
+
+// SetFlagged sets the secrets flagged to notify about when they rotate
+func (g *SecretGrid) SetFlagged(flagged map[string]bool) {
+	g.flagged = flagged
+}
+
+// highlightBadge marks a secret highlighted by watch mode, or returns ""
+func (g *SecretGrid) highlightBadge(secret models.Secret) string {
+	label, ok := g.highlights[secret.Name]
+	if !ok {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.Current().Success).
+		Bold(true).
+		Render("★ " + label)
+}
+
+// badges joins the badges that apply to a secret
+func (g *SecretGrid) badges(secret models.Secret) string {
+	var badges []string
+	if highlight := g.highlightBadge(secret); highlight != "" {
+		badges = append(badges, highlight)
+	}
+	if g.flagged[secret.Name] {
+		badges = append(badges, lipgloss.NewStyle().
+			Foreground(theme.Current().Secondary).
+			Render("⚑ notify on rotation"))
+	}
+	if secret.OwningService != "" {
+		badges = append(badges, ownerBadge(secret))
+	}
+	if changed := g.changedBadge(secret); changed != "" {
+		badges = append(badges, changed)
+	}
+	return strings.Join(badges, "  ")
 }
 
 // renderCell renders a single grid cell
 func (g *SecretGrid) renderCell(secret models.Secret, isSelected bool) string {
+	name := secret.Name
+	if g.IsMarked(name) {
+		name = markPrefix + name
+	}
+
 	// Wrap the secret name to fit width (account for padding)
-	nameLines := g.wrapText(secret.Name, g.cellWidth-2)
+	nameLines := g.wrapText(name, g.cellWidth-2)
 
 	// Take only first 2 lines for the name (save room for date)
 	if len(nameLines) > 2 {
@@ -415,28 +971,24 @@ func (g *SecretGrid) renderCell(secret models.Secret, isSelected bool) string {
 	}
 
 	// Style the name based on selection
-	var nameStyle lipgloss.Style
-	if isSelected {
-		// Selected: pink text, bold (no background)
-		nameStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("205")).
-			Bold(true)
-	} else {
-		// Normal: light grey text
-		nameStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("252"))
-	}
+	nameStyle := g.nameStyle(secret, isSelected)
 
-	// Style the date (always greyed out)
-	dateStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241"))
+	// Colour the date by how recently the secret changed
+	dateStyle := recencyStyle(changeRecency(secret.LastChangedDate, time.Now()))
 
-	// Render styled parts
-	styledName := nameStyle.Render(strings.Join(nameLines, "\n"))
+	// Render styled parts, with the text the filter matched picked out
+	term := inventory.NameTerm(g.filterQuery)
+	for i, line := range nameLines {
+		nameLines[i] = highlightTerm(line, term, nameStyle)
+	}
+	styledName := strings.Join(nameLines, "\n")
 	styledDate := dateStyle.Render(dateStr)
 
-	// Combine content
+	// Combine content, with the badges on the line left below the date
 	content := styledName + "\n" + styledDate
+	if badges := g.badges(secret); badges != "" {
+		content += "\n" + badges
+	}
 
 	// Apply cell style (sizing and padding only, no background color)
 	// Use the dynamically calculated cell width
@@ -448,6 +1000,51 @@ func (g *SecretGrid) renderCell(secret models.Secret, isSelected bool) string {
 	return cellStyle.Render(content)
 }
 
+// highlightTerm renders text in style, with each occurrence of term, in
+// lower case, picked out in bold
+func highlightTerm(text, term string, style lipgloss.Style) string {
+	lower := strings.ToLower(text)
+	// Case folding that changes byte lengths would misplace the matches
+	if term == "" || len(lower) != len(text) {
+		return style.Render(text)
+	}
+
+	matchStyle := style.Foreground(theme.Current().Success).Bold(true).Underline(true)
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, term)
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			b.WriteString(style.Render(text[:i]))
+		}
+		b.WriteString(matchStyle.Render(text[i : i+len(term)]))
+		text, lower = text[i+len(term):], lower[i+len(term):]
+	}
+	if text != "" {
+		b.WriteString(style.Render(text))
+	}
+	return b.String()
+}
+
+// markPrefix is shown before the names of marked secrets
+const markPrefix = "✓ "
+
+// nameStyle styles a secret name by selection and mark state
+func (g *SecretGrid) nameStyle(secret models.Secret, isSelected bool) lipgloss.Style {
+	t := theme.Current()
+	style := lipgloss.NewStyle().Foreground(t.Text)
+	if g.IsMarked(secret.Name) {
+		style = style.Foreground(t.Success)
+	}
+	if isSelected {
+		// Selected: primary text, bold (no background)
+		style = style.Foreground(t.Primary).Bold(true)
+	}
+	return style
+}
+
 // wrapText wraps text to fit within maxWidth
 func (g *SecretGrid) wrapText(text string, maxWidth int) []string {
 	if text == "" {
@@ -496,6 +1093,31 @@ func (g *SecretGrid) wrapText(text string, maxWidth int) []string {
 }
 
 // Helper functions
+
+// truncate shortens text to fit within width, adding an ellipsis when cut
+func truncate(text string, width int) string {
+	if lipgloss.Width(text) <= width {
+		return text
+	}
+	if width <= 3 {
+		return strings.Repeat(".", width)
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+3 > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// padRight pads text with spaces to the given display width
+func padRight(text string, width int) string {
+	if gap := width - lipgloss.Width(text); gap > 0 {
+		return text + strings.Repeat(" ", gap)
+	}
+	return text
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
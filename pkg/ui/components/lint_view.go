@@ -0,0 +1,187 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lintViewChrome is the number of lines around the findings: the title,
+// the score and the check summary with their spacing, and the scroll hints
+const lintViewChrome = 6
+
+// LintView is a component showing a compliance report: the score, how many
+// secrets fail each check, and each failing secret's findings
+type LintView struct {
+	title  string
+	report inventory.LintReport
+	lines  []lintLine
+	offset int
+	width  int
+	height int
+}
+
+// lintLine is a line of the findings: a secret's name, or one of its
+// findings with the weight of the check it failed
+type lintLine struct {
+	text   string
+	weight int // 0 for a secret's name
+}
+
+// NewLintView creates a view of report
+func NewLintView(title string, report inventory.LintReport) LintView {
+	l := LintView{title: title, report: report}
+	l.lines = l.findingLines()
+	return l
+}
+
+// Report returns the report shown
+func (l *LintView) Report() inventory.LintReport {
+	return l.report
+}
+
+// SetSize updates the view dimensions
+func (l *LintView) SetSize(width, height int) {
+	l.width = width
+	l.height = height
+	l.offset = min(l.offset, l.maxOffset())
+}
+
+// Update scrolls the findings
+func (l *LintView) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			l.offset = max(l.offset-1, 0)
+		case "down", "j":
+			l.offset = min(l.offset+1, l.maxOffset())
+		}
+	}
+	return nil
+}
+
+// visibleRows is how many finding lines fit at the current height
+func (l *LintView) visibleRows() int {
+	if l.height == 0 {
+		return len(l.lines)
+	}
+	return max(l.height-lintViewChrome-len(l.report.Checks), 3)
+}
+
+// maxOffset is the furthest the findings can scroll
+func (l *LintView) maxOffset() int {
+	return max(len(l.lines)-l.visibleRows(), 0)
+}
+
+// findingLines lists each failing secret followed by its findings
+func (l *LintView) findingLines() []lintLine {
+	var lines []lintLine
+	for _, entry := range l.report.Entries {
+		if len(entry.Findings) == 0 {
+			continue
+		}
+		lines = append(lines, lintLine{text: entry.Secret.Name})
+		for _, finding := range entry.Findings {
+			text := fmt.Sprintf("  %s %s: %s", severityMark(finding.Check.Weight), finding.Check.Title, finding.Detail)
+			lines = append(lines, lintLine{text: text, weight: finding.Check.Weight})
+		}
+	}
+	return lines
+}
+
+// severityMark marks a finding by how much it counts against the score
+func severityMark(weight int) string {
+	switch {
+	case weight >= 3:
+		return "!!"
+	case weight == 2:
+		return "! "
+	}
+	return "· "
+}
+
+// severityStyle colours a line by how much its finding counts against the
+// score, with secret names in bold
+func severityStyle(weight int) lipgloss.Style {
+	t := theme.Current()
+	switch {
+	case weight == 0:
+		return lipgloss.NewStyle().Foreground(t.Text).Bold(true)
+	case weight >= 3:
+		return lipgloss.NewStyle().Foreground(t.Error).Bold(true)
+	case weight == 2:
+		return lipgloss.NewStyle().Foreground(t.Error)
+	}
+	return lipgloss.NewStyle().Foreground(t.Subtle)
+}
+
+// View renders the report
+func (l *LintView) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary)
+
+	subtleStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	scoreStyle := lipgloss.NewStyle().Bold(true).Foreground(t.Success)
+	if l.report.Score < 80 {
+		scoreStyle = scoreStyle.Foreground(t.Error)
+	}
+
+	failing := 0
+	for _, entry := range l.report.Entries {
+		if len(entry.Findings) > 0 {
+			failing++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(l.title) + "\n\n")
+	summary := fmt.Sprintf("%d of %d secrets have findings", failing, len(l.report.Entries))
+	if l.report.Unchecked > 0 {
+		summary += fmt.Sprintf(" (%d resource policies couldn't be read)", l.report.Unchecked)
+	}
+	b.WriteString(scoreStyle.Render(fmt.Sprintf("Score %d/100", l.report.Score)) + "  " + summary + "\n")
+	for _, check := range l.report.Checks {
+		line := fmt.Sprintf("  %s %-50s %d", severityMark(check.Weight), check.Title, l.report.Failed[check.Name])
+		b.WriteString(l.fit(line) + "\n")
+	}
+	if len(l.report.Checks) == 0 {
+		b.WriteString(subtleStyle.Render("  Every check is disabled") + "\n")
+	}
+	b.WriteString("\n")
+
+	end := min(l.offset+l.visibleRows(), len(l.lines))
+	if l.offset > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↑ %d more", l.offset)) + "\n")
+	}
+	for _, line := range l.lines[l.offset:end] {
+		b.WriteString(severityStyle(line.weight).Render(l.fit(line.text)) + "\n")
+	}
+	if end < len(l.lines) {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↓ %d more", len(l.lines)-end)) + "\n")
+	}
+	if len(l.lines) == 0 && len(l.report.Entries) > 0 {
+		b.WriteString(subtleStyle.Render("  Every secret passes") + "\n")
+	}
+	if len(l.report.Entries) == 0 {
+		b.WriteString(subtleStyle.Render("  No secrets") + "\n")
+	}
+
+	return b.String()
+}
+
+// fit truncates a line to the width, once it is known
+func (l *LintView) fit(line string) string {
+	if l.width > 0 {
+		return truncate(line, l.width)
+	}
+	return line
+}
@@ -1,6 +1,7 @@
 package components
 
 import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -40,25 +41,27 @@ func (m *MFAInput) Update(msg tea.Msg) tea.Cmd {
 
 // View renders the MFA input component
 func (m *MFAInput) View() string {
+	t := theme.Current()
+
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("205")).
+		Foreground(t.Primary).
 		MarginBottom(1)
 
 	instructionStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(t.Subtle).
 		MarginBottom(1)
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("205")).
+		BorderForeground(t.Primary).
 		Padding(1, 2).
 		Width(50)
 
 	content := titleStyle.Render("MFA Authentication Required") + "\n\n" +
 		instructionStyle.Render("Enter your 6-digit MFA code:") + "\n\n" +
 		m.textInput.View() + "\n\n" +
-		lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("Press Enter to submit | Esc to cancel")
+		lipgloss.NewStyle().Foreground(t.Subtle).Render("Press Enter to submit | Esc to cancel")
 
 	return boxStyle.Render(content)
 }
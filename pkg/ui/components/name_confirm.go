@@ -0,0 +1,65 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// NameConfirm asks for a name to be typed out before a destructive
+// operation, such as the name of the secret being deleted
+type NameConfirm struct {
+	textInput textinput.Model
+	expected  string
+	err       string
+}
+
+// NewNameConfirm creates an empty input that accepts expected
+func NewNameConfirm(expected string) NameConfirm {
+	ti := textinput.New()
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.Width = 56
+	ti.Prompt = "> "
+
+	return NameConfirm{
+		textInput: ti,
+		expected:  expected,
+	}
+}
+
+// Matches reports whether the expected name was typed, ignoring spaces
+// around it
+func (c *NameConfirm) Matches() bool {
+	return strings.TrimSpace(c.textInput.Value()) == c.expected
+}
+
+// SetError shows an error below the input, e.g. when the name doesn't match
+func (c *NameConfirm) SetError(err string) {
+	c.err = err
+}
+
+// Update updates the input
+func (c *NameConfirm) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	c.textInput, cmd = c.textInput.Update(msg)
+	return cmd
+}
+
+// View renders the instruction and the input
+func (c *NameConfirm) View() string {
+	t := theme.Current()
+
+	textStyle := lipgloss.NewStyle().Foreground(t.Text)
+	nameStyle := lipgloss.NewStyle().Foreground(t.Primary).Bold(true)
+
+	content := textStyle.Render("Type ") + nameStyle.Render(c.expected) + textStyle.Render(" to confirm:") + "\n" +
+		c.textInput.View()
+	if c.err != "" {
+		content += "\n" + lipgloss.NewStyle().Foreground(t.Error).Render(c.err)
+	}
+	return content
+}
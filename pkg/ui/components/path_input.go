@@ -0,0 +1,81 @@
+package components
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PathInput is a component for entering a file path
+type PathInput struct {
+	textInput textinput.Model
+	title     string
+	err       string
+}
+
+// NewPathInput creates a new path input prefilled with defaultPath
+func NewPathInput(title, defaultPath string) PathInput {
+	ti := textinput.New()
+	ti.Placeholder = "path/to/file"
+	ti.SetValue(defaultPath)
+	ti.CursorEnd()
+	ti.Focus()
+	ti.CharLimit = 4096
+	ti.Width = 56
+	ti.Prompt = "> "
+
+	return PathInput{
+		textInput: ti,
+		title:     title,
+	}
+}
+
+// Value returns the current input value
+func (p *PathInput) Value() string {
+	return p.textInput.Value()
+}
+
+// SetError shows an error below the input, e.g. when the file cannot be written
+func (p *PathInput) SetError(err string) {
+	p.err = err
+}
+
+// Update updates the path input component
+func (p *PathInput) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	p.textInput, cmd = p.textInput.Update(msg)
+	return cmd
+}
+
+// View renders the path input component
+func (p *PathInput) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary).
+		MarginBottom(1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary).
+		Padding(1, 2).
+		Width(66)
+
+	content := titleStyle.Render(p.title) + "\n\n" +
+		instructionStyle.Render("Save to file (existing files are not overwritten):") + "\n\n" +
+		p.textInput.View() + "\n\n"
+
+	if p.err != "" {
+		content += lipgloss.NewStyle().Foreground(t.Error).Render(p.err) + "\n\n"
+	}
+
+	content += lipgloss.NewStyle().Foreground(t.Subtle).Render("Press Enter to save | Esc to cancel")
+
+	return boxStyle.Render(content)
+}
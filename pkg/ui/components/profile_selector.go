@@ -1,20 +1,23 @@
 package components
 
 import (
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // ProfileItem represents a profile in the list
 type ProfileItem struct {
 	name      string
+	summary   models.ProfileSummary
 	isCurrent bool
 }
 
-// FilterValue implements list.Item
+// FilterValue implements list.Item, so profiles can be found by account too
 func (i ProfileItem) FilterValue() string {
-	return i.name
+	return i.name + " " + i.summary.Account
 }
 
 // Title returns the title for the list item
@@ -25,8 +28,32 @@ func (i ProfileItem) Title() string {
 	return "  " + i.name
 }
 
-// Description returns the description for the list item
+// Description returns the description for the list item: the account and
+// default region, then badges for how the profile signs in
 func (i ProfileItem) Description() string {
+	var parts []string
+	if i.summary.Account != "" {
+		parts = append(parts, i.summary.Account)
+	}
+	if i.summary.Region != "" {
+		parts = append(parts, i.summary.Region)
+	}
+	if i.summary.SSO {
+		parts = append(parts, "SSO")
+	}
+	if i.summary.MFA {
+		parts = append(parts, "MFA")
+	}
+	if i.summary.RoleSource != "" {
+		parts = append(parts, "role→"+i.summary.RoleSource)
+	}
+	if i.summary.CredentialProcess {
+		parts = append(parts, "credential_process")
+	}
+
+	if len(parts) > 0 {
+		return strings.Join(parts, " · ")
+	}
 	if i.isCurrent {
 		return "Currently active profile"
 	}
@@ -35,42 +62,34 @@ func (i ProfileItem) Description() string {
 
 // ProfileSelector is a component for selecting AWS profiles
 type ProfileSelector struct {
-	list          list.Model
-	profiles      []string
+	list           list.Model
+	profiles       []models.ProfileSummary
 	currentProfile string
 }
 
 // NewProfileSelector creates a new profile selector
-func NewProfileSelector(profiles []string, currentProfile string, width, height int) ProfileSelector {
-	delegate := list.NewDefaultDelegate()
-
-	// Customize delegate styles
-	delegate.Styles.SelectedTitle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("205")).
-		Bold(true).
-		PaddingLeft(2)
-
-	delegate.Styles.SelectedDesc = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("170")).
-		PaddingLeft(2)
+func NewProfileSelector(profiles []models.ProfileSummary, currentProfile string, width, height int) ProfileSelector {
+	delegate := newListDelegate()
 
 	// Create list items
 	items := make([]list.Item, len(profiles))
 	for i, profile := range profiles {
 		items[i] = ProfileItem{
-			name:      profile,
-			isCurrent: profile == currentProfile,
+			name:      profile.Name,
+			summary:   profile,
+			isCurrent: profile.Name == currentProfile,
 		}
 	}
 
 	l := list.New(items, delegate, width, height)
+	applyListTheme(&l)
 	l.Title = "Select AWS Profile"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
 
 	return ProfileSelector{
-		list:          l,
-		profiles:      profiles,
+		list:           l,
+		profiles:       profiles,
 		currentProfile: currentProfile,
 	}
 }
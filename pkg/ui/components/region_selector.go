@@ -1,16 +1,17 @@
 package components
 
 import (
+	"github.com/benjamingriff/secretsrc/pkg/models"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // RegionItem represents a region in the list
 type RegionItem struct {
-	code      string
-	name      string
-	isCurrent bool
+	code       string
+	name       string
+	isCurrent  bool
+	hasSecrets bool
 }
 
 // FilterValue implements list.Item
@@ -28,6 +29,9 @@ func (i RegionItem) Title() string {
 
 // Description returns the description for the list item
 func (i RegionItem) Description() string {
+	if i.hasSecrets {
+		return i.name + " · has secrets"
+	}
 	return i.name
 }
 
@@ -38,7 +42,7 @@ type RegionSelector struct {
 	currentRegion string
 }
 
-// Common AWS regions with their descriptions
+// AWS regions with their descriptions
 var regionDescriptions = map[string]string{
 	"us-east-1":      "US East (N. Virginia)",
 	"us-east-2":      "US East (Ohio)",
@@ -57,37 +61,46 @@ var regionDescriptions = map[string]string{
 	"ap-southeast-1": "Asia Pacific (Singapore)",
 	"ap-southeast-2": "Asia Pacific (Sydney)",
 	"sa-east-1":      "South America (São Paulo)",
+	"af-south-1":     "Africa (Cape Town)",
+	"ap-east-1":      "Asia Pacific (Hong Kong)",
+	"ap-east-2":      "Asia Pacific (Taipei)",
+	"ap-south-2":     "Asia Pacific (Hyderabad)",
+	"ap-southeast-3": "Asia Pacific (Jakarta)",
+	"ap-southeast-4": "Asia Pacific (Melbourne)",
+	"ap-southeast-5": "Asia Pacific (Malaysia)",
+	"ap-southeast-7": "Asia Pacific (Thailand)",
+	"ca-west-1":      "Canada West (Calgary)",
+	"eu-central-2":   "Europe (Zurich)",
+	"eu-south-1":     "Europe (Milan)",
+	"eu-south-2":     "Europe (Spain)",
+	"il-central-1":   "Israel (Tel Aviv)",
+	"me-central-1":   "Middle East (UAE)",
+	"me-south-1":     "Middle East (Bahrain)",
+	"mx-central-1":   "Mexico (Central)",
 }
 
-// NewRegionSelector creates a new region selector
-func NewRegionSelector(regions []string, currentRegion string, width, height int) RegionSelector {
-	delegate := list.NewDefaultDelegate()
-
-	// Customize delegate styles
-	delegate.Styles.SelectedTitle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("205")).
-		Bold(true).
-		PaddingLeft(2)
-
-	delegate.Styles.SelectedDesc = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("170")).
-		PaddingLeft(2)
+// NewRegionSelector creates a new region selector, noting the regions known
+// to have secrets
+func NewRegionSelector(regions []models.Region, currentRegion string, width, height int) RegionSelector {
+	delegate := newListDelegate()
 
 	// Create list items
 	items := make([]list.Item, len(regions))
 	for i, region := range regions {
-		name := regionDescriptions[region]
+		name := regionDescriptions[region.Name]
 		if name == "" {
 			name = "AWS Region"
 		}
 		items[i] = RegionItem{
-			code:      region,
-			name:      name,
-			isCurrent: region == currentRegion,
+			code:       region.Name,
+			name:       name,
+			isCurrent:  region.Name == currentRegion,
+			hasSecrets: region.HasSecrets,
 		}
 	}
 
 	l := list.New(items, delegate, width, height)
+	applyListTheme(&l)
 	l.Title = "Select AWS Region"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
@@ -112,6 +125,16 @@ func (rs *RegionSelector) SelectedRegion() string {
 	return regionItem.code
 }
 
+// Select moves the cursor to a region, if it is listed
+func (rs *RegionSelector) Select(region string) {
+	for i, item := range rs.list.Items() {
+		if regionItem, ok := item.(RegionItem); ok && regionItem.code == region {
+			rs.list.Select(i)
+			return
+		}
+	}
+}
+
 // Update updates the region selector
 func (rs *RegionSelector) Update(msg tea.Msg) tea.Cmd {
 	var cmd tea.Cmd
@@ -3,7 +3,6 @@ package components
 import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // SecretField represents a top-level field from a JSON secret.
@@ -40,16 +39,7 @@ type SecretFieldSelector struct {
 
 // NewSecretFieldSelector creates a new secret field selector.
 func NewSecretFieldSelector(fields []SecretField, width, height int) SecretFieldSelector {
-	delegate := list.NewDefaultDelegate()
-
-	delegate.Styles.SelectedTitle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("205")).
-		Bold(true).
-		PaddingLeft(2)
-
-	delegate.Styles.SelectedDesc = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("170")).
-		PaddingLeft(2)
+	delegate := newListDelegate()
 
 	items := make([]list.Item, len(fields))
 	for i, field := range fields {
@@ -57,6 +47,7 @@ func NewSecretFieldSelector(fields []SecretField, width, height int) SecretField
 	}
 
 	l := list.New(items, delegate, width, height)
+	applyListTheme(&l)
 	l.Title = "Copy Secret Field"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
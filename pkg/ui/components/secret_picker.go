@@ -0,0 +1,89 @@
+package components
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickerItem is a list item for a secret name
+type pickerItem string
+
+// FilterValue implements list.Item.
+func (i pickerItem) FilterValue() string {
+	return string(i)
+}
+
+// Title returns the list item title.
+func (i pickerItem) Title() string {
+	return string(i)
+}
+
+// Description returns the list item description.
+func (i pickerItem) Description() string {
+	return ""
+}
+
+// SecretPicker is a component for choosing a secret by name from a list,
+// e.g. one in another account
+type SecretPicker struct {
+	list list.Model
+}
+
+// NewSecretPicker creates a new secret picker with selected highlighted, if
+// it is one of names
+func NewSecretPicker(title string, names []string, selected string, width, height int) SecretPicker {
+	delegate := newListDelegate()
+	delegate.ShowDescription = false
+	delegate.SetSpacing(0)
+
+	items := make([]list.Item, len(names))
+	selectedIndex := 0
+	for i, name := range names {
+		items[i] = pickerItem(name)
+		if name == selected {
+			selectedIndex = i
+		}
+	}
+
+	l := list.New(items, delegate, width, height)
+	applyListTheme(&l)
+	l.Title = title
+	l.SetFilteringEnabled(true)
+	l.Select(selectedIndex)
+
+	return SecretPicker{
+		list: l,
+	}
+}
+
+// SelectedName returns the selected secret name, or "" if none is selected
+func (sp *SecretPicker) SelectedName() string {
+	item, ok := sp.list.SelectedItem().(pickerItem)
+	if !ok {
+		return ""
+	}
+	return string(item)
+}
+
+// IsFiltering reports whether the user is typing a filter, when enter and
+// esc belong to the filter rather than the picker.
+func (sp *SecretPicker) IsFiltering() bool {
+	return sp.list.FilterState() == list.Filtering
+}
+
+// Update updates the picker.
+func (sp *SecretPicker) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	sp.list, cmd = sp.list.Update(msg)
+	return cmd
+}
+
+// View renders the picker.
+func (sp *SecretPicker) View() string {
+	return sp.list.View()
+}
+
+// SetSize updates the picker dimensions.
+func (sp *SecretPicker) SetSize(width, height int) {
+	sp.list.SetSize(width, height)
+}
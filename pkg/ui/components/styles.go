@@ -0,0 +1,52 @@
+package components
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newListDelegate returns a list delegate styled with the active theme
+func newListDelegate() list.DefaultDelegate {
+	t := theme.Current()
+	delegate := list.NewDefaultDelegate()
+
+	delegate.Styles.SelectedTitle = lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true).
+		PaddingLeft(2)
+
+	delegate.Styles.SelectedDesc = lipgloss.NewStyle().
+		Foreground(t.Secondary).
+		PaddingLeft(2)
+
+	delegate.Styles.NormalTitle = delegate.Styles.NormalTitle.
+		Foreground(t.Text)
+
+	delegate.Styles.NormalDesc = delegate.Styles.NormalDesc.
+		Foreground(t.Subtle)
+
+	delegate.Styles.DimmedTitle = delegate.Styles.DimmedTitle.
+		Foreground(t.Subtle)
+
+	delegate.Styles.DimmedDesc = delegate.Styles.DimmedDesc.
+		Foreground(t.Subtle)
+
+	return delegate
+}
+
+// applyListTheme styles a list's title and status chrome with the active theme
+func applyListTheme(l *list.Model) {
+	t := theme.Current()
+
+	l.Styles.Title = lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true).
+		Padding(0, 1)
+
+	l.Styles.StatusBar = l.Styles.StatusBar.
+		Foreground(t.Subtle)
+
+	l.Styles.NoItems = l.Styles.NoItems.
+		Foreground(t.Subtle)
+}
@@ -0,0 +1,164 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ItemStatus is the outcome shown next to an item in a summary
+type ItemStatus int
+
+const (
+	// ItemPending is an item an operation has not run on yet
+	ItemPending ItemStatus = iota
+	// ItemSucceeded is an item the operation succeeded on
+	ItemSucceeded
+	// ItemFailed is an item the operation failed on
+	ItemFailed
+)
+
+// SummaryItem is a line of a summary, with an optional detail such as an error
+type SummaryItem struct {
+	Name   string
+	Status ItemStatus
+	Detail string
+}
+
+// Summary is a scrollable list of items with a message above and below it,
+// used to confirm a bulk operation and report how it went
+type Summary struct {
+	title  string
+	intro  string
+	footer string
+	prompt string
+	items  []SummaryItem
+	offset int
+	width  int
+	height int
+}
+
+// summaryChrome is the number of lines around the items: the title, intro
+// and footer with their spacing, and the box border and padding
+const summaryChrome = 12
+
+// NewSummary creates a new summary
+func NewSummary(title, intro, footer string, items []SummaryItem) Summary {
+	return Summary{
+		title:  title,
+		intro:  intro,
+		footer: footer,
+		items:  items,
+	}
+}
+
+// SetSize updates the summary dimensions
+func (s *Summary) SetSize(width, height int) {
+	s.width = width
+	s.height = height
+	s.offset = min(s.offset, s.maxOffset())
+}
+
+// SetPrompt shows prompt, such as a rendered text input, between the items
+// and the footer
+func (s *Summary) SetPrompt(prompt string) {
+	s.prompt = prompt
+	s.offset = min(s.offset, s.maxOffset())
+}
+
+// Update scrolls the items
+func (s *Summary) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			s.offset = max(s.offset-1, 0)
+		case "down", "j":
+			s.offset = min(s.offset+1, s.maxOffset())
+		}
+	}
+	return nil
+}
+
+// visibleRows is how many items fit at the current height
+func (s *Summary) visibleRows() int {
+	if s.height == 0 {
+		return len(s.items)
+	}
+	chrome := summaryChrome
+	if s.prompt != "" {
+		chrome += lipgloss.Height(s.prompt) + 1
+	}
+	return max(s.height-chrome, 3)
+}
+
+// maxOffset is the furthest the items can scroll
+func (s *Summary) maxOffset() int {
+	return max(len(s.items)-s.visibleRows(), 0)
+}
+
+// View renders the summary
+func (s *Summary) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary)
+
+	subtleStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary).
+		Padding(1, 2).
+		Width(min(max(s.width-4, 40), 90))
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(s.title) + "\n\n")
+	if s.intro != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(t.Text).Render(s.intro) + "\n\n")
+	}
+
+	end := min(s.offset+s.visibleRows(), len(s.items))
+	if s.offset > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↑ %d more", s.offset)) + "\n")
+	}
+	for _, item := range s.items[s.offset:end] {
+		b.WriteString(s.renderItem(item) + "\n")
+	}
+	if end < len(s.items) {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↓ %d more", len(s.items)-end)) + "\n")
+	}
+
+	if s.prompt != "" {
+		b.WriteString("\n" + s.prompt + "\n")
+	}
+	b.WriteString("\n" + subtleStyle.Render(s.footer))
+
+	return boxStyle.Render(b.String())
+}
+
+// renderItem renders one item with its status mark
+func (s *Summary) renderItem(item SummaryItem) string {
+	t := theme.Current()
+
+	mark := "  •"
+	style := lipgloss.NewStyle().Foreground(t.Text)
+	switch item.Status {
+	case ItemSucceeded:
+		mark = "  ✓"
+		style = lipgloss.NewStyle().Foreground(t.Success)
+	case ItemFailed:
+		mark = "  ✗"
+		style = lipgloss.NewStyle().Foreground(t.Error)
+	}
+
+	line := style.Render(mark + " " + item.Name)
+	if item.Detail != "" {
+		line += lipgloss.NewStyle().Foreground(t.Subtle).Render("  " + item.Detail)
+	}
+	return line
+}
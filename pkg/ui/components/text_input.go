@@ -0,0 +1,93 @@
+package components
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TextInput is a component for entering a single line of text, such as a tag
+type TextInput struct {
+	textInput   textinput.Model
+	title       string
+	instruction string
+	err         string
+}
+
+// NewTextInput creates a new empty text input. placeholder shows the
+// expected format.
+func NewTextInput(title, instruction, placeholder string) TextInput {
+	ti := textinput.New()
+	ti.Placeholder = placeholder
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.Width = 56
+	ti.Prompt = "> "
+
+	return TextInput{
+		textInput:   ti,
+		title:       title,
+		instruction: instruction,
+	}
+}
+
+// Value returns the current input value
+func (t *TextInput) Value() string {
+	return t.textInput.Value()
+}
+
+// SetValue replaces the input value, e.g. with a suggested default
+func (t *TextInput) SetValue(value string) {
+	t.textInput.SetValue(value)
+}
+
+// SetMasked hides what is typed, e.g. a password
+func (t *TextInput) SetMasked() {
+	t.textInput.EchoMode = textinput.EchoPassword
+	t.textInput.EchoCharacter = '•'
+}
+
+// SetError shows an error below the input, e.g. when the value is malformed
+func (t *TextInput) SetError(err string) {
+	t.err = err
+}
+
+// Update updates the text input component
+func (t *TextInput) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	t.textInput, cmd = t.textInput.Update(msg)
+	return cmd
+}
+
+// View renders the text input component
+func (t *TextInput) View() string {
+	th := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(th.Primary).
+		MarginBottom(1)
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(th.Subtle).
+		MarginBottom(1)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(th.Primary).
+		Padding(1, 2).
+		Width(66)
+
+	content := titleStyle.Render(t.title) + "\n\n" +
+		instructionStyle.Render(t.instruction) + "\n\n" +
+		t.textInput.View() + "\n\n"
+
+	if t.err != "" {
+		content += lipgloss.NewStyle().Foreground(th.Error).Render(t.err) + "\n\n"
+	}
+
+	content += lipgloss.NewStyle().Foreground(th.Subtle).Render("Press Enter to continue | Esc to cancel")
+
+	return boxStyle.Render(content)
+}
@@ -0,0 +1,223 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffMask replaces values until they are revealed
+const diffMask = "••••••••"
+
+// valueDiffChrome is the number of lines around the rows: the title, the two
+// labels and the summary with their spacing, and the scroll hints
+const valueDiffChrome = 9
+
+// ValueDiff is a component showing a key-level diff of two secret values.
+// Values are masked until revealed, so drift can be checked without
+// putting secrets on screen.
+type ValueDiff struct {
+	title    string
+	labelA   string
+	labelB   string
+	diffs    []secretvalue.FieldDiff
+	notes    []string // Shown above the summary, e.g. how keys were mapped
+	revealed bool
+	hideSame bool
+	offset   int
+	width    int
+	height   int
+}
+
+// NewValueDiff creates a new value diff. labelA and labelB say where each
+// side comes from.
+func NewValueDiff(title, labelA, labelB string, diffs []secretvalue.FieldDiff) ValueDiff {
+	return ValueDiff{
+		title:  title,
+		labelA: labelA,
+		labelB: labelB,
+		diffs:  diffs,
+	}
+}
+
+// SetNotes sets lines shown between the labels and the summary
+func (d *ValueDiff) SetNotes(notes []string) {
+	d.notes = notes
+	d.offset = min(d.offset, d.maxOffset())
+}
+
+// ToggleReveal shows or masks the values
+func (d *ValueDiff) ToggleReveal() {
+	d.revealed = !d.revealed
+}
+
+// ToggleSame shows or hides the keys whose values match
+func (d *ValueDiff) ToggleSame() {
+	d.hideSame = !d.hideSame
+	d.offset = 0
+}
+
+// SetSize updates the diff dimensions
+func (d *ValueDiff) SetSize(width, height int) {
+	d.width = width
+	d.height = height
+	d.offset = min(d.offset, d.maxOffset())
+}
+
+// Update scrolls the rows
+func (d *ValueDiff) Update(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "up", "k":
+			d.offset = max(d.offset-1, 0)
+		case "down", "j":
+			d.offset = min(d.offset+1, d.maxOffset())
+		}
+	}
+	return nil
+}
+
+// rows returns the diffs currently listed
+func (d *ValueDiff) rows() []secretvalue.FieldDiff {
+	if !d.hideSame {
+		return d.diffs
+	}
+	var rows []secretvalue.FieldDiff
+	for _, diff := range d.diffs {
+		if diff.Status != secretvalue.DiffSame {
+			rows = append(rows, diff)
+		}
+	}
+	return rows
+}
+
+// visibleRows is how many rows fit at the current height
+func (d *ValueDiff) visibleRows() int {
+	if d.height == 0 {
+		return len(d.diffs)
+	}
+	chrome := valueDiffChrome
+	if len(d.notes) > 0 {
+		chrome += len(d.notes) + 1
+	}
+	return max(d.height-chrome, 3)
+}
+
+// maxOffset is the furthest the rows can scroll
+func (d *ValueDiff) maxOffset() int {
+	return max(len(d.rows())-d.visibleRows(), 0)
+}
+
+// summary counts the keys by status
+func (d *ValueDiff) summary() string {
+	counts := make(map[secretvalue.DiffStatus]int)
+	for _, diff := range d.diffs {
+		counts[diff.Status]++
+	}
+	if counts[secretvalue.DiffSame] == len(d.diffs) {
+		return fmt.Sprintf("Identical: all %d keys match", len(d.diffs))
+	}
+	return fmt.Sprintf("%d differ, %d only in A, %d only in B, %d the same",
+		counts[secretvalue.DiffChanged], counts[secretvalue.DiffOnlyA], counts[secretvalue.DiffOnlyB], counts[secretvalue.DiffSame])
+}
+
+// View renders the diff
+func (d *ValueDiff) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary)
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(t.Secondary).
+		Bold(true)
+
+	subtleStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(d.title) + "\n\n")
+	b.WriteString(labelStyle.Render("A: ") + d.labelA + "\n")
+	b.WriteString(labelStyle.Render("B: ") + d.labelB + "\n\n")
+	for _, note := range d.notes {
+		b.WriteString("  " + note + "\n")
+	}
+	if len(d.notes) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString(subtleStyle.Render(d.summary()) + "\n\n")
+
+	rows := d.rows()
+	keyWidth := 0
+	for _, diff := range rows {
+		keyWidth = max(keyWidth, lipgloss.Width(diff.Key))
+	}
+
+	end := min(d.offset+d.visibleRows(), len(rows))
+	if d.offset > 0 {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↑ %d more", d.offset)) + "\n")
+	}
+	for _, diff := range rows[d.offset:end] {
+		b.WriteString(d.renderRow(diff, keyWidth) + "\n")
+	}
+	if end < len(rows) {
+		b.WriteString(subtleStyle.Render(fmt.Sprintf("  ↓ %d more", len(rows)-end)) + "\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString(subtleStyle.Render("  No differences") + "\n")
+	}
+
+	return b.String()
+}
+
+// renderRow renders one key with its status mark and values
+func (d *ValueDiff) renderRow(diff secretvalue.FieldDiff, keyWidth int) string {
+	t := theme.Current()
+
+	value := func(v string) string {
+		if !d.revealed {
+			return diffMask
+		}
+		return strings.ReplaceAll(v, "\n", "\\n")
+	}
+
+	var mark, detail string
+	style := lipgloss.NewStyle().Foreground(t.Text)
+	switch diff.Status {
+	case secretvalue.DiffSame:
+		mark, detail = "=", "same"
+		style = lipgloss.NewStyle().Foreground(t.Subtle)
+		if d.revealed {
+			detail = value(diff.A)
+		}
+	case secretvalue.DiffChanged:
+		mark, detail = "~", "differs"
+		style = lipgloss.NewStyle().Foreground(t.Secondary)
+		if d.revealed {
+			detail = value(diff.A) + "  →  " + value(diff.B)
+		}
+	case secretvalue.DiffOnlyA:
+		mark, detail = "-", "only in A"
+		style = lipgloss.NewStyle().Foreground(t.Error)
+		if d.revealed {
+			detail += ": " + value(diff.A)
+		}
+	case secretvalue.DiffOnlyB:
+		mark, detail = "+", "only in B"
+		style = lipgloss.NewStyle().Foreground(t.Success)
+		if d.revealed {
+			detail += ": " + value(diff.B)
+		}
+	}
+
+	line := fmt.Sprintf("  %s %s  %s", mark, padRight(diff.Key, keyWidth), detail)
+	if d.width > 0 {
+		line = truncate(line, d.width)
+	}
+	return style.Render(line)
+}
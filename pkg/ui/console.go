@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/browser"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openBrowser opens a URL in the browser, replaced in tests
+var openBrowser = browser.Open
+
+// consoleHosts is the console's host in the partitions other than aws,
+// which has a host per region
+var consoleHosts = map[string]string{
+	"aws-cn":     "console.amazonaws.cn",
+	"aws-us-gov": "console.amazonaws-us-gov.com",
+}
+
+// consoleOpenedMsg reports opening a secret's console page
+type consoleOpenedMsg struct {
+	name string
+	err  error
+}
+
+// consoleURL returns the AWS console page for a secret. The partition and
+// region are taken from its ARN, falling back to region when there is none.
+func consoleURL(arn, region, name string) string {
+	partition := regionPartition(region)
+	if parts := strings.SplitN(arn, ":", 5); len(parts) == 5 && parts[0] == "arn" {
+		partition = parts[1]
+		if parts[3] != "" {
+			region = parts[3]
+		}
+	}
+
+	query := url.Values{}
+	query.Set("name", name)
+	if region == "" {
+		return "https://console.aws.amazon.com/secretsmanager/secret?" + query.Encode()
+	}
+	query.Set("region", region)
+	if host, ok := consoleHosts[partition]; ok {
+		return fmt.Sprintf("https://%s/secretsmanager/secret?%s", host, query.Encode())
+	}
+	return fmt.Sprintf("https://%s.console.aws.amazon.com/secretsmanager/secret?%s", region, query.Encode())
+}
+
+// regionPartition returns the partition a region is in
+func regionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
+
+// openConsole opens a secret's console page in the browser
+func openConsole(name, url string) tea.Cmd {
+	return func() tea.Msg {
+		return consoleOpenedMsg{name: name, err: openBrowser(url)}
+	}
+}
+
+// openInConsole opens the selected secret's page in the AWS console, for
+// what can only be done there, such as setting up a rotation Lambda
+func (m Model) openInConsole() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		return m, nil
+	}
+	switch {
+	case m.demoData():
+		m.statusMessage = "Demo secrets aren't in the AWS console"
+		return m, clearStatusAfter(3 * time.Second)
+	case !m.awsBackend():
+		m.statusMessage = m.backendName + " secrets aren't in the AWS console"
+		return m, clearStatusAfter(3 * time.Second)
+	case m.awsClient != nil && m.awsClient.GetEndpoint() != "":
+		m.statusMessage = "Secrets behind a custom endpoint aren't in the AWS console"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	return m, openConsole(secret.Name, consoleURL(secret.ARN, m.currentRegion, secret.Name))
+}
+
+// showConsoleOpened reports opening a console page. Without a browser the
+// link can still be copied with u.
+func (m Model) showConsoleOpened(msg consoleOpenedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to open the console: %v (u copies the link instead)", msg.err)
+		return m, nil
+	}
+	m.statusMessage = fmt.Sprintf("Opened %s in the AWS console", msg.name)
+	return m, clearStatusAfter(3 * time.Second)
+}
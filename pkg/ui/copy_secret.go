@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copyDestination is where the open secret is being copied: the same
+// account in another region, or another profile's account. A client for
+// another profile is only kept until the copy is done, so its credentials
+// are never used for anything else.
+type copyDestination struct {
+	client *aws.Client
+	label  string // Shown to the user, e.g. "us-east-1" or "profile prod"
+}
+
+// copyTargetCheckedMsg reports whether the secret being copied already
+// exists at the destination, and the service managing it if any
+type copyTargetCheckedMsg struct {
+	name   string
+	label  string
+	exists bool
+	owner  string
+	err    error
+}
+
+// secretCopiedMsg reports the result of copySecret
+type secretCopiedMsg struct {
+	name    string
+	label   string
+	created bool
+	err     error
+}
+
+// checkCopyTarget describes the secret at the destination, so an existing
+// one is only overwritten once confirmed, and never when another AWS service
+// manages it
+func checkCopyTarget(ctx context.Context, dest copyDestination, name string) tea.Cmd {
+	return func() tea.Msg {
+		details, err := dest.client.DescribeSecret(ctx, name)
+		if aws.ClassifyError(err) == aws.ErrorNotFound {
+			return copyTargetCheckedMsg{name: name, label: dest.label}
+		}
+		if err != nil {
+			return copyTargetCheckedMsg{name: name, label: dest.label, err: err}
+		}
+		return copyTargetCheckedMsg{name: name, label: dest.label, exists: true, owner: details.OwningService}
+	}
+}
+
+// copySecret reads a secret with its description and tags from src and
+// writes it under the same name at the destination, overwriting an existing
+// secret only when overwrite is set. Values that don't pass the secret's
+// schema aren't written.
+func copySecret(ctx context.Context, src *aws.Client, dest copyDestination, name string, overwrite bool, schemas *secretvalue.Schemas) tea.Cmd {
+	return func() tea.Msg {
+		secretCopy, err := src.ReadSecretCopy(ctx, name)
+		if err != nil {
+			return secretCopiedMsg{name: name, label: dest.label, err: err}
+		}
+		if err := schemas.Validate(name, secretCopy.Value); err != nil {
+			return secretCopiedMsg{name: name, label: dest.label, err: err}
+		}
+		created, err := dest.client.WriteSecretCopy(ctx, secretCopy, overwrite)
+		return secretCopiedMsg{name: name, label: dest.label, created: created, err: err}
+	}
+}
+
+// awsSource returns the client the open secret is read from for copying or
+// comparing, which need a real account: the demo store and other backends
+// have no other regions or profiles. action names what needs it, for the
+// error.
+func (m *Model) awsSource(action string) (*aws.Client, bool) {
+	client, ok := m.awsClient.(*aws.Client)
+	switch {
+	case !ok && m.demoData():
+		m.errorMessage = action + " needs an AWS account, the demo data has no other regions or profiles"
+	case !ok:
+		m.errorMessage = action + " needs an AWS account, " + m.backendName + " has no other regions or profiles"
+	}
+	return client, ok && m.grid.SelectedSecret() != nil
+}
+
+// startCopyToRegion opens the region selector for copying the open secret
+func (m Model) startCopyToRegion() (tea.Model, tea.Cmd) {
+	if _, ok := m.awsSource("Copying"); !ok {
+		return m, nil
+	}
+	m.openScreen(ScreenCopyRegion, newRegionScreen(m.knownRegions(), m.currentRegion), ScreenSecretDetail)
+	return m, nil
+}
+
+// startCopyToProfile opens the profile selector for copying the open secret
+func (m Model) startCopyToProfile() (tea.Model, tea.Cmd) {
+	if _, ok := m.awsSource("Copying"); !ok {
+		return m, nil
+	}
+	profiles, err := aws.GetAvailableProfiles()
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load profiles: %v", err)
+		return m, nil
+	}
+	if len(profiles) == 0 {
+		m.statusMessage = noProfilesMessage
+		return m, clearStatusAfter(8 * time.Second)
+	}
+	m.openScreen(ScreenCopyProfile, newProfileScreen(m.summarizeProfiles(profiles), m.currentProfile), ScreenSecretDetail)
+	return m, nil
+}
+
+// chooseCopyRegion checks the picked region for a secret with the same name
+func (m Model) chooseCopyRegion(region string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	client, ok := m.awsSource("Copying")
+	if !ok || region == "" {
+		return m, nil
+	}
+	if region == m.currentRegion {
+		m.statusMessage = "The secret is already in " + region + ", pick another region"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	m.copyTo = copyDestination{client: client.WithRegion(region), label: region}
+	return m.checkCopyTarget()
+}
+
+// chooseCopyProfile signs in to the picked profile, in the current region,
+// then checks it for the secret
+func (m Model) chooseCopyProfile(profile string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if _, ok := m.awsSource("Copying"); !ok || profile == "" {
+		return m, nil
+	}
+	if profile == m.currentProfile {
+		m.statusMessage = "The secret is already in " + profile + ", pick another profile or press C to copy to another region"
+		return m, clearStatusAfter(4 * time.Second)
+	}
+	return m.signInSecond(secondForCopy, profile, m.currentRegion)
+}
+
+// checkCopyTarget looks for the open secret at the destination
+func (m Model) checkCopyTarget() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil || m.copyTo.client == nil {
+		return m, nil
+	}
+	ctx := m.startLoad("Checking " + m.copyTo.label + " for " + secret.Name + "…")
+	return m, checkCopyTarget(ctx, m.copyTo, secret.Name)
+}
+
+// confirmCopyTarget copies the secret straight away when the destination
+// doesn't have it, and asks before overwriting it otherwise
+func (m Model) confirmCopyTarget(msg copyTargetCheckedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showErrorFor(m.copyTo.client.GetProfile(), m.copyTo.client.GetRegion(), "Failed to check "+msg.label, msg.err)
+		m.copyTo = copyDestination{}
+		return m, nil
+	}
+	if !msg.exists {
+		return m.copyOpenSecret(false)
+	}
+	if msg.owner != "" {
+		m.copyTo = copyDestination{}
+		service := models.OwningServiceName(msg.owner)
+		m.errorMessage = fmt.Sprintf("%s in %s is managed by %s and can only be changed through %s, so it can't be overwritten", msg.name, msg.label, service, service)
+		return m, nil
+	}
+
+	intro := fmt.Sprintf("A secret with this name already exists in %s. Its value will be replaced by a new version, and its description and tags updated:", msg.label)
+	items := []components.SummaryItem{{Name: msg.name}}
+	m.openDestructiveConfirm(ScreenCopyConfirm, "Overwrite in "+msg.label+"?", intro, items, msg.name, ScreenSecretDetail)
+	return m, nil
+}
+
+// copyOpenSecret copies the open secret to the chosen destination
+func (m Model) copyOpenSecret(overwrite bool) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	client, ok := m.awsSource("Copying")
+	if !ok || m.copyTo.client == nil {
+		return m, nil
+	}
+	ctx := m.startLoad("Copying " + secret.Name + " to " + m.copyTo.label + "…")
+	return m, copySecret(ctx, client, m.copyTo, secret.Name, overwrite, m.schemas)
+}
+
+// showSecretCopied reports the result of a copy and drops the destination
+// client
+func (m Model) showSecretCopied(msg secretCopiedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	dest := m.copyTo
+	m.copyTo = copyDestination{}
+	if msg.err != nil {
+		m.showError("Failed to copy secret to "+msg.label, msg.err)
+		m.logOperation(fmt.Sprintf("Failed to copy %s to %s", msg.name, msg.label), msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.statusMessage = fmt.Sprintf("Updated %s in %s", msg.name, msg.label)
+	if msg.created {
+		m.statusMessage = fmt.Sprintf("Created %s in %s", msg.name, msg.label)
+	} else {
+		m.pushUndo(undoEntry{kind: undoOverwrite, names: []string{msg.name}, dest: dest})
+	}
+	m.logOperation(m.statusMessage, nil)
+	return m, clearStatusAfter(4 * time.Second)
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// logMsg records a message the model received in the debug log, by type.
+// Typed text is left out, as it may be an MFA code or a secret being
+// entered; only named keys such as enter are logged.
+func logMsg(msg tea.Msg) {
+	if !logging.DebugEnabled() {
+		return
+	}
+
+	attrs := []any{"type", fmt.Sprintf("%T", msg)}
+	switch msg := msg.(type) {
+	case spinner.TickMsg:
+		// Many times a second while loading, drowning out everything else
+		return
+	case tea.KeyMsg:
+		if msg.Type != tea.KeyRunes {
+			attrs = append(attrs, "key", msg.String())
+		}
+	case tea.WindowSizeMsg:
+		attrs = append(attrs, "width", msg.Width, "height", msg.Height)
+	}
+	logging.Debug("tea message", attrs...)
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// linkedSecretMsg reports looking up the secret the UI was started on
+type linkedSecretMsg struct {
+	inventory *inventory.Service
+	target    string
+	secrets   []models.Secret
+	err       error
+}
+
+// WithSecret starts on the detail screen of a secret, given by name or ARN,
+// once the first page of the list has loaded
+func (m Model) WithSecret(target string) Model {
+	m.deepLink = target
+	return m
+}
+
+// linkedName is the name to look a linked secret up by
+func linkedName(target string) string {
+	if arn, ok := aws.ParseSecretARN(target); ok {
+		return arn.Name
+	}
+	return target
+}
+
+// isLinked reports whether secret is the one target names. An ARN may be
+// partial, without the random suffix.
+func isLinked(secret models.Secret, target string) bool {
+	if _, ok := aws.ParseSecretARN(target); ok {
+		return secret.ARN == target || strings.HasPrefix(secret.ARN, target+"-")
+	}
+	return secret.Name == target
+}
+
+// findLinkedSecret lists the secrets whose names start with the linked
+// secret's name, to find it wherever it is in the list
+func findLinkedSecret(ctx context.Context, inv *inventory.Service, target string) tea.Cmd {
+	return func() tea.Msg {
+		secrets, err := inv.Find(ctx, linkedName(target))
+		return linkedSecretMsg{inventory: inv, target: target, secrets: secrets, err: err}
+	}
+}
+
+// followDeepLink starts looking up the linked secret, once
+func (m *Model) followDeepLink() tea.Cmd {
+	if m.deepLink == "" || m.inventory == nil {
+		return nil
+	}
+	target := m.deepLink
+	m.deepLink = ""
+	return findLinkedSecret(m.startLoad("Finding "+linkedName(target)+"…"), m.inventory, target)
+}
+
+// openLinkedSecret opens the detail screen of the linked secret. When it
+// isn't on the page loaded, it is listed on its own until a refresh.
+func (m Model) openLinkedSecret(msg linkedSecretMsg) (tea.Model, tea.Cmd) {
+	if msg.inventory != m.inventory || cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showError("Failed to find "+linkedName(msg.target), msg.err)
+		return m, nil
+	}
+	if m.currentScreen != ScreenSecretList || m.screen != nil {
+		// Something else was opened while it was looked up
+		return m, nil
+	}
+
+	var secret *models.Secret
+	for i := range msg.secrets {
+		if isLinked(msg.secrets[i], msg.target) {
+			secret = &msg.secrets[i]
+			break
+		}
+	}
+	if secret == nil {
+		m.errorMessage = fmt.Sprintf("No secret %s in %s", msg.target, m.currentRegion)
+		return m, nil
+	}
+
+	if !m.grid.SelectName(secret.Name) {
+		m.grid.SetFilter("")
+		m.showSecrets([]models.Secret{*secret})
+		m.grid.SelectName(secret.Name)
+		m.statusMessage = "Listing " + secret.Name + " on its own; r lists every secret"
+	}
+	return m.Update(keyMsgFor("enter"))
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dryRunLog collects the writes a dry run skipped. Commands record them as
+// they run and Update reports them once their result arrives, so one log is
+// shared by every copy of the model.
+type dryRunLog struct {
+	mu      sync.Mutex
+	changes []aws.DryRunChange
+}
+
+// record adds a skipped write; it is called from commands
+func (l *dryRunLog) record(change aws.DryRunChange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.changes = append(l.changes, change)
+}
+
+// pending reports whether writes have been skipped since the last drain
+func (l *dryRunLog) pending() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.changes) > 0
+}
+
+// drain returns the skipped writes and forgets them
+func (l *dryRunLog) drain() []aws.DryRunChange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changes := l.changes
+	l.changes = nil
+	return changes
+}
+
+// WithDryRun starts the model in dry-run mode, from --dry-run
+func (m Model) WithDryRun() Model {
+	m.dryRun = &dryRunLog{}
+	return m
+}
+
+// dryRunContext makes ctx a dry run while dry-run mode is on
+func (m Model) dryRunContext(ctx context.Context) context.Context {
+	if m.dryRun == nil {
+		return ctx
+	}
+	return aws.WithDryRun(ctx, m.dryRun.record)
+}
+
+// dryRunPending reports whether the result being handled is of writes a dry
+// run skipped, which aren't logged or audited as if they were made
+func (m Model) dryRunPending() bool {
+	return m.dryRun != nil && m.dryRun.pending()
+}
+
+// toggleDryRun turns dry-run mode on or off. Only Secrets Manager can
+// rehearse writes, and the mode can't change under an operation in progress,
+// whose writes would then be reported wrongly.
+func (m Model) toggleDryRun() (tea.Model, tea.Cmd) {
+	switch {
+	case !m.awsBackend():
+		m.errorMessage = "Dry runs only work with AWS"
+		return m, nil
+	case m.loading:
+		m.errorMessage = "Wait for the operation in progress to finish before switching dry-run mode"
+		return m, nil
+	}
+	if m.dryRun != nil {
+		m.dryRun = nil
+		m.statusMessage = "Dry run off: changes are made again"
+	} else {
+		m.dryRun = &dryRunLog{}
+		m.statusMessage = "Dry run on: writes are listed in the operations pane instead of being made"
+	}
+	return m, clearStatusAfter(4 * time.Second)
+}
+
+// reportDryRun lists the writes skipped by the command whose result was just
+// handled in the operations pane, opening it
+func (m Model) reportDryRun(cmd tea.Cmd) (Model, tea.Cmd) {
+	if m.dryRun == nil {
+		return m, cmd
+	}
+	changes := m.dryRun.drain()
+	if len(changes) == 0 {
+		return m, cmd
+	}
+	for _, change := range changes {
+		m.ops.entries = append(m.ops.entries, operation{at: time.Now(), text: "Dry run: " + change.Action})
+	}
+	if m.ops.open {
+		m.resizeGrid()
+	} else {
+		m.toggleOperations()
+	}
+	m.statusMessage = fmt.Sprintf("Dry run: %d changes listed in the operations pane, none made", len(changes))
+	return m, tea.Batch(cmd, clearStatusAfter(4*time.Second))
+}
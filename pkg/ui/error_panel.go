@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// errorHint explains an AWS failure shown in the error panel
+type errorHint struct {
+	message   string // The errorMessage it explains; it is dropped once that changes
+	cause     string // What most likely went wrong, empty when unknown
+	fix       string // What to do about it
+	requestID string // AWS's request ID, to quote to AWS support
+}
+
+// showError shows an AWS failure in the error panel for the current
+// profile and region
+func (m *Model) showError(action string, err error) {
+	m.showErrorFor(m.currentProfile, m.currentRegion, action, err)
+}
+
+// showErrorFor shows an AWS failure in the error panel, explaining the
+// common causes for profile and region with how to fix them
+func (m *Model) showErrorFor(profile, region, action string, err error) {
+	m.errorMessage = m.describeError(action, err)
+	m.errorHint = m.explainError(profile, region, err)
+	m.errorHint.message = m.errorMessage
+}
+
+// explainError works out the likely cause of err and a fix, in terms of the
+// keys that apply it where there are some
+func (m Model) explainError(profile, region string, err error) errorHint {
+	hint := errorHint{requestID: aws.ErrorRequestID(err)}
+	switch aws.ClassifyError(err) {
+	case aws.ErrorExpiredToken:
+		hint.cause = fmt.Sprintf("The session for profile %s has expired.", profile)
+		hint.fix = fmt.Sprintf("Press p and choose %s to sign in again.", profile)
+		if m.usesSSO(profile) {
+			hint.fix = fmt.Sprintf("Run `aws sso login --profile %s`, then press p and choose %s again.", profile, profile)
+		}
+	case aws.ErrorAccessDenied:
+		operation := aws.ErrorOperation(err)
+		if operation == "" {
+			operation = "make this call"
+		}
+		hint.cause = fmt.Sprintf("Profile %s isn't allowed to %s.", profile, operation)
+		hint.fix = "Press p to switch to a profile with access, or ask for the permission (see Required IAM Permissions in the README)."
+	case aws.ErrorNoCredentials:
+		hint.cause = fmt.Sprintf("No usable credentials were found for profile %s.", profile)
+		hint.fix = "Press p to choose another profile, or set this one up with `aws configure` or `aws configure sso`."
+	case aws.ErrorWrongRegion:
+		hint.cause = fmt.Sprintf("Region %q doesn't exist or can't be reached.", region)
+		if region == "" {
+			hint.cause = "No region is set for this profile."
+		}
+		hint.fix = "Press g to pick a region."
+	case aws.ErrorNotFound:
+		hint.cause = fmt.Sprintf("The secret isn't in %s. It may have been deleted, or be in another region.", region)
+		hint.fix = "Press r on the secret list to refresh it, or g to look in another region."
+	case aws.ErrorClockSkew:
+		hint.cause = "Your system clock is too far from AWS's."
+		hint.fix = "Sync the clock with NTP, then try again."
+	case aws.ErrorNetwork:
+		hint.cause = "AWS could not be reached."
+		hint.fix = "Check your network connection, proxy or VPN, then try again."
+	}
+	return hint
+}
+
+// usesSSO reports whether profile signs in with IAM Identity Center, whose
+// sessions are renewed outside Secret Src
+func (m Model) usesSSO(profile string) bool {
+	if !m.awsBackend() {
+		return false
+	}
+	profileConfig, err := aws.GetProfileConfig(profile)
+	return err == nil && profileConfig.UsesSSO()
+}
+
+// viewError renders the error panel: the error, and for AWS failures the
+// likely cause, a fix and the request ID
+func (m Model) viewError() string {
+	t := theme.Current()
+	labelStyle := lipgloss.NewStyle().Foreground(t.Secondary).Bold(true)
+	textStyle := lipgloss.NewStyle().Foreground(t.Text)
+
+	// AWS errors can echo request data, so never show a loaded secret in them
+	lines := []string{ErrorStyle.Render("Error: " + logging.Scrub(m.errorMessage))}
+	if hint := m.errorHint; hint.message == m.errorMessage {
+		if hint.cause != "" {
+			lines = append(lines, labelStyle.Render("Cause: ")+textStyle.Render(hint.cause))
+		}
+		if hint.fix != "" {
+			lines = append(lines, labelStyle.Render("Fix: ")+textStyle.Render(hint.fix))
+		}
+		if hint.requestID != "" {
+			lines = append(lines, labelStyle.Render("Request ID: ")+textStyle.Render(hint.requestID))
+		}
+	}
+
+	panelStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Error).
+		Padding(0, 1)
+	// Wrap inside the border so the footer height accounts for every line
+	if width := m.width - appBorderWidth - appHorizontalPadding - 2; width > 0 {
+		panelStyle = panelStyle.Width(width)
+	}
+	return panelStyle.Render(strings.Join(lines, "\n"))
+}
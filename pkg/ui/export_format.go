@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportFormat is the kind of file the export prompt writes
+type exportFormat int
+
+const (
+	exportDocument  exportFormat = iota // JSON or YAML by extension, or a combined .env
+	exportDockerEnv                     // An env file for docker run --env-file
+	exportCompose                       // A compose secrets snippet, with a file per value
+)
+
+// composeSecretsDir is the directory beside a compose snippet that its
+// secret values are written to
+const composeSecretsDir = "secrets"
+
+// exportFormats lists the formats offered by the export format picker
+func exportFormats() []components.Action {
+	return []components.Action{
+		{Key: "d", Title: "JSON/YAML document", Description: "One document keyed by secret name, JSON or YAML by extension; a .env path writes a combined dotenv file"},
+		{Key: "v", Title: "Docker env file", Description: "Unquoted VAR=value lines for docker run --env-file"},
+		{Key: "c", Title: "Docker compose secrets", Description: "A compose secrets: snippet, with each value in its own owner-only file under " + composeSecretsDir + "/"},
+	}
+}
+
+// startExport asks which format to export the named secrets in
+func (m *Model) startExport(names []string, returnTo Screen) {
+	title := fmt.Sprintf("Export %d secrets as", len(names))
+	if len(names) == 1 {
+		title = "Export " + names[0] + " as"
+	}
+	m.exportTargets = names
+	m.openScreen(ScreenExportFormat, newActionScreen(title, exportFormats()), returnTo)
+}
+
+// chooseExportFormat prompts for the path to export in the picked format
+func (m Model) chooseExportFormat(key string) (tea.Model, tea.Cmd) {
+	names := m.exportTargets
+	base := "secrets"
+	if len(names) == 1 {
+		base = strings.TrimSuffix(defaultSavePath(names[0]), ".bin")
+	}
+
+	var title, path string
+	switch key {
+	case "d":
+		m.exportFormat = exportDocument
+		title = fmt.Sprintf("Export %d secrets as JSON, YAML or one combined .env (by extension)", len(names))
+		if len(names) == 1 {
+			title = "Export " + names[0] + " as JSON or YAML (by extension)"
+		}
+		path = base + ".json"
+	case "v":
+		m.exportFormat = exportDockerEnv
+		title = "Export as a Docker env file"
+		path = base + ".env"
+	case "c":
+		m.exportFormat = exportCompose
+		title = "Export as compose secrets, with the values under " + composeSecretsDir + "/ beside the snippet"
+		path = "compose.secrets.yaml"
+	default:
+		return m, nil
+	}
+	m.openScreen(ScreenExportSecrets, newPathScreen(title, path), m.currentScreen)
+	return m, nil
+}
+
+// writeDockerEnv renders the combined fields of several secrets as a Docker
+// env file, see combineDotenv
+func writeDockerEnv(names []string, values map[string]string, mapping secretvalue.EnvMapping) ([]byte, []string, error) {
+	vars, conflicts, err := combineEnvVars(names, values, mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	env, err := secretvalue.FormatDockerEnv(vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []byte(env), conflicts, nil
+}
+
+// writeComposeSecrets writes a compose snippet declaring the combined fields
+// of several secrets to path, and each value to its own file in the secrets
+// directory beside it. Nothing is written if any of the files exists.
+func writeComposeSecrets(path string, names []string, values map[string]string) secretsExportedMsg {
+	fields, conflicts := combineSecretFields(names, values)
+	snippet, err := secretvalue.ComposeSecrets(fields, composeSecretsDir)
+	if err != nil {
+		return secretsExportedMsg{err: err}
+	}
+
+	path, err = expandSavePath(path)
+	if err != nil {
+		return secretsExportedMsg{err: err}
+	}
+	dir := filepath.Join(filepath.Dir(path), composeSecretsDir)
+	for _, field := range fields {
+		file := filepath.Join(dir, field.Key)
+		if _, err := os.Lstat(file); err == nil {
+			return secretsExportedMsg{err: fmt.Errorf("%s %w", file, errFileExists)}
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return secretsExportedMsg{err: fmt.Errorf("failed to create directory: %w", err)}
+	}
+
+	written, err := writeSecretFile(path, snippet)
+	if err != nil {
+		return secretsExportedMsg{err: err}
+	}
+	for _, field := range fields {
+		if _, err := writeSecretFile(filepath.Join(dir, field.Key), []byte(field.Value)); err != nil {
+			return secretsExportedMsg{err: err}
+		}
+	}
+	return secretsExportedMsg{path: written, count: len(names), valueFiles: len(fields), conflicts: conflicts}
+}
@@ -0,0 +1,336 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpChrome is the number of lines the help border and padding take,
+// with the scroll hints above and below the text
+const helpChrome = 6
+
+// helpSection is a group of key bindings on the help screen, and the
+// screen whose extra keys from the keybindings setting apply to them
+type helpSection struct {
+	title    string
+	screen   Screen
+	bindings []key.Binding
+}
+
+// helpNotes follow the key bindings on the help screen
+var helpNotes = []string{
+	"Filter queries match names, descriptions, tags and ARNs; prefix them",
+	"with name: desc: tag: arn: or kms: to search one, e.g. tag:env=prod",
+	"",
+	"Secret values are only fetched on demand (v), and are cleared from",
+	"memory when you navigate away, unless value_cache_ttl keeps them for",
+	"a while (X clears them). Clipboard contents persist after the app",
+	"closes, until the clipboard timeout clears them.",
+}
+
+// helpSections groups the key map by screen, in the order the help
+// screen lists them
+func (k KeyMap) helpSections() []helpSection {
+	return []helpSection{
+		{"Secret list", ScreenSecretList, []key.Binding{
+			k.Up, k.Down, k.Left, k.Right, k.Select, k.Back, k.GridNextPage, k.GridPrevPage,
+			k.Count, k.FirstSecret, k.LastSecret, k.JumpToLetter, k.Filter, k.CycleSort,
+			k.ToggleLayout, k.DetailPanel, k.WidenPanel, k.NarrowPanel, k.Mark, k.ClearMarks,
+		}},
+		{"Secret list actions", ScreenSecretList, []key.Binding{
+			k.Export, k.CopyEnv, k.Bulk, k.Report, k.Audit, k.Lint, k.Apps, k.Refresh, k.Watch,
+			k.FlagRotation, k.Undo, k.Profile, k.Region, k.Workspaces, k.Backends, k.Import, k.NewSecret, k.ImportFile, k.DryRun, k.NextPage, k.PrevPage,
+			k.LoadAll,
+		}},
+		{"Secret detail", ScreenSecretDetail, []key.Binding{
+			k.Actions, k.ViewValue, k.Reveal, k.SelectKey, k.RevealKey, k.CopyKey, k.CopyPlain,
+			k.CopyJSON, k.CopyField, k.SaveToFile, k.ExportSecret, k.BinaryFormat, k.ConsoleLink, k.OpenConsole,
+			k.CopyToRegion, k.CopyToProfile, k.Compare, k.CompareKube, k.Sync, k.References, k.Access, k.KubeManifest, k.Terraform, k.ChangeKMSKey,
+		}},
+		{"Everywhere", ScreenSecretList, []key.Binding{
+			k.Operations, k.PurgeValues, k.Help, k.KeyReference, k.Quit,
+		}},
+	}
+}
+
+// withAliases adds the extra keys the keybindings setting gives a binding
+// to its keys and its help
+func withAliases(binding key.Binding, aliases map[string]string) key.Binding {
+	var extra []string
+	for alias, target := range aliases {
+		for _, bound := range binding.Keys() {
+			if bound == target {
+				extra = append(extra, alias)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return binding
+	}
+	sort.Strings(extra)
+	binding.SetKeys(append(binding.Keys(), extra...)...)
+	binding.SetHelp(binding.Help().Key+"/"+strings.Join(extra, "/"), binding.Help().Desc)
+	return binding
+}
+
+// helpLines renders every section of the help screen, with the extra keys
+// from the keybindings setting
+func (m Model) helpLines() []string {
+	t := theme.Current()
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(t.Primary)
+
+	view := help.New()
+	view.Styles.FullKey = lipgloss.NewStyle().Bold(true).Foreground(t.Secondary)
+	view.Styles.FullDesc = lipgloss.NewStyle().Foreground(t.Text)
+
+	// The keys are padded to the widest, so every section lines up
+	sections := m.keys.helpSections()
+	keyWidth := 0
+	for _, section := range sections {
+		for i, binding := range section.bindings {
+			section.bindings[i] = withAliases(binding, m.keyAliases[section.screen])
+			keyWidth = max(keyWidth, lipgloss.Width(section.bindings[i].Help().Key))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("AWS Secrets Manager TUI - Help") + "\n")
+	for _, section := range sections {
+		for i, binding := range section.bindings {
+			section.bindings[i].SetHelp(fmt.Sprintf("%-*s", keyWidth, binding.Help().Key), binding.Help().Desc)
+		}
+		b.WriteString("\n" + titleStyle.Render(strings.ToUpper(section.title)) + "\n")
+		b.WriteString(view.FullHelpView([][]key.Binding{section.bindings}) + "\n")
+	}
+	b.WriteString("\n" + HelpStyle.Render(strings.Join(helpNotes, "\n")))
+	return strings.Split(b.String(), "\n")
+}
+
+// helpRows is how many lines of help fit on the screen
+func (m Model) helpRows() int {
+	_, height := m.contentViewportSize()
+	return max(height-helpChrome, 3)
+}
+
+// scrollHelp moves the help screen by delta lines, within its length
+func (m *Model) scrollHelp(delta int) {
+	maxOffset := max(len(m.helpLines())-m.helpRows(), 0)
+	m.helpOffset = min(max(m.helpOffset+delta, 0), maxOffset)
+}
+
+// viewHelp renders the part of the help screen scrolled to
+func (m Model) viewHelp() string {
+	lines := m.helpLines()
+	rows := m.helpRows()
+	offset := min(m.helpOffset, max(len(lines)-rows, 0))
+	end := min(offset+rows, len(lines))
+
+	var b strings.Builder
+	if offset > 0 {
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("↑ %d more", offset)))
+	}
+	b.WriteString("\n" + strings.Join(lines[offset:end], "\n") + "\n")
+	if end < len(lines) {
+		b.WriteString(HelpStyle.Render(fmt.Sprintf("↓ %d more", len(lines)-end)))
+	}
+	return BorderStyle.Render(b.String())
+}
+
+// handleHelpKeys scrolls and closes the help screen
+func (m Model) handleHelpKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "H", "?", "esc", "q":
+		m.showHelp = false
+	case "up", "k":
+		m.scrollHelp(-1)
+	case "down", "j":
+		m.scrollHelp(1)
+	case "pgup":
+		m.scrollHelp(-m.helpRows())
+	case "pgdown", " ":
+		m.scrollHelp(m.helpRows())
+	case "home":
+		m.helpOffset = 0
+	case "end", "G":
+		m.scrollHelp(len(m.helpLines()))
+	}
+	return m, nil
+}
+
+// screenKeys are the key hints in the footer of the secret list and detail
+// screens: a line of the most used keys, and every key of the screen in
+// columns when expanded with ?
+type screenKeys struct {
+	short []key.Binding
+	full  []key.Binding
+}
+
+// ShortHelp implements help.KeyMap
+func (s screenKeys) ShortHelp() []key.Binding {
+	return s.short
+}
+
+// FullHelp implements help.KeyMap, leaving the columns to helpColumns
+func (s screenKeys) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.full}
+}
+
+// footerKeys returns the key hints of the current screen, with the extra
+// keys from the keybindings setting. Screens whose hints are fixed text,
+// see footerHelp, return false.
+func (m Model) footerKeys() (screenKeys, bool) {
+	k := m.keys
+	var keys screenKeys
+	switch {
+	case m.currentScreen == ScreenSecretList && !m.showHelp:
+		keys.short = []key.Binding{k.Select, k.Filter, k.Mark, k.Export, k.Refresh, k.Profile, k.Region}
+		if m.currentPage > 0 {
+			keys.short = append(keys.short, k.PrevPage)
+		}
+		if m.hasNextPage() {
+			keys.short = append(keys.short, k.NextPage, k.LoadAll)
+		}
+		keys.short = append(keys.short, k.Back)
+		for _, section := range k.helpSections() {
+			if section.screen != ScreenSecretList {
+				continue
+			}
+			for _, binding := range section.bindings {
+				// ? has its own hint after the others
+				if bindingKey(binding) != "?" {
+					keys.full = append(keys.full, binding)
+				}
+			}
+		}
+
+	case m.currentScreen == ScreenSecretDetail:
+		switch {
+		case !m.secretLoaded():
+			keys.short = []key.Binding{k.ViewValue, k.Actions}
+		case m.secretBinary != nil:
+			keys.short = []key.Binding{k.Actions, k.Reveal, k.BinaryFormat, k.CopyPlain, k.SaveToFile}
+		case len(m.secretFields) > 0 && !m.valueRevealed:
+			keys.short = []key.Binding{k.Actions, k.SelectKey, k.RevealKey, k.CopyKey, k.Reveal, k.CopyPlain, k.CopyJSON, k.CopyField}
+		case len(m.secretFields) > 0:
+			keys.short = []key.Binding{k.Actions, k.Reveal, k.CopyPlain, k.CopyJSON, k.CopyField, k.SaveToFile}
+		default:
+			keys.short = []key.Binding{k.Actions, k.Reveal, k.CopyPlain, k.CopyJSON, k.SaveToFile}
+		}
+		keys.short = append(keys.short, k.Back)
+		for _, section := range k.helpSections() {
+			if section.screen == ScreenSecretDetail {
+				keys.full = append(keys.full, section.bindings...)
+			}
+		}
+		keys.full = append(keys.full, k.Operations, k.Back, k.Quit)
+
+	default:
+		return screenKeys{}, false
+	}
+
+	aliases := m.keyAliases[m.currentScreen]
+	for i, binding := range keys.short {
+		keys.short[i] = withAliases(binding, aliases)
+	}
+	for i, binding := range keys.full {
+		keys.full[i] = withAliases(binding, aliases)
+	}
+	return keys, true
+}
+
+// toggleFooterHelp expands or collapses the key hints in the footer
+func (m *Model) toggleFooterHelp() {
+	m.fullHelp = !m.fullHelp
+	m.resizeGrid()
+}
+
+// newFooterHelp returns the help component drawing the footer hints, width
+// columns wide
+func newFooterHelp(width int) help.Model {
+	view := help.New()
+	view.Width = width
+	view.Styles.ShortKey = HelpStyle.Bold(true)
+	view.Styles.ShortDesc = HelpStyle
+	view.Styles.ShortSeparator = HelpStyle
+	view.Styles.FullKey = HelpStyle.Bold(true)
+	view.Styles.FullDesc = HelpStyle
+	view.Styles.FullSeparator = HelpStyle
+	view.Styles.Ellipsis = HelpStyle
+	return view
+}
+
+// moreKeys is the hint for ?, which stays in view when the hints before it
+// are cut short
+func (m Model) moreKeys() key.Binding {
+	binding := withAliases(m.keys.Help, m.keyAliases[ScreenSecretList])
+	desc := binding.Help().Desc
+	if m.fullHelp {
+		desc = "fewer keys"
+	}
+	if m.narrow() {
+		// Leave what room there is to the hints
+		desc, _, _ = strings.Cut(desc, " ")
+	}
+	binding.SetHelp(binding.Help().Key, desc)
+	return binding
+}
+
+// viewFooterKeys renders the key hints of keys: one line, cut short with
+// an ellipsis when it doesn't fit, or every key in columns
+func (m Model) viewFooterKeys(keys screenKeys) string {
+	width, height := m.innerSize()
+	tail := newFooterHelp(0).ShortHelpView([]key.Binding{m.moreKeys()})
+	if m.fullHelp {
+		// Leave at least half the screen to the list
+		columns := helpColumns(keys.full, width, max(height/2, 4))
+		return newFooterHelp(width).FullHelpView(columns) + "\n" + tail
+	}
+	separator := help.New().ShortSeparator
+	view := newFooterHelp(max(width-lipgloss.Width(tail+separator), 0))
+	return view.ShortHelpView(keys.short) + HelpStyle.Render(separator) + tail
+}
+
+// helpColumns splits bindings into columns as short as fit side by side
+// in width, but no taller than maxRows. Columns that still don't fit are
+// left to help.Model, which cuts them off with an ellipsis.
+func helpColumns(bindings []key.Binding, width, maxRows int) [][]key.Binding {
+	for rows := 1; ; rows++ {
+		columns := chunkBindings(bindings, rows)
+		if width <= 0 || rows >= len(bindings) || rows >= maxRows || columnsWidth(columns) <= width {
+			return columns
+		}
+	}
+}
+
+// chunkBindings splits bindings into columns of rows bindings
+func chunkBindings(bindings []key.Binding, rows int) [][]key.Binding {
+	var columns [][]key.Binding
+	for start := 0; start < len(bindings); start += rows {
+		columns = append(columns, bindings[start:min(start+rows, len(bindings))])
+	}
+	return columns
+}
+
+// columnsWidth is how wide help.Model.FullHelpView draws columns
+func columnsWidth(columns [][]key.Binding) int {
+	total := 0
+	for i, column := range columns {
+		keyWidth, descWidth := 0, 0
+		for _, binding := range column {
+			keyWidth = max(keyWidth, lipgloss.Width(binding.Help().Key))
+			descWidth = max(descWidth, lipgloss.Width(binding.Help().Desc))
+		}
+		if i > 0 {
+			total += lipgloss.Width(help.New().FullSeparator)
+		}
+		total += keyWidth + 1 + descWidth
+	}
+	return total
+}
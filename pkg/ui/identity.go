@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// identityLoadedMsg reports the result of loadIdentity
+type identityLoadedMsg struct {
+	client   SecretStore
+	identity *models.CallerIdentity
+	err      error
+}
+
+// loadIdentity asks who the client's credentials belong to
+func loadIdentity(client SecretStore, timeout time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		identity, err := client.CallerIdentity(ctx)
+		return identityLoadedMsg{client: client, identity: identity, err: err}
+	}
+}
+
+// showIdentity adds the account and identity to the header. It is
+// informational, so a failure is only logged.
+func (m Model) showIdentity(msg identityLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.client != m.awsClient {
+		// Loaded for a profile or region that has since changed
+		return m, nil
+	}
+	if msg.err != nil {
+		logging.Debugf("Caller identity unavailable: %v", msg.err)
+		return m, nil
+	}
+	m.identity = msg.identity
+	m.resizeGrid()
+	return m, nil
+}
+
+// summarizeProfiles describes profiles for the profile selector, filling in
+// the current profile's account from the caller identity when its settings
+// don't say
+func (m Model) summarizeProfiles(profiles []string) []models.ProfileSummary {
+	summaries := aws.SummarizeProfiles(profiles)
+	for i := range summaries {
+		if summaries[i].Name == m.currentProfile && summaries[i].Account == "" && m.identity != nil {
+			summaries[i].Account = m.identity.Account
+		}
+	}
+	return summaries
+}
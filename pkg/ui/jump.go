@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCount caps a count prefix, so holding a digit can't overflow it
+const maxCount = 9999
+
+// pendingGTimeout is how long a lone g waits for a second one before it
+// switches region, as vim's timeoutlen
+const pendingGTimeout = time.Second
+
+// jumpState is the vim-style prefix typed so far on the secret list
+type jumpState struct {
+	count   int  // Repeats the next movement, or picks the secret for G
+	finding bool // f was pressed and the next key is the letter to jump to
+	// pendingG is when g was pressed, waiting for a second g to jump to the
+	// first secret; zero when no g is pending
+	pendingG time.Time
+}
+
+// pendingGTimeoutMsg ends the wait for a second g. pressed tells it from the
+// timeouts of earlier presses.
+type pendingGTimeoutMsg struct {
+	pressed time.Time
+}
+
+// handleJumpKeys handles count prefixes, G, gg, home, end and f<char> on the
+// secret list. It reports whether it used the key; any other key drops a
+// pending count.
+func (m Model) handleJumpKeys(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	key := msg.String()
+	if !m.jump.pendingG.IsZero() {
+		count := m.jump.count
+		m.jump = jumpState{}
+		if key == "g" {
+			// Like vim, a count picks the secret by position
+			m.grid.Select(max(count, 1) - 1)
+			return m, nil, true
+		}
+		if key == "esc" {
+			return m, nil, true
+		}
+		// g was meant on its own: switch region, then handle this key as
+		// typed after it, on the region selector once it's open
+		updated, cmd := m.switchRegion()
+		updated, keyCmd := updated.Update(msg)
+		return updated.(Model), tea.Batch(cmd, keyCmd), true
+	}
+	if m.jump.finding {
+		m.jump = jumpState{}
+		if key == "esc" || len(msg.Runes) != 1 {
+			return m, nil, true
+		}
+		if !m.grid.JumpToPrefix(key) {
+			m.statusMessage = fmt.Sprintf("No secret starts with %q", key)
+			return m, clearStatusAfter(3 * time.Second), true
+		}
+		return m, nil, true
+	}
+
+	count := m.jump.count
+	m.jump.count = 0
+	switch key {
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9", "0":
+		digit, _ := strconv.Atoi(key)
+		if count == 0 && digit == 0 {
+			return m, nil, false
+		}
+		m.jump.count = min(count*10+digit, maxCount)
+		return m, nil, true
+
+	case "G":
+		// Like vim, a count picks the secret by position
+		if count > 0 {
+			m.grid.Select(count - 1)
+		} else {
+			m.grid.Select(m.grid.Len() - 1)
+		}
+		return m, nil, true
+
+	case "home":
+		m.grid.Select(0)
+		return m, nil, true
+
+	case "end":
+		m.grid.Select(m.grid.Len() - 1)
+		return m, nil, true
+
+	case "f":
+		m.jump.finding = true
+		return m, nil, true
+
+	case "g":
+		// Wait for a second g; the count is kept for it
+		pressed := time.Now()
+		m.jump.count = count
+		m.jump.pendingG = pressed
+		return m, tea.Tick(pendingGTimeout, func(time.Time) tea.Msg {
+			return pendingGTimeoutMsg{pressed: pressed}
+		}), true
+
+	case "up", "k", "down", "j", "left", "h", "right", "l":
+		for range max(count, 1) {
+			m.grid.Update(msg)
+		}
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// pendingGTimeout switches region once a lone g has waited long enough,
+// unless a later key has already been handled
+func (m Model) pendingGTimeout(msg pendingGTimeoutMsg) (tea.Model, tea.Cmd) {
+	if m.jump.pendingG.IsZero() || !m.jump.pendingG.Equal(msg.pressed) {
+		return m, nil
+	}
+	m.jump = jumpState{}
+	if m.currentScreen != ScreenSecretList || m.screen != nil {
+		return m, nil
+	}
+	return m.switchRegion()
+}
+
+// prompt shows the prefix typed so far, or "" when there is none
+func (j jumpState) prompt() string {
+	switch {
+	case !j.pendingG.IsZero():
+		return "g: g for the first secret, or wait to switch region"
+	case j.finding:
+		return "f: type a letter to jump to"
+	case j.count > 0:
+		return strconv.Itoa(j.count)
+	}
+	return ""
+}
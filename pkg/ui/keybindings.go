@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// boundAction is an action the keybindings setting can give another key:
+// the screen it is on and the key it is bound to by default
+type boundAction struct {
+	screen Screen
+	key    string
+}
+
+// boundActions are the actions the keybindings setting accepts, named after
+// their KeyMap bindings
+var boundActions = map[string]boundAction{
+	"select":          {ScreenSecretList, "enter"},
+	"refresh":         {ScreenSecretList, "r"},
+	"profile":         {ScreenSecretList, "p"},
+	"region":          {ScreenSecretList, "g"},
+	"next_page":       {ScreenSecretList, "n"},
+	"prev_page":       {ScreenSecretList, "b"},
+	"load_all":        {ScreenSecretList, "a"},
+	"filter":          {ScreenSecretList, "/"},
+	"toggle_layout":   {ScreenSecretList, "L"},
+	"cycle_sort":      {ScreenSecretList, "s"},
+	"mark":            {ScreenSecretList, "m"},
+	"clear_marks":     {ScreenSecretList, "M"},
+	"export":          {ScreenSecretList, "e"},
+	"copy_env":        {ScreenSecretList, "E"},
+	"report":          {ScreenSecretList, "R"},
+	"audit":           {ScreenSecretList, "A"},
+	"lint":            {ScreenSecretList, "C"},
+	"apps":            {ScreenSecretList, "t"},
+	"watch":           {ScreenSecretList, "w"},
+	"flag_rotation":   {ScreenSecretList, "F"},
+	"first_secret":    {ScreenSecretList, "home"},
+	"last_secret":     {ScreenSecretList, "G"},
+	"jump_to_letter":  {ScreenSecretList, "f"},
+	"detail_panel":    {ScreenSecretList, "|"},
+	"widen_panel":     {ScreenSecretList, "<"},
+	"narrow_panel":    {ScreenSecretList, ">"},
+	"operations":      {ScreenSecretList, "o"},
+	"purge_values":    {ScreenSecretList, "X"},
+	"undo":            {ScreenSecretList, "u"},
+	"workspaces":      {ScreenSecretList, "W"},
+	"backends":        {ScreenSecretList, "V"},
+	"import":          {ScreenSecretList, "I"},
+	"new_secret":      {ScreenSecretList, "N"},
+	"import_file":     {ScreenSecretList, "O"},
+	"dry_run":         {ScreenSecretList, "D"},
+	"bulk":            {ScreenSecretList, "B"},
+	"help":            {ScreenSecretList, "?"},
+	"key_reference":   {ScreenSecretList, "H"},
+	"view_value":      {ScreenSecretDetail, "v"},
+	"copy_plain":      {ScreenSecretDetail, "c"},
+	"copy_json":       {ScreenSecretDetail, "j"},
+	"copy_field":      {ScreenSecretDetail, "k"},
+	"save_to_file":    {ScreenSecretDetail, "s"},
+	"binary_format":   {ScreenSecretDetail, "x"},
+	"reveal":          {ScreenSecretDetail, "r"},
+	"actions":         {ScreenSecretDetail, "a"},
+	"console_link":    {ScreenSecretDetail, "u"},
+	"open_console":    {ScreenSecretDetail, "O"},
+	"copy_to_region":  {ScreenSecretDetail, "C"},
+	"copy_to_profile": {ScreenSecretDetail, "P"},
+	"compare":         {ScreenSecretDetail, "D"},
+	"compare_cluster": {ScreenSecretDetail, "M"},
+	"sync":            {ScreenSecretDetail, "S"},
+	"references":      {ScreenSecretDetail, "U"},
+	"access":          {ScreenSecretDetail, "W"},
+	"kube_manifest":   {ScreenSecretDetail, "K"},
+	"terraform":       {ScreenSecretDetail, "T"},
+	"change_kms_key":  {ScreenSecretDetail, "E"},
+}
+
+// reservedKeys are used on every screen without being in boundActions, so
+// they can't be given to an action. Digits are count prefixes on the secret
+// list.
+var reservedKeys = []string{"ctrl+c", "esc", "q", "up", "down", "left", "right", "h", "j", "k", "l", " ", "pgup", "pgdown", "tab",
+	"end", "0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
+
+// keyAliases maps the extra keys from the keybindings setting to the default
+// keys they stand for, per screen
+type keyAliases map[Screen]map[string]string
+
+// parseKeyBindings turns the keybindings setting into aliases. Bindings for
+// unknown actions or for keys that are already in use are reported and left
+// out.
+func parseKeyBindings(bindings map[string]string) (keyAliases, error) {
+	inUse := map[Screen]map[string]bool{}
+	for _, action := range boundActions {
+		if inUse[action.screen] == nil {
+			inUse[action.screen] = map[string]bool{}
+			for _, key := range reservedKeys {
+				inUse[action.screen][key] = true
+			}
+		}
+		inUse[action.screen][action.key] = true
+	}
+
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	aliases := keyAliases{}
+	var errs []error
+	for _, name := range names {
+		key := bindings[name]
+		action, ok := boundActions[name]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("keybindings: unknown action %q", name))
+		case key == "":
+			errs = append(errs, fmt.Errorf("keybindings: no key given for %s", name))
+		case inUse[action.screen][key]:
+			errs = append(errs, fmt.Errorf("keybindings: %q is already in use, so it can't be used for %s", key, name))
+		default:
+			if aliases[action.screen] == nil {
+				aliases[action.screen] = map[string]string{}
+			}
+			aliases[action.screen][key] = action.key
+			inUse[action.screen][key] = true
+		}
+	}
+	return aliases, errors.Join(errs...)
+}
+
+// translate replaces an extra key with the default key it stands for on
+// screen, leaving other keys as they are
+func (a keyAliases) translate(screen Screen, msg tea.KeyMsg) tea.KeyMsg {
+	target, ok := a[screen][msg.String()]
+	if !ok {
+		return msg
+	}
+	return keyMsgFor(target)
+}
+
+// namedKeys are the key names keyMsgFor builds special keys for
+var namedKeys = map[string]tea.KeyType{
+	"enter":  tea.KeyEnter,
+	"esc":    tea.KeyEsc,
+	"tab":    tea.KeyTab,
+	"home":   tea.KeyHome,
+	"end":    tea.KeyEnd,
+	"ctrl+c": tea.KeyCtrlC,
+}
+
+// keyMsgFor builds the key message a key name such as "enter" or "r" is
+// read from
+func keyMsgFor(name string) tea.KeyMsg {
+	if keyType, ok := namedKeys[name]; ok {
+		return tea.KeyMsg{Type: keyType}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
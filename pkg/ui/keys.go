@@ -4,26 +4,77 @@ import "github.com/charmbracelet/bubbles/key"
 
 // KeyMap defines all key bindings for the application
 type KeyMap struct {
-	Up           key.Binding
-	Down         key.Binding
-	Left         key.Binding
-	Right        key.Binding
-	Select       key.Binding
-	Back         key.Binding
-	ViewValue    key.Binding
-	CopyPlain    key.Binding
-	CopyJSON     key.Binding
-	CopyField    key.Binding
-	Refresh      key.Binding
-	Profile      key.Binding
-	Region       key.Binding
-	NextPage     key.Binding
-	PrevPage     key.Binding
-	Filter       key.Binding
-	GridNextPage key.Binding
-	GridPrevPage key.Binding
-	Help         key.Binding
-	Quit         key.Binding
+	Up            key.Binding
+	Down          key.Binding
+	Left          key.Binding
+	Right         key.Binding
+	Select        key.Binding
+	Back          key.Binding
+	Count         key.Binding
+	FirstSecret   key.Binding
+	LastSecret    key.Binding
+	JumpToLetter  key.Binding
+	ViewValue     key.Binding
+	SelectKey     key.Binding
+	RevealKey     key.Binding
+	CopyKey       key.Binding
+	CopyPlain     key.Binding
+	CopyJSON      key.Binding
+	CopyField     key.Binding
+	SaveToFile    key.Binding
+	ExportSecret  key.Binding
+	BinaryFormat  key.Binding
+	Reveal        key.Binding
+	Actions       key.Binding
+	ConsoleLink   key.Binding
+	OpenConsole   key.Binding
+	CopyToRegion  key.Binding
+	CopyToProfile key.Binding
+	Compare       key.Binding
+	CompareKube   key.Binding
+	Sync          key.Binding
+	References    key.Binding
+	Access        key.Binding
+	KubeManifest  key.Binding
+	Terraform     key.Binding
+	ChangeKMSKey  key.Binding
+	Refresh       key.Binding
+	Profile       key.Binding
+	Region        key.Binding
+	NextPage      key.Binding
+	PrevPage      key.Binding
+	LoadAll       key.Binding
+	Filter        key.Binding
+	GridNextPage  key.Binding
+	GridPrevPage  key.Binding
+	ToggleLayout  key.Binding
+	CycleSort     key.Binding
+	Mark          key.Binding
+	ClearMarks    key.Binding
+	Export        key.Binding
+	CopyEnv       key.Binding
+	Report        key.Binding
+	Audit         key.Binding
+	Lint          key.Binding
+	Watch         key.Binding
+	FlagRotation  key.Binding
+	DetailPanel   key.Binding
+	WidenPanel    key.Binding
+	NarrowPanel   key.Binding
+	Operations    key.Binding
+	PurgeValues   key.Binding
+	Undo          key.Binding
+	Workspaces    key.Binding
+	Backends      key.Binding
+	Apps          key.Binding
+	Import        key.Binding
+	NewSecret     key.Binding
+	ImportFile    key.Binding
+	DryRun        key.Binding
+	Bulk          key.Binding
+	Help          key.Binding
+	KeyReference  key.Binding
+	Quit          key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -47,16 +98,44 @@ func DefaultKeyMap() KeyMap {
 		),
 		Select: key.NewBinding(
 			key.WithKeys("enter"),
-			key.WithHelp("enter", "select"),
+			key.WithHelp("enter", "open"),
 		),
 		Back: key.NewBinding(
 			key.WithKeys("esc", "q"),
 			key.WithHelp("esc/q", "back/quit"),
 		),
+		Count: key.NewBinding(
+			key.WithKeys("0", "1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("10j", "repeat a movement with a count"),
+		),
+		FirstSecret: key.NewBinding(
+			key.WithKeys("home"),
+			key.WithHelp("home/gg", "first secret"),
+		),
+		LastSecret: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "last secret (5G: the fifth)"),
+		),
+		JumpToLetter: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f<letter>", "next secret starting with the letter"),
+		),
 		ViewValue: key.NewBinding(
 			key.WithKeys("v"),
 			key.WithHelp("v", "view secret"),
 		),
+		SelectKey: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "select a JSON key"),
+		),
+		RevealKey: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "reveal the selected JSON key"),
+		),
+		CopyKey: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy the selected JSON key"),
+		),
 		CopyPlain: key.NewBinding(
 			key.WithKeys("c"),
 			key.WithHelp("c", "copy plain"),
@@ -69,6 +148,74 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("k"),
 			key.WithHelp("k", "copy field"),
 		),
+		SaveToFile: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "save to file"),
+		),
+		ExportSecret: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export as JSON/YAML or for Docker"),
+		),
+		BinaryFormat: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "base64/hex view"),
+		),
+		Reveal: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "reveal/hide value"),
+		),
+		Actions: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "actions menu"),
+		),
+		ConsoleLink: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "copy console link"),
+		),
+		OpenConsole: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "open in AWS console"),
+		),
+		CopyToRegion: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy to region"),
+		),
+		CopyToProfile: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "copy to profile"),
+		),
+		Compare: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "compare environments"),
+		),
+		CompareKube: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "compare with the cluster"),
+		),
+		Sync: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "sync to an AWS secret"),
+		),
+		References: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "find where it's used"),
+		),
+		Access: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "find who can access it"),
+		),
+		KubeManifest: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "copy as Kubernetes Secret"),
+		),
+		Terraform: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "copy Terraform import"),
+		),
+		ChangeKMSKey: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "change KMS key"),
+		),
 		Refresh: key.NewBinding(
 			key.WithKeys("r"),
 			key.WithHelp("r", "refresh"),
@@ -89,6 +236,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("b"),
 			key.WithHelp("b", "prev AWS page"),
 		),
+		LoadAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "load all pages"),
+		),
 		Filter: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "filter"),
@@ -101,9 +252,113 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("pgup"),
 			key.WithHelp("pgup", "prev screen"),
 		),
+		ToggleLayout: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "grid/list layout"),
+		),
+		CycleSort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "cycle sort"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark secret"),
+		),
+		ClearMarks: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "clear marks"),
+		),
+		Export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export marked"),
+		),
+		CopyEnv: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "copy marked as .env"),
+		),
+		Report: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "export report"),
+		),
+		Audit: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "access audit"),
+		),
+		Lint: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "compliance checks"),
+		),
+		Watch: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "watch for changes"),
+		),
+		FlagRotation: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "notify on rotation"),
+		),
+		DetailPanel: key.NewBinding(
+			key.WithKeys("|"),
+			key.WithHelp("|", "detail panel"),
+		),
+		WidenPanel: key.NewBinding(
+			key.WithKeys("<"),
+			key.WithHelp("<", "widen detail panel"),
+		),
+		NarrowPanel: key.NewBinding(
+			key.WithKeys(">"),
+			key.WithHelp(">", "narrow detail panel"),
+		),
+		Operations: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "operations this session"),
+		),
+		PurgeValues: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "clear cached values"),
+		),
+		Undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo"),
+		),
+		Workspaces: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "workspaces"),
+		),
+		Backends: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "switch backend"),
+		),
+		Apps: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "secrets by app"),
+		),
+		Import: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "import from password manager"),
+		),
+		NewSecret: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "new secret from a template"),
+		),
+		ImportFile: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "import a manifest file"),
+		),
+		DryRun: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "dry run on/off"),
+		),
+		Bulk: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "bulk tag/delete"),
+		),
 		Help: key.NewBinding(
 			key.WithKeys("?"),
-			key.WithHelp("?", "help"),
+			key.WithHelp("?", "more keys"),
+		),
+		KeyReference: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "every key binding"),
 		),
 		Quit: key.NewBinding(
 			key.WithKeys("ctrl+c"),
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// kmsChange is the KMS key the open secret is being moved to
+type kmsChange struct {
+	keys   []models.KMSKey // Offered in the picker
+	target models.KMSKey   // Picked, waiting for confirmation
+}
+
+// kmsKeysListedMsg reports the keys the open secret can be encrypted with
+type kmsKeysListedMsg struct {
+	keys []models.KMSKey
+	err  error
+}
+
+// kmsKeyChangedMsg reports the result of changeKMSKey
+type kmsKeyChangedMsg struct {
+	name string
+	key  models.KMSKey
+	err  error
+}
+
+// listKMSKeys lists the keys in the current region by alias
+func listKMSKeys(ctx context.Context, client SecretStore) tea.Cmd {
+	return func() tea.Msg {
+		keys, err := client.ListKMSKeys(ctx)
+		return kmsKeysListedMsg{keys: keys, err: err}
+	}
+}
+
+// changeKMSKey encrypts a secret with key
+func changeKMSKey(ctx context.Context, client SecretStore, name string, key models.KMSKey) tea.Cmd {
+	return func() tea.Msg {
+		err := client.ChangeKMSKey(ctx, name, key.Alias)
+		return kmsKeyChangedMsg{name: name, key: key, err: err}
+	}
+}
+
+// currentKMSKey returns the open secret's key as AWS reports it, preferring
+// the loaded details to the list entry
+func (m Model) currentKMSKey() string {
+	if m.secretDetails != nil {
+		return m.secretDetails.KmsKeyID
+	}
+	if secret := m.grid.SelectedSecret(); secret != nil {
+		return secret.KmsKeyID
+	}
+	return ""
+}
+
+// isKMSKey reports whether kmsKeyID, as AWS reports a secret's key, is key
+func isKMSKey(kmsKeyID string, key models.KMSKey) bool {
+	if models.UsesDefaultKey(kmsKeyID) {
+		return models.UsesDefaultKey(key.Alias)
+	}
+	return strings.HasSuffix(kmsKeyID, key.Alias) || (key.KeyID != "" && strings.HasSuffix(kmsKeyID, key.KeyID))
+}
+
+// startKMSKeyChange lists the KMS keys to pick a new one for the open secret
+func (m Model) startKMSKeyChange() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		return m, nil
+	}
+	owner := secret.OwningService
+	if m.secretDetails != nil && m.secretDetails.OwningService != "" {
+		owner = m.secretDetails.OwningService
+	}
+	if owner != "" {
+		service := models.OwningServiceName(owner)
+		m.errorMessage = fmt.Sprintf("%s is managed by %s, so its KMS key can only be changed through %s", secret.Name, service, service)
+		return m, nil
+	}
+	return m, listKMSKeys(m.startLoad("Listing KMS keys…"), m.awsClient)
+}
+
+// pickKMSKey offers the listed keys, with the secret's current one selected
+func (m Model) pickKMSKey(msg kmsKeysListedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showError("Failed to list KMS keys", msg.err)
+		return m, nil
+	}
+
+	current := m.currentKMSKey()
+	names := make([]string, len(msg.keys))
+	selected := ""
+	for i, key := range msg.keys {
+		names[i] = key.Alias
+		if isKMSKey(current, key) {
+			selected = key.Alias
+		}
+	}
+	m.kmsChange = kmsChange{keys: msg.keys}
+	m.openScreen(ScreenKMSKeyPicker, newPickerScreen("KMS key for "+m.grid.SelectedSecret().Name, names, selected), ScreenSecretDetail)
+	return m, nil
+}
+
+// chooseKMSKey asks before encrypting the open secret with the picked key
+func (m Model) chooseKMSKey(alias string) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		return m, nil
+	}
+	var target models.KMSKey
+	for _, key := range m.kmsChange.keys {
+		if key.Alias == alias {
+			target = key
+		}
+	}
+	if target.Alias == "" {
+		return m, nil
+	}
+
+	current := m.currentKMSKey()
+	if isKMSKey(current, target) {
+		m.kmsChange = kmsChange{}
+		m.statusMessage = secret.Name + " is already encrypted with " + alias
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.kmsChange.target = target
+	intro := fmt.Sprintf("Encrypt this secret with %s instead of %s. Everyone who reads it will need kms:Decrypt on the new key:",
+		alias, models.KMSKeyLabel(current))
+	items := []components.SummaryItem{{Name: secret.Name}}
+	m.openScreen(ScreenKMSKeyConfirm, newConfirmScreen("Change KMS key?", intro, items), ScreenSecretDetail)
+	return m, nil
+}
+
+// confirmKMSKey changes the open secret's key to the one picked
+func (m Model) confirmKMSKey() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil || m.kmsChange.target.Alias == "" {
+		return m, nil
+	}
+	ctx := m.startLoad("Changing the KMS key of " + secret.Name + "…")
+	return m, changeKMSKey(ctx, m.awsClient, secret.Name, m.kmsChange.target)
+}
+
+// showKMSKeyChanged reports the new key and reloads the secret's details
+// to show it
+func (m Model) showKMSKeyChanged(msg kmsKeyChangedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	m.kmsChange = kmsChange{}
+	if msg.err != nil {
+		m.showError("Failed to change the KMS key", msg.err)
+		m.logOperation("Failed to change the KMS key of "+msg.name, msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.statusMessage = fmt.Sprintf("%s is now encrypted with %s", msg.name, msg.key.Alias)
+	m.logOperation(m.statusMessage, nil)
+	return m, tea.Batch(loadSecretDetails(m.awsClient, msg.name, m.requestTimeout), clearStatusAfter(4*time.Second))
+}
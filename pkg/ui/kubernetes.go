@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// startKubeManifest asks for the name, and optionally namespace, of the
+// Kubernetes Secret the loaded value is copied as
+func (m Model) startKubeManifest() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil || !m.secretLoaded() {
+		return m, nil
+	}
+	prompt := newTextScreen("Copy "+secret.Name+" as a Kubernetes Secret", "Secret name, or namespace/name:", "payments/app-db")
+	prompt.input.SetValue(secretvalue.KubernetesName(secret.Name))
+	m.openScreen(ScreenKubeManifest, prompt, ScreenSecretDetail)
+	return m, nil
+}
+
+// copyKubeManifest copies the loaded value to the clipboard as a Secret
+// manifest named by target, a name or namespace/name
+func (m Model) copyKubeManifest(target string) (tea.Model, tea.Cmd) {
+	name, namespace, err := secretvalue.ParseKubernetesTarget(target)
+	if err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	data, err := secretvalue.KubernetesData(m.secretValue, m.secretBinary)
+	if err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	manifest, err := secretvalue.KubernetesManifest(name, namespace, data)
+	if err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	m.closeScreen()
+	return m, copyToClipboard(string(manifest), false)
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lintLoadedMsg reports the result of loadLint
+type lintLoadedMsg struct {
+	secrets  []models.Secret
+	policies map[string]string // Nil when the rules don't check policies
+	err      error
+}
+
+// loadLint lists every secret for the compliance checks, and their
+// resource policies when the rules check them
+func loadLint(ctx context.Context, inv *inventory.Service, client SecretStore, rules inventory.LintRules) tea.Cmd {
+	return func() tea.Msg {
+		secrets, err := inv.Find(ctx, "")
+		if err != nil || !rules.NeedsPolicies() {
+			return lintLoadedMsg{secrets: secrets, err: err}
+		}
+		names := make([]string, len(secrets))
+		for i, secret := range secrets {
+			names[i] = secret.Name
+		}
+		policies, err := client.GetResourcePolicies(ctx, names)
+		return lintLoadedMsg{secrets: secrets, policies: policies, err: err}
+	}
+}
+
+// startLint lists every secret in the account to run the compliance checks
+func (m Model) startLint() (tea.Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	return m, loadLint(m.startLoad("Checking every secret…"), m.inventory, m.awsClient, m.lintRules)
+}
+
+// showLint runs the checks over the listed secrets and opens the report
+func (m Model) showLint(msg lintLoadedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		if updated, cmd, ok := m.reauthenticate(msg.err, retryLint); ok {
+			return updated, cmd
+		}
+		m.showError("Failed to check secrets", msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+
+	report := inventory.Lint(inventory.LintInput{
+		Secrets:   msg.secrets,
+		Policies:  msg.policies,
+		StaleDays: m.staleDays,
+		Now:       time.Now(),
+	}, m.lintRules)
+	title := fmt.Sprintf("Compliance checks for %s (%s)", m.currentProfile, m.currentRegion)
+	m.openScreen(ScreenLint, newLintScreen(components.NewLintView(title, report)), ScreenSecretList)
+	return m, nil
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cachedSecretsMsg carries the secret list cached by an earlier run, or nil
+// when there is none
+type cachedSecretsMsg struct {
+	profile string
+	region  string
+	list    *config.CachedSecretList
+}
+
+// usesListCache reports whether the secret list for profile is cached.
+// Other backends, demo data included, and custom endpoints such as
+// LocalStack are never cached, so they can't be mistaken for the account's
+// real secrets.
+func (m Model) usesListCache(profile string) bool {
+	return m.awsBackend() && m.listCacheTTL > 0 && aws.EndpointURL(profile, m.endpointURL) == ""
+}
+
+// loadCachedSecrets reads the cached secret list for profile and region
+func loadCachedSecrets(profile, region string, ttl time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		list, _ := config.GetCachedSecretList(profile, region, ttl)
+		return cachedSecretsMsg{profile: profile, region: region, list: list}
+	}
+}
+
+// saveCachedSecrets caches a loaded secret list for the next run
+func saveCachedSecrets(profile, region string, secrets []models.Secret, ttl time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		if err := config.SaveCachedSecretList(profile, region, secrets, ttl); err != nil {
+			logging.Debugf("failed to cache secret list: %v", err)
+		}
+		return nil
+	}
+}
+
+// showCachedSecrets shows a cached secret list until the first page loads
+// from AWS. It is dropped when AWS answered first or the profile or region
+// changed while it was read.
+func (m Model) showCachedSecrets(msg cachedSecretsMsg) Model {
+	if msg.list == nil || msg.profile != m.currentProfile || msg.region != m.currentRegion {
+		return m
+	}
+	if m.inventory != nil {
+		if _, loaded := m.inventory.Cached(0); loaded {
+			return m
+		}
+	}
+	m.cachedAt = msg.list.SavedAt
+	m.showSecrets(msg.list.Secrets)
+	return m
+}
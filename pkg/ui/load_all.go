@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// allSecretsLoadedMsg reports loading every page of the secret list
+type allSecretsLoadedMsg struct {
+	inventory *inventory.Service
+	secrets   []models.Secret
+	err       error
+}
+
+// loadAllSecrets fetches the pages of the secret list not loaded yet. Each
+// page is reported with a loadProgressMsg, followed by allSecretsLoadedMsg
+// with every secret.
+func loadAllSecrets(ctx context.Context, inv *inventory.Service) tea.Cmd {
+	return func() tea.Msg {
+		events := make(chan tea.Msg)
+		go func() {
+			secrets, err := inv.LoadAll(ctx, func(pages, secrets int) {
+				text := fmt.Sprintf("Loading every secret: %d pages, %d secrets so far…", pages, secrets)
+				events <- loadProgressMsg{text: text, next: waitForEvents(events)}
+			})
+			events <- allSecretsLoadedMsg{inventory: inv, secrets: secrets, err: err}
+		}()
+		return <-events
+	}
+}
+
+// loadAll starts loading every page, so paging, sorting and filtering the
+// grid cover the whole account and region rather than one AWS page
+func (m Model) loadAll() (tea.Model, tea.Cmd) {
+	if m.inventory == nil || m.allPages {
+		return m, nil
+	}
+	if m.inventory.AllLoaded() {
+		return m.showAllSecrets(m.inventory.Loaded())
+	}
+	return m, loadAllSecrets(m.startLoad("Loading every secret…"), m.inventory)
+}
+
+// retryLoadAll loads every page again after signing in
+func retryLoadAll(m Model) (Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	return m, loadAllSecrets(m.startLoad("Loading every secret…"), m.inventory)
+}
+
+// showAllLoaded shows every secret once the pages have loaded. The pages
+// loaded before an error stay cached, so loading again carries on from the
+// one that failed.
+func (m Model) showAllLoaded(msg allSecretsLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.inventory != m.inventory || cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		if updated, cmd, ok := m.reauthenticate(msg.err, retryLoadAll); ok {
+			return updated, cmd
+		}
+		m.showError("Failed to load every secret", msg.err)
+		return m, nil
+	}
+	return m.showAllSecrets(msg.secrets)
+}
+
+// showAllSecrets puts every secret in the grid as one list, until a page
+// is loaded on its own again, e.g. by a refresh
+func (m Model) showAllSecrets(secrets []models.Secret) (tea.Model, tea.Cmd) {
+	m.allPages = true
+	m.currentPage = 0
+	m.showSecrets(secrets)
+	m.cachedAt = time.Time{}
+	m.errorMessage = ""
+	m.statusMessage = fmt.Sprintf("Loaded all %d secrets", len(secrets))
+	cmds := []tea.Cmd{clearStatusAfter(3 * time.Second)}
+	if m.usesListCache(m.currentProfile) {
+		cmds = append(cmds, saveCachedSecrets(m.currentProfile, m.currentRegion, secrets, m.listCacheTTL))
+	}
+	return m, tea.Batch(cmds...)
+}
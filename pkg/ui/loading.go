@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadProgressMsg reports how far a running load has got. next waits for
+// the load's next message.
+type loadProgressMsg struct {
+	text string
+	next tea.Cmd
+}
+
+// newLoadingSpinner creates the spinner shown while loading
+func newLoadingSpinner() spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.MiniDot))
+}
+
+// keepSpinning starts the spinner's ticks when a load has started. The ticks
+// stop by themselves once nothing is loading.
+func (m Model) keepSpinning(cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if !m.loading || m.spinning {
+		return m, cmd
+	}
+	m.spinning = true
+	return m, tea.Batch(cmd, m.spinner.Tick)
+}
+
+// updateSpinner advances the spinner, or lets it stop when nothing is loading
+func (m Model) updateSpinner(msg spinner.TickMsg) (tea.Model, tea.Cmd) {
+	if !m.loading {
+		m.spinning = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	return m, cmd
+}
+
+// loadingView renders the spinner with what is being loaded
+func (m Model) loadingView() string {
+	text := m.loadingText
+	if text == "" {
+		text = "Loading…"
+	}
+	if m.loadCancel != nil {
+		text += " (x/esc: cancel)"
+	}
+	return SpinnerStyle.Render(m.spinner.View()) + " " + text
+}
+
+// waitForEvents waits for the next message from a load reporting progress
+// over events
+func waitForEvents(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-events
+	}
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/auth"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lockCheckMsg checks whether the UI has been idle for long enough to lock
+type lockCheckMsg struct{}
+
+// parseLockAfter reads the lock_after config setting. The lock is off unless
+// a positive duration is set.
+func parseLockAfter(value string) time.Duration {
+	lockAfter, err := time.ParseDuration(value)
+	if err != nil || lockAfter < 0 {
+		return 0
+	}
+	return lockAfter
+}
+
+// scheduleLockCheck checks for inactivity again after delay. A single check
+// is pending at a time; each one reschedules itself for when the UI could
+// next become idle.
+func scheduleLockCheck(delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return lockCheckMsg{}
+	})
+}
+
+// checkLock locks the UI once it has been idle for lockAfter
+func (m Model) checkLock() (Model, tea.Cmd) {
+	if m.lockAfter <= 0 || m.currentScreen == ScreenLocked {
+		// Unlocking schedules the next check
+		return m, nil
+	}
+
+	idle := time.Since(m.lastActivity)
+	if idle < m.lockAfter {
+		return m, scheduleLockCheck(m.lockAfter - idle)
+	}
+	return m.lock(), nil
+}
+
+// lock clears everything sensitive from the model and shows the lock screen.
+// With lock_requires_mfa the MFA session is dropped too, so unlocking asks
+// for a new code.
+func (m Model) lock() Model {
+	m.clearSecretValueState()
+	m.clearSecretDetails()
+	m.values.purge()
+	m.showHelp = false
+	m.statusMessage = ""
+	m.errorMessage = ""
+	m.copyTo = copyDestination{}
+	m.compare = compareState{}
+	m.syncTo = syncState{}
+	m.reauth.retries = nil
+
+	if m.lockRequiresMFA && m.awsBackend() && auth.ForgetMFASession(m.currentProfile) {
+		m.awsClient = nil
+	}
+
+	// Any open prompt or picker is discarded along with what it showed
+	m.openScreen(ScreenLocked, lockScreen{lockAfter: m.lockAfter, needsMFA: m.awsClient == nil}, ScreenSecretList)
+	return m
+}
+
+// unlock leaves the lock screen, reconnecting when the MFA session was dropped
+func (m Model) unlock() (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	cmds := []tea.Cmd{scheduleLockCheck(m.lockAfter)}
+	if m.awsClient == nil {
+		// Reconnecting prompts for a new MFA code
+		cmds = append(cmds, m.initAWSClient(m.currentProfile, m.currentRegion))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// lockScreen is shown while the UI is locked
+type lockScreen struct {
+	lockAfter     time.Duration
+	needsMFA      bool
+	width, height int
+}
+
+func (s lockScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "enter" {
+		return s, emit(unlockRequestedMsg{})
+	}
+	return s, nil
+}
+
+func (s lockScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	return s
+}
+
+func (s lockScreen) View() string {
+	t := theme.Current()
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary).
+		MarginBottom(1)
+
+	textStyle := lipgloss.NewStyle().
+		Foreground(t.Text)
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary).
+		Padding(1, 2).
+		Width(60)
+
+	hint := "Press Enter to unlock"
+	if s.needsMFA {
+		hint = "Press Enter to unlock (you'll be asked for your MFA code)"
+	}
+
+	content := titleStyle.Render("Locked") + "\n\n" +
+		textStyle.Render(fmt.Sprintf("Secret Src locked after %s without input. Loaded secret values were cleared.", s.lockAfter)) + "\n\n" +
+		hintStyle.Render(hint)
+
+	return placeCentered(s.width, s.height, boxStyle.Render(content))
+}
@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// manifestImport is the manifest being imported and what importing it
+// changes, secret by secret
+type manifestImport struct {
+	path    string
+	changes []secretvalue.ImportChange
+}
+
+// writes returns how many secrets the import creates or updates
+func (i manifestImport) writes() int {
+	count := 0
+	for _, change := range i.changes {
+		if !change.Unchanged() {
+			count++
+		}
+	}
+	return count
+}
+
+// manifestPlannedMsg reports what importing a manifest would change. read
+// are the secrets whose values were read to compare them.
+type manifestPlannedMsg struct {
+	path    string
+	changes []secretvalue.ImportChange
+	read    []string
+	err     error
+}
+
+// manifestImportedMsg reports the secrets written from a manifest, with an
+// error for each one that failed
+type manifestImportedMsg struct {
+	path    string
+	changes []secretvalue.ImportChange
+	errs    []error
+}
+
+// planManifestImport reads the manifest and compares each secret in it with
+// its current value
+func planManifestImport(ctx context.Context, client *aws.Client, path string, schemas *secretvalue.Schemas) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := secretvalue.ReadManifest(path)
+		if err != nil {
+			return manifestPlannedMsg{path: path, err: err}
+		}
+		var invalid []error
+		for _, entry := range entries {
+			invalid = append(invalid, schemas.Validate(entry.Name, entry.Value))
+		}
+		if err := errors.Join(invalid...); err != nil {
+			return manifestPlannedMsg{path: path, err: err}
+		}
+		changes, read, err := secretvalue.PlanManifest(ctx, client, entries)
+		return manifestPlannedMsg{path: path, changes: changes, read: read, err: err}
+	}
+}
+
+// importManifest writes each secret the import changes, carrying on past
+// the ones that fail
+func importManifest(ctx context.Context, client *aws.Client, plan manifestImport) tea.Cmd {
+	return func() tea.Msg {
+		msg := manifestImportedMsg{path: plan.path}
+		for _, change := range plan.changes {
+			if change.Unchanged() {
+				continue
+			}
+			entry := change.Entry
+			secretCopy := &aws.SecretCopy{Name: entry.Name, Description: entry.Description, Tags: entry.Tags, Value: entry.Value}
+			_, err := client.WriteSecretCopy(ctx, secretCopy, !change.Create)
+			msg.changes = append(msg.changes, change)
+			msg.errs = append(msg.errs, err)
+		}
+		return msg
+	}
+}
+
+// startManifestImport asks for the manifest to import
+func (m Model) startManifestImport() (tea.Model, tea.Cmd) {
+	if _, ok := m.awsClient.(*aws.Client); !ok {
+		m.errorMessage = "Importing a manifest needs an AWS account to import it into"
+		return m, nil
+	}
+	m.manifest = manifestImport{}
+	m.openScreen(ScreenManifestPath, newPathScreen("Import secrets from a YAML or JSON manifest", "secrets.yaml"), ScreenSecretList)
+	return m, nil
+}
+
+// planManifest compares the manifest with the secrets once it is chosen
+func (m Model) planManifest(path string) (tea.Model, tea.Cmd) {
+	client, ok := m.awsClient.(*aws.Client)
+	if !ok {
+		return m, nil
+	}
+	if strings.TrimSpace(path) == "" {
+		return m.updateScreen(promptErrorMsg{err: errors.New("enter the path of the manifest")})
+	}
+	m.closeScreen()
+	ctx := m.startLoad("Comparing " + path + " with the secrets in " + m.currentRegion + "…")
+	return m, planManifestImport(ctx, client, path, m.schemas)
+}
+
+// confirmManifest lists what importing the manifest changes, key by key,
+// and asks before writing
+func (m Model) confirmManifest(msg manifestPlannedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if len(msg.read) > 0 {
+		m.recordAudit("Read the values of "+strings.Join(msg.read, ", ")+" to compare them with "+msg.path, msg.err)
+	}
+	if msg.err != nil {
+		m.showError("Failed to import "+msg.path, msg.err)
+		return m, nil
+	}
+	m.manifest = manifestImport{path: msg.path, changes: msg.changes}
+	writes := m.manifest.writes()
+	if writes == 0 {
+		m.statusMessage = fmt.Sprintf("Nothing to import: the %d secrets in %s already have their values", len(msg.changes), msg.path)
+		return m, clearStatusAfter(4 * time.Second)
+	}
+
+	if err := secretvalue.CheckManaged(msg.changes); err != nil {
+		m.manifest = manifestImport{}
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	items := make([]components.SummaryItem, len(msg.changes))
+	created := 0
+	var overwritten []string
+	for i, change := range msg.changes {
+		items[i] = components.SummaryItem{Name: change.Entry.Name, Status: components.ItemPending, Detail: change.Summary()}
+		if change.Unchanged() {
+			items[i].Status = components.ItemSucceeded
+		}
+		switch {
+		case change.Create:
+			created++
+		case !change.Unchanged():
+			overwritten = append(overwritten, change.Entry.Name)
+		}
+	}
+	intro := fmt.Sprintf("Import %s into %s in %s: %d to create, %d to update, %d unchanged. Keys are marked + added, ~ changed and - removed; values aren't shown.",
+		msg.path, m.currentProfile, m.currentRegion, created, writes-created, len(msg.changes)-writes)
+	title := fmt.Sprintf("Import %d secrets", writes)
+	switch len(overwritten) {
+	case 0:
+		m.openScreen(ScreenManifestConfirm, newConfirmScreen(title, intro, items), ScreenSecretList)
+	case 1:
+		m.openDestructiveConfirm(ScreenManifestConfirm, title, intro, items, overwritten[0], ScreenSecretList)
+	default:
+		m.openDestructiveConfirm(ScreenManifestConfirm, title, intro, items, fmt.Sprintf("overwrite %d secrets", len(overwritten)), ScreenSecretList)
+	}
+	return m, nil
+}
+
+// writeManifest imports the manifest once confirmed
+func (m Model) writeManifest() (tea.Model, tea.Cmd) {
+	client, ok := m.awsClient.(*aws.Client)
+	if !ok || m.manifest.path == "" {
+		return m, nil
+	}
+	ctx := m.startLoad(fmt.Sprintf("Importing %d secrets from %s…", m.manifest.writes(), m.manifest.path))
+	return m, importManifest(ctx, client, m.manifest)
+}
+
+// showManifestImported reports each secret written, and reloads the list
+// when the results are closed
+func (m Model) showManifestImported(msg manifestImportedMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	m.manifest = manifestImport{}
+	items := make([]components.SummaryItem, len(msg.changes))
+	failed := 0
+	overwritten := undoEntry{kind: undoOverwrite}
+	if client, ok := m.awsClient.(*aws.Client); ok {
+		overwritten.dest = copyDestination{client: client, label: m.currentRegion}
+	}
+	for i, change := range msg.changes {
+		action, detail := "Updated ", "updated"
+		if change.Create {
+			action, detail = "Created ", "created"
+		}
+		items[i] = components.SummaryItem{Name: change.Entry.Name, Status: components.ItemSucceeded, Detail: detail}
+		switch err := msg.errs[i]; {
+		case cancelled(err):
+			items[i].Status, items[i].Detail = components.ItemPending, "skipped, cancelled"
+			failed++
+			continue
+		case err != nil:
+			items[i].Status, items[i].Detail = components.ItemFailed, m.describeError("failed", err)
+			failed++
+		case !change.Create:
+			overwritten.names = append(overwritten.names, change.Entry.Name)
+		}
+		m.recordAudit(action+change.Entry.Name+" from "+msg.path, msg.errs[i])
+	}
+	intro := fmt.Sprintf("All %d secrets were written.", len(items))
+	if failed > 0 {
+		intro = fmt.Sprintf("%d were written, %d were not.", len(items)-failed, failed)
+	}
+	if overwritten.dest.client != nil {
+		m.pushUndo(overwritten)
+	}
+	m.logOperation(fmt.Sprintf("Imported %s: %d written, %d not", msg.path, len(items)-failed, failed), nil)
+	m.openScreen(ScreenBulkResults, newResultsScreen("Imported "+msg.path, intro, items), ScreenSecretList)
+	m.bulkChanged = failed < len(items)
+	return m, nil
+}
@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// doubleClickTime is how soon a second click on the same secret opens it
+const doubleClickTime = 400 * time.Millisecond
+
+// click is the last secret clicked on the list, to spot double clicks
+type click struct {
+	index int
+	at    time.Time
+}
+
+// handleMouse selects and opens secrets on the list, pages it with the
+// wheel, and runs the footer hint that was clicked
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if msg.Action != tea.MouseActionPress || m.currentScreen == ScreenLocked {
+		return m, nil
+	}
+	m.lastActivity = time.Now()
+	onList := m.screen == nil && m.currentScreen == ScreenSecretList && !m.grid.IsFiltering()
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if onList {
+			m.grid.Update(tea.KeyMsg{Type: tea.KeyPgUp})
+		}
+		return m, nil
+	case tea.MouseButtonWheelDown:
+		if onList {
+			m.grid.Update(tea.KeyMsg{Type: tea.KeyPgDown})
+		}
+		return m, nil
+	case tea.MouseButtonLeft:
+	default:
+		return m, nil
+	}
+
+	if key := m.hintAt(msg.X, msg.Y); key != "" {
+		return m.Update(keyMsgFor(key))
+	}
+	if !onList {
+		return m, nil
+	}
+
+	x, y := m.listOrigin()
+	index, ok := m.grid.SecretAt(msg.X-x, msg.Y-y)
+	if !ok {
+		return m, nil
+	}
+	now := time.Now()
+	if m.lastClick.index == index && now.Sub(m.lastClick.at) < doubleClickTime {
+		m.lastClick = click{}
+		return m.Update(keyMsgFor("enter"))
+	}
+	m.jump = jumpState{}
+	m.grid.Select(index)
+	m.lastClick = click{index: index, at: now}
+	return m, nil
+}
+
+// listOrigin is where the secret grid is drawn on the terminal: inside the
+// border and padding, below the header and any prompt above the grid
+func (m Model) listOrigin() (int, int) {
+	x := appBorderWidth/2 + appHorizontalPadding/2
+	y := appBorderWidth/2 + lipgloss.Height(m.viewHeader())
+	if m.grid.IsFiltering() || m.jump.prompt() != "" {
+		// The prompt line and the margin below it
+		y += 2
+	}
+	return x, y
+}
+
+// hintAt returns the key of the footer hint drawn at x, y, or "" when
+// there is no hint there or it isn't a single key
+func (m Model) hintAt(x, y int) string {
+	width, _ := m.innerSize()
+	col := x - appBorderWidth/2 - appHorizontalPadding/2
+	if keys, ok := m.footerKeys(); ok {
+		// Only the line of hints can be clicked, not the expanded columns
+		if m.fullHelp || y != m.height-appBorderWidth/2-1 {
+			return ""
+		}
+		return m.shortHintAt(keys.short, col, width)
+	}
+
+	help := m.footerHelp()
+	if help == "" {
+		return ""
+	}
+	lines := wrapHints(help, width)
+
+	// The hints are the last lines above the bottom border
+	line := y - (m.height - appBorderWidth/2 - len(lines))
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+	start := 0
+	for _, hint := range strings.Split(lines[line], hintSeparator) {
+		end := start + lipgloss.Width(hint)
+		if col >= start && col < end {
+			return hintKey(hint)
+		}
+		start = end + len(hintSeparator)
+	}
+	return ""
+}
+
+// shortHintAt returns the key of the hint at col in the line drawn by
+// viewFooterKeys, following the layout of help.Model.ShortHelpView
+func (m Model) shortHintAt(bindings []key.Binding, col, width int) string {
+	more := m.moreKeys()
+	separator := help.New().ShortSeparator
+	itemWidth := func(binding key.Binding) int {
+		return lipgloss.Width(binding.Help().Key) + 1 + lipgloss.Width(binding.Help().Desc)
+	}
+	tail := itemWidth(more)
+	lineWidth := max(width-tail-lipgloss.Width(separator), 0)
+
+	total := 0
+	for _, binding := range bindings {
+		start := total
+		if total > 0 {
+			start += lipgloss.Width(separator)
+		}
+		end := start + itemWidth(binding)
+		if lineWidth > 0 && end > lineWidth {
+			break
+		}
+		if col >= start && col < end {
+			return bindingKey(binding)
+		}
+		total = end
+	}
+
+	line := newFooterHelp(lineWidth).ShortHelpView(bindings)
+	start := lipgloss.Width(line + separator)
+	if col >= start && col < start+tail {
+		return bindingKey(more)
+	}
+	return ""
+}
+
+// bindingKey returns the key a hint for binding stands for, or "" for
+// movement, which has no single key
+func bindingKey(binding key.Binding) string {
+	keys := binding.Keys()
+	if len(keys) == 0 {
+		return ""
+	}
+	switch keys[0] {
+	case "up", "down", "left", "right":
+		return ""
+	}
+	return keys[0]
+}
+
+// hintKey returns the key a footer hint such as "r: refresh" or
+// "n/esc: cancel" stands for, taking the first of alternatives. Hints for
+// movement or typing have no single key, and return "".
+func hintKey(hint string) string {
+	keys, _, ok := strings.Cut(hint, ": ")
+	if !ok {
+		return ""
+	}
+	key, _, _ := strings.Cut(keys, "/")
+	switch {
+	case key == "hjkl", key == "↑", strings.Contains(key, " "):
+		return ""
+	}
+	return key
+}
@@ -0,0 +1,81 @@
+package ui
+
+import "github.com/charmbracelet/lipgloss"
+
+const (
+	// narrowWidth is the terminal width below which the bordered layouts
+	// don't fit, and the compact ones are used instead
+	narrowWidth = 80
+
+	// wideDetailWidth is the detail box's width on a terminal that isn't
+	// narrow, inside its border
+	wideDetailWidth = 76
+
+	// wideDetailValueWidth is how much of a metadata value the detail box
+	// shows beside its label
+	wideDetailValueWidth = 60
+)
+
+// narrow reports whether the terminal is too narrow for the full layouts:
+// the list then shows names alone, the detail screen stacks its labels
+// above their values, and the footer abbreviates its hints
+func (m Model) narrow() bool {
+	return m.width > 0 && m.width < narrowWidth
+}
+
+// detailWidth is the detail box's width inside its border, which fills
+// a narrow terminal
+func (m Model) detailWidth() int {
+	if !m.narrow() {
+		return wideDetailWidth
+	}
+	width, _ := m.innerSize()
+	// The box's own border
+	return max(width-2, 20)
+}
+
+// detailTextWidth is the width of the text inside the detail box's padding
+func (m Model) detailTextWidth() int {
+	return m.detailWidth() - 4
+}
+
+// detailValueWidth is how many characters of a metadata value fit: beside
+// its label, or on its own indented line when narrow
+func (m Model) detailValueWidth() int {
+	if m.narrow() {
+		return m.detailTextWidth() - 2
+	}
+	return wideDetailValueWidth
+}
+
+// detailLine renders a metadata label and value, with the value under the
+// label on a narrow terminal
+func (m Model) detailLine(label, value string, keyStyle, valueStyle lipgloss.Style) string {
+	if m.narrow() {
+		return keyStyle.Render(label+":") + "\n  " + valueStyle.Render(value) + "\n"
+	}
+	return keyStyle.Render(label+": ") + valueStyle.Render(value) + "\n"
+}
+
+// cutEnd shortens text to width with an ellipsis at the end
+func cutEnd(text string, width int) string {
+	if len(text) <= width || width <= 3 {
+		return text
+	}
+	return text[:width-3] + "..."
+}
+
+// cutStart shortens text to width with an ellipsis at the start, for ARNs
+// and key IDs whose end tells them apart
+func cutStart(text string, width int) string {
+	if len(text) <= width || width <= 3 {
+		return text
+	}
+	return "..." + text[len(text)-width+3:]
+}
+
+// valueBoxWidth is the width of the box around a secret's value, inside
+// its border
+func (m Model) valueBoxWidth() int {
+	return m.detailTextWidth() - 6
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// opsPaneRows is how many of the latest operations the pane shows
+const opsPaneRows = 5
+
+// operation is a copy, write, deletion or export finished this session
+type operation struct {
+	at     time.Time
+	text   string
+	failed bool
+}
+
+// opsLog is the operations finished this session, oldest first, shown in
+// a pane above the footer while it is open
+type opsLog struct {
+	entries []operation
+	open    bool
+}
+
+// logOperation records a finished operation, or its failure when err is set,
+// in the pane and the audit log. The pane grows as it fills, so the grid is resized while it is open.
+func (m *Model) logOperation(text string, err error) {
+	m.recordAudit(text, err)
+	if err == nil && m.dryRunPending() {
+		// The writes the dry run skipped are listed in its place
+		return
+	}
+	entry := operation{at: time.Now(), text: text}
+	if err != nil {
+		entry.text = fmt.Sprintf("%s: %v", text, err)
+		entry.failed = true
+	}
+	m.ops.entries = append(m.ops.entries, entry)
+	if m.ops.open {
+		m.resizeGrid()
+	}
+}
+
+// selectedName is the name of the secret an operation on the detail screen
+// is about, or "" when none is selected
+func (m Model) selectedName() string {
+	if secret := m.grid.SelectedSecret(); secret != nil {
+		return secret.Name
+	}
+	return ""
+}
+
+// toggleOperations opens or collapses the operations pane
+func (m *Model) toggleOperations() {
+	m.ops.open = !m.ops.open
+	m.resizeGrid()
+}
+
+// viewOperations renders the latest operations, newest last, or "" while
+// the pane is collapsed
+func (m Model) viewOperations() string {
+	if !m.ops.open {
+		return ""
+	}
+	width, _ := m.innerSize()
+	title := fmt.Sprintf("Operations (%d this session)", len(m.ops.entries))
+
+	lines := []string{HelpStyle.Bold(true).Render(title)}
+	if len(m.ops.entries) == 0 {
+		lines = append(lines, HelpStyle.Render("  Nothing yet: copies, writes, deletions and exports are listed here"))
+	}
+	start := max(len(m.ops.entries)-opsPaneRows, 0)
+	for _, entry := range m.ops.entries[start:] {
+		style := SuccessStyle
+		if entry.failed {
+			style = ErrorStyle
+		}
+		if width > 0 {
+			// Long paths and errors are cut rather than wrapped
+			style = style.MaxWidth(width)
+		}
+		lines = append(lines, style.Render(entry.at.Format("15:04:05")+"  "+entry.text))
+	}
+	return lipgloss.NewStyle().MarginBottom(1).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/passwords"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// passwordImport is a password manager item being imported as a new secret:
+// the items listed to pick from, then the one picked and its fields
+type passwordImport struct {
+	items  []passwords.Item
+	item   passwords.Item
+	fields map[string]string
+}
+
+// passwordItemsMsg reports the items listed in every configured password
+// manager. failed says which managers couldn't be listed.
+type passwordItemsMsg struct {
+	items  []passwords.Item
+	failed []error
+}
+
+// passwordFieldsMsg reports the fields of the item picked
+type passwordFieldsMsg struct {
+	item   passwords.Item
+	fields map[string]string
+	err    error
+}
+
+// passwordImportedMsg reports creating a secret from an item
+type passwordImportedMsg struct {
+	item passwords.Item
+	name string
+	err  error
+}
+
+// listPasswordItems lists the items of each password manager. One that
+// fails, e.g. locked, doesn't hide the others' items.
+func listPasswordItems(ctx context.Context, managers []passwords.Manager) tea.Cmd {
+	return func() tea.Msg {
+		var msg passwordItemsMsg
+		for _, manager := range managers {
+			items, err := manager.List(ctx)
+			if err != nil {
+				msg.failed = append(msg.failed, fmt.Errorf("%s: %w", manager.Name(), err))
+				continue
+			}
+			msg.items = append(msg.items, items...)
+		}
+		return msg
+	}
+}
+
+// loadPasswordFields reads the fields of item
+func loadPasswordFields(ctx context.Context, managers []passwords.Manager, item passwords.Item) tea.Cmd {
+	return func() tea.Msg {
+		for _, manager := range managers {
+			if manager.Name() == item.Manager {
+				fields, err := manager.Fields(ctx, item.ID)
+				return passwordFieldsMsg{item: item, fields: fields, err: err}
+			}
+		}
+		return passwordFieldsMsg{item: item, err: fmt.Errorf("%s isn't configured", item.Manager)}
+	}
+}
+
+// createImportedSecret creates secret name holding an item's value. An
+// existing secret is never replaced.
+func createImportedSecret(ctx context.Context, client *aws.Client, item passwords.Item, name string, value models.SecretValue) tea.Cmd {
+	return func() tea.Msg {
+		secretCopy := &aws.SecretCopy{Name: name, Description: "Imported from " + item.Manager, Value: value}
+		_, err := client.WriteSecretCopy(ctx, secretCopy, false)
+		return passwordImportedMsg{item: item, name: name, err: err}
+	}
+}
+
+// importNameInvalid matches what secret names can't hold
+var importNameInvalid = regexp.MustCompile(`[^a-z0-9/_+=.@-]+`)
+
+// importName suggests a secret name for an item, after its title and under
+// the prefix filtered on, e.g. "prod/stripe-api" for "Stripe API"
+func importName(title, filter string) string {
+	name := strings.Trim(importNameInvalid.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if strings.HasSuffix(filter, "/") {
+		name = filter + name
+	}
+	return name
+}
+
+// startPasswordImport lists the items of the configured password managers
+// to pick one to import
+func (m Model) startPasswordImport() (tea.Model, tea.Cmd) {
+	if len(m.passwordManagers) == 0 {
+		m.statusMessage = "Set password_managers to 1password or bitwarden to import their items"
+		return m, clearStatusAfter(4 * time.Second)
+	}
+	if _, ok := m.awsClient.(*aws.Client); !ok {
+		m.errorMessage = "Importing needs an AWS account to create the secret in"
+		return m, nil
+	}
+	m.importing = passwordImport{}
+	return m, listPasswordItems(m.startLoad("Listing password manager items…"), m.passwordManagers)
+}
+
+// pickPasswordItem lists the items to import from
+func (m Model) pickPasswordItem(msg passwordItemsMsg) (tea.Model, tea.Cmd) {
+	m.loading = false
+	if len(msg.items) == 0 {
+		if len(msg.failed) > 0 {
+			m.showError("Failed to list password manager items", errors.Join(msg.failed...))
+		} else {
+			m.errorMessage = "No password manager items to import"
+		}
+		return m, nil
+	}
+
+	m.importing.items = msg.items
+	labels := make([]string, len(msg.items))
+	for i, item := range msg.items {
+		labels[i] = item.Label()
+	}
+	m.openScreen(ScreenImportPick, newPickerScreen("Import a password manager item as a new secret", labels, ""), ScreenSecretList)
+	if len(msg.failed) > 0 {
+		m.statusMessage = "Not listed: " + errors.Join(msg.failed...).Error()
+		return m, clearStatusAfter(6 * time.Second)
+	}
+	return m, nil
+}
+
+// choosePasswordItem reads the fields of the item picked
+func (m Model) choosePasswordItem(label string) (tea.Model, tea.Cmd) {
+	for _, item := range m.importing.items {
+		if item.Label() == label {
+			return m, loadPasswordFields(m.startLoad("Reading "+item.Title+"…"), m.passwordManagers, item)
+		}
+	}
+	m.importing = passwordImport{}
+	return m, nil
+}
+
+// promptImportName asks what to call the secret the item is imported as
+func (m Model) promptImportName(msg passwordFieldsMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.importing = passwordImport{}
+		m.showError("Failed to read "+msg.item.Title, msg.err)
+		return m, nil
+	}
+	if len(msg.fields) == 0 {
+		m.importing = passwordImport{}
+		m.errorMessage = msg.item.Title + " has no fields to import"
+		return m, nil
+	}
+
+	m.importing.item = msg.item
+	m.importing.fields = msg.fields
+	keys := slices.Sorted(maps.Keys(msg.fields))
+	prompt := newTextScreen("Import "+msg.item.Title+" ("+strings.Join(keys, ", ")+")", "New secret name:", "prod/app/api-key")
+	prompt.input.SetValue(importName(msg.item.Title, m.grid.GetFilterQuery()))
+	m.openScreen(ScreenImportName, prompt, ScreenSecretList)
+	return m, nil
+}
+
+// importPasswordItem creates the secret once it is named
+func (m Model) importPasswordItem(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return m.updateScreen(promptErrorMsg{err: errors.New("enter a name for the new secret")})
+	}
+	client, ok := m.awsClient.(*aws.Client)
+	if !ok || m.importing.fields == nil {
+		return m, nil
+	}
+	// The fields become a JSON object, as a key per field, like a credential
+	// created in the console
+	data, err := json.Marshal(m.importing.fields)
+	if err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	value := models.SecretValue{String: string(data)}
+	if err := m.schemas.Validate(name, value); err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	m.closeScreen()
+	ctx := m.startLoad("Creating " + name + "…")
+	return m, createImportedSecret(ctx, client, m.importing.item, name, value)
+}
+
+// showPasswordImported reports the secret created, and lists it
+func (m Model) showPasswordImported(msg passwordImportedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	m.importing = passwordImport{}
+	if msg.err != nil {
+		m.showError("Failed to import "+msg.item.Title, msg.err)
+		m.logOperation(fmt.Sprintf("Failed to import %s from %s as %s", msg.item.Title, msg.item.Manager, msg.name), msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.statusMessage = fmt.Sprintf("Created %s from %s in %s", msg.name, msg.item.Label(), m.currentRegion)
+	m.logOperation(fmt.Sprintf("Imported %s from %s as %s", msg.item.Title, msg.item.Manager, msg.name), nil)
+	m, refresh := m.refreshSecrets("Listing " + msg.name + "…")
+	return m, tea.Batch(refresh, clearStatusAfter(4*time.Second))
+}
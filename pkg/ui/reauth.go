@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/auth"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reauthCooldown is how soon after signing in again a further expired
+// session is reported instead, as signing in hasn't helped
+const reauthCooldown = 30 * time.Second
+
+// retryFunc runs an operation again with the model's new client
+type retryFunc func(m Model) (Model, tea.Cmd)
+
+// reauthState is the operations waiting for the current profile to sign in
+// again after its session expired
+type reauthState struct {
+	retries    []retryFunc
+	signedInAt time.Time // When the last re-auth finished
+}
+
+// ssoLoginMsg reports that `aws sso login` has exited
+type ssoLoginMsg struct {
+	err error
+}
+
+// reauthenticate signs in to the current profile again when err is an
+// expired session, then runs retry. It returns false when err is anything
+// else, or when a session from a re-auth moments ago has been rejected too,
+// so the caller reports err as usual.
+func (m Model) reauthenticate(err error, retry retryFunc) (Model, tea.Cmd, bool) {
+	if !m.awsBackend() || !aws.IsExpiredSession(err) || time.Since(m.reauth.signedInAt) < reauthCooldown {
+		return m, nil, false
+	}
+
+	pending := len(m.reauth.retries) > 0
+	m.reauth.retries = append(m.reauth.retries, retry)
+	if pending {
+		// Already signing in; this operation is retried along with the first
+		return m, nil, true
+	}
+
+	m.errorMessage = ""
+	m.loading = false
+	auth.ForgetSession(m.currentProfile)
+	if m.usesSSO(m.currentProfile) {
+		m.statusMessage = fmt.Sprintf("Session for %s expired, running aws sso login…", m.currentProfile)
+		login := exec.Command("aws", "sso", "login", "--profile", m.currentProfile)
+		return m, tea.ExecProcess(login, func(err error) tea.Msg {
+			return ssoLoginMsg{err: err}
+		}), true
+	}
+	m.statusMessage = fmt.Sprintf("Session for %s expired, signing in again…", m.currentProfile)
+	return m, m.initAWSClient(m.currentProfile, m.currentRegion), true
+}
+
+// finishSSOLogin signs in again once `aws sso login` has exited
+func (m Model) finishSSOLogin(msg ssoLoginMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.reauth.retries = nil
+		m.statusMessage = ""
+		m.errorMessage = fmt.Sprintf("Failed to run `aws sso login --profile %s`: %v", m.currentProfile, msg.err)
+		return m, nil
+	}
+	return m, m.initAWSClient(m.currentProfile, m.currentRegion)
+}
+
+// retryAfterReauth switches to the client signed in again with and runs the
+// operations that failed, keeping the secret list and open secret as they
+// were
+func (m Model) retryAfterReauth(client SecretStore) (tea.Model, tea.Cmd) {
+	m.awsClient = client
+	if m.inventory != nil {
+		m.inventory.SetSource(client)
+	}
+	m.loading = false
+	m.statusMessage = ""
+	retries := m.reauth.retries
+	m.reauth = reauthState{signedInAt: time.Now()}
+
+	cmds := make([]tea.Cmd, 0, len(retries))
+	for _, retry := range retries {
+		var cmd tea.Cmd
+		m, cmd = retry(m)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// retryLoadSecrets loads page of the secret list again
+func retryLoadSecrets(page int) retryFunc {
+	return func(m Model) (Model, tea.Cmd) {
+		if m.inventory == nil {
+			return m, nil
+		}
+		return m, loadSecrets(m.startLoad("Loading secrets…"), m.inventory, page)
+	}
+}
+
+// retryLoadValue loads the value of the open secret again
+func retryLoadValue(m Model) (Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if m.currentScreen != ScreenSecretDetail || secret == nil {
+		return m, nil
+	}
+	return m, loadSecretValue(m.startLoad("Decrypting secret…"), m.awsClient, secret.Name)
+}
+
+// retryCopyEnv copies the marked secrets, or the selected one, as .env again
+func retryCopyEnv(m Model) (Model, tea.Cmd) {
+	names := m.exportNames()
+	if len(names) == 0 {
+		return m, nil
+	}
+	ctx := m.startLoad(fmt.Sprintf("Fetching %d secrets…", len(names)))
+	return m, copyCombinedEnv(ctx, m.awsClient, names, m.envMapping)
+}
+
+// retryAudit lists every secret for the audit again
+func retryAudit(m Model) (Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	return m, loadAudit(m.startLoad("Listing every secret for the audit…"), m.inventory)
+}
+
+// retryLint runs the compliance checks again
+func retryLint(m Model) (Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	return m, loadLint(m.startLoad("Checking every secret…"), m.inventory, m.awsClient, m.lintRules)
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// secretReferencesMsg reports the ECS task definitions and Lambda functions
+// that read a secret. err says which service couldn't be read, if any.
+type secretReferencesMsg struct {
+	name    string
+	details *models.SecretDetails
+	refs    []aws.SecretReference
+	err     error
+}
+
+// findSecretReferences describes the secret, for its ARN and versions, then
+// looks for it in ECS and Lambda
+func findSecretReferences(ctx context.Context, client *aws.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		details, err := client.DescribeSecret(ctx, name)
+		if err != nil {
+			return secretReferencesMsg{name: name, err: err}
+		}
+		refs, err := client.FindSecretReferences(ctx, details)
+		return secretReferencesMsg{name: name, details: details, refs: refs, err: err}
+	}
+}
+
+// startFindReferences looks for what reads the open secret
+func (m Model) startFindReferences() (tea.Model, tea.Cmd) {
+	client, ok := m.awsSource("Finding where a secret is used")
+	if !ok {
+		return m, nil
+	}
+	if client.GetEndpoint() != "" {
+		m.errorMessage = "Finding where a secret is used needs AWS, ECS and Lambda can't be read through a custom endpoint"
+		return m, nil
+	}
+	name := m.grid.SelectedSecret().Name
+	return m, findSecretReferences(m.startLoad("Looking for "+name+" in ECS and Lambda…"), client, name)
+}
+
+// showSecretReferences lists what reads the secret, flagging references
+// pinned to a version other than the current one
+func (m Model) showSecretReferences(msg secretReferencesMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.details == nil || (msg.err != nil && len(msg.refs) == 0) {
+		m.showErrorFor(m.currentProfile, m.currentRegion, "Failed to find where "+msg.name+" is used", msg.err)
+		return m, nil
+	}
+
+	items := make([]components.SummaryItem, len(msg.refs))
+	stale := 0
+	for i, ref := range msg.refs {
+		status, detail := referenceStatus(ref, msg.details.Versions)
+		if status == components.ItemFailed {
+			stale++
+		}
+		items[i] = components.SummaryItem{Name: ref.Kind + " " + ref.Name, Status: status, Detail: detail}
+	}
+
+	var intro string
+	switch {
+	case len(items) == 0:
+		intro = fmt.Sprintf("No ECS task definition or Lambda function in %s reads %s.", m.currentRegion, msg.name)
+	case stale == 0:
+		intro = fmt.Sprintf("All %d references read the current version.", len(items))
+	default:
+		intro = fmt.Sprintf("%d of %d references don't read the current version.", stale, len(items))
+	}
+	if len(items) > 0 {
+		// A reference to AWSCURRENT can still be behind: ECS reads the value
+		// when a task starts, and functions usually read it once per instance
+		intro += " Running ECS tasks and Lambda instances keep the value they read at start, so restart them after a rotation."
+	}
+	if msg.err != nil {
+		intro += " Not checked: " + msg.err.Error()
+	}
+	m.openScreen(ScreenReferences, newResultsScreen("Where "+msg.name+" is used", intro, items), ScreenSecretDetail)
+	return m, nil
+}
+
+// referenceStatus says whether a reference reads the current version: ✓
+// for AWSCURRENT, ✗ for another or missing version and pending for one
+// pinned to the current version, which rotation will leave behind
+func referenceStatus(ref aws.SecretReference, versions []models.SecretVersion) (components.ItemStatus, string) {
+	where := ref.Where
+	if ref.Key != "" {
+		where += " (" + ref.Key + ")"
+	}
+	current := versionWithStage(versions, "AWSCURRENT")
+
+	switch {
+	case ref.VersionID != "" && ref.VersionID == current:
+		return components.ItemPending, where + " is pinned to version " + ref.VersionID + ", the current one, and won't follow rotation"
+	case ref.VersionID != "":
+		return components.ItemFailed, where + " is pinned to version " + ref.VersionID + ", not the current one"
+	case !ref.Pinned():
+		return components.ItemSucceeded, where + " reads AWSCURRENT"
+	}
+
+	pinned := versionWithStage(versions, ref.Stage)
+	switch {
+	case pinned == "":
+		return components.ItemFailed, where + " reads " + ref.Stage + ", which no version has"
+	case pinned == current:
+		return components.ItemPending, where + " reads " + ref.Stage + ", the current version for now"
+	default:
+		return components.ItemFailed, where + " reads " + ref.Stage + ", not the current version"
+	}
+}
+
+// versionWithStage returns the ID of the version with a staging label, or ""
+func versionWithStage(versions []models.SecretVersion, stage string) string {
+	for _, version := range versions {
+		if slices.Contains(version.Stages, stage) {
+			return version.ID
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"context"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// regionsLoadedMsg reports the result of loadRegions
+type regionsLoadedMsg struct {
+	client  SecretStore
+	regions []models.Region
+	err     error
+}
+
+// loadRegions lists the regions the client's account can use
+func loadRegions(ctx context.Context, client SecretStore) tea.Cmd {
+	return func() tea.Msg {
+		regions, err := client.ListRegions(ctx)
+		return regionsLoadedMsg{client: client, regions: regions, err: err}
+	}
+}
+
+// commonRegions is aws.GetCommonRegions with nothing known about them
+func commonRegions() []models.Region {
+	names := aws.GetCommonRegions()
+	regions := make([]models.Region, len(names))
+	for i, name := range names {
+		regions[i].Name = name
+	}
+	return regions
+}
+
+// knownRegions returns the regions listed for the current client, or the
+// common regions until they have been
+func (m Model) knownRegions() []models.Region {
+	if m.regions != nil {
+		return m.regions
+	}
+	return commonRegions()
+}
+
+// startRegionSelector lists the account's regions the first time the region
+// selector opens for a client. Without a client, e.g. when signing in failed
+// in the current region, it opens straight away with the common regions.
+func (m Model) startRegionSelector() (tea.Model, tea.Cmd) {
+	if m.awsClient == nil || m.regions != nil {
+		m.openScreen(ScreenRegionSelector, newRegionScreen(m.knownRegions(), m.currentRegion), ScreenSecretList)
+		return m, nil
+	}
+	return m, loadRegions(m.startLoad("Listing regions…"), m.awsClient)
+}
+
+// showRegions opens the region selector once the regions are listed
+func (m Model) showRegions(msg regionsLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.client != m.awsClient || cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		m.showError("Failed to list regions", msg.err)
+		return m, nil
+	}
+	m.regions = msg.regions
+	m.openScreen(ScreenRegionSelector, newRegionScreen(m.regions, m.currentRegion), ScreenSecretList)
+	return m, nil
+}
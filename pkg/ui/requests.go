@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultRequestTimeout bounds each AWS operation the UI starts, retries
+// included, so a hung connection can't leave it loading forever
+const defaultRequestTimeout = time.Minute
+
+// parseRequestTimeout reads the request_timeout config setting. An empty or
+// invalid value uses the default.
+func parseRequestTimeout(value string) time.Duration {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return timeout
+}
+
+// startLoad cancels any load in progress and returns the context for a new
+// one, which the cancel key can abort and which is a dry run while dry-run
+// mode is on. text is shown next to the spinner.
+func (m *Model) startLoad(text string) context.Context {
+	m.cancelLoad()
+	ctx, cancel := context.WithTimeout(context.Background(), m.requestTimeout)
+	m.loadCancel = cancel
+	m.loading = true
+	m.loadingText = text
+	return m.dryRunContext(ctx)
+}
+
+// cancelLoad aborts the load in progress, if any
+func (m *Model) cancelLoad() {
+	if m.loadCancel != nil {
+		m.loadCancel()
+		m.loadCancel = nil
+	}
+}
+
+// isCancelKey reports whether msg should abort the load in progress: esc
+// anywhere, or x outside of prompts, which may need to accept it as text
+func (m Model) isCancelKey(msg tea.KeyMsg) bool {
+	if !m.loading || m.loadCancel == nil {
+		return false
+	}
+	return msg.String() == "esc" || (m.screen == nil && msg.String() == "x")
+}
+
+// cancelInFlight aborts the load in progress. Its result arrives later with
+// context.Canceled and is dropped.
+func (m Model) cancelInFlight() (tea.Model, tea.Cmd) {
+	m.cancelLoad()
+	m.loading = false
+	// A cancelled copy, comparison or sync won't use its second client again
+	m.copyTo = copyDestination{}
+	m.compare = compareState{}
+	m.syncTo = syncState{}
+	m.statusMessage = "Cancelled"
+	return m, clearStatusAfter(2 * time.Second)
+}
+
+// cancelled reports whether err comes from a load that was cancelled
+func cancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/notify"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sendNotification shows a desktop notification. Tests replace it.
+var sendNotification = notify.Send
+
+// rotatedSince reports whether a secret rotated between two listings of it
+func rotatedSince(previous, current models.Secret) bool {
+	if current.LastRotatedDate == nil {
+		return false
+	}
+	return previous.LastRotatedDate == nil || current.LastRotatedDate.After(*previous.LastRotatedDate)
+}
+
+// rotationBanner says which flagged secrets rotated
+func rotationBanner(names []string, at time.Time) string {
+	return fmt.Sprintf("Rotated at %s: %s", at.Format("15:04"), strings.Join(names, ", "))
+}
+
+// notifyRotated shows a desktop notification for the flagged secrets that
+// rotated. Failures are only logged, as the status bar says the same.
+func notifyRotated(names []string) tea.Cmd {
+	return func() tea.Msg {
+		title := "Secret rotated"
+		if len(names) > 1 {
+			title = fmt.Sprintf("%d secrets rotated", len(names))
+		}
+		if err := sendNotification(title, strings.Join(names, ", ")); err != nil {
+			logging.Debugf("failed to notify about rotation: %v", err)
+		}
+		return nil
+	}
+}
+
+// toggleRotationFlag flags the selected secret to notify about when watch
+// mode sees it rotate, or unflags it
+func (m Model) toggleRotationFlag() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		return m, nil
+	}
+
+	flagged := make(map[string]bool, len(m.watch.flagged)+1)
+	for name := range m.watch.flagged {
+		flagged[name] = true
+	}
+	if flagged[secret.Name] {
+		delete(flagged, secret.Name)
+		m.statusMessage = "No longer notifying when " + secret.Name + " rotates"
+	} else {
+		flagged[secret.Name] = true
+		m.statusMessage = "Notifying when " + secret.Name + " rotates"
+		if !m.watch.on {
+			m.statusMessage += " (press w to start watching)"
+		}
+	}
+	m.watch.flagged = flagged
+	m.grid.SetFlagged(flagged)
+	return m, clearStatusAfter(3 * time.Second)
+}
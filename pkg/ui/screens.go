@@ -0,0 +1,774 @@
+package ui
+
+import (
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screen is a sub-model for a screen that owns its own state. Screens never
+// change the root Model directly: they report what the user did with the
+// messages below, and the root model decides what happens next. This keeps
+// each screen testable on its own.
+//
+// The secret list and detail screens are not sub-models: they share the
+// loaded secrets and values with most of the root model's state, and their
+// keys start actions that the root model runs, so they are handled by the
+// root model itself.
+type screen interface {
+	Update(msg tea.Msg) (screen, tea.Cmd)
+	View() string
+	// SetSize sets the content area available to the screen
+	SetSize(width, height int) screen
+}
+
+// Messages sent from screens to the root model
+type (
+	// closeScreenMsg asks the root model to close the screen without a result
+	closeScreenMsg struct{}
+
+	profileSelectedMsg struct {
+		profile string
+	}
+
+	regionSelectedMsg struct {
+		region string
+	}
+
+	// fieldChosenMsg carries the value of the JSON field picked for copying
+	fieldChosenMsg struct {
+		value string
+	}
+
+	// actionChosenMsg carries the shortcut key of the action picked from a menu
+	actionChosenMsg struct {
+		key string
+	}
+
+	pathEnteredMsg struct {
+		path string
+	}
+
+	textEnteredMsg struct {
+		value string
+	}
+
+	// confirmedMsg reports that the user accepted a confirmation screen
+	confirmedMsg struct{}
+
+	secretPickedMsg struct {
+		name string
+	}
+
+	// pickCounterpartMsg asks to compare with a different secret
+	pickCounterpartMsg struct{}
+
+	// startTargetChosenMsg carries the profile and region picked to start with
+	startTargetChosenMsg struct {
+		profile string
+		region  string
+	}
+
+	// exportAuditMsg asks to export the audit with the threshold shown
+	exportAuditMsg struct {
+		staleDays int
+	}
+
+	mfaCodeEnteredMsg struct {
+		code string
+	}
+
+	mfaCancelledMsg struct{}
+
+	unlockRequestedMsg struct{}
+)
+
+// promptErrorMsg is sent from the root model to the active screen when the
+// result it reported could not be used, e.g. a file that already exists
+type promptErrorMsg struct {
+	err error
+}
+
+// emit returns a command that delivers msg to the root model
+func emit(msg tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return msg
+	}
+}
+
+// openScreen makes s the active screen. returnTo is the screen shown when
+// it closes.
+func (m *Model) openScreen(id Screen, s screen, returnTo Screen) {
+	contentWidth, contentHeight := m.contentViewportSize()
+	m.screen = s.SetSize(contentWidth, contentHeight)
+	m.currentScreen = id
+	m.screenReturn = returnTo
+}
+
+// openDestructiveConfirm asks before deleting or overwriting secrets. The
+// user types name to confirm, or presses y with the confirm_with_key setting.
+func (m *Model) openDestructiveConfirm(id Screen, title, intro string, items []components.SummaryItem, name string, returnTo Screen) {
+	if m.confirmWithKey {
+		m.openScreen(id, newConfirmScreen(title, intro, items), returnTo)
+		return
+	}
+	m.openScreen(id, newTypedConfirmScreen(title, intro, items, name), returnTo)
+}
+
+// closeScreen drops the active screen and returns to the one it was opened from
+func (m *Model) closeScreen() {
+	m.screen = nil
+	m.currentScreen = m.screenReturn
+}
+
+// updateScreen passes msg to the active screen
+func (m Model) updateScreen(msg tea.Msg) (Model, tea.Cmd) {
+	if m.screen == nil {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.screen, cmd = m.screen.Update(msg)
+	return m, cmd
+}
+
+// profileScreen lets the user pick an AWS profile
+type profileScreen struct {
+	selector components.ProfileSelector
+}
+
+func newProfileScreen(profiles []models.ProfileSummary, currentProfile string) profileScreen {
+	return profileScreen{selector: components.NewProfileSelector(profiles, currentProfile, 0, 0)}
+}
+
+func (s profileScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			return s, emit(profileSelectedMsg{profile: s.selector.SelectedProfile()})
+		}
+	}
+	cmd := s.selector.Update(msg)
+	return s, cmd
+}
+
+func (s profileScreen) View() string {
+	return s.selector.View()
+}
+
+func (s profileScreen) SetSize(width, height int) screen {
+	s.selector.SetSize(width, height)
+	return s
+}
+
+// regionScreen lets the user pick an AWS region
+type regionScreen struct {
+	selector components.RegionSelector
+}
+
+func newRegionScreen(regions []models.Region, currentRegion string) regionScreen {
+	return regionScreen{selector: components.NewRegionSelector(regions, currentRegion, 0, 0)}
+}
+
+func (s regionScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			return s, emit(regionSelectedMsg{region: s.selector.SelectedRegion()})
+		}
+	}
+	cmd := s.selector.Update(msg)
+	return s, cmd
+}
+
+func (s regionScreen) View() string {
+	return s.selector.View()
+}
+
+func (s regionScreen) SetSize(width, height int) screen {
+	s.selector.SetSize(width, height)
+	return s
+}
+
+// fieldScreen lets the user pick a top-level JSON field to copy
+type fieldScreen struct {
+	selector components.SecretFieldSelector
+}
+
+func newFieldScreen(fields []components.SecretField) fieldScreen {
+	return fieldScreen{selector: components.NewSecretFieldSelector(fields, 0, 0)}
+}
+
+func (s fieldScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			field := s.selector.SelectedField()
+			if field == nil {
+				return s, emit(closeScreenMsg{})
+			}
+			return s, emit(fieldChosenMsg{value: field.CopyValue})
+		}
+	}
+	cmd := s.selector.Update(msg)
+	return s, cmd
+}
+
+func (s fieldScreen) View() string {
+	return s.selector.View()
+}
+
+func (s fieldScreen) SetSize(width, height int) screen {
+	s.selector.SetSize(width, height)
+	return s
+}
+
+// actionScreen lets the user pick an action from a menu
+type actionScreen struct {
+	menu components.ActionMenu
+}
+
+func newActionScreen(title string, actions []components.Action) actionScreen {
+	return actionScreen{menu: components.NewActionMenu(title, actions, 0, 0)}
+}
+
+func (s actionScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && !s.menu.IsFiltering() {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			action := s.menu.SelectedAction()
+			if action == nil {
+				return s, emit(closeScreenMsg{})
+			}
+			return s, emit(actionChosenMsg{key: action.Key})
+		}
+	}
+	cmd := s.menu.Update(msg)
+	return s, cmd
+}
+
+func (s actionScreen) View() string {
+	return s.menu.View()
+}
+
+func (s actionScreen) SetSize(width, height int) screen {
+	s.menu.SetSize(width, height)
+	return s
+}
+
+// pathScreen prompts for a file path, for saving and exporting
+type pathScreen struct {
+	input         components.PathInput
+	width, height int
+}
+
+func newPathScreen(title, defaultPath string) pathScreen {
+	return pathScreen{input: components.NewPathInput(title, defaultPath)}
+}
+
+func (s pathScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case promptErrorMsg:
+		s.input.SetError(msg.err.Error())
+		return s, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			return s, emit(pathEnteredMsg{path: s.input.Value()})
+		}
+		s.input.SetError("")
+	}
+	cmd := s.input.Update(msg)
+	return s, cmd
+}
+
+func (s pathScreen) View() string {
+	return placeCentered(s.width, s.height, s.input.View())
+}
+
+func (s pathScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	return s
+}
+
+// textScreen prompts for a line of text, such as a tag
+type textScreen struct {
+	input         components.TextInput
+	width, height int
+}
+
+func newTextScreen(title, instruction, placeholder string) textScreen {
+	return textScreen{input: components.NewTextInput(title, instruction, placeholder)}
+}
+
+func (s textScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case promptErrorMsg:
+		s.input.SetError(msg.err.Error())
+		return s, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			return s, emit(textEnteredMsg{value: s.input.Value()})
+		}
+		s.input.SetError("")
+	}
+	cmd := s.input.Update(msg)
+	return s, cmd
+}
+
+func (s textScreen) View() string {
+	return placeCentered(s.width, s.height, s.input.View())
+}
+
+func (s textScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	return s
+}
+
+// summaryScreen shows a list of items, either to confirm an operation on
+// them or to report how it went. Only a confirmation accepts y, or the
+// typed name when one is asked for.
+type summaryScreen struct {
+	summary       components.Summary
+	confirm       bool
+	typed         bool // Confirmed by typing name rather than with y
+	name          components.NameConfirm
+	width, height int
+}
+
+func newConfirmScreen(title, intro string, items []components.SummaryItem) summaryScreen {
+	summary := components.NewSummary(title, intro, "y: confirm | n/esc: cancel | ↑/↓: scroll", items)
+	return summaryScreen{summary: summary, confirm: true}
+}
+
+// newTypedConfirmScreen is a confirmation accepted by typing name, for
+// operations that delete or overwrite secrets
+func newTypedConfirmScreen(title, intro string, items []components.SummaryItem, name string) summaryScreen {
+	summary := components.NewSummary(title, intro, "enter: confirm | esc: cancel | ↑/↓: scroll", items)
+	s := summaryScreen{summary: summary, confirm: true, typed: true, name: components.NewNameConfirm(name)}
+	s.summary.SetPrompt(s.name.View())
+	return s
+}
+
+func newResultsScreen(title, intro string, items []components.SummaryItem) summaryScreen {
+	summary := components.NewSummary(title, intro, "enter/esc: close | ↑/↓: scroll", items)
+	return summaryScreen{summary: summary}
+}
+
+func (s summaryScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if s.typed {
+		return s.updateTyped(msg)
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "y":
+			if s.confirm {
+				return s, emit(confirmedMsg{})
+			}
+		case "n", "q", "esc", "enter":
+			return s, emit(closeScreenMsg{})
+		}
+	}
+	cmd := s.summary.Update(msg)
+	return s, cmd
+}
+
+// updateTyped handles keys while waiting for the name to be typed. Only the
+// arrow keys scroll, so every letter can be typed.
+func (s summaryScreen) updateTyped(msg tea.Msg) (screen, tea.Cmd) {
+	var cmd tea.Cmd
+	if key, ok := msg.(tea.KeyMsg); !ok {
+		cmd = s.name.Update(msg)
+	} else {
+		switch key.String() {
+		case "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			if s.name.Matches() {
+				return s, emit(confirmedMsg{})
+			}
+			s.name.SetError("The name doesn't match")
+		case "up", "down":
+			cmd = s.summary.Update(msg)
+		default:
+			s.name.SetError("")
+			cmd = s.name.Update(msg)
+		}
+	}
+	s.summary.SetPrompt(s.name.View())
+	return s, cmd
+}
+
+func (s summaryScreen) View() string {
+	return placeCentered(s.width, s.height, s.summary.View())
+}
+
+func (s summaryScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	s.summary.SetSize(width, height)
+	return s
+}
+
+// pickerScreen lets the user pick a secret by name, e.g. in another account
+type pickerScreen struct {
+	picker components.SecretPicker
+}
+
+func newPickerScreen(title string, names []string, selected string) pickerScreen {
+	return pickerScreen{picker: components.NewSecretPicker(title, names, selected, 0, 0)}
+}
+
+func (s pickerScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && !s.picker.IsFiltering() {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			name := s.picker.SelectedName()
+			if name == "" {
+				return s, emit(closeScreenMsg{})
+			}
+			return s, emit(secretPickedMsg{name: name})
+		}
+	}
+	cmd := s.picker.Update(msg)
+	return s, cmd
+}
+
+func (s pickerScreen) View() string {
+	return s.picker.View()
+}
+
+func (s pickerScreen) SetSize(width, height int) screen {
+	s.picker.SetSize(width, height)
+	return s
+}
+
+// compareScreen shows a key-level diff of two secret values
+type compareScreen struct {
+	diff components.ValueDiff
+}
+
+func newCompareScreen(diff components.ValueDiff) compareScreen {
+	return compareScreen{diff: diff}
+}
+
+func (s compareScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "r":
+			s.diff.ToggleReveal()
+			return s, nil
+		case "s":
+			s.diff.ToggleSame()
+			return s, nil
+		case "p":
+			return s, emit(pickCounterpartMsg{})
+		}
+	}
+	cmd := s.diff.Update(msg)
+	return s, cmd
+}
+
+func (s compareScreen) View() string {
+	return s.diff.View()
+}
+
+func (s compareScreen) SetSize(width, height int) screen {
+	s.diff.SetSize(width, height)
+	return s
+}
+
+// syncPreviewScreen shows what syncing a secret would write, as a diff of
+// the destination's value before and after. Nothing is written unless it is
+// accepted with enter.
+type syncPreviewScreen struct {
+	diff components.ValueDiff
+}
+
+func newSyncPreviewScreen(diff components.ValueDiff) syncPreviewScreen {
+	return syncPreviewScreen{diff: diff}
+}
+
+func (s syncPreviewScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			return s, emit(confirmedMsg{})
+		case "r":
+			s.diff.ToggleReveal()
+			return s, nil
+		case "s":
+			s.diff.ToggleSame()
+			return s, nil
+		}
+	}
+	cmd := s.diff.Update(msg)
+	return s, cmd
+}
+
+func (s syncPreviewScreen) View() string {
+	return s.diff.View()
+}
+
+func (s syncPreviewScreen) SetSize(width, height int) screen {
+	s.diff.SetSize(width, height)
+	return s
+}
+
+// auditScreen lists secrets by when they were last accessed
+type auditScreen struct {
+	audit components.AuditView
+}
+
+func newAuditScreen(audit components.AuditView) auditScreen {
+	return auditScreen{audit: audit}
+}
+
+func (s auditScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "e":
+			return s, emit(exportAuditMsg{staleDays: s.audit.StaleDays()})
+		}
+	}
+	cmd := s.audit.Update(msg)
+	return s, cmd
+}
+
+func (s auditScreen) View() string {
+	return s.audit.View()
+}
+
+func (s auditScreen) SetSize(width, height int) screen {
+	s.audit.SetSize(width, height)
+	return s
+}
+
+// appsScreen lists the secrets of each app in the config under the app's
+// name. Enter on a secret picks it, and on an app collapses or expands it.
+type appsScreen struct {
+	groups components.AppGroups
+}
+
+func newAppsScreen(groups components.AppGroups) appsScreen {
+	return appsScreen{groups: groups}
+}
+
+func (s appsScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			if _, secret := s.groups.Selected(); secret != "" {
+				return s, emit(secretPickedMsg{name: secret})
+			}
+			s.groups.Toggle()
+			return s, nil
+		case " ":
+			s.groups.Toggle()
+			return s, nil
+		}
+	}
+	cmd := s.groups.Update(msg)
+	return s, cmd
+}
+
+func (s appsScreen) View() string {
+	return s.groups.View()
+}
+
+func (s appsScreen) SetSize(width, height int) screen {
+	s.groups.SetSize(width, height)
+	return s
+}
+
+// lintScreen shows the compliance checks' score and findings
+type lintScreen struct {
+	lint components.LintView
+}
+
+func newLintScreen(lint components.LintView) lintScreen {
+	return lintScreen{lint: lint}
+}
+
+func (s lintScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "q", "esc":
+			return s, emit(closeScreenMsg{})
+		}
+	}
+	cmd := s.lint.Update(msg)
+	return s, cmd
+}
+
+func (s lintScreen) View() string {
+	return s.lint.View()
+}
+
+func (s lintScreen) SetSize(width, height int) screen {
+	s.lint.SetSize(width, height)
+	return s
+}
+
+// setupScreenIntro is the number of lines above the lists on setupScreen
+const setupScreenIntro = 3
+
+// setupScreen picks the profile and region to start with when none is
+// remembered, with the two lists side by side. Enter on a profile moves on
+// to the regions, with the profile's own region selected.
+type setupScreen struct {
+	profiles      components.ProfileSelector
+	regions       components.RegionSelector
+	summaries     []models.ProfileSummary
+	regionFocused bool
+	width, height int
+}
+
+func newSetupScreen(summaries []models.ProfileSummary, profile, region string) setupScreen {
+	s := setupScreen{
+		profiles:  components.NewProfileSelector(summaries, profile, 0, 0),
+		regions:   components.NewRegionSelector(commonRegions(), region, 0, 0),
+		summaries: summaries,
+	}
+	s.regions.Select(region)
+	return s
+}
+
+func (s setupScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "tab", "shift+tab":
+			s.regionFocused = !s.regionFocused
+			return s, nil
+		case "esc":
+			if s.regionFocused {
+				s.regionFocused = false
+				return s, nil
+			}
+			return s, emit(closeScreenMsg{})
+		case "enter":
+			if s.regionFocused {
+				return s, emit(startTargetChosenMsg{profile: s.profiles.SelectedProfile(), region: s.regions.SelectedRegion()})
+			}
+			s.regionFocused = true
+			for _, summary := range s.summaries {
+				if summary.Name == s.profiles.SelectedProfile() && summary.Region != "" {
+					s.regions.Select(summary.Region)
+				}
+			}
+			return s, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if s.regionFocused {
+		cmd = s.regions.Update(msg)
+	} else {
+		cmd = s.profiles.Update(msg)
+	}
+	return s, cmd
+}
+
+func (s setupScreen) View() string {
+	t := theme.Current()
+	focused := lipgloss.NewStyle().Foreground(t.Primary).Bold(true)
+	unfocused := lipgloss.NewStyle().Foreground(t.Subtle)
+	profileLabel, regionLabel := focused, unfocused
+	if s.regionFocused {
+		profileLabel, regionLabel = unfocused, focused
+	}
+
+	intro := "Welcome to Secret Src. Pick the AWS profile and region to start with;\nthey are remembered for next time."
+	profiles := profileLabel.Render("1. Profile") + "\n" + s.profiles.View()
+	regions := regionLabel.Render("2. Region") + "\n" + s.regions.View()
+	columnWidth := s.columnWidth()
+	return intro + "\n\n" + lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(columnWidth).Render(profiles),
+		"  ",
+		lipgloss.NewStyle().Width(columnWidth).Render(regions),
+	)
+}
+
+// columnWidth is the width of each list, with a gap between them
+func (s setupScreen) columnWidth() int {
+	return max((s.width-2)/2, 0)
+}
+
+func (s setupScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	listHeight := max(height-setupScreenIntro-1, 0)
+	s.profiles.SetSize(s.columnWidth(), listHeight)
+	s.regions.SetSize(s.columnWidth(), listHeight)
+	return s
+}
+
+// mfaScreen prompts for an MFA code
+type mfaScreen struct {
+	input         components.MFAInput
+	width, height int
+}
+
+func newMFAScreen() mfaScreen {
+	return mfaScreen{input: components.NewMFAInput()}
+}
+
+func (s mfaScreen) Update(msg tea.Msg) (screen, tea.Cmd) {
+	switch msg := msg.(type) {
+	case promptErrorMsg:
+		// Clear the rejected code so the next one can be typed straight away
+		s.input.Reset()
+		return s, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return s, emit(mfaCancelledMsg{})
+		case "enter":
+			return s, emit(mfaCodeEnteredMsg{code: s.input.Value()})
+		}
+	}
+	cmd := s.input.Update(msg)
+	return s, cmd
+}
+
+func (s mfaScreen) View() string {
+	return placeCentered(s.width, s.height, s.input.View())
+}
+
+func (s mfaScreen) SetSize(width, height int) screen {
+	s.width, s.height = width, height
+	return s
+}
+
+// placeCentered centers content in the given area once its size is known
+func placeCentered(width, height int, content string) string {
+	if width > 0 && height > 0 {
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+	}
+	return content
+}
@@ -0,0 +1,25 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// quit exits the UI. With scrollback_protection the screen is blanked first,
+// so no value is left drawn for terminals that keep the alternate screen in
+// their scrollback.
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	if !m.scrollbackProtection {
+		return m, tea.Quit
+	}
+	m.clearSecretValueState()
+	m.blanked = true
+	return m, tea.Sequence(tea.ClearScreen, tea.Quit)
+}
+
+// suspend hands the terminal back to the shell, as on ctrl+z, blanking the
+// screen first with scrollback_protection. The UI is drawn again on resume.
+func (m Model) suspend() (tea.Model, tea.Cmd) {
+	if !m.scrollbackProtection {
+		return m, tea.Suspend
+	}
+	m.blanked = true
+	return m, tea.Sequence(tea.ClearScreen, tea.Suspend)
+}
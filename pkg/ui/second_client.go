@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"context"
+	"errors"
+
+	"github.com/benjamingriff/secretsrc/pkg/auth"
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// secondPurpose is what a client for a second profile is signed in for.
+// The current client stays active alongside it, and the second one is only
+// handed to the operation that asked for it.
+type secondPurpose int
+
+const (
+	secondForCopy secondPurpose = iota
+	secondForCompare
+	secondForSync
+)
+
+// secondAuthMsg reports the result of signing in to a second profile
+type secondAuthMsg struct {
+	client  *aws.Client
+	profile string
+	region  string
+	err     error
+}
+
+// secondMFARequiredMsg asks for an MFA code for the second profile
+type secondMFARequiredMsg struct {
+	profile string
+	region  string
+}
+
+// authenticateSecond signs in to a second profile, leaving the current
+// client as it is. Steps are reported with authProgressMsg, followed by
+// secondMFARequiredMsg when a code is needed or secondAuthMsg with the result.
+func authenticateSecond(ctx context.Context, req auth.Request) tea.Cmd {
+	return runAuth(ctx, req, func(client *aws.Client, err error) tea.Msg {
+		var mfaErr *auth.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return secondMFARequiredMsg{profile: req.Profile, region: req.Region}
+		}
+		return secondAuthMsg{client: client, profile: req.Profile, region: req.Region, err: err}
+	})
+}
+
+// signInSecond signs in to profile in region for purpose
+func (m Model) signInSecond(purpose secondPurpose, profile, region string) (tea.Model, tea.Cmd) {
+	m.secondPurpose = purpose
+	ctx := m.startLoad("Signing in to " + profile + "…")
+	return m, authenticateSecond(ctx, auth.Request{Profile: profile, Region: region, EndpointURL: m.endpointURL})
+}
+
+// enterSecondMFACode retries signing in to the second profile with a code
+func (m Model) enterSecondMFACode(code string) (tea.Model, tea.Cmd) {
+	ctx := m.startLoad("Signing in to " + m.pendingMFAProfile + "…")
+	return m, authenticateSecond(ctx, auth.Request{
+		Profile:     m.pendingMFAProfile,
+		Region:      m.pendingMFARegion,
+		EndpointURL: m.endpointURL,
+		MFACode:     code,
+	})
+}
+
+// secondAuthenticated hands the signed-in client to the operation it was
+// for, or keeps the MFA prompt open when the code was rejected
+func (m Model) secondAuthenticated(msg secondAuthMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	m.statusMessage = ""
+	if msg.err != nil {
+		if m.currentScreen == ScreenSecondMFA {
+			if auth.FailedAt(msg.err, auth.StepMFA) {
+				m.showErrorFor(msg.profile, m.currentRegion, "MFA authentication failed", msg.err)
+				return m.updateScreen(promptErrorMsg{err: msg.err})
+			}
+			m.closeScreen()
+		}
+		m.showErrorFor(msg.profile, m.currentRegion, "Failed to sign in to "+msg.profile, msg.err)
+		return m, nil
+	}
+	if m.currentScreen == ScreenSecondMFA {
+		m.closeScreen()
+	}
+	m.errorMessage = ""
+
+	switch m.secondPurpose {
+	case secondForCompare:
+		return m.compareWith(msg.client, msg.profile+" ("+msg.region+")")
+	case secondForSync:
+		return m.syncWith(msg.client, msg.profile+" ("+msg.region+")")
+	default:
+		m.copyTo = copyDestination{client: msg.client, label: "profile " + msg.profile}
+		return m.checkCopyTarget()
+	}
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+)
+
+// detailActions lists the operations available on the detail screen for the
+// current state, each with the key that performs it directly
+func (m Model) detailActions() []components.Action {
+	var actions []components.Action
+	add := func(key, title, description string) {
+		actions = append(actions, components.Action{Key: key, Title: title, Description: description})
+	}
+
+	switch {
+	case !m.secretLoaded():
+		add("v", "View value", "Decrypt the secret value (shown masked until revealed)")
+	case m.secretBinary != nil:
+		add("r", "Reveal or hide value", "Show or mask the binary value")
+		add("x", "Switch base64/hex", "Change how the binary value is displayed")
+		add("c", "Copy as base64", "Copy the binary value to the clipboard as base64")
+	default:
+		add("r", "Reveal or hide value", "Show or mask the whole value")
+		add("c", "Copy plain text", "Copy the value to the clipboard as is")
+		add("j", "Copy as JSON", "Copy the value to the clipboard as formatted JSON")
+		if len(m.secretFields) > 0 {
+			add("k", "Copy a field", fmt.Sprintf("Pick one of the %d top-level JSON fields to copy", len(m.secretFields)))
+		}
+	}
+
+	if m.secretLoaded() {
+		add("s", "Save to file", "Write the value to a file with owner-only permissions, asking before replacing one")
+		add("K", "Copy as Kubernetes Secret", "Copy a Secret manifest with the value base64 encoded, for kubectl apply")
+	}
+	add("e", "Export", "Write this secret to a JSON or YAML document, a Docker env file or compose secrets")
+	add("O", "Open in console", "Open this secret's page in the AWS console in your browser")
+	add("u", "Copy console link", "Copy the AWS console URL for this secret")
+	add("T", "Copy Terraform import", "Copy an import block, terraform import command and resource skeleton for this secret")
+	add("E", "Change KMS key", "Encrypt this secret with another KMS key, picked from the region's key aliases")
+	add("C", "Copy to region", "Create or update the same secret, with its description and tags, in another region")
+	add("P", "Copy to profile", "Create or update the same secret in another profile's account, signing in to it first")
+	add("D", "Compare with another environment", "Diff the value key by key with the same secret, or one you pick, in another profile or region")
+	add("M", "Compare with the cluster", "Diff the value key by key with the Secret of the same name in the current kubectl context and namespace")
+	add("S", "Sync to an AWS secret", "Write the value, with keys mapped by sync_rules, to a secret you name in any profile and region, after previewing it")
+	add("U", "Find where it's used", "List the ECS task definitions and Lambda functions in this region that read the secret, flagging any not reading the current version")
+	add("W", "Find who can access it", "List the IAM users, groups and roles whose policies allow Secrets Manager actions on the secret, and the state_paths resources mentioning it")
+
+	return actions
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjamingriff/secretsrc/pkg/config"
+)
+
+// binaryFormat controls how a binary secret is shown on the detail screen
+type binaryFormat int
+
+const (
+	binaryFormatBase64 binaryFormat = iota
+	binaryFormatHex
+)
+
+// String returns the display name of the format
+func (f binaryFormat) String() string {
+	if f == binaryFormatHex {
+		return "hex"
+	}
+	return "base64"
+}
+
+// binaryLineWidth is the number of base64 characters per displayed line
+const binaryLineWidth = 64
+
+// formatBinary renders data as wrapped base64 or a hex dump
+func formatBinary(data []byte, format binaryFormat) string {
+	if format == binaryFormatHex {
+		return hexDump(data)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(encoded) > binaryLineWidth {
+		lines = append(lines, encoded[:binaryLineWidth])
+		encoded = encoded[binaryLineWidth:]
+	}
+	lines = append(lines, encoded)
+	return strings.Join(lines, "\n")
+}
+
+// hexDump renders data as offset-prefixed lines of 16 hex bytes. Unlike
+// hex.Dump it leaves out the ASCII column so lines fit the value box.
+func hexDump(data []byte) string {
+	var b strings.Builder
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+
+		fmt.Fprintf(&b, "%08x ", offset)
+		for i, c := range data[offset:end] {
+			if i == 8 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, " %02x", c)
+		}
+		if end < len(data) {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// defaultSavePath suggests a file name for a secret in the current directory
+func defaultSavePath(secretName string) string {
+	name := secretName
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		name = "secret"
+	}
+	return name + ".bin"
+}
+
+// errFileExists is returned by writeSecretFile when the file already exists
+var errFileExists = errors.New("already exists")
+
+// writeSecretFile writes data to path with owner-only permissions. It never
+// overwrites an existing file, and expands a leading ~ to the home directory.
+// The expanded path is returned with errFileExists too, so the write can be
+// confirmed and retried with replaceSecretFile.
+func writeSecretFile(path string, data []byte) (string, error) {
+	path, err := expandSavePath(path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return path, fmt.Errorf("%s %w", path, errFileExists)
+		}
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return path, nil
+}
+
+// replaceSecretFile writes data to path, replacing an existing file. The
+// data goes to a new owner-only file that is renamed over path, so the
+// secret never lands in a file with the old file's permissions.
+func replaceSecretFile(path string, data []byte) (string, error) {
+	path, err := expandSavePath(path)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+
+	// CreateTemp creates the file with 0600 permissions
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := file.Name()
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return path, nil
+}
+
+// expandSavePath trims path and expands a leading ~ to the home directory
+func expandSavePath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("enter a file path")
+	}
+
+	return config.ExpandHome(path)
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultRevealTimeout is how long a revealed value stays on screen before it
+// is masked again
+const defaultRevealTimeout = 30 * time.Second
+
+// maskedValue replaces a loaded value on screen until it is revealed. It has a
+// fixed length so the mask doesn't give away the length of the value.
+const maskedValue = "••••••••••••••••"
+
+// remaskMsg masks the value again once the reveal timeout has passed. seq
+// identifies the reveal it belongs to, so toggling again restarts the timer.
+type remaskMsg struct {
+	seq int
+}
+
+// parseRevealTimeout reads the reveal_timeout config setting. An empty value
+// uses the default and "0" keeps values revealed until they are hidden again.
+func parseRevealTimeout(value string) time.Duration {
+	if value == "" {
+		return defaultRevealTimeout
+	}
+	if value == "0" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		return defaultRevealTimeout
+	}
+	return timeout
+}
+
+// toggleReveal shows or hides the loaded value, scheduling the auto re-mask
+// when it is shown
+func (m Model) toggleReveal() (Model, tea.Cmd) {
+	m.revealSeq++
+	m.valueRevealed = !m.valueRevealed
+	if !m.valueRevealed {
+		m.revealedFields = nil
+		return m, nil
+	}
+	return m, m.scheduleRemask()
+}
+
+// toggleFieldReveal shows or hides the value of the JSON field under the
+// cursor. Revealing a field restarts the re-mask timer for all fields;
+// hiding one leaves the timer running for the others.
+func (m Model) toggleFieldReveal() (Model, tea.Cmd) {
+	if m.fieldCursor >= len(m.secretFields) {
+		return m, nil
+	}
+	key := m.secretFields[m.fieldCursor].Key
+
+	if m.revealedFields[key] {
+		delete(m.revealedFields, key)
+		return m, nil
+	}
+	if m.revealedFields == nil {
+		m.revealedFields = make(map[string]bool)
+	}
+	m.revealedFields[key] = true
+	m.revealSeq++
+	return m, m.scheduleRemask()
+}
+
+// scheduleRemask masks everything again after the reveal timeout, unless
+// another reveal toggle happens first
+func (m Model) scheduleRemask() tea.Cmd {
+	if m.revealTimeout <= 0 {
+		return nil
+	}
+	seq := m.revealSeq
+	return tea.Tick(m.revealTimeout, func(time.Time) tea.Msg {
+		return remaskMsg{seq: seq}
+	})
+}
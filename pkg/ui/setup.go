@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// WithStartupPicker starts on a profile and region picker instead of
+// signing in, for a first run where neither is remembered or set in the
+// environment
+func (m Model) WithStartupPicker(profiles []string) Model {
+	m.loading = false
+	m.openScreen(ScreenSetup, newSetupScreen(m.summarizeProfiles(profiles), m.currentProfile, m.currentRegion), ScreenSecretList)
+	return m
+}
+
+// chooseStartTarget signs in with the profile and region picked at startup,
+// starting what Init leaves until then
+func (m Model) chooseStartTarget(msg startTargetChosenMsg) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if msg.profile != "" {
+		m.currentProfile = msg.profile
+	}
+	if msg.region != "" {
+		m.currentRegion = msg.region
+	}
+	m.lastActivity = time.Now()
+	cmds := []tea.Cmd{m.initAWSClient(m.currentProfile, m.currentRegion), checkClockSkew(m.currentRegion)}
+	if m.lockAfter > 0 {
+		cmds = append(cmds, scheduleLockCheck(m.lockAfter))
+	}
+	return m, tea.Batch(cmds...)
+}
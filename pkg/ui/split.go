@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// defaultPanelWidth is the detail panel's share of the width, in percent
+	defaultPanelWidth = 45
+	minPanelWidth     = 25
+	maxPanelWidth     = 70
+	panelWidthStep    = 5
+
+	// minSplitWidth is the narrowest content the panel is shown beside the
+	// grid in; narrower, the panel collapses until there is room again
+	minSplitWidth = 80
+
+	// panelFollowDelay lets the cursor settle before the panel loads the
+	// selected secret's details, so scrolling doesn't describe every secret
+	panelFollowDelay = 150 * time.Millisecond
+)
+
+// splitState is the detail panel shown beside the secret list
+type splitState struct {
+	on    bool
+	width int // The panel's share of the width, in percent
+	// shown is the secret whose details the panel has loaded or is loading
+	shown string
+	// seq identifies the pending follow, so only the last move loads
+	seq int
+}
+
+// panelFollowMsg asks the panel to load the selected secret's details
+type panelFollowMsg struct {
+	seq int
+}
+
+// parsePanelWidth reads the detail_panel_width setting
+func parsePanelWidth(width int) int {
+	if width == 0 {
+		return defaultPanelWidth
+	}
+	return max(min(width, maxPanelWidth), minPanelWidth)
+}
+
+// splitActive reports whether the detail panel is drawn beside the list
+func (m Model) splitActive() bool {
+	if !m.split.on {
+		return false
+	}
+	width, _ := m.contentViewportSize()
+	return width >= minSplitWidth
+}
+
+// gridWidth is the width left for the grid beside the panel, if it is shown
+func (m Model) gridWidth(width int) int {
+	if !m.splitActive() {
+		return width
+	}
+	// One column separates the grid from the panel
+	return width - width*m.split.width/100 - 1
+}
+
+// toggleSplit shows or hides the detail panel, remembering the choice
+func (m Model) toggleSplit() (tea.Model, tea.Cmd) {
+	m.split.on = !m.split.on
+	m.split.shown = ""
+	m.resizeGrid()
+	on := m.split.on
+	updateConfig(func(cfg *config.Config) {
+		cfg.DetailPanel = on
+	})
+	width, _ := m.contentViewportSize()
+	if on && width < minSplitWidth {
+		m.statusMessage = "The window is too narrow for the detail panel; it will show when there is room"
+		return m, clearStatusAfter(4 * time.Second)
+	}
+	return m, nil
+}
+
+// resizeSplit widens or narrows the detail panel by step percent
+func (m Model) resizeSplit(step int) (tea.Model, tea.Cmd) {
+	if !m.splitActive() {
+		return m, nil
+	}
+	m.split.width = max(min(m.split.width+step, maxPanelWidth), minPanelWidth)
+	m.resizeGrid()
+	width := m.split.width
+	updateConfig(func(cfg *config.Config) {
+		cfg.DetailPanelWidth = width
+	})
+	return m, nil
+}
+
+// followSelection has the panel load the details of the selected secret
+// once the cursor stops on it
+func (m Model) followSelection(cmd tea.Cmd) (Model, tea.Cmd) {
+	if !m.splitActive() || m.screen != nil || m.currentScreen != ScreenSecretList {
+		return m, cmd
+	}
+	secret := m.grid.SelectedSecret()
+	if secret == nil || secret.Name == m.split.shown {
+		return m, cmd
+	}
+	m.clearSecretDetails()
+	m.split.shown = secret.Name
+	m.split.seq++
+	seq := m.split.seq
+	follow := tea.Tick(panelFollowDelay, func(time.Time) tea.Msg {
+		return panelFollowMsg{seq: seq}
+	})
+	return m, tea.Batch(cmd, follow)
+}
+
+// loadPanelDetails loads the details of the secret the panel follows, if
+// the cursor is still on it
+func (m Model) loadPanelDetails(msg panelFollowMsg) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if msg.seq != m.split.seq || secret == nil || secret.Name != m.split.shown {
+		return m, nil
+	}
+	return m, loadSecretDetails(m.awsClient, secret.Name, m.requestTimeout)
+}
+
+// viewSplit draws the grid with the detail panel beside it
+func (m Model) viewSplit(grid string) string {
+	width, height := m.contentViewportSize()
+	if m.grid.IsFiltering() || m.jump.prompt() != "" {
+		// The prompt above the grid and the margin below it
+		height -= 2
+	}
+	left := m.gridWidth(width)
+	panelWidth := width - left - 1
+
+	separator := lipgloss.NewStyle().
+		Foreground(theme.Current().Subtle).
+		Render(strings.TrimSuffix(strings.Repeat("│\n", max(height, 1)), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(left).Render(grid),
+		separator,
+		lipgloss.NewStyle().Width(panelWidth).MaxHeight(height).PaddingLeft(1).Render(m.viewDetailPanel(panelWidth-1)),
+	)
+}
+
+// viewDetailPanel renders the selected secret's metadata for the panel.
+// Values are only fetched from the detail screen.
+func (m Model) viewDetailPanel(width int) string {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		return ""
+	}
+	t := theme.Current()
+	keyStyle := lipgloss.NewStyle().Foreground(t.Secondary).Bold(true)
+	valueStyle := lipgloss.NewStyle().Foreground(t.Text)
+	subtleStyle := lipgloss.NewStyle().Foreground(t.Subtle)
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(t.Primary).Render(secret.Name) + "\n\n")
+	if secret.Description != "" {
+		b.WriteString(valueStyle.Render(secret.Description) + "\n\n")
+	}
+	b.WriteString(keyStyle.Render("ARN: ") + valueStyle.Render(secret.ARN) + "\n")
+	if secret.LastChangedDate != nil {
+		b.WriteString(keyStyle.Render("Last Modified: ") + valueStyle.Render(secret.LastChangedDate.Format(detailDateFormat)) + "\n")
+	}
+	if apps := m.appsUsing(secret.Name); len(apps) > 0 {
+		b.WriteString(keyStyle.Render("Apps: ") + valueStyle.Render(strings.Join(apps, ", ")) + "\n")
+	}
+	b.WriteString(m.viewSecretMetadata(secret, keyStyle, valueStyle))
+	if len(secret.Tags) > 0 {
+		b.WriteString("\n" + keyStyle.Render("Tags:") + "\n")
+		for _, key := range slices.Sorted(maps.Keys(secret.Tags)) {
+			b.WriteString(valueStyle.Render(fmt.Sprintf("  %s: %s", key, secret.Tags[key])) + "\n")
+		}
+	}
+	b.WriteString("\n" + subtleStyle.Render("enter: open to view the value"))
+	return lipgloss.NewStyle().Width(width).Render(b.String())
+}
@@ -1,75 +1,110 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	"github.com/charmbracelet/lipgloss"
+)
 
 var (
-	// Colors
-	primaryColor   = lipgloss.Color("205") // Pink
-	secondaryColor = lipgloss.Color("170") // Purple
-	successColor   = lipgloss.Color("42")  // Green
-	errorColor     = lipgloss.Color("196") // Red
-	subtleColor    = lipgloss.Color("241") // Gray
-
 	// Header style
-	HeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(primaryColor).
-			MarginBottom(1)
+	HeaderStyle lipgloss.Style
 
 	// Status bar style
-	StatusBarStyle = lipgloss.NewStyle().
-			Foreground(subtleColor).
-			MarginTop(1)
+	StatusBarStyle lipgloss.Style
 
 	// Selected item style
-	SelectedItemStyle = lipgloss.NewStyle().
-				Foreground(primaryColor).
-				Bold(true).
-				PaddingLeft(2)
+	SelectedItemStyle lipgloss.Style
 
 	// Normal item style
-	NormalItemStyle = lipgloss.NewStyle().
-			PaddingLeft(4)
+	NormalItemStyle lipgloss.Style
 
 	// Error message style
-	ErrorStyle = lipgloss.NewStyle().
-			Foreground(errorColor).
-			Bold(true).
-			Padding(1)
+	ErrorStyle lipgloss.Style
 
 	// Success message style
-	SuccessStyle = lipgloss.NewStyle().
-			Foreground(successColor).
-			Bold(true)
+	SuccessStyle lipgloss.Style
 
 	// Help style
-	HelpStyle = lipgloss.NewStyle().
-			Foreground(subtleColor)
+	HelpStyle lipgloss.Style
 
 	// Detail view styles
+	DetailKeyStyle   lipgloss.Style
+	DetailValueStyle lipgloss.Style
+
+	// Border style
+	BorderStyle lipgloss.Style
+
+	// Title style
+	TitleStyle lipgloss.Style
+
+	// Filter status style (for grid filtering)
+	FilterStatusStyle lipgloss.Style
+
+	// Loading spinner style
+	SpinnerStyle lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(theme.Current())
+}
+
+// ApplyTheme makes t the active theme and rebuilds the shared styles from it
+func ApplyTheme(t theme.Theme) {
+	theme.Set(t)
+
+	HeaderStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary).
+		MarginBottom(1)
+
+	StatusBarStyle = lipgloss.NewStyle().
+		Foreground(t.Subtle).
+		MarginTop(1)
+
+	SelectedItemStyle = lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true).
+		PaddingLeft(2)
+
+	NormalItemStyle = lipgloss.NewStyle().
+		Foreground(t.Text).
+		PaddingLeft(4)
+
+	ErrorStyle = lipgloss.NewStyle().
+		Foreground(t.Error).
+		Bold(true).
+		Padding(1)
+
+	SuccessStyle = lipgloss.NewStyle().
+		Foreground(t.Success).
+		Bold(true)
+
+	HelpStyle = lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
 	DetailKeyStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			Bold(true).
-			Width(20)
+		Foreground(t.Secondary).
+		Bold(true).
+		Width(20)
 
 	DetailValueStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("252"))
+		Foreground(t.Text)
 
-	// Border style
 	BorderStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(primaryColor).
-			Padding(1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary).
+		Padding(1)
 
-	// Title style
 	TitleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true).
-			Underline(true)
+		Foreground(t.Primary).
+		Bold(true).
+		Underline(true)
 
-	// Filter status style (for grid filtering)
 	FilterStatusStyle = lipgloss.NewStyle().
-			Foreground(secondaryColor).
-			Bold(true).
-			MarginBottom(1)
-)
+		Foreground(t.Secondary).
+		Bold(true).
+		MarginBottom(1)
+
+	SpinnerStyle = lipgloss.NewStyle().
+		Foreground(t.Primary)
+}
@@ -0,0 +1,328 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// syncState is the AWS secret the open secret is being synced to, from any
+// backend: the account and region it is in, its name, and once previewed,
+// the value to write. Like a copy's destination, a client for another
+// profile is dropped once the sync is done.
+type syncState struct {
+	profile string      // Chosen before the region is
+	client  *aws.Client // Writes the destination
+	label   string      // Shown to the user, e.g. "prod (eu-west-2)"
+	name    string      // The destination secret
+	value   models.SecretValue
+	exists  bool
+}
+
+// syncPreviewMsg reports what syncing source to name would write
+type syncPreviewMsg struct {
+	source   string
+	name     string
+	value    models.SecretValue
+	mappings []secretvalue.KeyMapping
+	diffs    []secretvalue.FieldDiff
+	exists   bool
+	owner    string
+	err      error
+}
+
+// secretSyncedMsg reports the result of writeSync
+type secretSyncedMsg struct {
+	source  string
+	name    string
+	label   string
+	created bool
+	err     error
+}
+
+// previewSync reads source from src, applies the sync rules to its value,
+// and diffs the result with secret name at the destination, which may not
+// exist yet. Nothing is written.
+func previewSync(ctx context.Context, src SecretStore, source string, dest *aws.Client, name string, rules secretvalue.SyncRules, schemas *secretvalue.Schemas) tea.Cmd {
+	return func() tea.Msg {
+		msg := syncPreviewMsg{source: source, name: name}
+		value, err := src.GetSecret(ctx, source)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.value, msg.mappings, msg.err = rules.Transform(source, *value)
+		if msg.err != nil {
+			return msg
+		}
+		if msg.err = schemas.Validate(name, msg.value); msg.err != nil {
+			return msg
+		}
+
+		details, err := dest.DescribeSecret(ctx, name)
+		if aws.ClassifyError(err) == aws.ErrorNotFound {
+			msg.diffs = secretvalue.DiffFields(nil, compareFields(&msg.value))
+			return msg
+		}
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.exists = true
+		msg.owner = details.OwningService
+		if msg.owner != "" {
+			return msg
+		}
+		current, err := dest.GetSecret(ctx, name)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.diffs = secretvalue.DiffFields(compareFields(current), compareFields(&msg.value))
+		return msg
+	}
+}
+
+// writeSync writes a synced value to the destination, overwriting an
+// existing secret only when overwrite is set
+func writeSync(ctx context.Context, dest *aws.Client, label, source string, secretCopy *aws.SecretCopy, overwrite bool) tea.Cmd {
+	return func() tea.Msg {
+		created, err := dest.WriteSecretCopy(ctx, secretCopy, overwrite)
+		return secretSyncedMsg{source: source, name: secretCopy.Name, label: label, created: created, err: err}
+	}
+}
+
+// syncNotes describes how the sync rule maps a value's keys, for the
+// preview. Keys synced unchanged aren't listed.
+func syncNotes(rule secretvalue.SyncRule, matched bool, mappings []secretvalue.KeyMapping) []string {
+	if !matched {
+		return []string{"No sync_rules match, so the value is written as it is"}
+	}
+	notes := []string{"Keys mapped by the sync rule for " + rule.Pattern + ":"}
+	for _, mapping := range mappings {
+		switch {
+		case mapping.From == "":
+			notes = append(notes, "  "+mapping.To+" set by the rule")
+		case mapping.To == "":
+			notes = append(notes, "  "+mapping.From+" left out")
+		case mapping.From != mapping.To:
+			notes = append(notes, "  "+mapping.From+" → "+mapping.To)
+		}
+	}
+	if len(notes) == 1 {
+		notes[0] = "The sync rule for " + rule.Pattern + " leaves every key as it is"
+	}
+	return notes
+}
+
+// sourceLabel says where the open secret is, e.g. "staging (eu-west-2)" or
+// "Azure Key Vault myvault"
+func (m Model) sourceLabel() string {
+	if m.awsBackend() {
+		return m.currentProfile + " (" + m.currentRegion + ")"
+	}
+	return m.backendName + " " + m.currentProfile
+}
+
+// startSync opens the profile selector for the account the open secret is
+// synced to
+func (m Model) startSync() (tea.Model, tea.Cmd) {
+	if m.grid.SelectedSecret() == nil || m.awsClient == nil {
+		return m, nil
+	}
+	if m.demoData() {
+		m.errorMessage = "Syncing writes to an AWS account, the demo data can only be browsed"
+		return m, nil
+	}
+	profiles, err := aws.GetAvailableProfiles()
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load profiles: %v", err)
+		return m, nil
+	}
+	if len(profiles) == 0 {
+		m.statusMessage = noProfilesMessage
+		return m, clearStatusAfter(8 * time.Second)
+	}
+	m.syncTo = syncState{}
+	m.openScreen(ScreenSyncProfile, newProfileScreen(m.summarizeProfiles(profiles), m.currentProfile), ScreenSecretDetail)
+	return m, nil
+}
+
+// chooseSyncProfile asks for the destination's region next
+func (m Model) chooseSyncProfile(profile string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if profile == "" {
+		return m, nil
+	}
+	m.syncTo = syncState{profile: profile}
+	regions, region := commonRegions(), aws.GetDefaultRegion()
+	if m.awsBackend() {
+		region = m.currentRegion
+		if profile == m.currentProfile {
+			regions = m.knownRegions()
+		}
+	}
+	m.openScreen(ScreenSyncRegion, newRegionScreen(regions, region), ScreenSecretDetail)
+	return m, nil
+}
+
+// chooseSyncRegion reaches the destination: the current account needs no
+// new sign-in, another profile does
+func (m Model) chooseSyncRegion(region string) (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	profile := m.syncTo.profile
+	if region == "" || profile == "" {
+		m.syncTo = syncState{}
+		return m, nil
+	}
+	if client, ok := m.awsClient.(*aws.Client); ok && profile == m.currentProfile {
+		return m.syncWith(client.WithRegion(region), profile+" ("+region+")")
+	}
+	return m.signInSecond(secondForSync, profile, region)
+}
+
+// syncWith asks for the name of the secret to sync to, suggesting the
+// source's own
+func (m Model) syncWith(client *aws.Client, label string) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil {
+		m.syncTo = syncState{}
+		return m, nil
+	}
+	m.syncTo.client = client
+	m.syncTo.label = label
+	prompt := newTextScreen("Sync "+secret.Name+" to "+label, "Destination secret name:", "prod/app/db")
+	prompt.input.SetValue(secret.Name)
+	m.openScreen(ScreenSyncName, prompt, ScreenSecretDetail)
+	return m, nil
+}
+
+// previewSyncTo previews syncing the open secret to the secret named
+func (m Model) previewSyncTo(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	secret := m.grid.SelectedSecret()
+	if secret == nil || m.syncTo.client == nil {
+		return m, nil
+	}
+	if name == "" {
+		return m.updateScreen(promptErrorMsg{err: fmt.Errorf("enter the name of the secret to sync to")})
+	}
+	if m.awsBackend() && name == secret.Name && m.syncTo.client.GetProfile() == m.currentProfile && m.syncTo.client.GetRegion() == m.currentRegion {
+		return m.updateScreen(promptErrorMsg{err: fmt.Errorf("that is the secret being synced, enter another name")})
+	}
+	m.closeScreen()
+	m.syncTo.name = name
+	ctx := m.startLoad("Previewing " + name + " in " + m.syncTo.label + "…")
+	return m, previewSync(ctx, m.awsClient, secret.Name, m.syncTo.client, name, m.syncRules, m.schemas)
+}
+
+// showSyncPreview shows the destination's value before and after the sync,
+// with how the keys were mapped
+func (m Model) showSyncPreview(msg syncPreviewMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	if msg.err != nil {
+		if m.syncTo.client != nil {
+			m.showErrorFor(m.syncTo.client.GetProfile(), m.syncTo.client.GetRegion(), "Failed to preview syncing to "+msg.name, msg.err)
+		}
+		m.syncTo = syncState{}
+		return m, nil
+	}
+	m.recordAudit("Read the value of "+msg.source+" to sync it", nil)
+	if msg.owner != "" {
+		service := models.OwningServiceName(msg.owner)
+		m.errorMessage = fmt.Sprintf("%s in %s is managed by %s and can only be changed through %s, so it can't be synced to", msg.name, m.syncTo.label, service, service)
+		m.syncTo = syncState{}
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.syncTo.value = msg.value
+	m.syncTo.exists = msg.exists
+
+	labelA := fmt.Sprintf("%s in %s, as it is now", msg.name, m.syncTo.label)
+	if !msg.exists {
+		labelA = fmt.Sprintf("%s in %s, which will be created", msg.name, m.syncTo.label)
+	}
+	labelB := fmt.Sprintf("After syncing %s from %s", msg.source, m.sourceLabel())
+	diff := components.NewValueDiff("Sync preview: nothing is written until you press enter", labelA, labelB, msg.diffs)
+	rule, matched := m.syncRules.Rule(msg.source)
+	diff.SetNotes(syncNotes(rule, matched, msg.mappings))
+	m.openScreen(ScreenSyncPreview, newSyncPreviewScreen(diff), ScreenSecretDetail)
+	return m, nil
+}
+
+// confirmSync writes a new secret straight away, and asks before
+// overwriting an existing one
+func (m Model) confirmSync() (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	if !m.syncTo.exists {
+		return m.writeSyncedSecret(false)
+	}
+	intro := fmt.Sprintf("The value of this secret in %s will be replaced by a new version, as previewed:", m.syncTo.label)
+	items := []components.SummaryItem{{Name: m.syncTo.name}}
+	m.openDestructiveConfirm(ScreenSyncConfirm, "Overwrite in "+m.syncTo.label+"?", intro, items, m.syncTo.name, ScreenSecretDetail)
+	return m, nil
+}
+
+// writeSyncedSecret writes the previewed value. A secret created is
+// described as synced from the source; an existing one keeps its
+// description and tags.
+func (m Model) writeSyncedSecret(overwrite bool) (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil || m.syncTo.client == nil || m.syncTo.name == "" {
+		m.syncTo = syncState{}
+		return m, nil
+	}
+	secretCopy := &aws.SecretCopy{Name: m.syncTo.name, Value: m.syncTo.value}
+	if !overwrite {
+		secretCopy.Description = "Synced from " + secret.Name + " in " + m.sourceLabel()
+	}
+	ctx := m.startLoad("Syncing " + secret.Name + " to " + m.syncTo.name + " in " + m.syncTo.label + "…")
+	return m, writeSync(ctx, m.syncTo.client, m.syncTo.label, secret.Name, secretCopy, overwrite)
+}
+
+// cancelSync leaves the sync without writing anything
+func (m Model) cancelSync() (tea.Model, tea.Cmd) {
+	m.closeScreen()
+	name := m.syncTo.name
+	m.syncTo = syncState{}
+	if name == "" {
+		return m, nil
+	}
+	m.statusMessage = "Nothing was written to " + name
+	return m, clearStatusAfter(3 * time.Second)
+}
+
+// showSecretSynced reports the result of a sync and drops the destination
+// client
+func (m Model) showSecretSynced(msg secretSyncedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	dest := copyDestination{client: m.syncTo.client, label: m.syncTo.label}
+	m.syncTo = syncState{}
+	if msg.err != nil {
+		m.showError("Failed to sync "+msg.source+" to "+msg.name, msg.err)
+		m.logOperation(fmt.Sprintf("Failed to sync %s to %s in %s", msg.source, msg.name, msg.label), msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	if msg.created {
+		m.statusMessage = fmt.Sprintf("Created %s in %s from %s", msg.name, msg.label, msg.source)
+	} else {
+		m.statusMessage = fmt.Sprintf("Updated %s in %s from %s", msg.name, msg.label, msg.source)
+		m.pushUndo(undoEntry{kind: undoOverwrite, names: []string{msg.name}, dest: dest})
+	}
+	m.logOperation(fmt.Sprintf("Synced %s from %s to %s in %s", msg.source, m.sourceLabel(), msg.name, msg.label), nil)
+	return m, clearStatusAfter(4 * time.Second)
+}
@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/aws"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/secretvalue"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// templateDraft is a secret being created from a template: the template
+// picked, the name given, the values typed so far and, once every field is
+// filled in, the value to write
+type templateDraft struct {
+	template secretvalue.Template
+	name     string
+	field    int // Index into template.Asked() of the field being asked for
+	values   map[string]string
+	value    models.SecretValue
+}
+
+// templateCreatedMsg reports creating a secret from a template. rotationErr
+// is set when the secret was created but rotation couldn't be turned on.
+type templateCreatedMsg struct {
+	template    string
+	name        string
+	err         error
+	rotationErr error
+}
+
+// createFromTemplate creates the secret, then turns on the template's
+// rotation. An existing secret is never replaced.
+func createFromTemplate(ctx context.Context, client *aws.Client, draft templateDraft) tea.Cmd {
+	return func() tea.Msg {
+		msg := templateCreatedMsg{template: draft.template.Name, name: draft.name}
+		secretCopy := &aws.SecretCopy{
+			Name:        draft.name,
+			Description: draft.template.Description,
+			Tags:        draft.template.Tags,
+			Value:       draft.value,
+		}
+		if _, msg.err = client.WriteSecretCopy(ctx, secretCopy, false); msg.err != nil {
+			return msg
+		}
+		if rotation := draft.template.Rotation; rotation != nil {
+			msg.rotationErr = client.EnableRotation(ctx, draft.name, rotation.Lambda, rotation.Days)
+		}
+		return msg
+	}
+}
+
+// startTemplateCreate lists the configured templates to create a secret
+// from
+func (m Model) startTemplateCreate() (tea.Model, tea.Cmd) {
+	if len(m.templates) == 0 {
+		m.statusMessage = "Add templates to config.yaml to create secrets from them"
+		return m, clearStatusAfter(4 * time.Second)
+	}
+	if _, ok := m.awsClient.(*aws.Client); !ok {
+		m.errorMessage = "Creating a secret needs an AWS account to create it in"
+		return m, nil
+	}
+	names := make([]string, len(m.templates))
+	for i, template := range m.templates {
+		names[i] = template.Name
+	}
+	m.creating = templateDraft{}
+	m.openScreen(ScreenTemplatePick, newPickerScreen("Create a secret from a template", names, ""), ScreenSecretList)
+	return m, nil
+}
+
+// chooseTemplate asks for the new secret's name
+func (m Model) chooseTemplate(name string) (tea.Model, tea.Cmd) {
+	for _, template := range m.templates {
+		if template.Name == name {
+			m.creating = templateDraft{template: template, values: make(map[string]string)}
+			prompt := newTextScreen("New "+template.Name+" secret", "Secret name:", "prod/app/db")
+			if filter := m.grid.GetFilterQuery(); strings.HasSuffix(filter, "/") {
+				prompt.input.SetValue(filter)
+			}
+			m.openScreen(ScreenTemplateName, prompt, ScreenSecretList)
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// nameTemplateSecret records the name and asks for the first field
+func (m Model) nameTemplateSecret(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return m.updateScreen(promptErrorMsg{err: errors.New("enter a name for the new secret")})
+	}
+	m.creating.name = name
+	m.closeScreen()
+	return m.askTemplateField()
+}
+
+// askTemplateField prompts for the next field, or confirms the value once
+// every field has been filled in
+func (m Model) askTemplateField() (tea.Model, tea.Cmd) {
+	asked := m.creating.template.Asked()
+	if m.creating.field >= len(asked) {
+		return m.confirmTemplateSecret()
+	}
+	field := asked[m.creating.field]
+	instruction := field.Key + ":"
+	switch {
+	case field.Optional:
+		instruction = field.Key + " (optional):"
+	case field.Type == "number" || field.Type == "boolean":
+		instruction = field.Key + " (" + field.Type + "):"
+	}
+	title := fmt.Sprintf("New %s secret %s (%d/%d)", m.creating.template.Name, m.creating.name, m.creating.field+1, len(asked))
+	prompt := newTextScreen(title, instruction, "")
+	prompt.input.SetValue(field.Default)
+	if field.Secret {
+		prompt.input.SetMasked()
+	}
+	m.openScreen(ScreenTemplateField, prompt, ScreenSecretList)
+	return m, nil
+}
+
+// fillTemplateField records a field's value and moves on to the next
+func (m Model) fillTemplateField(value string) (tea.Model, tea.Cmd) {
+	asked := m.creating.template.Asked()
+	if m.creating.field >= len(asked) {
+		return m, nil
+	}
+	field := asked[m.creating.field]
+	// Each field is checked as it is typed, rather than after the last one
+	check := secretvalue.Template{Fields: []secretvalue.TemplateField{field}}
+	if _, err := check.Build(map[string]string{field.Key: value}); err != nil {
+		return m.updateScreen(promptErrorMsg{err: err})
+	}
+	m.creating.values[field.Key] = value
+	m.creating.field++
+	m.closeScreen()
+	return m.askTemplateField()
+}
+
+// confirmTemplateSecret builds the value, generating fields that are, and
+// asks before creating the secret
+func (m Model) confirmTemplateSecret() (tea.Model, tea.Cmd) {
+	draft := m.creating
+	value, err := draft.template.Build(draft.values)
+	if err == nil {
+		err = m.schemas.Validate(draft.name, value)
+	}
+	if err != nil {
+		m.creating = templateDraft{}
+		m.showError("Can't create "+draft.name, err)
+		return m, nil
+	}
+	m.creating.value = value
+
+	var items []components.SummaryItem
+	for _, field := range draft.template.Fields {
+		detail := draft.values[field.Key]
+		switch {
+		case field.Generate > 0:
+			detail = fmt.Sprintf("%d generated characters", field.Generate)
+		case field.Secret && detail != "":
+			detail = maskedValue
+		case detail == "":
+			detail = "left out"
+		}
+		items = append(items, components.SummaryItem{Name: field.Key, Status: components.ItemPending, Detail: detail})
+	}
+	for _, key := range slices.Sorted(maps.Keys(draft.template.Tags)) {
+		items = append(items, components.SummaryItem{Name: "tag " + key, Status: components.ItemPending, Detail: draft.template.Tags[key]})
+	}
+	if rotation := draft.template.Rotation; rotation != nil {
+		items = append(items, components.SummaryItem{Name: "rotation", Status: components.ItemPending,
+			Detail: fmt.Sprintf("every %d days by %s", rotation.Days, rotation.Lambda)})
+	}
+	intro := fmt.Sprintf("Create %s in %s from the %s template?", draft.name, m.currentRegion, draft.template.Name)
+	m.openScreen(ScreenTemplateConfirm, newConfirmScreen("Create "+draft.name, intro, items), ScreenSecretList)
+	return m, nil
+}
+
+// writeTemplateSecret creates the secret once confirmed
+func (m Model) writeTemplateSecret() (tea.Model, tea.Cmd) {
+	client, ok := m.awsClient.(*aws.Client)
+	if !ok || m.creating.name == "" {
+		return m, nil
+	}
+	ctx := m.startLoad("Creating " + m.creating.name + "…")
+	return m, createFromTemplate(ctx, client, m.creating)
+}
+
+// showTemplateCreated reports the secret created, and lists it
+func (m Model) showTemplateCreated(msg templateCreatedMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	m.creating = templateDraft{}
+	if msg.err != nil {
+		m.showError("Failed to create "+msg.name, msg.err)
+		m.logOperation(fmt.Sprintf("Failed to create %s from template %s", msg.name, msg.template), msg.err)
+		return m, nil
+	}
+	m.errorMessage = ""
+	m.statusMessage = fmt.Sprintf("Created %s from the %s template in %s", msg.name, msg.template, m.currentRegion)
+	m.logOperation(fmt.Sprintf("Created %s from template %s", msg.name, msg.template), msg.rotationErr)
+	if msg.rotationErr != nil {
+		m.showError("Created "+msg.name+", but rotation isn't on", msg.rotationErr)
+	}
+	m, refresh := m.refreshSecrets("Listing " + msg.name + "…")
+	return m, tea.Batch(refresh, clearStatusAfter(4*time.Second))
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+)
+
+// terraformResourceType is the Terraform resource a secret is imported as
+const terraformResourceType = "aws_secretsmanager_secret"
+
+// terraformIdentifier matches names that can be used unquoted in HCL
+var terraformIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// terraformAttribute is a single-line attribute in a generated block
+type terraformAttribute struct {
+	name  string
+	value string
+}
+
+// terraformResourceName turns a secret name into a resource name, such as
+// app_prod_db for app/prod/db
+func terraformResourceName(secretName string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(secretName) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	name := strings.Trim(b.String(), "_")
+	for strings.Contains(name, "__") {
+		name = strings.ReplaceAll(name, "__", "_")
+	}
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "secret_" + name
+	}
+	return strings.TrimSuffix(name, "_")
+}
+
+// terraformString quotes s as an HCL string, escaping template sequences so
+// they are kept literally
+func terraformString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// writeTerraformAttributes writes attributes with their equals signs
+// aligned, as terraform fmt does
+func writeTerraformAttributes(b *strings.Builder, indent string, attributes []terraformAttribute) {
+	width := 0
+	for _, attribute := range attributes {
+		width = max(width, len(attribute.name))
+	}
+	for _, attribute := range attributes {
+		fmt.Fprintf(b, "%s%-*s = %s\n", indent, width, attribute.name, attribute.value)
+	}
+}
+
+// terraformImport generates a Terraform import block, the equivalent
+// terraform import command and a resource skeleton for a secret, to bring a
+// secret created by hand under Terraform. details may be nil if they haven't
+// loaded; the KMS key is then left out.
+func terraformImport(secret models.Secret, details *models.SecretDetails) string {
+	resource := terraformResourceType + "." + terraformResourceName(secret.Name)
+	id := secret.ARN
+	if id == "" {
+		id = secret.Name
+	}
+
+	var b strings.Builder
+	b.WriteString("# Terraform 1.5 or later imports the secret on the next plan and apply\n")
+	b.WriteString("import {\n")
+	writeTerraformAttributes(&b, "  ", []terraformAttribute{
+		{name: "to", value: resource},
+		{name: "id", value: terraformString(id)},
+	})
+	b.WriteString("}\n\n")
+	b.WriteString("# With older versions, run instead:\n")
+	fmt.Fprintf(&b, "# terraform import %s '%s'\n\n", resource, strings.ReplaceAll(id, "'", `'\''`))
+
+	if details != nil && details.OwningService != "" {
+		fmt.Fprintf(&b, "# This secret is managed by %s, which may undo changes made by Terraform\n", details.OwningService)
+	}
+	fmt.Fprintf(&b, "resource %q %q {\n", terraformResourceType, terraformResourceName(secret.Name))
+	attributes := []terraformAttribute{{name: "name", value: terraformString(secret.Name)}}
+	if secret.Description != "" {
+		attributes = append(attributes, terraformAttribute{name: "description", value: terraformString(secret.Description)})
+	}
+	if details != nil && details.KmsKeyID != "" {
+		attributes = append(attributes, terraformAttribute{name: "kms_key_id", value: terraformString(details.KmsKeyID)})
+	}
+	writeTerraformAttributes(&b, "  ", attributes)
+
+	if len(secret.Tags) > 0 {
+		keys := make([]string, 0, len(secret.Tags))
+		for key := range secret.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		tags := make([]terraformAttribute, len(keys))
+		for i, key := range keys {
+			name := key
+			if !terraformIdentifier.MatchString(key) {
+				name = terraformString(key)
+			}
+			tags[i] = terraformAttribute{name: name, value: terraformString(secret.Tags[key])}
+		}
+		b.WriteString("\n  tags = {\n")
+		writeTerraformAttributes(&b, "    ", tags)
+		b.WriteString("  }\n")
+	}
+
+	if details != nil && details.RotationEnabled {
+		b.WriteString("\n  # Rotation is enabled: import it as an aws_secretsmanager_secret_rotation too\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
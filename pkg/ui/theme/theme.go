@@ -0,0 +1,130 @@
+// Package theme defines the color palettes used to render the UI.
+package theme
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a named color palette
+type Theme struct {
+	Name      string
+	Primary   lipgloss.Color // Titles, borders, selected items
+	Secondary lipgloss.Color // Labels and secondary highlights
+	Success   lipgloss.Color // Success messages
+	Error     lipgloss.Color // Error messages
+	Subtle    lipgloss.Color // Hints, dates, muted text
+	Text      lipgloss.Color // Regular body text
+}
+
+// Built-in themes
+var (
+	Dark = Theme{
+		Name:      "dark",
+		Primary:   lipgloss.Color("205"), // Pink
+		Secondary: lipgloss.Color("170"), // Purple
+		Success:   lipgloss.Color("42"),  // Green
+		Error:     lipgloss.Color("196"), // Red
+		Subtle:    lipgloss.Color("241"), // Gray
+		Text:      lipgloss.Color("252"), // Light gray
+	}
+
+	Light = Theme{
+		Name:      "light",
+		Primary:   lipgloss.Color("125"), // Deep pink
+		Secondary: lipgloss.Color("91"),  // Dark purple
+		Success:   lipgloss.Color("28"),  // Dark green
+		Error:     lipgloss.Color("160"), // Dark red
+		Subtle:    lipgloss.Color("244"), // Mid gray
+		Text:      lipgloss.Color("235"), // Near black
+	}
+
+	HighContrast = Theme{
+		Name:      "high-contrast",
+		Primary:   lipgloss.Color("11"), // Bright yellow
+		Secondary: lipgloss.Color("14"), // Bright cyan
+		Success:   lipgloss.Color("10"), // Bright green
+		Error:     lipgloss.Color("9"),  // Bright red
+		Subtle:    lipgloss.Color("7"),  // White
+		Text:      lipgloss.Color("15"), // Bright white
+	}
+)
+
+var builtins = map[string]Theme{
+	Dark.Name:         Dark,
+	Light.Name:        Light,
+	HighContrast.Name: HighContrast,
+}
+
+// active is the theme components render with
+var active = Dark
+
+// Current returns the active theme
+func Current() Theme {
+	return active
+}
+
+// Set makes t the active theme
+func Set(t Theme) {
+	active = t
+}
+
+// Names returns the names of the built-in themes
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Resolve builds a theme from a built-in name (empty means dark) and a map of
+// hex color overrides keyed by role: primary, secondary, success, error,
+// subtle, text
+func Resolve(name string, overrides map[string]string) (Theme, error) {
+	if name == "" {
+		name = Dark.Name
+	}
+
+	t, ok := builtins[name]
+	if !ok {
+		return Dark, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	for role, value := range overrides {
+		if !hexColorPattern.MatchString(value) {
+			return Dark, fmt.Errorf("invalid color %q for %q: expected a hex color like #ff5f87", value, role)
+		}
+
+		color := lipgloss.Color(value)
+		switch strings.ToLower(role) {
+		case "primary":
+			t.Primary = color
+		case "secondary":
+			t.Secondary = color
+		case "success":
+			t.Success = color
+		case "error":
+			t.Error = color
+		case "subtle":
+			t.Subtle = color
+		case "text":
+			t.Text = color
+		default:
+			return Dark, fmt.Errorf("unknown color role %q", role)
+		}
+	}
+
+	if len(overrides) > 0 {
+		t.Name += " (custom)"
+	}
+
+	return t, nil
+}
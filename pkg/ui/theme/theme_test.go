@@ -0,0 +1,44 @@
+package theme
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolve(t *testing.T) {
+	t.Run("defaults to dark", func(t *testing.T) {
+		got, err := Resolve("", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != Dark {
+			t.Fatalf("expected dark theme, got %+v", got)
+		}
+	})
+
+	t.Run("applies hex overrides", func(t *testing.T) {
+		got, err := Resolve("light", map[string]string{"primary": "#ff5f87", "Subtle": "#abc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.Primary != lipgloss.Color("#ff5f87") || got.Subtle != lipgloss.Color("#abc") {
+			t.Fatalf("expected overrides to apply, got %+v", got)
+		}
+		if got.Error != Light.Error {
+			t.Fatalf("expected non-overridden colors to come from the base theme, got %q", got.Error)
+		}
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		if _, err := Resolve("solarized", nil); err == nil {
+			t.Fatal("expected unknown theme to be rejected")
+		}
+		if _, err := Resolve("dark", map[string]string{"primary": "205"}); err == nil {
+			t.Fatal("expected non-hex color to be rejected")
+		}
+		if _, err := Resolve("dark", map[string]string{"background": "#000000"}); err == nil {
+			t.Fatal("expected unknown role to be rejected")
+		}
+	})
+}
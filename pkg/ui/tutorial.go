@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tutorialStep is one prompt of the --tutorial walkthrough. done is checked
+// after every update and moves the tutorial on once it returns true.
+type tutorialStep struct {
+	title  string
+	prompt string
+	done   func(m Model, msg tea.Msg) bool
+}
+
+// tutorialSteps walks through the core flows in the order a new user needs them
+var tutorialSteps = []tutorialStep{
+	{
+		title:  "Choose a profile",
+		prompt: "Press p to open the AWS profile selector.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.currentScreen == ScreenProfileSelector
+		},
+	},
+	{
+		title:  "Choose a profile",
+		prompt: "Move with ↑/↓ and press enter to switch, or esc to keep the current profile.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.currentScreen == ScreenSecretList
+		},
+	},
+	{
+		title:  "Filter secrets",
+		prompt: "Press / and type part of a secret name to narrow the list.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.grid.GetFilterQuery() != ""
+		},
+	},
+	{
+		title:  "Open a secret",
+		prompt: "Move to a secret and press enter to see its details. Press esc first if you want to leave the filter.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.currentScreen == ScreenSecretDetail
+		},
+	},
+	{
+		title:  "Reveal the value",
+		prompt: "Press v to decrypt the value, then r to reveal it. Secretsrc only fetches a value when you ask for it and masks it again after a short time.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.secretLoaded() && m.valueRevealed
+		},
+	},
+	{
+		title:  "Copy safely",
+		prompt: "Press c to copy the value, or k to copy a single JSON field. The clipboard keeps it after you quit, so clear it when you're done.",
+		done: func(m Model, msg tea.Msg) bool {
+			copied, ok := msg.(clipboardCopiedMsg)
+			return ok && copied.success
+		},
+	},
+	{
+		title:  "All done",
+		prompt: "That's the core flow. Press ? at any time to see more keys, or H for every key binding.",
+		done: func(m Model, msg tea.Msg) bool {
+			return m.fullHelp || m.showHelp
+		},
+	},
+}
+
+// tutorial tracks progress through tutorialSteps
+type tutorial struct {
+	active bool
+	step   int
+}
+
+// WithTutorial starts the step-by-step walkthrough shown above the footer
+func (m Model) WithTutorial() Model {
+	m.tutorial = tutorial{active: true}
+	return m
+}
+
+// handleTutorialKeys handles the keys that control the tutorial itself. It
+// reports false for every other key so the current screen can handle it.
+func (m Model) handleTutorialKeys(msg tea.KeyMsg) (Model, bool) {
+	switch msg.String() {
+	case "ctrl+n":
+		m = m.advanceTutorial()
+		return m, true
+	case "ctrl+x":
+		m.tutorial = tutorial{}
+		m.resizeGrid()
+		return m, true
+	}
+	return m, false
+}
+
+// updateTutorial moves the tutorial on when the current step's goal was
+// reached by msg
+func (m Model) updateTutorial(msg tea.Msg) Model {
+	if !m.tutorial.active {
+		return m
+	}
+	if tutorialSteps[m.tutorial.step].done(m, msg) {
+		m = m.advanceTutorial()
+	}
+	return m
+}
+
+// advanceTutorial moves to the next step, ending the tutorial after the last
+func (m Model) advanceTutorial() Model {
+	m.tutorial.step++
+	if m.tutorial.step >= len(tutorialSteps) {
+		m.tutorial = tutorial{}
+	}
+	// Step prompts differ in height, so the grid has to be resized
+	m.resizeGrid()
+	return m
+}
+
+// resizeGrid fits the grid to the space left by the header and footer,
+// and by the detail panel when it is shown. A narrow terminal gets the
+// compact list of names.
+func (m *Model) resizeGrid() {
+	if m.width > 0 && m.height > 0 {
+		m.grid.SetCompact(m.narrow())
+		width, height := m.contentViewportSize()
+		m.grid.SetSize(m.gridWidth(width), height)
+	}
+}
+
+// viewTutorial renders the current tutorial prompt
+func (m Model) viewTutorial() string {
+	if !m.tutorial.active {
+		return ""
+	}
+
+	t := theme.Current()
+	step := tutorialSteps[m.tutorial.step]
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Primary)
+
+	hintStyle := lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Primary).
+		Padding(0, 1)
+
+	// contentViewportSize depends on the footer this box is part of, so the
+	// width is worked out from the window directly, leaving room for the
+	// app padding and this box's border
+	if width := m.width - appBorderWidth - 2*appHorizontalPadding - 2; width > 0 {
+		boxStyle = boxStyle.Width(width)
+	}
+
+	content := titleStyle.Render(fmt.Sprintf("Tutorial %d/%d: %s", m.tutorial.step+1, len(tutorialSteps), step.title)) + "\n" +
+		lipgloss.NewStyle().Foreground(t.Text).Render(step.prompt) + "\n" +
+		hintStyle.Render("ctrl+n: skip step | ctrl+x: end tutorial")
+
+	return boxStyle.Render(content)
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// undoKind is a destructive action that can be undone
+type undoKind int
+
+const (
+	undoDelete undoKind = iota
+	undoUntag
+	undoOverwrite
+)
+
+// undoEntry is a destructive action done this session, with what is needed
+// to reverse it
+type undoEntry struct {
+	id    int
+	kind  undoKind
+	names []string
+	at    time.Time
+	// key and values are the removed tag and its value on each secret, for
+	// undoUntag
+	key    string
+	values map[string]string
+	// dest is where a copy overwrote a secret, for undoOverwrite. Its client
+	// is kept only to restore that secret.
+	dest copyDestination
+}
+
+// undoStack is the actions that can still be undone, oldest first
+type undoStack struct {
+	entries []undoEntry
+	nextID  int
+}
+
+// undoneMsg reports the result of undo. failed lists the secrets it
+// couldn't be undone for.
+type undoneMsg struct {
+	entry  undoEntry
+	failed []string
+	err    error
+}
+
+// push adds an action to the stack. Actions on no secrets are left out.
+func (s *undoStack) push(entry undoEntry) {
+	if len(entry.names) == 0 {
+		return
+	}
+	s.nextID++
+	entry.id = s.nextID
+	entry.at = time.Now()
+	s.entries = append(s.entries, entry)
+}
+
+// pushUndo adds an action to the undo stack, unless a dry run skipped its
+// writes: undoing what was never done would change the secrets for real
+func (m *Model) pushUndo(entry undoEntry) {
+	if m.dryRunPending() {
+		return
+	}
+	m.undo.push(entry)
+}
+
+// describe returns the menu title and description of undoing the action
+func (e undoEntry) describe() (title, description string) {
+	names := strings.Join(e.names, ", ")
+	at := e.at.Format("15:04")
+	switch e.kind {
+	case undoDelete:
+		return fmt.Sprintf("Restore %s", countSecrets(e.names)),
+			fmt.Sprintf("Cancel the deletion scheduled at %s: %s", at, names)
+	case undoUntag:
+		return fmt.Sprintf("Re-add the tag %s to %s", e.key, countSecrets(e.names)),
+			fmt.Sprintf("Removed at %s: %s", at, names)
+	default:
+		return fmt.Sprintf("Restore the previous value of %s in %s", countSecrets(e.names), e.dest.label),
+			fmt.Sprintf("Overwritten at %s: %s", at, names)
+	}
+}
+
+// countSecrets names a single secret, or says how many there are
+func countSecrets(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return fmt.Sprintf("%d secrets", len(names))
+}
+
+// undoOne reverses the action for one secret
+func (e undoEntry) undoOne(ctx context.Context, client SecretStore, name string) error {
+	switch e.kind {
+	case undoDelete:
+		return client.RestoreSecret(ctx, name)
+	case undoUntag:
+		return client.TagSecret(ctx, name, e.key, e.values[name])
+	default:
+		return e.dest.client.RestorePreviousVersion(ctx, name)
+	}
+}
+
+// undo reverses entry for each of its secrets. A failure doesn't stop the
+// others.
+func undo(ctx context.Context, client SecretStore, entry undoEntry) tea.Cmd {
+	return func() tea.Msg {
+		if client == nil && entry.kind != undoOverwrite {
+			return undoneMsg{entry: entry, failed: entry.names, err: fmt.Errorf("AWS client not initialized")}
+		}
+		var failed []string
+		var errs []error
+		for _, name := range entry.names {
+			if err := entry.undoOne(ctx, client, name); err != nil {
+				failed = append(failed, name)
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		return undoneMsg{entry: entry, failed: failed, err: errors.Join(errs...)}
+	}
+}
+
+// openUndo lists the actions that can be undone, newest first
+func (m Model) openUndo() (tea.Model, tea.Cmd) {
+	if len(m.undo.entries) == 0 {
+		m.statusMessage = "Nothing to undo this session"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	actions := make([]components.Action, 0, len(m.undo.entries))
+	for i := len(m.undo.entries) - 1; i >= 0; i-- {
+		title, description := m.undo.entries[i].describe()
+		actions = append(actions, components.Action{Key: strconv.Itoa(len(m.undo.entries) - i), Title: title, Description: description})
+	}
+	m.openScreen(ScreenUndo, newActionScreen("Undo", actions), ScreenSecretList)
+	return m, nil
+}
+
+// chooseUndo undoes the action picked from the undo menu, numbered from
+// the newest
+func (m Model) chooseUndo(key string) (tea.Model, tea.Cmd) {
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 1 || index > len(m.undo.entries) {
+		return m, nil
+	}
+	entry := m.undo.entries[len(m.undo.entries)-index]
+	title, _ := entry.describe()
+	return m, undo(m.startLoad(title+"…"), m.awsClient, entry)
+}
+
+// showUndone reports an undo. The action stays on the stack for the secrets
+// it failed on, so it can be tried again.
+func (m Model) showUndone(msg undoneMsg) (tea.Model, tea.Cmd) {
+	if cancelled(msg.err) {
+		return m, nil
+	}
+	m.loading = false
+	title, _ := msg.entry.describe()
+	for i, entry := range m.undo.entries {
+		if entry.id != msg.entry.id {
+			continue
+		}
+		if len(msg.failed) == 0 {
+			m.undo.entries = append(m.undo.entries[:i], m.undo.entries[i+1:]...)
+		} else {
+			m.undo.entries[i].names = msg.failed
+		}
+		break
+	}
+
+	if msg.err != nil {
+		m.logOperation(title, msg.err)
+		m.showError("Failed to undo", msg.err)
+	} else {
+		m.logOperation(title, nil)
+		m.errorMessage = ""
+		m.statusMessage = "Undone: " + title
+	}
+
+	var cmds []tea.Cmd
+	if msg.err == nil {
+		cmds = append(cmds, clearStatusAfter(4*time.Second))
+	}
+	if msg.entry.kind != undoOverwrite && len(msg.failed) < len(msg.entry.names) && m.inventory != nil {
+		// Restored secrets and tags show up in the list again
+		m.inventory.Reset()
+		m.currentPage = 0
+		cmds = append(cmds, loadSecrets(m.startLoad("Refreshing secrets…"), m.inventory, 0))
+	}
+	return m, tea.Batch(cmds...)
+}
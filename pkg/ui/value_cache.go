@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cachedValue is a secret value fetched this session and when
+type cachedValue struct {
+	value     models.SecretValue
+	fetchedAt time.Time
+}
+
+// valueCache keeps the values fetched this session in memory for ttl, so
+// viewing a secret again soon after doesn't fetch it again. A zero ttl, the
+// default, keeps nothing: values are cleared as soon as you navigate away.
+type valueCache struct {
+	ttl     time.Duration
+	entries map[string]cachedValue // Keyed by name, for the profile and region shown
+}
+
+// valueCacheExpiredMsg drops the values kept longer than the TTL
+type valueCacheExpiredMsg struct{}
+
+// parseValueCacheTTL reads the value_cache_ttl setting. Empty, "0" and
+// invalid values keep no values.
+func parseValueCacheTTL(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	return ttl
+}
+
+// get returns the value of the named secret, when it was fetched less than
+// the TTL ago
+func (c valueCache) get(name string, now time.Time) (cachedValue, bool) {
+	entry, ok := c.entries[name]
+	if !ok || now.Sub(entry.fetchedAt) >= c.ttl {
+		return cachedValue{}, false
+	}
+	return entry, true
+}
+
+// put keeps a value for the TTL, returning the command that drops it once
+// the TTL is up
+func (c *valueCache) put(name string, value models.SecretValue, now time.Time) tea.Cmd {
+	if c.ttl <= 0 || name == "" {
+		return nil
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]cachedValue)
+	}
+	c.entries[name] = cachedValue{value: value, fetchedAt: now}
+	return tea.Tick(c.ttl, func(time.Time) tea.Msg {
+		return valueCacheExpiredMsg{}
+	})
+}
+
+// expire drops the values kept longer than the TTL
+func (c *valueCache) expire(now time.Time) {
+	for name, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl {
+			delete(c.entries, name)
+		}
+	}
+}
+
+// purge drops every value, returning how many were kept
+func (c *valueCache) purge() int {
+	count := len(c.entries)
+	c.entries = nil
+	return count
+}
+
+// viewValue shows the selected secret's value, from the cache when it was
+// fetched less than the TTL ago, and fetches it otherwise
+func (m Model) viewValue() (tea.Model, tea.Cmd) {
+	secret := m.grid.SelectedSecret()
+	if secret == nil || m.secretLoaded() {
+		return m, nil
+	}
+	if entry, ok := m.values.get(secret.Name, time.Now()); ok {
+		m.showValue(entry.value)
+		m.recordAudit("Viewed the cached value of "+secret.Name, nil)
+		age := time.Since(entry.fetchedAt).Round(time.Second)
+		m.statusMessage = fmt.Sprintf("Cached value from %s ago; X clears cached values", age)
+		return m, tea.Batch(m.recordOpenValue(entry.value), clearStatusAfter(3*time.Second))
+	}
+	return m, loadSecretValue(m.startLoad("Decrypting secret…"), m.awsClient, secret.Name)
+}
+
+// showValue shows a value of the selected secret on the detail screen
+func (m *Model) showValue(value models.SecretValue) {
+	m.secretValue = value.String
+	m.secretBinary = value.Binary
+	m.secretFields = parseSecretFields(value.String)
+	m.errorMessage = ""
+}
+
+// purgeValues drops every cached value at once
+func (m Model) purgeValues() (tea.Model, tea.Cmd) {
+	if m.values.ttl <= 0 {
+		m.statusMessage = "No values are cached; set value_cache_ttl to keep them"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+	m.statusMessage = fmt.Sprintf("Cleared %d cached values", m.values.purge())
+	return m, clearStatusAfter(3 * time.Second)
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// valueViewsLoadedMsg carries the value history journal read at startup
+type valueViewsLoadedMsg struct {
+	views map[string]config.ValueView
+}
+
+// valueViewRecordedMsg reports a view recorded in the journal, with the
+// view it replaced
+type valueViewRecordedMsg struct {
+	arn      string
+	view     config.ValueView
+	previous config.ValueView
+	found    bool // Whether the value had been viewed before
+}
+
+// tracksValues reports whether viewed values are recorded in the journal.
+// Demo data is never recorded.
+func (m Model) tracksValues() bool {
+	return m.trackValues && !m.demoData()
+}
+
+// loadValueViews reads when each secret's value was last viewed
+func loadValueViews() tea.Cmd {
+	return func() tea.Msg {
+		views, err := config.LoadValueViews()
+		if err != nil {
+			logging.Debugf("ignoring value history: %v", err)
+		}
+		return valueViewsLoadedMsg{views: views}
+	}
+}
+
+// recordValueView records a hash of a viewed value in the journal
+func recordValueView(arn string, value models.SecretValue) tea.Cmd {
+	return func() tea.Msg {
+		view := config.ValueView{Hash: config.HashValue(value), ViewedAt: time.Now()}
+		previous, found, err := config.RecordValueView(arn, view.Hash, view.ViewedAt)
+		if err != nil {
+			logging.Debugf("failed to record value view: %v", err)
+		}
+		return valueViewRecordedMsg{arn: arn, view: view, previous: previous, found: found}
+	}
+}
+
+// recordOpenValue records the loaded value of the open secret, when value
+// history is on
+func (m Model) recordOpenValue(value models.SecretValue) tea.Cmd {
+	secret := m.grid.SelectedSecret()
+	if !m.tracksValues() || secret == nil || secret.ARN == "" {
+		return nil
+	}
+	return recordValueView(secret.ARN, value)
+}
+
+// showValueViews badges the secrets changed since their values were viewed
+func (m *Model) showValueViews(views map[string]config.ValueView) {
+	m.valueViews = views
+	viewedAt := make(map[string]time.Time, len(views))
+	for arn, view := range views {
+		viewedAt[arn] = view.ViewedAt
+	}
+	m.grid.SetValueViews(viewedAt)
+}
+
+// showValueViewRecorded says when the value differs from the one last
+// viewed, and clears the secret's changed badge
+func (m Model) showValueViewRecorded(msg valueViewRecordedMsg) (tea.Model, tea.Cmd) {
+	views := make(map[string]config.ValueView, len(m.valueViews)+1)
+	for arn, view := range m.valueViews {
+		views[arn] = view
+	}
+	views[msg.arn] = msg.view
+	m.showValueViews(views)
+
+	if !msg.found || msg.previous.Hash == msg.view.Hash {
+		return m, nil
+	}
+	m.statusMessage = "Value changed since you last viewed it on " + msg.previous.ViewedAt.Local().Format("January 2")
+	return m, clearStatusAfter(6 * time.Second)
+}
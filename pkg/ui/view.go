@@ -4,10 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/benjamingriff/secretsrc/pkg/inventory"
+	"github.com/benjamingriff/secretsrc/pkg/logging"
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	"github.com/benjamingriff/secretsrc/pkg/ui/theme"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// detailDateFormat is the timestamp format used on the detail screen
+const detailDateFormat = "Jan 2, 2006 3:04 PM"
+
 const (
 	appBorderWidth       = 2
 	appHorizontalPadding = 2
@@ -15,25 +23,22 @@ const (
 
 // View renders the model
 func (m Model) View() string {
+	if m.blanked {
+		return ""
+	}
 	if m.width == 0 || m.height == 0 {
 		return "Initializing..."
 	}
 
 	var content string
 
-	switch m.currentScreen {
-	case ScreenSecretList:
+	switch {
+	case m.screen != nil:
+		content = m.screen.View()
+	case m.currentScreen == ScreenSecretList:
 		content = m.viewSecretList()
-	case ScreenSecretDetail:
+	case m.currentScreen == ScreenSecretDetail:
 		content = m.viewSecretDetail()
-	case ScreenSecretFieldSelector:
-		content = m.viewSecretFieldSelector()
-	case ScreenProfileSelector:
-		content = m.viewProfileSelector()
-	case ScreenRegionSelector:
-		content = m.viewRegionSelector()
-	case ScreenMFAInput:
-		content = m.viewMFAInput()
 	default:
 		content = "Unknown screen"
 	}
@@ -59,11 +64,12 @@ func (m Model) View() string {
 		footer,
 	)
 
-	// Create a bordered style that fills the terminal
+	// Create a bordered style that fills the terminal. Its width includes
+	// the padding, so the content keeps its full width.
 	appStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("205")).
-		Width(contentWidth).
+		BorderForeground(theme.Current().Primary).
+		Width(contentWidth+appHorizontalPadding).
 		Height(maxInt(availableHeight+lipgloss.Height(header)+lipgloss.Height(footer), 0)).
 		Padding(0, 1)
 
@@ -71,8 +77,7 @@ func (m Model) View() string {
 }
 
 func (m Model) contentViewportSize() (int, int) {
-	innerWidth := maxInt(m.width-appBorderWidth-appHorizontalPadding, 0)
-	innerHeight := maxInt(m.height-appBorderWidth, 0)
+	innerWidth, innerHeight := m.innerSize()
 
 	headerHeight := lipgloss.Height(m.viewHeader())
 	footerHeight := lipgloss.Height(m.viewFooter())
@@ -84,21 +89,136 @@ func (m Model) contentViewportSize() (int, int) {
 // viewHeader renders the header
 func (m Model) viewHeader() string {
 	title := "Secret Src - AWS Secrets Manager TUI"
-	info := fmt.Sprintf("Profile: %s | Region: %s", m.currentProfile, m.currentRegion)
+	profile := m.currentProfile
+	if m.awsClient != nil && m.awsClient.UsesDefaultChain() {
+		profile = "(no profiles configured)"
+	}
+	info := fmt.Sprintf("Profile: %s | Region: %s", profile, m.currentRegion)
+	if !m.awsBackend() && !m.demoData() {
+		// The profile holds the vault's name
+		info = fmt.Sprintf("%s: %s", m.backendName, m.currentProfile)
+	}
+	if m.identity != nil && m.identity.Account != "" {
+		info += fmt.Sprintf(" | Account: %s", m.identity.Account)
+	}
+	if m.awsClient != nil && m.awsClient.GetEndpoint() != "" {
+		info += fmt.Sprintf(" | Endpoint: %s", m.awsClient.GetEndpoint())
+	}
+	if m.demoData() {
+		info += " | Demo data"
+	}
+	if m.dryRun != nil {
+		info += " | DRY RUN: nothing is changed"
+	}
+	if !m.cachedAt.IsZero() {
+		info += fmt.Sprintf(" | Cached from %s", m.cachedAt.Local().Format("Jan 2 15:04"))
+	}
+	if position := m.listPosition(); position != "" {
+		info += " | " + position
+	}
+	if m.allPages {
+		info += " | All pages loaded"
+	}
+	if m.watch.on {
+		info += fmt.Sprintf(" | Watching every %s", m.watch.interval)
+	}
+	if order := m.grid.SortOrder(); order != inventory.SortDefault {
+		info += fmt.Sprintf(" | Sort: %s", order.Label())
+	}
+	if marked := len(m.grid.MarkedNames()); marked > 0 {
+		info += fmt.Sprintf(" | Marked: %d", marked)
+	}
 
-	return fmt.Sprintf("%s\n%s",
+	infoStyle := StatusBarStyle
+	if m.narrow() {
+		title = "Secret Src"
+		// Wrap explicitly so the header height accounts for every line
+		if width := m.width - appBorderWidth - appHorizontalPadding; width > 0 {
+			infoStyle = infoStyle.Width(width)
+		}
+	}
+	header := fmt.Sprintf("%s\n%s",
 		HeaderStyle.Render(title),
-		StatusBarStyle.Render(info),
+		infoStyle.Render(info),
 	)
+	if m.identity != nil && m.identity.ARN != "" {
+		identityStyle := StatusBarStyle
+		// Cut long role ARNs off rather than letting them wrap under the header
+		if width := m.width - appBorderWidth - appHorizontalPadding; width > 0 {
+			identityStyle = identityStyle.MaxWidth(width)
+		}
+		header += "\n" + identityStyle.Render(fmt.Sprintf("Identity: %s (%s)", m.identity.ARN, m.identity.UserID))
+	}
+	if m.clockWarning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(theme.Current().Error).Bold(true)
+		// Wrap explicitly so the header height accounts for every line
+		if width := m.width - appBorderWidth - appHorizontalPadding; width > 0 {
+			warningStyle = warningStyle.Width(width)
+		}
+		header += "\n" + warningStyle.Render("⚠ "+m.clockWarning)
+	}
+	if m.configWarning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(theme.Current().Error)
+		if width := m.width - appBorderWidth - appHorizontalPadding; width > 0 {
+			warningStyle = warningStyle.Width(width)
+		}
+		header += "\n" + warningStyle.Render("⚠ "+m.configWarning)
+	}
+	return header
+}
+
+// listPosition says where the selected secret is among those loaded, such
+// as "Secret 37 of 412 (page 2/9)", where the page is the grid's screen.
+// Secrets on earlier AWS pages are counted, a + follows the total while
+// there are pages left to load, and the AWS page is named once there is
+// more than one. While a filter is applied the count is of its matches.
+func (m Model) listPosition() string {
+	selected := m.grid.SelectedSecret()
+	if selected == nil || m.showHelp {
+		return ""
+	}
+	screen, screens := m.grid.Screen()
+	page := fmt.Sprintf("page %d/%d", screen, screens)
+	if m.currentPage > 0 || m.hasNextPage() {
+		page += fmt.Sprintf(", AWS page %d", m.currentPage+1)
+	}
+
+	if m.grid.GetFilterQuery() != "" {
+		return fmt.Sprintf("Secret %d of %d matching (%s)", m.grid.SelectedIndex()+1, m.grid.Len(), page)
+	}
+
+	position, total := m.grid.SelectedIndex()+1, len(m.secrets)
+	if m.inventory != nil && !m.allPages {
+		if _, ok := m.inventory.Cached(m.currentPage); ok {
+			for i := 0; i < m.currentPage; i++ {
+				earlier, _ := m.inventory.Cached(i)
+				position += len(earlier.Secrets)
+			}
+			total = len(m.inventory.Loaded())
+		}
+	}
+	more := ""
+	if m.inventory != nil && !m.allPages && !m.inventory.AllLoaded() {
+		more = "+"
+	}
+	return fmt.Sprintf("Secret %d of %d%s (%s)", position, total, more, page)
 }
 
 // viewFooter renders the footer with help text and status
 func (m Model) viewFooter() string {
 	var parts []string
 
+	if m.tutorial.active {
+		parts = append(parts, m.viewTutorial())
+	}
+
+	if ops := m.viewOperations(); ops != "" {
+		parts = append(parts, ops)
+	}
+
 	// Show error if present
 	if m.errorMessage != "" {
-		parts = append(parts, ErrorStyle.Render(fmt.Sprintf("Error: %s", m.errorMessage)))
+		parts = append(parts, m.viewError())
 	}
 
 	// Show status message if present
@@ -108,44 +228,100 @@ func (m Model) viewFooter() string {
 
 	// Show loading indicator
 	if m.loading {
-		parts = append(parts, "Loading...")
+		parts = append(parts, m.loadingView())
 	}
 
-	// Show help based on current screen
+	if keys, ok := m.footerKeys(); ok {
+		parts = append(parts, m.viewFooterKeys(keys))
+	} else if help := m.footerHelp(); help != "" {
+		width, _ := m.innerSize()
+		parts = append(parts, HelpStyle.Render(strings.Join(wrapHints(help, width), "\n")))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// innerSize is the width and height inside the app border and padding
+func (m Model) innerSize() (int, int) {
+	return maxInt(m.width-appBorderWidth-appHorizontalPadding, 0), maxInt(m.height-appBorderWidth, 0)
+}
+
+// wrapHints breaks a footer help line into lines no wider than width,
+// between hints rather than within them
+func wrapHints(help string, width int) []string {
+	var lines []string
+	line := ""
+	for _, hint := range strings.Split(help, hintSeparator) {
+		switch {
+		case line == "":
+			line = hint
+		case width > 0 && lipgloss.Width(line+hintSeparator+hint) > width:
+			lines = append(lines, line)
+			line = hint
+		default:
+			line += hintSeparator + hint
+		}
+	}
+	return append(lines, line)
+}
+
+// hintSeparator separates the hints of a footer help line
+const hintSeparator = " | "
+
+// footerHelp returns the key hints for the current screen
+func (m Model) footerHelp() string {
 	var help string
 	switch m.currentScreen {
 	case ScreenSecretList:
-		help = "hjkl/arrows: navigate | enter: view | /: filter | p: profile | g: region | r: refresh | ?: help | q: quit"
-		if m.currentPage > 0 {
-			help += " | b: prev page"
-		}
-		if m.hasMore {
-			help += " | n: next page"
-		}
-	case ScreenSecretDetail:
-		if m.secretValue == "" {
-			help = "v: view value | esc: back | q: quit"
-		} else {
-			help = "c: copy plain | j: copy json | esc: back | q: quit"
-			if len(m.secretFields) > 0 {
-				help = "c: copy plain | j: copy json | k: copy field | esc: back | q: quit"
-			}
+		// Otherwise the hints are drawn from the key map, see footerKeys
+		if m.showHelp {
+			help = "↑/↓: scroll | pgup/pgdn: page | H/esc: close"
 		}
 	case ScreenSecretFieldSelector:
 		help = "enter: copy field | esc: back | q: quit"
-	case ScreenProfileSelector:
+	case ScreenProfileSelector, ScreenCopyProfile, ScreenCompareProfile, ScreenSyncProfile:
 		help = "enter: select | esc: back | q: quit"
-	case ScreenRegionSelector:
+	case ScreenRegionSelector, ScreenCopyRegion, ScreenCompareRegion, ScreenSyncRegion:
 		help = "enter: select | esc: back | q: quit"
-	case ScreenMFAInput:
+	case ScreenMFAInput, ScreenSecondMFA:
 		help = "enter: submit | esc: cancel"
+	case ScreenSaveSecret, ScreenExportSecrets, ScreenExportReport, ScreenAuditExport, ScreenManifestPath:
+		help = "enter: save | esc: cancel"
+	case ScreenLocked:
+		help = "enter: unlock | ctrl+c: quit"
+	case ScreenSecretActions, ScreenBulkActions, ScreenExportFormat, ScreenWorkspaces, ScreenUndo, ScreenBackends:
+		help = "enter: run action | /: filter | esc: back"
+	case ScreenBulkTag, ScreenBulkUntag, ScreenKubeManifest, ScreenWorkspaceName, ScreenBackendVault, ScreenImportName, ScreenSyncName, ScreenTemplateName, ScreenTemplateField:
+		help = "enter: continue | esc: cancel"
+	case ScreenBulkConfirm, ScreenCopyConfirm, ScreenSaveConfirm, ScreenKMSKeyConfirm, ScreenSyncConfirm, ScreenTemplateConfirm, ScreenManifestConfirm:
+		help = "y: confirm | n/esc: cancel | ↑/↓: scroll"
+		if s, ok := m.screen.(summaryScreen); ok && s.typed {
+			help = "type the name, then enter: confirm | esc: cancel | ↑/↓: scroll"
+		}
+	case ScreenComparePick:
+		help = "enter: compare | /: filter | esc: cancel"
+	case ScreenImportPick:
+		help = "enter: import | /: filter | esc: cancel"
+	case ScreenTemplatePick:
+		help = "enter: use template | /: filter | esc: cancel"
+	case ScreenKMSKeyPicker:
+		help = "enter: choose key | /: filter | esc: cancel"
+	case ScreenCompare:
+		help = "r: reveal/mask values | s: show/hide matching keys | p: pick another counterpart | ↑/↓: scroll | esc: close"
+	case ScreenSyncPreview:
+		help = "enter: write | r: reveal/mask values | s: show/hide matching keys | ↑/↓: scroll | esc: cancel, writing nothing"
+	case ScreenBulkResults, ScreenReferences, ScreenAccess:
+		help = "enter/esc: close | ↑/↓: scroll"
+	case ScreenSetup:
+		help = "enter: next/start | tab: switch list | /: filter | esc: back/skip | ctrl+c: quit"
+	case ScreenAudit:
+		help = "+/-: stale threshold ±30 days | e: export | ↑/↓: scroll | esc: close"
+	case ScreenLint:
+		help = "↑/↓: scroll | esc: close"
+	case ScreenApps:
+		help = "enter: open secret/collapse app | space: collapse/expand app | ↑/↓: move | esc: close"
 	}
-
-	if help != "" {
-		parts = append(parts, HelpStyle.Render(help))
-	}
-
-	return strings.Join(parts, "\n")
+	return help
 }
 
 func maxInt(a, b int) int {
@@ -165,13 +341,21 @@ func (m Model) viewSecretList() string {
 		return "\n  No secrets found in this region.\n\n  Try switching regions with 'g' or refreshing with 'r'."
 	}
 
+	grid := m.grid.View()
+	if m.splitActive() {
+		grid = m.viewSplit(grid)
+	}
+
 	// Show filter status if filtering
 	if m.grid.IsFiltering() {
 		filterStatus := fmt.Sprintf("Filter: %s_", m.grid.GetFilterQuery())
-		return fmt.Sprintf("%s\n%s", FilterStatusStyle.Render(filterStatus), m.grid.View())
+		return fmt.Sprintf("%s\n%s", FilterStatusStyle.Render(filterStatus), grid)
+	}
+	if prompt := m.jump.prompt(); prompt != "" {
+		return fmt.Sprintf("%s\n%s", FilterStatusStyle.Render(prompt), grid)
 	}
 
-	return m.grid.View()
+	return grid
 }
 
 // viewSecretDetail renders the secret detail screen
@@ -182,68 +366,60 @@ func (m Model) viewSecretDetail() string {
 	}
 
 	var b strings.Builder
+	t := theme.Current()
 
 	// Title
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("205")).
+		Foreground(t.Primary).
 		MarginBottom(1)
 
 	b.WriteString(titleStyle.Render("Secret Details") + "\n\n")
 
 	// Secret metadata with compact key-value styling
 	keyStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("170")).
+		Foreground(t.Secondary).
 		Bold(true)
 
 	valueStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("252"))
+		Foreground(t.Text)
 
-	// Truncate name if too long
-	displayName := secret.Name
-	if len(displayName) > 60 {
-		displayName = displayName[:57] + "..."
-	}
-	b.WriteString(keyStyle.Render("Name: ") + valueStyle.Render(displayName) + "\n")
-
-	// Truncate ARN if too long
-	displayARN := secret.ARN
-	if len(displayARN) > 60 {
-		displayARN = "..." + displayARN[len(displayARN)-57:]
-	}
-	b.WriteString(keyStyle.Render("ARN: ") + valueStyle.Render(displayARN) + "\n")
+	// Long values are cut rather than wrapped under their labels
+	valueWidth := m.detailValueWidth()
+	b.WriteString(m.detailLine("Name", cutEnd(secret.Name, valueWidth), keyStyle, valueStyle))
+	b.WriteString(m.detailLine("ARN", cutStart(secret.ARN, valueWidth), keyStyle, valueStyle))
 
 	if secret.Description != "" {
-		displayDesc := secret.Description
-		if len(displayDesc) > 60 {
-			displayDesc = displayDesc[:57] + "..."
-		}
-		b.WriteString(keyStyle.Render("Description: ") + valueStyle.Render(displayDesc) + "\n")
+		b.WriteString(m.detailLine("Description", cutEnd(secret.Description, valueWidth), keyStyle, valueStyle))
 	}
 
 	if secret.LastChangedDate != nil {
-		b.WriteString(keyStyle.Render("Last Modified: ") +
-			valueStyle.Render(secret.LastChangedDate.Format("Jan 2, 2006 3:04 PM")) + "\n")
+		b.WriteString(m.detailLine("Last Modified", secret.LastChangedDate.Format(detailDateFormat), keyStyle, valueStyle))
 	}
 
+	if apps := m.appsUsing(secret.Name); len(apps) > 0 {
+		b.WriteString(m.detailLine("Apps", cutEnd(strings.Join(apps, ", "), valueWidth), keyStyle, valueStyle))
+	}
+
+	b.WriteString(m.viewSecretMetadata(secret, keyStyle, valueStyle))
+
 	if len(secret.Tags) > 0 {
 		b.WriteString("\n" + keyStyle.Render("Tags:") + "\n")
 		for k, v := range secret.Tags {
-			tagStr := fmt.Sprintf("  %s: %s", k, v)
-			if len(tagStr) > 62 {
-				tagStr = tagStr[:59] + "..."
-			}
+			tagStr := cutEnd(fmt.Sprintf("  %s: %s", k, v), min(62, m.detailTextWidth()))
 			b.WriteString(valueStyle.Render(tagStr) + "\n")
 		}
 	}
 
 	// Secret value section
-	b.WriteString("\n" + strings.Repeat("─", 70) + "\n\n")
+	b.WriteString("\n" + strings.Repeat("─", m.detailTextWidth()-2) + "\n\n")
 
-	if m.secretValue == "" {
+	if !m.secretLoaded() {
 		instructionStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241"))
+			Foreground(t.Subtle)
 		b.WriteString(instructionStyle.Render("Press 'v' to view the secret value") + "\n")
+	} else if m.secretBinary != nil {
+		b.WriteString(m.viewBinaryValue(keyStyle))
 	} else {
 		b.WriteString(keyStyle.Render("Secret Value:") + "\n\n")
 
@@ -267,32 +443,45 @@ func (m Model) viewSecretDetail() string {
 		if len(lines) > maxLines {
 			formatted = strings.Join(lines[:maxLines], "\n") + "\n... (truncated)"
 		}
+		if !m.valueRevealed {
+			if len(m.secretFields) > 0 {
+				formatted = m.viewMaskedFields(maxLines)
+			} else {
+				formatted = m.viewMaskedValue()
+			}
+		}
 
 		valueBoxStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("241")).
+			BorderForeground(t.Subtle).
 			Padding(1).
-			Width(66)
+			Width(m.valueBoxWidth())
 
 		b.WriteString(valueBoxStyle.Render(formatted) + "\n\n")
 
-		// Copy instructions
+		// Copy instructions, left to the footer when narrow
 		copyHelpStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(t.Subtle).
 			Italic(true)
-		copyHelp := "Press 'c' to copy as plain text | 'j' to copy as JSON"
+		copyHelp := "Press 'r' to reveal/hide | 'c' to copy as plain text | 'j' to copy as JSON"
 		if len(m.secretFields) > 0 {
 			copyHelp += fmt.Sprintf(" | 'k' to copy a field (%d keys)", len(m.secretFields))
+			if !m.valueRevealed {
+				copyHelp = "↑/↓ to select a key | enter to reveal it | 'y' to copy it | 'r' to reveal all | 'c' to copy as plain text | 'j' to copy as JSON"
+			}
+		}
+		copyHelp += " | 's' to save to a file"
+		if !m.narrow() {
+			b.WriteString(copyHelpStyle.Render(copyHelp))
 		}
-		b.WriteString(copyHelpStyle.Render(copyHelp))
 	}
 
 	// Wrap in a bordered box
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("205")).
+		BorderForeground(t.Primary).
 		Padding(1, 2).
-		Width(76)
+		Width(m.detailWidth())
 
 	boxContent := boxStyle.Render(b.String())
 
@@ -306,73 +495,164 @@ func (m Model) viewSecretDetail() string {
 	return boxContent
 }
 
-// viewHelp renders the help screen
-func (m Model) viewHelp() string {
-	help := `
-AWS Secrets Manager TUI - Help
-
-GRID NAVIGATION
-  ↑/k         Move up
-  ↓/j         Move down
-  ←/h         Move left
-  →/l         Move right
-  enter       View secret details
-  esc/q       Go back / Quit
-  space       Next screen (within current page)
-  pgup        Previous screen (within current page)
-
-FILTERING
-  /           Enter filter mode
-  type        Filter secrets by name
-  esc         Exit filter mode
-
-ACTIONS
-  v           View secret value (on detail screen)
-  c           Copy secret value as plain text
-  j           Copy secret value as JSON (on detail screen)
-  k           Copy one top-level JSON field (on eligible detail screens)
-  r           Refresh secret list
-  p           Switch AWS profile
-  g           Switch AWS region
-  n           Next AWS page (load 50 more secrets)
-  b           Previous AWS page
-
-GLOBAL
-  ?           Toggle this help
-  ctrl+c      Force quit
-
-SECURITY NOTE
-  • Secret values are only fetched on-demand (when you press 'v')
-  • Values are cleared from memory when you navigate away
-  • Clipboard contents persist after app closes
-
-Press '?' to close this help.
-`
-	return BorderStyle.Render(help)
-}
+// viewBinaryValue renders a binary secret as base64 or a hex dump
+func (m Model) viewBinaryValue(keyStyle lipgloss.Style) string {
+	t := theme.Current()
+	var b strings.Builder
+
+	title := fmt.Sprintf("Binary Secret (%d bytes, %s):", len(m.secretBinary), m.binaryFormat)
+	b.WriteString(keyStyle.Render(title) + "\n\n")
 
-// viewProfileSelector renders the profile selector screen
-func (m Model) viewProfileSelector() string {
-	return m.profileSelector.View()
+	formatted := formatBinary(m.secretBinary, m.binaryFormat)
+	lines := strings.Split(formatted, "\n")
+	maxLines := 15
+	if len(lines) > maxLines {
+		formatted = strings.Join(lines[:maxLines], "\n") + "\n... (truncated, save to a file for the full value)"
+	}
+	if !m.valueRevealed {
+		formatted = m.viewMaskedValue()
+	}
+
+	valueBoxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Subtle).
+		Padding(1).
+		Width(m.valueBoxWidth())
+
+	b.WriteString(valueBoxStyle.Render(formatted) + "\n\n")
+
+	if !m.narrow() {
+		copyHelpStyle := lipgloss.NewStyle().
+			Foreground(t.Subtle).
+			Italic(true)
+		b.WriteString(copyHelpStyle.Render("Press 'r' to reveal/hide | 'x' to switch base64/hex | 'c' to copy as base64 | 's' to save to a file"))
+	}
+
+	return b.String()
 }
 
-// viewSecretFieldSelector renders the secret field selector screen.
-func (m Model) viewSecretFieldSelector() string {
-	return m.fieldSelector.View()
+// viewMaskedValue renders the placeholder shown while a loaded value is masked
+func (m Model) viewMaskedValue() string {
+	hint := "Hidden. Press 'r' to reveal"
+	if m.revealTimeout > 0 {
+		hint += fmt.Sprintf(" (masked again after %s)", m.revealTimeout)
+	}
+	return maskedValue + "\n\n" + lipgloss.NewStyle().Foreground(theme.Current().Subtle).Render(hint)
 }
 
-// viewRegionSelector renders the region selector screen
-func (m Model) viewRegionSelector() string {
-	return m.regionSelector.View()
+// viewMaskedFields renders the keys of a JSON value in clear text with each
+// value masked unless it was revealed on its own. At most maxLines keys are
+// shown, scrolled to keep the cursor visible.
+func (m Model) viewMaskedFields(maxLines int) string {
+	t := theme.Current()
+	keyStyle := lipgloss.NewStyle().Foreground(t.Secondary)
+	selectedStyle := lipgloss.NewStyle().Foreground(t.Primary).Bold(true)
+	maskStyle := lipgloss.NewStyle().Foreground(t.Subtle)
+
+	start := 0
+	if m.fieldCursor >= maxLines {
+		start = m.fieldCursor - maxLines + 1
+	}
+	end := start + maxLines
+	if end > len(m.secretFields) {
+		end = len(m.secretFields)
+	}
+
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		field := m.secretFields[i]
+
+		cursor := "  "
+		style := keyStyle
+		if i == m.fieldCursor {
+			cursor = "› "
+			style = selectedStyle
+		}
+
+		value := maskStyle.Render(maskedValue)
+		if m.revealedFields[field.Key] {
+			value = field.Preview
+		}
+		lines = append(lines, cursor+style.Render(field.Key)+": "+value)
+	}
+	return strings.Join(lines, "\n")
 }
 
-// viewMFAInput renders the MFA input screen
-func (m Model) viewMFAInput() string {
-	// Center the MFA input box
-	if m.width > 0 && m.height > 0 {
-		return lipgloss.Place(m.width-6, m.height-10,
-			lipgloss.Center, lipgloss.Center,
-			m.mfaInput.View())
+// maxDetailVersions is how many secret versions the detail screen lists
+const maxDetailVersions = 5
+
+// viewSecretMetadata renders the DescribeSecret metadata for the detail screen
+func (m Model) viewSecretMetadata(secret *models.Secret, keyStyle, valueStyle lipgloss.Style) string {
+	subtleStyle := lipgloss.NewStyle().Foreground(theme.Current().Subtle)
+
+	if m.detailsError != "" {
+		msg := cutEnd("Extended metadata unavailable: "+logging.Scrub(m.detailsError), m.detailTextWidth()-2)
+		return subtleStyle.Render(msg) + "\n"
+	}
+
+	details := m.secretDetails
+	if details == nil {
+		return subtleStyle.Render("Loading metadata...") + "\n"
+	}
+
+	var b strings.Builder
+	writeDate := func(label string, date *time.Time) {
+		if date != nil {
+			b.WriteString(m.detailLine(label, date.Format(detailDateFormat), keyStyle, valueStyle))
+		}
+	}
+
+	writeDate("Created", details.CreatedDate)
+
+	lastAccessed := details.LastAccessedDate
+	if lastAccessed == nil {
+		lastAccessed = secret.LastAccessedDate
 	}
-	return m.mfaInput.View()
+	if lastAccessed != nil {
+		// AWS only records the day a secret was last accessed
+		b.WriteString(m.detailLine("Last Accessed", lastAccessed.Format("Jan 2, 2006"), keyStyle, valueStyle))
+	}
+
+	rotation := "Disabled"
+	if details.RotationEnabled {
+		rotation = "Enabled"
+		if details.NextRotationDate != nil {
+			rotation += ", next " + details.NextRotationDate.Format(detailDateFormat)
+		}
+	}
+	b.WriteString(m.detailLine("Rotation", rotation, keyStyle, valueStyle))
+	writeDate("Last Rotated", details.LastRotatedDate)
+
+	kmsKey := details.KmsKeyID
+	if kmsKey == "" {
+		kmsKey = "aws/secretsmanager (default)"
+	} else {
+		kmsKey = cutStart(kmsKey, m.detailValueWidth())
+	}
+	b.WriteString(m.detailLine("KMS Key", kmsKey, keyStyle, valueStyle))
+
+	if details.OwningService != "" {
+		b.WriteString(m.detailLine("Owning Service", details.OwningService, keyStyle, valueStyle))
+	}
+
+	if len(details.Versions) > 0 {
+		b.WriteString(keyStyle.Render("Versions:") + "\n")
+		for i, version := range details.Versions {
+			if i == maxDetailVersions {
+				b.WriteString(subtleStyle.Render(fmt.Sprintf("  ... and %d more", len(details.Versions)-maxDetailVersions)) + "\n")
+				break
+			}
+			id := version.ID
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			stages := strings.Join(version.Stages, ", ")
+			if stages == "" {
+				stages = "(no labels)"
+			}
+			b.WriteString(valueStyle.Render(fmt.Sprintf("  %s  %s", id, stages)) + "\n")
+		}
+	}
+
+	return b.String()
 }
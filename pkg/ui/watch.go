@@ -0,0 +1,155 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/models"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultWatchInterval is how often watch mode re-lists the secrets unless
+// watch_interval says otherwise
+const defaultWatchInterval = 30 * time.Second
+
+// minWatchInterval keeps watch mode from listing secrets faster than the
+// ListSecrets quota comfortably allows
+const minWatchInterval = 5 * time.Second
+
+// watchState is the secret list's auto-refresh
+type watchState struct {
+	on       bool
+	interval time.Duration
+	// seq identifies the pending tick, so ticks scheduled before watch mode
+	// was turned off and on again are dropped
+	seq int
+	// before is each listed secret, by name, taken when a refresh starts to
+	// compare the fresh list with. Nil when no refresh is pending.
+	before map[string]models.Secret
+	// flagged is the secrets to notify about when they rotate, by name
+	flagged map[string]bool
+}
+
+// watchTickMsg asks for the next watch refresh
+type watchTickMsg struct {
+	seq int
+}
+
+// parseWatchInterval reads the watch_interval config setting
+func parseWatchInterval(value string) time.Duration {
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		return defaultWatchInterval
+	}
+	return max(interval, minWatchInterval)
+}
+
+// scheduleWatchTick asks for a refresh after interval
+func scheduleWatchTick(seq int, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return watchTickMsg{seq: seq}
+	})
+}
+
+// changedAt is when a secret last changed, or the zero time if AWS didn't
+// say
+func changedAt(secret models.Secret) time.Time {
+	if secret.LastChangedDate != nil {
+		return *secret.LastChangedDate
+	}
+	return time.Time{}
+}
+
+// toggleWatch turns watch mode on or off. Turning it on refreshes straight
+// away, so the first highlights are against the list already shown.
+func (m Model) toggleWatch() (tea.Model, tea.Cmd) {
+	m.watch.seq++
+	m.watch.before = nil
+	if m.watch.on {
+		m.watch.on = false
+		m.grid.SetHighlights(nil)
+		m.statusMessage = "Stopped watching"
+		return m, clearStatusAfter(3 * time.Second)
+	}
+
+	m.watch.on = true
+	m.statusMessage = fmt.Sprintf("Watching: refreshing every %s", m.watch.interval)
+	updated, cmd := m.refreshSecrets("Refreshing secrets…")
+	return updated, tea.Batch(cmd, scheduleWatchTick(m.watch.seq, m.watch.interval), clearStatusAfter(3*time.Second))
+}
+
+// refreshSecrets drops the cached pages and lists the secrets again. In
+// watch mode the secrets loaded so far are kept to highlight what changed.
+func (m Model) refreshSecrets(text string) (Model, tea.Cmd) {
+	if m.inventory == nil {
+		return m, nil
+	}
+	if m.watch.on {
+		// The list shown may be from the cache rather than the inventory
+		m.watch.before = make(map[string]models.Secret)
+		for _, secret := range append(m.inventory.Loaded(), m.secrets...) {
+			m.watch.before[secret.Name] = secret
+		}
+	}
+	m.inventory.Reset()
+	return m, loadSecrets(m.startLoad(text), m.inventory, 0)
+}
+
+// watchTick refreshes the secret list, unless it isn't shown or something
+// else is loading, and schedules the next tick
+func (m Model) watchTick(msg watchTickMsg) (tea.Model, tea.Cmd) {
+	if !m.watch.on || msg.seq != m.watch.seq {
+		return m, nil
+	}
+	next := scheduleWatchTick(m.watch.seq, m.watch.interval)
+	if m.currentScreen != ScreenSecretList || m.loading || m.grid.IsFiltering() || m.awsClient == nil {
+		return m, next
+	}
+	updated, cmd := m.refreshSecrets("Watching: refreshing secrets…")
+	return updated, tea.Batch(cmd, next)
+}
+
+// highlightChanges labels the secrets in a refreshed list that are new or
+// changed since the list before it, and says how many there are. A first
+// page loaded any other way, such as after switching profile, clears the
+// highlights.
+func (m *Model) highlightChanges(page int, secrets []models.Secret) tea.Cmd {
+	before := m.watch.before
+	m.watch.before = nil
+	if before == nil {
+		if page == 0 {
+			m.grid.SetHighlights(nil)
+		}
+		return nil
+	}
+
+	highlights := make(map[string]string)
+	added, changed := 0, 0
+	var rotated []string
+	for _, secret := range secrets {
+		previous, found := before[secret.Name]
+		switch {
+		case !found:
+			highlights[secret.Name] = "new"
+			added++
+		case changedAt(secret).After(changedAt(previous)):
+			highlights[secret.Name] = "changed"
+			changed++
+		}
+		if found && m.watch.flagged[secret.Name] && rotatedSince(previous, secret) {
+			rotated = append(rotated, secret.Name)
+		}
+	}
+	m.grid.SetHighlights(highlights)
+
+	if len(rotated) > 0 {
+		// Left up until something else replaces it, for whoever looks back
+		m.statusMessage = rotationBanner(rotated, time.Now())
+		return notifyRotated(rotated)
+	}
+	if added+changed == 0 {
+		return nil
+	}
+	m.statusMessage = fmt.Sprintf("Since the last refresh: %d new, %d changed", added, changed)
+	return clearStatusAfter(5 * time.Second)
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benjamingriff/secretsrc/pkg/config"
+	"github.com/benjamingriff/secretsrc/pkg/ui/components"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveWorkspaceKey is the workspace menu entry that saves the current one
+const saveWorkspaceKey = "+"
+
+// errNoWorkspaceName is shown when a workspace is saved without a name
+var errNoWorkspaceName = errors.New("a workspace needs a name")
+
+// WithFilter starts with the secret list filtered by query, e.g. from a
+// workspace given with --workspace
+func (m Model) WithFilter(query string) Model {
+	m.grid.SetFilter(query)
+	return m
+}
+
+// workspaceNames returns the saved workspaces in the order they are listed
+func (m Model) workspaceNames() []string {
+	names := make([]string, 0, len(m.workspaces))
+	for name := range m.workspaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeWorkspace summarizes a workspace for the menu
+func describeWorkspace(workspace config.Workspace) string {
+	parts := []string{"profile " + workspace.Profile}
+	if workspace.Region != "" {
+		parts = append(parts, workspace.Region)
+	}
+	if workspace.Filter != "" {
+		parts = append(parts, fmt.Sprintf("filter %q", workspace.Filter))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// openWorkspaces lists the saved workspaces, numbered in name order, after
+// an entry to save the current profile, region and filter as another
+func (m Model) openWorkspaces() (tea.Model, tea.Cmd) {
+	current := config.Workspace{Profile: m.currentProfile, Region: m.currentRegion, Filter: m.grid.GetFilterQuery()}
+	actions := []components.Action{{
+		Key:         saveWorkspaceKey,
+		Title:       "Save as a workspace",
+		Description: describeWorkspace(current),
+	}}
+	for i, name := range m.workspaceNames() {
+		actions = append(actions, components.Action{
+			Key:         strconv.Itoa(i + 1),
+			Title:       name,
+			Description: describeWorkspace(m.workspaces[name]),
+		})
+	}
+	m.openScreen(ScreenWorkspaces, newActionScreen("Workspaces", actions), ScreenSecretList)
+	return m, nil
+}
+
+// chooseWorkspace switches to the workspace picked from the menu, or asks
+// for a name to save the current one under
+func (m Model) chooseWorkspace(key string) (tea.Model, tea.Cmd) {
+	if key == saveWorkspaceKey {
+		prompt := newTextScreen("Save the current profile, region and filter", "Workspace name:", "prod-eu")
+		prompt.input.SetValue(m.currentProfile)
+		m.openScreen(ScreenWorkspaceName, prompt, ScreenSecretList)
+		return m, nil
+	}
+	index, err := strconv.Atoi(key)
+	names := m.workspaceNames()
+	if err != nil || index < 1 || index > len(names) {
+		return m, nil
+	}
+	return m.switchWorkspace(m.workspaces[names[index-1]])
+}
+
+// switchWorkspace applies a workspace's filter and signs in to its profile
+// and region when they differ from the current ones
+func (m Model) switchWorkspace(workspace config.Workspace) (tea.Model, tea.Cmd) {
+	m.grid.SetFilter(workspace.Filter)
+	profile, region := workspace.Profile, workspace.Region
+	if profile == "" {
+		profile = m.currentProfile
+	}
+	if region == "" {
+		region = m.currentRegion
+	}
+	if profile == m.currentProfile && region == m.currentRegion {
+		return m, nil
+	}
+	return m, m.initAWSClient(profile, region)
+}
+
+// saveWorkspace saves the current profile, region and filter under name
+func (m Model) saveWorkspace(name string) (tea.Model, tea.Cmd) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return m.updateScreen(promptErrorMsg{err: errNoWorkspaceName})
+	}
+	m.closeScreen()
+	workspace := config.Workspace{Profile: m.currentProfile, Region: m.currentRegion, Filter: m.grid.GetFilterQuery()}
+	if m.workspaces == nil {
+		m.workspaces = make(map[string]config.Workspace)
+	}
+	m.workspaces[name] = workspace
+	if m.awsBackend() {
+		updateConfig(func(cfg *config.Config) {
+			cfg.SaveWorkspace(name, workspace)
+		})
+	}
+	m.statusMessage = fmt.Sprintf("Saved workspace %s", name)
+	return m, clearStatusAfter(3 * time.Second)
+}